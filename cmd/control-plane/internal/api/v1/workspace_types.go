@@ -17,6 +17,7 @@ limitations under the License.
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -37,8 +38,98 @@ const (
 	WorkspacePhaseStaring                 = "Starting"
 	WorkspacePhaseStopping                = "Stopping"
 	WorkspacePhaseStopped                 = "Stopped"
+	WorkspacePhaseFailed                  = "Failed"
+	// 工作空间运行中失去了就绪状态, 已经从网关中注销
+	WorkspacePhaseDegraded = "Degraded"
 )
 
+// Workspace的Condition类型
+const (
+	WorkspaceConditionPVCBound     = "PVCBound"
+	WorkspaceConditionPodScheduled = "PodScheduled"
+	WorkspaceConditionRepoCloned   = "RepoCloned"
+	WorkspaceConditionReady        = "Ready"
+	WorkspaceConditionResizing     = "Resizing"
+)
+
+// 由control-plane主动停止Workspace的原因
+const (
+	StopReasonIdle = "Idle"
+	// 用户本月的运行时长配额已经用完
+	StopReasonRuntimeQuota = "RuntimeQuotaExceeded"
+)
+
+// 快照的实现方式
+const (
+	// 使用Kubernetes VolumeSnapshot, 需要存储类支持
+	SnapshotMethodVolumeSnapshot = "VolumeSnapshot"
+	// 将存储卷打包上传到对象存储
+	SnapshotMethodArchive = "Archive"
+)
+
+// WorkSpaceRestore describes the snapshot which the volume is restored from
+type WorkSpaceRestore struct {
+	// Name of the snapshot
+	Snapshot string `json:"snapshot"`
+
+	// How the snapshot was taken, "VolumeSnapshot" or "Archive"
+	// +kubebuilder:validation:Enum=VolumeSnapshot;Archive
+	Method string `json:"method"`
+
+	// Object storage url of the archive, only used by "Archive"
+	URL string `json:"url,omitempty"`
+}
+
+// WorkSpaceLaunch describes how to launch the workspace container, it comes from the space template
+type WorkSpaceLaunch struct {
+	// Entrypoint of the workspace container, the image's ENTRYPOINT is used if not provided.
+	// Variable references $(VAR_NAME) are expanded using the container's environment,
+	// e.g. $(WORKSPACE_DIR) is the mount path of the volume and $(WORKSPACE_PORT) is the port of the workspace
+	// +optional
+	Command []string `json:"command,omitempty"`
+
+	// Arguments to the entrypoint
+	// +optional
+	Args []string `json:"args,omitempty"`
+
+	// Working directory of the workspace container
+	// +optional
+	WorkingDir string `json:"workingDir,omitempty"`
+
+	// HTTP path used to check whether the workspace is ready, the port is checked by tcp if empty
+	// +optional
+	ReadinessProbePath string `json:"readinessProbePath,omitempty"`
+
+	// HTTP path used to check whether the workspace is alive, the container is restarted when the check fails.
+	// No liveness probe if empty
+	// +optional
+	LivenessProbePath string `json:"livenessProbePath,omitempty"`
+
+	// HTTP path used to check whether the workspace has started, the other probes are disabled until it succeeds.
+	// No startup probe if empty
+	// +optional
+	StartupProbePath string `json:"startupProbePath,omitempty"`
+
+	// Steps run in order as init containers after the repository is cloned
+	// +optional
+	InitSteps []WorkSpaceInitStep `json:"initSteps,omitempty"`
+}
+
+// WorkSpaceInitStep is a step run before the workspace container starts, the volume is mounted at the mount path
+type WorkSpaceInitStep struct {
+	// Name of the step
+	// +kubebuilder:validation:MaxLength=50
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// Image of the step, the workspace image is used if not provided
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Command of the step
+	Command []string `json:"command"`
+}
+
 // WorkSpaceSpec defines the desired state of WorkSpace
 type WorkSpaceSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
@@ -81,8 +172,40 @@ type WorkSpaceSpec struct {
 	// git repository to clone
 	GitRepository string `json:"gitRepository,omitempty"`
 
+	// Environment variables of the workspace container, sensitive values should be referenced from secrets
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// Sources to populate environment variables of the workspace container,
+	// the sensitive values created by control-plane are stored in the secret named "<workspace>-env"
+	// +optional
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+
 	// The command can be "Start", "Stop" or ""
 	Command WorkspaceCommand `json:"operation,omitempty"`
+
+	// Idle timeout in seconds, the workspace will be stopped when there is no user activity for this long.
+	// 0 means using the default timeout of control-plane, negative means never
+	IdleTimeoutSeconds int32 `json:"idleTimeoutSeconds,omitempty"`
+
+	// Maximum seconds the workspace can run after it is started, it is set from the user's remaining runtime quota.
+	// 0 means unlimited
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxRuntimeSeconds int64 `json:"maxRuntimeSeconds,omitempty"`
+
+	// PriorityClass of the workspace pod, it is set to the user's quota class so the pod
+	// is counted in the user's ResourceQuota when it is admitted
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// How to launch the workspace container
+	// +optional
+	Launch *WorkSpaceLaunch `json:"launch,omitempty"`
+
+	// Restore the volume from a snapshot when the PVC is created
+	// +optional
+	Restore *WorkSpaceRestore `json:"restore,omitempty"`
 }
 
 // WorkSpaceStatus defines the observed state of WorkSpace
@@ -91,6 +214,44 @@ type WorkSpaceStatus struct {
 	// Important: Run "make" to regenerate code after modifying this file
 	// +kubebuilder:default="Created"
 	Phase WorkSpacePhase `json:"phase,omitempty"`
+
+	// The last time user activity was observed
+	LastActivityTime *metav1.Time `json:"lastActivityTime,omitempty"`
+
+	// Why the workspace was stopped by control-plane, eg. "Idle"
+	StopReason string `json:"stopReason,omitempty"`
+
+	// Human readable message about the stop reason
+	StopMessage string `json:"stopMessage,omitempty"`
+
+	// Conditions of the workspace: PVCBound, PodScheduled, RepoCloned, Ready and Resizing
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// The last time the phase transitioned from one to another
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+
+	// The time the workspace became running
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+
+	// The time the workspace was stopped
+	StoppedAt *metav1.Time `json:"stoppedAt,omitempty"`
+
+	// IP address of the workspace pod
+	PodIP string `json:"podIP,omitempty"`
+
+	// The endpoint registered to the gateway, eg. "10.0.0.1:9999"
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// A brief CamelCase reason why the workspace is in the current phase, eg. "ImagePullBackOff"
+	Reason string `json:"reason,omitempty"`
+
+	// Human readable message about the reason
+	Message string `json:"message,omitempty"`
 }
 
 // +kubebuilder:object:root=true
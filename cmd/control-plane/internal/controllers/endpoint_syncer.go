@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/mangohow/cloud-ide/pkg/notifier"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// EndpointSyncer 启动时和定期将所有就绪的Workspace全量同步到网关
+// 网关重启或者注册请求丢失后, 在下一次同步时恢复, 已经不存在的Workspace从网关中删除
+type EndpointSyncer struct {
+	logger    logr.Logger
+	client    client.Client
+	notifier  notifier.Notifier
+	namespace string
+	// 同步间隔
+	interval time.Duration
+}
+
+func NewEndpointSyncer(c client.Client, logger logr.Logger, ntf notifier.Notifier, namespace string, interval time.Duration) *EndpointSyncer {
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+
+	return &EndpointSyncer{
+		logger:    logger.WithName("endpoint-syncer"),
+		client:    c,
+		notifier:  ntf,
+		namespace: namespace,
+		interval:  interval,
+	}
+}
+
+// Start 由manager在缓存同步完成后调用, 直到ctx结束
+func (s *EndpointSyncer) Start(ctx context.Context) error {
+	s.logger.Info("endpoint syncer started", "interval", s.interval)
+
+	s.sync(ctx)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			s.sync(ctx)
+		}
+	}
+}
+
+func (s *EndpointSyncer) sync(ctx context.Context) {
+	var count int
+	err := s.notifier.Resync(ctx, func() ([]notifier.Request, error) {
+		var pods v1.PodList
+		err := s.client.List(ctx, &pods, client.InNamespace(s.namespace), client.MatchingLabels{"app": "cloud-ide"})
+		if err != nil {
+			return nil, err
+		}
+		reqs := readyEndpoints(pods.Items)
+		count = len(reqs)
+		return reqs, nil
+	})
+	if err != nil {
+		s.logger.Error(err, "resync endpoints")
+		return
+	}
+	s.logger.V(5).Info("resync endpoints", "count", count)
+}
+
+// readyEndpoints 返回就绪的Workspace Pod在网关中注册的信息, 与PodReconciler调用Login时相同
+func readyEndpoints(pods []v1.Pod) []notifier.Request {
+	reqs := make([]notifier.Request, 0, len(pods))
+	for i := range pods {
+		pod := &pods[i]
+		sid := pod.Annotations["sid"]
+		if sid == "" || pod.DeletionTimestamp != nil || pod.Status.Phase != v1.PodRunning || !podReady(pod) {
+			continue
+		}
+		reqs = append(reqs, notifier.Request{
+			Sid:      sid,
+			Uid:      pod.Annotations["uid"],
+			Endpoint: podEndpoint(pod),
+		})
+	}
+
+	return reqs
+}
@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/mangohow/cloud-ide/pkg/notifier"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReadyEndpoints(t *testing.T) {
+	readyPod := func(sid string) v1.Pod {
+		pod := newTestPod()
+		pod.Annotations = map[string]string{"sid": sid, "uid": "u-" + sid}
+		pod.Status.Phase = v1.PodRunning
+		pod.Status.PodIP = "10.0.0.1"
+		pod.Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}}
+		return *pod
+	}
+
+	notReady := readyPod("b")
+	notReady.Status.Conditions[0].Status = v1.ConditionFalse
+	terminating := readyPod("c")
+	terminating.DeletionTimestamp = &metav1.Time{}
+	pending := readyPod("d")
+	pending.Status.Phase = v1.PodPending
+	noSid := readyPod("")
+
+	reqs := readyEndpoints([]v1.Pod{readyPod("a"), notReady, terminating, pending, noSid})
+	want := notifier.Request{Sid: "a", Uid: "u-a", Endpoint: "10.0.0.1:9999"}
+	if len(reqs) != 1 || reqs[0] != want {
+		t.Fatalf("want [%+v], got %+v", want, reqs)
+	}
+}
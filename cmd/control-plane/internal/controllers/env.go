@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	mv1 "github.com/mangohow/cloud-ide/cmd/control-plane/internal/api/v1"
+	"github.com/mangohow/cloud-ide/pkg/utils"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// 旧版本将环境变量编码在GitRepository中: ENV:{json}|GIT:url
+const (
+	legacyEnvPrefix    = "ENV:"
+	legacyGitSeparator = "|GIT:"
+)
+
+// EnvSecretName 保存工作空间敏感环境变量的Secret名称
+func EnvSecretName(workspace string) string {
+	return workspace + "-env"
+}
+
+// EnvSecret 构造保存工作空间敏感环境变量的Secret
+func EnvSecret(workspace, namespace string, data map[string]string) *v1.Secret {
+	return &v1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      EnvSecretName(workspace),
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app": "cloud-ide",
+			},
+		},
+		Type:       v1.SecretTypeOpaque,
+		StringData: data,
+	}
+}
+
+// SecretEnvFrom 从Secret中加载所有环境变量
+func SecretEnvFrom(secret string) v1.EnvFromSource {
+	return v1.EnvFromSource{
+		SecretRef: &v1.SecretEnvSource{
+			LocalObjectReference: v1.LocalObjectReference{Name: secret},
+		},
+	}
+}
+
+// EnvVars 将map转换为环境变量, 按名称排序保证每次生成的Pod相同
+func EnvVars(m map[string]string) []v1.EnvVar {
+	if len(m) == 0 {
+		return nil
+	}
+
+	env := make([]v1.EnvVar, 0, len(m))
+	for name, value := range m {
+		env = append(env, v1.EnvVar{Name: name, Value: value})
+	}
+	sort.Slice(env, func(i, j int) bool {
+		return env[i].Name < env[j].Name
+	})
+
+	return env
+}
+
+// decodeLegacyEnv 解析旧版本编码在GitRepository中的环境变量, 不是旧版本格式时ok为false
+func decodeLegacyEnv(repo string) (env map[string]string, gitRepo string, ok bool) {
+	if !strings.HasPrefix(repo, legacyEnvPrefix) {
+		return nil, repo, false
+	}
+
+	encoded, gitRepo, found := strings.Cut(strings.TrimPrefix(repo, legacyEnvPrefix), legacyGitSeparator)
+	if !found {
+		return nil, "", true
+	}
+	// 格式错误的环境变量直接丢弃
+	if err := json.Unmarshal([]byte(encoded), &env); err != nil {
+		env = nil
+	}
+
+	return env, gitRepo, true
+}
+
+// migrateLegacyEnv 将旧版本编码在GitRepository中的环境变量迁移到env和Secret中, 只会执行一次
+// 返回true表示Workspace已经被更新, 更新会再次触发Reconcile
+func (r *WorkSpaceReconciler) migrateLegacyEnv(ctx context.Context, space *mv1.WorkSpace) (bool, error) {
+	env, gitRepo, ok := decodeLegacyEnv(space.Spec.GitRepository)
+	if !ok {
+		return false, nil
+	}
+
+	plain, sensitive := make(map[string]string), make(map[string]string)
+	for name, value := range env {
+		if utils.IsSensitiveEnv(name) {
+			sensitive[name] = value
+		} else {
+			plain[name] = value
+		}
+	}
+
+	if len(sensitive) > 0 {
+		if err := r.applyEnvSecret(ctx, space, sensitive); err != nil {
+			return false, err
+		}
+		space.Spec.EnvFrom = append(space.Spec.EnvFrom, SecretEnvFrom(EnvSecretName(space.Name)))
+	}
+	space.Spec.Env = append(space.Spec.Env, EnvVars(plain)...)
+	space.Spec.GitRepository = gitRepo
+
+	ctx, cancelFunc := context.WithTimeout(ctx, time.Second*30)
+	defer cancelFunc()
+	if err := r.Client.Update(ctx, space); err != nil {
+		return false, err
+	}
+
+	log.FromContext(ctx).Info("migrated legacy env", "workspace", space.Name, "env", len(env))
+	return true, nil
+}
+
+// applyEnvSecret 创建或更新工作空间的Secret
+func (r *WorkSpaceReconciler) applyEnvSecret(ctx context.Context, space *mv1.WorkSpace, data map[string]string) error {
+	secret := EnvSecret(space.Name, space.Namespace, data)
+	if err := controllerutil.SetControllerReference(space, secret, r.Scheme); err != nil {
+		return err
+	}
+
+	ctx, cancelFunc := context.WithTimeout(ctx, time.Second*30)
+	defer cancelFunc()
+	err := r.Client.Create(ctx, secret)
+	if errors.IsAlreadyExists(err) {
+		return r.Client.Update(ctx, secret)
+	}
+
+	return err
+}
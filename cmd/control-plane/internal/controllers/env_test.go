@@ -0,0 +1,28 @@
+package controllers
+
+import (
+	"testing"
+)
+
+func TestDecodeLegacyEnv(t *testing.T) {
+	env, repo, ok := decodeLegacyEnv(`ENV:{"ANTHROPIC_AUTH_TOKEN":"sk-1","BIG_MODEL":"m"}|GIT:https://github.com/a/b.git`)
+	if !ok || repo != "https://github.com/a/b.git" || env["ANTHROPIC_AUTH_TOKEN"] != "sk-1" || env["BIG_MODEL"] != "m" {
+		t.Fatalf("unexpected result: %v %q %v", ok, repo, env)
+	}
+
+	env, repo, ok = decodeLegacyEnv(`ENV:{"BIG_MODEL":"m"}|GIT:`)
+	if !ok || repo != "" || len(env) != 1 {
+		t.Fatalf("unexpected result without repo: %v %q %v", ok, repo, env)
+	}
+
+	if _, repo, ok = decodeLegacyEnv("https://github.com/a/b.git"); ok || repo != "https://github.com/a/b.git" {
+		t.Fatalf("plain repository should not be decoded: %v %q", ok, repo)
+	}
+}
+
+func TestEnvVars(t *testing.T) {
+	env := EnvVars(map[string]string{"B": "2", "A": "1"})
+	if len(env) != 2 || env[0].Name != "A" || env[1].Name != "B" {
+		t.Fatalf("env should be sorted: %+v", env)
+	}
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"time"
+
+	mv1 "github.com/mangohow/cloud-ide/cmd/control-plane/internal/api/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// 初始化步骤容器名称的前缀, 防止与其它初始化容器重名
+const initStepPrefix = "init-"
+
+// 连续失败次数达到该值时探针失败
+const probeFailureThreshold = 3
+
+// applyLaunch 按照模板中的启动配置设置工作空间容器, 没有配置时使用镜像默认的启动命令
+// 就绪探针总是会设置, 只有通过就绪检查后工作空间才会注册到网关中
+func applyLaunch(container *v1.Container, launch *mv1.WorkSpaceLaunch, port int32) {
+	if launch == nil {
+		launch = &mv1.WorkSpaceLaunch{}
+	}
+
+	container.Command = launch.Command
+	container.Args = launch.Args
+	container.WorkingDir = launch.WorkingDir
+
+	// 没有设置就绪检查路径时检查端口是否在监听
+	container.ReadinessProbe = newProbe(probeHandler(launch.ReadinessProbePath, port), probeFailureThreshold)
+	if launch.LivenessProbePath != "" {
+		container.LivenessProbe = newProbe(probeHandler(launch.LivenessProbePath, port), probeFailureThreshold)
+	}
+	if launch.StartupProbePath != "" {
+		threshold := int32(StartupTimeout/time.Second) / probePeriodSeconds()
+		if threshold < 1 {
+			threshold = 1
+		}
+		container.StartupProbe = newProbe(probeHandler(launch.StartupProbePath, port), threshold)
+	}
+}
+
+func probeHandler(path string, port int32) v1.ProbeHandler {
+	if path == "" {
+		return v1.ProbeHandler{
+			TCPSocket: &v1.TCPSocketAction{
+				Port: intstr.FromInt(int(port)),
+			},
+		}
+	}
+
+	return v1.ProbeHandler{
+		HTTPGet: &v1.HTTPGetAction{
+			Path: path,
+			Port: intstr.FromInt(int(port)),
+		},
+	}
+}
+
+func newProbe(handler v1.ProbeHandler, failureThreshold int32) *v1.Probe {
+	return &v1.Probe{
+		ProbeHandler:     handler,
+		PeriodSeconds:    probePeriodSeconds(),
+		FailureThreshold: failureThreshold,
+	}
+}
+
+func probePeriodSeconds() int32 {
+	if period := int32(ProbePeriod / time.Second); period > 0 {
+		return period
+	}
+
+	return 1
+}
+
+// initStepContainers 将模板中的初始化步骤转换为初始化容器
+// 初始化容器与工作空间容器使用相同的环境变量和存储卷
+func initStepContainers(space *mv1.WorkSpace, workspace *v1.Container, volumeName string) []v1.Container {
+	if space.Spec.Launch == nil || len(space.Spec.Launch.InitSteps) == 0 {
+		return nil
+	}
+
+	containers := make([]v1.Container, 0, len(space.Spec.Launch.InitSteps))
+	for _, step := range space.Spec.Launch.InitSteps {
+		image := step.Image
+		if image == "" {
+			image = space.Spec.Image
+		}
+
+		containers = append(containers, v1.Container{
+			Name:            initStepPrefix + step.Name,
+			Image:           image,
+			ImagePullPolicy: v1.PullIfNotPresent,
+			Command:         step.Command,
+			WorkingDir:      space.Spec.MountPath,
+			Env:             workspace.Env,
+			EnvFrom:         workspace.EnvFrom,
+			VolumeMounts: []v1.VolumeMount{
+				{
+					Name:      volumeName,
+					MountPath: space.Spec.MountPath,
+				},
+			},
+		})
+	}
+
+	return containers
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"testing"
+
+	mv1 "github.com/mangohow/cloud-ide/cmd/control-plane/internal/api/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestApplyLaunch(t *testing.T) {
+	var container v1.Container
+	applyLaunch(&container, nil, 9999)
+	if container.Command != nil || container.LivenessProbe != nil || container.StartupProbe != nil {
+		t.Fatalf("image default command should be kept: %+v", container)
+	}
+	// 没有设置就绪检查路径时检查端口
+	if probe := container.ReadinessProbe; probe == nil || probe.TCPSocket == nil || probe.TCPSocket.Port.IntValue() != 9999 {
+		t.Fatalf("unexpected default readiness probe: %+v", probe)
+	}
+
+	applyLaunch(&container, &mv1.WorkSpaceLaunch{
+		Command:            []string{"/usr/bin/code-server"},
+		Args:               []string{"$(WORKSPACE_DIR)"},
+		ReadinessProbePath: "/healthz",
+		StartupProbePath:   "/healthz",
+	}, 9999)
+	if container.Command[0] != "/usr/bin/code-server" || container.Args[0] != "$(WORKSPACE_DIR)" {
+		t.Fatalf("unexpected command: %v %v", container.Command, container.Args)
+	}
+	if probe := container.ReadinessProbe; probe == nil || probe.HTTPGet.Path != "/healthz" || probe.HTTPGet.Port.IntValue() != 9999 {
+		t.Fatalf("unexpected readiness probe: %+v", probe)
+	}
+	// 启动探针在启动超时时间内重试
+	if probe := container.StartupProbe; probe == nil || probe.PeriodSeconds*probe.FailureThreshold != int32(StartupTimeout.Seconds()) {
+		t.Fatalf("unexpected startup probe: %+v", probe)
+	}
+}
+
+func TestInitStepContainers(t *testing.T) {
+	space := &mv1.WorkSpace{
+		Spec: mv1.WorkSpaceSpec{
+			Image:     "code-server-go:v1.21",
+			MountPath: "/root/",
+			Launch: &mv1.WorkSpaceLaunch{
+				InitSteps: []mv1.WorkSpaceInitStep{
+					{Name: "deps", Command: []string{"go", "mod", "download"}},
+					{Name: "tools", Image: "busybox", Command: []string{"true"}},
+				},
+			},
+		},
+	}
+
+	containers := initStepContainers(space, &v1.Container{}, "volume")
+	if len(containers) != 2 || containers[0].Name != "init-deps" || containers[0].Image != space.Spec.Image || containers[1].Image != "busybox" {
+		t.Fatalf("unexpected init containers: %+v", containers)
+	}
+	if containers[0].WorkingDir != "/root/" || containers[0].VolumeMounts[0].Name != "volume" {
+		t.Fatalf("init container should mount workspace volume: %+v", containers[0])
+	}
+}
+
+func TestConstructPodLaunchEnv(t *testing.T) {
+	space := &mv1.WorkSpace{
+		Spec: mv1.WorkSpaceSpec{
+			Image:     "codercom/code-server",
+			MountPath: "/root/",
+			Port:      8080,
+			Launch: &mv1.WorkSpaceLaunch{
+				Args: []string{"--bind-addr", "0.0.0.0:$(WORKSPACE_PORT)", "$(WORKSPACE_DIR)"},
+			},
+		},
+	}
+
+	// 启动参数中引用的变量由kubelet根据容器的环境变量展开
+	pod := (&WorkSpaceReconciler{}).constructPod(space)
+	env := map[string]string{}
+	for _, e := range pod.Spec.Containers[0].Env {
+		env[e.Name] = e.Value
+	}
+	if env["WORKSPACE_PORT"] != "8080" || env["WORKSPACE_DIR"] != "/root/" {
+		t.Fatalf("unexpected launch env: %v", env)
+	}
+}
+
+func TestConstructPodQuotaClass(t *testing.T) {
+	space := &mv1.WorkSpace{
+		Spec: mv1.WorkSpaceSpec{
+			Image:             "codercom/code-server",
+			MountPath:         "/root/",
+			Port:              8080,
+			PriorityClassName: "cloud-ide-user-u1",
+		},
+	}
+
+	// Pod使用用户的配额类, 创建时计入用户的ResourceQuota
+	pod := (&WorkSpaceReconciler{}).constructPod(space)
+	if pod.Spec.PriorityClassName != "cloud-ide-user-u1" {
+		t.Fatalf("priority class = %q", pod.Spec.PriorityClassName)
+	}
+}
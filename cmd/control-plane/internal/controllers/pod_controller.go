@@ -7,7 +7,9 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/mangohow/cloud-ide/pkg/notifier"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -45,7 +47,7 @@ func (r *PodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 	if errors.IsNotFound(err) {
 		lgr.V(5).Info("pod is terminated", "name", req.Name)
 
-		r.updateWorkspaceStatus(ctx, req.NamespacedName, mv1.WorkspacePhaseStopped)
+		r.updateWorkspaceStatus(ctx, req.NamespacedName, mv1.WorkspacePhaseStopped, nil)
 
 		return ctrl.Result{}, nil
 	}
@@ -67,43 +69,82 @@ func (r *PodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		lgr.V(5).Info("pod is terminating", "name", req.Name, "phase", pod.Status.Phase)
 
 		r.notifier.Logout(pod.Annotations["sid"])
+		r.notifier.Notify(pod.Annotations["sid"], notifier.Status{State: notifier.StateStopped})
 
-		r.updateWorkspaceStatus(ctx, req.NamespacedName, mv1.WorkspacePhaseStopping)
+		r.updateWorkspaceStatus(ctx, req.NamespacedName, mv1.WorkspacePhaseStopping, &pod)
 
 		return ctrl.Result{}, nil
 	}
 
-	// 3.Pod已经启动完成
-	if pod.Status.Phase == v1.PodRunning {
-		lgr.V(5).Info("pod is running", "name", req.Name, "phase", pod.Status.Phase)
+	// 3.Pod处于无法恢复的错误状态，例如镜像拉取失败、git-cloner不断崩溃
+	if reason, _, failed := podFailure(&pod); failed {
+		lgr.V(5).Info("pod is failed", "name", req.Name, "reason", reason)
 
-		// 3.1 更新Workspace状态
-		r.updateWorkspaceStatus(ctx, req.NamespacedName, mv1.WorkspacePhaseRunning)
+		r.notifier.Logout(pod.Annotations["sid"])
+		// 通知等待者Workspace启动失败, 不再等待到超时
+		r.notifier.Notify(pod.Annotations["sid"], notifier.Status{State: notifier.StateFailed, Reason: reason})
+
+		r.updateWorkspaceStatus(ctx, req.NamespacedName, mv1.WorkspacePhaseFailed, &pod)
+
+		return ctrl.Result{}, nil
+	}
 
-		// 3.2 将Workspace注册到网关中
-		endpoint := pod.Status.PodIP + ":" + strconv.Itoa(int(pod.Spec.Containers[0].Ports[0].ContainerPort))
+	// 4.Pod已经启动并且通过了就绪检查, 此时工作空间才可以访问
+	if pod.Status.Phase == v1.PodRunning && podReady(&pod) {
+		lgr.V(5).Info("pod is ready", "name", req.Name, "phase", pod.Status.Phase)
+
+		// 4.1 更新Workspace状态
+		r.updateWorkspaceStatus(ctx, req.NamespacedName, mv1.WorkspacePhaseRunning, &pod)
+
+		// 4.2 将Workspace注册到网关中
 		sid, ok := pod.Annotations["sid"]
 		if !ok {
 			lgr.Error(err, "get sid from annotations")
 			return ctrl.Result{Requeue: true}, err
 		}
-		r.notifier.Login(sid, endpoint)
+		r.notifier.Login(sid, pod.Annotations["uid"], podEndpoint(&pod))
+
+		// 4.3 通知用户Workspace可用
+		r.notifier.Notify(sid, notifier.Status{State: notifier.StateReady})
+
+		return ctrl.Result{}, nil
+	}
+
+	// 5.Pod运行中但是失去了就绪状态, 从网关中注销, 防止用户访问到不可用的工作空间
+	if pod.Status.Phase == v1.PodRunning && r.wasReady(ctx, req.NamespacedName) {
+		lgr.V(5).Info("pod readiness lost", "name", req.Name)
 
-		// 3.3 通知用户Workspace可用
-		r.notifier.Notify(sid)
+		r.notifier.Logout(pod.Annotations["sid"])
+
+		r.updateWorkspaceStatus(ctx, req.NamespacedName, mv1.WorkspacePhaseDegraded, &pod)
 
 		return ctrl.Result{}, nil
 	}
 
 	lgr.V(5).Info("pod is creating", "name", req.Name, "phase", pod.Status.Phase)
-	// 4.Pod正在被创建,更新ws状态
-	r.updateWorkspaceStatus(ctx, req.NamespacedName, mv1.WorkspacePhaseStaring)
+	// 6.Pod正在被创建或者还未通过就绪检查,更新ws状态
+	r.updateWorkspaceStatus(ctx, req.NamespacedName, mv1.WorkspacePhaseStaring, &pod)
 
 	return ctrl.Result{}, nil
 }
 
-// 更新workspace的状态
-func (r *PodReconciler) updateWorkspaceStatus(ctx context.Context, key client.ObjectKey, phase mv1.WorkSpacePhase) {
+// wasReady 判断Workspace之前是否已经就绪, 就绪之后的Pod不会再回到启动中状态
+func (r *PodReconciler) wasReady(ctx context.Context, key client.ObjectKey) bool {
+	var ws mv1.WorkSpace
+	if err := r.Client.Get(ctx, key, &ws); err != nil {
+		return false
+	}
+
+	return ws.Status.Phase == mv1.WorkspacePhaseRunning || ws.Status.Phase == mv1.WorkspacePhaseDegraded
+}
+
+func podReady(pod *v1.Pod) bool {
+	pc := podCondition(pod, v1.PodReady)
+	return pc != nil && pc.Status == v1.ConditionTrue
+}
+
+// 更新workspace的状态, pod为nil时说明Pod已经被删除
+func (r *PodReconciler) updateWorkspaceStatus(ctx context.Context, key client.ObjectKey, phase mv1.WorkSpacePhase, pod *v1.Pod) {
 	lgr, _ := logr.FromContext(ctx)
 	var (
 		ws  mv1.WorkSpace
@@ -118,13 +159,25 @@ func (r *PodReconciler) updateWorkspaceStatus(ctx context.Context, key client.Ob
 		return
 	}
 
-	// 2.如果实际状态就算期望状态，返回
-	if ws.Status.Phase == phase {
+	// 2.PVC和Pod名称与Workspace相同
+	var pvc *v1.PersistentVolumeClaim
+	var p v1.PersistentVolumeClaim
+	if err = r.Client.Get(ctx, key, &p); err == nil {
+		pvc = &p
+	} else if !errors.IsNotFound(err) {
+		lgr.Error(err, "get pvc")
 		return
 	}
 
-	// 3.更新状态
-	ws.Status.Phase = phase
+	// 3.计算新的状态，如果实际状态就是期望状态，返回
+	status := ws.Status.DeepCopy()
+	computeWorkspaceStatus(status, phase, pod, pvc, metav1.Now())
+	if equality.Semantic.DeepEqual(&ws.Status, status) {
+		return
+	}
+
+	// 4.更新状态
+	ws.Status = *status
 	err = r.Status().Update(ctx, &ws)
 	if err != nil {
 		lgr.Error(err, "update status")
@@ -133,6 +186,68 @@ func (r *PodReconciler) updateWorkspaceStatus(ctx context.Context, key client.Ob
 	return
 }
 
+// computeWorkspaceStatus 根据观察到的Pod和PVC计算Workspace的状态
+func computeWorkspaceStatus(status *mv1.WorkSpaceStatus, phase mv1.WorkSpacePhase, pod *v1.Pod, pvc *v1.PersistentVolumeClaim, now metav1.Time) {
+	if status.Phase != phase {
+		// 重新启动时清除上一次运行的信息
+		if phase == mv1.WorkspacePhaseStaring ||
+			(phase == mv1.WorkspacePhaseRunning && status.Phase != mv1.WorkspacePhaseStaring &&
+				status.Phase != mv1.WorkspacePhaseDegraded) {
+			status.StopReason = ""
+			status.StopMessage = ""
+			status.LastActivityTime = nil
+			status.StartedAt = nil
+			status.StoppedAt = nil
+			status.Reason = ""
+			status.Message = ""
+		}
+
+		switch phase {
+		case mv1.WorkspacePhaseRunning:
+			// 从Degraded恢复时保留启动时间
+			if status.Phase != mv1.WorkspacePhaseDegraded {
+				status.StartedAt = &now
+			}
+		case mv1.WorkspacePhaseStopped:
+			status.StoppedAt = &now
+		}
+
+		status.Phase = phase
+		status.LastTransitionTime = &now
+	}
+
+	setPVCCondition(status, pvc)
+	setPodConditions(status, pod)
+
+	if pod == nil {
+		status.PodIP = ""
+		status.Endpoint = ""
+		return
+	}
+
+	status.PodIP = pod.Status.PodIP
+	status.Endpoint = ""
+	if pod.Status.PodIP != "" && phase == mv1.WorkspacePhaseRunning {
+		status.Endpoint = podEndpoint(pod)
+	}
+
+	// 停止中保留之前的原因，例如Failed之后被停止
+	switch phase {
+	case mv1.WorkspacePhaseFailed:
+		status.Reason, status.Message, _ = podFailure(pod)
+	case mv1.WorkspacePhaseStaring:
+		status.Reason, status.Message = pendingReason(pod)
+	case mv1.WorkspacePhaseDegraded:
+		status.Reason, status.Message = degradedReason(pod)
+	case mv1.WorkspacePhaseRunning:
+		status.Reason, status.Message = "", ""
+	}
+}
+
+func podEndpoint(pod *v1.Pod) string {
+	return pod.Status.PodIP + ":" + strconv.Itoa(int(pod.Spec.Containers[0].Ports[0].ContainerPort))
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *PodReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
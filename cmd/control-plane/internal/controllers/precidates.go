@@ -1,14 +1,39 @@
 package controllers
 
 import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
-// 过滤所有的PVC, 防止其触发Reconcile方法
-var predicatePVC = predicate.NewPredicateFuncs(func(object client.Object) bool {
-	return false
-})
+// 过滤PVC的事件, 防止其触发Reconcile方法
+// 只有扩容过程中PVC的容量或者状态条件发生变化时才触发, 用于更新Resizing条件
+var predicatePVC = predicate.Funcs{
+	CreateFunc: func(event.CreateEvent) bool {
+		return false
+	},
+	DeleteFunc: func(event.DeleteEvent) bool {
+		return false
+	},
+	GenericFunc: func(event.GenericEvent) bool {
+		return false
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldPVC, ok := e.ObjectOld.(*v1.PersistentVolumeClaim)
+		if !ok {
+			return false
+		}
+		newPVC, ok := e.ObjectNew.(*v1.PersistentVolumeClaim)
+		if !ok {
+			return false
+		}
+
+		return !equality.Semantic.DeepEqual(oldPVC.Status.Capacity, newPVC.Status.Capacity) ||
+			!equality.Semantic.DeepEqual(oldPVC.Status.Conditions, newPVC.Status.Conditions)
+	},
+}
 
 var predicatePod = predicate.NewPredicateFuncs(func(object client.Object) bool {
 	return false
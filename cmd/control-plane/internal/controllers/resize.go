@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	mv1 "github.com/mangohow/cloud-ide/cmd/control-plane/internal/api/v1"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Resizing条件的原因
+const (
+	ResizeReasonExpanding           = "Expanding"
+	ResizeReasonFileSystemPending   = "FileSystemResizePending"
+	ResizeReasonResized             = "Resized"
+	ResizeReasonShrinkNotSupported  = "ShrinkNotSupported"
+	ResizeReasonExpansionNotAllowed = "ExpansionNotAllowed"
+)
+
+// resizePVC 当Workspace的存储大小大于PVC的申请时, 在线扩容PVC, 并通过Resizing条件报告进度
+func (r *WorkSpaceReconciler) resizePVC(ctx context.Context, space *mv1.WorkSpace, key client.ObjectKey) error {
+	desired, err := resource.ParseQuantity(space.Spec.Storage)
+	if err != nil {
+		return err
+	}
+
+	pvc := &v1.PersistentVolumeClaim{}
+	if err = r.Client.Get(ctx, key, pvc); err != nil {
+		// PVC还未创建
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	// 只有需要扩容时才查询存储类
+	expandable := false
+	requested := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+	if desired.Cmp(requested) > 0 {
+		if expandable, err = r.volumeExpandable(ctx, pvc); err != nil {
+			return err
+		}
+	}
+
+	existing := meta.FindStatusCondition(space.Status.Conditions, mv1.WorkspaceConditionResizing)
+	cond, expand := resizingCondition(existing, desired, pvc, expandable)
+	if expand {
+		pvc.Spec.Resources.Requests[v1.ResourceStorage] = desired
+		if pvc.Spec.Resources.Limits != nil {
+			pvc.Spec.Resources.Limits[v1.ResourceStorage] = desired
+		}
+
+		ctx, cancelFunc := context.WithTimeout(ctx, time.Second*30)
+		defer cancelFunc()
+		if err = r.Client.Update(ctx, pvc); err != nil {
+			return err
+		}
+	}
+
+	return r.setResizingCondition(ctx, key, cond)
+}
+
+// resizingCondition 根据期望的存储大小和PVC的状态计算Resizing条件, 返回nil表示移除该条件
+// expand为true表示需要更新PVC的申请大小
+func resizingCondition(existing *metav1.Condition, desired resource.Quantity, pvc *v1.PersistentVolumeClaim,
+	expandable bool) (cond *metav1.Condition, expand bool) {
+	requested := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+	cond = &metav1.Condition{
+		Type:   mv1.WorkspaceConditionResizing,
+		Status: metav1.ConditionFalse,
+	}
+
+	switch desired.Cmp(requested) {
+	// 存储卷不支持缩容
+	case -1:
+		cond.Reason = ResizeReasonShrinkNotSupported
+		cond.Message = fmt.Sprintf("cannot shrink volume from %s to %s", requested.String(), desired.String())
+		return cond, false
+
+	case 1:
+		if !expandable {
+			cond.Reason = ResizeReasonExpansionNotAllowed
+			cond.Message = fmt.Sprintf("storage class %s does not allow volume expansion", storageClassOf(pvc))
+			return cond, false
+		}
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = ResizeReasonExpanding
+		cond.Message = fmt.Sprintf("expanding volume from %s to %s", requested.String(), desired.String())
+		return cond, true
+	}
+
+	// 申请大小已经满足, 等待实际容量达到申请大小
+	capacity, ok := pvc.Status.Capacity[v1.ResourceStorage]
+	if ok && capacity.Cmp(requested) < 0 {
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = ResizeReasonExpanding
+		cond.Message = fmt.Sprintf("expanding volume from %s to %s", capacity.String(), requested.String())
+		// 文件系统需要在Pod挂载存储卷时扩容, 已停止的工作空间在下次启动时完成
+		for _, c := range pvc.Status.Conditions {
+			if c.Type == v1.PersistentVolumeClaimFileSystemResizePending && c.Status == v1.ConditionTrue {
+				cond.Reason = ResizeReasonFileSystemPending
+				cond.Message = "waiting for the workspace to start to resize the file system"
+			}
+		}
+		return cond, false
+	}
+
+	// 扩容完成
+	if existing != nil && existing.Status == metav1.ConditionTrue {
+		cond.Reason = ResizeReasonResized
+		cond.Message = "volume resized to " + requested.String()
+		return cond, false
+	}
+	if existing != nil && existing.Reason == ResizeReasonResized {
+		return existing, false
+	}
+
+	// 从未扩容过, 或者缩容/无法扩容的请求已经被撤销
+	return nil, false
+}
+
+// volumeExpandable 判断PVC的存储类是否允许扩容
+func (r *WorkSpaceReconciler) volumeExpandable(ctx context.Context, pvc *v1.PersistentVolumeClaim) (bool, error) {
+	name := storageClassOf(pvc)
+	if name == "" {
+		return false, nil
+	}
+
+	var sc storagev1.StorageClass
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: name}, &sc); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return sc.AllowVolumeExpansion != nil && *sc.AllowVolumeExpansion, nil
+}
+
+func storageClassOf(pvc *v1.PersistentVolumeClaim) string {
+	if pvc.Spec.StorageClassName == nil {
+		return ""
+	}
+
+	return *pvc.Spec.StorageClassName
+}
+
+// setResizingCondition 更新Workspace的Resizing条件, 没有变化时不更新
+func (r *WorkSpaceReconciler) setResizingCondition(ctx context.Context, key client.ObjectKey, cond *metav1.Condition) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var ws mv1.WorkSpace
+		if err := r.Client.Get(ctx, key, &ws); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		conditions := make([]metav1.Condition, len(ws.Status.Conditions))
+		copy(conditions, ws.Status.Conditions)
+		if cond == nil {
+			meta.RemoveStatusCondition(&conditions, mv1.WorkspaceConditionResizing)
+		} else {
+			cond.ObservedGeneration = ws.Generation
+			meta.SetStatusCondition(&conditions, *cond)
+		}
+		if equality.Semantic.DeepEqual(conditions, ws.Status.Conditions) {
+			return nil
+		}
+
+		ws.Status.Conditions = conditions
+		return r.Client.Status().Update(ctx, &ws)
+	})
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestPVC(requested, capacity string) *v1.PersistentVolumeClaim {
+	return &v1.PersistentVolumeClaim{
+		Spec: v1.PersistentVolumeClaimSpec{
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse(requested)},
+			},
+		},
+		Status: v1.PersistentVolumeClaimStatus{
+			Capacity: v1.ResourceList{v1.ResourceStorage: resource.MustParse(capacity)},
+		},
+	}
+}
+
+func TestResizingCondition(t *testing.T) {
+	// 不允许缩容
+	cond, expand := resizingCondition(nil, resource.MustParse("1Gi"), newTestPVC("2Gi", "2Gi"), true)
+	if expand || cond.Status != metav1.ConditionFalse || cond.Reason != ResizeReasonShrinkNotSupported {
+		t.Fatalf("unexpected shrink condition: %v %+v", expand, cond)
+	}
+
+	// 存储类不支持扩容
+	cond, expand = resizingCondition(nil, resource.MustParse("4Gi"), newTestPVC("2Gi", "2Gi"), false)
+	if expand || cond.Reason != ResizeReasonExpansionNotAllowed {
+		t.Fatalf("unexpected not allowed condition: %v %+v", expand, cond)
+	}
+
+	// 开始扩容
+	cond, expand = resizingCondition(nil, resource.MustParse("4Gi"), newTestPVC("2Gi", "2Gi"), true)
+	if !expand || cond.Status != metav1.ConditionTrue || cond.Reason != ResizeReasonExpanding {
+		t.Fatalf("unexpected expanding condition: %v %+v", expand, cond)
+	}
+
+	// 等待文件系统扩容
+	pvc := newTestPVC("4Gi", "2Gi")
+	pvc.Status.Conditions = []v1.PersistentVolumeClaimCondition{{
+		Type: v1.PersistentVolumeClaimFileSystemResizePending, Status: v1.ConditionTrue,
+	}}
+	cond, expand = resizingCondition(cond, resource.MustParse("4Gi"), pvc, false)
+	if expand || cond.Status != metav1.ConditionTrue || cond.Reason != ResizeReasonFileSystemPending {
+		t.Fatalf("unexpected pending condition: %v %+v", expand, cond)
+	}
+
+	// 扩容完成
+	cond, _ = resizingCondition(cond, resource.MustParse("4Gi"), newTestPVC("4Gi", "4Gi"), false)
+	if cond.Status != metav1.ConditionFalse || cond.Reason != ResizeReasonResized {
+		t.Fatalf("unexpected resized condition: %+v", cond)
+	}
+
+	// 从未扩容过
+	if cond, _ = resizingCondition(nil, resource.MustParse("4Gi"), newTestPVC("4Gi", "4Gi"), false); cond != nil {
+		t.Fatalf("condition should be removed: %+v", cond)
+	}
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	mv1 "github.com/mangohow/cloud-ide/cmd/control-plane/internal/api/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	VolumeSnapshotGroup = "snapshot.storage.k8s.io"
+	VolumeSnapshotKind  = "VolumeSnapshot"
+
+	// 从快照恢复存储卷的初始化容器名称
+	snapshotRestorerName = "snapshot-restorer"
+	// 恢复完成后在存储卷中写入的标记文件, 内容为快照地址, 防止每次启动时重复恢复
+	snapshotRestoredMarker = ".snapshot-restored"
+)
+
+// 打包存储卷并上传到对象存储
+const archiveScript = `set -e
+tar -czf - -C "$DATA_DIR" . | aws s3 cp - "$SNAPSHOT_URL"`
+
+// 从对象存储下载并解压到存储卷, 已经恢复过则跳过
+const restoreScript = `set -e
+marker="$DATA_DIR/` + snapshotRestoredMarker + `"
+if [ -f "$marker" ] && [ "$(cat "$marker")" = "$SNAPSHOT_URL" ]; then exit 0; fi
+aws s3 cp "$SNAPSHOT_URL" - | tar -xzf - -C "$DATA_DIR"
+echo "$SNAPSHOT_URL" > "$marker"`
+
+// ArchiveContainer 构造打包或恢复快照的容器, 存储卷挂载在dataDir
+// restore为true时从url恢复, 否则打包上传到url
+func ArchiveContainer(name, volumeName, dataDir, url string, restore bool) v1.Container {
+	script := archiveScript
+	if restore {
+		script = restoreScript
+	}
+
+	c := v1.Container{
+		Name:            name,
+		Image:           SnapshotArchiveImage,
+		ImagePullPolicy: v1.PullIfNotPresent,
+		Command:         []string{"/bin/sh", "-c", script},
+		VolumeMounts: []v1.VolumeMount{
+			{
+				Name:      volumeName,
+				MountPath: dataDir,
+			},
+		},
+		Env: []v1.EnvVar{
+			{
+				Name:  "DATA_DIR",
+				Value: dataDir,
+			},
+			{
+				Name:  "SNAPSHOT_URL",
+				Value: url,
+			},
+		},
+	}
+
+	if SnapshotArchiveSecret != "" {
+		c.EnvFrom = []v1.EnvFromSource{
+			{
+				SecretRef: &v1.SecretEnvSource{
+					LocalObjectReference: v1.LocalObjectReference{Name: SnapshotArchiveSecret},
+				},
+			},
+		}
+	}
+
+	return c
+}
+
+// setRestoreSource 如果Workspace需要从VolumeSnapshot恢复, 设置PVC的数据源
+func setRestoreSource(space *mv1.WorkSpace, pvc *v1.PersistentVolumeClaim) {
+	restore := space.Spec.Restore
+	if restore == nil || restore.Method != mv1.SnapshotMethodVolumeSnapshot {
+		return
+	}
+
+	group := VolumeSnapshotGroup
+	pvc.Spec.DataSource = &v1.TypedLocalObjectReference{
+		APIGroup: &group,
+		Kind:     VolumeSnapshotKind,
+		Name:     restore.Snapshot,
+	}
+}
+
+// restoreInitContainer 如果Workspace需要从对象存储恢复, 返回恢复用的初始化容器
+func restoreInitContainer(space *mv1.WorkSpace, volumeName string) *v1.Container {
+	restore := space.Spec.Restore
+	if restore == nil || restore.Method != mv1.SnapshotMethodArchive {
+		return nil
+	}
+
+	c := ArchiveContainer(snapshotRestorerName, volumeName, space.Spec.MountPath, restore.URL, true)
+	return &c
+}
@@ -1,8 +1,21 @@
 package controllers
 
+import "time"
+
 var (
 	WorkspaceNamespace    = "cloud-ide-ws"
 	StorageClassName      = "standard"
 	GitClonerName         = "git-cloner"
 	DynamicStorageEnabled bool
+
+	// 存储类不支持VolumeSnapshot时, 将存储卷打包上传到对象存储
+	// SnapshotArchiveURL为对象存储的地址, 例如s3://cloud-ide-snapshots
+	// SnapshotArchiveSecret中保存对象存储的凭证, 以环境变量的形式注入
+	SnapshotArchiveURL    string
+	SnapshotArchiveImage  = "amazon/aws-cli"
+	SnapshotArchiveSecret string
+
+	// 工作空间容器探针的检查间隔, 以及启动探针允许的最长启动时间
+	ProbePeriod    = 5 * time.Second
+	StartupTimeout = 5 * time.Minute
 )
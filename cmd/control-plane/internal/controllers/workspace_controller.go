@@ -18,8 +18,8 @@ package controllers
 
 import (
 	"context"
-	"encoding/json"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -62,7 +62,8 @@ func NewWorkSpaceReconciler(c client.Client, scheme *runtime.Scheme) *WorkSpaceR
 // +kubebuilder:rbac:groups=cloud-ide.mangohow.com,resources=workspaces/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=cloud-ide.mangohow.com,resources=workspaces/finalizers,verbs=update
 // +kubebuilder:rbac:groups="",resources=pod,verbs=get;list;watch;create;delete
-// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -98,6 +99,16 @@ func (r *WorkSpaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{Requeue: true}, err
 	}
 
+	// 旧版本的环境变量编码在GitRepository中, 迁移后等待下一次Reconcile
+	migrated, err := r.migrateLegacyEnv(ctx, &ws)
+	if err != nil {
+		lgr.Error(err, "migrate legacy env")
+		return ctrl.Result{Requeue: true}, err
+	}
+	if migrated {
+		return ctrl.Result{}, nil
+	}
+
 	// 2.找到了WorkSpace,根据WorkSpace的Operation字段判断要进行的操作
 	switch ws.Spec.Command {
 	// case2: 启动WorkSpace,检查PVC是否存在,如果不存在则创建
@@ -125,6 +136,12 @@ func (r *WorkSpaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		}
 	}
 
+	// 3.存储大小发生变化时, 扩容PVC
+	if err = r.resizePVC(ctx, &ws, req.NamespacedName); err != nil {
+		lgr.Error(err, "resize pvc")
+		return ctrl.Result{Requeue: true}, err
+	}
+
 	return ctrl.Result{}, nil
 }
 
@@ -211,64 +228,25 @@ func (r *WorkSpaceReconciler) constructPod(space *mv1.WorkSpace) *v1.Pod {
 				Name:  "OPEN_DIR",
 				Value: workspaceDir,
 			},
-		},
-	}
-	
-	// 解析环境变量配置（特别是Claude模板）
-	actualGitRepo := space.Spec.GitRepository
-	if strings.HasPrefix(space.Spec.GitRepository, "ENV:") {
-		// 解析编码的环境变量信息
-		parts := strings.SplitN(space.Spec.GitRepository, "|GIT:", 2)
-		if len(parts) == 2 {
-			envJSON := strings.TrimPrefix(parts[0], "ENV:")
-			actualGitRepo = parts[1]
-			
-			// 解析环境变量JSON
-			var envVars map[string]string
-			if err := json.Unmarshal([]byte(envJSON), &envVars); err == nil {
-				// 添加环境变量到容器
-				for key, value := range envVars {
-					container.Env = append(container.Env, v1.EnvVar{
-						Name:  key,
-						Value: value,
-					})
-				}
-			}
-		}
-	}
-	
-	// 为codercom/code-server镜像添加正确的启动命令
-	if strings.Contains(space.Spec.Image, "codercom/code-server") || strings.Contains(space.Spec.Image, "claude-code-server") {
-		container.Command = []string{"/usr/bin/code-server"}
-		container.Args = []string{
-			"--bind-addr", "0.0.0.0:9999",
-			"--auth", "none",
-			"--disable-update-check",
-			"--disable-telemetry",
-			space.Spec.MountPath,  // 直接使用 MountPath 而不是 workspaceDir
-		}
-		
-		// 如果是增强版Claude镜像，使用特殊的启动脚本
-		if strings.Contains(space.Spec.Image, "claude-code-server") {
-			container.Command = []string{"/app/start-claude-code.sh"}
-			container.Args = []string{}
-			// 添加环境变量
-			container.Env = append(container.Env, v1.EnvVar{
+			// 启动命令中可以通过$(WORKSPACE_DIR)引用存储卷的挂载路径, 通过$(WORKSPACE_PORT)引用工作空间的端口
+			{
 				Name:  "WORKSPACE_DIR",
 				Value: space.Spec.MountPath,
-			})
-		}
-	}
-	
-	// 为简化版Claude AI镜像配置启动命令
-	if strings.Contains(space.Spec.Image, "claude-ai-simple") {
-		// 使用镜像默认的entrypoint，只添加环境变量
-		container.Env = append(container.Env, v1.EnvVar{
-			Name:  "WORKSPACE_DIR",
-			Value: space.Spec.MountPath,
-		})
+			},
+			{
+				Name:  "WORKSPACE_PORT",
+				Value: strconv.Itoa(int(space.Spec.Port)),
+			},
+		},
 	}
-	
+
+	// 用户配置的环境变量, 敏感信息通过envFrom从Secret中加载
+	container.Env = append(container.Env, space.Spec.Env...)
+	container.EnvFrom = space.Spec.EnvFrom
+
+	// 按照模板中的启动配置设置启动命令
+	applyLaunch(&container, space.Spec.Launch, space.Spec.Port)
+
 	pod := &v1.Pod{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "Pod",
@@ -298,6 +276,8 @@ func (r *WorkSpaceReconciler) constructPod(space *mv1.WorkSpace) *v1.Pod {
 				},
 			},
 			Containers: []v1.Container{container},
+			// 使用用户的配额类时, Pod在创建时计入用户的ResourceQuota
+			PriorityClassName: space.Spec.PriorityClassName,
 		},
 	}
 
@@ -316,17 +296,18 @@ func (r *WorkSpaceReconciler) constructPod(space *mv1.WorkSpace) *v1.Pod {
 		}
 	}
 
-	if actualGitRepo == "" {
-		return pod
+	// 从对象存储中的快照恢复时, 需要在其它初始化容器之前执行
+	if restorer := restoreInitContainer(space, volumeName); restorer != nil {
+		pod.Spec.InitContainers = append(pod.Spec.InitContainers, *restorer)
 	}
 
 	// 如果设置了git仓库，则通过init容器来clone
-	idx := strings.LastIndexByte(actualGitRepo, '/') + 1
+	if gitRepo := space.Spec.GitRepository; gitRepo != "" {
+		idx := strings.LastIndexByte(gitRepo, '/') + 1
 
-	localPath := filepath.Join(workspaceDir, strings.TrimSuffix(actualGitRepo[idx:], ".git"))
-	pod.Spec.InitContainers = []v1.Container{
-		{
-			Name:            "git-cloner",
+		localPath := filepath.Join(workspaceDir, strings.TrimSuffix(gitRepo[idx:], ".git"))
+		pod.Spec.InitContainers = append(pod.Spec.InitContainers, v1.Container{
+			Name:            gitClonerName,
 			Image:           GitClonerName,
 			WorkingDir:      space.Spec.MountPath,
 			ImagePullPolicy: v1.PullIfNotPresent,
@@ -341,17 +322,20 @@ func (r *WorkSpaceReconciler) constructPod(space *mv1.WorkSpace) *v1.Pod {
 			Env: []v1.EnvVar{
 				{
 					Name:  "REPO_URL",
-					Value: actualGitRepo,
+					Value: gitRepo,
 				},
 				{
 					Name:  "LOCAL_PATH",
 					Value: localPath,
 				},
 			},
-		},
+		})
+		// 设置环境变量，code-server打开时使用该路径
+		pod.Spec.Containers[0].Env[0].Value = localPath
 	}
-	// 设置环境变量，code-server打开时使用该路径
-	pod.Spec.Containers[0].Env[0].Value = localPath
+
+	// 模板中的初始化步骤在代码仓库克隆之后执行
+	pod.Spec.InitContainers = append(pod.Spec.InitContainers, initStepContainers(space, &container, volumeName)...)
 
 	return pod
 }
@@ -427,6 +411,9 @@ func (r *WorkSpaceReconciler) constructPVC(space *mv1.WorkSpace) (*v1.Persistent
 		pvc.Spec.StorageClassName = &StorageClassName
 	}
 
+	// 从快照恢复
+	setRestoreSource(space, pvc)
+
 	return pvc, nil
 }
 
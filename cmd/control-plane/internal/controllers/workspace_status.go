@@ -0,0 +1,257 @@
+package controllers
+
+import (
+	"fmt"
+
+	mv1 "github.com/mangohow/cloud-ide/cmd/control-plane/internal/api/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// 拉取代码的初始化容器名称
+const gitClonerName = "git-cloner"
+
+// 工作空间运行中就绪检查失败的原因
+const ReasonReadinessLost = "ReadinessLost"
+
+// 容器处于这些等待状态时, 不经过人工干预无法恢复, Workspace进入Failed状态
+var terminalWaitingReasons = map[string]bool{
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+	"ErrImageNeverPull":          true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+	"CrashLoopBackOff":           true,
+}
+
+// podFailure 检查Pod是否处于无法恢复的错误状态, 返回错误原因和描述
+func podFailure(pod *v1.Pod) (reason, message string, failed bool) {
+	if pod.Status.Phase == v1.PodFailed {
+		reason = pod.Status.Reason
+		if reason == "" {
+			reason = "PodFailed"
+		}
+		return reason, pod.Status.Message, true
+	}
+
+	for _, cs := range pod.Status.InitContainerStatuses {
+		if reason, message, failed = containerFailure(&cs); failed {
+			return reason, cs.Name + ": " + message, true
+		}
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		if reason, message, failed = containerFailure(&cs); failed {
+			return reason, cs.Name + ": " + message, true
+		}
+	}
+
+	return "", "", false
+}
+
+func containerFailure(cs *v1.ContainerStatus) (reason, message string, failed bool) {
+	waiting := cs.State.Waiting
+	if waiting == nil || !terminalWaitingReasons[waiting.Reason] {
+		return "", "", false
+	}
+
+	message = waiting.Message
+	// CrashLoopBackOff的message中没有退出原因, 使用上一次退出的信息
+	if last := cs.LastTerminationState.Terminated; last != nil {
+		message = terminatedMessage(last)
+	}
+
+	return waiting.Reason, message, true
+}
+
+func terminatedMessage(t *v1.ContainerStateTerminated) string {
+	msg := fmt.Sprintf("exited with code %d", t.ExitCode)
+	if t.Reason != "" {
+		msg += " (" + t.Reason + ")"
+	}
+	if t.Message != "" {
+		msg += ": " + t.Message
+	}
+
+	return msg
+}
+
+// setPVCCondition 根据PVC的状态设置PVCBound
+func setPVCCondition(status *mv1.WorkSpaceStatus, pvc *v1.PersistentVolumeClaim) {
+	cond := metav1.Condition{
+		Type:   mv1.WorkspaceConditionPVCBound,
+		Status: metav1.ConditionFalse,
+		Reason: "NotFound",
+	}
+	if pvc != nil {
+		cond.Reason = string(pvc.Status.Phase)
+		if cond.Reason == "" {
+			cond.Reason = string(v1.ClaimPending)
+		}
+		if pvc.Status.Phase == v1.ClaimBound {
+			cond.Status = metav1.ConditionTrue
+		}
+	}
+
+	meta.SetStatusCondition(&status.Conditions, cond)
+}
+
+// setPodConditions 根据Pod以及容器的状态设置PodScheduled、RepoCloned和Ready
+// pod为nil时说明Pod已经被删除
+func setPodConditions(status *mv1.WorkSpaceStatus, pod *v1.Pod) {
+	if pod == nil {
+		meta.RemoveStatusCondition(&status.Conditions, mv1.WorkspaceConditionPodScheduled)
+		meta.RemoveStatusCondition(&status.Conditions, mv1.WorkspaceConditionRepoCloned)
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:   mv1.WorkspaceConditionReady,
+			Status: metav1.ConditionFalse,
+			Reason: "PodNotFound",
+		})
+		return
+	}
+
+	// 1.PodScheduled, 直接使用Pod的Condition
+	scheduled := metav1.Condition{
+		Type:   mv1.WorkspaceConditionPodScheduled,
+		Status: metav1.ConditionFalse,
+		Reason: "Pending",
+	}
+	if pc := podCondition(pod, v1.PodScheduled); pc != nil {
+		scheduled.Status = metav1.ConditionStatus(pc.Status)
+		scheduled.Message = pc.Message
+		if pc.Reason != "" {
+			scheduled.Reason = pc.Reason
+		} else if pc.Status == v1.ConditionTrue {
+			scheduled.Reason = "Scheduled"
+		}
+	}
+	meta.SetStatusCondition(&status.Conditions, scheduled)
+
+	// 2.RepoCloned, 只有指定了git仓库时才有git-cloner容器
+	if cs := initContainerStatus(pod, gitClonerName); cs != nil {
+		meta.SetStatusCondition(&status.Conditions, repoClonedCondition(cs))
+	} else if hasInitContainer(pod, gitClonerName) {
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:   mv1.WorkspaceConditionRepoCloned,
+			Status: metav1.ConditionFalse,
+			Reason: "Pending",
+		})
+	} else {
+		meta.RemoveStatusCondition(&status.Conditions, mv1.WorkspaceConditionRepoCloned)
+	}
+
+	// 3.Ready, Pod Ready时为True, 否则使用容器的等待原因
+	ready := metav1.Condition{
+		Type:   mv1.WorkspaceConditionReady,
+		Status: metav1.ConditionFalse,
+		Reason: "ContainersNotReady",
+	}
+	if pc := podCondition(pod, v1.PodReady); pc != nil && pc.Status == v1.ConditionTrue {
+		ready.Status = metav1.ConditionTrue
+		ready.Reason = "PodReady"
+	} else {
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" {
+				ready.Reason = cs.State.Waiting.Reason
+				ready.Message = cs.State.Waiting.Message
+				break
+			}
+		}
+	}
+	meta.SetStatusCondition(&status.Conditions, ready)
+}
+
+func repoClonedCondition(cs *v1.ContainerStatus) metav1.Condition {
+	cond := metav1.Condition{
+		Type:   mv1.WorkspaceConditionRepoCloned,
+		Status: metav1.ConditionFalse,
+		Reason: "Pending",
+	}
+
+	switch {
+	case cs.State.Terminated != nil && cs.State.Terminated.ExitCode == 0:
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = "Cloned"
+	case cs.State.Terminated != nil:
+		cond.Reason = "CloneFailed"
+		cond.Message = terminatedMessage(cs.State.Terminated)
+	case cs.State.Running != nil:
+		cond.Reason = "Cloning"
+	case cs.State.Waiting != nil:
+		if cs.State.Waiting.Reason != "" {
+			cond.Reason = cs.State.Waiting.Reason
+		}
+		cond.Message = cs.State.Waiting.Message
+		if last := cs.LastTerminationState.Terminated; last != nil {
+			cond.Message = terminatedMessage(last)
+		}
+	}
+
+	return cond
+}
+
+func podCondition(pod *v1.Pod, t v1.PodConditionType) *v1.PodCondition {
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == t {
+			return &pod.Status.Conditions[i]
+		}
+	}
+
+	return nil
+}
+
+func initContainerStatus(pod *v1.Pod, name string) *v1.ContainerStatus {
+	for i := range pod.Status.InitContainerStatuses {
+		if pod.Status.InitContainerStatuses[i].Name == name {
+			return &pod.Status.InitContainerStatuses[i]
+		}
+	}
+
+	return nil
+}
+
+func hasInitContainer(pod *v1.Pod, name string) bool {
+	for _, c := range pod.Spec.InitContainers {
+		if c.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pendingReason 获取Pod启动中时的原因, 例如资源不足无法调度
+func pendingReason(pod *v1.Pod) (reason, message string) {
+	if pc := podCondition(pod, v1.PodScheduled); pc != nil && pc.Status == v1.ConditionFalse {
+		return pc.Reason, pc.Message
+	}
+	for _, cs := range pod.Status.InitContainerStatuses {
+		if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" && cs.State.Waiting.Reason != "PodInitializing" {
+			return cs.State.Waiting.Reason, cs.Name + ": " + cs.State.Waiting.Message
+		}
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" &&
+			cs.State.Waiting.Reason != "ContainerCreating" && cs.State.Waiting.Reason != "PodInitializing" {
+			return cs.State.Waiting.Reason, cs.Name + ": " + cs.State.Waiting.Message
+		}
+	}
+
+	return "", ""
+}
+
+// degradedReason 获取工作空间失去就绪状态的原因, 例如容器重启或就绪检查失败
+func degradedReason(pod *v1.Pod) (reason, message string) {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" {
+			return cs.State.Waiting.Reason, cs.Name + ": " + cs.State.Waiting.Message
+		}
+	}
+
+	reason = ReasonReadinessLost
+	if pc := podCondition(pod, v1.PodReady); pc != nil {
+		message = pc.Message
+	}
+
+	return reason, message
+}
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"testing"
+
+	mv1 "github.com/mangohow/cloud-ide/cmd/control-plane/internal/api/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestPod() *v1.Pod {
+	return &v1.Pod{
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{{Name: gitClonerName}},
+			Containers:     []v1.Container{{Name: "workspace", Ports: []v1.ContainerPort{{ContainerPort: 9999}}}},
+		},
+	}
+}
+
+func TestPodFailure(t *testing.T) {
+	pod := newTestPod()
+	pod.Status.ContainerStatuses = []v1.ContainerStatus{{
+		Name:  "workspace",
+		State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ErrImagePull"}},
+	}}
+	if _, _, failed := podFailure(pod); failed {
+		t.Fatal("ErrImagePull should be retried")
+	}
+
+	pod.Status.ContainerStatuses[0].State.Waiting.Reason = "ImagePullBackOff"
+	if reason, _, failed := podFailure(pod); !failed || reason != "ImagePullBackOff" {
+		t.Fatalf("want ImagePullBackOff, got %q", reason)
+	}
+
+	pod = newTestPod()
+	pod.Status.InitContainerStatuses = []v1.ContainerStatus{{
+		Name:                 gitClonerName,
+		State:                v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+		LastTerminationState: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 128, Reason: "Error"}},
+	}}
+	reason, message, failed := podFailure(pod)
+	if !failed || reason != "CrashLoopBackOff" || message != "git-cloner: exited with code 128 (Error)" {
+		t.Fatalf("unexpected failure: %v %q %q", failed, reason, message)
+	}
+}
+
+func TestComputeWorkspaceStatus(t *testing.T) {
+	now := metav1.Now()
+	status := &mv1.WorkSpaceStatus{Phase: mv1.WorkspacePhaseStopped, StopReason: mv1.StopReasonIdle}
+	pvc := &v1.PersistentVolumeClaim{Status: v1.PersistentVolumeClaimStatus{Phase: v1.ClaimBound}}
+
+	// 资源不足无法调度
+	pod := newTestPod()
+	pod.Status.Conditions = []v1.PodCondition{{
+		Type: v1.PodScheduled, Status: v1.ConditionFalse, Reason: "Unschedulable", Message: "0/3 nodes are available",
+	}}
+	computeWorkspaceStatus(status, mv1.WorkspacePhaseStaring, pod, pvc, now)
+	if status.StopReason != "" || status.Reason != "Unschedulable" || status.LastTransitionTime == nil {
+		t.Fatalf("unexpected starting status: %+v", status)
+	}
+	if !meta.IsStatusConditionTrue(status.Conditions, mv1.WorkspaceConditionPVCBound) ||
+		!meta.IsStatusConditionFalse(status.Conditions, mv1.WorkspaceConditionPodScheduled) {
+		t.Fatalf("unexpected conditions: %+v", status.Conditions)
+	}
+
+	// 启动完成
+	pod.Status.PodIP = "10.0.0.1"
+	pod.Status.Conditions = []v1.PodCondition{
+		{Type: v1.PodScheduled, Status: v1.ConditionTrue},
+		{Type: v1.PodReady, Status: v1.ConditionTrue},
+	}
+	pod.Status.InitContainerStatuses = []v1.ContainerStatus{{
+		Name:  gitClonerName,
+		State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 0}},
+	}}
+	computeWorkspaceStatus(status, mv1.WorkspacePhaseRunning, pod, pvc, now)
+	if status.StartedAt == nil || status.Endpoint != "10.0.0.1:9999" || status.Reason != "" {
+		t.Fatalf("unexpected running status: %+v", status)
+	}
+	for _, c := range []string{mv1.WorkspaceConditionPodScheduled, mv1.WorkspaceConditionRepoCloned, mv1.WorkspaceConditionReady} {
+		if !meta.IsStatusConditionTrue(status.Conditions, c) {
+			t.Fatalf("condition %s should be true: %+v", c, status.Conditions)
+		}
+	}
+
+	// 失去就绪状态后恢复, 保留启动时间
+	startedAt := status.StartedAt
+	pod.Status.Conditions[1] = v1.PodCondition{Type: v1.PodReady, Status: v1.ConditionFalse, Message: "containers with unready status: [workspace]"}
+	computeWorkspaceStatus(status, mv1.WorkspacePhaseDegraded, pod, pvc, metav1.Now())
+	if status.Reason != ReasonReadinessLost || status.Endpoint != "" ||
+		!meta.IsStatusConditionFalse(status.Conditions, mv1.WorkspaceConditionReady) {
+		t.Fatalf("unexpected degraded status: %+v", status)
+	}
+	pod.Status.Conditions[1].Status = v1.ConditionTrue
+	computeWorkspaceStatus(status, mv1.WorkspacePhaseRunning, pod, pvc, metav1.Now())
+	if status.StartedAt != startedAt || status.Reason != "" || status.Endpoint != "10.0.0.1:9999" {
+		t.Fatalf("unexpected recovered status: %+v", status)
+	}
+
+	// Pod被删除
+	computeWorkspaceStatus(status, mv1.WorkspacePhaseStopped, nil, pvc, now)
+	if status.StoppedAt == nil || status.StartedAt == nil || status.Endpoint != "" ||
+		meta.FindStatusCondition(status.Conditions, mv1.WorkspaceConditionRepoCloned) != nil ||
+		!meta.IsStatusConditionFalse(status.Conditions, mv1.WorkspaceConditionReady) {
+		t.Fatalf("unexpected stopped status: %+v", status)
+	}
+}
@@ -22,3 +22,16 @@ func RecoveryInterceptorMiddleware(logger *logr.Logger) grpc.UnaryServerIntercep
 		return handler(ctx, req)
 	}
 }
+
+// RecoveryStreamInterceptorMiddleware 防止流式接口panic导致整个服务崩溃
+func RecoveryStreamInterceptorMiddleware(logger *logr.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		defer func() {
+			if err := recover(); err != nil {
+				logger.Error(RecoveredErr, "", "info", err)
+			}
+		}()
+
+		return handler(srv, ss)
+	}
+}
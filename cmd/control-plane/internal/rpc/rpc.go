@@ -34,9 +34,14 @@ func (r *GrpcServer) Start(ctx context.Context) error {
 		r.logger.Error(err, "create grpc service")
 		return err
 	}
-	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
-		middleware.RecoveryInterceptorMiddleware(&r.logger),
-	))
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			middleware.RecoveryInterceptorMiddleware(&r.logger),
+		),
+		grpc.ChainStreamInterceptor(
+			middleware.RecoveryStreamInterceptorMiddleware(&r.logger),
+		),
+	)
 	pb.RegisterCloudIdeServiceServer(server, r.wsSvc)
 
 	go func() {
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	mv1 "github.com/mangohow/cloud-ide/cmd/control-plane/internal/api/v1"
+	"github.com/mangohow/cloud-ide/pkg/pb"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// code-server的心跳接口, 返回 {"status":"alive","lastHeartbeat":1700000000000}
+// 用户每次访问code-server时都会更新lastHeartbeat
+const heartbeatPath = "/healthz"
+
+type heartbeat struct {
+	Status        string `json:"status"`
+	LastHeartbeat int64  `json:"lastHeartbeat"`
+}
+
+// IdleDetector 定期检查运行中的Workspace的最后活跃时间
+// 超过空闲时间没有用户活动或者用完运行时长配额的Workspace会通过StopSpace被停止
+type IdleDetector struct {
+	logger    logr.Logger
+	client    client.Client
+	svc       *WorkSpaceService
+	namespace string
+	// 检查间隔
+	interval time.Duration
+	// Workspace未指定空闲时间时使用的默认值, 0表示不检测
+	defaultTimeout time.Duration
+	httpClient     *http.Client
+}
+
+func NewIdleDetector(c client.Client, logger logr.Logger, svc *WorkSpaceService, namespace string, interval, defaultTimeout time.Duration) *IdleDetector {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	return &IdleDetector{
+		logger:         logger.WithName("idle-detector"),
+		client:         c,
+		svc:            svc,
+		namespace:      namespace,
+		interval:       interval,
+		defaultTimeout: defaultTimeout,
+		httpClient:     &http.Client{Timeout: time.Second * 5},
+	}
+}
+
+// Start 由manager调用, 直到ctx结束
+func (d *IdleDetector) Start(ctx context.Context) error {
+	d.logger.Info("idle detector started", "interval", d.interval, "defaultTimeout", d.defaultTimeout)
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			d.detect(ctx)
+		}
+	}
+}
+
+func (d *IdleDetector) detect(ctx context.Context) {
+	var wss mv1.WorkSpaceList
+	if err := d.client.List(ctx, &wss, client.InNamespace(d.namespace)); err != nil {
+		d.logger.Error(err, "list workspace")
+		return
+	}
+
+	now := time.Now()
+	for i := range wss.Items {
+		ws := &wss.Items[i]
+		// 失去就绪状态的工作空间同样会因为空闲而停止
+		if ws.Spec.Command != mv1.WorkSpaceStart ||
+			(ws.Status.Phase != mv1.WorkspacePhaseRunning && ws.Status.Phase != mv1.WorkspacePhaseDegraded) {
+			continue
+		}
+
+		if runtime, ok := runtimeExceeded(ws, now); ok {
+			d.logger.Info("workspace runtime quota exceeded, stopping", "sid", ws.Spec.SID, "runtime", runtime.Round(time.Second))
+			d.stopWorkspace(ctx, ws, mv1.StopReasonRuntimeQuota,
+				fmt.Sprintf("runtime quota exceeded after running for %s", runtime.Round(time.Minute)))
+			continue
+		}
+
+		timeout := d.timeoutOf(ws)
+		if timeout <= 0 {
+			continue
+		}
+
+		last, ok := d.lastActivity(ctx, ws)
+		if !ok || last.IsZero() {
+			continue
+		}
+		d.recordActivity(ctx, ws, last)
+
+		if idle := now.Sub(last); idle >= timeout {
+			d.logger.Info("workspace is idle, stopping", "sid", ws.Spec.SID, "idle", idle.Round(time.Second))
+			d.stopWorkspace(ctx, ws, mv1.StopReasonIdle, fmt.Sprintf("no user activity for %s", idle.Round(time.Minute)))
+		}
+	}
+}
+
+// timeoutOf 获取Workspace的空闲超时时间
+func (d *IdleDetector) timeoutOf(ws *mv1.WorkSpace) time.Duration {
+	switch {
+	case ws.Spec.IdleTimeoutSeconds > 0:
+		return time.Duration(ws.Spec.IdleTimeoutSeconds) * time.Second
+	case ws.Spec.IdleTimeoutSeconds < 0:
+		return 0
+	default:
+		return d.defaultTimeout
+	}
+}
+
+// runtimeExceeded 检查Workspace本次启动后的运行时长是否超过了限制
+func runtimeExceeded(ws *mv1.WorkSpace, now time.Time) (time.Duration, bool) {
+	if ws.Spec.MaxRuntimeSeconds <= 0 || ws.Status.StartedAt == nil {
+		return 0, false
+	}
+
+	runtime := now.Sub(ws.Status.StartedAt.Time)
+	return runtime, runtime >= time.Duration(ws.Spec.MaxRuntimeSeconds)*time.Second
+}
+
+// lastActivity 获取Workspace的最后活跃时间, 使用code-server的心跳和已记录的活跃时间中较晚的一个
+// 探测失败时(例如镜像没有心跳接口)无法判断用户是否在使用, 返回false, 不能当作空闲处理
+func (d *IdleDetector) lastActivity(ctx context.Context, ws *mv1.WorkSpace) (time.Time, bool) {
+	var pod v1.Pod
+	if err := d.client.Get(ctx, client.ObjectKey{Name: ws.Name, Namespace: ws.Namespace}, &pod); err != nil {
+		if !errors.IsNotFound(err) {
+			d.logger.Error(err, "get pod", "name", ws.Name)
+		}
+		return time.Time{}, false
+	}
+	if pod.Status.PodIP == "" {
+		return time.Time{}, false
+	}
+
+	endpoint := pod.Status.PodIP + ":" + strconv.Itoa(int(ws.Spec.Port))
+	hb, err := d.probeHeartbeat(ctx, endpoint)
+	if err != nil {
+		d.logger.V(1).Info("probe heartbeat failed, skip idle detection", "sid", ws.Spec.SID, "error", err.Error())
+		return time.Time{}, false
+	}
+
+	var last time.Time
+	if ws.Status.LastActivityTime != nil {
+		last = ws.Status.LastActivityTime.Time
+	}
+	// code-server启动后还没有用户连接时没有心跳, 从Pod启动开始计算
+	if last.IsZero() && pod.Status.StartTime != nil {
+		last = pod.Status.StartTime.Time
+	}
+	if hb.After(last) {
+		last = hb
+	}
+
+	return last, true
+}
+
+func (d *IdleDetector) probeHeartbeat(ctx context.Context, endpoint string) (time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+endpoint+heartbeatPath, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var hb heartbeat
+	if err := json.NewDecoder(resp.Body).Decode(&hb); err != nil {
+		return time.Time{}, err
+	}
+	if hb.LastHeartbeat <= 0 {
+		return time.Time{}, nil
+	}
+
+	return time.UnixMilli(hb.LastHeartbeat), nil
+}
+
+// recordActivity 将最后活跃时间记录到Workspace的状态中
+func (d *IdleDetector) recordActivity(ctx context.Context, ws *mv1.WorkSpace, last time.Time) {
+	if ws.Status.LastActivityTime != nil && !last.After(ws.Status.LastActivityTime.Time) {
+		return
+	}
+
+	err := d.svc.updateStatus(ctx, client.ObjectKeyFromObject(ws), func(status *mv1.WorkSpaceStatus) {
+		status.LastActivityTime = &metav1.Time{Time: last}
+	})
+	if err != nil {
+		d.logger.Error(err, "record activity", "sid", ws.Spec.SID)
+	}
+}
+
+func (d *IdleDetector) stopWorkspace(ctx context.Context, ws *mv1.WorkSpace, reason, message string) {
+	_, err := d.svc.StopSpace(ctx, &pb.RequestStop{
+		Uid:     ws.Spec.UID,
+		Sid:     ws.Spec.SID,
+		Reason:  reason,
+		Message: message,
+	})
+	if err != nil {
+		d.logger.Error(err, "stop workspace", "sid", ws.Spec.SID, "reason", reason)
+	}
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	mv1 "github.com/mangohow/cloud-ide/cmd/control-plane/internal/api/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// heartbeatServer 模拟code-server的心跳接口, last为零值时返回404
+func heartbeatServer(t *testing.T, last time.Time) (string, int32) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != heartbeatPath || last.IsZero() {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"status":"alive","lastHeartbeat":%d}`, last.UnixMilli())
+	}))
+	t.Cleanup(server.Close)
+
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return host, int32(p)
+}
+
+func newTestDetector(defaultTimeout time.Duration, objs ...client.Object) (*IdleDetector, client.Client) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = mv1.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+
+	svc := NewWorkSpaceService(c, c, logr.Discard(), nil, nil, "ns")
+	return NewIdleDetector(c, logr.Discard(), svc, "ns", time.Minute, defaultTimeout), c
+}
+
+func TestIdleDetectorDetect(t *testing.T) {
+	now := time.Now()
+	ago := func(d time.Duration) *metav1.Time { return &metav1.Time{Time: now.Add(-d)} }
+
+	tests := []struct {
+		name           string
+		defaultTimeout time.Duration
+		idleTimeout    int32
+		maxRuntime     int64
+		phase          mv1.WorkSpacePhase
+		command        mv1.WorkspaceCommand
+		lastActivity   *metav1.Time
+		startedAt      *metav1.Time
+		// 心跳接口返回的最后活跃时间, 零值表示探测失败
+		heartbeat time.Time
+		// 没有Pod时只能使用已记录的活跃时间
+		noPod bool
+
+		wantReason string
+		// 期望记录的最后活跃时间, nil表示不检查
+		wantActivity *time.Time
+	}{
+		{
+			name:         "idle beyond threshold",
+			idleTimeout:  600,
+			lastActivity: ago(time.Hour),
+			heartbeat:    now.Add(-20 * time.Minute),
+			wantReason:   mv1.StopReasonIdle,
+		},
+		{
+			name:         "heartbeat resets activity",
+			idleTimeout:  600,
+			lastActivity: ago(time.Hour),
+			heartbeat:    now.Add(-time.Minute),
+			wantActivity: timePtr(now.Add(-time.Minute)),
+		},
+		{
+			name:         "just below threshold",
+			idleTimeout:  600,
+			lastActivity: ago(9 * time.Minute),
+			heartbeat:    now.Add(-9 * time.Minute),
+		},
+		{
+			name:           "default timeout",
+			defaultTimeout: 30 * time.Minute,
+			lastActivity:   ago(time.Hour),
+			heartbeat:      now.Add(-time.Hour),
+			wantReason:     mv1.StopReasonIdle,
+		},
+		{
+			name:         "no default timeout",
+			lastActivity: ago(time.Hour),
+		},
+		{
+			name:           "detection disabled",
+			defaultTimeout: time.Minute,
+			idleTimeout:    -1,
+			lastActivity:   ago(time.Hour),
+		},
+		{
+			// 镜像没有心跳接口时无法判断是否空闲, 不能按已记录的活跃时间停止
+			name:         "probe failed skips workspace",
+			idleTimeout:  600,
+			lastActivity: ago(time.Hour),
+		},
+		{
+			name:         "no pod skips workspace",
+			idleTimeout:  600,
+			lastActivity: ago(time.Hour),
+			noPod:        true,
+		},
+		{
+			name:        "no activity known",
+			idleTimeout: 600,
+			noPod:       true,
+		},
+		{
+			name:         "degraded workspace is checked",
+			idleTimeout:  600,
+			phase:        mv1.WorkspacePhaseDegraded,
+			lastActivity: ago(time.Hour),
+			heartbeat:    now.Add(-time.Hour),
+			wantReason:   mv1.StopReasonIdle,
+		},
+		{
+			name:         "stopping workspace is skipped",
+			idleTimeout:  600,
+			phase:        mv1.WorkspacePhaseStopping,
+			lastActivity: ago(time.Hour),
+		},
+		{
+			name:         "stopped workspace is skipped",
+			idleTimeout:  600,
+			command:      mv1.WorkSpaceStop,
+			lastActivity: ago(time.Hour),
+		},
+		{
+			name:         "runtime quota exceeded",
+			idleTimeout:  600,
+			maxRuntime:   3600,
+			startedAt:    ago(2 * time.Hour),
+			lastActivity: ago(time.Minute),
+			heartbeat:    now,
+			wantReason:   mv1.StopReasonRuntimeQuota,
+		},
+		{
+			name:         "runtime quota not exceeded",
+			idleTimeout:  600,
+			maxRuntime:   3600,
+			startedAt:    ago(30 * time.Minute),
+			lastActivity: ago(time.Minute),
+			heartbeat:    now,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.phase == "" {
+				tt.phase = mv1.WorkspacePhaseRunning
+			}
+			if tt.command == "" {
+				tt.command = mv1.WorkSpaceStart
+			}
+
+			host, port := heartbeatServer(t, tt.heartbeat)
+			ws := &mv1.WorkSpace{
+				ObjectMeta: metav1.ObjectMeta{Name: workspaceName("u1", "s1"), Namespace: "ns"},
+				Spec: mv1.WorkSpaceSpec{
+					UID:                "u1",
+					SID:                "s1",
+					Port:               port,
+					Command:            tt.command,
+					IdleTimeoutSeconds: tt.idleTimeout,
+					MaxRuntimeSeconds:  tt.maxRuntime,
+				},
+				Status: mv1.WorkSpaceStatus{
+					Phase:            tt.phase,
+					LastActivityTime: tt.lastActivity,
+					StartedAt:        tt.startedAt,
+				},
+			}
+			objs := []client.Object{ws}
+			if !tt.noPod {
+				objs = append(objs, &v1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: ws.Name, Namespace: "ns"},
+					Status:     v1.PodStatus{PodIP: host},
+				})
+			}
+
+			d, c := newTestDetector(tt.defaultTimeout, objs...)
+			d.detect(context.Background())
+
+			var got mv1.WorkSpace
+			if err := c.Get(context.Background(), client.ObjectKeyFromObject(ws), &got); err != nil {
+				t.Fatal(err)
+			}
+			stopped := got.Spec.Command == mv1.WorkSpaceStop && tt.command == mv1.WorkSpaceStart
+			if (tt.wantReason != "") != stopped {
+				t.Fatalf("stopped = %v, want reason %q", stopped, tt.wantReason)
+			}
+			if got.Status.StopReason != tt.wantReason {
+				t.Errorf("stop reason = %q, want %q", got.Status.StopReason, tt.wantReason)
+			}
+			if tt.wantActivity != nil {
+				if got.Status.LastActivityTime == nil || !got.Status.LastActivityTime.Time.Equal(tt.wantActivity.Truncate(time.Second)) {
+					t.Errorf("last activity = %v, want %v", got.Status.LastActivityTime, *tt.wantActivity)
+				}
+			}
+		})
+	}
+}
+
+func TestIdleDetectorTimeoutOf(t *testing.T) {
+	d := &IdleDetector{defaultTimeout: 30 * time.Minute}
+	tests := []struct {
+		seconds int32
+		want    time.Duration
+	}{
+		{0, 30 * time.Minute},
+		{600, 10 * time.Minute},
+		{-1, 0},
+	}
+	for _, tt := range tests {
+		ws := &mv1.WorkSpace{Spec: mv1.WorkSpaceSpec{IdleTimeoutSeconds: tt.seconds}}
+		if got := d.timeoutOf(ws); got != tt.want {
+			t.Errorf("timeoutOf(%d) = %v, want %v", tt.seconds, got, tt.want)
+		}
+	}
+}
+
+func TestIdleDetectorStop(t *testing.T) {
+	d, _ := newTestDetector(time.Minute)
+	d.interval = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.Start(ctx) }()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Start returned error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("detector did not stop after the context was cancelled")
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
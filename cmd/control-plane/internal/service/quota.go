@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-logr/logr"
+	mv1 "github.com/mangohow/cloud-ide/cmd/control-plane/internal/api/v1"
+	"github.com/mangohow/cloud-ide/pkg/pb"
+	"google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	v1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;create;delete
+// +kubebuilder:rbac:groups="",resources=resourcequotas,verbs=get;create;update
+// +kubebuilder:rbac:groups=scheduling.k8s.io,resources=priorityclasses,verbs=get;create
+
+// 所有用户的Workspace都在同一个命名空间中, 用户的配额分两层执行:
+// 1. 每个用户有一个ResourceQuota, 通过PriorityClass作用域只统计使用该用户配额类的Pod,
+//    API Server在创建Pod时执行, controller重建的Pod同样受限制
+// 2. ResourceQuota的作用域只能统计Pod, 存储总量在创建或启动Workspace时检查: 检查之前通过Lease锁定用户,
+//    并直接从API Server读取用户的Workspace, control-plane有多个副本时同一个用户的检查也是串行执行的
+
+const (
+	// 用户配额类的名称前缀, 后面为用户的uid
+	quotaClassPrefix = "cloud-ide-user-"
+	// 锁定用户的Lease的名称前缀, 后面为用户的uid
+	quotaLeasePrefix = "quota-"
+	// Lease的有效期, 持有者崩溃后其它副本在过期后接管
+	quotaLeaseSeconds = 30
+	// 等待其它请求释放锁的间隔
+	quotaLockInterval = 100 * time.Millisecond
+	// 最多等待的时间, 超过Lease的有效期, 因此持有者崩溃时也能获得锁
+	quotaLockTimeout = (quotaLeaseSeconds + 10) * time.Second
+)
+
+// quotaLocker 使用coordination.k8s.io的Lease锁定用户
+type quotaLocker struct {
+	client    client.Client
+	reader    client.Reader
+	logger    logr.Logger
+	namespace string
+	identity  string
+}
+
+func newQuotaLocker(c client.Client, reader client.Reader, logger logr.Logger, namespace string) *quotaLocker {
+	identity, _ := os.Hostname()
+	return &quotaLocker{
+		client:    c,
+		reader:    reader,
+		logger:    logger,
+		namespace: namespace,
+		identity:  identity,
+	}
+}
+
+// lock 锁定用户直到获得锁或者超时, 返回的解锁函数可以多次调用, 便于提前解锁
+func (l *quotaLocker) lock(ctx context.Context, uid string) (func(), error) {
+	ctx, cancel := context.WithTimeout(ctx, quotaLockTimeout)
+	defer cancel()
+
+	var lease *coordinationv1.Lease
+	err := wait.PollImmediateUntilWithContext(ctx, quotaLockInterval, func(ctx context.Context) (bool, error) {
+		var err error
+		lease, err = l.tryLock(ctx, uid)
+		return lease != nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var unlocked bool
+	return func() {
+		if unlocked {
+			return
+		}
+		unlocked = true
+		// 只删除自己创建的Lease, 过期后被其它副本接管的Lease不会被删除
+		err := l.client.Delete(context.Background(), lease, client.Preconditions{UID: &lease.UID})
+		if err != nil && !errors.IsNotFound(err) && !errors.IsConflict(err) {
+			l.logger.Error(err, "release quota lease", "uid", uid)
+		}
+	}, nil
+}
+
+// tryLock 尝试创建用户的Lease, 已被其它请求持有时返回nil, 已过期时删除后重试
+func (l *quotaLocker) tryLock(ctx context.Context, uid string) (*coordinationv1.Lease, error) {
+	now := metav1.NewMicroTime(time.Now())
+	seconds := int32(quotaLeaseSeconds)
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      quotaLeasePrefix + uid,
+			Namespace: l.namespace,
+		},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &l.identity,
+			LeaseDurationSeconds: &seconds,
+			AcquireTime:          &now,
+		},
+	}
+	err := l.client.Create(ctx, lease)
+	if err == nil {
+		return lease, nil
+	}
+	if !errors.IsAlreadyExists(err) {
+		return nil, err
+	}
+
+	var held coordinationv1.Lease
+	if err = l.reader.Get(ctx, client.ObjectKeyFromObject(lease), &held); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !leaseExpired(&held, now.Time) {
+		return nil, nil
+	}
+
+	l.logger.Info("take over expired quota lease", "uid", uid, "holder", held.Spec.HolderIdentity)
+	err = l.client.Delete(ctx, &held, client.Preconditions{UID: &held.UID, ResourceVersion: &held.ResourceVersion})
+	if err != nil && !errors.IsNotFound(err) && !errors.IsConflict(err) {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// leaseExpired 检查Lease是否已经超过有效期
+func leaseExpired(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.AcquireTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	expire := lease.Spec.AcquireTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+
+	return now.After(expire)
+}
+
+// lockQuota 锁定用户以检查配额, quota为空时不需要锁定, 返回的解锁函数可以多次调用
+func (s *WorkSpaceService) lockQuota(ctx context.Context, uid string, quota *pb.UserQuota) (func(), error) {
+	if quota == nil {
+		return func() {}, nil
+	}
+
+	unlock, err := s.quotas.lock(ctx, uid)
+	if err != nil {
+		s.logger.Error(err, "lock quota", "uid", uid)
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+
+	return unlock, nil
+}
+
+// quotaClassName 用户的配额类名称, Pod使用该PriorityClass后计入用户的ResourceQuota
+func quotaClassName(uid string) string {
+	return quotaClassPrefix + uid
+}
+
+// userResourceQuota 构造用户的ResourceQuota, 只统计使用用户配额类的Pod, 每个运行的Workspace有一个Pod
+func userResourceQuota(namespace, uid string, maxRunning int32) *v1.ResourceQuota {
+	return &v1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      quotaClassName(uid),
+			Namespace: namespace,
+			Labels:    map[string]string{"uid": uid},
+		},
+		Spec: v1.ResourceQuotaSpec{
+			Hard: v1.ResourceList{
+				v1.ResourcePods: *resource.NewQuantity(int64(maxRunning), resource.DecimalSI),
+			},
+			ScopeSelector: &v1.ScopeSelector{
+				MatchExpressions: []v1.ScopedResourceSelectorRequirement{
+					{
+						ScopeName: v1.ResourceQuotaScopePriorityClass,
+						Operator:  v1.ScopeSelectorOpIn,
+						Values:    []string{quotaClassName(uid)},
+					},
+				},
+			},
+		},
+	}
+}
+
+// applyUserQuota 创建用户的配额类, 并创建或更新用户的ResourceQuota, 返回Workspace的Pod需要使用的PriorityClass
+// 不限制运行数量时返回空字符串, Pod不计入用户的ResourceQuota
+func (s *WorkSpaceService) applyUserQuota(ctx context.Context, uid string, quota *pb.UserQuota) (string, error) {
+	if quota == nil || quota.MaxRunning <= 0 {
+		return "", nil
+	}
+
+	// 配额类只用于统计, 与默认优先级相同且不抢占其它Pod
+	never := v1.PreemptNever
+	class := &schedulingv1.PriorityClass{
+		ObjectMeta:       metav1.ObjectMeta{Name: quotaClassName(uid)},
+		PreemptionPolicy: &never,
+		Description:      "cloud-ide quota class of user " + uid,
+	}
+	if err := s.client.Create(ctx, class); err != nil && !errors.IsAlreadyExists(err) {
+		s.logger.Error(err, "create quota class", "uid", uid)
+		return "", status.Error(codes.Unknown, err.Error())
+	}
+
+	desired := userResourceQuota(s.namespace, uid, quota.MaxRunning)
+	err := s.client.Create(ctx, desired)
+	if errors.IsAlreadyExists(err) {
+		// 用户的套餐可能已经改变, 更新为最新的配额
+		err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			var rq v1.ResourceQuota
+			if err := s.client.Get(ctx, client.ObjectKeyFromObject(desired), &rq); err != nil {
+				return err
+			}
+			if rq.Spec.Hard.Pods().Equal(*desired.Spec.Hard.Pods()) && rq.Spec.ScopeSelector != nil {
+				return nil
+			}
+			rq.Spec = desired.Spec
+			return s.client.Update(ctx, &rq)
+		})
+	}
+	if err != nil {
+		s.logger.Error(err, "apply resource quota", "uid", uid)
+		return "", status.Error(codes.Unknown, err.Error())
+	}
+
+	return class.Name, nil
+}
+
+// checkQuota 检查用户创建或启动sid对应的Workspace后是否会超过配额, quota为空时不检查
+// 需要先通过lockQuota锁定用户, 用户的Workspace直接从API Server读取, 不使用可能过时的缓存
+func (s *WorkSpaceService) checkQuota(ctx context.Context, uid, sid, storage string, quota *pb.UserQuota) error {
+	if quota == nil {
+		return nil
+	}
+
+	var wss mv1.WorkSpaceList
+	err := s.reader.List(ctx, &wss, client.InNamespace(s.namespace), client.MatchingLabels{"uid": uid})
+	if err != nil {
+		s.logger.Error(err, "list workspace", "uid", uid)
+		return status.Error(codes.Unknown, err.Error())
+	}
+
+	if msg := exceedQuota(wss.Items, sid, storage, quota); msg != "" {
+		s.logger.Info("workspace quota exceeded", "uid", uid, "sid", sid, "reason", msg)
+		return status.Error(codes.FailedPrecondition, WorkspaceQuotaExceeded+": "+msg)
+	}
+
+	return nil
+}
+
+// exceedQuota 统计用户其它Workspace的运行数量和存储总量, 超过配额时返回原因
+func exceedQuota(items []mv1.WorkSpace, sid, storage string, quota *pb.UserQuota) string {
+	var (
+		running int32
+		used    resource.Quantity
+	)
+	for i := range items {
+		ws := &items[i]
+		if ws.Spec.SID == sid {
+			continue
+		}
+		if ws.Spec.Command == mv1.WorkSpaceStart && ws.Status.Phase != mv1.WorkspacePhaseFailed {
+			running++
+		}
+		if q, err := resource.ParseQuantity(ws.Spec.Storage); err == nil {
+			used.Add(q)
+		}
+	}
+
+	if quota.MaxRunning > 0 && running >= quota.MaxRunning {
+		return fmt.Sprintf("at most %d workspaces can run at the same time", quota.MaxRunning)
+	}
+
+	if quota.MaxStorage == "" {
+		return ""
+	}
+	max, err := resource.ParseQuantity(quota.MaxStorage)
+	if err != nil {
+		return ""
+	}
+	if q, err := resource.ParseQuantity(storage); err == nil {
+		used.Add(q)
+	}
+	if used.Cmp(max) > 0 {
+		return fmt.Sprintf("total storage %s exceeds %s", used.String(), max.String())
+	}
+
+	return ""
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/mangohow/cloud-ide/pkg/pb"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	v1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestLocker(objs ...client.Object) *quotaLocker {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+
+	return newQuotaLocker(c, c, logr.Discard(), "ns")
+}
+
+func TestQuotaLockerExclusive(t *testing.T) {
+	l := newTestLocker()
+	unlock, err := l.lock(context.Background(), "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 其它用户不受影响
+	unlockOther, err := l.lock(context.Background(), "u2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlockOther()
+
+	// 同一个用户在释放之前无法获得锁
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	if _, err = l.lock(ctx, "u1"); err == nil {
+		t.Fatal("lock held by another request should not be acquired")
+	}
+
+	unlock()
+	unlock()
+	unlock, err = l.lock(context.Background(), "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+}
+
+func TestQuotaLockerTakeOverExpired(t *testing.T) {
+	acquire := metav1.NewMicroTime(time.Now().Add(-time.Minute))
+	seconds := int32(quotaLeaseSeconds)
+	holder := "crashed"
+	l := newTestLocker(&coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: quotaLeasePrefix + "u1", Namespace: "ns"},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &holder,
+			LeaseDurationSeconds: &seconds,
+			AcquireTime:          &acquire,
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	unlock, err := l.lock(ctx, "u1")
+	if err != nil {
+		t.Fatalf("expired lease should be taken over: %v", err)
+	}
+	unlock()
+}
+
+func TestApplyUserQuota(t *testing.T) {
+	svc := NewWorkSpaceService(newTestLocker().client, nil, logr.Discard(), nil, nil, "ns")
+	ctx := context.Background()
+
+	// 不限制运行数量时不使用配额类
+	if class, err := svc.applyUserQuota(ctx, "u1", nil); err != nil || class != "" {
+		t.Fatalf("nil quota: class %q, err %v", class, err)
+	}
+	if class, err := svc.applyUserQuota(ctx, "u1", &pb.UserQuota{MaxStorage: "10Gi"}); err != nil || class != "" {
+		t.Fatalf("no running limit: class %q, err %v", class, err)
+	}
+
+	// 套餐改变后更新用户的ResourceQuota
+	for _, maxRunning := range []int32{1, 3} {
+		class, err := svc.applyUserQuota(ctx, "u1", &pb.UserQuota{MaxRunning: maxRunning})
+		if err != nil || class != quotaClassName("u1") {
+			t.Fatalf("class %q, err %v", class, err)
+		}
+
+		var pc schedulingv1.PriorityClass
+		if err = svc.client.Get(ctx, client.ObjectKey{Name: class}, &pc); err != nil {
+			t.Fatal(err)
+		}
+		if pc.Value != 0 || pc.PreemptionPolicy == nil || *pc.PreemptionPolicy != v1.PreemptNever {
+			t.Errorf("unexpected priority class %+v", pc)
+		}
+
+		var rq v1.ResourceQuota
+		if err = svc.client.Get(ctx, client.ObjectKey{Name: class, Namespace: "ns"}, &rq); err != nil {
+			t.Fatal(err)
+		}
+		if got := rq.Spec.Hard.Pods().Value(); got != int64(maxRunning) {
+			t.Errorf("hard pods = %d, want %d", got, maxRunning)
+		}
+		scope := rq.Spec.ScopeSelector
+		if scope == nil || len(scope.MatchExpressions) != 1 || scope.MatchExpressions[0].ScopeName != v1.ResourceQuotaScopePriorityClass ||
+			len(scope.MatchExpressions[0].Values) != 1 || scope.MatchExpressions[0].Values[0] != class {
+			t.Errorf("unexpected scope selector %+v", scope)
+		}
+	}
+}
@@ -0,0 +1,445 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	mv1 "github.com/mangohow/cloud-ide/cmd/control-plane/internal/api/v1"
+	"github.com/mangohow/cloud-ide/cmd/control-plane/internal/controllers"
+	"github.com/mangohow/cloud-ide/pkg/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshotclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+
+const (
+	SnapshotNotExist       = "snapshot not exist"
+	SnapshotNotReady       = "snapshot is not ready"
+	SnapshotNotSupported   = "snapshot is not supported by the storage"
+	SnapshotCreateFailed   = "create snapshot error"
+	SnapshotRestoreFailed  = "restore snapshot error"
+	WorkspaceIsRunning     = "workspace is running"
+	snapshotApp            = "cloud-ide-snapshot"
+	snapshotURLAnnotation  = "cloud-ide.mangohow.com/snapshot-url"
+	snapshotVolumeName     = "volume-user-workspace"
+	snapshotArchiverName   = "snapshot-archiver"
+	snapshotArchiveDataDir = "/data"
+)
+
+var (
+	volumeSnapshotGVK          = schema.GroupVersionKind{Group: controllers.VolumeSnapshotGroup, Version: "v1", Kind: controllers.VolumeSnapshotKind}
+	volumeSnapshotListGVK      = schema.GroupVersionKind{Group: controllers.VolumeSnapshotGroup, Version: "v1", Kind: "VolumeSnapshotList"}
+	volumeSnapshotClassListGVK = schema.GroupVersionKind{Group: controllers.VolumeSnapshotGroup, Version: "v1", Kind: "VolumeSnapshotClassList"}
+)
+
+// SnapshotSpace 为Workspace的存储卷创建快照
+// 存储类支持时使用VolumeSnapshot, 否则通过Job将存储卷打包上传到对象存储, 此时需要先停止Workspace
+func (s *WorkSpaceService) SnapshotSpace(ctx context.Context, req *pb.RequestSnapshot) (*pb.ResponseSnapshot, error) {
+	res := &pb.ResponseSnapshot{}
+
+	// 1.查询Workspace和PVC
+	var ws mv1.WorkSpace
+	key := client.ObjectKey{Name: workspaceName(req.Uid, req.Sid), Namespace: s.namespace}
+	if !s.checkWorkspaceExist(ctx, key, &ws) {
+		res.Status = pb.ResponseSnapshot_NotFound
+		res.Message = WorkspaceNotExist
+		return res, status.Error(codes.NotFound, WorkspaceNotExist)
+	}
+
+	var pvc v1.PersistentVolumeClaim
+	if err := s.client.Get(ctx, key, &pvc); err != nil {
+		if errors.IsNotFound(err) {
+			res.Status = pb.ResponseSnapshot_NotFound
+			res.Message = WorkspaceNotExist
+			return res, status.Error(codes.NotFound, WorkspaceNotExist)
+		}
+
+		s.logger.Error(err, "get pvc")
+		res.Status = pb.ResponseSnapshot_Error
+		res.Message = SnapshotCreateFailed
+		return res, status.Error(codes.Unknown, err.Error())
+	}
+
+	// 2.根据存储类选择快照方式
+	class, err := s.volumeSnapshotClass(ctx, &pvc)
+	if err != nil {
+		s.logger.Error(err, "get volume snapshot class")
+		res.Status = pb.ResponseSnapshot_Error
+		res.Message = SnapshotCreateFailed
+		return res, status.Error(codes.Unknown, err.Error())
+	}
+
+	name := snapshotName(&ws)
+	if class != "" {
+		res.Snapshot, err = s.createVolumeSnapshot(ctx, &ws, name, class)
+	} else {
+		if controllers.SnapshotArchiveURL == "" {
+			res.Status = pb.ResponseSnapshot_Error
+			res.Message = SnapshotNotSupported
+			return res, status.Error(codes.FailedPrecondition, SnapshotNotSupported)
+		}
+		// 打包时存储卷不能被使用
+		if workspaceActive(&ws) {
+			res.Status = pb.ResponseSnapshot_IsRunning
+			res.Message = WorkspaceIsRunning
+			return res, status.Error(codes.FailedPrecondition, WorkspaceIsRunning)
+		}
+		res.Snapshot, err = s.createArchiveJob(ctx, &ws, name)
+	}
+
+	if err != nil {
+		s.logger.Error(err, "create snapshot", "sid", req.Sid)
+		res.Status = pb.ResponseSnapshot_Error
+		res.Message = SnapshotCreateFailed
+		return res, status.Error(codes.Unknown, err.Error())
+	}
+
+	return res, nil
+}
+
+// ListSnapshots 获取Workspace的所有快照, 按创建时间倒序
+func (s *WorkSpaceService) ListSnapshots(ctx context.Context, req *pb.RequestListSnapshots) (*pb.ResponseListSnapshots, error) {
+	snapshots, err := s.listSnapshots(ctx, req.Uid, req.Sid)
+	if err != nil {
+		s.logger.Error(err, "list snapshots", "sid", req.Sid)
+		return &pb.ResponseListSnapshots{}, status.Error(codes.Unknown, err.Error())
+	}
+
+	return &pb.ResponseListSnapshots{Snapshots: snapshots}, nil
+}
+
+// RestoreSpace 将已停止的Workspace恢复到指定快照
+// 记录要恢复的快照并删除PVC, 下次启动时controller会从快照创建新的PVC
+func (s *WorkSpaceService) RestoreSpace(ctx context.Context, req *pb.RequestRestore) (*pb.ResponseRestore, error) {
+	res := &pb.ResponseRestore{}
+
+	// 1.Workspace必须存在并且已经停止
+	var ws mv1.WorkSpace
+	key := client.ObjectKey{Name: workspaceName(req.Uid, req.Sid), Namespace: s.namespace}
+	if !s.checkWorkspaceExist(ctx, key, &ws) {
+		res.Status = pb.ResponseRestore_NotFound
+		res.Message = WorkspaceNotExist
+		return res, status.Error(codes.NotFound, WorkspaceNotExist)
+	}
+	if workspaceActive(&ws) {
+		res.Status = pb.ResponseRestore_IsRunning
+		res.Message = WorkspaceIsRunning
+		return res, status.Error(codes.FailedPrecondition, WorkspaceIsRunning)
+	}
+
+	// 2.快照必须存在并且可用
+	snapshots, err := s.listSnapshots(ctx, req.Uid, req.Sid)
+	if err != nil {
+		s.logger.Error(err, "list snapshots", "sid", req.Sid)
+		res.Status = pb.ResponseRestore_Error
+		res.Message = SnapshotRestoreFailed
+		return res, status.Error(codes.Unknown, err.Error())
+	}
+	var snapshot *pb.Snapshot
+	for _, item := range snapshots {
+		if item.Name == req.Snapshot {
+			snapshot = item
+			break
+		}
+	}
+	if snapshot == nil {
+		res.Status = pb.ResponseRestore_NotFound
+		res.Message = SnapshotNotExist
+		return res, status.Error(codes.NotFound, SnapshotNotExist)
+	}
+	if snapshot.Phase != pb.Snapshot_Ready {
+		res.Status = pb.ResponseRestore_NotReady
+		res.Message = SnapshotNotReady
+		return res, status.Error(codes.FailedPrecondition, SnapshotNotReady)
+	}
+
+	// 3.记录要恢复的快照
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var wp mv1.WorkSpace
+		if err := s.client.Get(ctx, key, &wp); err != nil {
+			return err
+		}
+
+		wp.Spec.Restore = &mv1.WorkSpaceRestore{
+			Snapshot: snapshot.Name,
+			Method:   snapshot.Method,
+			URL:      snapshot.Url,
+		}
+		return s.client.Update(ctx, &wp)
+	})
+	if err != nil {
+		s.logger.Error(err, "update workspace")
+		res.Status = pb.ResponseRestore_Error
+		res.Message = SnapshotRestoreFailed
+		return res, status.Error(codes.Unknown, err.Error())
+	}
+
+	// 4.删除PVC并等待删除完成, 防止下次启动时使用正在删除的PVC
+	if err := s.deletePVC(ctx, key); err != nil {
+		s.logger.Error(err, "delete pvc")
+		res.Status = pb.ResponseRestore_Error
+		res.Message = SnapshotRestoreFailed
+		return res, status.Error(codes.Unknown, err.Error())
+	}
+
+	return res, nil
+}
+
+func (s *WorkSpaceService) deletePVC(ctx context.Context, key client.ObjectKey) error {
+	pvc := &v1.PersistentVolumeClaim{}
+	pvc.Name = key.Name
+	pvc.Namespace = key.Namespace
+	if err := s.client.Delete(ctx, pvc); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	return wait.PollImmediate(time.Millisecond*500, time.Second*30, func() (bool, error) {
+		err := s.client.Get(ctx, key, pvc)
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+
+		return false, err
+	})
+}
+
+// volumeSnapshotClass 获取PVC的存储类对应的VolumeSnapshotClass, 不支持时返回空
+func (s *WorkSpaceService) volumeSnapshotClass(ctx context.Context, pvc *v1.PersistentVolumeClaim) (string, error) {
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return "", nil
+	}
+
+	var sc storagev1.StorageClass
+	if err := s.client.Get(ctx, client.ObjectKey{Name: *pvc.Spec.StorageClassName}, &sc); err != nil {
+		if errors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	var classes unstructured.UnstructuredList
+	classes.SetGroupVersionKind(volumeSnapshotClassListGVK)
+	if err := s.client.List(ctx, &classes); err != nil {
+		// 集群中没有安装VolumeSnapshot的CRD
+		if meta.IsNoMatchError(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	// 同一个driver有多个时优先使用默认的
+	class := ""
+	for _, item := range classes.Items {
+		driver, _, _ := unstructured.NestedString(item.Object, "driver")
+		if driver != sc.Provisioner {
+			continue
+		}
+		if item.GetAnnotations()["snapshot.storage.kubernetes.io/is-default-class"] == "true" {
+			return item.GetName(), nil
+		}
+		if class == "" {
+			class = item.GetName()
+		}
+	}
+
+	return class, nil
+}
+
+func (s *WorkSpaceService) createVolumeSnapshot(ctx context.Context, ws *mv1.WorkSpace, name, class string) (*pb.Snapshot, error) {
+	vs := &unstructured.Unstructured{}
+	vs.SetGroupVersionKind(volumeSnapshotGVK)
+	vs.SetName(name)
+	vs.SetNamespace(ws.Namespace)
+	vs.SetLabels(snapshotLabels(ws))
+	if err := unstructured.SetNestedField(vs.Object, class, "spec", "volumeSnapshotClassName"); err != nil {
+		return nil, err
+	}
+	if err := unstructured.SetNestedField(vs.Object, ws.Name, "spec", "source", "persistentVolumeClaimName"); err != nil {
+		return nil, err
+	}
+	// Workspace被删除时快照也会被删除
+	if err := controllerutil.SetOwnerReference(ws, vs, s.client.Scheme()); err != nil {
+		return nil, err
+	}
+
+	if err := s.client.Create(ctx, vs); err != nil {
+		return nil, err
+	}
+
+	return &pb.Snapshot{
+		Name:       name,
+		Sid:        ws.Spec.SID,
+		Method:     mv1.SnapshotMethodVolumeSnapshot,
+		Phase:      pb.Snapshot_Pending,
+		CreateTime: time.Now().UnixMilli(),
+	}, nil
+}
+
+func (s *WorkSpaceService) createArchiveJob(ctx context.Context, ws *mv1.WorkSpace, name string) (*pb.Snapshot, error) {
+	url := fmt.Sprintf("%s/%s/%s.tar.gz", strings.TrimSuffix(controllers.SnapshotArchiveURL, "/"), ws.Spec.UID, name)
+	labels := snapshotLabels(ws)
+	backoffLimit := int32(2)
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   ws.Namespace,
+			Labels:      labels,
+			Annotations: map[string]string{snapshotURLAnnotation: url},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyNever,
+					Volumes: []v1.Volume{
+						{
+							Name: snapshotVolumeName,
+							VolumeSource: v1.VolumeSource{
+								PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+									ClaimName: ws.Name,
+									ReadOnly:  true,
+								},
+							},
+						},
+					},
+					Containers: []v1.Container{
+						controllers.ArchiveContainer(snapshotArchiverName, snapshotVolumeName, snapshotArchiveDataDir, url, false),
+					},
+				},
+			},
+		},
+	}
+	if err := controllerutil.SetOwnerReference(ws, job, s.client.Scheme()); err != nil {
+		return nil, err
+	}
+
+	if err := s.client.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	return &pb.Snapshot{
+		Name:       name,
+		Sid:        ws.Spec.SID,
+		Method:     mv1.SnapshotMethodArchive,
+		Phase:      pb.Snapshot_Pending,
+		Url:        url,
+		CreateTime: time.Now().UnixMilli(),
+	}, nil
+}
+
+func (s *WorkSpaceService) listSnapshots(ctx context.Context, uid, sid string) ([]*pb.Snapshot, error) {
+	var (
+		snapshots []*pb.Snapshot
+		selector  = client.MatchingLabels{"app": snapshotApp, "uid": uid, "sid": sid}
+	)
+
+	// 1.VolumeSnapshot, 集群中可能没有安装CRD
+	var vss unstructured.UnstructuredList
+	vss.SetGroupVersionKind(volumeSnapshotListGVK)
+	err := s.client.List(ctx, &vss, client.InNamespace(s.namespace), selector)
+	if err != nil && !meta.IsNoMatchError(err) {
+		return nil, err
+	}
+	for i := range vss.Items {
+		snapshots = append(snapshots, snapshotFromVolumeSnapshot(&vss.Items[i], sid))
+	}
+
+	// 2.打包上传到对象存储的Job
+	var jobs batchv1.JobList
+	if err := s.client.List(ctx, &jobs, client.InNamespace(s.namespace), selector); err != nil {
+		return nil, err
+	}
+	for i := range jobs.Items {
+		snapshots = append(snapshots, snapshotFromJob(&jobs.Items[i], sid))
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreateTime > snapshots[j].CreateTime
+	})
+
+	return snapshots, nil
+}
+
+func snapshotFromVolumeSnapshot(vs *unstructured.Unstructured, sid string) *pb.Snapshot {
+	snapshot := &pb.Snapshot{
+		Name:       vs.GetName(),
+		Sid:        sid,
+		Method:     mv1.SnapshotMethodVolumeSnapshot,
+		Phase:      pb.Snapshot_Pending,
+		CreateTime: vs.GetCreationTimestamp().UnixMilli(),
+	}
+
+	if msg, _, _ := unstructured.NestedString(vs.Object, "status", "error", "message"); msg != "" {
+		snapshot.Phase = pb.Snapshot_Failed
+		snapshot.Message = msg
+	} else if ready, _, _ := unstructured.NestedBool(vs.Object, "status", "readyToUse"); ready {
+		snapshot.Phase = pb.Snapshot_Ready
+	}
+
+	return snapshot
+}
+
+func snapshotFromJob(job *batchv1.Job, sid string) *pb.Snapshot {
+	snapshot := &pb.Snapshot{
+		Name:       job.Name,
+		Sid:        sid,
+		Method:     mv1.SnapshotMethodArchive,
+		Phase:      pb.Snapshot_Pending,
+		Url:        job.Annotations[snapshotURLAnnotation],
+		CreateTime: job.CreationTimestamp.UnixMilli(),
+	}
+
+	if job.Status.Succeeded > 0 {
+		snapshot.Phase = pb.Snapshot_Ready
+		return snapshot
+	}
+	for _, cond := range job.Status.Conditions {
+		if cond.Type == batchv1.JobFailed && cond.Status == v1.ConditionTrue {
+			snapshot.Phase = pb.Snapshot_Failed
+			snapshot.Message = cond.Message
+			break
+		}
+	}
+
+	return snapshot
+}
+
+func snapshotLabels(ws *mv1.WorkSpace) map[string]string {
+	return map[string]string{
+		"app": snapshotApp,
+		"uid": ws.Spec.UID,
+		"sid": ws.Spec.SID,
+	}
+}
+
+// snapshotName 生成快照名称, 同时作为Job名称, 需要满足标签值的长度限制
+func snapshotName(ws *mv1.WorkSpace) string {
+	return fmt.Sprintf("snap-%s-%s", ws.Spec.SID, strconv.FormatInt(time.Now().Unix(), 36))
+}
+
+// workspaceActive Workspace是否正在使用存储卷
+func workspaceActive(ws *mv1.WorkSpace) bool {
+	return ws.Spec.Command == mv1.WorkSpaceStart || ws.Status.Phase == mv1.WorkspacePhaseStopping
+}
@@ -9,31 +9,41 @@ import (
 
 	"github.com/go-logr/logr"
 	mv1 "github.com/mangohow/cloud-ide/cmd/control-plane/internal/api/v1"
+	"github.com/mangohow/cloud-ide/cmd/control-plane/internal/controllers"
 	"github.com/mangohow/cloud-ide/pkg/notifier"
 	"github.com/mangohow/cloud-ide/pkg/pb"
 	"google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
 type WorkSpaceService struct {
 	pb.UnimplementedCloudIdeServiceServer
 	logger    logr.Logger
 	client    client.Client
+	// 不经过缓存直接读取API Server, 用于检查配额
+	reader    client.Reader
 	waiter    notifier.Waiter
+	watcher   *WorkspaceWatcher
 	namespace string
+	quotas    *quotaLocker
 }
 
-func NewWorkSpaceService(c client.Client, logger logr.Logger, waiter notifier.Waiter, namespace string) *WorkSpaceService {
+func NewWorkSpaceService(c client.Client, reader client.Reader, logger logr.Logger, waiter notifier.Waiter, watcher *WorkspaceWatcher, namespace string) *WorkSpaceService {
 	return &WorkSpaceService{
 		logger:    logger,
 		client:    c,
+		reader:    reader,
 		waiter:    waiter,
+		watcher:   watcher,
 		namespace: namespace,
+		quotas:    newQuotaLocker(c, reader, logger, namespace),
 	}
 }
 
@@ -46,10 +56,21 @@ const (
 	WorkspaceStartFailed  = "start workspace error"
 	WorkspaceStopFailed   = "stop workspace error"
 	WorkspaceDeleteFailed = "delete workspace error"
+	WorkspaceResizeFailed = "resize workspace error"
+
+	WorkspaceShrinkNotAllowed = "workspace storage cannot be shrunk"
+	WorkspaceQuotaExceeded    = "workspace quota exceeded"
 )
 
 const WorkspaceNameFormat = "ws-%s-%s"
 
+var (
+	// 环境变量名称必须是C_IDENTIFIER
+	envNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// 初始化步骤的名称会作为容器名称的一部分, 必须是DNS label
+	initStepNameRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+)
+
 // CreateSpace 创建并且启动Workspace,将Operation字段置为"Start",当Workspace被创建时,PVC和Pod也会被创建
 // 该接口仅被用于第一次创建工作空间并且启动
 func (s *WorkSpaceService) CreateSpace(ctx context.Context, info *pb.RequestCreate) (*pb.ResponseCreate, error) {
@@ -76,8 +97,45 @@ func (s *WorkSpaceService) CreateSpace(ctx context.Context, info *pb.RequestCrea
 		return res, stus.Err()
 	}
 
-	// 2.如果不存在就创建
+	// 检查用户的配额, 直到Workspace创建完成之前不允许该用户的其它请求通过检查
+	unlock, err := s.lockQuota(ctx, info.Uid, info.Quota)
+	if err != nil {
+		res.Status = pb.ResponseCreate_Error
+		res.Message = WorkspaceCreateFailed
+		return res, err
+	}
+	defer unlock()
+	if err := s.checkQuota(ctx, info.Uid, info.Sid, info.ResourceLimit.Storage, info.Quota); err != nil {
+		res.Status = pb.ResponseCreate_Error
+		res.Message = WorkspaceQuotaExceeded
+		return res, err
+	}
+	quotaClass, err := s.applyUserQuota(ctx, info.Uid, info.Quota)
+	if err != nil {
+		res.Status = pb.ResponseCreate_Error
+		res.Message = WorkspaceCreateFailed
+		return res, err
+	}
+
+	// 2.敏感的环境变量保存在Secret中, 需要在Pod创建之前创建
+	var secret *v1.Secret
+	if len(info.SecretEnvVars) > 0 {
+		secret = controllers.EnvSecret(name, s.namespace, info.SecretEnvVars)
+		if err := s.applySecret(ctx, secret); err != nil {
+			s.logger.Error(err, "create secret")
+			res.Status = pb.ResponseCreate_Error
+			res.Message = WorkspaceCreateFailed
+			return res, status.Error(codes.Unknown, err.Error())
+		}
+	}
+
+	// 3.如果不存在就创建, 同名的工作空间之前的状态不再有效
 	w := s.constructWorkspace(info, name)
+	w.Spec.PriorityClassName = quotaClass
+	s.waiter.Reset(info.Sid)
+	if secret != nil {
+		w.Spec.EnvFrom = []v1.EnvFromSource{controllers.SecretEnvFrom(secret.Name)}
+	}
 	if err := s.client.Create(ctx, w); err != nil {
 		if errors.IsAlreadyExists(err) {
 			res.Status = pb.ResponseCreate_AlreadyExist
@@ -86,12 +144,27 @@ func (s *WorkSpaceService) CreateSpace(ctx context.Context, info *pb.RequestCrea
 		}
 
 		s.logger.Error(err, "create workspace")
+		if secret != nil {
+			s.client.Delete(context.Background(), secret)
+		}
 		res.Status = pb.ResponseCreate_Error
 		res.Message = WorkspaceCreateFailed
 		return res, status.Error(codes.Unknown, err.Error())
 	}
 
-	// 3.等待Pod处于Running状态
+	// Secret随Workspace一起被删除
+	if secret != nil {
+		if err := s.ownSecret(ctx, w, secret); err != nil {
+			s.logger.Error(err, "set secret owner", "secret", secret.Name)
+		}
+	}
+
+	// 4.等待Pod处于Running状态, 异步请求在后台等待, 启动进度通过WatchSpace获取
+	unlock()
+	if info.Async {
+		go s.waitForPodRunning(context.Background(), client.ObjectKey{Name: w.Name, Namespace: w.Namespace}, w)
+		return res, nil
+	}
 	err = s.waitForPodRunning(ctx, client.ObjectKey{Name: w.Name, Namespace: w.Namespace}, w)
 	if err != nil {
 		s.logger.Error(err, "wait for pod running")
@@ -116,12 +189,12 @@ func (s *WorkSpaceService) waitForPodRunning(ctx context.Context, key client.Obj
 
 	s.logger.Error(err, "wait for pod ready")
 	// 2.处理错误情况,停止工作空间
-	_, err = s.StopSpace(ctx, &pb.RequestStop{
+	_, stopErr := s.StopSpace(ctx, &pb.RequestStop{
 		Uid: ws.Spec.UID,
 		Sid: ws.Spec.SID,
 	})
-	if err != nil {
-		s.logger.Error(err, "stop workspace")
+	if stopErr != nil {
+		s.logger.Error(stopErr, "stop workspace")
 	}
 
 	return err
@@ -133,6 +206,14 @@ func (s *WorkSpaceService) StartSpace(ctx context.Context, req *pb.RequestStart)
 		s.logger.Error(err, "request param invalid")
 		return &pb.ResponseStart{}, err
 	}
+	if err := validateEnvNames(req.SecretEnvVars); err != nil {
+		s.logger.Error(err, "request param invalid")
+		return &pb.ResponseStart{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := validateLaunch(req.Launch); err != nil {
+		s.logger.Error(err, "request param invalid")
+		return &pb.ResponseStart{}, status.Error(codes.InvalidArgument, err.Error())
+	}
 
 	res := &pb.ResponseStart{}
 
@@ -150,30 +231,85 @@ func (s *WorkSpaceService) StartSpace(ctx context.Context, req *pb.RequestStart)
 	}
 
 	// 2.判断workspace是否处于运行或启动中状态
-	if ws.Status.Phase == mv1.WorkspacePhaseStaring || ws.Status.Phase == mv1.WorkspacePhaseRunning {
+	if ws.Status.Phase == mv1.WorkspacePhaseStaring || ws.Status.Phase == mv1.WorkspacePhaseRunning ||
+		ws.Status.Phase == mv1.WorkspacePhaseDegraded {
 		return res, nil
 	}
 
-	// 3.Pod的配置可能会改变
-	ws.Spec.Cpu = req.ResourceLimit.Cpu
-	ws.Spec.Memory = req.ResourceLimit.Memory
-	// TODO storage改变需要特殊处理
-	// ws.Spec.Storage = req.ResourceLimit.Storage
+	// 3.Pod的配置可能会改变, 更新时应用到最新版本的Workspace上
+	applySpec := func(w *mv1.WorkSpace) error {
+		w.Spec.Cpu = req.ResourceLimit.Cpu
+		w.Spec.Memory = req.ResourceLimit.Memory
+		w.Spec.IdleTimeoutSeconds = req.IdleTimeout
+		// 模板的启动配置可能会改变, 未传递时保留原有配置
+		if req.Launch != nil {
+			w.Spec.Launch = launchFromPb(req.Launch)
+		}
+		// 每次启动时重新设置剩余的运行时长
+		w.Spec.MaxRuntimeSeconds = req.MaxRuntime
+		if len(req.SecretEnvVars) > 0 {
+			w.Spec.EnvFrom = withSecretEnvFrom(w.Spec.EnvFrom, controllers.EnvSecretName(w.Name))
+		}
+		// 存储卷只能扩容, 由controller在线扩容PVC
+		return applyStorage(w, req.ResourceLimit.Storage)
+	}
+	if err := applySpec(&ws); err != nil {
+		res.Status = pb.ResponseStart_ShrinkNotAllowed
+		res.Message = WorkspaceShrinkNotAllowed
+		return res, status.Error(codes.InvalidArgument, WorkspaceShrinkNotAllowed)
+	}
+
+	// 检查用户的配额, 直到Workspace更新完成之前不允许该用户的其它请求通过检查
+	unlock, err := s.lockQuota(ctx, req.Uid, req.Quota)
+	if err != nil {
+		res.Status = pb.ResponseStart_Error
+		res.Message = WorkspaceStartFailed
+		return res, err
+	}
+	defer unlock()
+	if err := s.checkQuota(ctx, req.Uid, req.Sid, ws.Spec.Storage, req.Quota); err != nil {
+		res.Status = pb.ResponseStart_Error
+		res.Message = WorkspaceQuotaExceeded
+		return res, err
+	}
+	quotaClass, err := s.applyUserQuota(ctx, req.Uid, req.Quota)
+	if err != nil {
+		res.Status = pb.ResponseStart_Error
+		res.Message = WorkspaceStartFailed
+		return res, err
+	}
+
+	// 敏感的环境变量合并到Workspace的Secret中
+	if len(req.SecretEnvVars) > 0 {
+		if err := s.mergeSecret(ctx, &ws, req.SecretEnvVars); err != nil {
+			s.logger.Error(err, "merge secret")
+			res.Status = pb.ResponseStart_Error
+			res.Message = WorkspaceStartFailed
+			return res, status.Error(codes.Unknown, err.Error())
+		}
+	}
 
 	// 4.更新Workspace的Operation字段以启动,使用RetryOnConflict,当资源版本冲突时重试
-	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		// 每次更新前要获取最新的版本
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		// 每次更新前要获取最新的版本, 等待配额检查期间Workspace可能已经被修改
 		var p mv1.WorkSpace
-		exist := s.checkWorkspaceExist(ctx, key, &p)
+		exist = s.checkWorkspaceExist(ctx, key, &p)
 		if !exist {
 			return nil
 		}
+		if err := applySpec(&p); err != nil {
+			return err
+		}
 
-		// 更新workspace的Operation字段
-		ws.Spec.Command = mv1.WorkSpaceStart
-		if err := s.client.Update(ctx, &ws); err != nil {
+		// 套餐可能已经改变, 每次启动时使用最新的配额类
+		p.Spec.PriorityClassName = quotaClass
+		// 更新workspace的Operation字段, 上一次运行的状态不再有效
+		p.Spec.Command = mv1.WorkSpaceStart
+		s.waiter.Reset(req.Sid)
+		if err := s.client.Update(ctx, &p); err != nil {
 			return err
 		}
+		ws = p
 
 		return nil
 	})
@@ -191,6 +327,11 @@ func (s *WorkSpaceService) StartSpace(ctx context.Context, req *pb.RequestStart)
 		return res, status.Error(codes.NotFound, WorkspaceNotExist)
 	}
 
+	unlock()
+	if req.Async {
+		go s.waitForPodRunning(context.Background(), key, &ws)
+		return res, nil
+	}
 	err = s.waitForPodRunning(ctx, key, &ws)
 	if err != nil {
 		s.logger.Error(err, "wait for pod running")
@@ -243,12 +384,27 @@ func (s *WorkSpaceService) StopSpace(ctx context.Context, req *pb.RequestStop) (
 		return res, nil
 	}
 
-	// 3.更新Operation字段以停止Workspace
+	// 3.主动停止时先记录停止原因, 再停止Workspace
+	key := client.ObjectKey{Name: name, Namespace: s.namespace}
+	if req.Reason != "" {
+		err = s.updateStatus(ctx, key, func(status *mv1.WorkSpaceStatus) {
+			status.StopReason = req.Reason
+			status.StopMessage = req.Message
+		})
+		if err != nil {
+			s.logger.Error(err, "record stop reason", "sid", req.Sid)
+			res.Status = pb.ResponseStop_Error
+			res.Message = WorkspaceStopFailed
+			return res, status.Error(codes.Unknown, err.Error())
+		}
+	}
+
+	// 4.更新Operation字段以停止Workspace
 	// 使用Update时,可能由于版本冲突而导致失败,需要重试
 	exist := true
 	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		var wp mv1.WorkSpace
-		exist = s.checkWorkspaceExist(ctx, client.ObjectKey{Name: name, Namespace: s.namespace}, &wp)
+		exist = s.checkWorkspaceExist(ctx, key, &wp)
 		if !exist {
 			return nil
 		}
@@ -278,11 +434,16 @@ func (s *WorkSpaceService) StopSpace(ctx context.Context, req *pb.RequestStop) (
 	return res, nil
 }
 
-// RunningWorkspaces 获取运行中的Workspace
+// RunningWorkspaces 获取运行中的Workspace, 以及已停止的Workspace的停止时间和原因
 func (s *WorkSpaceService) RunningWorkspaces(ctx context.Context, req *pb.RequestRunningWorkspaces) (*pb.ResponseRunningWorkspace, error) {
 	res := &pb.ResponseRunningWorkspace{}
+	// uid为空时查询所有用户的Workspace
+	opts := []client.ListOption{client.InNamespace(s.namespace)}
+	if req.Uid != "" {
+		opts = append(opts, client.MatchingLabels{"uid": req.Uid})
+	}
 	var wss mv1.WorkSpaceList
-	err := s.client.List(ctx, &wss, client.MatchingLabels{"uid": req.Uid})
+	err := s.client.List(ctx, &wss, opts...)
 	if err != nil {
 		s.logger.Error(err, "list workspace")
 		return res, status.Error(codes.Unknown, err.Error())
@@ -290,10 +451,45 @@ func (s *WorkSpaceService) RunningWorkspaces(ctx context.Context, req *pb.Reques
 
 	// 过滤出正在运行中的Workspace
 	for _, item := range wss.Items {
-		if item.Status.Phase == mv1.WorkspacePhaseStaring || item.Status.Phase == mv1.WorkspacePhaseRunning {
+		if item.Status.Phase == mv1.WorkspacePhaseStaring || item.Status.Phase == mv1.WorkspacePhaseRunning ||
+			item.Status.Phase == mv1.WorkspacePhaseDegraded {
 			res.Workspaces = append(res.Workspaces, &pb.ResponseRunningWorkspace_WorkspaceBasicInfo{
-				Sid:  item.Spec.SID,
-				Name: "", // 不返回Kubernetes内部名称，让webserver使用数据库中的友好名称
+				Sid:       item.Spec.SID,
+				Name:      "", // 不返回Kubernetes内部名称，让webserver使用数据库中的友好名称
+				Phase:     string(item.Status.Phase),
+				Uid:       item.Spec.UID,
+				StartedAt: startedAtOf(&item),
+			})
+			continue
+		}
+
+		// 启动失败的Workspace, 返回失败原因
+		if item.Status.Phase == mv1.WorkspacePhaseFailed {
+			res.Stopped = append(res.Stopped, &pb.ResponseRunningWorkspace_WorkspaceStopInfo{
+				Sid:       item.Spec.SID,
+				Reason:    item.Status.Reason,
+				Message:   item.Status.Message,
+				StoppedAt: stoppedAtOf(&item),
+			})
+			continue
+		}
+
+		// 被control-plane主动停止的Workspace, 返回停止原因
+		if item.Status.StopReason != "" {
+			res.Stopped = append(res.Stopped, &pb.ResponseRunningWorkspace_WorkspaceStopInfo{
+				Sid:       item.Spec.SID,
+				Reason:    item.Status.StopReason,
+				Message:   item.Status.StopMessage,
+				StoppedAt: stoppedAtOf(&item),
+			})
+			continue
+		}
+
+		// 其它已停止的Workspace, 返回停止时间用于计量
+		if item.Status.StoppedAt != nil {
+			res.Stopped = append(res.Stopped, &pb.ResponseRunningWorkspace_WorkspaceStopInfo{
+				Sid:       item.Spec.SID,
+				StoppedAt: stoppedAtOf(&item),
 			})
 		}
 	}
@@ -301,6 +497,56 @@ func (s *WorkSpaceService) RunningWorkspaces(ctx context.Context, req *pb.Reques
 	return res, nil
 }
 
+// WatchSpace 发送Workspace的生命周期事件, 先发送当前状态, 之后每次阶段或原因变化时发送
+// 直到客户端取消或Workspace被删除
+func (s *WorkSpaceService) WatchSpace(req *pb.RequestWatch, stream pb.CloudIdeService_WatchSpaceServer) error {
+	ctx := stream.Context()
+	key := client.ObjectKey{
+		Name:      workspaceName(req.Uid, req.Sid),
+		Namespace: s.namespace,
+	}
+
+	// 先订阅再获取, 防止遗漏两者之间的变化
+	ch, cancel := s.watcher.Subscribe(key.Name)
+	defer cancel()
+
+	var last *pb.WorkspaceEvent
+	for {
+		var ws mv1.WorkSpace
+		err := s.client.Get(ctx, key, &ws)
+		if errors.IsNotFound(err) {
+			if last == nil {
+				return status.Error(codes.NotFound, WorkspaceNotExist)
+			}
+
+			return stream.Send(&pb.WorkspaceEvent{
+				Sid:       req.Sid,
+				Phase:     PhaseDeleted,
+				Stage:     PhaseDeleted,
+				Timestamp: time.Now().UnixMilli(),
+			})
+		}
+		if err != nil {
+			s.logger.Error(err, "get workspace")
+			return status.Error(codes.Unknown, err.Error())
+		}
+
+		if ev := workspaceEvent(&ws); eventChanged(last, ev) {
+			ev.Timestamp = time.Now().UnixMilli()
+			if err := stream.Send(ev); err != nil {
+				return err
+			}
+			last = ev
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ch:
+		}
+	}
+}
+
 func (s *WorkSpaceService) checkWorkspaceExist(ctx context.Context, key client.ObjectKey, w *mv1.WorkSpace) bool {
 	if err := s.client.Get(ctx, key, w); err != nil {
 		if errors.IsNotFound(err) {
@@ -331,17 +577,21 @@ func (s *WorkSpaceService) constructWorkspace(space *pb.RequestCreate, name stri
 			},
 		},
 		Spec: mv1.WorkSpaceSpec{
-			UID:           space.Uid,
-			SID:           space.Sid,
-			Cpu:           space.ResourceLimit.Cpu,
-			Memory:        space.ResourceLimit.Memory,
-			Storage:       space.ResourceLimit.Storage,
-			Hardware:      hardware,
-			Image:         space.Image,
-			Port:          space.Port,
-			MountPath:     space.VolumeMountPath,
-			GitRepository: space.GitRepository,
-			Command:       mv1.WorkSpaceStart,
+			UID:                space.Uid,
+			SID:                space.Sid,
+			Cpu:                space.ResourceLimit.Cpu,
+			Memory:             space.ResourceLimit.Memory,
+			Storage:            space.ResourceLimit.Storage,
+			Hardware:           hardware,
+			Image:              space.Image,
+			Port:               space.Port,
+			MountPath:          space.VolumeMountPath,
+			GitRepository:      space.GitRepository,
+			Env:                controllers.EnvVars(space.EnvVars),
+			Launch:             launchFromPb(space.Launch),
+			Command:            mv1.WorkSpaceStart,
+			IdleTimeoutSeconds: space.IdleTimeout,
+			MaxRuntimeSeconds:  space.MaxRuntime,
 		},
 	}
 }
@@ -357,39 +607,24 @@ func (s *WorkSpaceService) validateRequestCreate(req *pb.RequestCreate) error {
 		return fmt.Errorf("port invalid, port must be [1024,65535], now is%d", req.Port)
 	}
 	if req.GitRepository != "" {
-		// 检查是否是环境变量编码格式（Claude模板使用）
-		if strings.HasPrefix(req.GitRepository, "ENV:") {
-			// 解析编码格式: ENV:{"key":"value"}|GIT:actual_repo_url
-			parts := strings.SplitN(req.GitRepository, "|GIT:", 2)
-			if len(parts) == 2 {
-				// 验证实际的git仓库URL（如果存在）
-				actualGitRepo := parts[1]
-				if actualGitRepo != "" {
-					matched, err := regexp.MatchString(`^https://\S+.git$`, actualGitRepo)
-					if err != nil {
-						s.logger.Error(err, "regexp")
-						return err
-					}
-					if !matched {
-						return fmt.Errorf("git repository invalid")
-					}
-				}
-				// 环境变量编码格式验证通过
-			} else {
-				return fmt.Errorf("git repository invalid: malformed environment encoding")
-			}
-		} else {
-			// 常规git URL验证
-			matched, err := regexp.MatchString(`^https://\S+.git$`, req.GitRepository)
-			if err != nil {
-				s.logger.Error(err, "regexp")
-				return err
-			}
-			if !matched {
-				return fmt.Errorf("git repository invalid")
-			}
+		matched, err := regexp.MatchString(`^https://\S+.git$`, req.GitRepository)
+		if err != nil {
+			s.logger.Error(err, "regexp")
+			return err
+		}
+		if !matched {
+			return fmt.Errorf("git repository invalid")
 		}
 	}
+	if err := validateEnvNames(req.EnvVars); err != nil {
+		return err
+	}
+	if err := validateEnvNames(req.SecretEnvVars); err != nil {
+		return err
+	}
+	if err := validateLaunch(req.Launch); err != nil {
+		return err
+	}
 	matched, err := regexp.MatchString(`^\/(?:[\w-]+\/)*(?:[\w-]+\.[\w-]+|[\w-]+\/?)$`, req.VolumeMountPath)
 	if err != nil {
 		s.logger.Error(err, "regexp")
@@ -402,6 +637,69 @@ func (s *WorkSpaceService) validateRequestCreate(req *pb.RequestCreate) error {
 	return s.validateResourceLimit(req.ResourceLimit)
 }
 
+func validateEnvNames(env map[string]string) error {
+	for name := range env {
+		if !envNameRegexp.MatchString(name) {
+			return fmt.Errorf("env name invalid %s", name)
+		}
+	}
+
+	return nil
+}
+
+func validateLaunch(launch *pb.LaunchSpec) error {
+	if launch == nil {
+		return nil
+	}
+
+	names := make(map[string]bool, len(launch.InitSteps))
+	for _, step := range launch.InitSteps {
+		if len(step.Name) > 50 || !initStepNameRegexp.MatchString(step.Name) {
+			return fmt.Errorf("init step name invalid %s", step.Name)
+		}
+		if names[step.Name] {
+			return fmt.Errorf("init step name duplicate %s", step.Name)
+		}
+		if len(step.Command) == 0 {
+			return fmt.Errorf("init step command is empty %s", step.Name)
+		}
+		names[step.Name] = true
+	}
+	for _, path := range []string{launch.ReadinessProbePath, launch.LivenessProbePath, launch.StartupProbePath} {
+		if path != "" && !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("probe path invalid %s", path)
+		}
+	}
+
+	return nil
+}
+
+// launchFromPb 将模板的启动配置转换为Workspace的启动配置
+func launchFromPb(launch *pb.LaunchSpec) *mv1.WorkSpaceLaunch {
+	if launch == nil {
+		return nil
+	}
+
+	steps := make([]mv1.WorkSpaceInitStep, 0, len(launch.InitSteps))
+	for _, step := range launch.InitSteps {
+		steps = append(steps, mv1.WorkSpaceInitStep{
+			Name:    step.Name,
+			Image:   step.Image,
+			Command: step.Command,
+		})
+	}
+
+	return &mv1.WorkSpaceLaunch{
+		Command:            launch.Command,
+		Args:               launch.Args,
+		WorkingDir:         launch.WorkingDir,
+		ReadinessProbePath: launch.ReadinessProbePath,
+		LivenessProbePath:  launch.LivenessProbePath,
+		StartupProbePath:   launch.StartupProbePath,
+		InitSteps:          steps,
+	}
+}
+
 func (s *WorkSpaceService) validateResourceLimit(limit *pb.ResourceLimit) error {
 	_, err := resource.ParseQuantity(limit.Cpu)
 	if err != nil {
@@ -421,6 +719,158 @@ func (s *WorkSpaceService) validateResourceLimit(limit *pb.ResourceLimit) error
 	return nil
 }
 
+// applySecret 创建Secret, 已经存在时更新
+func (s *WorkSpaceService) applySecret(ctx context.Context, secret *v1.Secret) error {
+	err := s.client.Create(ctx, secret)
+	if errors.IsAlreadyExists(err) {
+		return s.client.Update(ctx, secret)
+	}
+
+	return err
+}
+
+// mergeSecret 将环境变量合并到Workspace的Secret中, 已存在的同名环境变量会被覆盖
+func (s *WorkSpaceService) mergeSecret(ctx context.Context, ws *mv1.WorkSpace, data map[string]string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var secret v1.Secret
+		err := s.client.Get(ctx, client.ObjectKey{Name: controllers.EnvSecretName(ws.Name), Namespace: ws.Namespace}, &secret)
+		if errors.IsNotFound(err) {
+			created := controllers.EnvSecret(ws.Name, ws.Namespace, data)
+			if err := controllerutil.SetControllerReference(ws, created, s.client.Scheme()); err != nil {
+				return err
+			}
+			return s.client.Create(ctx, created)
+		}
+		if err != nil {
+			return err
+		}
+
+		// 更新时StringData会合并到Data中
+		secret.StringData = data
+		return s.client.Update(ctx, &secret)
+	})
+}
+
+// withSecretEnvFrom 确保从指定的Secret中加载环境变量
+func withSecretEnvFrom(envFrom []v1.EnvFromSource, secret string) []v1.EnvFromSource {
+	for _, source := range envFrom {
+		if source.SecretRef != nil && source.SecretRef.Name == secret {
+			return envFrom
+		}
+	}
+
+	return append(envFrom, controllers.SecretEnvFrom(secret))
+}
+
+// ownSecret 将Secret的owner设置为Workspace
+func (s *WorkSpaceService) ownSecret(ctx context.Context, ws *mv1.WorkSpace, secret *v1.Secret) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var latest v1.Secret
+		if err := s.client.Get(ctx, client.ObjectKeyFromObject(secret), &latest); err != nil {
+			return err
+		}
+		if err := controllerutil.SetControllerReference(ws, &latest, s.client.Scheme()); err != nil {
+			return err
+		}
+
+		return s.client.Update(ctx, &latest)
+	})
+}
+
+// ResizeSpace 修改工作空间的规格, 存储卷由controller在线扩容, CPU和内存在下次启动时生效
+func (s *WorkSpaceService) ResizeSpace(ctx context.Context, req *pb.RequestResize) (*pb.ResponseResize, error) {
+	res := &pb.ResponseResize{}
+	if err := s.validateResourceLimit(req.ResourceLimit); err != nil {
+		s.logger.Error(err, "request param invalid")
+		res.Status = pb.ResponseResize_Error
+		return res, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	key := client.ObjectKey{
+		Name:      workspaceName(req.Uid, req.Sid),
+		Namespace: s.namespace,
+	}
+	var errShrink error
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var ws mv1.WorkSpace
+		if err := s.client.Get(ctx, key, &ws); err != nil {
+			return err
+		}
+
+		if errShrink = applyStorage(&ws, req.ResourceLimit.Storage); errShrink != nil {
+			return nil
+		}
+		ws.Spec.Cpu = req.ResourceLimit.Cpu
+		ws.Spec.Memory = req.ResourceLimit.Memory
+
+		return s.client.Update(ctx, &ws)
+	})
+
+	switch {
+	case errors.IsNotFound(err):
+		res.Status = pb.ResponseResize_NotFound
+		res.Message = WorkspaceNotExist
+		return res, status.Error(codes.NotFound, WorkspaceNotExist)
+	case err != nil:
+		s.logger.Error(err, "update workspace")
+		res.Status = pb.ResponseResize_Error
+		res.Message = WorkspaceResizeFailed
+		return res, status.Error(codes.Unknown, err.Error())
+	case errShrink != nil:
+		res.Status = pb.ResponseResize_ShrinkNotAllowed
+		res.Message = WorkspaceShrinkNotAllowed
+		return res, status.Error(codes.InvalidArgument, WorkspaceShrinkNotAllowed)
+	}
+
+	return res, nil
+}
+
+// applyStorage 修改Workspace的存储大小, 不允许缩容
+func applyStorage(ws *mv1.WorkSpace, storage string) error {
+	desired, err := resource.ParseQuantity(storage)
+	if err != nil {
+		return err
+	}
+	current, err := resource.ParseQuantity(ws.Spec.Storage)
+	if err == nil && desired.Cmp(current) < 0 {
+		return fmt.Errorf("cannot shrink storage from %s to %s", current.String(), desired.String())
+	}
+
+	ws.Spec.Storage = storage
+	return nil
+}
+
+// startedAtOf 获取Workspace的运行时间(unix毫秒), 0表示尚未运行
+func startedAtOf(ws *mv1.WorkSpace) int64 {
+	if ws.Status.StartedAt == nil {
+		return 0
+	}
+
+	return ws.Status.StartedAt.UnixMilli()
+}
+
+// stoppedAtOf 获取Workspace的停止时间(unix毫秒), 0表示未知
+func stoppedAtOf(ws *mv1.WorkSpace) int64 {
+	if ws.Status.StoppedAt == nil {
+		return 0
+	}
+
+	return ws.Status.StoppedAt.UnixMilli()
+}
+
+// updateStatus 更新Workspace的状态, 版本冲突时重试
+func (s *WorkSpaceService) updateStatus(ctx context.Context, key client.ObjectKey, mutate func(status *mv1.WorkSpaceStatus)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var ws mv1.WorkSpace
+		if err := s.client.Get(ctx, key, &ws); err != nil {
+			return err
+		}
+
+		mutate(&ws.Status)
+		return s.client.Status().Update(ctx, &ws)
+	})
+}
+
 func workspaceName(uid, sid string) string {
 	return fmt.Sprintf(WorkspaceNameFormat, uid, sid)
 }
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"sync"
+
+	mv1 "github.com/mangohow/cloud-ide/cmd/control-plane/internal/api/v1"
+	"github.com/mangohow/cloud-ide/pkg/pb"
+	"k8s.io/apimachinery/pkg/api/meta"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+// 启动中的细分阶段
+const (
+	StagePending        = "Pending"
+	StageCreatingVolume = "CreatingVolume"
+	StageScheduling     = "Scheduling"
+	StageCloningRepo    = "CloningRepo"
+	StagePullingImage   = "PullingImage"
+)
+
+// 工作空间被删除时发送的phase
+const PhaseDeleted = "Deleted"
+
+// WorkspaceWatcher 监听Workspace的变化并通知订阅者
+// 注册到Workspace的informer中, 订阅者收到通知后从缓存中获取最新的Workspace
+type WorkspaceWatcher struct {
+	mux sync.Mutex
+	// Workspace名称到订阅者的映射
+	subs map[string]map[chan struct{}]struct{}
+}
+
+var _ toolscache.ResourceEventHandler = &WorkspaceWatcher{}
+
+func NewWorkspaceWatcher() *WorkspaceWatcher {
+	return &WorkspaceWatcher{
+		subs: make(map[string]map[chan struct{}]struct{}),
+	}
+}
+
+// Subscribe 订阅指定Workspace的变化, 返回的cancel用于取消订阅
+func (w *WorkspaceWatcher) Subscribe(name string) (<-chan struct{}, func()) {
+	// 缓冲为1, 多次变化只需要通知一次
+	ch := make(chan struct{}, 1)
+
+	w.mux.Lock()
+	if w.subs[name] == nil {
+		w.subs[name] = make(map[chan struct{}]struct{})
+	}
+	w.subs[name][ch] = struct{}{}
+	w.mux.Unlock()
+
+	return ch, func() {
+		w.mux.Lock()
+		delete(w.subs[name], ch)
+		if len(w.subs[name]) == 0 {
+			delete(w.subs, name)
+		}
+		w.mux.Unlock()
+	}
+}
+
+func (w *WorkspaceWatcher) OnAdd(obj interface{}) {
+	w.notify(obj)
+}
+
+func (w *WorkspaceWatcher) OnUpdate(_, newObj interface{}) {
+	w.notify(newObj)
+}
+
+func (w *WorkspaceWatcher) OnDelete(obj interface{}) {
+	if d, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		obj = d.Obj
+	}
+	w.notify(obj)
+}
+
+func (w *WorkspaceWatcher) notify(obj interface{}) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	for ch := range w.subs[accessor.GetName()] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// workspaceEvent 根据Workspace的状态生成事件
+// 已经下达启动或停止命令但controller还未处理时, 按照命令报告启动中或停止中
+func workspaceEvent(ws *mv1.WorkSpace) *pb.WorkspaceEvent {
+	phase, stage := string(ws.Status.Phase), workspaceStage(ws)
+	switch {
+	case ws.Spec.Command == mv1.WorkSpaceStart &&
+		(ws.Status.Phase == "" || ws.Status.Phase == mv1.WorkspacePhaseStopped):
+		phase, stage = mv1.WorkspacePhaseStaring, StagePending
+	case ws.Spec.Command == mv1.WorkSpaceStop &&
+		(ws.Status.Phase == mv1.WorkspacePhaseStaring || ws.Status.Phase == mv1.WorkspacePhaseRunning ||
+			ws.Status.Phase == mv1.WorkspacePhaseDegraded):
+		phase, stage = mv1.WorkspacePhaseStopping, mv1.WorkspacePhaseStopping
+	}
+
+	return &pb.WorkspaceEvent{
+		Sid:      ws.Spec.SID,
+		Phase:    phase,
+		Stage:    stage,
+		Reason:   ws.Status.Reason,
+		Message:  ws.Status.Message,
+		Endpoint: ws.Status.Endpoint,
+	}
+}
+
+// workspaceStage 获取启动中的细分阶段, 其它情况与phase相同
+func workspaceStage(ws *mv1.WorkSpace) string {
+	if ws.Status.Phase != mv1.WorkspacePhaseStaring {
+		return string(ws.Status.Phase)
+	}
+
+	conds := ws.Status.Conditions
+	switch {
+	case !meta.IsStatusConditionTrue(conds, mv1.WorkspaceConditionPVCBound):
+		return StageCreatingVolume
+	case !meta.IsStatusConditionTrue(conds, mv1.WorkspaceConditionPodScheduled):
+		return StageScheduling
+	case meta.FindStatusCondition(conds, mv1.WorkspaceConditionRepoCloned) != nil &&
+		!meta.IsStatusConditionTrue(conds, mv1.WorkspaceConditionRepoCloned):
+		return StageCloningRepo
+	default:
+		return StagePullingImage
+	}
+}
+
+// eventChanged 判断两个事件是否不同, 不比较时间戳
+func eventChanged(last, ev *pb.WorkspaceEvent) bool {
+	return last == nil || last.Phase != ev.Phase || last.Stage != ev.Stage ||
+		last.Reason != ev.Reason || last.Message != ev.Message || last.Endpoint != ev.Endpoint
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"testing"
+
+	mv1 "github.com/mangohow/cloud-ide/cmd/control-plane/internal/api/v1"
+	"github.com/mangohow/cloud-ide/pkg/pb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+func testWorkspace(name string) *mv1.WorkSpace {
+	return &mv1.WorkSpace{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"}}
+}
+
+func notified(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestWorkspaceWatcherNotify(t *testing.T) {
+	w := NewWorkspaceWatcher()
+	ch1, cancel1 := w.Subscribe("ws-a")
+	ch2, cancel2 := w.Subscribe("ws-a")
+	other, cancelOther := w.Subscribe("ws-b")
+	defer cancelOther()
+
+	// 多次变化只通知一次, 不会阻塞informer
+	w.OnAdd(testWorkspace("ws-a"))
+	w.OnUpdate(nil, testWorkspace("ws-a"))
+	if !notified(ch1) || !notified(ch2) {
+		t.Fatal("all subscribers should be notified")
+	}
+	if notified(ch1) {
+		t.Fatal("notifications should be coalesced")
+	}
+	if notified(other) {
+		t.Fatal("subscribers of other workspaces should not be notified")
+	}
+
+	// 删除时包括informer错过删除事件的情况
+	w.OnDelete(toolscache.DeletedFinalStateUnknown{Key: "ns/ws-a", Obj: testWorkspace("ws-a")})
+	if !notified(ch1) {
+		t.Fatal("subscriber should be notified on delete")
+	}
+
+	// 取消订阅后不再通知, 最后一个订阅者取消后删除映射
+	cancel1()
+	w.OnUpdate(nil, testWorkspace("ws-a"))
+	if notified(ch1) {
+		t.Fatal("cancelled subscriber should not be notified")
+	}
+	if !notified(ch2) {
+		t.Fatal("remaining subscriber should be notified")
+	}
+	cancel2()
+	if _, ok := w.subs["ws-a"]; ok {
+		t.Fatal("subscription map should be removed after the last subscriber cancelled")
+	}
+
+	// 无法获取名称的对象被忽略
+	w.OnAdd("not an object")
+}
+
+func TestWorkspaceEvent(t *testing.T) {
+	conds := func(types ...string) []metav1.Condition {
+		var cs []metav1.Condition
+		for _, tp := range types {
+			cs = append(cs, metav1.Condition{Type: tp, Status: metav1.ConditionTrue})
+		}
+		return cs
+	}
+
+	tests := []struct {
+		name       string
+		command    mv1.WorkspaceCommand
+		phase      mv1.WorkSpacePhase
+		conditions []metav1.Condition
+		wantPhase  string
+		wantStage  string
+	}{
+		{"start not handled", mv1.WorkSpaceStart, "", nil, mv1.WorkspacePhaseStaring, StagePending},
+		{"restart not handled", mv1.WorkSpaceStart, mv1.WorkspacePhaseStopped, nil, mv1.WorkspacePhaseStaring, StagePending},
+		{"creating volume", mv1.WorkSpaceStart, mv1.WorkspacePhaseStaring, nil, mv1.WorkspacePhaseStaring, StageCreatingVolume},
+		{"scheduling", mv1.WorkSpaceStart, mv1.WorkspacePhaseStaring,
+			conds(mv1.WorkspaceConditionPVCBound), mv1.WorkspacePhaseStaring, StageScheduling},
+		{"cloning repo", mv1.WorkSpaceStart, mv1.WorkspacePhaseStaring,
+			append(conds(mv1.WorkspaceConditionPVCBound, mv1.WorkspaceConditionPodScheduled),
+				metav1.Condition{Type: mv1.WorkspaceConditionRepoCloned, Status: metav1.ConditionFalse}),
+			mv1.WorkspacePhaseStaring, StageCloningRepo},
+		{"pulling image", mv1.WorkSpaceStart, mv1.WorkspacePhaseStaring,
+			conds(mv1.WorkspaceConditionPVCBound, mv1.WorkspaceConditionPodScheduled, mv1.WorkspaceConditionRepoCloned),
+			mv1.WorkspacePhaseStaring, StagePullingImage},
+		{"running", mv1.WorkSpaceStart, mv1.WorkspacePhaseRunning, nil, string(mv1.WorkspacePhaseRunning), string(mv1.WorkspacePhaseRunning)},
+		{"stop not handled", mv1.WorkSpaceStop, mv1.WorkspacePhaseRunning, nil, mv1.WorkspacePhaseStopping, mv1.WorkspacePhaseStopping},
+		{"stop degraded", mv1.WorkSpaceStop, mv1.WorkspacePhaseDegraded, nil, mv1.WorkspacePhaseStopping, mv1.WorkspacePhaseStopping},
+		{"stopped", mv1.WorkSpaceStop, mv1.WorkspacePhaseStopped, nil, mv1.WorkspacePhaseStopped, mv1.WorkspacePhaseStopped},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws := testWorkspace("ws-a")
+			ws.Spec.SID = "sid"
+			ws.Spec.Command = tt.command
+			ws.Status.Phase = tt.phase
+			ws.Status.Conditions = tt.conditions
+
+			ev := workspaceEvent(ws)
+			if ev.Sid != "sid" || ev.Phase != tt.wantPhase || ev.Stage != tt.wantStage {
+				t.Errorf("event = %s/%s, want %s/%s", ev.Phase, ev.Stage, tt.wantPhase, tt.wantStage)
+			}
+		})
+	}
+}
+
+func TestEventChanged(t *testing.T) {
+	ev := &pb.WorkspaceEvent{Phase: "Starting", Stage: StagePending, Timestamp: 1}
+	if !eventChanged(nil, ev) {
+		t.Error("first event should be sent")
+	}
+	if eventChanged(ev, &pb.WorkspaceEvent{Phase: "Starting", Stage: StagePending, Timestamp: 2}) {
+		t.Error("timestamp should not be compared")
+	}
+	if !eventChanged(ev, &pb.WorkspaceEvent{Phase: "Starting", Stage: StageScheduling}) {
+		t.Error("stage change should be sent")
+	}
+	if !eventChanged(ev, &pb.WorkspaceEvent{Phase: "Starting", Stage: StagePending, Message: "pulling"}) {
+		t.Error("message change should be sent")
+	}
+}
@@ -19,6 +19,7 @@ package main
 import (
 	"flag"
 	"os"
+	"time"
 
 	"github.com/mangohow/cloud-ide/cmd/control-plane/internal/controllers"
 	"github.com/mangohow/cloud-ide/cmd/control-plane/internal/rpc"
@@ -35,6 +36,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	cloudidev1 "github.com/mangohow/cloud-ide/cmd/control-plane/internal/api/v1"
 	// +kubebuilder:scaffold:imports
@@ -61,6 +63,12 @@ func main() {
 		gatewayToken   string
 		gatewayPath    string
 		gatewayService string
+		gatewayCA      string
+
+		idleCheckInterval time.Duration
+		idleTimeout       time.Duration
+
+		endpointResyncInterval time.Duration
 	)
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
@@ -79,12 +87,30 @@ func main() {
 	flag.StringVar(&gatewayPath, "gateway-path", "/internal/endpoint", "specify gateway path")
 	// 指定gateway的service name
 	flag.StringVar(&gatewayService, "gateway-service", "cloud-ide-gateway-svc", "specify gateway service")
+	// 指定gateway证书的CA，用于校验gateway的证书，为空时不校验
+	flag.StringVar(&gatewayCA, "gateway-ca", "", "specify the CA file to verify gateway certificate, empty means skip verification")
 	// 指定动态卷的storageClass
 	flag.StringVar(&controllers.StorageClassName, "storage-class-name", "nfs-csi", "specify storage class name if dynamic-storage-enabled enabled")
 	// 指定是否启用动态卷制备
 	flag.BoolVar(&controllers.DynamicStorageEnabled, "dynamic-storage-enabled", false, "specify dynamic storage enabled")
 	// 指定用于克隆git的初始化容器镜像
 	flag.StringVar(&controllers.GitClonerName, "git-cloner-image", "git-cloner", "specify git cloner images")
+	// 存储类不支持VolumeSnapshot时，快照打包上传的对象存储地址，为空则不支持快照
+	flag.StringVar(&controllers.SnapshotArchiveURL, "snapshot-archive-url", "", "specify the object storage url of snapshot archives, eg. s3://cloud-ide-snapshots")
+	// 指定打包上传快照的镜像，需要包含tar和aws命令
+	flag.StringVar(&controllers.SnapshotArchiveImage, "snapshot-archive-image", "amazon/aws-cli", "specify the image used to archive snapshots")
+	// 指定对象存储凭证的Secret，以环境变量的形式注入
+	flag.StringVar(&controllers.SnapshotArchiveSecret, "snapshot-archive-secret", "", "specify the secret of object storage credentials")
+	// 指定空闲检测的间隔
+	flag.DurationVar(&idleCheckInterval, "idle-check-interval", time.Minute, "specify the interval of workspace idle detection")
+	// 指定默认的空闲超时时间，Workspace未指定时使用，0表示不自动停止
+	flag.DurationVar(&idleTimeout, "idle-timeout", 0, "specify the default idle timeout of workspace, 0 means never")
+	// 指定工作空间容器探针的检查间隔
+	flag.DurationVar(&controllers.ProbePeriod, "probe-period", 5*time.Second, "specify the period of workspace probes")
+	// 指定工作空间启动的超时时间，超时后启动探针失败，容器被重启
+	flag.DurationVar(&controllers.StartupTimeout, "startup-timeout", 5*time.Minute, "specify the startup timeout of workspace, only used when the startup probe is set")
+	// 指定全量同步工作空间地址到网关的间隔
+	flag.DurationVar(&endpointResyncInterval, "endpoint-resync-interval", 5*time.Minute, "specify the interval of resyncing all workspace endpoints to the gateway")
 
 	opts := zap.Options{
 		Development: true,
@@ -139,10 +165,14 @@ func main() {
 		os.Exit(1)
 	}
 
-	ntf, err := notifier.NewWorkspaceNotifier(ctx, logger, gatewayService, gatewayPath, gatewayToken, 8)
+	ntf, err := notifier.NewWorkspaceNotifier(ctx, logger, gatewayService, gatewayPath, gatewayToken, gatewayCA, 8)
 	if err != nil {
 		panic(err)
 	}
+	if err = notifier.RegisterMetrics(metrics.Registry); err != nil {
+		setupLog.Error(err, "unable to register notifier metrics")
+		os.Exit(1)
+	}
 	if err = controllers.NewPodReconciler(
 		mgr.GetClient(),
 		mgr.GetScheme(),
@@ -151,6 +181,11 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "Pod")
 		os.Exit(1)
 	}
+	// 启动时和定期将所有就绪的工作空间同步到网关
+	if err = mgr.Add(controllers.NewEndpointSyncer(mgr.GetClient(), logger, ntf, controllers.WorkspaceNamespace, endpointResyncInterval)); err != nil {
+		setupLog.Error(err, "unable to set up endpoint syncer")
+		os.Exit(1)
+	}
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -162,12 +197,28 @@ func main() {
 		os.Exit(1)
 	}
 
+	// 监听Workspace的变化, 用于向客户端推送生命周期事件
+	watcher := service.NewWorkspaceWatcher()
+	informer, err := mgr.GetCache().GetInformer(ctx, &cloudidev1.WorkSpace{})
+	if err != nil {
+		setupLog.Error(err, "unable to get workspace informer")
+		os.Exit(1)
+	}
+	informer.AddEventHandler(watcher)
+
+	wsSvc := service.NewWorkSpaceService(mgr.GetClient(), mgr.GetAPIReader(), logger, ntf, watcher, controllers.WorkspaceNamespace)
 	// 将grpc交由manager管理,manager会调用Start方法启动
-	if err := mgr.Add(rpc.New(":6387", logger, service.NewWorkSpaceService(mgr.GetClient(), logger, ntf, controllers.WorkspaceNamespace))); err != nil {
+	if err := mgr.Add(rpc.New(":6387", logger, wsSvc)); err != nil {
 		setupLog.Error(err, "unable to set up grpc server")
 		os.Exit(1)
 	}
 
+	// 空闲检测，自动停止长时间无人使用的Workspace
+	if err := mgr.Add(service.NewIdleDetector(mgr.GetClient(), logger, wsSvc, controllers.WorkspaceNamespace, idleCheckInterval, idleTimeout)); err != nil {
+		setupLog.Error(err, "unable to set up idle detector")
+		os.Exit(1)
+	}
+
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "problem running manager")
@@ -0,0 +1,90 @@
+package gateway
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mangohow/cloud-ide/pkg/utils/encrypt"
+)
+
+const (
+	ticketParam       = "ticket"
+	sessionCookieName = "cloudide_session"
+)
+
+// checkAuth 验证访问者的票据或会话, 与proxy.lua的行为相同, 返回false时已经写入响应
+// 票据由webserver签发并放在ticket参数中, 验证通过后写入会话cookie, 然后重定向到去掉票据的地址
+// owner为注册的工作空间所有者, 票据和会话只能访问所有者为owner的sid
+// 本地先验证签名过滤伪造的令牌, 再由webserver检查用户状态和访问权限, 会话的检查结果缓存30秒
+func (s *Server) checkAuth(w http.ResponseWriter, r *http.Request, sid, owner string) bool {
+	query := r.URL.Query()
+	if ticket := query.Get(ticketParam); ticket != "" {
+		claims, err := encrypt.VerifyWorkspaceToken(s.cfg.WorkspaceKey, encrypt.WorkspaceTicket, ticket)
+		if err != nil || !claims.Allow(sid, owner) {
+			s.unauthorized(w, r)
+			return false
+		}
+
+		session, expire, err := s.sessions.Redeem(r.Context(), ticket, sid, owner)
+		if err != nil {
+			if err != errSessionInvalid {
+				slog.Warn("redeem workspace ticket", "sid", sid, "error", err)
+			}
+			s.unauthorized(w, r)
+			return false
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     sessionCookieName,
+			Value:    session,
+			Path:     "/",
+			MaxAge:   int(time.Until(expire).Seconds()),
+			HttpOnly: true,
+			Secure:   true,
+			SameSite: http.SameSiteLaxMode,
+		})
+		http.Redirect(w, r, pathWithout(r, query, ticketParam), http.StatusFound)
+		return false
+	}
+
+	if c, err := r.Cookie(sessionCookieName); err == nil {
+		claims, err := encrypt.VerifyWorkspaceToken(s.cfg.WorkspaceKey, encrypt.WorkspaceSession, c.Value)
+		if err == nil && claims.Allow(sid, owner) {
+			err = s.sessions.Verify(r.Context(), c.Value, sid, owner)
+			if err == nil {
+				return true
+			}
+			if err != errSessionInvalid {
+				slog.Warn("verify workspace session", "sid", sid, "error", err)
+			}
+		}
+	}
+	s.unauthorized(w, r)
+
+	return false
+}
+
+// unauthorized 浏览器打开页面时重定向到登录页, 登录后前端获取票据并回到redirect, 其它请求返回401
+// 登录页与工作空间不同源, 因此redirect为包含工作空间域名的完整地址
+func (s *Server) unauthorized(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.LoginUrl == "" || r.Method != http.MethodGet || r.Header.Get("Upgrade") != "" ||
+		!strings.Contains(r.Header.Get("Accept"), "text/html") {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	redirect := "https://" + r.Host + pathWithout(r, r.URL.Query(), ticketParam)
+	http.Redirect(w, r, s.cfg.LoginUrl+"?redirect="+url.QueryEscape(redirect), http.StatusFound)
+}
+
+// pathWithout 返回去掉param参数的请求地址, 重定向到该地址防止令牌留在浏览器的地址栏和历史记录中
+func pathWithout(r *http.Request, query url.Values, param string) string {
+	query.Del(param)
+	location := r.URL.EscapedPath()
+	if q := query.Encode(); q != "" {
+		location += "?" + q
+	}
+	return location
+}
@@ -0,0 +1,64 @@
+package gateway
+
+import "sync"
+
+// Endpoint 工作空间Pod的地址和工作空间的所有者
+type Endpoint struct {
+	// Pod的地址 ip:port
+	Addr string
+	// 工作空间在control-plane中的所有者, 网关用来检查访问者的票据
+	Uid string
+}
+
+// EndpointTable 保存sid到工作空间Pod地址的映射, 由control-plane通过/internal/endpoint注册
+type EndpointTable interface {
+	Get(sid string) (Endpoint, bool)
+	Set(sid string, ep Endpoint) error
+	Delete(sid string) error
+	// Replace 使用control-plane全量同步的映射替换所有映射
+	Replace(eps map[string]Endpoint) error
+}
+
+// MemoryTable 保存在内存中的映射表, 相当于OpenResty的lua_shared_dict, 网关重启后丢失
+type MemoryTable struct {
+	mu        sync.RWMutex
+	endpoints map[string]Endpoint
+}
+
+func NewMemoryTable() *MemoryTable {
+	return &MemoryTable{
+		endpoints: make(map[string]Endpoint),
+	}
+}
+
+func (t *MemoryTable) Get(sid string) (Endpoint, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	ep, ok := t.endpoints[sid]
+	return ep, ok
+}
+
+func (t *MemoryTable) Set(sid string, ep Endpoint) error {
+	t.mu.Lock()
+	t.endpoints[sid] = ep
+	t.mu.Unlock()
+	return nil
+}
+
+func (t *MemoryTable) Delete(sid string) error {
+	t.mu.Lock()
+	delete(t.endpoints, sid)
+	t.mu.Unlock()
+	return nil
+}
+
+func (t *MemoryTable) Replace(eps map[string]Endpoint) error {
+	endpoints := make(map[string]Endpoint, len(eps))
+	for sid, ep := range eps {
+		endpoints[sid] = ep
+	}
+	t.mu.Lock()
+	t.endpoints = endpoints
+	t.mu.Unlock()
+	return nil
+}
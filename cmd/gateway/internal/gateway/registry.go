@@ -0,0 +1,179 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/mangohow/cloud-ide/pkg/notifier"
+)
+
+const (
+	// 保存sid到notifier.Request的hash, 与registry.lua相同
+	registryKey = "cloud-ide:gateway:endpoints"
+	// 映射修改的通知频道, 内容为修改的sid, 为空时表示全量同步
+	registryChannel = "cloud-ide:gateway:endpoints"
+	// 定时全量加载, 防止连接断开期间丢失通知
+	registryReloadInterval = time.Minute
+)
+
+// RedisTable 保存在redis中的映射表, 网关重启后从redis加载, 多个网关副本共享
+// 每个副本在内存中保存一份映射, 通过redis频道接收其它副本的修改
+type RedisTable struct {
+	client *redis.Client
+	local  *MemoryTable
+}
+
+// NewRedisTable 订阅修改通知并加载所有映射, 直到ctx结束
+func NewRedisTable(ctx context.Context, client *redis.Client) (*RedisTable, error) {
+	t := &RedisTable{
+		client: client,
+		local:  NewMemoryTable(),
+	}
+
+	// 订阅成功后再加载, 加载期间的修改不会丢失
+	pubsub := client.Subscribe(ctx, registryChannel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+	if err := t.load(ctx, ""); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+	go t.watch(ctx, pubsub)
+
+	return t, nil
+}
+
+func (t *RedisTable) Get(sid string) (Endpoint, bool) {
+	return t.local.Get(sid)
+}
+
+func (t *RedisTable) Set(sid string, ep Endpoint) error {
+	data, err := json.Marshal(notifier.Request{Sid: sid, Uid: ep.Uid, Endpoint: ep.Addr})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	pipe := t.client.TxPipeline()
+	pipe.HSet(ctx, registryKey, sid, data)
+	pipe.Publish(ctx, registryChannel, sid)
+	if _, err = pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	return t.local.Set(sid, ep)
+}
+
+func (t *RedisTable) Delete(sid string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	pipe := t.client.TxPipeline()
+	pipe.HDel(ctx, registryKey, sid)
+	pipe.Publish(ctx, registryChannel, sid)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	return t.local.Delete(sid)
+}
+
+func (t *RedisTable) Replace(eps map[string]Endpoint) error {
+	values := make(map[string]interface{}, len(eps))
+	for sid, ep := range eps {
+		data, err := json.Marshal(notifier.Request{Sid: sid, Uid: ep.Uid, Endpoint: ep.Addr})
+		if err != nil {
+			return err
+		}
+		values[sid] = data
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	pipe := t.client.TxPipeline()
+	pipe.Del(ctx, registryKey)
+	if len(values) > 0 {
+		pipe.HSet(ctx, registryKey, values)
+	}
+	pipe.Publish(ctx, registryChannel, "")
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	return t.local.Replace(eps)
+}
+
+// watch 接收其它副本的修改通知, 并定时全量加载
+// 连接断开时go-redis会自动重新订阅, 期间丢失的通知由定时加载兜底
+func (t *RedisTable) watch(ctx context.Context, pubsub *redis.PubSub) {
+	defer pubsub.Close()
+
+	ticker := time.NewTicker(registryReloadInterval)
+	defer ticker.Stop()
+	ch := pubsub.Channel()
+	for {
+		var sid string
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			sid = msg.Payload
+		}
+
+		if err := t.load(ctx, sid); err != nil {
+			slog.Error("load endpoints", "sid", sid, "error", err)
+		}
+	}
+}
+
+// load 从redis加载sid的映射到内存中, sid为空时加载所有映射
+func (t *RedisTable) load(ctx context.Context, sid string) error {
+	if sid != "" {
+		data, err := t.client.HGet(ctx, registryKey, sid).Bytes()
+		if err == redis.Nil {
+			return t.local.Delete(sid)
+		}
+		if err != nil {
+			return err
+		}
+		ep, err := decodeEndpoint(data)
+		if err != nil {
+			return err
+		}
+		return t.local.Set(sid, ep)
+	}
+
+	all, err := t.client.HGetAll(ctx, registryKey).Result()
+	if err != nil {
+		return err
+	}
+	eps := make(map[string]Endpoint, len(all))
+	for sid, data := range all {
+		ep, err := decodeEndpoint([]byte(data))
+		if err != nil {
+			slog.Warn("decode endpoint", "sid", sid, "error", err)
+			continue
+		}
+		eps[sid] = ep
+	}
+
+	return t.local.Replace(eps)
+}
+
+func decodeEndpoint(data []byte) (Endpoint, error) {
+	var req notifier.Request
+	if err := json.Unmarshal(data, &req); err != nil {
+		return Endpoint{}, err
+	}
+
+	return Endpoint{Addr: req.Endpoint, Uid: req.Uid}, nil
+}
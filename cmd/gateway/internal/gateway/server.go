@@ -0,0 +1,369 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mangohow/cloud-ide/pkg/notifier"
+	"golang.org/x/net/netutil"
+)
+
+const (
+	shareTokenParam  = "share_token"
+	shareCookieName  = "cloudide_share"
+	sharePermHeader  = "X-Share-Permission"
+	endpointTokenKey = "token"
+)
+
+// Config 网关的配置, 与nginx.tmpl使用的配置相同
+type Config struct {
+	// 监听地址
+	Addr string
+	// control-plane注册endpoint时使用的token
+	Token string
+	// webserver的地址 host:port, /api和/auth转发到webserver, 分享令牌由webserver验证
+	WebAddr string
+	// 静态资源的目录
+	StaticDir string
+	// debug模式不转发/api和/auth, 开启/internal/test
+	Debug bool
+	// 前端的登录页, 未登录的浏览器重定向到该地址, 为空时返回401
+	// 工作空间与前端不同源, 因此需要包含域名
+	LoginUrl string
+	// 工作空间的域名, 每个工作空间使用独立的源 <sid>.<WorkspaceDomain>, cookie只发送给该工作空间
+	// 防止被分享的工作空间中的脚本以访问者的身份访问其它工作空间
+	WorkspaceDomain string
+	// 最大连接数, 0表示不限制
+	MaxConns int
+	// 验证工作空间票据和会话的密钥, 与webserver共享
+	WorkspaceKey []byte
+}
+
+// Server 使用Go实现的网关, 与OpenResty的nginx.tmpl和lua脚本的行为相同
+type Server struct {
+	cfg       Config
+	endpoints EndpointTable
+	shares    *shareVerifier
+	sessions  *sessionVerifier
+	handler   http.Handler
+	workspace http.Handler
+}
+
+type targetKey struct{}
+
+// target 工作空间请求转发的目标
+type target struct {
+	endpoint string
+	path     string
+}
+
+func NewServer(cfg Config, endpoints EndpointTable) *Server {
+	s := &Server{
+		cfg:       cfg,
+		endpoints: endpoints,
+		shares:    newShareVerifier(cfg.WebAddr, cfg.Token),
+		sessions:  newSessionVerifier(cfg.WebAddr, cfg.Token),
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.Dir(cfg.StaticDir)))
+	mux.HandleFunc("/internal/endpoint", s.serveEndpoint)
+	// 兼容之前的/ws/{sid}/地址, 重定向到工作空间的域名
+	mux.HandleFunc("/ws/", s.redirectWorkspace)
+	if cfg.Debug {
+		mux.HandleFunc("/internal/test", s.serveTest)
+	} else {
+		web := s.webHandler()
+		for _, p := range []string{"/api", "/api/", "/auth", "/auth/"} {
+			mux.Handle(p, web)
+		}
+	}
+	s.handler = mux
+	s.workspace = s.workspaceHandler()
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.workspaceSid(r.Host); ok {
+		s.workspace.ServeHTTP(w, r)
+		return
+	}
+	s.handler.ServeHTTP(w, r)
+}
+
+// ListenAndServeTLS 监听HTTPS, 同时支持HTTP/1.1和HTTP/2, ctx结束后关闭服务
+func (s *Server) ListenAndServeTLS(ctx context.Context, certFile, keyFile string) error {
+	ln, err := net.Listen("tcp", s.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	if s.cfg.MaxConns > 0 {
+		ln = netutil.LimitListener(ln, s.cfg.MaxConns)
+	}
+
+	server := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       5 * time.Minute,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("gateway listening", "addr", s.cfg.Addr, "maxConns", s.cfg.MaxConns)
+	err = server.ServeTLS(ln, certFile, keyFile)
+	if err == http.ErrServerClosed {
+		return nil
+	}
+
+	return err
+}
+
+// serveEndpoint 注册或注销工作空间的地址, 请求体为notifier.Request, 与endpoint.lua相同
+// PUT为control-plane的全量同步, 请求体为[]notifier.Request, 替换所有的地址
+func (s *Server) serveEndpoint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete && r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if token := r.Header.Get(endpointTokenKey); token == "" || token != s.cfg.Token {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var err error
+	if r.Method == http.MethodPut {
+		var reqs []notifier.Request
+		if err = json.NewDecoder(http.MaxBytesReader(w, r.Body, 8<<20)).Decode(&reqs); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		eps := make(map[string]Endpoint, len(reqs))
+		for _, req := range reqs {
+			if req.Sid == "" || req.Endpoint == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			eps[req.Sid] = Endpoint{Addr: req.Endpoint, Uid: req.Uid}
+		}
+		if err = s.endpoints.Replace(eps); err != nil {
+			slog.Error("replace endpoints", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		slog.Debug("resync endpoints", "count", len(eps))
+		return
+	}
+
+	var req notifier.Request
+	if err = json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil || req.Sid == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		if req.Endpoint == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		err = s.endpoints.Set(req.Sid, Endpoint{Addr: req.Endpoint, Uid: req.Uid})
+	} else {
+		err = s.endpoints.Delete(req.Sid)
+	}
+	if err != nil {
+		slog.Error("save endpoint", "sid", req.Sid, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	slog.Debug("endpoint", "method", r.Method, "sid", req.Sid, "uid", req.Uid, "endpoint", req.Endpoint)
+}
+
+// serveTest 查询sid对应的地址, 只在debug模式下开启
+func (s *Server) serveTest(w http.ResponseWriter, r *http.Request) {
+	var req notifier.Request
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	ep, ok := s.endpoints.Get(req.Sid)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.Write([]byte(ep.Addr + "\n"))
+}
+
+// workspaceHandler 将{sid}.{WorkspaceDomain}的请求转发到工作空间, 支持WebSocket
+// 携带分享令牌的请求检查分享链接, 其它请求必须携带所有者签发的票据或会话
+func (s *Server) workspaceHandler() http.Handler {
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			t := pr.In.Context().Value(targetKey{}).(*target)
+			pr.Out.URL.Scheme = "http"
+			pr.Out.URL.Host = t.endpoint
+			pr.Out.URL.RawPath = t.path
+			pr.Out.URL.Path, _ = url.PathUnescape(t.path)
+			setProxyHeaders(pr)
+		},
+		// 不缓冲响应, 与proxy_buffering off相同
+		FlushInterval: -1,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			slog.Warn("proxy workspace", "path", r.URL.Path, "error", err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sid, _ := s.workspaceSid(r.Host)
+
+		// 先验证访问者, 防止未登录的请求探测sid是否存在
+		ep, found := s.endpoints.Get(sid)
+		if token, fromLink := shareToken(r); token != "" {
+			if !s.checkShare(w, r, sid, token, fromLink) {
+				return
+			}
+		} else {
+			r.Header.Del(sharePermHeader)
+			if !s.checkAuth(w, r, sid, ep.Uid) {
+				return
+			}
+		}
+		if !found {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), targetKey{}, &target{endpoint: ep.Addr, path: r.URL.EscapedPath()})
+		proxy.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// shareToken 获取分享令牌, fromLink表示令牌来自分享链接的参数而不是cookie
+func shareToken(r *http.Request) (token string, fromLink bool) {
+	if token = r.URL.Query().Get(shareTokenParam); token != "" {
+		return token, true
+	}
+	if c, err := r.Cookie(shareCookieName); err == nil {
+		return c.Value, false
+	}
+	return "", false
+}
+
+// checkShare 验证分享令牌, 与proxy.lua的行为相同, 返回false时已经写入响应
+// 通过链接首次访问时记录访问并写入cookie, 然后重定向到去掉令牌的地址
+func (s *Server) checkShare(w http.ResponseWriter, r *http.Request, sid, token string, fromLink bool) bool {
+	permission, err := s.shares.Verify(r, token, sid, fromLink)
+	if err != nil {
+		if err != errShareForbidden {
+			slog.Warn("verify share token", "sid", sid, "error", err)
+		}
+		w.WriteHeader(http.StatusForbidden)
+		return false
+	}
+
+	if fromLink {
+		http.SetCookie(w, &http.Cookie{
+			Name:     shareCookieName,
+			Value:    token,
+			Path:     "/",
+			HttpOnly: true,
+			Secure:   true,
+			SameSite: http.SameSiteLaxMode,
+		})
+		http.Redirect(w, r, pathWithout(r, r.URL.Query(), shareTokenParam), http.StatusFound)
+		return false
+	}
+
+	// 后端可以根据该请求头限制分享访问者的操作
+	r.Header.Set(sharePermHeader, permission)
+
+	return true
+}
+
+// webHandler 将/api和/auth转发到webserver
+func (s *Server) webHandler() http.Handler {
+	web := &url.URL{Scheme: "http", Host: s.cfg.WebAddr}
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(web)
+			setProxyHeaders(pr)
+		},
+		FlushInterval: -1,
+	}
+}
+
+// setProxyHeaders 设置与nginx的proxy_set_header相同的请求头
+func setProxyHeaders(pr *httputil.ProxyRequest) {
+	pr.Out.Host = pr.In.Host
+	pr.SetXForwarded()
+	pr.Out.Header.Set("X-Real-IP", clientIP(pr.In))
+	if _, port, err := net.SplitHostPort(pr.In.Host); err == nil {
+		pr.Out.Header.Set("X-Forwarded-Port", port)
+	} else if pr.In.TLS != nil {
+		pr.Out.Header.Set("X-Forwarded-Port", "443")
+	}
+}
+
+// redirectWorkspace 将/ws/{sid}/...重定向到https://{sid}.{WorkspaceDomain}/..., 保留票据和分享令牌等参数
+func (s *Server) redirectWorkspace(w http.ResponseWriter, r *http.Request) {
+	sid, path, ok := splitWorkspacePath(r.URL.EscapedPath())
+	if !ok || strings.Contains(sid, ".") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	location := "https://" + sid + "." + s.cfg.WorkspaceDomain + path
+	if r.URL.RawQuery != "" {
+		location += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, location, http.StatusFound)
+}
+
+// workspaceSid 从{sid}.{WorkspaceDomain}中解析出sid
+func (s *Server) workspaceSid(host string) (string, bool) {
+	if s.cfg.WorkspaceDomain == "" {
+		return "", false
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	sid, found := strings.CutSuffix(strings.ToLower(host), "."+strings.ToLower(s.cfg.WorkspaceDomain))
+	if !found || sid == "" || strings.Contains(sid, ".") {
+		return "", false
+	}
+
+	return sid, true
+}
+
+// splitWorkspacePath 从/ws/{sid}/...中解析出sid和工作空间中的路径
+func splitWorkspacePath(p string) (sid, path string, ok bool) {
+	rest, found := strings.CutPrefix(p, "/ws/")
+	if !found {
+		return "", "", false
+	}
+	sid, path, _ = strings.Cut(rest, "/")
+	if sid == "" {
+		return "", "", false
+	}
+
+	return sid, "/" + path, true
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,433 @@
+package gateway
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mangohow/cloud-ide/pkg/utils/encrypt"
+)
+
+const (
+	testToken           = "test-token"
+	testLoginUrl        = "https://cloud.test/cloud-ide/#/login"
+	testWorkspaceDomain = "ws.test"
+)
+
+var testWorkspaceKey = []byte("test-workspace-key")
+
+func newTestServer(t *testing.T, webAddr string) (*Server, *MemoryTable) {
+	t.Helper()
+	table := NewMemoryTable()
+	return NewServer(Config{Token: testToken, WebAddr: webAddr, StaticDir: t.TempDir(), LoginUrl: testLoginUrl,
+		WorkspaceDomain: testWorkspaceDomain, WorkspaceKey: testWorkspaceKey}, table), table
+}
+
+// testSession 签发所有者uid访问sid的会话cookie
+func testSession(t *testing.T, uid, sid string) *http.Cookie {
+	t.Helper()
+	session, err := encrypt.CreateWorkspaceToken(testWorkspaceKey, encrypt.WorkspaceSession, 1, uid, sid, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &http.Cookie{Name: sessionCookieName, Value: session}
+}
+
+// testWebserver 模拟webserver的/internal/workspace/verify, 票据换取会话, revoked中的用户失去访问权限
+type testWebserver struct {
+	addr     string
+	revoked  sync.Map
+	verifies atomic.Int32
+}
+
+func newTestWebserver(t *testing.T) *testWebserver {
+	t.Helper()
+	web := &testWebserver{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(endpointTokenKey) != testToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		q := r.URL.Query()
+		kind, token := encrypt.WorkspaceSession, q.Get("session")
+		if ticket := q.Get("ticket"); ticket != "" {
+			kind, token = encrypt.WorkspaceTicket, ticket
+		} else {
+			web.verifies.Add(1)
+		}
+		claims, err := encrypt.VerifyWorkspaceToken(testWorkspaceKey, kind, token)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if _, revoked := web.revoked.Load(claims.Uid); revoked || !claims.Allow(q.Get("sid"), q.Get("uid")) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if kind == encrypt.WorkspaceTicket {
+			token, _ = encrypt.CreateWorkspaceToken(testWorkspaceKey, encrypt.WorkspaceSession, claims.Id, claims.Uid, claims.Sid, time.Minute)
+		}
+		fmt.Fprintf(w, `{"data":{"session":%q,"expire_time":%d},"status":0}`, token, time.Now().Add(time.Minute).Unix())
+	}))
+	t.Cleanup(server.Close)
+	web.addr = strings.TrimPrefix(server.URL, "http://")
+
+	return web
+}
+
+func TestSplitWorkspacePath(t *testing.T) {
+	cases := []struct {
+		path string
+		sid  string
+		rest string
+		ok   bool
+	}{
+		{"/ws/abc/", "abc", "/", true},
+		{"/ws/abc", "abc", "/", true},
+		{"/ws/abc/static/a%20b.js", "abc", "/static/a%20b.js", true},
+		{"/ws/", "", "", false},
+		{"/api/abc", "", "", false},
+	}
+	for _, c := range cases {
+		sid, rest, ok := splitWorkspacePath(c.path)
+		if sid != c.sid || rest != c.rest || ok != c.ok {
+			t.Errorf("splitWorkspacePath(%q) = %q, %q, %v, want %q, %q, %v", c.path, sid, rest, ok, c.sid, c.rest, c.ok)
+		}
+	}
+}
+
+func TestWorkspaceSid(t *testing.T) {
+	s, _ := newTestServer(t, "")
+	cases := []struct {
+		host string
+		sid  string
+		ok   bool
+	}{
+		{"abc.ws.test", "abc", true},
+		{"ABC.ws.test:8443", "abc", true},
+		{"ws.test", "", false},
+		{".ws.test", "", false},
+		{"a.b.ws.test", "", false},
+		{"abc.ws.test.evil", "", false},
+		{"cloud.test", "", false},
+	}
+	for _, c := range cases {
+		sid, ok := s.workspaceSid(c.host)
+		if sid != c.sid || ok != c.ok {
+			t.Errorf("workspaceSid(%q) = %q, %v, want %q, %v", c.host, sid, ok, c.sid, c.ok)
+		}
+	}
+}
+
+func TestRedirectWorkspace(t *testing.T) {
+	s, _ := newTestServer(t, "")
+	do := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	// 之前的/ws/{sid}/地址重定向到工作空间的域名, 保留参数
+	rec := do("https://cloud.test/ws/abc/static/a%20b.js?ticket=t&v=1")
+	if want := "https://abc.ws.test/static/a%20b.js?ticket=t&v=1"; rec.Code != http.StatusFound || rec.Header().Get("Location") != want {
+		t.Fatalf("redirect: got %d, location %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if rec = do("https://cloud.test/ws/a.b/"); rec.Code != http.StatusNotFound {
+		t.Fatalf("invalid sid: got %d", rec.Code)
+	}
+	// 工作空间的域名只转发到工作空间, 不能访问前端和webserver的接口
+	if rec = do("https://abc.ws.test/api/user"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("workspace api: got %d", rec.Code)
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	s, table := newTestServer(t, "")
+	do := func(method, token, body string) int {
+		req := httptest.NewRequest(method, "/internal/endpoint", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("token", token)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := do(http.MethodPost, "wrong", `{"sid":"a","endpoint":"10.0.0.1:9999"}`); code != http.StatusUnauthorized {
+		t.Fatalf("wrong token: got %d", code)
+	}
+	if code := do(http.MethodGet, testToken, ""); code != http.StatusBadRequest {
+		t.Fatalf("GET: got %d", code)
+	}
+	if code := do(http.MethodPost, testToken, `{"sid":"a"}`); code != http.StatusBadRequest {
+		t.Fatalf("missing endpoint: got %d", code)
+	}
+	if code := do(http.MethodPost, testToken, `{"sid":"a","uid":"u","endpoint":"10.0.0.1:9999"}`); code != http.StatusOK {
+		t.Fatalf("login: got %d", code)
+	}
+	if ep, _ := table.Get("a"); ep != (Endpoint{Addr: "10.0.0.1:9999", Uid: "u"}) {
+		t.Fatalf("endpoint not saved, got %+v", ep)
+	}
+	if code := do(http.MethodDelete, testToken, `{"sid":"a"}`); code != http.StatusOK {
+		t.Fatalf("logout: got %d", code)
+	}
+	if _, ok := table.Get("a"); ok {
+		t.Fatal("endpoint not deleted")
+	}
+
+	// 全量同步替换所有地址
+	table.Set("stale", Endpoint{Addr: "10.0.0.2:9999"})
+	if code := do(http.MethodPut, testToken, `[{"sid":"b","endpoint":"10.0.0.3:9999"}]`); code != http.StatusOK {
+		t.Fatalf("resync: got %d", code)
+	}
+	if _, ok := table.Get("stale"); ok {
+		t.Fatal("stale endpoint not deleted")
+	}
+	if ep, _ := table.Get("b"); ep.Addr != "10.0.0.3:9999" {
+		t.Fatalf("resync endpoint not saved, got %+v", ep)
+	}
+	if code := do(http.MethodPut, testToken, `[{"sid":"c"}]`); code != http.StatusBadRequest {
+		t.Fatalf("resync missing endpoint: got %d", code)
+	}
+	if _, ok := table.Get("b"); !ok {
+		t.Fatal("invalid resync should not change endpoints")
+	}
+}
+
+func TestProxyWorkspace(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s?%s %s", r.URL.EscapedPath(), r.URL.RawQuery, r.Header.Get(sharePermHeader))
+	}))
+	defer backend.Close()
+
+	s, table := newTestServer(t, newTestWebserver(t).addr)
+	table.Set("abc", Endpoint{Addr: strings.TrimPrefix(backend.URL, "http://"), Uid: "u"})
+	gw := httptest.NewServer(s)
+	defer gw.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, gw.URL+"/static/a%20b.js?v=1", nil)
+	req.Host = "abc." + testWorkspaceDomain
+	req.Header.Set(sharePermHeader, "full")
+	req.AddCookie(testSession(t, "u", "abc"))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	// 没有分享令牌时删除客户端伪造的权限请求头
+	if got := string(body); got != "/static/a%20b.js?v=1 " {
+		t.Fatalf("unexpected upstream request %q", got)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, gw.URL+"/", nil)
+	req.Host = "unknown." + testWorkspaceDomain
+	req.AddCookie(testSession(t, "u", "unknown"))
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("unknown sid: got %d", resp.StatusCode)
+	}
+}
+
+func TestProxyWebSocket(t *testing.T) {
+	// 后端完成升级后回显收到的数据
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" || r.URL.Path != "/socket" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+		rw.Flush()
+		io.Copy(conn, rw)
+	}))
+	defer backend.Close()
+
+	s, table := newTestServer(t, newTestWebserver(t).addr)
+	table.Set("abc", Endpoint{Addr: strings.TrimPrefix(backend.URL, "http://"), Uid: "u"})
+	gw := httptest.NewServer(s)
+	defer gw.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(gw.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	fmt.Fprintf(conn, "GET /socket HTTP/1.1\r\nHost: abc.ws.test\r\nCookie: %s\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n",
+		testSession(t, "u", "abc").String())
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("upgrade: got %d", resp.StatusCode)
+	}
+
+	conn.Write([]byte("ping"))
+	buf := make([]byte, 4)
+	if _, err = io.ReadFull(reader, buf); err != nil || string(buf) != "ping" {
+		t.Fatalf("echo: got %q, err:%v", buf, err)
+	}
+}
+
+func TestProxyShare(t *testing.T) {
+	var audits atomic.Int32
+	web := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/internal/share/verify" || q.Get("sid") != "abc" || r.Header.Get(endpointTokenKey) != testToken {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if q.Get("audit") == "1" {
+			audits.Add(1)
+		}
+		if q.Get("token") != "full-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, `{"data":{"permission":"full","expire_time":4102444800},"status":0}`)
+	}))
+	defer web.Close()
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get(sharePermHeader))
+	}))
+	defer backend.Close()
+
+	s, table := newTestServer(t, strings.TrimPrefix(web.URL, "http://"))
+	table.Set("abc", Endpoint{Addr: strings.TrimPrefix(backend.URL, "http://"), Uid: "u"})
+	do := func(method, target, cookie string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if cookie != "" {
+			req.AddCookie(&http.Cookie{Name: shareCookieName, Value: cookie})
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// 通过链接访问时记录访问, 写入cookie并重定向到去掉令牌的地址
+	rec := do(http.MethodGet, "https://abc.ws.test/?share_token=full-token&folder=%2Fhome", "")
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/?folder=%2Fhome" {
+		t.Fatalf("share link: got %d, location %q", rec.Code, rec.Header().Get("Location"))
+	}
+	// cookie不设置Domain, 只发送给当前的工作空间
+	if c := rec.Result().Cookies(); len(c) != 1 || c[0].Value != "full-token" || c[0].Path != "/" || c[0].Domain != "" {
+		t.Fatalf("unexpected cookies %v", c)
+	}
+	if audits.Load() != 1 {
+		t.Fatalf("want 1 audit, got %d", audits.Load())
+	}
+
+	if rec = do(http.MethodGet, "https://abc.ws.test/", "full-token"); rec.Code != http.StatusOK || rec.Body.String() != "full" {
+		t.Fatalf("full GET: got %d %q", rec.Code, rec.Body.String())
+	}
+	if rec = do(http.MethodPost, "https://abc.ws.test/", "full-token"); rec.Code != http.StatusOK || rec.Body.String() != "full" {
+		t.Fatalf("full POST: got %d %q", rec.Code, rec.Body.String())
+	}
+	if rec = do(http.MethodGet, "https://abc.ws.test/", "revoked-token"); rec.Code != http.StatusForbidden {
+		t.Fatalf("invalid token: got %d", rec.Code)
+	}
+	if audits.Load() != 1 {
+		t.Fatalf("cookie access should not be audited, got %d", audits.Load())
+	}
+}
+
+func TestProxyAuth(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer backend.Close()
+
+	web := newTestWebserver(t)
+	s, table := newTestServer(t, web.addr)
+	table.Set("abc", Endpoint{Addr: strings.TrimPrefix(backend.URL, "http://"), Uid: "u"})
+	do := func(target, accept string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// 未登录的浏览器重定向到登录页, 其它请求返回401, 未注册的sid同样需要登录
+	rec := do("https://abc.ws.test/?folder=%2Fhome", "text/html,*/*", nil)
+	if want := testLoginUrl + "?redirect=https%3A%2F%2Fabc.ws.test%2F%3Ffolder%3D%252Fhome"; rec.Code != http.StatusFound || rec.Header().Get("Location") != want {
+		t.Fatalf("browser: got %d, location %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if rec = do("https://abc.ws.test/static/a.js", "*/*", nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("no session: got %d", rec.Code)
+	}
+	if rec = do("https://unknown.ws.test/", "*/*", nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unknown sid: got %d", rec.Code)
+	}
+
+	// 票据换取会话cookie并重定向到去掉票据的地址
+	ticket, _ := encrypt.CreateWorkspaceToken(testWorkspaceKey, encrypt.WorkspaceTicket, 1, "u", "abc", time.Minute)
+	rec = do("https://abc.ws.test/?ticket="+ticket+"&folder=%2Fhome", "text/html", nil)
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/?folder=%2Fhome" {
+		t.Fatalf("ticket: got %d, location %q", rec.Code, rec.Header().Get("Location"))
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != sessionCookieName || cookies[0].Path != "/" || cookies[0].Domain != "" {
+		t.Fatalf("unexpected cookies %v", cookies)
+	}
+	if rec = do("https://abc.ws.test/", "text/html", cookies[0]); rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+		t.Fatalf("session: got %d %q", rec.Code, rec.Body.String())
+	}
+
+	// 其它工作空间的票据和会话, 以及其它所有者签发的票据都不能访问
+	if rec = do("https://abc.ws.test/", "*/*", testSession(t, "u", "other")); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("other sid session: got %d", rec.Code)
+	}
+	if rec = do("https://abc.ws.test/", "*/*", testSession(t, "x", "abc")); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("other owner session: got %d", rec.Code)
+	}
+	if rec = do("https://abc.ws.test/?ticket="+cookies[0].Value, "*/*", nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("session as ticket: got %d", rec.Code)
+	}
+	// 其它密钥签发的会话不能访问
+	forged, _ := encrypt.CreateWorkspaceToken([]byte("other-key"), encrypt.WorkspaceSession, 1, "u", "abc", time.Minute)
+	if rec = do("https://abc.ws.test/", "*/*", &http.Cookie{Name: sessionCookieName, Value: forged}); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("forged session: got %d", rec.Code)
+	}
+
+	// 会话的检查结果缓存30秒, 用户失去访问权限后未缓存的会话和票据不能访问
+	before := web.verifies.Load()
+	if rec = do("https://abc.ws.test/", "*/*", cookies[0]); rec.Code != http.StatusOK {
+		t.Fatalf("cached session: got %d", rec.Code)
+	}
+	if web.verifies.Load() != before {
+		t.Fatalf("session should be cached, got %d verifies", web.verifies.Load()-before)
+	}
+	web.revoked.Store("u", true)
+	revoked, _ := encrypt.CreateWorkspaceToken(testWorkspaceKey, encrypt.WorkspaceSession, 2, "u", "abc", time.Minute)
+	if rec = do("https://abc.ws.test/", "*/*", &http.Cookie{Name: sessionCookieName, Value: revoked}); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("revoked session: got %d", rec.Code)
+	}
+	if rec = do("https://abc.ws.test/?ticket="+ticket, "*/*", nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("revoked ticket: got %d", rec.Code)
+	}
+}
@@ -0,0 +1,111 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// 会话的验证结果的缓存时间, 用户被禁用或者失去工作空间的访问权限后, 会话在缓存过期后失效
+	sessionCacheTTL = 30 * time.Second
+	// 缓存超过该数量时清理过期的验证结果
+	sessionCacheSweepSize = 10000
+)
+
+var errSessionInvalid = errors.New("workspace session invalid")
+
+// sessionVerifier 通过webserver的/internal/workspace/verify验证票据和会话, 与proxy.lua的行为相同
+// 本地验证签名只能过滤伪造的令牌, 访问权限由webserver检查
+type sessionVerifier struct {
+	client *http.Client
+	url    string
+	// webserver验证内部接口调用者的令牌
+	token string
+
+	mu    sync.Mutex
+	cache map[string]time.Time
+}
+
+func newSessionVerifier(webAddr, token string) *sessionVerifier {
+	return &sessionVerifier{
+		client: &http.Client{Timeout: 5 * time.Second},
+		url:    "http://" + webAddr + "/internal/workspace/verify",
+		token:  token,
+		cache:  make(map[string]time.Time),
+	}
+}
+
+// Redeem 使用票据换取访问sid的会话, 返回会话和会话的过期时间, owner为注册的工作空间所有者
+func (v *sessionVerifier) Redeem(ctx context.Context, ticket, sid, owner string) (string, time.Time, error) {
+	return v.verify(ctx, url.Values{"ticket": {ticket}, "sid": {sid}, "uid": {owner}})
+}
+
+// Verify 验证访问sid的会话, 验证结果缓存30秒, 不超过会话的有效期
+func (v *sessionVerifier) Verify(ctx context.Context, session, sid, owner string) error {
+	key := sid + ":" + owner + ":" + session
+	now := time.Now()
+	v.mu.Lock()
+	expire, ok := v.cache[key]
+	v.mu.Unlock()
+	if ok && now.Before(expire) {
+		return nil
+	}
+
+	_, sessionExpire, err := v.verify(ctx, url.Values{"session": {session}, "sid": {sid}, "uid": {owner}})
+	if err != nil {
+		return err
+	}
+
+	expire = now.Add(sessionCacheTTL)
+	if sessionExpire.Before(expire) {
+		expire = sessionExpire
+	}
+	v.mu.Lock()
+	if len(v.cache) >= sessionCacheSweepSize {
+		for k, e := range v.cache {
+			if !now.Before(e) {
+				delete(v.cache, k)
+			}
+		}
+	}
+	v.cache[key] = expire
+	v.mu.Unlock()
+
+	return nil
+}
+
+func (v *sessionVerifier) verify(ctx context.Context, query url.Values) (string, time.Time, error) {
+	ctx, cancel := context.WithTimeout(ctx, v.client.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.url+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set(endpointTokenKey, v.token)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", time.Time{}, errSessionInvalid
+	}
+
+	var body struct {
+		Data struct {
+			Session    string `json:"session"`
+			ExpireTime int64  `json:"expire_time"`
+		} `json:"data"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Data.ExpireTime == 0 || body.Data.Session == "" {
+		return "", time.Time{}, errSessionInvalid
+	}
+
+	return body.Data.Session, time.Unix(body.Data.ExpireTime, 0), nil
+}
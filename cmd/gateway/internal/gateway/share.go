@@ -0,0 +1,112 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// 分享令牌的验证结果的缓存时间, 撤销的链接在缓存过期后失效
+	shareCacheTTL = 30 * time.Second
+	// 缓存超过该数量时清理过期的验证结果
+	shareCacheSweepSize = 10000
+)
+
+var errShareForbidden = errors.New("share token forbidden")
+
+type shareEntry struct {
+	permission string
+	expire     time.Time
+}
+
+// shareVerifier 通过webserver的/internal/share/verify验证分享令牌, 与proxy.lua的行为相同
+type shareVerifier struct {
+	client *http.Client
+	url    string
+	// webserver验证内部接口调用者的令牌
+	token string
+
+	mu    sync.Mutex
+	cache map[string]shareEntry
+}
+
+func newShareVerifier(webAddr, token string) *shareVerifier {
+	return &shareVerifier{
+		client: &http.Client{Timeout: 5 * time.Second},
+		url:    "http://" + webAddr + "/internal/share/verify",
+		token:  token,
+		cache:  make(map[string]shareEntry),
+	}
+}
+
+// Verify 验证访问sid的分享令牌, 返回分享的权限, audit为true时webserver记录访问
+// 访问者的ip和User-Agent从r中转发给webserver
+func (v *shareVerifier) Verify(r *http.Request, token, sid string, audit bool) (string, error) {
+	key := sid + ":" + token
+	now := time.Now()
+	if !audit {
+		v.mu.Lock()
+		entry, ok := v.cache[key]
+		v.mu.Unlock()
+		if ok && now.Before(entry.expire) {
+			return entry.permission, nil
+		}
+	}
+
+	query := url.Values{"token": {token}, "sid": {sid}, "audit": {"0"}}
+	if audit {
+		query.Set("audit", "1")
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), v.client.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.url+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set(endpointTokenKey, v.token)
+	req.Header.Set("User-Agent", r.UserAgent())
+	req.Header.Set("X-Real-IP", clientIP(r))
+	req.Header.Set("X-Forwarded-For", clientIP(r))
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errShareForbidden
+	}
+
+	var body struct {
+		Data struct {
+			Permission string `json:"permission"`
+			ExpireTime int64  `json:"expire_time"`
+		} `json:"data"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Data.Permission == "" {
+		return "", errShareForbidden
+	}
+
+	// 缓存30秒, 不超过令牌的有效期
+	expire := now.Add(shareCacheTTL)
+	if tokenExpire := time.Unix(body.Data.ExpireTime, 0); tokenExpire.Before(expire) {
+		expire = tokenExpire
+	}
+	v.mu.Lock()
+	if len(v.cache) >= shareCacheSweepSize {
+		for k, e := range v.cache {
+			if !now.Before(e.expire) {
+				delete(v.cache, k)
+			}
+		}
+	}
+	v.cache[key] = shareEntry{permission: body.Data.Permission, expire: expire}
+	v.mu.Unlock()
+
+	return body.Data.Permission, nil
+}
@@ -6,13 +6,17 @@ import (
 	"errors"
 	"flag"
 	"log/slog"
+	"net"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"runtime"
 	"strconv"
+	"strings"
 	"syscall"
 
+	"github.com/go-redis/redis/v8"
+	"github.com/mangohow/cloud-ide/cmd/gateway/internal/gateway"
 	"github.com/mangohow/cloud-ide/pkg/nginx"
 	"github.com/mangohow/cloud-ide/pkg/tmpl"
 	_ "go.uber.org/automaxprocs"
@@ -29,6 +33,20 @@ var (
 	serverKey         string
 	webSvcName        string
 	webPort           int
+	mode              string
+	loginUrl          string
+	workspaceDomain   string
+	redisAddr         string
+	redisPassword     string
+	redisDB           int
+	workspaceKey      string
+)
+
+const (
+	// 生成nginx配置并启动OpenResty
+	modeOpenResty = "openresty"
+	// 使用Go实现的网关, 不依赖OpenResty
+	modeNative = "native"
 )
 
 func main() {
@@ -41,6 +59,12 @@ func main() {
 		os.Exit(1)
 	}
 
+	if mode == modeNative {
+		runtime.GOMAXPROCS(gomaxprocs)
+		runNative(cfg)
+		return
+	}
+
 	// 生成nginx配置文件
 	err = tmpl.ApplyNginxConf(cfg, nginxConfPath)
 	if err != nil {
@@ -59,6 +83,47 @@ func main() {
 	nginx.StartNginx(nginxConfPath)
 }
 
+// runNative 使用Go实现的网关, 与OpenResty使用相同的配置
+// nginx的worker数量和共享内存大小不再使用, 最大连接数为workers * conns-per-worker
+func runNative(cfg *tmpl.Config) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	go signalHandler(cancelFunc)
+
+	var endpoints gateway.EndpointTable = gateway.NewMemoryTable()
+	if cfg.RedisHost != "" {
+		client := redis.NewClient(&redis.Options{
+			Addr:     net.JoinHostPort(cfg.RedisHost, strconv.Itoa(cfg.RedisPort)),
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+		})
+		table, err := gateway.NewRedisTable(ctx, client)
+		if err != nil {
+			slog.Error("load endpoints from redis", "error", err)
+			os.Exit(1)
+		}
+		endpoints = table
+	}
+
+	server := gateway.NewServer(gateway.Config{
+		Addr:      ":443",
+		Token:     cfg.Token,
+		WebAddr:   net.JoinHostPort(cfg.WebServiceName, strconv.Itoa(cfg.WebPort)),
+		StaticDir: filepath.Join(filepath.Dir(nginxConfPath), "html"),
+		Debug:     cfg.Debug,
+		MaxConns:  cfg.WorkerProcess * cfg.WorkerConnections,
+		LoginUrl:  cfg.LoginUrl,
+
+		WorkspaceDomain: cfg.WorkspaceDomain,
+
+		WorkspaceKey: []byte(cfg.WorkspaceKey),
+	}, endpoints)
+
+	if err := server.ListenAndServeTLS(ctx, cfg.ServerCrt, cfg.ServerKey); err != nil {
+		slog.Error("run gateway", "error", err)
+		os.Exit(1)
+	}
+}
+
 func parseFlags(gomaxprocs int) (*tmpl.Config, error) {
 	flag.StringVar(&workerProcess, "workers", "1", "specify nginx worker_processes")
 	flag.IntVar(&workerConnections, "conns-per-worker", 1024, "specify nginx worker_connections")
@@ -70,8 +135,20 @@ func parseFlags(gomaxprocs int) (*tmpl.Config, error) {
 	flag.StringVar(&serverKey, "server-key", "", "specify ssl certificate key")
 	flag.StringVar(&webSvcName, "web-service-name", "cloud-ide-web-svc.cloud-ide.svc.cluster.local", "specify the service of the web to reverse proxy, fully qualified domain names must be written")
 	flag.IntVar(&webPort, "web-port", 8088, "specify the port of the web to reverse proxy")
+	flag.StringVar(&mode, "mode", modeOpenResty, "specify gateway mode [openresty, native], native does not need openresty")
+	flag.StringVar(&redisAddr, "redis-addr", "", "specify the redis address host:port to persist workspace endpoints, empty means shared dict only")
+	flag.StringVar(&redisPassword, "redis-password", "", "specify the redis password")
+	flag.IntVar(&redisDB, "redis-db", 0, "specify the redis db")
+	flag.StringVar(&loginUrl, "login-url", "https://tiantianai.co/cloud-ide/#/login", "specify the login page that unauthenticated browsers are redirected to, must be an absolute url because workspaces are served from their own domains")
+	flag.StringVar(&workspaceDomain, "workspace-domain", "ws.tiantianai.co", "specify the domain of workspaces, each workspace is served from <sid>.<domain>, the certificate must cover *.<domain>")
+	flag.StringVar(&workspaceKey, "workspace-key", os.Getenv("WORKSPACE_KEY"), "specify the key shared with the web to verify workspace sessions, defaults to $WORKSPACE_KEY, required by native mode")
 	flag.Parse()
 
+	if mode != modeOpenResty && mode != modeNative {
+		slog.Error("set mode", "error", "must be openresty or native")
+		return nil, errors.New("mode invalid")
+	}
+
 	cfg := &tmpl.Config{}
 
 	// TLS证书解析验证
@@ -129,6 +206,36 @@ func parseFlags(gomaxprocs int) (*tmpl.Config, error) {
 
 	cfg.WebServiceName = webSvcName
 	cfg.WebPort = webPort
+	cfg.LoginUrl = loginUrl
+
+	// 每个工作空间使用独立的源, 防止工作空间中的脚本访问其它工作空间
+	if workspaceDomain == "" || strings.HasPrefix(workspaceDomain, ".") {
+		slog.Error("set workspace domain", "error", "must be a domain like ws.example.com")
+		return nil, errors.New("workspace domain invalid")
+	}
+	cfg.WorkspaceDomain = workspaceDomain
+
+	// native模式在本地验证工作空间的票据和会话, 需要与webserver相同的密钥
+	if mode == modeNative && workspaceKey == "" {
+		slog.Error("must specify workspace key in native mode")
+		return nil, errors.New("must specify workspace key")
+	}
+	cfg.WorkspaceKey = workspaceKey
+
+	if redisAddr != "" {
+		host, port, err := net.SplitHostPort(redisAddr)
+		if err != nil {
+			slog.Error("set redis addr", "error", err)
+			return nil, err
+		}
+		cfg.RedisHost = host
+		if cfg.RedisPort, err = strconv.Atoi(port); err != nil {
+			slog.Error("set redis addr", "error", "port must be a number")
+			return nil, err
+		}
+		cfg.RedisPassword = redisPassword
+		cfg.RedisDB = redisDB
+	}
 
 	return cfg, nil
 }
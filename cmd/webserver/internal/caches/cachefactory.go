@@ -9,11 +9,16 @@ import (
 
 type cacheFactory struct {
 	caches map[reflect.Type]interface{}
-	lock   sync.Mutex
+	// 按名称保存可以重新加载的缓存, 收到失效通知时使用
+	reloaders map[string]reloader
+	lock      sync.Mutex
 }
 
 var (
-	factory = &cacheFactory{caches: make(map[reflect.Type]interface{})}
+	factory = &cacheFactory{
+		caches:    make(map[reflect.Type]interface{}),
+		reloaders: make(map[string]reloader),
+	}
 )
 
 func CacheFactory() *cacheFactory {
@@ -30,6 +35,7 @@ func (f *cacheFactory) TmplCache(dao *dao.SpaceTemplateDao) *TmplCache {
 
 	cache := newTmplCache(dao)
 	f.caches[t] = cache
+	f.reloaders[TmplCacheName] = cache
 
 	cache.LoadCache()
 
@@ -46,7 +52,23 @@ func (f *cacheFactory) SpecCache(dao *dao.SpaceTemplateDao) *SpecCache {
 
 	cache := newSpecCache(dao)
 	f.caches[t] = cache
+	f.reloaders[SpecCacheName] = cache
 	cache.LoadCache()
 
 	return cache
 }
+
+func (f *cacheFactory) UserStatusCache(dao *dao.UserDao) *UserStatusCache {
+	t := reflect.TypeOf(&UserStatusCache{})
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if c, ok := f.caches[t]; ok {
+		return c.(*UserStatusCache)
+	}
+
+	cache := newUserStatusCache(dao)
+	f.caches[t] = cache
+	f.reloaders[UserStatusCacheName] = cache
+
+	return cache
+}
@@ -0,0 +1,80 @@
+package caches
+
+import (
+	"context"
+	"time"
+
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao/rdis"
+	"github.com/mangohow/cloud-ide/pkg/logger"
+)
+
+// 缓存失效通知的redis频道, 所有webserver副本都订阅该频道
+const invalidationChannel = "cloud-ide:cache:invalidation"
+
+// 缓存名称, 作为失效通知的内容
+const (
+	TmplCacheName       = "tmpl"
+	SpecCacheName       = "spec"
+	UserStatusCacheName = "user-status"
+)
+
+type reloader interface {
+	Reload() error
+}
+
+// Invalidate 重新加载本副本的缓存, 并通知其它副本重新加载
+// 没有启用redis时其它副本只能等待定时刷新
+func (f *cacheFactory) Invalidate(name string) error {
+	if err := f.reload(name); err != nil {
+		return err
+	}
+
+	client := rdis.RedisInstance()
+	if client == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	return client.Publish(ctx, invalidationChannel, name).Err()
+}
+
+// Subscribe 订阅缓存失效通知, 直到ctx被取消
+// 连接断开时go-redis会自动重新订阅, 期间丢失的通知由定时刷新兜底
+func (f *cacheFactory) Subscribe(ctx context.Context) {
+	client := rdis.RedisInstance()
+	if client == nil {
+		return
+	}
+
+	pubsub := client.Subscribe(ctx, invalidationChannel)
+	go func() {
+		defer pubsub.Close()
+		ch := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				if err := f.reload(msg.Payload); err != nil {
+					logger.Logger().Errorf("reload cache %s error:%v", msg.Payload, err)
+				}
+			}
+		}
+	}()
+}
+
+func (f *cacheFactory) reload(name string) error {
+	f.lock.Lock()
+	r, ok := f.reloaders[name]
+	f.lock.Unlock()
+	// 本副本还没有使用该缓存
+	if !ok {
+		return nil
+	}
+
+	return r.Reload()
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
 	"github.com/mangohow/cloud-ide/pkg/cache"
+	"github.com/mangohow/cloud-ide/pkg/logger"
 )
 
 // 加载mysql中的SpaceSpec到内存中，数据量不大
@@ -22,42 +23,43 @@ func newSpecCache(dao *dao.SpaceTemplateDao) *SpecCache {
 		dao:   dao,
 	}
 
-	// 每隔一分钟刷新一次
+	// 每隔一分钟刷新一次, 修改规格时通过Invalidate立即刷新
 	go func() {
 		ticker := time.NewTicker(time.Minute)
 		defer ticker.Stop()
 		for {
 			<-ticker.C
-			s.refresh()
+			s.LoadCache()
 		}
 	}()
 
 	return s
 }
 
-func (c *SpecCache) refresh() {
-	specs, err := c.dao.GetAllSpec()
-	if err != nil {
-		return
-	}
-	m := make(map[string]interface{}, len(specs))
-	for i, _ := range specs {
-		m[strconv.Itoa(int(specs[i].Id))] = &specs[i]
+// LoadCache 加载失败时保留原有的缓存, 等待下一次刷新
+func (c *SpecCache) LoadCache() {
+	if err := c.Reload(); err != nil {
+		logger.Logger().Errorf("load spec cache error:%v", err)
 	}
-	c.cache.Replace(m)
 }
 
-func (c *SpecCache) LoadCache() {
+// Reload 重新从数据库加载规格
+// 已删除的规格也会被加载, 使用该规格的工作空间仍然可以启动
+func (c *SpecCache) Reload() error {
 	specs, err := c.dao.GetAllSpec()
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	// 巨坑
-	for i, _ := range specs {
+	m := make(map[string]interface{}, len(specs))
+	for i := range specs {
 		spec := specs[i]
-		c.cache.Set(strconv.Itoa(int(spec.Id)), &spec)
+		m[strconv.Itoa(int(spec.Id))] = &spec
 	}
+	c.cache.Replace(m)
+
+	return nil
 }
 
 func (c *SpecCache) Get(key uint32) *model.SpaceSpec {
@@ -71,13 +73,17 @@ func (c *SpecCache) Get(key uint32) *model.SpaceSpec {
 	return &spec
 }
 
+// GetAll 获取所有可用的规格
 func (c *SpecCache) GetAll() []*model.SpaceSpec {
 	all := c.cache.GetAll()
-	items := make([]*model.SpaceSpec, len(all))
+	items := make([]*model.SpaceSpec, 0, len(all))
 	for i := 0; i < len(all); i++ {
 		item := all[i].(*model.SpaceSpec)
+		if item.Status != dao.TmplUsing {
+			continue
+		}
 		s := *item
-		items[i] = &s
+		items = append(items, &s)
 	}
 
 	return items
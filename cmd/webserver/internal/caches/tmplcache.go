@@ -6,6 +6,7 @@ import (
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
 	"github.com/mangohow/cloud-ide/pkg/cache"
+	"github.com/mangohow/cloud-ide/pkg/logger"
 )
 
 // TmplCache 加载mysql中的SpaceTemplate到内存中，数据量不大
@@ -20,7 +21,7 @@ func newTmplCache(dao *dao.SpaceTemplateDao) *TmplCache {
 		dao:   dao,
 	}
 
-	// 每隔一分钟刷新一次缓存
+	// 每隔一分钟刷新一次缓存, 修改模板时通过Invalidate立即刷新
 	go func() {
 		ticker := time.NewTicker(time.Minute)
 		defer ticker.Stop()
@@ -38,30 +39,41 @@ const (
 	KindsKey = "kinds"
 )
 
+// LoadCache 加载失败时保留原有的缓存, 等待下一次刷新
 func (t *TmplCache) LoadCache() {
-	tmpls, err := t.dao.GetAllUsingTmpl()
+	if err := t.Reload(); err != nil {
+		logger.Logger().Errorf("load template cache error:%v", err)
+	}
+}
+
+// Reload 重新从数据库加载模板, 模板被修改后调用
+// 已下架的模板也会被加载, 使用该模板创建的工作空间仍然可以启动
+func (t *TmplCache) Reload() error {
+	tmpls, err := t.dao.GetAllTmpl()
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	tpls := make(map[uint32]*model.SpaceTemplate, len(tmpls))
+	kinds, err := t.dao.GetAllTmplKind()
+	if err != nil {
+		return err
+	}
 
+	tpls := make(map[uint32]*model.SpaceTemplate, len(tmpls))
 	for i := 0; i < len(tmpls); i++ {
 		tp := tmpls[i]
 		tpls[tp.Id] = &tp
 	}
 	t.cache.Set(TmplsKey, tpls)
 
-	kinds, err := t.dao.GetAllTmplKind()
-	if err != nil {
-		panic(err)
-	}
 	kds := make(map[uint32]*model.TmplKind, len(kinds))
 	for i := 0; i < len(kinds); i++ {
 		kd := kinds[i]
 		kds[kd.Id] = &kd
 	}
 	t.cache.Set(KindsKey, kds)
+
+	return nil
 }
 
 func (t *TmplCache) GetTmpl(key uint32) *model.SpaceTemplate {
@@ -75,7 +87,11 @@ func (t *TmplCache) GetTmpl(key uint32) *model.SpaceTemplate {
 		return nil
 	}
 
-	tmpl := *(tps[key])
+	tp, ok := tps[key]
+	if !ok {
+		return nil
+	}
+	tmpl := *tp
 	return &tmpl
 }
 
@@ -87,13 +103,14 @@ func (t *TmplCache) GetAllTmpl() []*model.SpaceTemplate {
 
 	items := get.(map[uint32]*model.SpaceTemplate)
 
-	// 拷贝一份
-	tmpls := make([]*model.SpaceTemplate, len(items))
-	i := 0
+	// 拷贝一份, 只返回可用的模板
+	tmpls := make([]*model.SpaceTemplate, 0, len(items))
 	for _, v := range items {
+		if v.Status != dao.TmplUsing {
+			continue
+		}
 		p := *v
-		tmpls[i] = &p
-		i++
+		tmpls = append(tmpls, &p)
 	}
 
 	return tmpls
@@ -106,11 +123,14 @@ func (t *TmplCache) GetAllKinds() []*model.TmplKind {
 	}
 	items := get.(map[uint32]*model.TmplKind)
 
-	kinds := make([]*model.TmplKind, len(items))
-	i := 0
+	// 只返回可用的类别
+	kinds := make([]*model.TmplKind, 0, len(items))
 	for _, v := range items {
+		if v.Status != dao.TmplUsing {
+			continue
+		}
 		k := *v
-		kinds[i] = &k
+		kinds = append(kinds, &k)
 	}
 
 	return kinds
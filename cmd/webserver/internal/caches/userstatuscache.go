@@ -0,0 +1,93 @@
+package caches
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/code"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao"
+	"github.com/mangohow/cloud-ide/pkg/logger"
+)
+
+// userStatusTTL 用户状态的缓存时间, 没有启用redis时禁用用户最多经过该时间后生效
+const userStatusTTL = time.Second * 30
+
+type userStatusItem struct {
+	active bool
+	expire time.Time
+}
+
+// UserStatusCache 缓存用户是否可以继续使用已签发的令牌, 每个请求都需要检查, 不能每次都查询数据库
+// 管理员修改用户状态时通过Invalidate清空所有副本的缓存
+type UserStatusCache struct {
+	dao   *dao.UserDao
+	lock  sync.RWMutex
+	items map[uint32]userStatusItem
+}
+
+func newUserStatusCache(dao *dao.UserDao) *UserStatusCache {
+	c := &UserStatusCache{
+		dao:   dao,
+		items: make(map[uint32]userStatusItem),
+	}
+
+	// 定期清理过期的用户, 防止缓存一直增长
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			<-ticker.C
+			c.evict()
+		}
+	}()
+
+	return c
+}
+
+// Active 检查用户是否为正常状态, 被禁用、删除或不存在的用户返回false, 查询数据库失败时返回错误
+func (c *UserStatusCache) Active(id uint32) (bool, error) {
+	now := time.Now()
+	c.lock.RLock()
+	item, ok := c.items[id]
+	c.lock.RUnlock()
+	if ok && now.Before(item.expire) {
+		return item.active, nil
+	}
+
+	_, status, err := c.dao.FindRoleAndStatusById(id)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		logger.Logger().Warnf("find user status error:%v, id:%d", err, id)
+		return false, err
+	}
+	active := code.UserStatus(status) == code.StatusNormal
+
+	c.lock.Lock()
+	c.items[id] = userStatusItem{active: active, expire: now.Add(userStatusTTL)}
+	c.lock.Unlock()
+
+	return active, nil
+}
+
+// Reload 清空缓存, 之后的请求重新查询数据库
+func (c *UserStatusCache) Reload() error {
+	c.lock.Lock()
+	c.items = make(map[uint32]userStatusItem)
+	c.lock.Unlock()
+
+	return nil
+}
+
+func (c *UserStatusCache) evict() {
+	now := time.Now()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for id, item := range c.items {
+		if !now.Before(item.expire) {
+			delete(c.items, id)
+		}
+	}
+}
@@ -59,6 +59,85 @@ const (
 	PaymentFailed
 	PaymentCallbackSuccess
 	PaymentCallbackFailed
+
+	// 快照相关错误码
+	SnapshotCreateFailed
+	SnapshotReachMaxCount
+	SnapshotNotFound
+	SnapshotNotReady
+	SnapshotNotSupported
+	SnapshotRestoreFailed
+	SnapshotSpaceIsRunning
+
+	// 修改规格相关错误码
+	SpaceSpecModifyFailed
+	SpaceSpecShrinkNotAllowed
+
+	// 密钥相关错误码
+	SecretCreateFailed
+	SecretUpdateFailed
+	SecretDeleteFailed
+	SecretNotFound
+	SecretNameDuplicate
+	SecretReachMaxCount
+	SecretInvalid
+	SecretNotConfigured
+
+	// 管理员相关错误码
+	LoginUserDisabled
+	AdminPermissionDenied
+	AdminUserNotFound
+	AdminOperationFailed
+	AdminCannotModifySelf
+	AdminTemplateNotFound
+	AdminTemplateInvalid
+	AdminKindNotFound
+	AdminKindInvalid
+	AdminKindInUse
+	AdminSpecNotFound
+	AdminSpecInvalid
+
+	// 配额相关错误码
+	QuotaSpecNotAllowed
+	QuotaStorageExceeded
+	QuotaRuntimeExceeded
+	QuotaExceeded
+
+	// 订单相关错误码
+	OrderNotFound
+	OrderRefundNotAllowed
+	OrderRefundFailed
+
+	// 收据相关错误码
+	InvoiceNotFound
+	InvoiceNoEmail
+	InvoiceEmailFailed
+
+	// 优惠码相关错误码
+	CouponNotFound
+	CouponUnavailable
+	CouponNotApplicable
+	CouponLimitReached
+	CouponFirstPurchase
+	CouponInvalid
+	CouponDuplicate
+	OrgNotFound
+	OrgPermissionDenied
+	OrgInvalid
+	OrgReachMaxCount
+	OrgReachMaxMembers
+	OrgUserNotFound
+	OrgMemberExists
+	OrgMemberNotFound
+	OrgProductNotAllowed
+	ShareCreateFailed
+	ShareNotConfigured
+	ShareInvalid
+	ShareNotFound
+	ShareReachMaxCount
+	ShareTokenInvalid
+	SpaceTicketFailed
+	SpaceTicketInvalid
 )
 
 type UserStatus uint32
@@ -66,6 +145,7 @@ type UserStatus uint32
 const (
 	StatusNormal UserStatus = iota
 	StatusDeleted
+	StatusDisabled
 )
 
 var messageForCode = map[int]string{
@@ -114,6 +194,69 @@ var messageForCode = map[int]string{
 	PaymentFailed:               "支付失败",
 	PaymentCallbackSuccess:      "支付回调处理成功",
 	PaymentCallbackFailed:       "支付回调处理失败",
+	SnapshotCreateFailed:        "创建快照失败",
+	SnapshotReachMaxCount:       "达到最大快照数量,请删除其它快照后重试",
+	SnapshotNotFound:            "快照不存在",
+	SnapshotNotReady:            "快照尚未创建完成",
+	SnapshotNotSupported:        "当前存储不支持快照",
+	SnapshotRestoreFailed:       "恢复快照失败",
+	SnapshotSpaceIsRunning:      "请先停止工作空间",
+	SpaceSpecModifyFailed:       "修改规格失败",
+	SpaceSpecShrinkNotAllowed:   "存储空间不支持缩容,请选择存储更大的规格",
+	SecretCreateFailed:          "创建密钥失败",
+	SecretUpdateFailed:          "更新密钥失败",
+	SecretDeleteFailed:          "删除密钥失败",
+	SecretNotFound:              "密钥不存在",
+	SecretNameDuplicate:         "密钥名称重复",
+	SecretReachMaxCount:         "达到最大密钥数量,请删除其它密钥后重试",
+	SecretInvalid:               "密钥名称或值不合法",
+	SecretNotConfigured:         "服务端未配置密钥加密,暂不支持保存密钥",
+	LoginUserDisabled:           "该用户已被禁用",
+	AdminPermissionDenied:       "没有管理员权限",
+	AdminUserNotFound:           "用户不存在",
+	AdminOperationFailed:        "操作失败",
+	AdminCannotModifySelf:       "不能修改自己的账号状态",
+	AdminTemplateNotFound:       "模板不存在",
+	AdminTemplateInvalid:        "模板参数不合法",
+	AdminKindNotFound:           "模板类别不存在",
+	AdminKindInvalid:            "模板类别参数不合法",
+	AdminKindInUse:              "模板类别下还有可用的模板",
+	AdminSpecNotFound:           "规格不存在",
+	AdminSpecInvalid:            "规格参数不合法",
+	QuotaSpecNotAllowed:         "当前套餐不能使用该规格,请升级套餐后重试",
+	QuotaStorageExceeded:        "工作空间的存储总量超过了套餐限制,请删除其它工作空间后重试",
+	QuotaRuntimeExceeded:        "本月的运行时长已经用完,请升级套餐后重试",
+	QuotaExceeded:               "超过了套餐的资源限制,请停止其它工作空间后重试",
+	OrderNotFound:               "订单不存在",
+	OrderRefundNotAllowed:       "订单不能退款",
+	OrderRefundFailed:           "退款失败",
+	InvoiceNotFound:             "收据不存在",
+	InvoiceNoEmail:              "没有绑定邮箱",
+	InvoiceEmailFailed:          "收据发送失败,请稍后重试",
+	CouponNotFound:              "优惠码不存在",
+	CouponUnavailable:           "优惠码已停用或不在有效期内",
+	CouponNotApplicable:         "优惠码不能用于该产品",
+	CouponLimitReached:          "优惠码的使用次数已达上限",
+	CouponFirstPurchase:         "优惠码只能在首次购买时使用",
+	CouponInvalid:               "优惠码参数不合法",
+	CouponDuplicate:             "优惠码已存在",
+	OrgNotFound:                 "组织不存在",
+	OrgPermissionDenied:         "没有该组织的操作权限",
+	OrgInvalid:                  "组织参数不合法",
+	OrgReachMaxCount:            "达到最大组织创建上限",
+	OrgReachMaxMembers:          "达到组织的最大成员数量",
+	OrgUserNotFound:             "用户不存在",
+	OrgMemberExists:             "该用户已经是组织成员",
+	OrgMemberNotFound:           "组织成员不存在",
+	OrgProductNotAllowed:        "组织只能购买订阅套餐",
+	ShareCreateFailed:           "创建分享链接失败",
+	ShareNotConfigured:          "服务端未配置分享密钥,暂不支持分享",
+	ShareInvalid:                "分享链接的权限或有效期不合法",
+	ShareNotFound:               "分享链接不存在",
+	ShareReachMaxCount:          "达到最大分享链接数量,请撤销其它链接后重试",
+	ShareTokenInvalid:           "分享链接无效或已过期",
+	SpaceTicketFailed:           "获取工作空间访问票据失败",
+	SpaceTicketInvalid:          "工作空间访问票据无效或已过期,请重新登录",
 }
 
 func GetMessage(code int) string {
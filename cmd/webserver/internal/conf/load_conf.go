@@ -1,6 +1,7 @@
 package conf
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -19,6 +20,15 @@ var (
 	GrpcConfig   conf.GrpcConf
 	EmailConfig  conf.EmailConf
 	OAuthConfig  conf.OAuthConf
+	SecretConfig conf.SecretConf
+	// 每个套餐的配额, key为free或者订阅类型
+	QuotaConfig     map[string]conf.QuotaConf
+	MeteringConfig  conf.MeteringConf
+	SchedulerConfig conf.SchedulerConf
+	PaymentConfig   conf.PaymentConf
+	InvoiceConfig   conf.InvoiceConf
+	ShareConfig     conf.ShareConf
+	WorkspaceConfig conf.WorkspaceConf
 )
 
 func LoadConf() error {
@@ -52,6 +62,16 @@ func LoadConf() error {
 	initGrpcConf()
 	initEmailConf()
 	initOAuthConf()
+	initSecretConf()
+	initQuotaConf()
+	initMeteringConf()
+	initSchedulerConf()
+	initPaymentConf()
+	initInvoiceConf()
+	initShareConf()
+	if err := initWorkspaceConf(); err != nil {
+		return err
+	}
 
 	parseFlags()
 
@@ -237,6 +257,159 @@ func initOAuthConf() {
 	}
 }
 
+func initSecretConf() {
+	SecretConfig = conf.SecretConf{
+		Key: viper.GetString("secret.key"),
+	}
+
+	// 从环境变量覆盖主密钥
+	if key := os.Getenv("SECRET_KEY"); key != "" {
+		SecretConfig.Key = key
+	}
+}
+
+// 套餐配额的默认值, 配置文件中的quota.<套餐>可以覆盖其中的字段
+func defaultQuotaConf() map[string]conf.QuotaConf {
+	return map[string]conf.QuotaConf{
+		"free":  {MaxSpaces: 2, MaxRunning: 1, SpecIds: []uint32{4}, MaxStorage: "8Gi", MonthlyHours: 30},
+		"day":   {MaxSpaces: 5, MaxRunning: 1, MaxStorage: "64Gi"},
+		"week":  {MaxSpaces: 10, MaxRunning: 2, MaxStorage: "128Gi"},
+		"month": {MaxSpaces: 10, MaxRunning: 3, MaxStorage: "256Gi"},
+		// 有预付费余额的普通用户
+		"credit": {MaxSpaces: 10, MaxRunning: 2, MaxStorage: "128Gi"},
+	}
+}
+
+func initQuotaConf() {
+	QuotaConfig = defaultQuotaConf()
+	for name, quota := range QuotaConfig {
+		key := "quota." + name
+		if !viper.IsSet(key) {
+			continue
+		}
+		if err := viper.UnmarshalKey(key, &quota); err != nil {
+			fmt.Printf("[WARN] parse %s config error: %v\n", key, err)
+			continue
+		}
+		QuotaConfig[name] = quota
+	}
+}
+
+func initMeteringConf() {
+	MeteringConfig = conf.MeteringConf{
+		CpuCoreHourPrice: viper.GetFloat64("metering.cpuCoreHourPrice"),
+		MemGbHourPrice:   viper.GetFloat64("metering.memGbHourPrice"),
+	}
+
+	// 设置默认值
+	if !viper.IsSet("metering.cpuCoreHourPrice") {
+		MeteringConfig.CpuCoreHourPrice = 10
+	}
+	if !viper.IsSet("metering.memGbHourPrice") {
+		MeteringConfig.MemGbHourPrice = 5
+	}
+}
+
+func initSchedulerConf() {
+	SchedulerConfig = conf.SchedulerConf{
+		ExpireSpec:     "*/5 * * * *",
+		RemindSpec:     "0 10 * * *",
+		RemindDays:     3,
+		StopLapsedSpec: "*/5 * * * *",
+		CloseOrderSpec: "*/5 * * * *",
+		MeteringSpec:   "* * * * *",
+	}
+	if viper.IsSet("scheduler") {
+		if err := viper.UnmarshalKey("scheduler", &SchedulerConfig); err != nil {
+			fmt.Printf("[WARN] parse scheduler config error: %v\n", err)
+		}
+	}
+}
+
+func initPaymentConf() {
+	PaymentConfig = conf.PaymentConf{
+		Gateway:   "ouyun",
+		NotifyUrl: "https://tiantianai.co/api/payment/callback",
+		ReturnUrl: "https://tiantianai.co/idea/#/payment",
+		OrderTTL:  30,
+		Ouyun: conf.OuyunConf{
+			Url: "https://pay.ouyun.cc",
+		},
+	}
+	if viper.IsSet("payment") {
+		if err := viper.UnmarshalKey("payment", &PaymentConfig); err != nil {
+			fmt.Printf("[WARN] parse payment config error: %v\n", err)
+		}
+	}
+
+	// 从环境变量覆盖商户信息
+	if pid := os.Getenv("OUYUN_PID"); pid != "" {
+		PaymentConfig.Ouyun.Pid = pid
+	}
+	if key := os.Getenv("OUYUN_KEY"); key != "" {
+		PaymentConfig.Ouyun.Key = key
+	}
+}
+
+func initInvoiceConf() {
+	InvoiceConfig = conf.InvoiceConf{
+		Seller: "Cloud Code",
+	}
+	if viper.IsSet("invoice") {
+		if err := viper.UnmarshalKey("invoice", &InvoiceConfig); err != nil {
+			fmt.Printf("[WARN] parse invoice config error: %v\n", err)
+		}
+	}
+}
+
+func initShareConf() {
+	ShareConfig = conf.ShareConf{
+		MaxHours: 168,
+	}
+	if viper.IsSet("share") {
+		if err := viper.UnmarshalKey("share", &ShareConfig); err != nil {
+			fmt.Printf("[WARN] parse share config error: %v\n", err)
+		}
+	}
+
+	// 从环境变量覆盖签名密钥
+	if key := os.Getenv("SHARE_KEY"); key != "" {
+		ShareConfig.Key = key
+	}
+}
+
+// initWorkspaceConf 网关使用相同的密钥验证会话, 没有密钥时无法访问工作空间, 因此拒绝启动
+// 没有网关令牌时/internal接口无法认证, 同样拒绝启动
+func initWorkspaceConf() error {
+	WorkspaceConfig = conf.WorkspaceConf{
+		Key:          viper.GetString("workspace.key"),
+		GatewayToken: viper.GetString("workspace.gatewayToken"),
+		Domain:       "ws.tiantianai.co",
+	}
+	if viper.IsSet("workspace.domain") {
+		WorkspaceConfig.Domain = viper.GetString("workspace.domain")
+	}
+
+	// 从环境变量覆盖签名密钥和网关令牌
+	if key := os.Getenv("WORKSPACE_KEY"); key != "" {
+		WorkspaceConfig.Key = key
+	}
+	if token := os.Getenv("GATEWAY_TOKEN"); token != "" {
+		WorkspaceConfig.GatewayToken = token
+	}
+	if WorkspaceConfig.Key == "" {
+		return errors.New("workspace key is empty, set workspace.key or WORKSPACE_KEY")
+	}
+	if WorkspaceConfig.GatewayToken == "" {
+		return errors.New("gateway token is empty, set workspace.gatewayToken or GATEWAY_TOKEN")
+	}
+	if WorkspaceConfig.Domain == "" {
+		return errors.New("workspace domain is empty, set workspace.domain")
+	}
+
+	return nil
+}
+
 // 解析命令行参数
 func parseFlags() {
 	var (
@@ -0,0 +1,483 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/code"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model/reqtype"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/service"
+	"github.com/mangohow/cloud-ide/pkg/logger"
+	"github.com/mangohow/cloud-ide/pkg/serialize"
+	"github.com/mangohow/cloud-ide/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// AdminController 管理员接口, 路由需要经过Auth和AdminRequired中间件
+type AdminController struct {
+	logger       *logrus.Logger
+	adminService *service.AdminService
+	spaceService *service.CloudCodeService
+	tmplService  *service.SpaceTmplService
+	coupons      *service.CouponService
+}
+
+func NewAdminController() *AdminController {
+	return &AdminController{
+		logger:       logger.Logger(),
+		adminService: service.NewAdminService(),
+		spaceService: service.NewCloudCodeService(),
+		tmplService:  service.NewSpaceTmplService(),
+		coupons:      service.NewCouponService(),
+	}
+}
+
+// ListUsers 分页查询用户 method: GET path: /api/admin/users
+// Query Param: keyword page page_size
+func (a *AdminController) ListUsers(ctx *gin.Context) *serialize.Response {
+	page, pageSize := pageQuery(ctx)
+
+	users, total, err := a.adminService.ListUsers(ctx.Query("keyword"), page, pageSize)
+	if err != nil {
+		return serialize.Fail(code.QueryFailed)
+	}
+
+	return serialize.OkData(gin.H{
+		"list":  users,
+		"total": total,
+	})
+}
+
+// SetUserStatus 禁用或启用用户 method: PUT path: /api/admin/user/status
+// Request Param: reqtype.UserStatusOption
+func (a *AdminController) SetUserStatus(ctx *gin.Context) *serialize.Response {
+	var req reqtype.UserStatusOption
+	if err := ctx.ShouldBind(&req); err != nil {
+		a.logger.Warnf("bind param error:%v", err)
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	operatorId := utils.MustGet[uint32](ctx, "id")
+
+	err := a.adminService.SetUserDisabled(operatorId, req.Id, req.Disabled)
+	switch err {
+	case nil:
+		return serialize.Ok()
+	case service.ErrAdminModifySelf:
+		return serialize.Fail(code.AdminCannotModifySelf)
+	case service.ErrAdminUserNotFound:
+		return serialize.Fail(code.AdminUserNotFound)
+	default:
+		return serialize.Fail(code.AdminOperationFailed)
+	}
+}
+
+// GrantVip 赠送或延长用户的VIP method: POST path: /api/admin/user/vip
+// Request Param: reqtype.VipGrantOption
+func (a *AdminController) GrantVip(ctx *gin.Context) *serialize.Response {
+	var req reqtype.VipGrantOption
+	if err := ctx.ShouldBind(&req); err != nil {
+		a.logger.Warnf("bind param error:%v", err)
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	operatorId := utils.MustGet[uint32](ctx, "id")
+
+	err := a.adminService.GrantVip(operatorId, req.UserId, req.Days, req.Reason)
+	switch err {
+	case nil:
+		return serialize.Ok()
+	case service.ErrReqParamInvalid:
+		return serialize.Error(http.StatusBadRequest)
+	case service.ErrAdminUserNotFound:
+		return serialize.Fail(code.AdminUserNotFound)
+	default:
+		return serialize.Fail(code.AdminOperationFailed)
+	}
+}
+
+// RefundOrder 全额退款用户的订单 method: POST path: /api/admin/order/refund
+// Request Param: reqtype.OrderRefundOption
+func (a *AdminController) RefundOrder(ctx *gin.Context) *serialize.Response {
+	var req reqtype.OrderRefundOption
+	if err := ctx.ShouldBind(&req); err != nil {
+		a.logger.Warnf("bind param error:%v", err)
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	operatorId := utils.MustGet[uint32](ctx, "id")
+
+	err := a.adminService.RefundOrder(operatorId, req.OrderNo, req.Reason)
+	switch err {
+	case nil:
+		return serialize.Ok()
+	case service.ErrReqParamInvalid:
+		return serialize.Error(http.StatusBadRequest)
+	case service.ErrOrderNotFound:
+		return serialize.Fail(code.OrderNotFound)
+	case service.ErrRefundNotAllowed:
+		return serialize.Fail(code.OrderRefundNotAllowed)
+	case service.ErrRefundFailed:
+		return serialize.Fail(code.OrderRefundFailed)
+	default:
+		return serialize.Fail(code.AdminOperationFailed)
+	}
+}
+
+// ListCoupons 分页查询优惠码 method: GET path: /api/admin/coupons
+// Query Param: page page_size
+func (a *AdminController) ListCoupons(ctx *gin.Context) *serialize.Response {
+	page, pageSize := pageQuery(ctx)
+
+	coupons, total, err := a.coupons.List(page, pageSize)
+	if err != nil {
+		return serialize.Fail(code.QueryFailed)
+	}
+
+	return serialize.OkData(gin.H{
+		"list":  coupons,
+		"total": total,
+	})
+}
+
+// CreateCoupon 创建优惠码 method: POST path: /api/admin/coupon
+// Request Param: reqtype.CouponOption
+func (a *AdminController) CreateCoupon(ctx *gin.Context) *serialize.Response {
+	var req reqtype.CouponOption
+	if err := ctx.ShouldBind(&req); err != nil {
+		a.logger.Warnf("bind param error:%v", err)
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	coupon, err := a.coupons.Create(&req)
+	if err != nil {
+		if resp := couponErrorResponse(err); resp != nil {
+			return resp
+		}
+		return serialize.Fail(code.AdminOperationFailed)
+	}
+
+	return serialize.OkData(coupon)
+}
+
+// SetCouponStatus 启用或停用优惠码 method: PUT path: /api/admin/coupon/status
+// Request Param: reqtype.CouponStatusOption
+func (a *AdminController) SetCouponStatus(ctx *gin.Context) *serialize.Response {
+	var req reqtype.CouponStatusOption
+	if err := ctx.ShouldBind(&req); err != nil {
+		a.logger.Warnf("bind param error:%v", err)
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	err := a.coupons.SetStatus(req.Id, req.Status)
+	switch err {
+	case nil:
+		return serialize.Ok()
+	case service.ErrReqParamInvalid:
+		return serialize.Error(http.StatusBadRequest)
+	case service.ErrCouponNotFound:
+		return serialize.Fail(code.CouponNotFound)
+	default:
+		return serialize.Fail(code.AdminOperationFailed)
+	}
+}
+
+// SubscriptionStats 获取VIP用户统计 method: GET path: /api/admin/subscription/stats
+func (a *AdminController) SubscriptionStats(ctx *gin.Context) *serialize.Response {
+	stats, err := a.adminService.SubscriptionStats()
+	if err != nil {
+		return serialize.Fail(code.QueryFailed)
+	}
+
+	return serialize.OkData(stats)
+}
+
+// JobHistory 分页查询定时任务的执行记录 method: GET path: /api/admin/job/history
+// Query Param: name page page_size
+func (a *AdminController) JobHistory(ctx *gin.Context) *serialize.Response {
+	page, pageSize := pageQuery(ctx)
+	history, err := a.adminService.JobHistory(ctx.Query("name"), page, pageSize)
+	if err != nil {
+		return serialize.Fail(code.QueryFailed)
+	}
+
+	return serialize.OkData(history)
+}
+
+// ListWorkspaces 分页查询所有用户的工作空间以及运行状态 method: GET path: /api/admin/workspaces
+// Query Param: user_id page page_size
+func (a *AdminController) ListWorkspaces(ctx *gin.Context) *serialize.Response {
+	page, pageSize := pageQuery(ctx)
+
+	var userId uint64
+	if s := ctx.Query("user_id"); s != "" {
+		var err error
+		if userId, err = strconv.ParseUint(s, 10, 32); err != nil {
+			return serialize.Error(http.StatusBadRequest)
+		}
+	}
+
+	spaces, total, err := a.spaceService.ListAllWorkspaces(uint32(userId), page, pageSize)
+	if err != nil {
+		return serialize.Fail(code.QueryFailed)
+	}
+
+	return serialize.OkData(gin.H{
+		"list":  spaces,
+		"total": total,
+	})
+}
+
+// StopWorkspace 强制停止任意用户的工作空间 method: PUT path: /api/admin/workspace/stop
+// Request Param: reqtype.SpaceId
+func (a *AdminController) StopWorkspace(ctx *gin.Context) *serialize.Response {
+	var req reqtype.SpaceId
+	if err := ctx.ShouldBind(&req); err != nil {
+		a.logger.Warnf("bind param error:%v", err)
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	err := a.spaceService.ForceStopWorkspace(req.Id)
+	switch err {
+	case nil:
+		return serialize.OkCode(code.SpaceStopSuccess)
+	case service.ErrWorkSpaceNotExist:
+		return serialize.Fail(code.SpaceNotFound)
+	case service.ErrWorkSpaceIsNotRunning:
+		return serialize.Fail(code.SpaceStopIsNotRunning)
+	default:
+		return serialize.Fail(code.SpaceStopFailed)
+	}
+}
+
+// ListTemplates 获取所有模板, 包括已下架的模板 method: GET path: /api/admin/templates
+func (a *AdminController) ListTemplates(ctx *gin.Context) *serialize.Response {
+	tmpls, err := a.tmplService.GetAllTmplForAdmin()
+	if err != nil {
+		return serialize.Fail(code.QueryFailed)
+	}
+
+	return serialize.OkData(tmpls)
+}
+
+// SetTemplateStatus 上架或下架模板 method: PUT path: /api/admin/template/status
+// Request Param: reqtype.TmplStatusOption
+func (a *AdminController) SetTemplateStatus(ctx *gin.Context) *serialize.Response {
+	var req reqtype.TmplStatusOption
+	if err := ctx.ShouldBind(&req); err != nil {
+		a.logger.Warnf("bind param error:%v", err)
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	if err := a.tmplService.SetTmplStatus(req.Id, req.Status); err != nil {
+		return tmplErrorResponse(err)
+	}
+
+	return serialize.Ok()
+}
+
+// CreateTemplate 创建模板 method: POST path: /api/admin/template
+// Request Param: reqtype.TmplOption
+func (a *AdminController) CreateTemplate(ctx *gin.Context) *serialize.Response {
+	var req reqtype.TmplOption
+	if err := ctx.ShouldBind(&req); err != nil {
+		a.logger.Warnf("bind param error:%v", err)
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	tmpl, err := a.tmplService.CreateTmpl(&req)
+	if err != nil {
+		return tmplErrorResponse(err)
+	}
+
+	return serialize.OkData(tmpl)
+}
+
+// UpdateTemplate 修改模板 method: PUT path: /api/admin/template
+// Request Param: reqtype.TmplOption
+func (a *AdminController) UpdateTemplate(ctx *gin.Context) *serialize.Response {
+	var req reqtype.TmplOption
+	if err := ctx.ShouldBind(&req); err != nil {
+		a.logger.Warnf("bind param error:%v", err)
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	tmpl, err := a.tmplService.UpdateTmpl(&req)
+	if err != nil {
+		return tmplErrorResponse(err)
+	}
+
+	return serialize.OkData(tmpl)
+}
+
+// DeleteTemplate 删除模板 method: DELETE path: /api/admin/template
+// Query Param: id
+func (a *AdminController) DeleteTemplate(ctx *gin.Context) *serialize.Response {
+	id, err := strconv.ParseUint(ctx.Query("id"), 10, 32)
+	if err != nil {
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	if err = a.tmplService.DeleteTmpl(uint32(id)); err != nil {
+		return tmplErrorResponse(err)
+	}
+
+	return serialize.Ok()
+}
+
+// ListKinds 获取所有模板类别, 包括已删除的类别 method: GET path: /api/admin/kinds
+func (a *AdminController) ListKinds(ctx *gin.Context) *serialize.Response {
+	kinds, err := a.tmplService.GetAllKindForAdmin()
+	if err != nil {
+		return serialize.Fail(code.QueryFailed)
+	}
+
+	return serialize.OkData(kinds)
+}
+
+// CreateKind 创建模板类别 method: POST path: /api/admin/kind
+// Request Param: reqtype.TmplKindOption
+func (a *AdminController) CreateKind(ctx *gin.Context) *serialize.Response {
+	var req reqtype.TmplKindOption
+	if err := ctx.ShouldBind(&req); err != nil {
+		a.logger.Warnf("bind param error:%v", err)
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	kind, err := a.tmplService.CreateKind(&req)
+	if err != nil {
+		return tmplErrorResponse(err)
+	}
+
+	return serialize.OkData(kind)
+}
+
+// UpdateKind 修改模板类别的名称 method: PUT path: /api/admin/kind
+// Request Param: reqtype.TmplKindOption
+func (a *AdminController) UpdateKind(ctx *gin.Context) *serialize.Response {
+	var req reqtype.TmplKindOption
+	if err := ctx.ShouldBind(&req); err != nil {
+		a.logger.Warnf("bind param error:%v", err)
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	if err := a.tmplService.UpdateKind(&req); err != nil {
+		return tmplErrorResponse(err)
+	}
+
+	return serialize.Ok()
+}
+
+// DeleteKind 删除模板类别 method: DELETE path: /api/admin/kind
+// Query Param: id
+func (a *AdminController) DeleteKind(ctx *gin.Context) *serialize.Response {
+	id, err := strconv.ParseUint(ctx.Query("id"), 10, 32)
+	if err != nil {
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	if err = a.tmplService.DeleteKind(uint32(id)); err != nil {
+		return tmplErrorResponse(err)
+	}
+
+	return serialize.Ok()
+}
+
+// ListSpecs 获取所有规格, 包括已删除的规格, 不按VIP过滤 method: GET path: /api/admin/specs
+func (a *AdminController) ListSpecs(ctx *gin.Context) *serialize.Response {
+	specs, err := a.tmplService.GetAllSpecForAdmin()
+	if err != nil {
+		return serialize.Fail(code.QueryFailed)
+	}
+
+	return serialize.OkData(specs)
+}
+
+// CreateSpec 创建规格 method: POST path: /api/admin/spec
+// Request Param: reqtype.SpecOption
+func (a *AdminController) CreateSpec(ctx *gin.Context) *serialize.Response {
+	var req reqtype.SpecOption
+	if err := ctx.ShouldBind(&req); err != nil {
+		a.logger.Warnf("bind param error:%v", err)
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	spec, err := a.tmplService.CreateSpec(&req)
+	if err != nil {
+		return tmplErrorResponse(err)
+	}
+
+	return serialize.OkData(spec)
+}
+
+// UpdateSpec 修改规格, 存储规格不能修改 method: PUT path: /api/admin/spec
+// Request Param: reqtype.SpecOption
+func (a *AdminController) UpdateSpec(ctx *gin.Context) *serialize.Response {
+	var req reqtype.SpecOption
+	if err := ctx.ShouldBind(&req); err != nil {
+		a.logger.Warnf("bind param error:%v", err)
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	spec, err := a.tmplService.UpdateSpec(&req)
+	if err != nil {
+		return tmplErrorResponse(err)
+	}
+
+	return serialize.OkData(spec)
+}
+
+// DeleteSpec 删除规格 method: DELETE path: /api/admin/spec
+// Query Param: id
+func (a *AdminController) DeleteSpec(ctx *gin.Context) *serialize.Response {
+	id, err := strconv.ParseUint(ctx.Query("id"), 10, 32)
+	if err != nil {
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	if err = a.tmplService.DeleteSpec(uint32(id)); err != nil {
+		return tmplErrorResponse(err)
+	}
+
+	return serialize.Ok()
+}
+
+// tmplErrorResponse 将模板、类别和规格相关的错误转换为响应
+func tmplErrorResponse(err error) *serialize.Response {
+	switch err {
+	case service.ErrReqParamInvalid:
+		return serialize.Error(http.StatusBadRequest)
+	case service.ErrTmplNotFound:
+		return serialize.Fail(code.AdminTemplateNotFound)
+	case service.ErrTmplInvalid:
+		return serialize.Fail(code.AdminTemplateInvalid)
+	case service.ErrKindNotFound:
+		return serialize.Fail(code.AdminKindNotFound)
+	case service.ErrKindInvalid:
+		return serialize.Fail(code.AdminKindInvalid)
+	case service.ErrKindInUse:
+		return serialize.Fail(code.AdminKindInUse)
+	case service.ErrSpecNotFound:
+		return serialize.Fail(code.AdminSpecNotFound)
+	case service.ErrSpecInvalid:
+		return serialize.Fail(code.AdminSpecInvalid)
+	default:
+		return serialize.Fail(code.AdminOperationFailed)
+	}
+}
+
+// pageQuery 获取分页参数, 参数不合法时使用默认值
+func pageQuery(ctx *gin.Context) (page, pageSize int) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err = strconv.Atoi(ctx.DefaultQuery("page_size", "10"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	return page, pageSize
+}
@@ -1,12 +1,17 @@
 package controller
 
 import (
+	"context"
 	"errors"
+	"io"
 	"net/http"
 	"regexp"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/code"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model/reqtype"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/service"
 	"github.com/mangohow/cloud-ide/pkg/logger"
@@ -17,27 +22,26 @@ import (
 
 const (
 	// 普通用户限制常量
-	TestSpecId   = uint32(4) // 测试型规格ID
 	ClaudeTmplId = uint32(7) // Claude模板ID
 )
 
 var (
 	// 权限错误
-	ErrPermissionDeniedSpec = errors.New("普通用户只能创建测试型配置的工作空间，请升级为VIP用户使用其他配置")
+	ErrPermissionDeniedSpec = errors.New("当前套餐不能使用该规格的工作空间，请升级套餐后使用其他配置")
 	ErrPermissionDeniedTemplate = errors.New("Claude AI助手功能仅限VIP用户使用，请升级为VIP用户")
 )
 
 type CloudCodeController struct {
 	logger              *logrus.Logger
 	spaceService        *service.CloudCodeService
-	subscriptionService *service.SubscriptionService
+	quotaService        *service.QuotaService
 }
 
 func NewCloudCodeController() *CloudCodeController {
 	return &CloudCodeController{
 		logger:              logger.Logger(),
 		spaceService:        service.NewCloudCodeService(),
-		subscriptionService: service.NewSubscriptionService(),
+		quotaService:        service.NewQuotaService(),
 	}
 }
 
@@ -66,10 +70,24 @@ func (c *CloudCodeController) CreateSpace(ctx *gin.Context) *serialize.Response
 		return serialize.Fail(code.SpaceCreateNameDuplicate)
 	case service.ErrReachMaxSpaceCount:
 		return serialize.Fail(code.SpaceCreateReachMaxCount)
+	case service.ErrSpecNotAllowed:
+		return serialize.Fail(code.QuotaSpecNotAllowed)
+	case service.ErrStorageQuotaExceeded:
+		return serialize.Fail(code.QuotaStorageExceeded)
 	case service.ErrSpaceCreate:
 		return serialize.Fail(code.SpaceCreateFailed)
 	case service.ErrReqParamInvalid:
 		return serialize.Error(http.StatusBadRequest)
+	case service.ErrSecretNotFound:
+		return serialize.Fail(code.SecretNotFound)
+	case service.ErrSecretInvalid:
+		return serialize.Fail(code.SecretInvalid)
+	case service.ErrReachMaxSecretCount:
+		return serialize.Fail(code.SecretReachMaxCount)
+	case service.ErrSecretNotConfigured:
+		return serialize.Fail(code.SecretNotConfigured)
+	case service.ErrOrgNotFound, service.ErrOrgPermissionDenied:
+		return orgErrorResponse(err)
 	}
 
 	if err != nil {
@@ -114,22 +132,15 @@ func (c *CloudCodeController) creationCheck(ctx *gin.Context) (*reqtype.SpaceCre
 		return nil, errors.New("user id mismatch")
 	}
 
-	// 检查用户权限
-	isVip := c.subscriptionService.IsUserVip(req.UserId)
-	if !isVip {
-		// 普通用户权限限制（试用阶段开放Claude模板）
-		if req.SpaceSpecId != TestSpecId {
-			c.logger.Warnf("普通用户尝试使用非测试型规格: spec_id=%d, user_id=%d", req.SpaceSpecId, req.UserId)
-			return nil, ErrPermissionDeniedSpec
-		}
-		
-		// 试用阶段：普通用户也可以使用Claude模板
-		// if req.TmplId == ClaudeTmplId {
-		//	c.logger.Warnf("普通用户尝试使用Claude模板: tmpl_id=%d, user_id=%d", req.TmplId, req.UserId)
-		//	return nil, ErrPermissionDeniedTemplate
-		// }
+	// 检查用户套餐是否可以使用该规格
+	// 组织的工作空间使用组织的套餐, 由service检查
+	if req.OrgId == 0 && !c.quotaService.AllowSpec(req.UserId, req.SpaceSpecId) {
+		c.logger.Warnf("用户套餐不能使用该规格: spec_id=%d, user_id=%d", req.SpaceSpecId, req.UserId)
+		return nil, ErrPermissionDeniedSpec
 	}
 
+	// 试用阶段：普通用户也可以使用Claude模板, 不检查模板
+
 	return &req, nil
 }
 
@@ -156,18 +167,36 @@ func (c *CloudCodeController) CreateSpaceAndStart(ctx *gin.Context) *serialize.R
 		return serialize.Fail(code.SpaceCreateNameDuplicate)
 	case service.ErrReachMaxSpaceCount:
 		return serialize.Fail(code.SpaceCreateReachMaxCount)
+	case service.ErrSpecNotAllowed:
+		return serialize.Fail(code.QuotaSpecNotAllowed)
+	case service.ErrStorageQuotaExceeded:
+		return serialize.Fail(code.QuotaStorageExceeded)
 	case service.ErrSpaceCreate:
 		return serialize.Fail(code.SpaceCreateFailed)
 	case service.ErrSpaceStart:
 		return serialize.Fail(code.SpaceStartFailed)
 	case service.ErrOtherSpaceIsRunning:
 		return serialize.Fail(code.SpaceOtherSpaceIsRunning)
+	case service.ErrRuntimeQuotaExceeded:
+		return serialize.Fail(code.QuotaRuntimeExceeded)
+	case service.ErrQuotaExceeded:
+		return serialize.Fail(code.QuotaExceeded)
 	case service.ErrReqParamInvalid:
 		return serialize.Error(http.StatusBadRequest)
 	case service.ErrSpaceAlreadyExist:
 		return serialize.Fail(code.SpaceAlreadyExist)
 	case service.ErrResourceExhausted:
 		return serialize.Fail(code.ResourceExhausted)
+	case service.ErrSecretNotFound:
+		return serialize.Fail(code.SecretNotFound)
+	case service.ErrSecretInvalid:
+		return serialize.Fail(code.SecretInvalid)
+	case service.ErrReachMaxSecretCount:
+		return serialize.Fail(code.SecretReachMaxCount)
+	case service.ErrSecretNotConfigured:
+		return serialize.Fail(code.SecretNotConfigured)
+	case service.ErrOrgNotFound, service.ErrOrgPermissionDenied:
+		return orgErrorResponse(err)
 	}
 
 	if err != nil {
@@ -178,9 +207,9 @@ func (c *CloudCodeController) CreateSpaceAndStart(ctx *gin.Context) *serialize.R
 }
 
 // StartSpace 启动一个已存在的云空间 method: POST path: /api/workspace/start
-// request param: space id
+// request param: reqtype.SpaceStartOption
 func (c *CloudCodeController) StartSpace(ctx *gin.Context) *serialize.Response {
-	var req reqtype.SpaceId
+	var req reqtype.SpaceStartOption
 	err := ctx.ShouldBind(&req)
 	if err != nil {
 		c.logger.Warnf("bind param error:%v", err)
@@ -190,7 +219,7 @@ func (c *CloudCodeController) StartSpace(ctx *gin.Context) *serialize.Response {
 	userId := utils.MustGet[uint32](ctx, "id")
 	uid := utils.MustGet[string](ctx, "uid")
 
-	space, err := c.spaceService.StartWorkspace(req.Id, userId, uid)
+	space, err := c.spaceService.StartWorkspace(req.Id, userId, uid, req.Async, req.Secrets)
 	switch err {
 	case service.ErrWorkSpaceNotExist:
 		return serialize.Fail(code.SpaceStartNotExist)
@@ -198,8 +227,18 @@ func (c *CloudCodeController) StartSpace(ctx *gin.Context) *serialize.Response {
 		return serialize.Fail(code.SpaceStartFailed)
 	case service.ErrOtherSpaceIsRunning:
 		return serialize.Fail(code.SpaceOtherSpaceIsRunning)
+	case service.ErrRuntimeQuotaExceeded:
+		return serialize.Fail(code.QuotaRuntimeExceeded)
+	case service.ErrQuotaExceeded:
+		return serialize.Fail(code.QuotaExceeded)
 	case service.ErrSpaceNotFound:
 		return serialize.Fail(code.SpaceNotFound)
+	case service.ErrSpaceShrink:
+		return serialize.Fail(code.SpaceSpecShrinkNotAllowed)
+	case service.ErrSecretNotFound:
+		return serialize.Fail(code.SecretNotFound)
+	case service.ErrOrgNotFound, service.ErrOrgPermissionDenied:
+		return orgErrorResponse(err)
 	}
 
 	if err != nil {
@@ -227,6 +266,9 @@ func (c *CloudCodeController) StopSpace(ctx *gin.Context) *serialize.Response {
 		if err == service.ErrWorkSpaceIsNotRunning {
 			return serialize.Ok()
 		}
+		if err == service.ErrOrgPermissionDenied {
+			return orgErrorResponse(err)
+		}
 
 		return serialize.Fail(code.SpaceStopFailed)
 	}
@@ -254,6 +296,9 @@ func (c *CloudCodeController) DeleteSpace(ctx *gin.Context) *serialize.Response
 		if err == service.ErrWorkSpaceIsRunning {
 			return serialize.Fail(code.SpaceDeleteIsRunning)
 		}
+		if err == service.ErrOrgPermissionDenied {
+			return orgErrorResponse(err)
+		}
 
 		return serialize.Fail(code.SpaceDeleteFailed)
 	}
@@ -262,17 +307,201 @@ func (c *CloudCodeController) DeleteSpace(ctx *gin.Context) *serialize.Response
 }
 
 // ListSpace 获取所有创建的云空间 method: GET path: /api/workspace/list
-// Request param: id uid
+// Request param: org_id 可选, 查询组织的工作空间
 func (c *CloudCodeController) ListSpace(ctx *gin.Context) *serialize.Response {
+	var orgId uint64
+	if s := ctx.Query("org_id"); s != "" {
+		var err error
+		if orgId, err = strconv.ParseUint(s, 10, 32); err != nil {
+			return serialize.Error(http.StatusBadRequest)
+		}
+	}
+
+	userId := utils.MustGet[uint32](ctx, "id")
+	uid := utils.MustGet[string](ctx, "uid")
+
+	spaces, err := c.spaceService.ListWorkspace(userId, uint32(orgId), uid)
+	switch err {
+	case nil:
+		return serialize.OkData(spaces)
+	case service.ErrOrgNotFound, service.ErrOrgPermissionDenied:
+		return orgErrorResponse(err)
+	default:
+		return serialize.Fail(code.QueryFailed)
+	}
+}
+
+// SpaceAccess 查询用户访问工作空间的权限, 供网关检查访问者 method: GET path: /api/workspace/access
+// Request param: sid
+func (c *CloudCodeController) SpaceAccess(ctx *gin.Context) *serialize.Response {
+	sid := ctx.Query("sid")
+	if sid == "" {
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	userId := utils.MustGet[uint32](ctx, "id")
+	uid := utils.MustGet[string](ctx, "uid")
+
+	access, err := c.spaceService.SpaceAccess(sid, userId, uid)
+	switch err {
+	case nil:
+		return serialize.OkData(access)
+	case service.ErrOrgPermissionDenied:
+		return orgErrorResponse(err)
+	default:
+		return serialize.Fail(code.SpaceNotFound)
+	}
+}
+
+// CreateTicket 获取打开工作空间的票据 method: POST path: /api/workspace/ticket
+// Request param: reqtype.SpaceTicketOption
+// 前端打开工作空间时将票据放在ticket参数中, 网关验证后写入会话cookie
+func (c *CloudCodeController) CreateTicket(ctx *gin.Context) *serialize.Response {
+	var req reqtype.SpaceTicketOption
+	if err := ctx.ShouldBind(&req); err != nil || req.Sid == "" {
+		c.logger.Warnf("bind param error:%v", err)
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	userId := utils.MustGet[uint32](ctx, "id")
+	uid := utils.MustGet[string](ctx, "uid")
+
+	ticket, err := c.spaceService.CreateTicket(req.Sid, userId, uid)
+	switch err {
+	case nil:
+		return serialize.OkData(gin.H{"ticket": ticket})
+	case service.ErrWorkSpaceNotExist:
+		return serialize.Fail(code.SpaceNotFound)
+	case service.ErrOrgPermissionDenied:
+		return orgErrorResponse(err)
+	default:
+		return serialize.Fail(code.SpaceTicketFailed)
+	}
+}
+
+// VerifyTicket 网关在代理前验证访问者 method: GET path: /internal/workspace/verify
+// Request Param: sid uid ticket session, uid为网关中注册的工作空间所有者
+// 携带ticket时返回新的会话, 携带session时只验证会话, 无效时返回401
+func (c *CloudCodeController) VerifyTicket(ctx *gin.Context) *serialize.Response {
+	sid, uid := ctx.Query("sid"), ctx.Query("uid")
+	ticket, session := ctx.Query("ticket"), ctx.Query("session")
+	if sid == "" || (ticket == "" && session == "") {
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	var (
+		expire time.Time
+		err    error
+	)
+	if ticket != "" {
+		session, expire, err = c.spaceService.RedeemTicket(ticket, sid, uid)
+	} else {
+		expire, err = c.spaceService.VerifySession(session, sid, uid)
+	}
+	switch err {
+	case nil:
+		return serialize.OkData(gin.H{
+			"session":     session,
+			"expire_time": expire.Unix(),
+		})
+	case service.ErrSpaceTicketInvalid:
+		return serialize.NewResponse(http.StatusUnauthorized, code.SpaceTicketInvalid, nil, code.GetMessage(code.SpaceTicketInvalid))
+	default:
+		return serialize.Fail(code.SpaceTicketFailed)
+	}
+}
+
+// 工作空间进入这些阶段后不再推送事件
+var settledPhases = map[string]bool{
+	"Running": true,
+	"Failed":  true,
+	"Stopped": true,
+	"Deleted": true,
+}
+
+// CreateWatchTicket 获取订阅云空间事件的票据 method: POST path: /api/workspace/watch/ticket
+// Request Param: reqtype.SpaceId
+// 浏览器的EventSource无法设置请求头, 使用票据代替登录令牌建立SSE连接
+func (c *CloudCodeController) CreateWatchTicket(ctx *gin.Context) *serialize.Response {
+	var req reqtype.SpaceId
+	if err := ctx.ShouldBind(&req); err != nil || req.Id == 0 {
+		c.logger.Warnf("bind param error:%v", err)
+		return serialize.Error(http.StatusBadRequest)
+	}
+
 	userId := utils.MustGet[uint32](ctx, "id")
 	uid := utils.MustGet[string](ctx, "uid")
 
-	spaces, err := c.spaceService.ListWorkspace(userId, uid)
+	ticket, err := c.spaceService.CreateWatchTicket(req.Id, userId, uid)
+	switch err {
+	case nil:
+		return serialize.OkData(gin.H{"ticket": ticket})
+	case service.ErrWorkSpaceNotExist:
+		return serialize.Fail(code.SpaceNotFound)
+	case service.ErrOrgPermissionDenied:
+		return orgErrorResponse(err)
+	default:
+		return serialize.Fail(code.SpaceTicketFailed)
+	}
+}
+
+// WatchSpace 通过SSE推送云空间的启动进度 method: GET path: /api/workspace/watch
+// Request Param: id ticket, ticket为CreateWatchTicket签发的票据, 由WatchAuth验证
+// 每个事件为一个status消息, 工作空间启动完成、失败或停止后发送end消息并关闭连接
+func (c *CloudCodeController) WatchSpace(ctx *gin.Context) *serialize.Response {
+	id, err := strconv.ParseUint(ctx.Query("id"), 10, 32)
 	if err != nil {
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	userId := utils.MustGet[uint32](ctx, "id")
+	uid := utils.MustGet[string](ctx, "uid")
+
+	// 最多推送10分钟, 浏览器断开连接时请求的ctx会被取消
+	watchCtx, cancelFunc := context.WithTimeout(ctx.Request.Context(), time.Minute*10)
+	defer cancelFunc()
+	space, stream, err := c.spaceService.WatchWorkspace(watchCtx, uint32(id), userId, uid)
+	if err != nil {
+		if err == service.ErrWorkSpaceNotExist {
+			return serialize.Fail(code.SpaceNotFound)
+		}
 		return serialize.Fail(code.QueryFailed)
 	}
 
-	return serialize.OkData(spaces)
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	for {
+		ev, err := stream.Recv()
+		if err != nil {
+			if err != io.EOF && watchCtx.Err() == nil {
+				c.logger.Warnf("receive workspace event error:%v, sid:%s", err, space.Sid)
+			}
+			break
+		}
+
+		ctx.SSEvent("status", &model.SpaceEvent{
+			Id:      space.Id,
+			Sid:     ev.Sid,
+			Phase:   ev.Phase,
+			Stage:   ev.Stage,
+			Reason:  ev.Reason,
+			Message: ev.Message,
+			Time:    time.UnixMilli(ev.Timestamp),
+		})
+		ctx.Writer.Flush()
+
+		if settledPhases[ev.Phase] {
+			break
+		}
+	}
+
+	// 告诉浏览器不要重连
+	ctx.SSEvent("end", "")
+	ctx.Writer.Flush()
+
+	return nil
 }
 
 // ModifySpaceName 修改工作空间名称 method: POST path: /api/workspace/name
@@ -293,9 +522,46 @@ func (c *CloudCodeController) ModifySpaceName(ctx *gin.Context) *serialize.Respo
 	switch err {
 	case service.ErrNameDuplicate:
 		return serialize.Fail(code.SpaceCreateNameDuplicate)
+	case service.ErrOrgPermissionDenied:
+		return orgErrorResponse(err)
 	case nil:
 		return serialize.Ok()
 	default:
 		return serialize.Fail(code.SpaceNameModifyFailed)
 	}
 }
+
+// ModifySpaceSpec 修改工作空间规格 method: PUT path: /api/workspace/spec
+// Request Param: reqtype.SpaceSpecModifyOption
+func (c *CloudCodeController) ModifySpaceSpec(ctx *gin.Context) *serialize.Response {
+	var req reqtype.SpaceSpecModifyOption
+	err := ctx.ShouldBind(&req)
+	if err != nil {
+		c.logger.Warnf("bind req error:%v", err)
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	userId := utils.MustGet[uint32](ctx, "id")
+	uid := utils.MustGet[string](ctx, "uid")
+
+	// 订阅可能已经过期, 每次修改时由service重新检查套餐的配额
+	err = c.spaceService.ModifySpec(req.Id, req.SpecId, userId, uid)
+	switch err {
+	case service.ErrWorkSpaceNotExist:
+		return serialize.Fail(code.SpaceNotFound)
+	case service.ErrReqParamInvalid:
+		return serialize.Error(http.StatusBadRequest)
+	case service.ErrSpaceShrink:
+		return serialize.Fail(code.SpaceSpecShrinkNotAllowed)
+	case service.ErrSpecNotAllowed:
+		return serialize.NewResponse(http.StatusForbidden, code.QueryFailed, nil, ErrPermissionDeniedSpec.Error())
+	case service.ErrStorageQuotaExceeded:
+		return serialize.Fail(code.QuotaStorageExceeded)
+	case service.ErrOrgPermissionDenied:
+		return orgErrorResponse(err)
+	case nil:
+		return serialize.Ok()
+	default:
+		return serialize.Fail(code.SpaceSpecModifyFailed)
+	}
+}
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/code"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/service"
+	"github.com/mangohow/cloud-ide/pkg/logger"
+	"github.com/mangohow/cloud-ide/pkg/serialize"
+	"github.com/mangohow/cloud-ide/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+type InvoiceController struct {
+	logger  *logrus.Logger
+	service *service.InvoiceService
+}
+
+func NewInvoiceController() *InvoiceController {
+	return &InvoiceController{
+		logger:  logger.Logger(),
+		service: service.NewInvoiceService(),
+	}
+}
+
+// List 分页查询用户的收据 method: GET path: /api/payment/invoices
+// Query Param: page page_size
+func (i *InvoiceController) List(ctx *gin.Context) *serialize.Response {
+	page, pageSize := pageQuery(ctx)
+	userId := utils.MustGet[uint32](ctx, "id")
+
+	invoices, total, err := i.service.List(userId, page, pageSize)
+	if err != nil {
+		return serialize.Fail(code.QueryFailed)
+	}
+
+	return serialize.OkData(gin.H{
+		"list":  invoices,
+		"total": total,
+	})
+}
+
+// Download 下载收据 method: GET path: /api/payment/invoice/download
+// Query Param: invoice_no format[pdf, html] 默认为pdf
+func (i *InvoiceController) Download(ctx *gin.Context) *serialize.Response {
+	invoiceNo := ctx.Query("invoice_no")
+	format := ctx.DefaultQuery("format", model.InvoiceFormatPdf)
+	if invoiceNo == "" || (format != model.InvoiceFormatPdf && format != model.InvoiceFormatHtml) {
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	userId := utils.MustGet[uint32](ctx, "id")
+	content, err := i.service.Content(userId, invoiceNo, format)
+	if err == service.ErrInvoiceNotFound {
+		return serialize.Fail(code.InvoiceNotFound)
+	}
+	if err != nil {
+		return serialize.Fail(code.QueryFailed)
+	}
+
+	// 跨域中间件设置了json的Content-Type, 这里需要覆盖
+	contentType := "application/pdf"
+	if format == model.InvoiceFormatHtml {
+		contentType = "text/html; charset=utf-8"
+	} else {
+		ctx.Header("Content-Disposition", "attachment; filename="+invoiceNo+".pdf")
+	}
+	ctx.Header("Content-Type", contentType)
+	ctx.Data(http.StatusOK, contentType, content)
+	return nil
+}
+
+// Email 将收据发送到用户的邮箱 method: POST path: /api/payment/invoice/email
+// Request Param: invoice_no
+func (i *InvoiceController) Email(ctx *gin.Context) *serialize.Response {
+	var req struct {
+		InvoiceNo string `json:"invoice_no" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		i.logger.Warnf("bind param error:%v", err)
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	userId := utils.MustGet[uint32](ctx, "id")
+	err := i.service.SendEmail(userId, req.InvoiceNo)
+	switch err {
+	case nil:
+		return serialize.Ok()
+	case service.ErrInvoiceNotFound:
+		return serialize.Fail(code.InvoiceNotFound)
+	case service.ErrInvoiceNoEmail:
+		return serialize.Fail(code.InvoiceNoEmail)
+	default:
+		i.logger.Errorf("send invoice email error:%v, invoice:%s", err, req.InvoiceNo)
+		return serialize.Fail(code.InvoiceEmailFailed)
+	}
+}
@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/pay"
+	"github.com/mangohow/cloud-ide/pkg/logger"
+	"github.com/mangohow/cloud-ide/pkg/serialize"
+	"github.com/sirupsen/logrus"
+)
+
+// MockPayController 模拟支付网关的收银台, 只在配置的支付网关为mock时注册
+type MockPayController struct {
+	logger  *logrus.Logger
+	gateway *pay.MockGateway
+}
+
+func NewMockPayController(gateway *pay.MockGateway) *MockPayController {
+	return &MockPayController{
+		logger:  logger.Logger(),
+		gateway: gateway,
+	}
+}
+
+// Checkout 模拟收银台页面 method: GET path: /api/payment/mock/checkout
+// Query Param: out_trade_no
+func (m *MockPayController) Checkout(ctx *gin.Context) *serialize.Response {
+	var page bytes.Buffer
+	if err := m.gateway.RenderCheckout(&page, ctx.Query("out_trade_no")); err != nil {
+		m.logger.Warnf("render mock checkout error:%v", err)
+		return serialize.Error(http.StatusNotFound)
+	}
+
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+	return nil
+}
+
+// Pay 在模拟收银台确认或者取消支付, 确认支付时向notify_url发送签名的异步通知 method: POST path: /api/payment/mock/pay
+// Form Param: out_trade_no action[pay, cancel]
+func (m *MockPayController) Pay(ctx *gin.Context) *serialize.Response {
+	orderNo := ctx.PostForm("out_trade_no")
+	returnUrl, err := m.gateway.Complete(ctx.Request.Context(), orderNo, ctx.PostForm("action") == "pay")
+	if err != nil {
+		m.logger.Errorf("mock payment error:%v, order:%s", err, orderNo)
+		ctx.String(http.StatusOK, "支付失败: %v", err)
+		return nil
+	}
+	if returnUrl == "" {
+		ctx.String(http.StatusOK, "success")
+		return nil
+	}
+
+	ctx.Redirect(http.StatusFound, returnUrl)
+	return nil
+}
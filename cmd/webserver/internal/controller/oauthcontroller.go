@@ -103,6 +103,10 @@ func (o *OAuthController) LinuxDoCallback(ctx *gin.Context) *serialize.Response
 	user, err := o.oauthService.LoginOrCreateUser(userInfo)
 	if err != nil {
 		o.logger.Errorf("Failed to login or create OAuth user: %v", err)
+		if err == service.ErrUserDisabled {
+			ctx.Redirect(http.StatusFound, "https://tiantianai.co/idea/#/login?error=user_disabled")
+			return nil
+		}
 		ctx.Redirect(http.StatusFound, "https://tiantianai.co/idea/#/login?error=login_failed")
 		return nil
 	}
@@ -157,6 +161,9 @@ func (o *OAuthController) LinuxDoCallbackAPI(ctx *gin.Context) *serialize.Respon
 	user, err := o.oauthService.LoginOrCreateUser(userInfo)
 	if err != nil {
 		o.logger.Errorf("Failed to login or create OAuth user: %v", err)
+		if err == service.ErrUserDisabled {
+			return serialize.Fail(code.LoginUserDisabled)
+		}
 		return serialize.FailData(code.LoginFailed, gin.H{"message": "Login failed"})
 	}
 	
@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/code"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model/reqtype"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/service"
+	"github.com/mangohow/cloud-ide/pkg/logger"
+	"github.com/mangohow/cloud-ide/pkg/serialize"
+	"github.com/mangohow/cloud-ide/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+type OrgController struct {
+	logger  *logrus.Logger
+	service *service.OrgService
+}
+
+func NewOrgController() *OrgController {
+	return &OrgController{
+		logger:  logger.Logger(),
+		service: service.NewOrgService(),
+	}
+}
+
+// ListOrgs 获取用户加入的所有组织 method: GET path: /api/orgs
+func (o *OrgController) ListOrgs(ctx *gin.Context) *serialize.Response {
+	userId := utils.MustGet[uint32](ctx, "id")
+
+	orgs, err := o.service.List(userId)
+	if err != nil {
+		return serialize.Fail(code.QueryFailed)
+	}
+
+	return serialize.OkData(orgs)
+}
+
+// CreateOrg 创建组织 method: POST path: /api/org
+// Request Param: reqtype.OrgCreateOption
+func (o *OrgController) CreateOrg(ctx *gin.Context) *serialize.Response {
+	var req reqtype.OrgCreateOption
+	if err := ctx.ShouldBind(&req); err != nil {
+		o.logger.Warnf("bind param error:%v", err)
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	userId := utils.MustGet[uint32](ctx, "id")
+
+	org, err := o.service.Create(userId, req.Name)
+	if err != nil {
+		return orgErrorResponse(err)
+	}
+
+	return serialize.OkData(org)
+}
+
+// ListMembers 获取组织的成员 method: GET path: /api/org/members
+// Request Param: org_id
+func (o *OrgController) ListMembers(ctx *gin.Context) *serialize.Response {
+	orgId, err := strconv.ParseUint(ctx.Query("org_id"), 10, 32)
+	if err != nil {
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	userId := utils.MustGet[uint32](ctx, "id")
+
+	members, err := o.service.Members(uint32(orgId), userId)
+	if err != nil {
+		return orgErrorResponse(err)
+	}
+
+	return serialize.OkData(members)
+}
+
+// AddMember 添加组织成员 method: POST path: /api/org/member
+// Request Param: reqtype.OrgMemberOption
+func (o *OrgController) AddMember(ctx *gin.Context) *serialize.Response {
+	var req reqtype.OrgMemberOption
+	if err := ctx.ShouldBind(&req); err != nil {
+		o.logger.Warnf("bind param error:%v", err)
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	userId := utils.MustGet[uint32](ctx, "id")
+
+	if err := o.service.AddMember(userId, &req); err != nil {
+		return orgErrorResponse(err)
+	}
+
+	return serialize.Ok()
+}
+
+// SetMemberRole 修改成员的角色 method: PUT path: /api/org/member/role
+// Request Param: reqtype.OrgMemberOption
+func (o *OrgController) SetMemberRole(ctx *gin.Context) *serialize.Response {
+	var req reqtype.OrgMemberOption
+	if err := ctx.ShouldBind(&req); err != nil {
+		o.logger.Warnf("bind param error:%v", err)
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	userId := utils.MustGet[uint32](ctx, "id")
+
+	if err := o.service.SetRole(userId, &req); err != nil {
+		return orgErrorResponse(err)
+	}
+
+	return serialize.Ok()
+}
+
+// RemoveMember 移除组织成员或退出组织 method: DELETE path: /api/org/member
+// Request Param: org_id user_id
+func (o *OrgController) RemoveMember(ctx *gin.Context) *serialize.Response {
+	orgId, err := strconv.ParseUint(ctx.Query("org_id"), 10, 32)
+	if err != nil {
+		return serialize.Error(http.StatusBadRequest)
+	}
+	memberId, err := strconv.ParseUint(ctx.Query("user_id"), 10, 32)
+	if err != nil {
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	userId := utils.MustGet[uint32](ctx, "id")
+
+	req := &reqtype.OrgMemberOption{OrgId: uint32(orgId), UserId: uint32(memberId)}
+	if err = o.service.RemoveMember(userId, req); err != nil {
+		return orgErrorResponse(err)
+	}
+
+	return serialize.Ok()
+}
+
+// orgErrorResponse 组织相关错误的响应, 其它错误返回操作失败
+func orgErrorResponse(err error) *serialize.Response {
+	switch err {
+	case service.ErrOrgNotFound:
+		return serialize.Fail(code.OrgNotFound)
+	case service.ErrOrgPermissionDenied:
+		return serialize.Fail(code.OrgPermissionDenied)
+	case service.ErrOrgInvalid:
+		return serialize.Fail(code.OrgInvalid)
+	case service.ErrReachMaxOrgCount:
+		return serialize.Fail(code.OrgReachMaxCount)
+	case service.ErrReachMaxOrgMembers:
+		return serialize.Fail(code.OrgReachMaxMembers)
+	case service.ErrOrgUserNotFound:
+		return serialize.Fail(code.OrgUserNotFound)
+	case service.ErrOrgMemberExists:
+		return serialize.Fail(code.OrgMemberExists)
+	case service.ErrOrgMemberNotFound:
+		return serialize.Fail(code.OrgMemberNotFound)
+	case service.ErrOrgProductNotAllowed:
+		return serialize.Fail(code.OrgProductNotAllowed)
+	}
+
+	return serialize.Fail(code.AdminOperationFailed)
+}
@@ -1,11 +1,9 @@
 package controller
 
 import (
-	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/code"
@@ -13,18 +11,21 @@ import (
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/service"
 	"github.com/mangohow/cloud-ide/pkg/logger"
 	"github.com/mangohow/cloud-ide/pkg/serialize"
+	"github.com/mangohow/cloud-ide/pkg/utils"
 	"github.com/sirupsen/logrus"
 )
 
 type PaymentController struct {
 	logger         *logrus.Logger
 	paymentService *service.PaymentService
+	couponService  *service.CouponService
 }
 
 func NewPaymentController() *PaymentController {
 	return &PaymentController{
 		logger:         logger.Logger(),
 		paymentService: service.NewPaymentService(),
+		couponService:  service.NewCouponService(),
 	}
 }
 
@@ -67,19 +68,21 @@ func (p *PaymentController) CreateOrder(ctx *gin.Context) *serialize.Response {
 		req.PaymentMethod = "alipay" // 默认支付宝
 	}
 
-	// 设置默认跳转地址
-	if req.ReturnUrl == "" {
-		req.ReturnUrl = "https://tiantianai.co/idea/#/payment"
-	}
+	req.ClientIp = ctx.ClientIP()
 
 	orderDetail, err := p.paymentService.CreateOrder(userId, &req)
 	if err != nil {
 		p.logger.Errorf("create order failed: %v", err)
+		if resp := couponErrorResponse(err); resp != nil {
+			return resp
+		}
 		switch err {
 		case service.ErrProductNotFound:
 			return serialize.FailData(code.QueryFailed, gin.H{"message": "产品不存在"})
 		case service.ErrPaymentFailed:
 			return serialize.FailData(code.QueryFailed, gin.H{"message": "支付创建失败"})
+		case service.ErrOrgNotFound, service.ErrOrgPermissionDenied, service.ErrOrgProductNotAllowed:
+			return orgErrorResponse(err)
 		default:
 			return serialize.Fail(code.QueryFailed)
 		}
@@ -88,6 +91,30 @@ func (p *PaymentController) CreateOrder(ctx *gin.Context) *serialize.Response {
 	return serialize.OkData(orderDetail)
 }
 
+// PreviewCoupon 计算使用优惠码购买产品的价格
+// method: GET path: /api/payment/coupon
+// Query Param: code product_id
+func (p *PaymentController) PreviewCoupon(ctx *gin.Context) *serialize.Response {
+	productId, err := strconv.Atoi(ctx.Query("product_id"))
+	if err != nil || productId <= 0 || ctx.Query("code") == "" {
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	userId := utils.MustGet[uint32](ctx, "id")
+	preview, err := p.couponService.Preview(userId, ctx.Query("code"), uint32(productId))
+	if err != nil {
+		if resp := couponErrorResponse(err); resp != nil {
+			return resp
+		}
+		if err == service.ErrProductNotFound {
+			return serialize.FailData(code.QueryFailed, gin.H{"message": "产品不存在"})
+		}
+		return serialize.Fail(code.QueryFailed)
+	}
+
+	return serialize.OkData(preview)
+}
+
 // GetOrders 获取用户订单列表
 // method: GET path: /api/payment/orders
 func (p *PaymentController) GetOrders(ctx *gin.Context) *serialize.Response {
@@ -152,53 +179,33 @@ func (p *PaymentController) GetSubscription(ctx *gin.Context) *serialize.Respons
 	return serialize.OkData(subscription)
 }
 
-// PaymentCallback 支付回调处理
+// PaymentCallback 支付网关的异步通知, 通知的签名必须正确
 // method: POST/GET path: /api/payment/callback
 func (p *PaymentController) PaymentCallback(ctx *gin.Context) *serialize.Response {
-	var req model.PaymentCallbackRequest
-	
 	// 支持GET、POST form和POST JSON三种方式的回调
+	params := make(map[string]string)
 	contentType := ctx.GetHeader("Content-Type")
-	
-	if ctx.Request.Method == "GET" {
-		// GET方式，从query参数获取（ouyun支付网关回调使用）
-		req.Pid = ctx.Query("pid")
-		req.TradeNo = ctx.Query("trade_no")
-		req.OutTradeNo = ctx.Query("out_trade_no")
-		req.Type = ctx.Query("type")
-		req.Name = ctx.Query("name")
-		req.Money = ctx.Query("money")
-		req.TradeStatus = ctx.Query("trade_status")
-		req.Param = ctx.Query("param")
-		req.Sign = ctx.Query("sign")
-		req.SignType = ctx.Query("sign_type")
-		
-		p.logger.Infof("GET callback from payment gateway: %+v", req)
-		
-	} else if strings.Contains(contentType, "application/json") {
-		// POST JSON方式，从JSON body获取（前端验证使用）
-		if err := ctx.ShouldBindJSON(&req); err != nil {
+	if ctx.Request.Method != http.MethodGet && strings.Contains(contentType, "application/json") {
+		// 前端转发的回调
+		if err := ctx.ShouldBindJSON(&params); err != nil {
 			p.logger.Errorf("bind payment callback JSON request failed: %v", err)
 			return serialize.Error(http.StatusBadRequest)
 		}
-		
-		p.logger.Infof("POST JSON callback from frontend: %+v", req)
-		
 	} else {
-		// POST form方式，从form参数获取（ouyun支付网关回调使用）
-		if err := ctx.ShouldBind(&req); err != nil {
-			p.logger.Errorf("bind payment callback form request failed: %v", err)
+		if err := ctx.Request.ParseForm(); err != nil {
+			p.logger.Errorf("parse payment callback form failed: %v", err)
 			ctx.String(http.StatusBadRequest, "fail")
 			return nil
 		}
-		
-		p.logger.Infof("POST form callback from payment gateway: %+v", req)
+		for k := range ctx.Request.Form {
+			params[k] = ctx.Request.Form.Get(k)
+		}
 	}
 
-	p.logger.Infof("processing payment callback: trade_no=%s, out_trade_no=%s, status=%s", 
-		req.TradeNo, req.OutTradeNo, req.TradeStatus)
+	p.logger.Infof("processing payment callback: trade_no=%s, out_trade_no=%s, status=%s",
+		params["trade_no"], params["out_trade_no"], params["trade_status"])
 
-	err := p.paymentService.HandlePaymentCallback(&req)
+	err := p.paymentService.HandlePaymentCallback(params)
 	if err != nil {
 		p.logger.Errorf("handle payment callback failed: %v", err)
 		
@@ -235,7 +242,7 @@ func (p *PaymentController) PaymentReturn(ctx *gin.Context) *serialize.Response
 	return nil
 }
 
-// SyncPaymentStatus 手动同步支付状态
+// SyncPaymentStatus 手动同步支付状态, 向支付网关查询订单是否已经支付
 // method: POST path: /api/payment/sync
 func (p *PaymentController) SyncPaymentStatus(ctx *gin.Context) *serialize.Response {
 	// 从token中获取用户ID
@@ -262,21 +269,8 @@ func (p *PaymentController) SyncPaymentStatus(ctx *gin.Context) *serialize.Respo
 
 	p.logger.Infof("user %d requesting payment sync for order: %s", userId, req.OrderNo)
 
-	// 模拟支付成功回调
-	callbackReq := &model.PaymentCallbackRequest{
-		Pid:         "47",
-		TradeNo:     fmt.Sprintf("SYNC_%d", time.Now().Unix()),
-		OutTradeNo:  req.OrderNo,
-		Type:        "alipay",
-		Name:        "VIP支付",
-		Money:       "9.90", // 这里可以从订单中获取实际金额
-		TradeStatus: "TRADE_SUCCESS",
-		Param:       "",
-		Sign:        "manual_sync",
-		SignType:    "MD5",
-	}
-
-	err := p.paymentService.HandlePaymentCallback(callbackReq)
+	// 向支付网关查询支付结果
+	err := p.paymentService.SyncPaymentStatus(userId, req.OrderNo)
 	if err != nil {
 		p.logger.Errorf("sync payment status failed: %v", err)
 		return serialize.FailData(code.PaymentFailed, gin.H{"message": err.Error()})
@@ -284,4 +278,26 @@ func (p *PaymentController) SyncPaymentStatus(ctx *gin.Context) *serialize.Respo
 
 	p.logger.Infof("payment status synced successfully for order: %s", req.OrderNo)
 	return serialize.OkData(gin.H{"message": "支付状态同步成功"})
-} 
\ No newline at end of file
+}
+
+// couponErrorResponse 优惠码错误对应的响应, 不是优惠码错误时返回nil
+func couponErrorResponse(err error) *serialize.Response {
+	switch err {
+	case service.ErrCouponNotFound:
+		return serialize.Fail(code.CouponNotFound)
+	case service.ErrCouponUnavailable:
+		return serialize.Fail(code.CouponUnavailable)
+	case service.ErrCouponNotApplicable:
+		return serialize.Fail(code.CouponNotApplicable)
+	case service.ErrCouponLimitReached:
+		return serialize.Fail(code.CouponLimitReached)
+	case service.ErrCouponFirstPurchase:
+		return serialize.Fail(code.CouponFirstPurchase)
+	case service.ErrCouponInvalid:
+		return serialize.Fail(code.CouponInvalid)
+	case service.ErrCouponDuplicate:
+		return serialize.Fail(code.CouponDuplicate)
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/code"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model/reqtype"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/service"
+	"github.com/mangohow/cloud-ide/pkg/logger"
+	"github.com/mangohow/cloud-ide/pkg/serialize"
+	"github.com/mangohow/cloud-ide/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+type SecretController struct {
+	logger  *logrus.Logger
+	service *service.SecretService
+}
+
+func NewSecretController() *SecretController {
+	return &SecretController{
+		logger:  logger.Logger(),
+		service: service.NewSecretService(),
+	}
+}
+
+// ListSecrets 获取用户的所有密钥, 不包含明文 method: GET path: /api/secrets
+func (s *SecretController) ListSecrets(ctx *gin.Context) *serialize.Response {
+	userId := utils.MustGet[uint32](ctx, "id")
+
+	secrets, err := s.service.ListSecrets(userId)
+	if err != nil {
+		return serialize.Fail(code.QueryFailed)
+	}
+
+	return serialize.OkData(secrets)
+}
+
+// CreateSecret 创建密钥 method: POST path: /api/secrets
+// Request Param: reqtype.SecretCreateOption
+func (s *SecretController) CreateSecret(ctx *gin.Context) *serialize.Response {
+	var req reqtype.SecretCreateOption
+	if err := ctx.ShouldBind(&req); err != nil {
+		s.logger.Warnf("bind param error:%v", err)
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	userId := utils.MustGet[uint32](ctx, "id")
+
+	secret, err := s.service.CreateSecret(&req, userId)
+	switch err {
+	case nil:
+		return serialize.OkData(secret)
+	case service.ErrSecretInvalid:
+		return serialize.Fail(code.SecretInvalid)
+	case service.ErrSecretNameDuplicate:
+		return serialize.Fail(code.SecretNameDuplicate)
+	case service.ErrReachMaxSecretCount:
+		return serialize.Fail(code.SecretReachMaxCount)
+	case service.ErrSecretNotConfigured:
+		return serialize.Fail(code.SecretNotConfigured)
+	default:
+		return serialize.Fail(code.SecretCreateFailed)
+	}
+}
+
+// UpdateSecret 更新密钥的值 method: PUT path: /api/secrets
+// Request Param: reqtype.SecretUpdateOption
+func (s *SecretController) UpdateSecret(ctx *gin.Context) *serialize.Response {
+	var req reqtype.SecretUpdateOption
+	if err := ctx.ShouldBind(&req); err != nil {
+		s.logger.Warnf("bind param error:%v", err)
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	userId := utils.MustGet[uint32](ctx, "id")
+
+	secret, err := s.service.UpdateSecret(&req, userId)
+	switch err {
+	case nil:
+		return serialize.OkData(secret)
+	case service.ErrSecretInvalid:
+		return serialize.Fail(code.SecretInvalid)
+	case service.ErrSecretNotFound:
+		return serialize.Fail(code.SecretNotFound)
+	case service.ErrSecretNotConfigured:
+		return serialize.Fail(code.SecretNotConfigured)
+	default:
+		return serialize.Fail(code.SecretUpdateFailed)
+	}
+}
+
+// DeleteSecret 删除密钥 method: DELETE path: /api/secrets
+// Request Param: id
+func (s *SecretController) DeleteSecret(ctx *gin.Context) *serialize.Response {
+	id, err := strconv.ParseUint(ctx.Query("id"), 10, 32)
+	if err != nil {
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	userId := utils.MustGet[uint32](ctx, "id")
+
+	err = s.service.DeleteSecret(uint32(id), userId)
+	switch err {
+	case nil:
+		return serialize.Ok()
+	case service.ErrSecretNotFound:
+		return serialize.Fail(code.SecretNotFound)
+	default:
+		return serialize.Fail(code.SecretDeleteFailed)
+	}
+}
@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/code"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model/reqtype"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/service"
+	"github.com/mangohow/cloud-ide/pkg/logger"
+	"github.com/mangohow/cloud-ide/pkg/serialize"
+	"github.com/mangohow/cloud-ide/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+type ShareController struct {
+	logger  *logrus.Logger
+	service *service.ShareService
+}
+
+func NewShareController() *ShareController {
+	return &ShareController{
+		logger:  logger.Logger(),
+		service: service.NewShareService(),
+	}
+}
+
+// CreateShare 创建工作空间的分享链接 method: POST path: /api/workspace/share
+// Request Param: reqtype.ShareCreateOption
+func (s *ShareController) CreateShare(ctx *gin.Context) *serialize.Response {
+	var req reqtype.ShareCreateOption
+	if err := ctx.ShouldBind(&req); err != nil {
+		s.logger.Warnf("bind param error:%v", err)
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	userId := utils.MustGet[uint32](ctx, "id")
+
+	share, err := s.service.CreateShare(&req, userId)
+	switch err {
+	case nil:
+		return serialize.OkData(share)
+	case service.ErrShareNotConfigured:
+		return serialize.Fail(code.ShareNotConfigured)
+	case service.ErrShareInvalid:
+		return serialize.Fail(code.ShareInvalid)
+	case service.ErrReachMaxShareCount:
+		return serialize.Fail(code.ShareReachMaxCount)
+	case service.ErrWorkSpaceNotExist:
+		return serialize.Fail(code.SpaceNotFound)
+	case service.ErrOrgPermissionDenied:
+		return orgErrorResponse(err)
+	default:
+		return serialize.Fail(code.ShareCreateFailed)
+	}
+}
+
+// ListShares 获取工作空间有效的分享链接 method: GET path: /api/workspace/shares
+// Request Param: id
+func (s *ShareController) ListShares(ctx *gin.Context) *serialize.Response {
+	id, err := strconv.ParseUint(ctx.Query("id"), 10, 32)
+	if err != nil {
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	userId := utils.MustGet[uint32](ctx, "id")
+
+	shares, err := s.service.ListShares(uint32(id), userId)
+	return s.spaceQueryResponse(shares, err)
+}
+
+// RevokeShare 撤销分享链接 method: DELETE path: /api/workspace/share
+// Request Param: id 分享链接id
+func (s *ShareController) RevokeShare(ctx *gin.Context) *serialize.Response {
+	id, err := strconv.ParseUint(ctx.Query("id"), 10, 32)
+	if err != nil {
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	userId := utils.MustGet[uint32](ctx, "id")
+
+	err = s.service.RevokeShare(uint32(id), userId)
+	switch err {
+	case nil:
+		return serialize.Ok()
+	case service.ErrShareNotFound, service.ErrWorkSpaceNotExist:
+		return serialize.Fail(code.ShareNotFound)
+	case service.ErrOrgPermissionDenied:
+		return orgErrorResponse(err)
+	default:
+		return serialize.Fail(code.AdminOperationFailed)
+	}
+}
+
+// ListShareAccess 获取工作空间的分享链接的访问记录 method: GET path: /api/workspace/share/access
+// Request Param: id
+func (s *ShareController) ListShareAccess(ctx *gin.Context) *serialize.Response {
+	id, err := strconv.ParseUint(ctx.Query("id"), 10, 32)
+	if err != nil {
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	userId := utils.MustGet[uint32](ctx, "id")
+
+	records, err := s.service.ListAccess(uint32(id), userId)
+	return s.spaceQueryResponse(records, err)
+}
+
+// VerifyShare 网关在代理前验证分享令牌 method: GET path: /internal/share/verify
+// Request Param: token sid audit, audit为1时记录访问, 访问者的ip和User-Agent由网关转发
+// 令牌无效时返回403
+func (s *ShareController) VerifyShare(ctx *gin.Context) *serialize.Response {
+	token, sid := ctx.Query("token"), ctx.Query("sid")
+	if token == "" || sid == "" {
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	var access *model.ShareAccess
+	if ctx.Query("audit") == "1" {
+		access = &model.ShareAccess{
+			Ip:        ctx.ClientIP(),
+			UserAgent: truncate(ctx.Request.UserAgent(), 255),
+		}
+	}
+
+	share, err := s.service.Verify(token, sid, access)
+	if err != nil {
+		return serialize.NewResponse(http.StatusForbidden, code.ShareTokenInvalid, nil, code.GetMessage(code.ShareTokenInvalid))
+	}
+
+	return serialize.OkData(gin.H{
+		"share_id":    share.Id,
+		"permission":  share.Permission,
+		"expire_time": share.ExpireTime.Unix(),
+	})
+}
+
+func (s *ShareController) spaceQueryResponse(data interface{}, err error) *serialize.Response {
+	switch err {
+	case nil:
+		return serialize.OkData(data)
+	case service.ErrWorkSpaceNotExist:
+		return serialize.Fail(code.SpaceNotFound)
+	case service.ErrOrgPermissionDenied:
+		return orgErrorResponse(err)
+	default:
+		return serialize.Fail(code.QueryFailed)
+	}
+}
+
+// truncate 按字节截断字符串, 不截断多字节字符
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n]
+}
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/code"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model/reqtype"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/service"
+	"github.com/mangohow/cloud-ide/pkg/logger"
+	"github.com/mangohow/cloud-ide/pkg/serialize"
+	"github.com/mangohow/cloud-ide/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+type SnapshotController struct {
+	logger  *logrus.Logger
+	service *service.SnapshotService
+}
+
+func NewSnapshotController() *SnapshotController {
+	return &SnapshotController{
+		logger:  logger.Logger(),
+		service: service.NewSnapshotService(),
+	}
+}
+
+// CreateSnapshot 为工作空间创建快照 method: POST path: /api/workspace/snapshot
+// Request Param: reqtype.SnapshotCreateOption
+func (s *SnapshotController) CreateSnapshot(ctx *gin.Context) *serialize.Response {
+	var req reqtype.SnapshotCreateOption
+	if err := ctx.ShouldBind(&req); err != nil {
+		s.logger.Warnf("bind param error:%v", err)
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	userId := utils.MustGet[uint32](ctx, "id")
+	uid := utils.MustGet[string](ctx, "uid")
+
+	snapshot, err := s.service.CreateSnapshot(req.Id, userId, uid, req.Desc)
+	switch err {
+	case nil:
+		return serialize.OkData(snapshot)
+	case service.ErrWorkSpaceNotExist:
+		return serialize.Fail(code.SpaceNotFound)
+	case service.ErrWorkSpaceIsRunning:
+		return serialize.Fail(code.SnapshotSpaceIsRunning)
+	case service.ErrReachMaxSnapshotCount:
+		return serialize.Fail(code.SnapshotReachMaxCount)
+	case service.ErrSnapshotNotSupported:
+		return serialize.Fail(code.SnapshotNotSupported)
+	default:
+		return serialize.Fail(code.SnapshotCreateFailed)
+	}
+}
+
+// ListSnapshots 获取工作空间的所有快照 method: GET path: /api/workspace/snapshot/list
+// Request Param: id
+func (s *SnapshotController) ListSnapshots(ctx *gin.Context) *serialize.Response {
+	id, err := strconv.ParseUint(ctx.Query("id"), 10, 32)
+	if err != nil {
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	userId := utils.MustGet[uint32](ctx, "id")
+	uid := utils.MustGet[string](ctx, "uid")
+
+	snapshots, err := s.service.ListSnapshots(uint32(id), userId, uid)
+	switch err {
+	case nil:
+		return serialize.OkData(snapshots)
+	case service.ErrWorkSpaceNotExist:
+		return serialize.Fail(code.SpaceNotFound)
+	default:
+		return serialize.Fail(code.QueryFailed)
+	}
+}
+
+// RestoreSnapshot 将已停止的工作空间恢复到指定快照 method: PUT path: /api/workspace/snapshot/restore
+// Request Param: reqtype.SnapshotRestoreOption
+func (s *SnapshotController) RestoreSnapshot(ctx *gin.Context) *serialize.Response {
+	var req reqtype.SnapshotRestoreOption
+	if err := ctx.ShouldBind(&req); err != nil {
+		s.logger.Warnf("bind param error:%v", err)
+		return serialize.Error(http.StatusBadRequest)
+	}
+
+	userId := utils.MustGet[uint32](ctx, "id")
+	uid := utils.MustGet[string](ctx, "uid")
+
+	err := s.service.RestoreSnapshot(req.Id, req.SnapshotId, userId, uid)
+	switch err {
+	case nil:
+		return serialize.Ok()
+	case service.ErrWorkSpaceNotExist:
+		return serialize.Fail(code.SpaceNotFound)
+	case service.ErrWorkSpaceIsRunning:
+		return serialize.Fail(code.SnapshotSpaceIsRunning)
+	case service.ErrSnapshotNotFound:
+		return serialize.Fail(code.SnapshotNotFound)
+	case service.ErrSnapshotNotReady:
+		return serialize.Fail(code.SnapshotNotReady)
+	default:
+		return serialize.Fail(code.SnapshotRestoreFailed)
+	}
+}
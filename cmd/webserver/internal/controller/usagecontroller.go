@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/code"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/service"
+	"github.com/mangohow/cloud-ide/pkg/logger"
+	"github.com/mangohow/cloud-ide/pkg/serialize"
+	"github.com/mangohow/cloud-ide/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultUsageMonths = 12
+	maxUsageMonths     = 24
+	creditRecordLimit  = 20
+)
+
+type UsageController struct {
+	logger  *logrus.Logger
+	service *service.MeteringService
+}
+
+func NewUsageController() *UsageController {
+	return &UsageController{
+		logger:  logger.Logger(),
+		service: service.NewMeteringService(),
+	}
+}
+
+// Usage 获取用户某个月的资源使用量和每天的明细 method: GET path: /api/usage
+// Request Param: month 格式为2006-01, 为空表示本月
+func (u *UsageController) Usage(ctx *gin.Context) *serialize.Response {
+	month := time.Now()
+	if m := ctx.Query("month"); m != "" {
+		t, err := time.ParseInLocation("2006-01", m, time.Local)
+		if err != nil {
+			u.logger.Warnf("parse month error:%v", err)
+			return serialize.Error(http.StatusBadRequest)
+		}
+		month = t
+	}
+
+	userId := utils.MustGet[uint32](ctx, "id")
+	usage, err := u.service.Usage(userId, month)
+	if err != nil {
+		return serialize.Fail(code.QueryFailed)
+	}
+
+	return serialize.OkData(usage)
+}
+
+// MonthlyUsage 获取用户最近几个月每个月的资源使用量 method: GET path: /api/usage/monthly
+// Request Param: months 月数, 默认12, 最多24
+func (u *UsageController) MonthlyUsage(ctx *gin.Context) *serialize.Response {
+	months := defaultUsageMonths
+	if m := ctx.Query("months"); m != "" {
+		n, err := strconv.Atoi(m)
+		if err != nil || n <= 0 || n > maxUsageMonths {
+			return serialize.Error(http.StatusBadRequest)
+		}
+		months = n
+	}
+
+	userId := utils.MustGet[uint32](ctx, "id")
+	usage, err := u.service.MonthlyUsage(userId, months)
+	if err != nil {
+		return serialize.Fail(code.QueryFailed)
+	}
+
+	return serialize.OkData(usage)
+}
+
+// Credit 获取用户的预付费余额和最近的余额变动 method: GET path: /api/credit
+func (u *UsageController) Credit(ctx *gin.Context) *serialize.Response {
+	userId := utils.MustGet[uint32](ctx, "id")
+	balance, records, err := u.service.CreditInfo(userId, creditRecordLimit)
+	if err != nil {
+		return serialize.Fail(code.QueryFailed)
+	}
+
+	return serialize.OkData(gin.H{
+		"balance": balance,
+		"records": records,
+	})
+}
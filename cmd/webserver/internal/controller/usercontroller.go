@@ -5,7 +5,6 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/code"
-	"github.com/mangohow/cloud-ide/cmd/webserver/internal/conf"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/service"
 	"github.com/mangohow/cloud-ide/pkg/logger"
@@ -22,17 +21,7 @@ type UserController struct {
 }
 
 func NewUserController() *UserController {
-	var emailService service.EmailService
-	if conf.EmailConfig.Enabled {
-		emailService = service.NewEmailService()
-	} else {
-		emailService = service.NewFakeEmailService()
-	}
-
-	err := emailService.Start()
-	if err != nil {
-		panic(err)
-	}
+	emailService := service.DefaultEmailService()
 	return &UserController{
 		service:      service.NewUserService(emailService),
 		logger:       logger.Logger(),
@@ -63,6 +52,8 @@ func (u *UserController) Login(ctx *gin.Context) *serialize.Response {
 		switch err {
 		case service.ErrUserDeleted:
 			return serialize.Fail(code.LoginUserDeleted)
+		case service.ErrUserDisabled:
+			return serialize.Fail(code.LoginUserDisabled)
 		case service.ErrUserNotExist:
 			return serialize.Fail(code.LoginUserNotExist)
 		case service.ErrPasswordIncorrect:
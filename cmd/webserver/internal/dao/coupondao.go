@@ -0,0 +1,102 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao/db"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+)
+
+// CouponDao 优惠码的管理和查询, 创建订单时在支付事务中使用优惠码, 见PaymentTx
+type CouponDao struct {
+	db *sqlx.DB
+}
+
+func NewCouponDao() *CouponDao {
+	return &CouponDao{
+		db: db.DB(),
+	}
+}
+
+// InsertCoupon 创建优惠码, 返回优惠码id
+func (d *CouponDao) InsertCoupon(c *model.Coupon) (uint32, error) {
+	sql := `INSERT INTO t_coupon (code, name, discount_type, discount_value, product_types, first_purchase,
+			max_redemptions, per_user_limit, start_time, end_time, status, create_time, update_time)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	res, err := d.db.Exec(sql, c.Code, c.Name, c.DiscountType, c.DiscountValue, c.ProductTypes, c.FirstPurchase,
+		c.MaxRedemptions, c.PerUserLimit, c.StartTime, c.EndTime, c.Status, c.CreateTime, c.UpdateTime)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+
+	return uint32(id), err
+}
+
+// FindCoupons 分页查询优惠码以及已经使用的次数
+func (d *CouponDao) FindCoupons(limit, offset int) (coupons []model.Coupon, err error) {
+	sql := `SELECT c.id, c.code, c.name, c.discount_type, c.discount_value, c.product_types, c.first_purchase,
+			c.max_redemptions, c.per_user_limit, c.start_time, c.end_time, c.status, c.create_time, c.update_time,
+			(SELECT COUNT(*) FROM t_coupon_redemption r WHERE r.coupon_id = c.id AND r.status != ?) AS redeemed
+			FROM t_coupon c ORDER BY c.id DESC LIMIT ? OFFSET ?`
+	err = d.db.Select(&coupons, sql, model.RedemptionReleased, limit, offset)
+	return
+}
+
+// CountCoupons 查询优惠码的数量
+func (d *CouponDao) CountCoupons() (count uint32, err error) {
+	err = d.db.Get(&count, `SELECT COUNT(*) FROM t_coupon`)
+	return
+}
+
+// FindCouponByCode 根据优惠码查询
+func (d *CouponDao) FindCouponByCode(code string) (*model.Coupon, error) {
+	sql := `SELECT id, code, name, discount_type, discount_value, product_types, first_purchase, max_redemptions,
+			per_user_limit, start_time, end_time, status, create_time, update_time
+			FROM t_coupon WHERE code = ?`
+	var coupon model.Coupon
+	err := d.db.Get(&coupon, sql, code)
+	return &coupon, err
+}
+
+// UpdateCouponStatus 启用或停用优惠码, 返回是否存在该优惠码
+func (d *CouponDao) UpdateCouponStatus(id uint32, status uint8) (bool, error) {
+	res, err := d.db.Exec(`UPDATE t_coupon SET status = ?, update_time = ? WHERE id = ?`, status, time.Now(), id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+
+	return n == 1, err
+}
+
+// CountCouponRedemptions 查询优惠码总共和用户已经使用的次数
+func (d *CouponDao) CountCouponRedemptions(couponId, userId uint32) (total, user uint32, err error) {
+	return countCouponRedemptions(d.db, couponId, userId)
+}
+
+// CountPaidOrders 查询用户已经支付过的订单数量
+func (d *CouponDao) CountPaidOrders(userId uint32) (uint32, error) {
+	return countPaidOrders(d.db, userId)
+}
+
+// countCouponRedemptions 未支付的订单占用的次数也计算在内, 关闭的订单释放的不计算
+func countCouponRedemptions(q sqlx.Queryer, couponId, userId uint32) (total, user uint32, err error) {
+	sql := `SELECT COUNT(*) AS total, COALESCE(SUM(user_id = ?), 0) AS user_count
+			FROM t_coupon_redemption WHERE coupon_id = ? AND status != ?`
+	var count struct {
+		Total     uint32 `db:"total"`
+		UserCount uint32 `db:"user_count"`
+	}
+	err = sqlx.Get(q, &count, sql, userId, couponId, model.RedemptionReleased)
+
+	return count.Total, count.UserCount, err
+}
+
+// countPaidOrders 退款的订单也算作购买过
+func countPaidOrders(q sqlx.Queryer, userId uint32) (count uint32, err error) {
+	sql := `SELECT COUNT(*) FROM t_order WHERE user_id = ? AND status IN (?, ?, ?)`
+	err = sqlx.Get(q, &count, sql, userId, model.OrderStatusPaid, model.OrderStatusRefunding, model.OrderStatusRefunded)
+	return
+}
@@ -0,0 +1,86 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao/db"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+)
+
+type CreditDao struct {
+	db *sqlx.DB
+}
+
+func NewCreditDao() *CreditDao {
+	return &CreditDao{
+		db: db.DB(),
+	}
+}
+
+// FindBalance 查询用户的余额, 没有充值过的用户余额为0
+func (d *CreditDao) FindBalance(userId uint32) (balance int64, err error) {
+	sql := `SELECT COALESCE((SELECT balance FROM t_user_credit WHERE user_id = ?), 0)`
+	err = d.db.Get(&balance, sql, userId)
+	return
+}
+
+// Charge 将运行记录的费用更新为cost, 并从用户的余额中扣除新增的部分, 返回扣费后的余额
+// 在事务中锁定运行记录, 重复扣费时不会多扣
+func (d *CreditDao) Charge(runtime *model.SpaceRuntime, cost int64) (int64, error) {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var charged int64
+	if err = tx.Get(&charged, `SELECT cost FROM t_space_runtime WHERE id = ? FOR UPDATE`, runtime.Id); err != nil {
+		return 0, err
+	}
+	if cost <= charged {
+		var balance int64
+		err = tx.Get(&balance, `SELECT COALESCE((SELECT balance FROM t_user_credit WHERE user_id = ?), 0)`, runtime.UserId)
+		return balance, err
+	}
+
+	if _, err = tx.Exec(`UPDATE t_space_runtime SET cost = ? WHERE id = ?`, cost, runtime.Id); err != nil {
+		return 0, err
+	}
+	balance, err := addBalance(tx, runtime.UserId, charged-cost, model.CreditUsage, runtime.Sid)
+	if err != nil {
+		return 0, err
+	}
+
+	return balance, tx.Commit()
+}
+
+// addBalance 修改用户的余额并记录变动, 返回修改后的余额
+func addBalance(tx *sqlx.Tx, userId uint32, amount int64, typ, ref string) (int64, error) {
+	now := time.Now()
+	sql := `INSERT INTO t_user_credit (user_id, balance, update_time) VALUES (?, ?, ?)
+ON DUPLICATE KEY UPDATE balance = balance + VALUES(balance), update_time = VALUES(update_time)`
+	if _, err := tx.Exec(sql, userId, amount, now); err != nil {
+		return 0, err
+	}
+
+	var balance int64
+	if err := tx.Get(&balance, `SELECT balance FROM t_user_credit WHERE user_id = ?`, userId); err != nil {
+		return 0, err
+	}
+
+	sql = `INSERT INTO t_credit_record (user_id, type, amount, balance, ref, create_time) VALUES (?, ?, ?, ?, ?, ?)`
+	if _, err := tx.Exec(sql, userId, typ, amount, balance, ref, now); err != nil {
+		return 0, err
+	}
+
+	return balance, nil
+}
+
+// FindRecords 查询用户最近的余额变动记录
+func (d *CreditDao) FindRecords(userId uint32, limit int) (records []model.CreditRecord, err error) {
+	sql := `SELECT id, user_id, type, amount, balance, ref, create_time FROM t_credit_record
+WHERE user_id = ? ORDER BY id DESC LIMIT ?`
+	err = d.db.Select(&records, sql, userId, limit)
+	return
+}
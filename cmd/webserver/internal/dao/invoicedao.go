@@ -0,0 +1,51 @@
+package dao
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao/db"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+)
+
+// InvoiceDao 收据的查询, 收据在支付事务中创建, 见PaymentTx.CreateInvoice
+type InvoiceDao struct {
+	db *sqlx.DB
+}
+
+func NewInvoiceDao() *InvoiceDao {
+	return &InvoiceDao{
+		db: db.DB(),
+	}
+}
+
+// FindByUserId 分页查询用户的收据, 不包括生成的收据内容
+func (d *InvoiceDao) FindByUserId(userId uint32, limit, offset int) (invoices []model.Invoice, err error) {
+	sql := `SELECT i.id, i.invoice_no, i.order_id, i.order_no, i.user_id, i.buyer_name, i.buyer_email, i.product_name,
+			i.product_type, i.amount, i.payment_method, i.paid_time, i.create_time, o.status AS order_status
+			FROM t_invoice i JOIN t_order o ON i.order_id = o.id
+			WHERE i.user_id = ? ORDER BY i.id DESC LIMIT ? OFFSET ?`
+	err = d.db.Select(&invoices, sql, userId, limit, offset)
+	return
+}
+
+// CountByUserId 查询用户的收据数量
+func (d *InvoiceDao) CountByUserId(userId uint32) (count uint32, err error) {
+	err = d.db.Get(&count, `SELECT COUNT(*) FROM t_invoice WHERE user_id = ?`, userId)
+	return
+}
+
+// FindByInvoiceNo 根据收据编号查询收据以及生成的收据内容
+func (d *InvoiceDao) FindByInvoiceNo(invoiceNo string) (*model.Invoice, error) {
+	sql := `SELECT i.id, i.invoice_no, i.order_id, i.order_no, i.user_id, i.buyer_name, i.buyer_email, i.product_name,
+			i.product_type, i.amount, i.payment_method, i.paid_time, i.create_time, o.status AS order_status, i.html, i.pdf
+			FROM t_invoice i JOIN t_order o ON i.order_id = o.id
+			WHERE i.invoice_no = ?`
+	var invoice model.Invoice
+	err := d.db.Get(&invoice, sql, invoiceNo)
+	return &invoice, err
+}
+
+// UpdateContent 保存生成的收据
+func (d *InvoiceDao) UpdateContent(id uint32, html, pdf []byte) error {
+	_, err := d.db.Exec(`UPDATE t_invoice SET html = ?, pdf = ? WHERE id = ?`, html, pdf, id)
+	return err
+}
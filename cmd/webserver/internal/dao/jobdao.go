@@ -0,0 +1,53 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao/db"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+)
+
+type JobDao struct {
+	db *sqlx.DB
+}
+
+func NewJobDao() *JobDao {
+	return &JobDao{
+		db: db.DB(),
+	}
+}
+
+// Start 记录任务开始执行, 返回记录的id
+func (d *JobDao) Start(name, instance string, startTime time.Time) (uint32, error) {
+	sql := `INSERT INTO t_job_history (name, instance, status, start_time) VALUES (?, ?, ?, ?)`
+	res, err := d.db.Exec(sql, name, instance, model.JobStatusRunning, startTime)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+
+	return uint32(id), err
+}
+
+// Finish 记录任务执行结束
+func (d *JobDao) Finish(id uint32, status uint8, message string, endTime time.Time) error {
+	sql := `UPDATE t_job_history SET status = ?, message = ?, end_time = ? WHERE id = ?`
+	_, err := d.db.Exec(sql, status, message, endTime, id)
+	return err
+}
+
+// FindHistory 分页查询任务的执行记录, 按照时间倒序, name为空时查询所有任务
+func (d *JobDao) FindHistory(name string, limit, offset int) (history []model.JobHistory, err error) {
+	if name == "" {
+		sql := `SELECT id, name, instance, status, message, start_time, end_time FROM t_job_history 
+				ORDER BY id DESC LIMIT ? OFFSET ?`
+		err = d.db.Select(&history, sql, limit, offset)
+		return
+	}
+
+	sql := `SELECT id, name, instance, status, message, start_time, end_time FROM t_job_history 
+			WHERE name = ? ORDER BY id DESC LIMIT ? OFFSET ?`
+	err = d.db.Select(&history, sql, name, limit, offset)
+	return
+}
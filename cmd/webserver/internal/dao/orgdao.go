@@ -0,0 +1,136 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao/db"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+)
+
+// OrgDao 组织和组织成员, 组织的订阅在支付事务中修改, 见PaymentTx
+type OrgDao struct {
+	db *sqlx.DB
+}
+
+func NewOrgDao() *OrgDao {
+	return &OrgDao{
+		db: db.DB(),
+	}
+}
+
+const orgColumns = `o.id, o.uid, o.name, o.owner_id, o.vip_status, o.vip_expire_time, o.create_time, o.update_time`
+
+// Insert 创建组织, 创建者作为所有者加入组织, 返回组织id
+func (d *OrgDao) Insert(org *model.Organization) (uint32, error) {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	sql := `INSERT INTO t_organization (uid, name, owner_id, vip_status, create_time, update_time) VALUES (?, ?, ?, ?, ?, ?)`
+	res, err := tx.Exec(sql, org.Uid, org.Name, org.OwnerId, model.VipStatusNormal, org.CreateTime, org.UpdateTime)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	sql = `INSERT INTO t_org_member (org_id, user_id, role, create_time) VALUES (?, ?, ?, ?)`
+	if _, err = tx.Exec(sql, id, org.OwnerId, model.OrgRoleOwner, org.CreateTime); err != nil {
+		return 0, err
+	}
+
+	return uint32(id), tx.Commit()
+}
+
+// FindById 根据id查询组织
+func (d *OrgDao) FindById(id uint32) (*model.Organization, error) {
+	sql := `SELECT ` + orgColumns + ` FROM t_organization o WHERE o.id = ?`
+	var org model.Organization
+	err := d.db.Get(&org, sql, id)
+	return &org, err
+}
+
+// FindByUserId 查询用户加入的所有组织以及用户在组织中的角色
+func (d *OrgDao) FindByUserId(userId uint32) (orgs []model.Organization, err error) {
+	sql := `SELECT ` + orgColumns + `, m.role FROM t_organization o JOIN t_org_member m ON o.id = m.org_id
+			WHERE m.user_id = ? ORDER BY o.id`
+	err = d.db.Select(&orgs, sql, userId)
+	return
+}
+
+// FindWithRole 查询组织以及用户在组织中的角色, 用户不是组织的成员时返回sql.ErrNoRows
+func (d *OrgDao) FindWithRole(id, userId uint32) (*model.Organization, error) {
+	sql := `SELECT ` + orgColumns + `, m.role FROM t_organization o JOIN t_org_member m ON o.id = m.org_id
+			WHERE o.id = ? AND m.user_id = ?`
+	var org model.Organization
+	err := d.db.Get(&org, sql, id, userId)
+	return &org, err
+}
+
+// CountByOwnerId 查询用户创建的组织数量
+func (d *OrgDao) CountByOwnerId(ownerId uint32) (count uint32, err error) {
+	err = d.db.Get(&count, `SELECT COUNT(*) FROM t_organization WHERE owner_id = ?`, ownerId)
+	return
+}
+
+// FindMembers 查询组织的所有成员
+func (d *OrgDao) FindMembers(orgId uint32) (members []model.OrgMember, err error) {
+	sql := `SELECT m.id, m.org_id, m.user_id, u.username, u.nickname, m.role, m.create_time
+			FROM t_org_member m JOIN t_user u ON m.user_id = u.id WHERE m.org_id = ? ORDER BY m.id`
+	err = d.db.Select(&members, sql, orgId)
+	return
+}
+
+// FindMemberRole 查询用户在组织中的角色, 用户不是组织的成员时返回sql.ErrNoRows
+func (d *OrgDao) FindMemberRole(orgId, userId uint32) (role string, err error) {
+	err = d.db.Get(&role, `SELECT role FROM t_org_member WHERE org_id = ? AND user_id = ?`, orgId, userId)
+	return
+}
+
+// CountMembers 查询组织的成员数量
+func (d *OrgDao) CountMembers(orgId uint32) (count uint32, err error) {
+	err = d.db.Get(&count, `SELECT COUNT(*) FROM t_org_member WHERE org_id = ?`, orgId)
+	return
+}
+
+// InsertMember 添加组织成员, 用户已经是成员时返回唯一键冲突的错误
+func (d *OrgDao) InsertMember(orgId, userId uint32, role string) error {
+	sql := `INSERT INTO t_org_member (org_id, user_id, role, create_time) VALUES (?, ?, ?, ?)`
+	_, err := d.db.Exec(sql, orgId, userId, role, time.Now())
+	return err
+}
+
+// UpdateMemberRole 修改成员的角色, 成员不存在时返回false
+func (d *OrgDao) UpdateMemberRole(orgId, userId uint32, role string) (bool, error) {
+	res, err := d.db.Exec(`UPDATE t_org_member SET role = ? WHERE org_id = ? AND user_id = ?`, role, orgId, userId)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// DeleteMember 移除组织成员, 成员不存在时返回false
+func (d *OrgDao) DeleteMember(orgId, userId uint32) (bool, error) {
+	res, err := d.db.Exec(`DELETE FROM t_org_member WHERE org_id = ? AND user_id = ?`, orgId, userId)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetActiveSubscription 获取组织的有效订阅
+func (d *OrgDao) GetActiveSubscription(orgId uint32) (*model.UserSubscription, error) {
+	sql := `SELECT id, user_id, org_id, subscription_type, start_time, end_time, status, order_id, create_time, update_time
+			FROM t_user_subscription WHERE org_id = ? AND status = ? AND end_time > NOW()
+			ORDER BY end_time DESC LIMIT 1`
+	var subscription model.UserSubscription
+	err := d.db.Get(&subscription, sql, orgId, model.SubscriptionStatusActive)
+	return &subscription, err
+}
@@ -49,20 +49,10 @@ func (d *PaymentDao) GetPaymentProductById(productId uint32) (*model.PaymentProd
 
 // =============== Order 相关 ===============
 
-// CreateOrder 创建订单
-func (d *PaymentDao) CreateOrder(order *model.Order) error {
-	sql := `INSERT INTO t_order (order_no, user_id, product_id, product_name, product_type, amount, 
-			status, payment_method) 
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
-	_, err := d.db.Exec(sql, order.OrderNo, order.UserId, order.ProductId, order.ProductName,
-		order.ProductType, order.Amount, order.Status, order.PaymentMethod)
-	return err
-}
-
 // GetOrderByOrderNo 根据订单号获取订单
 func (d *PaymentDao) GetOrderByOrderNo(orderNo string) (*model.Order, error) {
 	sql := `SELECT id, order_no, user_id, product_id, product_name, product_type, amount, 
-			status, payment_method, payment_time as paid_at, create_time, update_time 
+			status, payment_method, payment_time as paid_at, refund_time, refund_reason, coupon_id, original_amount, discount_amount, org_id, create_time, update_time 
 			FROM t_order WHERE order_no = ?`
 	var order model.Order
 	err := d.db.Get(&order, sql, orderNo)
@@ -72,47 +62,65 @@ func (d *PaymentDao) GetOrderByOrderNo(orderNo string) (*model.Order, error) {
 // GetOrdersByUserId 根据用户ID获取订单列表
 func (d *PaymentDao) GetOrdersByUserId(userId uint32, limit, offset int) ([]model.Order, error) {
 	sql := `SELECT id, order_no, user_id, product_id, product_name, product_type, amount, 
-			status, payment_method, payment_time as paid_at, create_time, update_time 
+			status, payment_method, payment_time as paid_at, refund_time, refund_reason, coupon_id, original_amount, discount_amount, org_id, create_time, update_time 
 			FROM t_order WHERE user_id = ? ORDER BY create_time DESC LIMIT ? OFFSET ?`
 	var orders []model.Order
 	err := d.db.Select(&orders, sql, userId, limit, offset)
 	return orders, err
 }
 
-// UpdateOrderStatus 更新订单状态
-func (d *PaymentDao) UpdateOrderStatus(orderNo string, status uint8, tradeNo string, paidAt *time.Time) error {
-	sql := `UPDATE t_order SET status = ?, payment_time = ? WHERE order_no = ?`
-	_, err := d.db.Exec(sql, status, paidAt, orderNo)
-	return err
-}
+// CloseExpiredOrders 关闭在before之前创建的未支付订单, 将订单处理中的支付记录设置为失败并释放占用的优惠码, 返回关闭的订单数量
+func (d *PaymentDao) CloseExpiredOrders(before time.Time) (int64, error) {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
 
-// =============== UserSubscription 相关 ===============
+	sql := `UPDATE t_order SET status = ? WHERE status = ? AND create_time < ?`
+	res, err := tx.Exec(sql, model.OrderStatusClosed, model.OrderStatusPending, before)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil || n == 0 {
+		return 0, err
+	}
 
-// CreateUserSubscription 创建用户订阅
-func (d *PaymentDao) CreateUserSubscription(subscription *model.UserSubscription) error {
-	sql := `INSERT INTO t_user_subscription (user_id, subscription_type, start_time, end_time, 
-			status, order_id, create_time, update_time) 
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
-	_, err := d.db.Exec(sql, subscription.UserId, subscription.SubscriptionType,
-		subscription.StartTime, subscription.EndTime, subscription.Status,
-		subscription.OrderId, subscription.CreateTime, subscription.UpdateTime)
-	return err
+	sql = `UPDATE t_payment_record r JOIN t_order o ON r.order_id = o.id SET r.status = ?, r.update_time = ?
+			WHERE o.status = ? AND r.status = ?`
+	_, err = tx.Exec(sql, model.PaymentStatusFailed, time.Now(), model.OrderStatusClosed, model.PaymentStatusProcessing)
+	if err != nil {
+		return 0, err
+	}
+
+	// 释放关闭的订单占用的优惠码
+	sql = `UPDATE t_coupon_redemption r JOIN t_order o ON r.order_id = o.id SET r.status = ?, r.update_time = ?
+			WHERE o.status = ? AND r.status = ?`
+	_, err = tx.Exec(sql, model.RedemptionReleased, time.Now(), model.OrderStatusClosed, model.RedemptionReserved)
+	if err != nil {
+		return 0, err
+	}
+
+	return n, tx.Commit()
 }
 
-// GetActiveSubscriptionByUserId 获取用户的有效订阅
+// =============== UserSubscription 相关 ===============
+
+// GetActiveSubscriptionByUserId 获取用户的有效订阅, 不包含为组织购买的订阅
 func (d *PaymentDao) GetActiveSubscriptionByUserId(userId uint32) (*model.UserSubscription, error) {
 	sql := `SELECT id, user_id, subscription_type, start_time, end_time, status, order_id, create_time, update_time 
-			FROM t_user_subscription WHERE user_id = ? AND status = 1 AND end_time > NOW() 
+			FROM t_user_subscription WHERE user_id = ? AND org_id = 0 AND status = 1 AND end_time > NOW() 
 			ORDER BY end_time DESC LIMIT 1`
 	var subscription model.UserSubscription
 	err := d.db.Get(&subscription, sql, userId)
 	return &subscription, err
 }
 
-// GetSubscriptionsByUserId 获取用户的所有订阅记录
+// GetSubscriptionsByUserId 获取用户的所有订阅记录, 不包含为组织购买的订阅
 func (d *PaymentDao) GetSubscriptionsByUserId(userId uint32) ([]model.UserSubscription, error) {
 	sql := `SELECT id, user_id, subscription_type, start_time, end_time, status, order_id, create_time, update_time 
-			FROM t_user_subscription WHERE user_id = ? ORDER BY create_time DESC`
+			FROM t_user_subscription WHERE user_id = ? AND org_id = 0 ORDER BY create_time DESC`
 	var subscriptions []model.UserSubscription
 	err := d.db.Select(&subscriptions, sql, userId)
 	return subscriptions, err
@@ -128,24 +136,17 @@ func (d *PaymentDao) ExpireSubscriptions() error {
 
 // =============== PaymentRecord 相关 ===============
 
-// CreatePaymentRecord 创建支付记录
+// CreatePaymentRecord 创建支付记录, 交易号为空时保存为NULL
 func (d *PaymentDao) CreatePaymentRecord(record *model.PaymentRecord) error {
 	sql := `INSERT INTO t_payment_record (order_id, user_id, payment_gateway, payment_method, 
 			amount, trade_no, gateway_order_no, status, callback_data, create_time, update_time) 
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+			VALUES (?, ?, ?, ?, ?, NULLIF(?, ''), ?, ?, ?, ?, ?)`
 	_, err := d.db.Exec(sql, record.OrderId, record.UserId, record.PaymentGateway,
 		record.PaymentMethod, record.Amount, record.TradeNo, record.GatewayOrderNo,
 		record.Status, record.CallbackData, record.CreateTime, record.UpdateTime)
 	return err
 }
 
-// UpdatePaymentRecordStatus 更新支付记录状态
-func (d *PaymentDao) UpdatePaymentRecordStatus(tradeNo string, status uint8, callbackData string) error {
-	sql := `UPDATE t_payment_record SET status = ?, callback_data = ?, update_time = ? WHERE trade_no = ?`
-	_, err := d.db.Exec(sql, status, callbackData, time.Now(), tradeNo)
-	return err
-}
-
 // GetPaymentRecordByTradeNo 根据交易号获取支付记录
 func (d *PaymentDao) GetPaymentRecordByTradeNo(tradeNo string) (*model.PaymentRecord, error) {
 	sql := `SELECT id, order_id, user_id, payment_gateway, payment_method, amount, trade_no, 
@@ -178,4 +179,53 @@ func (d *PaymentDao) BatchExpireUserVip() error {
 	sql := `UPDATE t_user SET vip_status = 0 WHERE vip_status = 1 AND vip_expire_time <= NOW()`
 	_, err := d.db.Exec(sql)
 	return err
-} 
\ No newline at end of file
+}
+
+// BatchExpireOrgVip 批量过期组织VIP
+func (d *PaymentDao) BatchExpireOrgVip() error {
+	sql := `UPDATE t_organization SET vip_status = 0 WHERE vip_status = 1 AND vip_expire_time <= NOW()`
+	_, err := d.db.Exec(sql)
+	return err
+}
+
+// CountVipUsers 获取有效的VIP用户数量
+func (d *PaymentDao) CountVipUsers() (count int, err error) {
+	sql := `SELECT COUNT(*) FROM t_user WHERE vip_status = 1 AND vip_expire_time > NOW()`
+	err = d.db.Get(&count, sql)
+	return
+}
+
+// CountVipExpiringBefore 获取在指定时间之前过期的有效VIP用户数量
+func (d *PaymentDao) CountVipExpiringBefore(t time.Time) (count int, err error) {
+	sql := `SELECT COUNT(*) FROM t_user WHERE vip_status = 1 AND vip_expire_time > NOW() AND vip_expire_time <= ?`
+	err = d.db.Get(&count, sql, t)
+	return
+}
+
+// FindVipExpiringBefore 查询在指定时间之前过期并且有邮箱的有效VIP用户
+func (d *PaymentDao) FindVipExpiringBefore(t time.Time) (users []model.User, err error) {
+	sql := `SELECT id, username, nickname, email, vip_status, vip_expire_time FROM t_user 
+			WHERE vip_status = 1 AND vip_expire_time > NOW() AND vip_expire_time <= ? AND email != ''`
+	err = d.db.Select(&users, sql, t)
+	return
+}
+
+// AddVipReminder 记录用户在该过期时间已经被提醒过, 已经提醒过时返回false
+func (d *PaymentDao) AddVipReminder(userId uint32, expireTime time.Time) (bool, error) {
+	sql := `INSERT IGNORE INTO t_vip_reminder (user_id, expire_time, create_time) VALUES (?, ?, ?)`
+	res, err := d.db.Exec(sql, userId, expireTime, time.Now())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+
+	return n == 1, err
+}
+
+// CountActiveSubscribersByType 按订阅类型统计有效订阅的用户数量
+func (d *PaymentDao) CountActiveSubscribersByType() (counts []model.SubscriptionCount, err error) {
+	sql := `SELECT subscription_type, COUNT(DISTINCT user_id) AS count FROM t_user_subscription 
+			WHERE status = 1 AND end_time > NOW() GROUP BY subscription_type`
+	err = d.db.Select(&counts, sql)
+	return
+}
@@ -0,0 +1,264 @@
+package dao
+
+import (
+	dsql "database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+)
+
+// PaymentTx 支付相关的事务, 订单、支付记录、订阅、VIP和余额的修改在同一个事务中完成
+type PaymentTx struct {
+	tx *sqlx.Tx
+}
+
+// Tx 在事务中执行fn, fn返回错误时回滚
+func (d *PaymentDao) Tx(fn func(tx *PaymentTx) error) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = fn(&PaymentTx{tx: tx}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateOrder 创建订单
+func (t *PaymentTx) CreateOrder(order *model.Order) error {
+	sql := `INSERT INTO t_order (order_no, user_id, product_id, product_name, product_type, amount,
+			status, payment_method, coupon_id, original_amount, discount_amount, org_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	res, err := t.tx.Exec(sql, order.OrderNo, order.UserId, order.ProductId, order.ProductName,
+		order.ProductType, order.Amount, order.Status, order.PaymentMethod,
+		order.CouponId, order.OriginalAmount, order.DiscountAmount, order.OrgId)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	order.Id = uint32(id)
+	return err
+}
+
+// GetOrderForUpdate 根据订单号获取并锁定订单, 同一个订单的处理在这里排队
+func (t *PaymentTx) GetOrderForUpdate(orderNo string) (*model.Order, error) {
+	sql := `SELECT id, order_no, user_id, product_id, product_name, product_type, amount,
+			status, payment_method, payment_time as paid_at, refund_time, refund_reason, coupon_id, original_amount, discount_amount, org_id, create_time, update_time
+			FROM t_order WHERE order_no = ? FOR UPDATE`
+	var order model.Order
+	err := t.tx.Get(&order, sql, orderNo)
+	return &order, err
+}
+
+// SetOrderPaid 将订单设置为已支付
+func (t *PaymentTx) SetOrderPaid(orderId uint32, paidAt time.Time) error {
+	sql := `UPDATE t_order SET status = ?, payment_time = ? WHERE id = ?`
+	_, err := t.tx.Exec(sql, model.OrderStatusPaid, paidAt, orderId)
+	return err
+}
+
+// SetOrderRefunding 将订单设置为退款中, 支付网关退款成功后再设置为已退款
+func (t *PaymentTx) SetOrderRefunding(orderId uint32, reason string) error {
+	sql := `UPDATE t_order SET status = ?, refund_reason = ? WHERE id = ?`
+	_, err := t.tx.Exec(sql, model.OrderStatusRefunding, reason, orderId)
+	return err
+}
+
+// SetOrderRefunded 将订单设置为已退款
+func (t *PaymentTx) SetOrderRefunded(orderId uint32, reason string, refundTime time.Time) error {
+	sql := `UPDATE t_order SET status = ?, refund_time = ?, refund_reason = ? WHERE id = ?`
+	_, err := t.tx.Exec(sql, model.OrderStatusRefunded, refundTime, reason, orderId)
+	return err
+}
+
+// GetPaymentRecordByOrderId 获取订单的支付记录
+func (t *PaymentTx) GetPaymentRecordByOrderId(orderId uint32) (*model.PaymentRecord, error) {
+	sql := `SELECT id, order_id, user_id, payment_gateway, payment_method, amount, COALESCE(trade_no, '') AS trade_no,
+			gateway_order_no, status, callback_data, create_time, update_time
+			FROM t_payment_record WHERE order_id = ? ORDER BY id DESC LIMIT 1`
+	var record model.PaymentRecord
+	err := t.tx.Get(&record, sql, orderId)
+	return &record, err
+}
+
+// UpdatePaymentRecordStatus 更新订单的支付记录状态, 交易号有唯一约束, 同一笔交易不能完成多个订单
+func (t *PaymentTx) UpdatePaymentRecordStatus(orderId uint32, status uint8, tradeNo, callbackData string) error {
+	sql := `UPDATE t_payment_record SET status = ?, trade_no = NULLIF(?, ''), callback_data = ?, update_time = ? WHERE order_id = ?`
+	_, err := t.tx.Exec(sql, status, tradeNo, callbackData, time.Now(), orderId)
+	return err
+}
+
+// SetPaymentRecordRefunded 将订单支付成功的记录设置为已退款
+func (t *PaymentTx) SetPaymentRecordRefunded(orderId uint32) error {
+	sql := `UPDATE t_payment_record SET status = ?, update_time = ? WHERE order_id = ? AND status = ?`
+	_, err := t.tx.Exec(sql, model.PaymentStatusRefunded, time.Now(), orderId, model.PaymentStatusSuccess)
+	return err
+}
+
+// GetUserVipInfoForUpdate 获取并锁定用户的VIP信息, 同一个用户的续期和退款在这里排队
+func (t *PaymentTx) GetUserVipInfoForUpdate(userId uint32) (*model.User, error) {
+	sql := `SELECT id, vip_status, vip_expire_time FROM t_user WHERE id = ? FOR UPDATE`
+	var user model.User
+	err := t.tx.Get(&user, sql, userId)
+	return &user, err
+}
+
+// UpdateUserVipStatus 更新用户VIP状态
+func (t *PaymentTx) UpdateUserVipStatus(userId uint32, vipStatus uint8, expireTime *time.Time) error {
+	sql := `UPDATE t_user SET vip_status = ?, vip_expire_time = ? WHERE id = ?`
+	_, err := t.tx.Exec(sql, vipStatus, expireTime, userId)
+	return err
+}
+
+// GetOrgVipInfoForUpdate 获取并锁定组织的VIP信息, 同一个组织的续期和退款在这里排队
+func (t *PaymentTx) GetOrgVipInfoForUpdate(orgId uint32) (*model.Organization, error) {
+	sql := `SELECT id, vip_status, vip_expire_time FROM t_organization WHERE id = ? FOR UPDATE`
+	var org model.Organization
+	err := t.tx.Get(&org, sql, orgId)
+	return &org, err
+}
+
+// UpdateOrgVipStatus 更新组织VIP状态
+func (t *PaymentTx) UpdateOrgVipStatus(orgId uint32, vipStatus uint8, expireTime *time.Time) error {
+	sql := `UPDATE t_organization SET vip_status = ?, vip_expire_time = ?, update_time = ? WHERE id = ?`
+	_, err := t.tx.Exec(sql, vipStatus, expireTime, time.Now(), orgId)
+	return err
+}
+
+// CreateUserSubscription 创建用户或组织的订阅
+func (t *PaymentTx) CreateUserSubscription(subscription *model.UserSubscription) error {
+	sql := `INSERT INTO t_user_subscription (user_id, org_id, subscription_type, start_time, end_time,
+			status, order_id, create_time, update_time)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := t.tx.Exec(sql, subscription.UserId, subscription.OrgId, subscription.SubscriptionType,
+		subscription.StartTime, subscription.EndTime, subscription.Status,
+		subscription.OrderId, subscription.CreateTime, subscription.UpdateTime)
+	return err
+}
+
+// GetSubscriptionByOrderId 获取订单开通的订阅
+func (t *PaymentTx) GetSubscriptionByOrderId(orderId uint32) (*model.UserSubscription, error) {
+	sql := `SELECT id, user_id, org_id, subscription_type, start_time, end_time, status, order_id, create_time, update_time
+			FROM t_user_subscription WHERE order_id = ? FOR UPDATE`
+	var subscription model.UserSubscription
+	err := t.tx.Get(&subscription, sql, orderId)
+	return &subscription, err
+}
+
+// RefundSubscription 将订阅设置为已退款, 订阅在endTime结束
+func (t *PaymentTx) RefundSubscription(id uint32, endTime time.Time) error {
+	sql := `UPDATE t_user_subscription SET status = ?, end_time = ?, update_time = ? WHERE id = ?`
+	_, err := t.tx.Exec(sql, model.SubscriptionStatusRefunded, endTime, time.Now(), id)
+	return err
+}
+
+// ShiftSubscriptions 将用户或组织在from之后开始的有效订阅提前d, orgId不为0时为组织的订阅
+func (t *PaymentTx) ShiftSubscriptions(userId, orgId uint32, from time.Time, d time.Duration) error {
+	seconds := int64(d / time.Second)
+	sql := `UPDATE t_user_subscription SET start_time = DATE_SUB(start_time, INTERVAL ? SECOND),
+			end_time = DATE_SUB(end_time, INTERVAL ? SECOND), update_time = ?
+			WHERE status = ? AND start_time >= ? AND `
+	args := []interface{}{seconds, seconds, time.Now(), model.SubscriptionStatusActive, from}
+	if orgId != 0 {
+		sql += `org_id = ?`
+		args = append(args, orgId)
+	} else {
+		sql += `user_id = ? AND org_id = 0`
+		args = append(args, userId)
+	}
+	_, err := t.tx.Exec(sql, args...)
+	return err
+}
+
+// FindBalanceForUpdate 查询并锁定用户的余额, 没有充值过的用户余额为0
+func (t *PaymentTx) FindBalanceForUpdate(userId uint32) (balance int64, err error) {
+	sql := `SELECT balance FROM t_user_credit WHERE user_id = ? FOR UPDATE`
+	err = t.tx.Get(&balance, sql, userId)
+	if errors.Is(err, dsql.ErrNoRows) {
+		return 0, nil
+	}
+	return
+}
+
+// AddBalance 修改用户的余额并记录变动, 返回修改后的余额
+func (t *PaymentTx) AddBalance(userId uint32, amount int64, typ, ref string) (int64, error) {
+	return addBalance(t.tx, userId, amount, typ, ref)
+}
+
+// CreateInvoice 为已支付的订单开具收据, 返回收据编号, 收据编号按年连续递增
+// 序号在事务提交前一直被锁定, 事务回滚时序号也回滚, 编号不会出现空缺
+func (t *PaymentTx) CreateInvoice(order *model.Order, paidAt time.Time) (string, error) {
+	year := paidAt.Year()
+	sql := `INSERT INTO t_invoice_seq (year, seq) VALUES (?, 1) ON DUPLICATE KEY UPDATE seq = seq + 1`
+	if _, err := t.tx.Exec(sql, year); err != nil {
+		return "", err
+	}
+	var seq uint32
+	if err := t.tx.Get(&seq, `SELECT seq FROM t_invoice_seq WHERE year = ?`, year); err != nil {
+		return "", err
+	}
+
+	invoiceNo := fmt.Sprintf("INV%d%06d", year, seq)
+	sql = `INSERT INTO t_invoice (invoice_no, order_id, order_no, user_id, buyer_name, buyer_email,
+			product_name, product_type, amount, payment_method, paid_time, create_time)
+			SELECT ?, ?, ?, id, IF(nickname = '', username, nickname), COALESCE(email, ''), ?, ?, ?, ?, ?, ?
+			FROM t_user WHERE id = ?`
+	_, err := t.tx.Exec(sql, invoiceNo, order.Id, order.OrderNo, order.ProductName, order.ProductType,
+		order.Amount, order.PaymentMethod, paidAt, time.Now(), order.UserId)
+	if err != nil {
+		return "", err
+	}
+
+	return invoiceNo, nil
+}
+
+// GetCouponByCodeForUpdate 根据优惠码获取并锁定优惠码, 同一个优惠码的使用在这里排队
+func (t *PaymentTx) GetCouponByCodeForUpdate(code string) (*model.Coupon, error) {
+	sql := `SELECT id, code, name, discount_type, discount_value, product_types, first_purchase, max_redemptions,
+			per_user_limit, start_time, end_time, status, create_time, update_time
+			FROM t_coupon WHERE code = ? FOR UPDATE`
+	var coupon model.Coupon
+	err := t.tx.Get(&coupon, sql, code)
+	return &coupon, err
+}
+
+// GetCouponByIdForUpdate 根据id获取并锁定优惠码
+func (t *PaymentTx) GetCouponByIdForUpdate(id uint32) (*model.Coupon, error) {
+	sql := `SELECT id, code, name, discount_type, discount_value, product_types, first_purchase, max_redemptions,
+			per_user_limit, start_time, end_time, status, create_time, update_time
+			FROM t_coupon WHERE id = ? FOR UPDATE`
+	var coupon model.Coupon
+	err := t.tx.Get(&coupon, sql, id)
+	return &coupon, err
+}
+
+// CountCouponRedemptions 查询优惠码总共和用户已经使用的次数
+func (t *PaymentTx) CountCouponRedemptions(couponId, userId uint32) (total, user uint32, err error) {
+	return countCouponRedemptions(t.tx, couponId, userId)
+}
+
+// CountPaidOrders 查询用户已经支付过的订单数量
+func (t *PaymentTx) CountPaidOrders(userId uint32) (uint32, error) {
+	return countPaidOrders(t.tx, userId)
+}
+
+// CreateCouponRedemption 创建订单时占用优惠码
+func (t *PaymentTx) CreateCouponRedemption(r *model.CouponRedemption) error {
+	sql := `INSERT INTO t_coupon_redemption (coupon_id, user_id, order_id, discount, status, create_time, update_time)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err := t.tx.Exec(sql, r.CouponId, r.UserId, r.OrderId, r.Discount, r.Status, r.CreateTime, r.UpdateTime)
+	return err
+}
+
+// UseCouponRedemption 订单支付后将优惠码的使用记录设置为已使用
+func (t *PaymentTx) UseCouponRedemption(orderId uint32) error {
+	sql := `UPDATE t_coupon_redemption SET status = ?, update_time = ? WHERE order_id = ?`
+	_, err := t.tx.Exec(sql, model.RedemptionUsed, time.Now(), orderId)
+	return err
+}
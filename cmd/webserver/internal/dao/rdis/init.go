@@ -14,8 +14,13 @@ func RedisInstance() *redis.Client {
 	return client
 }
 
+// InitRedis 初始化redis连接, 已经初始化成功时直接返回
 func InitRedis() error {
-	client = redis.NewClient(&redis.Options{
+	if client != nil {
+		return nil
+	}
+
+	c := redis.NewClient(&redis.Options{
 		Addr:         conf.RedisConfig.Addr,
 		Password:     conf.RedisConfig.Password,
 		DB:           int(conf.RedisConfig.DB),
@@ -25,14 +30,18 @@ func InitRedis() error {
 
 	timeoutCtx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
-	_, err := client.Ping(timeoutCtx).Result()
+	_, err := c.Ping(timeoutCtx).Result()
 	if err != nil {
+		c.Close()
 		return err
 	}
+	client = c
 
 	return nil
 }
 
 func CloseRedisConn() {
-	client.Close()
+	if client != nil {
+		client.Close()
+	}
 }
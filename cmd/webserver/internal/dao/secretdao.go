@@ -0,0 +1,120 @@
+package dao
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao/db"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+)
+
+type SecretDao struct {
+	db *sqlx.DB
+}
+
+func NewSecretDao() *SecretDao {
+	return &SecretDao{
+		db: db.DB(),
+	}
+}
+
+func (d *SecretDao) Insert(secret *model.UserSecret) (uint32, error) {
+	sql := "INSERT INTO t_user_secret (user_id, name, env_name, `desc`, hint, ciphertext, data_key, create_time, update_time) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	res, err := d.db.Exec(sql, secret.UserId, secret.Name, secret.EnvName, secret.Desc, secret.Hint,
+		secret.Ciphertext, secret.DataKey, secret.CreateTime, secret.UpdateTime)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+
+	return uint32(id), err
+}
+
+// FindByUserId 查询用户的所有密钥, 不查询密文
+func (d *SecretDao) FindByUserId(userId uint32) (secrets []model.UserSecret, err error) {
+	sql := "SELECT id, user_id, name, env_name, `desc`, hint, create_time, update_time FROM t_user_secret WHERE user_id = ? ORDER BY create_time DESC"
+	err = d.db.Select(&secrets, sql, userId)
+
+	return
+}
+
+func (d *SecretDao) FindCountByUserId(userId uint32) (count uint32, err error) {
+	sql := `SELECT COUNT(*) FROM t_user_secret WHERE user_id = ?`
+	err = d.db.Get(&count, sql, userId)
+
+	return
+}
+
+func (d *SecretDao) FindByIdAndUserId(id, userId uint32) (secret *model.UserSecret, err error) {
+	sql := "SELECT id, user_id, name, env_name, `desc`, hint, create_time, update_time FROM t_user_secret WHERE id = ? AND user_id = ?"
+	secret = &model.UserSecret{}
+	err = d.db.Get(secret, sql, id, userId)
+
+	return
+}
+
+// FindByNames 根据名称查询用户的密钥
+func (d *SecretDao) FindByNames(userId uint32, names []string) (secrets []model.UserSecret, err error) {
+	query, args, err := sqlx.In("SELECT id, user_id, name, env_name FROM t_user_secret WHERE user_id = ? AND name IN (?)", userId, names)
+	if err != nil {
+		return nil, err
+	}
+	err = d.db.Select(&secrets, d.db.Rebind(query), args...)
+
+	return
+}
+
+// FindByEnvNames 根据环境变量名称查询用户的密钥, 包括密文
+func (d *SecretDao) FindByEnvNames(userId uint32, envNames []string) (secrets []model.UserSecret, err error) {
+	query, args, err := sqlx.In("SELECT id, user_id, name, env_name, ciphertext, data_key FROM t_user_secret WHERE user_id = ? AND env_name IN (?) ORDER BY id", userId, envNames)
+	if err != nil {
+		return nil, err
+	}
+	err = d.db.Select(&secrets, d.db.Rebind(query), args...)
+
+	return
+}
+
+// FindBySpaceId 查询工作空间关联的所有密钥, 包括密文
+func (d *SecretDao) FindBySpaceId(spaceId uint32) (secrets []model.UserSecret, err error) {
+	sql := `SELECT s.id, s.user_id, s.name, s.env_name, s.ciphertext, s.data_key FROM t_user_secret s
+JOIN t_space_secret ss ON ss.secret_id = s.id WHERE ss.space_id = ? ORDER BY s.id`
+	err = d.db.Select(&secrets, sql, spaceId)
+
+	return
+}
+
+func (d *SecretDao) UpdateValueById(secret *model.UserSecret) error {
+	sql := "UPDATE t_user_secret SET `desc` = ?, hint = ?, ciphertext = ?, data_key = ?, update_time = ? WHERE id = ?"
+	_, err := d.db.Exec(sql, secret.Desc, secret.Hint, secret.Ciphertext, secret.DataKey, secret.UpdateTime, secret.Id)
+
+	return err
+}
+
+// DeleteById 删除密钥以及与工作空间的关联
+func (d *SecretDao) DeleteById(id uint32) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(`DELETE FROM t_space_secret WHERE secret_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM t_user_secret WHERE id = ?`, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AttachToSpace 将密钥关联到工作空间, 已关联的忽略
+func (d *SecretDao) AttachToSpace(spaceId uint32, secretIds []uint32) error {
+	sql := `INSERT IGNORE INTO t_space_secret (space_id, secret_id) VALUES (?, ?)`
+	for _, id := range secretIds {
+		if _, err := d.db.Exec(sql, spaceId, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package dao
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao/db"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+)
+
+type ShareDao struct {
+	db *sqlx.DB
+}
+
+func NewShareDao() *ShareDao {
+	return &ShareDao{
+		db: db.DB(),
+	}
+}
+
+const shareColumns = `id, space_id, sid, user_id, permission, status, expire_time, create_time`
+
+func (d *ShareDao) Insert(share *model.SpaceShare) (uint32, error) {
+	sql := `INSERT INTO t_space_share (space_id, sid, user_id, permission, status, expire_time, create_time) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	res, err := d.db.Exec(sql, share.SpaceId, share.Sid, share.UserId, share.Permission, share.Status,
+		share.ExpireTime, share.CreateTime)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+
+	return uint32(id), err
+}
+
+func (d *ShareDao) FindById(id uint32) (share *model.SpaceShare, err error) {
+	sql := `SELECT ` + shareColumns + ` FROM t_space_share WHERE id = ?`
+	share = &model.SpaceShare{}
+	err = d.db.Get(share, sql, id)
+
+	return
+}
+
+// FindActiveBySpaceId 查询工作空间未撤销且未过期的分享链接
+func (d *ShareDao) FindActiveBySpaceId(spaceId uint32) (shares []model.SpaceShare, err error) {
+	sql := `SELECT ` + shareColumns + ` FROM t_space_share WHERE space_id = ? AND status = ? AND expire_time > NOW() ORDER BY id DESC`
+	err = d.db.Select(&shares, sql, spaceId, model.ShareStatusActive)
+
+	return
+}
+
+// FindActiveCountBySpaceId 查询工作空间未撤销且未过期的分享链接数量
+func (d *ShareDao) FindActiveCountBySpaceId(spaceId uint32) (count uint32, err error) {
+	sql := `SELECT COUNT(*) FROM t_space_share WHERE space_id = ? AND status = ? AND expire_time > NOW()`
+	err = d.db.Get(&count, sql, spaceId, model.ShareStatusActive)
+
+	return
+}
+
+// Revoke 撤销分享链接
+func (d *ShareDao) Revoke(id uint32) error {
+	_, err := d.db.Exec(`UPDATE t_space_share SET status = ? WHERE id = ?`, model.ShareStatusRevoked, id)
+	return err
+}
+
+func (d *ShareDao) InsertAccess(access *model.ShareAccess) error {
+	sql := `INSERT INTO t_space_share_access (share_id, sid, permission, ip, user_agent, access_time) VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := d.db.Exec(sql, access.ShareId, access.Sid, access.Permission, access.Ip, access.UserAgent, access.AccessTime)
+	return err
+}
+
+// FindAccessBySpaceId 查询工作空间的分享链接最近的访问记录
+func (d *ShareDao) FindAccessBySpaceId(spaceId uint32, limit int) (records []model.ShareAccess, err error) {
+	sql := `SELECT a.id, a.share_id, a.sid, a.permission, a.ip, a.user_agent, a.access_time FROM t_space_share_access a
+			JOIN t_space_share s ON a.share_id = s.id WHERE s.space_id = ? ORDER BY a.id DESC LIMIT ?`
+	err = d.db.Select(&records, sql, spaceId, limit)
+
+	return
+}
@@ -0,0 +1,59 @@
+package dao
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao/db"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+)
+
+type SnapshotDao struct {
+	db *sqlx.DB
+}
+
+func NewSnapshotDao() *SnapshotDao {
+	return &SnapshotDao{
+		db: db.DB(),
+	}
+}
+
+func (d *SnapshotDao) Insert(snapshot *model.SpaceSnapshot) (uint32, error) {
+	sql := "INSERT INTO t_space_snapshot (space_id, user_id, name, `desc`, method, status, message, create_time) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	res, err := d.db.Exec(sql, snapshot.SpaceId, snapshot.UserId, snapshot.Name, snapshot.Desc,
+		snapshot.Method, snapshot.Status, snapshot.Message, snapshot.CreateTime)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+
+	return uint32(id), err
+}
+
+// FindBySpaceId 查询某个工作空间的所有快照, 按创建时间倒序
+func (d *SnapshotDao) FindBySpaceId(spaceId, userId uint32) (snapshots []model.SpaceSnapshot, err error) {
+	sql := "SELECT id, space_id, user_id, name, `desc`, method, status, message, create_time FROM t_space_snapshot WHERE space_id = ? AND user_id = ? ORDER BY create_time DESC"
+	err = d.db.Select(&snapshots, sql, spaceId, userId)
+
+	return
+}
+
+func (d *SnapshotDao) FindCountBySpaceId(spaceId uint32) (count uint32, err error) {
+	sql := `SELECT COUNT(*) FROM t_space_snapshot WHERE space_id = ?`
+	err = d.db.Get(&count, sql, spaceId)
+
+	return
+}
+
+func (d *SnapshotDao) FindByIdAndUserId(id, userId uint32) (snapshot *model.SpaceSnapshot, err error) {
+	sql := "SELECT id, space_id, user_id, name, `desc`, method, status, message, create_time FROM t_space_snapshot WHERE id = ? AND user_id = ?"
+	snapshot = &model.SpaceSnapshot{}
+	err = d.db.Get(snapshot, sql, id, userId)
+
+	return
+}
+
+func (d *SnapshotDao) UpdateStatusById(id, status uint32, message string) error {
+	sql := `UPDATE t_space_snapshot SET status = ?, message = ? WHERE id = ?`
+	_, err := d.db.Exec(sql, status, message, id)
+
+	return err
+}
@@ -16,11 +16,12 @@ func NewSpaceDao() *SpaceDao {
 	}
 }
 
+const insertSpaceSql = `INSERT INTO t_space
+(user_id, org_id, tmpl_id, spec_id, sid, name, status, create_time, delete_time, stop_time, total_time, git_repository)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
 func (d *SpaceDao) Insert(space *model.Space) (uint32, error) {
-	sql := `INSERT INTO t_space 
-(user_id, tmpl_id, spec_id, sid, name, status, create_time, delete_time, stop_time, total_time, git_repository)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-	res, err := d.db.Exec(sql, space.UserId, space.TmplId, space.SpecId, space.Sid, space.Name,
+	res, err := d.db.Exec(insertSpaceSql, space.UserId, space.OrgId, space.TmplId, space.SpecId, space.Sid, space.Name,
 		space.Status, space.CreateTime, space.DeleteTime, space.StopTime, space.TotalTime, space.GitRepository)
 	if err != nil {
 		return 0, err
@@ -30,24 +31,110 @@ VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 	return uint32(id), err
 }
 
+// InsertWithLock 在事务中锁定用户或组织后插入工作空间, 同一个用户或组织的创建请求串行执行
+// check在插入之前根据用户或组织已有工作空间的规格检查配额, 返回错误时不插入
+func (d *SpaceDao) InsertWithLock(space *model.Space, check func(specIds []uint32) error) (uint32, error) {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	specIds, err := lockOwnerSpecs(tx, space.UserId, space.OrgId, 0)
+	if err != nil {
+		return 0, err
+	}
+	if err = check(specIds); err != nil {
+		return 0, err
+	}
+
+	res, err := tx.Exec(insertSpaceSql, space.UserId, space.OrgId, space.TmplId, space.SpecId, space.Sid, space.Name,
+		space.Status, space.CreateTime, space.DeleteTime, space.StopTime, space.TotalTime, space.GitRepository)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint32(id), tx.Commit()
+}
+
+// UpdateSpecWithLock 在事务中锁定用户或组织后修改工作空间的规格, 与InsertWithLock使用同一个锁
+// check在修改之前根据用户或组织其它工作空间的规格检查配额, 返回错误时不修改
+func (d *SpaceDao) UpdateSpecWithLock(space *model.Space, specId uint32, check func(specIds []uint32) error) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	specIds, err := lockOwnerSpecs(tx, space.UserId, space.OrgId, space.Id)
+	if err != nil {
+		return err
+	}
+	if err = check(specIds); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(`UPDATE t_space SET spec_id = ? WHERE id = ?`, specId, space.Id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// lockOwnerSpecs 锁定用户或组织, 返回其未删除的工作空间的规格, 不包含id为excludeId的工作空间
+func lockOwnerSpecs(tx *sqlx.Tx, userId, orgId, excludeId uint32) ([]uint32, error) {
+	var ownerId uint32
+	lock := `SELECT id FROM t_user WHERE id = ? FOR UPDATE`
+	if orgId != 0 {
+		lock = `SELECT id FROM t_organization WHERE id = ? FOR UPDATE`
+	}
+	where, args := spaceOwnerCondition(userId, orgId)
+	if err := tx.Get(&ownerId, lock, args[0]); err != nil {
+		return nil, err
+	}
+
+	var specIds []uint32
+	sql := `SELECT spec_id FROM t_space WHERE status != ? AND id != ? AND ` + where
+	err := tx.Select(&specIds, sql, append([]interface{}{model.SpaceStatusDeleted, excludeId}, args...)...)
+
+	return specIds, err
+}
+
+// spaceOwnerCondition 工作空间和运行记录所属用户或组织的查询条件, orgId不为0时为组织
+// 返回的参数中第一个为用户id或组织id
+func spaceOwnerCondition(userId, orgId uint32) (string, []interface{}) {
+	if orgId != 0 {
+		return `org_id = ?`, []interface{}{orgId}
+	}
+
+	return `user_id = ? AND org_id = 0`, []interface{}{userId}
+}
+
 // FindByUserIdAndName TODO 增加联合索引 idx_userid_name
-// 根据userid和name查询, 用于查询某个用户下的space名称是否重复
-func (d *SpaceDao) FindByUserIdAndName(userId uint32, name string) error {
-	sql := `SELECT id FROM t_space WHERE user_id = ? AND name = ? AND status != ?`
+// 根据userid和name查询, 用于查询某个用户或组织下的space名称是否重复
+func (d *SpaceDao) FindByUserIdAndName(userId, orgId uint32, name string) error {
+	where, args := spaceOwnerCondition(userId, orgId)
+	sql := `SELECT id FROM t_space WHERE name = ? AND status != ? AND ` + where
 	var id uint32
-	return d.db.Get(&id, sql, userId, name, model.SpaceStatusDeleted)
+	return d.db.Get(&id, sql, append([]interface{}{name, model.SpaceStatusDeleted}, args...)...)
 }
 
 func (d *SpaceDao) FindCountByUserId(userId uint32) (count uint32, err error) {
-	sql := `SELECT COUNT(*) FROM t_space WHERE user_id = ? AND status != ?`
+	sql := `SELECT COUNT(*) FROM t_space WHERE user_id = ? AND org_id = 0 AND status != ?`
 	err = d.db.Get(&count, sql, userId, model.SpaceStatusDeleted)
 
 	return
 }
 
-func (d *SpaceDao) FindAllSpaceByUserId(userId uint32) (spaces []model.Space, err error) {
-	sql := `SELECT id, user_id, tmpl_id, spec_id, sid, name, create_time, stop_time, total_time, git_repository FROM t_space WHERE status != ? AND user_id = ?`
-	err = d.db.Select(&spaces, sql, model.SpaceStatusDeleted, userId)
+// FindAllSpaceByUserId 查询用户或组织的所有工作空间, orgId不为0时查询组织的工作空间
+func (d *SpaceDao) FindAllSpaceByUserId(userId, orgId uint32) (spaces []model.Space, err error) {
+	where, args := spaceOwnerCondition(userId, orgId)
+	sql := `SELECT id, user_id, org_id, tmpl_id, spec_id, sid, name, create_time, stop_time, total_time, git_repository FROM t_space WHERE status != ? AND ` + where
+	err = d.db.Select(&spaces, sql, append([]interface{}{model.SpaceStatusDeleted}, args...)...)
 	return
 }
 
@@ -59,13 +146,30 @@ func (d *SpaceDao) DeleteSpaceById(id uint32) error {
 	return err
 }
 
+// FindByIdAndUserId 查询用户的个人工作空间, 不包含组织的工作空间
 func (d *SpaceDao) FindByIdAndUserId(id, userId uint32) (space *model.Space, err error) {
-	sql := `SELECT tmpl_id, spec_id, sid, name, status, git_repository FROM t_space WHERE id = ? AND user_id = ?;`
+	sql := `SELECT tmpl_id, spec_id, sid, name, status, git_repository FROM t_space WHERE id = ? AND user_id = ? AND org_id = 0;`
 	space = &model.Space{}
 	err = d.db.Get(space, sql, id, userId)
 	return
 }
 
+// FindById 根据id查询工作空间以及所属的用户和组织, 由调用者检查访问权限
+func (d *SpaceDao) FindById(id uint32) (space *model.Space, err error) {
+	sql := `SELECT id, user_id, org_id, tmpl_id, spec_id, sid, name, status, git_repository FROM t_space WHERE id = ?`
+	space = &model.Space{}
+	err = d.db.Get(space, sql, id)
+	return
+}
+
+// FindBySid 根据sid查询工作空间, 用于计量control-plane上报的工作空间
+func (d *SpaceDao) FindBySid(sid string) (space *model.Space, err error) {
+	sql := `SELECT id, user_id, org_id, tmpl_id, spec_id, sid, name, status FROM t_space WHERE sid = ?`
+	space = &model.Space{}
+	err = d.db.Get(space, sql, sid)
+	return
+}
+
 func (d *SpaceDao) UpdateStatusById(id, status uint32) error {
 	sql := `UPDATE t_space SET status = ? WHERE id = ?`
 	_, err := d.db.Exec(sql, status, id)
@@ -77,3 +181,41 @@ func (d *SpaceDao) UpdateNameById(name string, id uint32) error {
 	_, err := d.db.Exec(sql, name, id)
 	return err
 }
+
+// FindPageWithUser 分页查询所有用户的云空间, userId不为0时只查询该用户的云空间
+func (d *SpaceDao) FindPageWithUser(userId uint32, offset, limit int) (spaces []model.AdminSpace, err error) {
+	where, args := adminSpaceCondition(userId)
+	sql := `SELECT s.id, s.user_id, s.org_id, s.tmpl_id, s.spec_id, s.sid, s.name, s.create_time, s.stop_time, s.total_time, s.git_repository,
+COALESCE(o.uid, u.uid) AS uid, u.username FROM t_space s JOIN t_user u ON s.user_id = u.id
+LEFT JOIN t_organization o ON s.org_id = o.id` + where + ` ORDER BY s.id DESC LIMIT ?, ?`
+	err = d.db.Select(&spaces, sql, append(args, offset, limit)...)
+	return
+}
+
+// FindCountWithUser 查询云空间数量, 查询条件与FindPageWithUser相同
+func (d *SpaceDao) FindCountWithUser(userId uint32) (count uint32, err error) {
+	where, args := adminSpaceCondition(userId)
+	sql := `SELECT COUNT(*) FROM t_space s` + where
+	err = d.db.Get(&count, sql, args...)
+	return
+}
+
+func adminSpaceCondition(userId uint32) (string, []interface{}) {
+	where := ` WHERE s.status != ?`
+	args := []interface{}{model.SpaceStatusDeleted}
+	if userId != 0 {
+		where += ` AND s.user_id = ?`
+		args = append(args, userId)
+	}
+
+	return where, args
+}
+
+// FindByIdWithUser 根据id查询云空间以及在control-plane中所有者的uid, 组织的工作空间为组织的uid
+func (d *SpaceDao) FindByIdWithUser(id uint32) (space *model.AdminSpace, err error) {
+	sql := `SELECT s.id, s.user_id, s.org_id, s.sid, s.name, s.status, COALESCE(o.uid, u.uid) AS uid, u.username
+FROM t_space s JOIN t_user u ON s.user_id = u.id LEFT JOIN t_organization o ON s.org_id = o.id WHERE s.id = ?`
+	space = &model.AdminSpace{}
+	err = d.db.Get(space, sql, id)
+	return
+}
@@ -0,0 +1,91 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao/db"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+)
+
+type SpaceRuntimeDao struct {
+	db *sqlx.DB
+}
+
+func NewSpaceRuntimeDao() *SpaceRuntimeDao {
+	return &SpaceRuntimeDao{
+		db: db.DB(),
+	}
+}
+
+const runtimeColumns = `id, space_id, sid, user_id, org_id, start_time, stop_time, spec_id, cpu_millis, memory_mb, tier, cost`
+
+// Start 记录工作空间开始运行, 已经有未结束的记录时不重复记录
+func (d *SpaceRuntimeDao) Start(runtime *model.SpaceRuntime) error {
+	sql := `INSERT INTO t_space_runtime (space_id, sid, user_id, org_id, start_time, spec_id, cpu_millis, memory_mb, tier)
+SELECT ?, ?, ?, ?, ?, ?, ?, ?, ? FROM DUAL WHERE NOT EXISTS (SELECT 1 FROM t_space_runtime WHERE sid = ? AND stop_time IS NULL)`
+	_, err := d.db.Exec(sql, runtime.SpaceId, runtime.Sid, runtime.UserId, runtime.OrgId, runtime.StartTime, runtime.SpecId,
+		runtime.CpuMillis, runtime.MemoryMb, runtime.Tier, runtime.Sid)
+	return err
+}
+
+// Close 结束运行记录并累加工作空间的运行时间, 停止时间不早于启动时间
+// 记录已经被结束时不做任何修改
+func (d *SpaceRuntimeDao) Close(runtime *model.SpaceRuntime, stopTime time.Time) error {
+	if stopTime.Before(runtime.StartTime) {
+		stopTime = runtime.StartTime
+	}
+
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE t_space_runtime SET stop_time = ? WHERE id = ? AND stop_time IS NULL`, stopTime, runtime.Id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+
+	sql := `UPDATE t_space SET stop_time = ?, total_time = total_time + ? WHERE id = ?`
+	if _, err = tx.Exec(sql, stopTime, stopTime.Sub(runtime.StartTime), runtime.SpaceId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// FindRunning 查询所有未结束的运行记录
+func (d *SpaceRuntimeDao) FindRunning() (runtimes []model.SpaceRuntime, err error) {
+	sql := `SELECT ` + runtimeColumns + ` FROM t_space_runtime WHERE stop_time IS NULL`
+	err = d.db.Select(&runtimes, sql)
+	return
+}
+
+// FindRunningByUserId 查询用户或组织所有未结束的运行记录, orgId不为0时查询组织的运行记录
+func (d *SpaceRuntimeDao) FindRunningByUserId(userId, orgId uint32) (runtimes []model.SpaceRuntime, err error) {
+	where, args := spaceOwnerCondition(userId, orgId)
+	sql := `SELECT ` + runtimeColumns + ` FROM t_space_runtime WHERE stop_time IS NULL AND ` + where
+	err = d.db.Select(&runtimes, sql, args...)
+	return
+}
+
+// FindByUserIdBetween 查询用户的个人工作空间在[start, end)期间运行过的记录
+func (d *SpaceRuntimeDao) FindByUserIdBetween(userId uint32, start, end time.Time) (runtimes []model.SpaceRuntime, err error) {
+	sql := `SELECT ` + runtimeColumns + ` FROM t_space_runtime
+WHERE user_id = ? AND org_id = 0 AND start_time < ? AND (stop_time IS NULL OR stop_time > ?)`
+	err = d.db.Select(&runtimes, sql, userId, end, start)
+	return
+}
+
+// SumSecondsSince 统计用户或组织从since到now的运行时长(秒), 未结束的记录计算到now
+func (d *SpaceRuntimeDao) SumSecondsSince(userId, orgId uint32, since, now time.Time) (seconds int64, err error) {
+	where, args := spaceOwnerCondition(userId, orgId)
+	sql := `SELECT COALESCE(SUM(TIMESTAMPDIFF(SECOND, GREATEST(start_time, ?), COALESCE(stop_time, ?))), 0)
+FROM t_space_runtime WHERE (stop_time IS NULL OR stop_time > ?) AND ` + where
+	err = d.db.Get(&seconds, sql, append([]interface{}{since, now, since}, args...)...)
+	return
+}
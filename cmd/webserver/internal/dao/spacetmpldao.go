@@ -1,6 +1,8 @@
 package dao
 
 import (
+	"time"
+
 	"github.com/jmoiron/sqlx"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao/db"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
@@ -16,34 +18,162 @@ func NewSpaceTemplateDao() *SpaceTemplateDao {
 	}
 }
 
+// 模板、类别和规格的状态
 const (
 	TmplUsing = iota
 	TmplDeleted
 )
 
 func (s *SpaceTemplateDao) GetAllTmplKind() (kinds []model.TmplKind, err error) {
-	sql := `SELECT id, name FROM t_template_kind`
+	sql := `SELECT id, name, status FROM t_template_kind`
 	err = s.db.Select(&kinds, sql)
 	return
 }
 
+func (s *SpaceTemplateDao) FindKindById(id uint32) (*model.TmplKind, error) {
+	sql := `SELECT id, name, status FROM t_template_kind WHERE id = ?`
+	kind := &model.TmplKind{}
+	err := s.db.Get(kind, sql, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return kind, nil
+}
+
+func (s *SpaceTemplateDao) InsertKind(kind *model.TmplKind) (uint32, error) {
+	sql := `INSERT INTO t_template_kind (name, status) VALUES (?, ?)`
+	result, err := s.db.Exec(sql, kind.Name, kind.Status)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+
+	return uint32(id), err
+}
+
+func (s *SpaceTemplateDao) UpdateKindName(id uint32, name string) error {
+	sql := `UPDATE t_template_kind SET name = ? WHERE id = ?`
+	_, err := s.db.Exec(sql, name, id)
+	return err
+}
+
+func (s *SpaceTemplateDao) DeleteKind(id uint32) error {
+	sql := `UPDATE t_template_kind SET status = ? WHERE id = ?`
+	_, err := s.db.Exec(sql, TmplDeleted, id)
+	return err
+}
+
+// FindUsingTmplCountByKind 查询类别下可用模板的数量
+func (s *SpaceTemplateDao) FindUsingTmplCountByKind(kindId uint32) (count uint32, err error) {
+	sql := `SELECT COUNT(*) FROM t_space_template WHERE kind_id = ? AND status = ?`
+	err = s.db.Get(&count, sql, kindId, TmplUsing)
+	return
+}
+
 func (s *SpaceTemplateDao) GetAllUsingTmpl() (tmpls []model.SpaceTemplate, err error) {
-	sql := "SELECT id, kind_id, name, `desc`, tags, image, avatar FROM t_space_template WHERE status = ?"
+	sql := "SELECT id, kind_id, name, `desc`, tags, image, avatar, idle_timeout, port, command, args, env, working_dir, readiness_path, liveness_path, startup_path, init_steps FROM t_space_template WHERE status = ?"
 	err = s.db.Select(&tmpls, sql, TmplUsing)
 
 	return
 }
 
 func (s *SpaceTemplateDao) GetAllTmpl() (tmpls []model.SpaceTemplate, err error) {
-	sql := "SELECT id, kind_id, name, `desc`, tags, image, avatar FROM t_space_template"
+	sql := "SELECT id, kind_id, name, `desc`, tags, image, status, avatar, idle_timeout, port, command, args, env, working_dir, readiness_path, liveness_path, startup_path, init_steps FROM t_space_template"
 	err = s.db.Select(&tmpls, sql)
 
 	return
 }
 
+func (s *SpaceTemplateDao) FindTmplById(id uint32) (*model.SpaceTemplate, error) {
+	sql := "SELECT id, kind_id, name, `desc`, tags, image, status, avatar, idle_timeout, create_time, delete_time, port, command, args, env, working_dir, readiness_path, liveness_path, startup_path, init_steps FROM t_space_template WHERE id = ?"
+	tmpl := &model.SpaceTemplate{}
+	err := s.db.Get(tmpl, sql, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return tmpl, nil
+}
+
+func (s *SpaceTemplateDao) InsertTmpl(tmpl *model.SpaceTemplate) (uint32, error) {
+	sql := "INSERT INTO t_space_template (kind_id, name, `desc`, tags, image, status, avatar, idle_timeout, create_time, delete_time, " +
+		"port, command, args, env, working_dir, readiness_path, liveness_path, startup_path, init_steps) " +
+		"VALUES (:kind_id, :name, :desc, :tags, :image, :status, :avatar, :idle_timeout, :create_time, :delete_time, " +
+		":port, :command, :args, :env, :working_dir, :readiness_path, :liveness_path, :startup_path, :init_steps)"
+	result, err := s.db.NamedExec(sql, tmpl)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+
+	return uint32(id), err
+}
+
+// UpdateTmpl 修改模板的所有可编辑字段, 不修改状态
+func (s *SpaceTemplateDao) UpdateTmpl(tmpl *model.SpaceTemplate) error {
+	sql := "UPDATE t_space_template SET kind_id = :kind_id, name = :name, `desc` = :desc, tags = :tags, image = :image, " +
+		"avatar = :avatar, idle_timeout = :idle_timeout, port = :port, command = :command, args = :args, env = :env, " +
+		"working_dir = :working_dir, readiness_path = :readiness_path, liveness_path = :liveness_path, " +
+		"startup_path = :startup_path, init_steps = :init_steps WHERE id = :id"
+	_, err := s.db.NamedExec(sql, tmpl)
+	return err
+}
+
+// DeleteTmpl 软删除模板, 已有的工作空间仍然可以使用
+func (s *SpaceTemplateDao) DeleteTmpl(id uint32, deleteTime time.Time) error {
+	sql := `UPDATE t_space_template SET status = ?, delete_time = ? WHERE id = ?`
+	_, err := s.db.Exec(sql, TmplDeleted, deleteTime, id)
+	return err
+}
+
+// UpdateTmplStatus 修改模板的状态, 用于上架或下架模板
+func (s *SpaceTemplateDao) UpdateTmplStatus(id, status uint32) error {
+	sql := `UPDATE t_space_template SET status = ? WHERE id = ?`
+	_, err := s.db.Exec(sql, status, id)
+	return err
+}
+
 func (s *SpaceTemplateDao) GetAllSpec() (specs []model.SpaceSpec, err error) {
-	sql := "SELECT id, cpu_spec, mem_spec, storage_spec, name, `desc` FROM t_spacespec"
+	sql := "SELECT id, cpu_spec, mem_spec, storage_spec, name, `desc`, idle_timeout, status FROM t_spacespec"
 	err = s.db.Select(&specs, sql)
 
 	return
 }
+
+func (s *SpaceTemplateDao) FindSpecById(id uint32) (*model.SpaceSpec, error) {
+	sql := "SELECT id, cpu_spec, mem_spec, storage_spec, name, `desc`, idle_timeout, status FROM t_spacespec WHERE id = ?"
+	spec := &model.SpaceSpec{}
+	err := s.db.Get(spec, sql, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return spec, nil
+}
+
+func (s *SpaceTemplateDao) InsertSpec(spec *model.SpaceSpec) (uint32, error) {
+	sql := "INSERT INTO t_spacespec (cpu_spec, mem_spec, storage_spec, name, `desc`, idle_timeout, status) " +
+		"VALUES (:cpu_spec, :mem_spec, :storage_spec, :name, :desc, :idle_timeout, :status)"
+	result, err := s.db.NamedExec(sql, spec)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+
+	return uint32(id), err
+}
+
+// UpdateSpec 修改规格, 存储规格不能修改
+func (s *SpaceTemplateDao) UpdateSpec(spec *model.SpaceSpec) error {
+	sql := "UPDATE t_spacespec SET cpu_spec = :cpu_spec, mem_spec = :mem_spec, name = :name, `desc` = :desc, " +
+		"idle_timeout = :idle_timeout WHERE id = :id"
+	_, err := s.db.NamedExec(sql, spec)
+	return err
+}
+
+func (s *SpaceTemplateDao) DeleteSpec(id uint32) error {
+	sql := `UPDATE t_spacespec SET status = ? WHERE id = ?`
+	_, err := s.db.Exec(sql, TmplDeleted, id)
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"strings"
 	"github.com/jmoiron/sqlx"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/code"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao/db"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
 )
@@ -19,7 +20,7 @@ func NewUserDao() *UserDao {
 }
 
 func (u *UserDao) FindByUsernameDetailed(username string) (user *model.User, _ error) {
-	sql := `SELECT id, uid, username, password, nickname, email, avatar, status, linuxdo_id, linuxdo_username FROM t_user WHERE username = ? AND delete_time > NOW()`
+	sql := `SELECT id, uid, username, password, nickname, email, avatar, status, linuxdo_id, linuxdo_username, role FROM t_user WHERE username = ? AND delete_time > NOW()`
 	user = &model.User{}
 	err := u.db.Get(user, sql, username)
 	return user, err
@@ -38,7 +39,7 @@ func (u *UserDao) FindByEmail(email string) error {
 }
 
 func (u *UserDao) FindByEmailDetailed(email string) (user *model.User, _ error) {
-	sql := `SELECT id, uid, username, password, nickname, email, avatar, status, linuxdo_id, linuxdo_username FROM t_user WHERE email = ? AND delete_time > NOW()`
+	sql := `SELECT id, uid, username, password, nickname, email, avatar, status, linuxdo_id, linuxdo_username, role FROM t_user WHERE email = ? AND delete_time > NOW()`
 	user = &model.User{}
 	err := u.db.Get(user, sql, email)
 	return user, err
@@ -73,7 +74,7 @@ func (u *UserDao) UpdatePasswordByEmail(email, newPassword string) error {
 
 // FindByLinuxDoID 根据LinuxDo ID查找用户
 func (u *UserDao) FindByLinuxDoID(linuxdoID int) (user *model.User, _ error) {
-	sql := `SELECT id, uid, username, password, nickname, email, avatar, status, linuxdo_id, linuxdo_username FROM t_user WHERE linuxdo_id = ? AND delete_time > NOW()`
+	sql := `SELECT id, uid, username, password, nickname, email, avatar, status, linuxdo_id, linuxdo_username, role FROM t_user WHERE linuxdo_id = ? AND delete_time > NOW()`
 	user = &model.User{}
 	err := u.db.Get(user, sql, linuxdoID)
 	return user, err
@@ -99,3 +100,55 @@ func (u *UserDao) UpdateUser(userID uint32, updates map[string]interface{}) erro
 	_, err := u.db.Exec(sql, args...)
 	return err
 }
+
+// FindRoleAndStatusById 查询用户的角色和状态, 用于管理员权限检查
+func (u *UserDao) FindRoleAndStatusById(id uint32) (role uint8, status uint32, err error) {
+	sql := `SELECT role, status FROM t_user WHERE id = ?`
+	var user model.User
+	err = u.db.Get(&user, sql, id)
+	return user.Role, user.Status, err
+}
+
+// FindById 根据id查询用户, 不包含密码
+func (u *UserDao) FindById(id uint32) (user *model.User, _ error) {
+	sql := `SELECT id, uid, username, nickname, email, avatar, create_time, status, vip_status, vip_expire_time, role FROM t_user WHERE id = ?`
+	user = &model.User{}
+	err := u.db.Get(user, sql, id)
+	return user, err
+}
+
+// FindPage 分页查询未删除的用户, keyword不为空时按用户名、昵称和邮箱模糊搜索
+func (u *UserDao) FindPage(keyword string, offset, limit int) (users []model.User, err error) {
+	where, args := userSearchCondition(keyword)
+	sql := `SELECT id, uid, username, nickname, email, avatar, create_time, status, vip_status, vip_expire_time, role FROM t_user` +
+		where + ` ORDER BY id DESC LIMIT ?, ?`
+	err = u.db.Select(&users, sql, append(args, offset, limit)...)
+	return
+}
+
+// FindCount 查询未删除的用户数量, 搜索条件与FindPage相同
+func (u *UserDao) FindCount(keyword string) (count uint32, err error) {
+	where, args := userSearchCondition(keyword)
+	sql := `SELECT COUNT(*) FROM t_user` + where
+	err = u.db.Get(&count, sql, args...)
+	return
+}
+
+func userSearchCondition(keyword string) (string, []interface{}) {
+	where := ` WHERE status != ?`
+	args := []interface{}{code.StatusDeleted}
+	if keyword != "" {
+		like := "%" + keyword + "%"
+		where += ` AND (username LIKE ? OR nickname LIKE ? OR email LIKE ?)`
+		args = append(args, like, like, like)
+	}
+
+	return where, args
+}
+
+// UpdateStatusById 更新用户状态, 例如禁用或启用账号
+func (u *UserDao) UpdateStatusById(id, status uint32) error {
+	sql := `UPDATE t_user SET status = ? WHERE id = ? AND status != ?`
+	_, err := u.db.Exec(sql, status, id, code.StatusDeleted)
+	return err
+}
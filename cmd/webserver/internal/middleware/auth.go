@@ -1,17 +1,23 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/code"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/conf"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/service"
 	"github.com/mangohow/cloud-ide/pkg/logger"
 	"github.com/mangohow/cloud-ide/pkg/serialize"
 	"github.com/mangohow/cloud-ide/pkg/utils/encrypt"
 )
 
+// Auth 验证登录令牌, 被禁用或删除的用户签发过的令牌同样被拒绝
 func Auth() gin.HandlerFunc {
+	adminService := service.NewAdminService()
+
 	return func(ctx *gin.Context) {
 		authHeader := ctx.GetHeader("Authorization")
 		if authHeader == "" {
@@ -37,6 +43,9 @@ func Auth() gin.HandlerFunc {
 			ctx.Abort()
 			return
 		}
+		if !checkUserActive(ctx, adminService, id) {
+			return
+		}
 		ctx.Set("id", id)
 		ctx.Set("user_id", id) // 兼容性设置
 		ctx.Set("username", username)
@@ -46,6 +55,73 @@ func Auth() gin.HandlerFunc {
 	}
 }
 
+// WatchAuth 订阅工作空间事件的SSE请求使用的认证中间件
+// 浏览器的EventSource无法设置请求头, 使用ticket参数携带的短期票据代替登录令牌, 票据只能订阅id参数对应的工作空间
+func WatchAuth() gin.HandlerFunc {
+	adminService := service.NewAdminService()
+
+	return func(ctx *gin.Context) {
+		id, err := strconv.ParseUint(ctx.Query("id"), 10, 32)
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			ctx.Abort()
+			return
+		}
+
+		claims, err := encrypt.VerifyWatchTicket(ctx.Query("ticket"), uint32(id))
+		if err != nil {
+			logger.Logger().Warningf("无效的订阅票据, ip:%s", ctx.Request.RemoteAddr)
+			ctx.Status(http.StatusUnauthorized)
+			ctx.Abort()
+			return
+		}
+		if !checkUserActive(ctx, adminService, claims.Id) {
+			return
+		}
+		ctx.Set("id", claims.Id)
+		ctx.Set("user_id", claims.Id)
+		ctx.Set("uid", claims.Uid)
+
+		ctx.Next()
+	}
+}
+
+// InternalAuth 网关调用的内部接口使用的认证中间件, token请求头必须与网关的令牌相同
+// webserver的Service以NodePort暴露, 工作空间的Pod也可以访问, 不能只依赖网关不转发/internal
+func InternalAuth() gin.HandlerFunc {
+	token := []byte(conf.WorkspaceConfig.GatewayToken)
+
+	return func(ctx *gin.Context) {
+		if subtle.ConstantTimeCompare([]byte(ctx.GetHeader("token")), token) != 1 {
+			logger.Logger().Warningf("无效的网关令牌, path:%s, ip:%s", ctx.Request.URL.Path, ctx.Request.RemoteAddr)
+			ctx.Status(http.StatusUnauthorized)
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// checkUserActive 检查用户是否可以继续访问, 不能访问时中止请求
+// 无法查询用户状态时返回503, 浏览器不会因此清除登录状态
+func checkUserActive(ctx *gin.Context, adminService *service.AdminService, id uint32) bool {
+	active, err := adminService.IsUserActive(id)
+	if err != nil {
+		ctx.Status(http.StatusServiceUnavailable)
+		ctx.Abort()
+		return false
+	}
+	if !active {
+		logger.Logger().Warningf("已禁用的用户访问, id:%d, ip:%s", id, ctx.Request.RemoteAddr)
+		ctx.Status(http.StatusUnauthorized)
+		ctx.Abort()
+		return false
+	}
+
+	return true
+}
+
 // VipRequired VIP权限检查中间件
 func VipRequired() gin.HandlerFunc {
 	subscriptionService := service.NewSubscriptionService()
@@ -108,4 +184,34 @@ func CheckWorkspacePermission(action string) gin.HandlerFunc {
 
 		ctx.Next()
 	}
-}
\ No newline at end of file
+}
+// AdminRequired 管理员权限检查中间件, 需要在Auth之后使用
+func AdminRequired() gin.HandlerFunc {
+	adminService := service.NewAdminService()
+
+	return func(ctx *gin.Context) {
+		userIdVal, exists := ctx.Get("user_id")
+		if !exists {
+			ctx.JSON(http.StatusUnauthorized, serialize.Fail(code.LoginFailed))
+			ctx.Abort()
+			return
+		}
+
+		userId, ok := userIdVal.(uint32)
+		if !ok {
+			ctx.JSON(http.StatusUnauthorized, serialize.Fail(code.LoginFailed))
+			ctx.Abort()
+			return
+		}
+
+		// 每次请求都查询数据库, 角色或状态变更后立即生效
+		if !adminService.IsAdmin(userId) {
+			logger.Logger().Warnf("非管理员访问管理接口, id:%d, path:%s", userId, ctx.Request.URL.Path)
+			ctx.JSON(http.StatusForbidden, serialize.Fail(code.AdminPermissionDenied))
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// 优惠码的减免方式
+const (
+	CouponPercent = "percent" // 按比例减免, DiscountValue为减免的百分比
+	CouponFixed   = "fixed"   // 减免固定金额, DiscountValue为减免的金额(元)
+)
+
+// 优惠码状态
+const (
+	CouponDisabled uint8 = 0
+	CouponActive   uint8 = 1
+)
+
+// 优惠码使用记录状态
+const (
+	RedemptionReserved uint8 = 0 // 创建订单时占用
+	RedemptionUsed     uint8 = 1 // 订单已支付
+	RedemptionReleased uint8 = 2 // 订单关闭后释放
+)
+
+// Coupon 优惠码, MaxRedemptions和PerUserLimit为0表示不限制
+type Coupon struct {
+	Id             uint32     `json:"id" db:"id"`
+	Code           string     `json:"code" db:"code"`
+	Name           string     `json:"name" db:"name"`
+	DiscountType   string     `json:"discount_type" db:"discount_type"`
+	DiscountValue  float64    `json:"discount_value" db:"discount_value"`
+	ProductTypes   string     `json:"product_types" db:"product_types"` // 使用,隔开, 为空表示所有订阅产品
+	FirstPurchase  bool       `json:"first_purchase" db:"first_purchase"`
+	MaxRedemptions uint32     `json:"max_redemptions" db:"max_redemptions"`
+	PerUserLimit   uint32     `json:"per_user_limit" db:"per_user_limit"`
+	StartTime      *time.Time `json:"start_time" db:"start_time"`
+	EndTime        *time.Time `json:"end_time" db:"end_time"`
+	Status         uint8      `json:"status" db:"status"`
+	CreateTime     time.Time  `json:"create_time" db:"create_time"`
+	UpdateTime     time.Time  `json:"update_time" db:"update_time"`
+	// 已经使用的次数, 包括未支付的订单占用的次数, 只在管理员查询时返回
+	Redeemed uint32 `json:"redeemed" db:"redeemed"`
+}
+
+// AllowProduct 优惠码是否可以用于该类型的产品, 没有限制产品时只能用于订阅, 预付费充值需要明确指定
+func (c *Coupon) AllowProduct(productType string) bool {
+	if c.ProductTypes == "" {
+		return productType != ProductTypeCredit
+	}
+	for _, t := range strings.Split(c.ProductTypes, ",") {
+		if strings.TrimSpace(t) == productType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CouponRedemption 优惠码的使用记录
+type CouponRedemption struct {
+	Id         uint32    `json:"id" db:"id"`
+	CouponId   uint32    `json:"coupon_id" db:"coupon_id"`
+	UserId     uint32    `json:"user_id" db:"user_id"`
+	OrderId    uint32    `json:"order_id" db:"order_id"`
+	Discount   float64   `json:"discount" db:"discount"`
+	Status     uint8     `json:"status" db:"status"`
+	CreateTime time.Time `json:"create_time" db:"create_time"`
+	UpdateTime time.Time `json:"update_time" db:"update_time"`
+}
+
+// CouponPreview 优惠码用于某个产品时的价格
+type CouponPreview struct {
+	Code           string  `json:"code"`
+	OriginalAmount float64 `json:"original_amount"`
+	DiscountAmount float64 `json:"discount_amount"`
+	Amount         float64 `json:"amount"`
+}
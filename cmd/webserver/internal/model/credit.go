@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// 余额变动类型
+const (
+	CreditRecharge = "recharge"
+	CreditUsage    = "usage"
+	CreditRefund   = "refund"
+)
+
+// CreditRecord 余额变动记录, 金额的单位为分
+type CreditRecord struct {
+	Id         uint32    `json:"id" db:"id"`
+	UserId     uint32    `json:"user_id" db:"user_id"`
+	Type       string    `json:"type" db:"type"`
+	Amount     int64     `json:"amount" db:"amount"`
+	Balance    int64     `json:"balance" db:"balance"`
+	Ref        string    `json:"ref" db:"ref"`
+	CreateTime time.Time `json:"create_time" db:"create_time"`
+}
+
+// UsageItem 一段时间内的资源使用量, Cost为按量计费的费用(分)
+type UsageItem struct {
+	Date         string  `json:"date"`
+	Hours        float64 `json:"hours"`
+	CpuCoreHours float64 `json:"cpu_core_hours"`
+	MemGbHours   float64 `json:"mem_gb_hours"`
+	Cost         int64   `json:"cost"`
+}
+
+// Usage 用户一个月的资源使用量以及每天的明细
+type Usage struct {
+	Month   string      `json:"month"`
+	Total   UsageItem   `json:"total"`
+	Days    []UsageItem `json:"days"`
+	Balance int64       `json:"balance"`
+}
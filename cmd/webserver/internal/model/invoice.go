@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// Invoice 已支付订单的收据, 创建时保存订单和购买人的信息, 之后修改用户信息不影响已经开具的收据
+type Invoice struct {
+	Id            uint32    `json:"id" db:"id"`
+	InvoiceNo     string    `json:"invoice_no" db:"invoice_no"`
+	OrderId       uint32    `json:"order_id" db:"order_id"`
+	OrderNo       string    `json:"order_no" db:"order_no"`
+	UserId        uint32    `json:"-" db:"user_id"`
+	BuyerName     string    `json:"buyer_name" db:"buyer_name"`
+	BuyerEmail    string    `json:"buyer_email" db:"buyer_email"`
+	ProductName   string    `json:"product_name" db:"product_name"`
+	ProductType   string    `json:"product_type" db:"product_type"`
+	Amount        float64   `json:"amount" db:"amount"`
+	PaymentMethod string    `json:"payment_method" db:"payment_method"`
+	PaidTime      time.Time `json:"paid_time" db:"paid_time"`
+	CreateTime    time.Time `json:"create_time" db:"create_time"`
+	// 订单当前的状态, 订单退款后收据仍然保留
+	OrderStatus uint8 `json:"order_status" db:"order_status"`
+	// 生成的收据, 列表查询时不返回
+	Html []byte `json:"-" db:"html"`
+	Pdf  []byte `json:"-" db:"pdf"`
+}
+
+// 收据的格式
+const (
+	InvoiceFormatHtml = "html"
+	InvoiceFormatPdf  = "pdf"
+)
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// 定时任务的执行状态
+const (
+	JobStatusRunning uint8 = iota
+	JobStatusSuccess
+	JobStatusFailed
+)
+
+// JobHistory 定时任务的一次执行记录, EndTime为空表示正在执行
+type JobHistory struct {
+	Id        uint32     `json:"id" db:"id"`
+	Name      string     `json:"name" db:"name"`
+	Instance  string     `json:"instance" db:"instance"`
+	Status    uint8      `json:"status" db:"status"`
+	Message   string     `json:"message" db:"message"`
+	StartTime time.Time  `json:"start_time" db:"start_time"`
+	EndTime   *time.Time `json:"end_time" db:"end_time"`
+}
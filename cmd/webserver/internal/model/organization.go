@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// 组织成员的角色
+const (
+	OrgRoleOwner  = "owner"  // 创建者, 可以修改成员的角色
+	OrgRoleAdmin  = "admin"  // 管理员, 可以管理工作空间、成员和订阅
+	OrgRoleMember = "member" // 成员, 可以打开、启动和停止工作空间
+)
+
+// Organization 组织, Uid作为组织的工作空间在control-plane中的所有者
+type Organization struct {
+	Id            uint32     `json:"id" db:"id"`
+	Uid           string     `json:"-" db:"uid"`
+	Name          string     `json:"name" db:"name"`
+	OwnerId       uint32     `json:"owner_id" db:"owner_id"`
+	VipStatus     uint8      `json:"vip_status" db:"vip_status"`
+	VipExpireTime *time.Time `json:"vip_expire_time" db:"vip_expire_time"`
+	CreateTime    time.Time  `json:"create_time" db:"create_time"`
+	UpdateTime    time.Time  `json:"update_time" db:"update_time"`
+	Role          string     `json:"role" db:"role"` // 当前用户在组织中的角色
+}
+
+// OrgMember 组织成员
+type OrgMember struct {
+	Id         uint32    `json:"id" db:"id"`
+	OrgId      uint32    `json:"org_id" db:"org_id"`
+	UserId     uint32    `json:"user_id" db:"user_id"`
+	Username   string    `json:"username" db:"username"`
+	Nickname   string    `json:"nickname" db:"nickname"`
+	Role       string    `json:"role" db:"role"`
+	CreateTime time.Time `json:"create_time" db:"create_time"`
+}
+
+// SpaceAccess 用户访问工作空间的权限, 网关根据Uid和Sid找到工作空间
+type SpaceAccess struct {
+	Sid  string `json:"sid"`
+	Uid  string `json:"uid"`  // 工作空间在control-plane中的所有者
+	Role string `json:"role"` // 个人工作空间为owner, 组织的工作空间为用户在组织中的角色
+}
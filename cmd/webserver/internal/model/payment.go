@@ -34,8 +34,18 @@ type Order struct {
 	PaymentMethod string     `db:"payment_method" json:"payment_method"`
 	TradeNo       string     `db:"trade_no" json:"trade_no"`
 	PaidAt        *time.Time `db:"paid_at" json:"paid_at"`
+	RefundTime    *time.Time `db:"refund_time" json:"refund_time"`
+	RefundReason  string     `db:"refund_reason" json:"refund_reason"`
 	CreateTime    time.Time  `db:"create_time" json:"create_time"`
 	UpdateTime    time.Time  `db:"update_time" json:"update_time"`
+
+	// 使用优惠码时Amount为优惠后的金额, OriginalAmount为原价
+	CouponId       uint32  `db:"coupon_id" json:"coupon_id"`
+	OriginalAmount float64 `db:"original_amount" json:"original_amount"`
+	DiscountAmount float64 `db:"discount_amount" json:"discount_amount"`
+
+	// 为组织购买的订阅, 0表示个人订单
+	OrgId uint32 `db:"org_id" json:"org_id"`
 }
 
 // =============== 用户订阅相关 ===============
@@ -44,6 +54,7 @@ type Order struct {
 type UserSubscription struct {
 	Id               uint32    `db:"id" json:"id"`
 	UserId           uint32    `db:"user_id" json:"user_id"`
+	OrgId            uint32    `db:"org_id" json:"org_id"`
 	SubscriptionType string    `db:"subscription_type" json:"subscription_type"`
 	StartTime        time.Time `db:"start_time" json:"start_time"`
 	EndTime          time.Time `db:"end_time" json:"end_time"`
@@ -53,6 +64,12 @@ type UserSubscription struct {
 	UpdateTime       time.Time `db:"update_time" json:"update_time"`
 }
 
+// SubscriptionCount 每种订阅类型的用户数量
+type SubscriptionCount struct {
+	SubscriptionType string `db:"subscription_type" json:"subscription_type"`
+	Count            int    `db:"count" json:"count"`
+}
+
 // =============== 支付记录相关 ===============
 
 // PaymentRecord 支付记录表
@@ -75,9 +92,12 @@ type PaymentRecord struct {
 
 // 订单状态
 const (
-	OrderStatusPending uint8 = 0 // 待支付
-	OrderStatusPaid    uint8 = 1 // 已支付
-	OrderStatusClosed  uint8 = 2 // 已关闭
+	OrderStatusPending  uint8 = 0 // 待支付
+	OrderStatusPaid     uint8 = 1 // 已支付
+	OrderStatusClosed   uint8 = 2 // 已关闭
+	OrderStatusRefunded uint8 = 3 // 已退款
+	// 退款中, 已经收回订阅或余额, 等待支付网关退款
+	OrderStatusRefunding uint8 = 4
 )
 
 // 支付状态
@@ -85,13 +105,15 @@ const (
 	PaymentStatusProcessing uint8 = 0 // 处理中
 	PaymentStatusSuccess    uint8 = 1 // 成功
 	PaymentStatusFailed     uint8 = 2 // 失败
+	PaymentStatusRefunded   uint8 = 3 // 已退款
 )
 
 // 产品类型
 const (
-	ProductTypeDay   = "day"   // 日卡
-	ProductTypeWeek  = "week"  // 周卡
-	ProductTypeMonth = "month" // 月卡
+	ProductTypeDay    = "day"    // 日卡
+	ProductTypeWeek   = "week"   // 周卡
+	ProductTypeMonth  = "month"  // 月卡
+	ProductTypeCredit = "credit" // 预付费充值
 )
 
 // VIP状态
@@ -102,8 +124,9 @@ const (
 
 // 订阅状态
 const (
-	SubscriptionStatusActive  uint8 = 1 // 活跃
-	SubscriptionStatusExpired uint8 = 0 // 过期
+	SubscriptionStatusActive   uint8 = 1 // 活跃
+	SubscriptionStatusExpired  uint8 = 0 // 过期
+	SubscriptionStatusRefunded uint8 = 2 // 已退款
 )
 
 // =============== 请求/响应结构体 ===============
@@ -114,6 +137,10 @@ type CreateOrderRequest struct {
 	ProductType   string `json:"product_type" binding:"required"`
 	PaymentMethod string `json:"payment_method" binding:"required"`
 	ReturnUrl     string `json:"return_url"`
+	CouponCode    string `json:"coupon_code"` // 优惠码, 可以为空
+	OrgId         uint32 `json:"org_id"`      // 为组织购买订阅, 0表示为自己购买
+	// 用户的IP, 由服务端设置
+	ClientIp string `json:"-"`
 }
 
 // OrderDetailResponse 订单详情响应
@@ -130,18 +157,4 @@ type UserSubscriptionResponse struct {
 	IsActive     bool       `json:"is_active"`
 	DaysLeft     int        `json:"days_left"`
 	CurrentLevel string     `json:"current_level"`
-}
-
-// PaymentCallbackRequest 支付回调请求
-type PaymentCallbackRequest struct {
-	Pid         string `form:"pid" json:"pid"`
-	TradeNo     string `form:"trade_no" json:"trade_no"`
-	OutTradeNo  string `form:"out_trade_no" json:"out_trade_no"`
-	Type        string `form:"type" json:"type"`
-	Name        string `form:"name" json:"name"`
-	Money       string `form:"money" json:"money"`
-	TradeStatus string `form:"trade_status" json:"trade_status"`
-	Param       string `form:"param" json:"param"`
-	Sign        string `form:"sign" json:"sign"`
-	SignType    string `form:"sign_type" json:"sign_type"`
-} 
\ No newline at end of file
+}
\ No newline at end of file
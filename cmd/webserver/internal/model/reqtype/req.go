@@ -1,5 +1,11 @@
 package reqtype
 
+import (
+	"time"
+
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+)
+
 type SpaceCreateOption struct {
 	Name                 string `json:"name"`
 	TmplId               uint32 `json:"tmpl_id"`
@@ -23,8 +29,135 @@ type SpaceCreateOption struct {
 	// 模型配置
 	BigModel             string `json:"big_model,omitempty"`
 	SmallModel           string `json:"small_model,omitempty"`
+	// 不等待工作空间启动完成, 通过/api/workspace/watch获取启动进度
+	Async                bool   `json:"async,omitempty"`
+	// 关联到工作空间的密钥名称, 启动时注入到环境变量中
+	Secrets              []string `json:"secrets,omitempty"`
+	// 在组织中创建工作空间, 0表示个人工作空间
+	OrgId                uint32 `json:"org_id,omitempty"`
 }
 
 type SpaceId struct {
 	Id uint32 `json:"id"`
 }
+
+type SpaceStartOption struct {
+	Id      uint32   `json:"id"`
+	Async   bool     `json:"async,omitempty"`   // 不等待工作空间启动完成
+	Secrets []string `json:"secrets,omitempty"` // 启动前关联到工作空间的密钥名称
+}
+
+type OrgCreateOption struct {
+	Name string `json:"name"` // 组织名称
+}
+
+type OrgMemberOption struct {
+	OrgId    uint32 `json:"org_id"`
+	Username string `json:"username"` // 添加成员时使用用户名
+	UserId   uint32 `json:"user_id"`  // 修改角色和移除成员时使用用户id
+	Role     string `json:"role"`     // admin或member
+}
+
+type SecretCreateOption struct {
+	Name    string `json:"name"`     // 密钥名称
+	EnvName string `json:"env_name"` // 注入工作空间时的环境变量名称
+	Value   string `json:"value"`    // 密钥的值, 创建后不再返回
+	Desc    string `json:"desc"`
+}
+
+type SecretUpdateOption struct {
+	Id    uint32 `json:"id"`
+	Value string `json:"value"`
+	Desc  string `json:"desc"`
+}
+
+type SpaceSpecModifyOption struct {
+	Id     uint32 `json:"id"`      // 工作空间id
+	SpecId uint32 `json:"spec_id"` // 新的规格id
+}
+
+type ShareCreateOption struct {
+	Id         uint32 `json:"id"`         // 工作空间id
+	Permission string `json:"permission"` // 只支持full, 为空时为full
+	Hours      uint32 `json:"hours"`      // 有效期(小时)
+}
+
+type SpaceTicketOption struct {
+	Sid string `json:"sid"`
+}
+
+type SnapshotCreateOption struct {
+	Id   uint32 `json:"id"`   // 工作空间id
+	Desc string `json:"desc"` // 快照描述
+}
+
+type SnapshotRestoreOption struct {
+	Id         uint32 `json:"id"`          // 工作空间id
+	SnapshotId uint32 `json:"snapshot_id"` // 快照id
+}
+
+type UserStatusOption struct {
+	Id       uint32 `json:"id"`       // 用户id
+	Disabled bool   `json:"disabled"` // 是否禁用
+}
+
+type VipGrantOption struct {
+	UserId uint32 `json:"user_id"`
+	Days   int    `json:"days"`   // 赠送的天数
+	Reason string `json:"reason"` // 赠送原因, 记录在订阅日志中
+}
+
+type OrderRefundOption struct {
+	OrderNo string `json:"order_no"` // 订单号
+	Reason  string `json:"reason"`   // 退款原因, 记录在订单中
+}
+
+type CouponOption struct {
+	Code           string     `json:"code"`            // 优惠码, 不区分大小写
+	Name           string     `json:"name"`            // 活动名称
+	DiscountType   string     `json:"discount_type"`   // percent按比例 fixed固定金额
+	DiscountValue  float64    `json:"discount_value"`  // 减免的百分比或者金额(元)
+	ProductTypes   []string   `json:"product_types"`   // 可以使用的产品类型, 为空表示所有订阅产品
+	FirstPurchase  bool       `json:"first_purchase"`  // 是否只有首次购买可以使用
+	MaxRedemptions uint32     `json:"max_redemptions"` // 总共可以使用的次数, 0表示不限制
+	PerUserLimit   uint32     `json:"per_user_limit"`  // 每个用户可以使用的次数, 0表示不限制
+	StartTime      *time.Time `json:"start_time"`      // 生效时间, 为空表示立即生效
+	EndTime        *time.Time `json:"end_time"`        // 失效时间, 为空表示不失效
+}
+
+type CouponStatusOption struct {
+	Id     uint32 `json:"id"`     // 优惠码id
+	Status uint8  `json:"status"` // 1启用 0停用
+}
+
+type TmplStatusOption struct {
+	Id     uint32 `json:"id"`     // 模板id
+	Status uint32 `json:"status"` // 0上架 1下架
+}
+
+type TmplOption struct {
+	Id          uint32           `json:"id"`      // 模板id, 修改时使用
+	KindId      uint32           `json:"kind_id"` // 类别id
+	Name        string           `json:"name"`
+	Desc        string           `json:"desc"`
+	Tags        string           `json:"tags"`  // 标签，使用|隔开
+	Image       string           `json:"image"` // 镜像
+	Avatar      string           `json:"avatar"`
+	IdleTimeout uint32           `json:"idle_timeout"` // 空闲超时时间(分钟), 0表示使用规格中的设置
+	Launch      model.TmplLaunch `json:"launch"`       // 启动配置
+}
+
+type TmplKindOption struct {
+	Id   uint32 `json:"id"` // 类别id, 修改时使用
+	Name string `json:"name"`
+}
+
+type SpecOption struct {
+	Id          uint32 `json:"id"`           // 规格id, 修改时使用
+	CpuSpec     string `json:"cpu_spec"`     // CPU规格, 例如 2
+	MemSpec     string `json:"mem_spec"`     // 内存规格, 例如 4Gi
+	StorageSpec string `json:"storage_spec"` // 存储规格, 创建后不能修改
+	Name        string `json:"name"`
+	Desc        string `json:"desc"`
+	IdleTimeout uint32 `json:"idle_timeout"` // 空闲超时时间(分钟), 0表示使用默认值
+}
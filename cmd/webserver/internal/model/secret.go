@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// UserSecret 用户保存的密钥, 例如AI提供商的API Key, 创建后不再返回明文
+type UserSecret struct {
+	Id         uint32    `json:"id" db:"id"`
+	UserId     uint32    `json:"-" db:"user_id"`
+	Name       string    `json:"name" db:"name"`         // 密钥名称, 同一用户下唯一
+	EnvName    string    `json:"env_name" db:"env_name"` // 注入工作空间时的环境变量名称
+	Desc       string    `json:"desc" db:"desc"`
+	Hint       string    `json:"hint" db:"hint"`    // 脱敏后的值, 例如 sk-a****1234
+	Ciphertext string    `json:"-" db:"ciphertext"` // 密文
+	DataKey    string    `json:"-" db:"data_key"`   // 被主密钥加密的数据密钥
+	CreateTime time.Time `json:"create_time" db:"create_time"`
+	UpdateTime time.Time `json:"update_time" db:"update_time"`
+}
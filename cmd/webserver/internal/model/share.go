@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// 分享链接的权限, 网关无法限制WebSocket中的操作, 而code-server依赖WebSocket, 因此只支持完全访问
+const (
+	SharePermFull = "full" // 完全访问, 与所有者相同
+)
+
+// 分享链接的状态
+const (
+	ShareStatusActive = iota
+	ShareStatusRevoked
+)
+
+// SpaceShare 工作空间的分享链接
+type SpaceShare struct {
+	Id         uint32    `json:"id" db:"id"`
+	SpaceId    uint32    `json:"space_id" db:"space_id"`
+	Sid        string    `json:"sid" db:"sid"`
+	UserId     uint32    `json:"user_id" db:"user_id"` // 创建者
+	Permission string    `json:"permission" db:"permission"`
+	Status     uint8     `json:"status" db:"status"` // 0 有效 1 已撤销
+	ExpireTime time.Time `json:"expire_time" db:"expire_time"`
+	CreateTime time.Time `json:"create_time" db:"create_time"`
+	Url        string    `json:"url,omitempty" db:"-"` // 只在创建时返回
+}
+
+// ShareAccess 分享链接的访问记录
+type ShareAccess struct {
+	Id         uint32    `json:"id" db:"id"`
+	ShareId    uint32    `json:"share_id" db:"share_id"`
+	Sid        string    `json:"sid" db:"sid"`
+	Permission string    `json:"permission" db:"permission"`
+	Ip         string    `json:"ip" db:"ip"`
+	UserAgent  string    `json:"user_agent" db:"user_agent"`
+	AccessTime time.Time `json:"access_time" db:"access_time"`
+}
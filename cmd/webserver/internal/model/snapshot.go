@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// 快照的状态
+const (
+	SnapshotStatusPending = iota
+	SnapshotStatusReady
+	SnapshotStatusFailed
+)
+
+// SpaceSnapshot 工作空间存储卷的快照
+type SpaceSnapshot struct {
+	Id         uint32    `json:"id" db:"id"`
+	SpaceId    uint32    `json:"space_id" db:"space_id"`
+	UserId     uint32    `json:"-" db:"user_id"`
+	Name       string    `json:"name" db:"name"`     // control-plane中的快照名称
+	Desc       string    `json:"desc" db:"desc"`     // 用户填写的描述
+	Method     string    `json:"method" db:"method"` // VolumeSnapshot 或 Archive
+	Status     uint32    `json:"status" db:"status"` // 0 创建中 1 可用 2 失败
+	Message    string    `json:"message" db:"message"`
+	CreateTime time.Time `json:"create_time" db:"create_time"`
+}
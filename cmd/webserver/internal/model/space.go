@@ -1,6 +1,24 @@
 package model
 
+import "time"
+
 type RunningSpace struct {
 	Sid  string `json:"sid"`
 	Host string `json:"host"`
 }
+
+// SpaceRuntime 工作空间的一次运行记录, StopTime为空表示正在运行
+type SpaceRuntime struct {
+	Id        uint32     `json:"id" db:"id"`
+	SpaceId   uint32     `json:"space_id" db:"space_id"`
+	Sid       string     `json:"sid" db:"sid"`
+	UserId    uint32     `json:"user_id" db:"user_id"`
+	OrgId     uint32     `json:"org_id" db:"org_id"`
+	StartTime time.Time  `json:"start_time" db:"start_time"`
+	StopTime  *time.Time `json:"stop_time" db:"stop_time"`
+	SpecId    uint32     `json:"spec_id" db:"spec_id"`
+	CpuMillis uint32     `json:"cpu_millis" db:"cpu_millis"` // 启动时规格的CPU(毫核)
+	MemoryMb  uint32     `json:"memory_mb" db:"memory_mb"`   // 启动时规格的内存(MiB)
+	Tier      string     `json:"tier" db:"tier"`             // 启动时的套餐
+	Cost      int64      `json:"cost" db:"cost"`             // 已扣除的费用(分)
+}
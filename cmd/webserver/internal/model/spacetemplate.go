@@ -4,21 +4,60 @@ import "time"
 
 // SpaceTemplate 云开发空间模板
 type SpaceTemplate struct {
-	Id         uint32    `json:"id" db:"id"`
-	KindId     uint32    `json:"kind_id" db:"kind_id"` // 类别Id
-	Name       string    `json:"name" db:"name"`       // 空间模板名称
-	Desc       string    `json:"desc" db:"desc"`       // 描述
-	Tags       string    `json:"tags" db:"tags"`       // 标签，使用|隔开
-	Image      string    `json:"image" db:"image"`     // 镜像
-	Status     uint32    `json:"status" db:"status"`   // 0可用 1 已删除
-	Avatar     string    `json:"avatar" db:"avatar"`
-	CreateTime time.Time `json:"create_time" db:"create_time"`
-	DeleteTime time.Time `json:"delete_time" db:"delete_time"`
+	Id          uint32    `json:"id" db:"id"`
+	KindId      uint32    `json:"kind_id" db:"kind_id"` // 类别Id
+	Name        string    `json:"name" db:"name"`       // 空间模板名称
+	Desc        string    `json:"desc" db:"desc"`       // 描述
+	Tags        string    `json:"tags" db:"tags"`       // 标签，使用|隔开
+	Image       string    `json:"image" db:"image"`     // 镜像
+	Status      uint32    `json:"status" db:"status"`   // 0可用 1 已删除
+	Avatar      string    `json:"avatar" db:"avatar"`
+	IdleTimeout uint32    `json:"idle_timeout" db:"idle_timeout"` // 空闲超时时间(分钟), 0表示使用规格中的设置
+	CreateTime  time.Time `json:"create_time" db:"create_time"`
+	DeleteTime  time.Time `json:"delete_time" db:"delete_time"`
+
+	// 启动配置, 列表类型的字段使用json保存
+	Port          uint32 `json:"-" db:"port"`           // 容器端口, 0表示使用默认端口
+	Command       string `json:"-" db:"command"`        // 启动命令, 为空时使用镜像默认的启动命令
+	Args          string `json:"-" db:"args"`           // 启动参数
+	Env           string `json:"-" db:"env"`            // 默认的环境变量, 用户配置的环境变量优先
+	WorkingDir    string `json:"-" db:"working_dir"`    // 工作目录
+	ReadinessPath string `json:"-" db:"readiness_path"` // 就绪检查的http路径
+	LivenessPath  string `json:"-" db:"liveness_path"`  // 存活检查的http路径, 检查失败时重启容器
+	StartupPath   string `json:"-" db:"startup_path"`   // 启动检查的http路径, 适用于启动较慢的镜像
+	InitSteps     string `json:"-" db:"init_steps"`     // 启动前执行的初始化步骤
+}
+
+// TmplInitStep 模板的初始化步骤, 镜像为空时使用模板的镜像
+type TmplInitStep struct {
+	Name    string   `json:"name"`
+	Image   string   `json:"image"`
+	Command []string `json:"command"`
+}
+
+// TmplLaunch 模板的启动配置, 管理员编辑模板时使用
+type TmplLaunch struct {
+	Port          uint32            `json:"port"`
+	Command       []string          `json:"command"`
+	Args          []string          `json:"args"`
+	Env           map[string]string `json:"env"`
+	WorkingDir    string            `json:"working_dir"`
+	ReadinessPath string            `json:"readiness_path"`
+	LivenessPath  string            `json:"liveness_path"`
+	StartupPath   string            `json:"startup_path"`
+	InitSteps     []TmplInitStep    `json:"init_steps"`
+}
+
+// AdminTemplate 管理员查看的模板, 包含启动配置
+type AdminTemplate struct {
+	SpaceTemplate
+	Launch TmplLaunch `json:"launch"`
 }
 
 type TmplKind struct {
-	Id   uint32 `json:"id" db:"id"`
-	Name string `json:"name" db:"name"`
+	Id     uint32 `json:"id" db:"id"`
+	Name   string `json:"name" db:"name"`
+	Status uint32 `json:"status" db:"status"` // 0可用 1 已删除
 }
 
 // Space的Status
@@ -31,19 +70,21 @@ const (
 const (
 	RunningStatusStop = iota
 	RunningStatusRunning
+	RunningStatusStarting
 )
 
 // Space 用户根据模板创建的空间
 type Space struct {
 	Id            uint32        `json:"id" db:"id"`
-	UserId        uint32        `json:"user_id" db:"user_id"` // 所属用户的id
+	UserId        uint32        `json:"user_id" db:"user_id"` // 所属用户的id, 组织的工作空间为创建者
+	OrgId         uint32        `json:"org_id" db:"org_id"`   // 所属组织的id, 0表示个人工作空间
 	TmplId        uint32        `json:"tmpl_id" db:"tmpl_id"` // 模板的id
 	SpecId        uint32        `json:"spec_id" db:"spec_id"` // 规格id
 	Spec          SpaceSpec     `json:"spec"`
 	Sid           string        `json:"sid" db:"sid"`   // 工作空间Id，用于访问时的url中
 	Name          string        `json:"name" db:"name"` // 名称
 	Status        uint32        `json:"-" db:"status"`  // 0 已删除  1 可用 2 未创建
-	RunningStatus uint32        `json:"running_status"` // 0 停止  1 正在运行
+	RunningStatus uint32        `json:"running_status"` // 0 停止  1 正在运行  2 启动中
 	GitRepository string        `json:"git_repository" db:"git_repository"`
 	CreateTime    time.Time     `json:"create_time" db:"create_time"`
 	DeleteTime    time.Time     `json:"delete_time" db:"delete_time"`
@@ -51,6 +92,27 @@ type Space struct {
 	TotalTime     time.Duration `json:"total_time" db:"total_time"` // 总运行时间
 	Environment   string        `json:"environment"`
 	Avatar        string        `json:"avatar"`
+	StopReason    string        `json:"stop_reason,omitempty"` // 被自动停止的原因
+	StopMessage   string        `json:"stop_message,omitempty"`
+}
+
+// AdminSpace 管理员查看的云空间, 包含所属用户的信息
+type AdminSpace struct {
+	Space
+	Uid      string `json:"uid" db:"uid"`
+	Username string `json:"username" db:"username"`
+	Phase    string `json:"phase"` // control-plane中的状态, 未运行时为空
+}
+
+// SpaceEvent 云空间的生命周期事件, 通过SSE推送给浏览器
+type SpaceEvent struct {
+	Id      uint32    `json:"id"`
+	Sid     string    `json:"sid"`
+	Phase   string    `json:"phase"`
+	Stage   string    `json:"stage"`
+	Reason  string    `json:"reason,omitempty"`
+	Message string    `json:"message,omitempty"`
+	Time    time.Time `json:"time"`
 }
 
 // SpaceSpec 云空间的配置
@@ -61,4 +123,6 @@ type SpaceSpec struct {
 	StorageSpec string `json:"storage_spec" db:"storage_spec"` // 存储规格
 	Name        string `json:"name" db:"name"`
 	Desc        string `json:"desc" db:"desc"`
+	IdleTimeout uint32 `json:"idle_timeout" db:"idle_timeout"` // 空闲超时时间(分钟), 0表示使用默认值
+	Status      uint32 `json:"status" db:"status"`             // 0可用 1 已删除
 }
@@ -18,10 +18,17 @@ type User struct {
 	VipExpireTime   *time.Time `json:"vip_expire_time" db:"vip_expire_time"` // VIP到期时间
 	LinuxDoID       *int       `json:"linuxdo_id,omitempty" db:"linuxdo_id"`           // LinuxDo用户ID
 	LinuxDoUsername *string    `json:"linuxdo_username,omitempty" db:"linuxdo_username"` // LinuxDo用户名
+	Role            uint8      `json:"role" db:"role"` // 角色 0普通用户 1管理员
 
 	Token string `json:"token"`
 }
 
+// 用户角色
+const (
+	RoleUser uint8 = iota
+	RoleAdmin
+)
+
 type RegisterInfo struct {
 	Nickname  string `json:"nickname"`
 	Username  string `json:"username"`
@@ -0,0 +1,103 @@
+package pay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/conf"
+)
+
+var (
+	ErrInvalidSign      = errors.New("invalid signature")
+	ErrTradeNotFound    = errors.New("trade not found")
+	ErrRefundNotAllowed = errors.New("refund not allowed")
+	ErrTradeMismatch    = errors.New("trade mismatch")
+)
+
+// Gateway 支付网关, 负责创建支付、校验异步通知、查询支付结果和退款
+type Gateway interface {
+	// Name 支付网关的名称, 保存到支付记录中
+	Name() string
+
+	// CreatePayment 创建支付, 返回用户的支付地址
+	CreatePayment(ctx context.Context, req *PayRequest) (*PayResult, error)
+
+	// VerifyCallback 校验异步通知的签名并解析支付结果, 签名不正确时返回ErrInvalidSign
+	VerifyCallback(params map[string]string) (*Notification, error)
+
+	// QueryOrder 主动查询订单的支付结果
+	QueryOrder(ctx context.Context, orderNo string) (*Notification, error)
+
+	// Refund 按照支付网关的交易号退款
+	Refund(ctx context.Context, req *RefundRequest) error
+}
+
+// PayRequest 创建支付的参数, 金额的单位为元
+type PayRequest struct {
+	OrderNo   string
+	Subject   string
+	Amount    float64
+	Method    string
+	ClientIp  string
+	NotifyUrl string
+	ReturnUrl string
+}
+
+// PayResult 创建支付的结果, 页面跳转方式的支付网关在支付完成之前没有交易号
+type PayResult struct {
+	TradeNo string
+	PayUrl  string
+	QrCode  string
+}
+
+// Notification 支付网关通知或者查询到的支付结果
+type Notification struct {
+	OrderNo string
+	TradeNo string
+	Amount  float64
+	Paid    bool
+	// 原始数据, 保存到支付记录中
+	Raw string
+}
+
+// RefundRequest 退款的参数, 金额的单位为元
+type RefundRequest struct {
+	OrderNo string
+	TradeNo string
+	Amount  float64
+}
+
+var gateway Gateway
+
+// InitGateway 根据配置创建支付网关
+func InitGateway() error {
+	c := conf.PaymentConfig
+	switch c.Gateway {
+	case "ouyun":
+		if c.Ouyun.Pid == "" || c.Ouyun.Key == "" {
+			return errors.New("ouyun pid and key must be configured")
+		}
+		gateway = NewOuyunGateway(c.Ouyun)
+	case "mock":
+		if c.Mock.Key == "" {
+			return errors.New("mock payment key must be configured")
+		}
+		gateway = NewMockGateway(c.Mock)
+	default:
+		return fmt.Errorf("unknown payment gateway: %s", c.Gateway)
+	}
+
+	return nil
+}
+
+// Default 获取配置的支付网关, 需要先调用InitGateway
+func Default() Gateway {
+	return gateway
+}
+
+// Mock 配置的支付网关为模拟网关时返回该网关, 否则返回nil
+func Mock() *MockGateway {
+	m, _ := gateway.(*MockGateway)
+	return m
+}
@@ -0,0 +1,130 @@
+package pay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	pconf "github.com/mangohow/cloud-ide/pkg/conf"
+)
+
+func TestEpaySign(t *testing.T) {
+	params := map[string]string{
+		"pid":       "47",
+		"money":     "9.90",
+		"name":      "",
+		"sign_type": "MD5",
+		"sign":      "ignored",
+	}
+	// md5("money=9.90&pid=47key")
+	if sign := epaySign(params, "key"); sign != "e89867bbf1bdee05dd431a8360a82ee8" {
+		t.Fatalf("epaySign = %s", sign)
+	}
+
+	params["sign"] = epaySign(params, "key")
+	if _, err := epayVerify(params, "47", "key"); err != nil {
+		t.Errorf("epayVerify error: %v", err)
+	}
+	params["money"] = "0.01"
+	if _, err := epayVerify(params, "47", "key"); err != ErrInvalidSign {
+		t.Errorf("tampered params should fail verification, err: %v", err)
+	}
+}
+
+func TestMockGateway(t *testing.T) {
+	m := NewMockGateway(pconf.MockPayConf{Key: "test"})
+
+	var notified *Notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		params := make(map[string]string)
+		for k := range r.PostForm {
+			params[k] = r.PostForm.Get(k)
+		}
+		n, err := m.VerifyCallback(params)
+		if err != nil {
+			w.Write([]byte("fail"))
+			return
+		}
+		notified = n
+		w.Write([]byte("success"))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	res, err := m.CreatePayment(ctx, &PayRequest{
+		OrderNo:   "ORDER1",
+		Subject:   "VIP Month Card",
+		Amount:    29.9,
+		Method:    "alipay",
+		NotifyUrl: server.URL,
+		ReturnUrl: "https://example.com/idea/#/payment",
+	})
+	if err != nil || !strings.Contains(res.PayUrl, "ORDER1") {
+		t.Fatalf("CreatePayment = %+v, %v", res, err)
+	}
+
+	var page strings.Builder
+	if err = m.RenderCheckout(&page, "ORDER1"); err != nil || !strings.Contains(page.String(), "29.90") {
+		t.Fatalf("RenderCheckout error: %v", err)
+	}
+
+	returnUrl, err := m.Complete(ctx, "ORDER1", true)
+	if err != nil {
+		t.Fatalf("Complete error: %v", err)
+	}
+	if !strings.HasPrefix(returnUrl, "https://example.com/idea/?") || !strings.HasSuffix(returnUrl, "#/payment") {
+		t.Errorf("return url = %s", returnUrl)
+	}
+	if notified == nil || !notified.Paid || notified.OrderNo != "ORDER1" || notified.Amount != 29.9 {
+		t.Fatalf("notification = %+v", notified)
+	}
+
+	n, err := m.QueryOrder(ctx, "ORDER1")
+	if err != nil || !n.Paid || n.TradeNo != notified.TradeNo {
+		t.Errorf("QueryOrder = %+v, %v", n, err)
+	}
+
+	if err = m.Refund(ctx, &RefundRequest{OrderNo: "ORDER1", TradeNo: n.TradeNo, Amount: 29.9}); err != nil {
+		t.Errorf("Refund error: %v", err)
+	}
+	if err = m.Refund(ctx, &RefundRequest{OrderNo: "ORDER1", TradeNo: n.TradeNo, Amount: 29.9}); err != ErrRefundNotAllowed {
+		t.Errorf("refund twice should fail, err: %v", err)
+	}
+}
+
+func TestOuyunQueryOrder(t *testing.T) {
+	var response string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+	o := NewOuyunGateway(pconf.OuyunConf{Url: server.URL, Pid: "1000", Key: "test"})
+
+	tests := []struct {
+		name     string
+		response string
+		wantErr  error
+	}{
+		{"paid", `{"code":1,"trade_no":"T1","out_trade_no":"ORDER1","money":"29.90","status":1}`, nil},
+		{"not found", `{"code":-1,"msg":"not found"}`, ErrTradeNotFound},
+		// 其它订单的结果不能完成该订单
+		{"other order", `{"code":1,"trade_no":"T2","out_trade_no":"ORDER2","money":"29.90","status":1}`, ErrTradeMismatch},
+		{"invalid money", `{"code":1,"trade_no":"T1","out_trade_no":"ORDER1","money":"","status":1}`, ErrTradeMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response = tt.response
+			n, err := o.QueryOrder(context.Background(), "ORDER1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("QueryOrder error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (n.OrderNo != "ORDER1" || n.Amount != 29.9 || !n.Paid) {
+				t.Errorf("QueryOrder = %+v", n)
+			}
+		})
+	}
+}
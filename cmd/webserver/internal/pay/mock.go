@@ -0,0 +1,229 @@
+package pay
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	pconf "github.com/mangohow/cloud-ide/pkg/conf"
+)
+
+// mockPid 模拟网关的商户号
+const mockPid = "mock"
+
+// MockGateway 本地模拟的支付网关, 使用和ouyun相同的签名方式
+// 支付地址为本服务提供的模拟收银台, 用户确认支付后向notify_url发送签名的异步通知,
+// 因此不需要真实的支付网关就可以测试下单到开通VIP的完整流程, 订单只保存在内存中
+type MockGateway struct {
+	key    string
+	client *http.Client
+	mu     sync.Mutex
+	orders map[string]*mockOrder
+}
+
+type mockOrder struct {
+	req      PayRequest
+	tradeNo  string
+	paid     bool
+	refunded bool
+}
+
+func NewMockGateway(c pconf.MockPayConf) *MockGateway {
+	return &MockGateway{
+		key:    c.Key,
+		client: &http.Client{Timeout: time.Second * 10},
+		orders: make(map[string]*mockOrder),
+	}
+}
+
+func (m *MockGateway) Name() string {
+	return "mock"
+}
+
+// CreatePayment 返回模拟收银台的地址, 收银台和接口部署在同一个域名下, 因此使用相对地址
+func (m *MockGateway) CreatePayment(ctx context.Context, req *PayRequest) (*PayResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.orders[req.OrderNo] = &mockOrder{
+		req:     *req,
+		tradeNo: fmt.Sprintf("MOCK%d", time.Now().UnixNano()),
+	}
+
+	return &PayResult{
+		PayUrl: "/api/payment/mock/checkout?out_trade_no=" + url.QueryEscape(req.OrderNo),
+	}, nil
+}
+
+func (m *MockGateway) VerifyCallback(params map[string]string) (*Notification, error) {
+	return epayVerify(params, mockPid, m.key)
+}
+
+func (m *MockGateway) QueryOrder(ctx context.Context, orderNo string) (*Notification, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	o, ok := m.orders[orderNo]
+	if !ok {
+		return nil, ErrTradeNotFound
+	}
+
+	return &Notification{
+		OrderNo: orderNo,
+		TradeNo: o.tradeNo,
+		Amount:  o.req.Amount,
+		Paid:    o.paid && !o.refunded,
+	}, nil
+}
+
+func (m *MockGateway) Refund(ctx context.Context, req *RefundRequest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	o, ok := m.orders[req.OrderNo]
+	if !ok || o.tradeNo != req.TradeNo {
+		return ErrTradeNotFound
+	}
+	if !o.paid || o.refunded || req.Amount > o.req.Amount {
+		return ErrRefundNotAllowed
+	}
+	o.refunded = true
+
+	return nil
+}
+
+var checkoutTmpl = template.Must(template.New("checkout").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>模拟收银台</title></head>
+<body style="font-family: sans-serif; max-width: 480px; margin: 60px auto;">
+<h2>模拟收银台</h2>
+<p>仅用于开发和测试, 不会产生真实的扣款</p>
+<table>
+<tr><td>订单号</td><td>{{.OrderNo}}</td></tr>
+<tr><td>商品</td><td>{{.Subject}}</td></tr>
+<tr><td>金额</td><td>{{.Money}}</td></tr>
+<tr><td>支付方式</td><td>{{.Method}}</td></tr>
+</table>
+{{if .Paid}}<p>该订单已经支付</p>{{else}}
+<form method="post" action="/api/payment/mock/pay">
+<input type="hidden" name="out_trade_no" value="{{.OrderNo}}">
+<button type="submit" name="action" value="pay">确认支付</button>
+<button type="submit" name="action" value="cancel">取消</button>
+</form>{{end}}
+</body>
+</html>
+`))
+
+// RenderCheckout 渲染订单的模拟收银台页面
+func (m *MockGateway) RenderCheckout(w io.Writer, orderNo string) error {
+	m.mu.Lock()
+	o, ok := m.orders[orderNo]
+	var data map[string]interface{}
+	if ok {
+		data = map[string]interface{}{
+			"OrderNo": orderNo,
+			"Subject": o.req.Subject,
+			"Money":   formatMoney(o.req.Amount),
+			"Method":  o.req.Method,
+			"Paid":    o.paid,
+		}
+	}
+	m.mu.Unlock()
+	if !ok {
+		return ErrTradeNotFound
+	}
+
+	return checkoutTmpl.Execute(w, data)
+}
+
+// Complete 模拟用户完成支付, 向notify_url发送签名的异步通知, 返回支付完成后的跳转地址
+// paid为false表示用户取消支付, 不发送通知
+func (m *MockGateway) Complete(ctx context.Context, orderNo string, paid bool) (string, error) {
+	m.mu.Lock()
+	o, ok := m.orders[orderNo]
+	var req PayRequest
+	if ok {
+		req = o.req
+	}
+	m.mu.Unlock()
+	if !ok {
+		return "", ErrTradeNotFound
+	}
+	if !paid {
+		return req.ReturnUrl, nil
+	}
+
+	params := map[string]string{
+		"pid":          mockPid,
+		"trade_no":     o.tradeNo,
+		"out_trade_no": orderNo,
+		"type":         req.Method,
+		"name":         req.Subject,
+		"money":        formatMoney(req.Amount),
+		"trade_status": "TRADE_SUCCESS",
+		"sign_type":    "MD5",
+	}
+	params["sign"] = epaySign(params, m.key)
+	if err := m.notify(ctx, req.NotifyUrl, params); err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	o.paid = true
+	m.mu.Unlock()
+
+	return appendQuery(req.ReturnUrl, params), nil
+}
+
+// notify 发送异步通知, 和真实的支付网关一样要求返回success
+func (m *MockGateway) notify(ctx context.Context, notifyUrl string, params map[string]string) error {
+	form := url.Values{}
+	for k, v := range params {
+		form.Set(k, v)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, notifyUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if strings.TrimSpace(string(body)) != "success" {
+		return fmt.Errorf("notify %s failed, response: %s", notifyUrl, body)
+	}
+
+	return nil
+}
+
+// appendQuery 在跳转地址上附加参数, 地址中有#时附加在#之前
+func appendQuery(rawUrl string, params map[string]string) string {
+	if rawUrl == "" {
+		return ""
+	}
+	q := url.Values{}
+	for _, k := range []string{"out_trade_no", "trade_no", "trade_status"} {
+		q.Set(k, params[k])
+	}
+
+	base, fragment := rawUrl, ""
+	if i := strings.Index(rawUrl, "#"); i >= 0 {
+		base, fragment = rawUrl[:i], rawUrl[i:]
+	}
+	sep := "?"
+	if strings.Contains(base, "?") {
+		sep = "&"
+	}
+
+	return base + sep + q.Encode() + fragment
+}
@@ -0,0 +1,158 @@
+package pay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	pconf "github.com/mangohow/cloud-ide/pkg/conf"
+)
+
+// OuyunGateway ouyun聚合支付, 使用页面跳转的方式支付
+type OuyunGateway struct {
+	conf   pconf.OuyunConf
+	client *http.Client
+}
+
+func NewOuyunGateway(c pconf.OuyunConf) *OuyunGateway {
+	return &OuyunGateway{
+		conf:   c,
+		client: &http.Client{Timeout: time.Second * 30},
+	}
+}
+
+func (o *OuyunGateway) Name() string {
+	return "ouyun"
+}
+
+// CreatePayment 构造页面跳转支付的地址, 交易号在异步通知中返回
+func (o *OuyunGateway) CreatePayment(ctx context.Context, req *PayRequest) (*PayResult, error) {
+	params := map[string]string{
+		"pid":          o.conf.Pid,
+		"type":         req.Method,
+		"out_trade_no": req.OrderNo,
+		"notify_url":   req.NotifyUrl,
+		"return_url":   req.ReturnUrl,
+		"name":         req.Subject,
+		"money":        formatMoney(req.Amount),
+		"clientip":     req.ClientIp,
+		"device":       "pc",
+		"sign_type":    "MD5",
+	}
+	params["sign"] = epaySign(params, o.conf.Key)
+
+	// 按照固定的顺序拼接参数
+	keys := []string{"pid", "type", "out_trade_no", "notify_url", "return_url", "name", "money", "clientip", "device", "sign_type", "sign"}
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if v := params[k]; v != "" {
+			parts = append(parts, k+"="+url.QueryEscape(v))
+		}
+	}
+
+	return &PayResult{
+		PayUrl: o.conf.Url + "/submit.php?" + strings.Join(parts, "&"),
+	}, nil
+}
+
+func (o *OuyunGateway) VerifyCallback(params map[string]string) (*Notification, error) {
+	return epayVerify(params, o.conf.Pid, o.conf.Key)
+}
+
+// ouyunResponse 查询和退款接口的响应, code为1表示成功, status为1表示已支付
+type ouyunResponse struct {
+	Code       int    `json:"code"`
+	Msg        string `json:"msg"`
+	TradeNo    string `json:"trade_no"`
+	OutTradeNo string `json:"out_trade_no"`
+	Money      string `json:"money"`
+	Status     int    `json:"status"`
+}
+
+func (o *OuyunGateway) QueryOrder(ctx context.Context, orderNo string) (*Notification, error) {
+	query := url.Values{
+		"act":          {"order"},
+		"pid":          {o.conf.Pid},
+		"key":          {o.conf.Key},
+		"out_trade_no": {orderNo},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.conf.Url+"/api.php?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, body, err := o.do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Code != 1 {
+		return nil, ErrTradeNotFound
+	}
+
+	// 查询结果没有签名, 只接受该订单的结果, 防止错误或重放的响应完成其它订单
+	if resp.OutTradeNo != orderNo {
+		return nil, fmt.Errorf("%w: out_trade_no %q, want %q", ErrTradeMismatch, resp.OutTradeNo, orderNo)
+	}
+	amount, err := strconv.ParseFloat(resp.Money, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid money %q", ErrTradeMismatch, resp.Money)
+	}
+
+	return &Notification{
+		OrderNo: resp.OutTradeNo,
+		TradeNo: resp.TradeNo,
+		Amount:  amount,
+		Paid:    resp.Status == 1,
+		Raw:     string(body),
+	}, nil
+}
+
+func (o *OuyunGateway) Refund(ctx context.Context, r *RefundRequest) error {
+	form := url.Values{
+		"pid":      {o.conf.Pid},
+		"key":      {o.conf.Key},
+		"trade_no": {r.TradeNo},
+		"money":    {formatMoney(r.Amount)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.conf.Url+"/api.php?act=refund", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, _, err := o.do(req)
+	if err != nil {
+		return err
+	}
+	if resp.Code != 1 {
+		return fmt.Errorf("%w: %s", ErrRefundNotAllowed, resp.Msg)
+	}
+
+	return nil
+}
+
+func (o *OuyunGateway) do(req *http.Request) (*ouyunResponse, []byte, error) {
+	res, err := o.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("ouyun response status %d", res.StatusCode)
+	}
+	var resp ouyunResponse
+	if err = json.Unmarshal(body, &resp); err != nil {
+		return nil, nil, fmt.Errorf("decode ouyun response error:%v", err)
+	}
+
+	return &resp, body, nil
+}
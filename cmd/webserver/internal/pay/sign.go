@@ -0,0 +1,75 @@
+package pay
+
+import (
+	"crypto/md5"
+	"crypto/subtle"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// epay是国内聚合支付常用的协议, ouyun和模拟网关都使用这种签名方式:
+// 除sign、sign_type和空值以外的参数按照参数名排序, 拼接为a=1&b=2, 然后直接拼接商户密钥计算小写的MD5
+
+// epaySign 计算参数的签名
+func epaySign(params map[string]string, key string) string {
+	keys := make([]string, 0, len(params))
+	for k, v := range params {
+		if k != "sign" && k != "sign_type" && v != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+params[k])
+	}
+
+	return fmt.Sprintf("%x", md5.Sum([]byte(strings.Join(parts, "&")+key)))
+}
+
+// epayVerify 校验异步通知的签名和商户号, 解析支付结果
+func epayVerify(params map[string]string, pid, key string) (*Notification, error) {
+	if params["pid"] != pid || params["sign"] == "" {
+		return nil, ErrInvalidSign
+	}
+	sign := epaySign(params, key)
+	if subtle.ConstantTimeCompare([]byte(sign), []byte(strings.ToLower(params["sign"]))) != 1 {
+		return nil, ErrInvalidSign
+	}
+
+	amount, err := strconv.ParseFloat(params["money"], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid money %q", params["money"])
+	}
+
+	return &Notification{
+		OrderNo: params["out_trade_no"],
+		TradeNo: params["trade_no"],
+		Amount:  amount,
+		Paid:    params["trade_status"] == "TRADE_SUCCESS",
+		Raw:     encodeParams(params),
+	}, nil
+}
+
+// encodeParams 按照参数名排序后编码, 用于保存原始数据
+func encodeParams(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+params[k])
+	}
+
+	return strings.Join(parts, "&")
+}
+
+func formatMoney(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
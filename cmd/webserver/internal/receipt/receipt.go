@@ -0,0 +1,119 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Receipt 收据上显示的内容
+type Receipt struct {
+	Seller     string
+	InvoiceNo  string
+	OrderNo    string
+	BuyerName  string
+	BuyerEmail string
+	Product    string
+	// 产品的英文名称, PDF没有中文字体时使用
+	Subject       string
+	PaymentMethod string
+	Amount        float64
+	PaidTime      time.Time
+}
+
+var funcs = map[string]any{
+	"money": func(amount float64) string { return fmt.Sprintf("%.2f", amount) },
+	"date":  func(t time.Time) string { return t.Format("2006-01-02 15:04:05") },
+}
+
+var htmlTmpl = htmltemplate.Must(htmltemplate.New("receipt").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>收据 {{.InvoiceNo}}</title></head>
+<body style="font-family: sans-serif; max-width: 640px; margin: 40px auto; color: #333;">
+<h2 style="margin-bottom: 4px;">{{.Seller}}</h2>
+<p style="margin-top: 0; color: #888;">收据</p>
+<table style="width: 100%; border-collapse: collapse;">
+<tr><td style="padding: 6px 0; color: #888;">收据编号</td><td>{{.InvoiceNo}}</td></tr>
+<tr><td style="padding: 6px 0; color: #888;">订单号</td><td>{{.OrderNo}}</td></tr>
+<tr><td style="padding: 6px 0; color: #888;">支付时间</td><td>{{date .PaidTime}}</td></tr>
+<tr><td style="padding: 6px 0; color: #888;">购买人</td><td>{{.BuyerName}}{{with .BuyerEmail}} &lt;{{.}}&gt;{{end}}</td></tr>
+<tr><td style="padding: 6px 0; color: #888;">支付方式</td><td>{{.PaymentMethod}}</td></tr>
+</table>
+<table style="width: 100%; border-collapse: collapse; margin-top: 24px;">
+<tr style="border-bottom: 1px solid #ddd;"><th style="text-align: left; padding: 8px 0;">商品</th><th style="text-align: right;">金额(元)</th></tr>
+<tr style="border-bottom: 1px solid #ddd;"><td style="padding: 8px 0;">{{.Product}}</td><td style="text-align: right;">{{money .Amount}}</td></tr>
+<tr><td style="padding: 8px 0;"><b>合计</b></td><td style="text-align: right;"><b>{{money .Amount}}</b></td></tr>
+</table>
+<p style="margin-top: 32px; color: #888;">已全额支付, 感谢您的购买</p>
+</body>
+</html>
+`))
+
+// pdf的每一行为 名称|值, 内置字体不支持中文, 使用英文
+var pdfTmpl = template.Must(template.New("receipt").Funcs(funcs).Parse(`Receipt No.|{{.InvoiceNo}}
+Order No.|{{.OrderNo}}
+Date Paid|{{date .PaidTime}}
+Billed To|{{.BuyerName}}{{with .BuyerEmail}} <{{.}}>{{end}}
+Payment Method|{{.PaymentMethod}}
+Item|{{.Subject}}
+Amount (CNY)|{{money .Amount}}`))
+
+// RenderHTML 生成HTML收据
+func RenderHTML(w io.Writer, r *Receipt) error {
+	return htmlTmpl.Execute(w, r)
+}
+
+// RenderPDF 生成PDF收据, fontFile为UTF-8字体文件, 为空时使用内置字体并显示产品的英文名称
+func RenderPDF(w io.Writer, r *Receipt, fontFile string) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetCreationDate(r.PaidTime)
+	pdf.SetTitle("Receipt "+r.InvoiceNo, true)
+	pdf.SetAuthor(r.Seller, true)
+
+	family, tr := "Helvetica", pdf.UnicodeTranslatorFromDescriptor("")
+	if fontFile != "" {
+		font, err := os.ReadFile(fontFile)
+		if err != nil {
+			return err
+		}
+		pdf.AddUTF8FontFromBytes("receipt", "", font)
+		family, tr = "receipt", func(s string) string { return s }
+		rc := *r
+		rc.Subject = r.Product
+		r = &rc
+	}
+
+	var body bytes.Buffer
+	if err := pdfTmpl.Execute(&body, r); err != nil {
+		return err
+	}
+
+	pdf.AddPage()
+	pdf.SetFont(family, "", 20)
+	pdf.CellFormat(0, 12, tr(r.Seller), "", 1, "L", false, 0, "")
+	pdf.SetFont(family, "", 12)
+	pdf.SetTextColor(128, 128, 128)
+	pdf.CellFormat(0, 8, "RECEIPT", "", 1, "L", false, 0, "")
+	pdf.Ln(6)
+
+	for _, line := range strings.Split(body.String(), "\n") {
+		label, value, _ := strings.Cut(line, "|")
+		pdf.SetTextColor(128, 128, 128)
+		pdf.CellFormat(45, 9, label, "", 0, "L", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+		pdf.CellFormat(0, 9, tr(value), "", 1, "L", false, 0, "")
+	}
+
+	pdf.Ln(10)
+	pdf.SetTextColor(128, 128, 128)
+	pdf.CellFormat(0, 8, "Paid in full. Thank you for your purchase.", "", 1, "L", false, 0, "")
+
+	return pdf.Output(w)
+}
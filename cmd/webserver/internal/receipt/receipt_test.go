@@ -0,0 +1,48 @@
+package receipt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testReceipt = &Receipt{
+	Seller:        "Cloud Code",
+	InvoiceNo:     "INV2024000001",
+	OrderNo:       "ORDER1714521600000001",
+	BuyerName:     "<mango>",
+	BuyerEmail:    "mango@example.com",
+	Product:       "月卡会员",
+	Subject:       "VIP Month Card",
+	PaymentMethod: "alipay",
+	Amount:        29.9,
+	PaidTime:      time.Date(2024, 5, 1, 8, 0, 0, 0, time.Local),
+}
+
+func TestRenderHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderHTML(&buf, testReceipt); err != nil {
+		t.Fatal(err)
+	}
+	html := buf.String()
+	for _, s := range []string{"INV2024000001", "月卡会员", "29.90", "2024-05-01 08:00:00", "&lt;mango&gt;"} {
+		if !strings.Contains(html, s) {
+			t.Errorf("html receipt does not contain %q", s)
+		}
+	}
+}
+
+func TestRenderPDF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderPDF(&buf, testReceipt, ""); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Errorf("pdf receipt has invalid header %q", buf.Bytes()[:8])
+	}
+
+	if err := RenderPDF(&buf, testReceipt, "not-exist.ttf"); err == nil {
+		t.Errorf("RenderPDF with missing font file should fail")
+	}
+}
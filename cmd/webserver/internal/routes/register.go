@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/controller"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/middleware"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/pay"
 	"github.com/mangohow/cloud-ide/pkg/router"
 )
 
@@ -49,6 +50,53 @@ func Register(engine *gin.Engine) {
 		apiGroup.PUT("/workspace/start", router.HandlerAdapter(spaceController.StartSpace))
 		apiGroup.PUT("/workspace/stop", router.HandlerAdapter(spaceController.StopSpace))
 		apiGroup.PUT("/workspace/name", router.HandlerAdapter(spaceController.ModifySpaceName))
+		apiGroup.PUT("/workspace/spec", router.HandlerAdapter(spaceController.ModifySpaceSpec))
+		apiGroup.POST("/workspace/watch/ticket", router.HandlerAdapter(spaceController.CreateWatchTicket))
+		apiGroup.GET("/workspace/access", router.HandlerAdapter(spaceController.SpaceAccess))
+		apiGroup.POST("/workspace/ticket", router.HandlerAdapter(spaceController.CreateTicket))
+
+		// SSE请求使用订阅票据认证, 不经过Auth
+		engine.GET("/api/workspace/watch", middleware.WatchAuth(), router.HandlerAdapter(spaceController.WatchSpace))
+	}
+
+	snapshotController := controller.NewSnapshotController()
+	{
+		apiGroup.POST("/workspace/snapshot", router.HandlerAdapter(snapshotController.CreateSnapshot))
+		apiGroup.GET("/workspace/snapshot/list", router.HandlerAdapter(snapshotController.ListSnapshots))
+		apiGroup.PUT("/workspace/snapshot/restore", router.HandlerAdapter(snapshotController.RestoreSnapshot))
+	}
+
+	shareController := controller.NewShareController()
+	{
+		apiGroup.POST("/workspace/share", router.HandlerAdapter(shareController.CreateShare))
+		apiGroup.GET("/workspace/shares", router.HandlerAdapter(shareController.ListShares))
+		apiGroup.DELETE("/workspace/share", router.HandlerAdapter(shareController.RevokeShare))
+		apiGroup.GET("/workspace/share/access", router.HandlerAdapter(shareController.ListShareAccess))
+	}
+
+	secretController := controller.NewSecretController()
+	{
+		apiGroup.GET("/secrets", router.HandlerAdapter(secretController.ListSecrets))
+		apiGroup.POST("/secrets", router.HandlerAdapter(secretController.CreateSecret))
+		apiGroup.PUT("/secrets", router.HandlerAdapter(secretController.UpdateSecret))
+		apiGroup.DELETE("/secrets", router.HandlerAdapter(secretController.DeleteSecret))
+	}
+
+	orgController := controller.NewOrgController()
+	{
+		apiGroup.GET("/orgs", router.HandlerAdapter(orgController.ListOrgs))
+		apiGroup.POST("/org", router.HandlerAdapter(orgController.CreateOrg))
+		apiGroup.GET("/org/members", router.HandlerAdapter(orgController.ListMembers))
+		apiGroup.POST("/org/member", router.HandlerAdapter(orgController.AddMember))
+		apiGroup.PUT("/org/member/role", router.HandlerAdapter(orgController.SetMemberRole))
+		apiGroup.DELETE("/org/member", router.HandlerAdapter(orgController.RemoveMember))
+	}
+
+	usageController := controller.NewUsageController()
+	{
+		apiGroup.GET("/usage", router.HandlerAdapter(usageController.Usage))
+		apiGroup.GET("/usage/monthly", router.HandlerAdapter(usageController.MonthlyUsage))
+		apiGroup.GET("/credit", router.HandlerAdapter(usageController.Credit))
 	}
 
 	// 支付相关路由
@@ -60,6 +108,14 @@ func Register(engine *gin.Engine) {
 		paymentGroup.GET("/orders", router.HandlerAdapter(paymentController.GetOrders))
 		paymentGroup.GET("/subscription", router.HandlerAdapter(paymentController.GetSubscription))
 		paymentGroup.POST("/sync", router.HandlerAdapter(paymentController.SyncPaymentStatus))
+		paymentGroup.GET("/coupon", router.HandlerAdapter(paymentController.PreviewCoupon))
+	}
+
+	invoiceController := controller.NewInvoiceController()
+	{
+		paymentGroup.GET("/invoices", router.HandlerAdapter(invoiceController.List))
+		paymentGroup.GET("/invoice/download", router.HandlerAdapter(invoiceController.Download))
+		paymentGroup.POST("/invoice/email", router.HandlerAdapter(invoiceController.Email))
 	}
 
 	// 支付回调路由和公开API（不需要认证）
@@ -71,6 +127,50 @@ func Register(engine *gin.Engine) {
 		callbackGroup.Any("/callback", router.HandlerAdapter(paymentController.PaymentCallback))
 		callbackGroup.GET("/return", router.HandlerAdapter(paymentController.PaymentReturn))
 	}
+
+	// 模拟支付网关的收银台, 只用于开发和测试
+	if gateway := pay.Mock(); gateway != nil {
+		mockPayController := controller.NewMockPayController(gateway)
+		callbackGroup.GET("/mock/checkout", router.HandlerAdapter(mockPayController.Checkout))
+		callbackGroup.POST("/mock/pay", router.HandlerAdapter(mockPayController.Pay))
+	}
+
+	// 网关调用的内部接口, 网关只转发/api和/auth, 不会暴露给浏览器, 集群内的其它调用者需要网关的令牌
+	internalGroup := engine.Group("/internal", middleware.InternalAuth())
+	{
+		internalGroup.GET("/share/verify", router.HandlerAdapter(shareController.VerifyShare))
+		internalGroup.GET("/workspace/verify", router.HandlerAdapter(spaceController.VerifyTicket))
+	}
+
+	// 管理员路由, 挂载在/api下由网关转发
+	adminGroup := engine.Group("/api/admin", middleware.Auth(), middleware.AdminRequired())
+	adminController := controller.NewAdminController()
+	{
+		adminGroup.GET("/users", router.HandlerAdapter(adminController.ListUsers))
+		adminGroup.PUT("/user/status", router.HandlerAdapter(adminController.SetUserStatus))
+		adminGroup.POST("/user/vip", router.HandlerAdapter(adminController.GrantVip))
+		adminGroup.GET("/subscription/stats", router.HandlerAdapter(adminController.SubscriptionStats))
+		adminGroup.POST("/order/refund", router.HandlerAdapter(adminController.RefundOrder))
+		adminGroup.GET("/coupons", router.HandlerAdapter(adminController.ListCoupons))
+		adminGroup.POST("/coupon", router.HandlerAdapter(adminController.CreateCoupon))
+		adminGroup.PUT("/coupon/status", router.HandlerAdapter(adminController.SetCouponStatus))
+		adminGroup.GET("/job/history", router.HandlerAdapter(adminController.JobHistory))
+		adminGroup.GET("/workspaces", router.HandlerAdapter(adminController.ListWorkspaces))
+		adminGroup.PUT("/workspace/stop", router.HandlerAdapter(adminController.StopWorkspace))
+		adminGroup.GET("/templates", router.HandlerAdapter(adminController.ListTemplates))
+		adminGroup.POST("/template", router.HandlerAdapter(adminController.CreateTemplate))
+		adminGroup.PUT("/template", router.HandlerAdapter(adminController.UpdateTemplate))
+		adminGroup.DELETE("/template", router.HandlerAdapter(adminController.DeleteTemplate))
+		adminGroup.PUT("/template/status", router.HandlerAdapter(adminController.SetTemplateStatus))
+		adminGroup.GET("/kinds", router.HandlerAdapter(adminController.ListKinds))
+		adminGroup.POST("/kind", router.HandlerAdapter(adminController.CreateKind))
+		adminGroup.PUT("/kind", router.HandlerAdapter(adminController.UpdateKind))
+		adminGroup.DELETE("/kind", router.HandlerAdapter(adminController.DeleteKind))
+		adminGroup.GET("/specs", router.HandlerAdapter(adminController.ListSpecs))
+		adminGroup.POST("/spec", router.HandlerAdapter(adminController.CreateSpec))
+		adminGroup.PUT("/spec", router.HandlerAdapter(adminController.UpdateSpec))
+		adminGroup.DELETE("/spec", router.HandlerAdapter(adminController.DeleteSpec))
+	}
 }
 
 // corsMiddleware 添加CORS支持
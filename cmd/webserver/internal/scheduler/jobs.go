@@ -0,0 +1,41 @@
+package scheduler
+
+import (
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/conf"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/service"
+)
+
+// 定时任务名称
+const (
+	JobSubscriptionExpire   = "subscription-expire"
+	JobSubscriptionRemind   = "subscription-remind"
+	JobStopLapsedWorkspaces = "stop-lapsed-workspaces"
+	JobCloseExpiredOrders   = "close-expired-orders"
+	JobMeterWorkspaces      = "meter-workspaces"
+)
+
+// RegisterJobs 注册所有的定时任务
+func RegisterJobs(s *Scheduler) error {
+	subscription := service.NewSubscriptionService()
+	spaces := service.NewCloudCodeService()
+	payments := service.NewPaymentService()
+	metering := service.NewMeteringService()
+	jobs := []struct {
+		name string
+		spec string
+		job  Job
+	}{
+		{JobSubscriptionExpire, conf.SchedulerConfig.ExpireSpec, subscription.HandleSubscriptionExpiration},
+		{JobSubscriptionRemind, conf.SchedulerConfig.RemindSpec, subscription.NotifyExpiringUsers},
+		{JobStopLapsedWorkspaces, conf.SchedulerConfig.StopLapsedSpec, spaces.StopLapsedWorkspaces},
+		{JobCloseExpiredOrders, conf.SchedulerConfig.CloseOrderSpec, payments.CloseExpiredOrders},
+		{JobMeterWorkspaces, conf.SchedulerConfig.MeteringSpec, metering.MeterAll},
+	}
+	for _, j := range jobs {
+		if err := s.Register(j.name, j.spec, j.job); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// redisLocker 使用SETNX抢占调度, value为抢占成功的副本, 便于排查
+type redisLocker struct {
+	client *redis.Client
+	value  string
+}
+
+func (r *redisLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, key, r.value, ttl).Result()
+}
+
+// localLocker 没有redis时使用, 只适用于单副本部署
+type localLocker struct{}
+
+func (localLocker) TryLock(context.Context, string, time.Duration) (bool, error) {
+	return true, nil
+}
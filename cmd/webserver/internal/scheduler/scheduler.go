@@ -0,0 +1,156 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao/rdis"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+	"github.com/mangohow/cloud-ide/pkg/logger"
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+)
+
+// lockTTL 调度锁的过期时间, 锁不主动释放, 避免执行较快的副本释放锁之后其它副本再次执行
+const lockTTL = time.Minute * 10
+
+// Job 定时任务, 返回的错误记录到执行记录中
+type Job func() error
+
+// Locker 保证所有副本对同一次调度只有一个副本执行
+type Locker interface {
+	// TryLock 抢占key, 成功时返回true, ttl之后自动过期
+	TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// History 记录任务的执行记录
+type History interface {
+	// Start 记录任务开始执行, 返回记录的id
+	Start(name, instance string, startTime time.Time) (uint32, error)
+	// Finish 记录任务执行结束
+	Finish(id uint32, status uint8, message string, endTime time.Time) error
+}
+
+// Scheduler 进程内的定时任务调度器, 每个副本都按照cron表达式触发任务,
+// 然后通过Locker抢占本次调度, 只有抢占成功的副本执行并记录执行记录
+type Scheduler struct {
+	logger   *logrus.Logger
+	cron     *cron.Cron
+	locker   Locker
+	history  History
+	instance string
+}
+
+func New() *Scheduler {
+	l := logger.Logger()
+	instance, _ := os.Hostname()
+
+	var locker Locker = localLocker{}
+	if client := rdis.RedisInstance(); client != nil {
+		locker = &redisLocker{client: client, value: instance}
+	} else {
+		l.Warn("redis is not available, scheduled jobs will run on every replica")
+	}
+
+	return &Scheduler{
+		logger: l,
+		// 上一次执行还没有结束时跳过本次调度
+		cron:     cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.PrintfLogger(l)))),
+		locker:   locker,
+		history:  dao.NewJobDao(),
+		instance: instance,
+	}
+}
+
+// Register 注册定时任务, spec为5位的cron表达式, 为空时不注册
+func (s *Scheduler) Register(name, spec string, job Job) error {
+	if spec == "" {
+		s.logger.Infof("job %s is disabled", name)
+		return nil
+	}
+
+	// 触发时Entry的Prev为本次调度的时间, 所有副本的调度时间相同, 与各自的时钟误差无关
+	var id cron.EntryID
+	id, err := s.cron.AddFunc(spec, func() { s.run(name, s.cron.Entry(id).Prev, job) })
+	if err != nil {
+		return fmt.Errorf("register job %s error:%v", name, err)
+	}
+	s.logger.Infof("job %s registered, spec:%s", name, spec)
+
+	return nil
+}
+
+// Start 开始调度
+func (s *Scheduler) Start() {
+	s.cron.Start()
+}
+
+// Stop 停止调度, 等待正在执行的任务结束
+func (s *Scheduler) Stop() {
+	<-s.cron.Stop().Done()
+}
+
+// run 抢占scheduled这一次调度, 抢占成功时执行任务, scheduled为cron计算出的调度时间
+func (s *Scheduler) run(name string, scheduled time.Time, job Job) {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*5)
+	ok, err := s.locker.TryLock(ctx, lockKey(name, scheduled), lockTTL)
+	cancelFunc()
+	if err != nil {
+		s.logger.Errorf("lock job %s error:%v", name, err)
+		return
+	}
+	if !ok {
+		s.logger.Debugf("job %s is running on other replica", name)
+		return
+	}
+
+	start := time.Now()
+	id, err := s.history.Start(name, s.instance, start)
+	if err != nil {
+		s.logger.Warnf("add job history error:%v, job:%s", err, name)
+	}
+
+	status, message := model.JobStatusSuccess, ""
+	if err = runJob(job); err != nil {
+		status, message = model.JobStatusFailed, err.Error()
+		s.logger.Errorf("job %s failed:%v", name, err)
+	}
+	s.logger.Infof("job %s finished, cost:%v", name, time.Since(start))
+
+	if id == 0 {
+		return
+	}
+	if err = s.history.Finish(id, status, truncate(message, 1024), time.Now()); err != nil {
+		s.logger.Warnf("update job history error:%v, job:%s", err, name)
+	}
+}
+
+// lockKey 调度锁的key, 同一个任务的同一次调度在所有副本上相同
+func lockKey(name string, scheduled time.Time) string {
+	// 没有调度时间时按照分钟截断当前时间
+	if scheduled.IsZero() {
+		scheduled = time.Now().Truncate(time.Minute)
+	}
+	return fmt.Sprintf("cloud-ide:job:%s:%d", name, scheduled.Unix())
+}
+
+// runJob 执行任务, 任务panic时返回错误
+func runJob(job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return job()
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,189 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+)
+
+// memLocker 模拟redis的SETNX, 记录抢占过的key
+type memLocker struct {
+	mu   sync.Mutex
+	keys map[string]bool
+	err  error
+}
+
+func (m *memLocker) TryLock(_ context.Context, key string, _ time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return false, m.err
+	}
+	if m.keys[key] {
+		return false, nil
+	}
+	m.keys[key] = true
+	return true, nil
+}
+
+type historyRecord struct {
+	name    string
+	status  uint8
+	message string
+}
+
+type memHistory struct {
+	mu      sync.Mutex
+	records []historyRecord
+}
+
+func (m *memHistory) Start(name, _ string, _ time.Time) (uint32, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records = append(m.records, historyRecord{name: name})
+	return uint32(len(m.records)), nil
+}
+
+func (m *memHistory) Finish(id uint32, status uint8, message string, _ time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records[id-1].status, m.records[id-1].message = status, message
+	return nil
+}
+
+func newTestScheduler(locker Locker, history History, instance string) *Scheduler {
+	l := logrus.New()
+	l.SetLevel(logrus.PanicLevel)
+	return &Scheduler{
+		logger:   l,
+		cron:     cron.New(),
+		locker:   locker,
+		history:  history,
+		instance: instance,
+	}
+}
+
+func TestSchedulerRunOncePerSlot(t *testing.T) {
+	locker := &memLocker{keys: map[string]bool{}}
+	history := &memHistory{}
+	// 两个副本共享同一个locker, 模拟多个副本抢占同一次调度
+	replicas := []*Scheduler{
+		newTestScheduler(locker, history, "a"),
+		newTestScheduler(locker, history, "b"),
+	}
+
+	var runs int
+	job := func() error { runs++; return nil }
+	slot := time.Date(2026, 1, 1, 10, 5, 0, 0, time.UTC)
+	for _, s := range replicas {
+		s.run("job", slot, job)
+	}
+	if runs != 1 {
+		t.Fatalf("job ran %d times in one slot, want 1", runs)
+	}
+
+	// 下一次调度可以再次抢占
+	replicas[1].run("job", slot.Add(5*time.Minute), job)
+	if runs != 2 {
+		t.Fatalf("job ran %d times after next slot, want 2", runs)
+	}
+	if len(history.records) != 2 || history.records[0].status != model.JobStatusSuccess {
+		t.Errorf("unexpected history: %+v", history.records)
+	}
+}
+
+func TestSchedulerSkip(t *testing.T) {
+	slot := time.Date(2026, 1, 1, 10, 5, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		locker *memLocker
+	}{
+		{"held by other replica", &memLocker{keys: map[string]bool{lockKey("job", slot): true}}},
+		{"lock error", &memLocker{keys: map[string]bool{}, err: errors.New("redis down")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history := &memHistory{}
+			s := newTestScheduler(tt.locker, history, "a")
+			var ran bool
+			s.run("job", slot, func() error { ran = true; return nil })
+			if ran {
+				t.Error("job should be skipped")
+			}
+			if len(history.records) != 0 {
+				t.Errorf("skipped job should not be recorded: %+v", history.records)
+			}
+		})
+	}
+}
+
+func TestSchedulerRecordFailure(t *testing.T) {
+	history := &memHistory{}
+	s := newTestScheduler(&memLocker{keys: map[string]bool{}}, history, "a")
+
+	s.run("fail", time.Now(), func() error { return errors.New("boom") })
+	s.run("panic", time.Now(), func() error { panic("oops") })
+
+	if len(history.records) != 2 {
+		t.Fatalf("unexpected history: %+v", history.records)
+	}
+	for _, r := range history.records {
+		if r.status != model.JobStatusFailed || r.message == "" {
+			t.Errorf("job %s should be recorded as failed: %+v", r.name, r)
+		}
+	}
+}
+
+func TestLockKey(t *testing.T) {
+	slot := time.Date(2026, 1, 1, 10, 5, 0, 0, time.UTC)
+	// 调度时间相同时key相同, 与触发时各副本的时钟无关
+	if lockKey("job", slot) != lockKey("job", slot.In(time.Local)) {
+		t.Error("lock key should not depend on location")
+	}
+	if lockKey("job", slot) == lockKey("job", slot.Add(time.Minute)) {
+		t.Error("different slots should use different keys")
+	}
+	if lockKey("job", slot) == lockKey("other", slot) {
+		t.Error("different jobs should use different keys")
+	}
+}
+
+func TestRegisterUsesScheduledTime(t *testing.T) {
+	locker := &memLocker{keys: map[string]bool{}}
+	s := newTestScheduler(locker, &memHistory{}, "a")
+	s.cron = cron.New(cron.WithSeconds())
+
+	done := make(chan struct{}, 1)
+	if err := s.Register("job", "* * * * * *", func() error {
+		select {
+		case done <- struct{}{}:
+		default:
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("job was not triggered")
+	}
+	s.Stop()
+
+	// 最后一次调度使用的key为该次调度的时间
+	prev := s.cron.Entries()[0].Prev
+	locker.mu.Lock()
+	defer locker.mu.Unlock()
+	if !locker.keys[lockKey("job", prev)] {
+		t.Errorf("lock key of scheduled time %v not found: %v", prev, locker.keys)
+	}
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"errors"
+	"unicode/utf8"
+
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/caches"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/code"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+	"github.com/mangohow/cloud-ide/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// MaxVipGrantDays 管理员一次最多赠送的VIP天数
+	MaxVipGrantDays = 366
+	// MaxRefundReasonLen 退款原因的最大长度
+	MaxRefundReasonLen = 255
+)
+
+var (
+	ErrAdminUserNotFound = errors.New("user not found")
+	ErrAdminModifySelf   = errors.New("cannot modify self")
+	ErrAdminOperation    = errors.New("admin operation failed")
+)
+
+// AdminService 管理员对用户的管理
+type AdminService struct {
+	logger       *logrus.Logger
+	dao          *dao.UserDao
+	subscription *SubscriptionService
+	spaces       *CloudCodeService
+	payments     *PaymentService
+	jobs         *dao.JobDao
+	statuses     *caches.UserStatusCache
+}
+
+func NewAdminService() *AdminService {
+	return &AdminService{
+		logger:       logger.Logger(),
+		dao:          dao.NewUserDao(),
+		subscription: NewSubscriptionService(),
+		spaces:       NewCloudCodeService(),
+		payments:     NewPaymentService(),
+		jobs:         dao.NewJobDao(),
+		statuses:     caches.CacheFactory().UserStatusCache(dao.NewUserDao()),
+	}
+}
+
+// IsAdmin 检查用户是否为管理员, 被禁用或删除的管理员没有权限
+func (a *AdminService) IsAdmin(userId uint32) bool {
+	role, status, err := a.dao.FindRoleAndStatusById(userId)
+	if err != nil {
+		a.logger.Warnf("find user role error:%v, id:%d", err, userId)
+		return false
+	}
+
+	return role == model.RoleAdmin && code.UserStatus(status) == code.StatusNormal
+}
+
+// IsUserActive 检查用户是否可以继续使用已签发的令牌, 被禁用或删除的用户不能使用
+// 用户状态缓存30秒, 管理员修改用户状态时立即失效
+func (a *AdminService) IsUserActive(userId uint32) (bool, error) {
+	return a.statuses.Active(userId)
+}
+
+// ListUsers 分页查询用户, keyword不为空时按用户名、昵称和邮箱搜索
+func (a *AdminService) ListUsers(keyword string, page, size int) ([]model.User, uint32, error) {
+	total, err := a.dao.FindCount(keyword)
+	if err != nil {
+		a.logger.Warnf("find user count error:%v", err)
+		return nil, 0, ErrAdminOperation
+	}
+	users, err := a.dao.FindPage(keyword, (page-1)*size, size)
+	if err != nil {
+		a.logger.Warnf("find users error:%v", err)
+		return nil, 0, ErrAdminOperation
+	}
+
+	return users, total, nil
+}
+
+// SetUserDisabled 禁用或启用用户, 禁用时停止用户所有运行中的工作空间
+// 禁用后用户无法再次登录, 已经签发的token也会被Auth拒绝
+func (a *AdminService) SetUserDisabled(operatorId, userId uint32, disabled bool) error {
+	if operatorId == userId {
+		return ErrAdminModifySelf
+	}
+
+	user, err := a.dao.FindById(userId)
+	if err != nil || code.UserStatus(user.Status) == code.StatusDeleted {
+		a.logger.Warnf("find user error:%v, id:%d", err, userId)
+		return ErrAdminUserNotFound
+	}
+
+	status := code.StatusNormal
+	if disabled {
+		status = code.StatusDisabled
+	}
+	if err = a.dao.UpdateStatusById(userId, uint32(status)); err != nil {
+		a.logger.Errorf("update user status error:%v, id:%d", err, userId)
+		return ErrAdminOperation
+	}
+	a.logger.Infof("user status is changed by admin %d, id:%d, disabled:%v", operatorId, userId, disabled)
+
+	// 通知所有副本重新查询用户状态, 失败时等待缓存过期
+	if err = caches.CacheFactory().Invalidate(caches.UserStatusCacheName); err != nil {
+		a.logger.Warnf("invalidate user status cache error:%v, id:%d", err, userId)
+	}
+
+	if disabled {
+		if err = a.spaces.StopUserWorkspaces(user.Uid); err != nil {
+			a.logger.Warnf("stop workspaces of disabled user error:%v, id:%d", err, userId)
+		}
+	}
+
+	return nil
+}
+
+// GrantVip 赠送或延长用户的VIP
+func (a *AdminService) GrantVip(operatorId, userId uint32, days int, reason string) error {
+	if days <= 0 || days > MaxVipGrantDays {
+		return ErrReqParamInvalid
+	}
+
+	user, err := a.dao.FindById(userId)
+	if err != nil || code.UserStatus(user.Status) == code.StatusDeleted {
+		a.logger.Warnf("find user error:%v, id:%d", err, userId)
+		return ErrAdminUserNotFound
+	}
+
+	if err = a.subscription.ExtendSubscription(userId, days, reason); err != nil {
+		a.logger.Errorf("extend subscription error:%v, id:%d", err, userId)
+		return ErrAdminOperation
+	}
+	a.logger.Infof("vip is granted by admin %d, id:%d, days:%d", operatorId, userId, days)
+
+	return nil
+}
+
+// RefundOrder 全额退款用户已经支付的订单
+func (a *AdminService) RefundOrder(operatorId uint32, orderNo, reason string) error {
+	if orderNo == "" || utf8.RuneCountInString(reason) > MaxRefundReasonLen {
+		return ErrReqParamInvalid
+	}
+
+	if err := a.payments.RefundOrder(orderNo, reason); err != nil {
+		a.logger.Errorf("refund order error:%v, order:%s", err, orderNo)
+		return err
+	}
+	a.logger.Infof("order is refunded by admin %d, order:%s", operatorId, orderNo)
+
+	return nil
+}
+
+// SubscriptionStats 获取VIP用户的统计信息
+func (a *AdminService) SubscriptionStats() (map[string]interface{}, error) {
+	stats, err := a.subscription.GetSubscriptionStats()
+	if err != nil {
+		return nil, ErrAdminOperation
+	}
+
+	return stats, nil
+}
+
+// JobHistory 分页查询定时任务的执行记录, name为空时查询所有任务
+func (a *AdminService) JobHistory(name string, page, size int) ([]model.JobHistory, error) {
+	history, err := a.jobs.FindHistory(name, size, (page-1)*size)
+	if err != nil {
+		a.logger.Errorf("find job history error:%v", err)
+		return nil, ErrAdminOperation
+	}
+
+	return history, nil
+}
@@ -4,23 +4,28 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/caches"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/conf"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model/reqtype"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/rpc"
+	pconf "github.com/mangohow/cloud-ide/pkg/conf"
 	"github.com/mangohow/cloud-ide/pkg/logger"
 	"github.com/mangohow/cloud-ide/pkg/pb"
+	"github.com/mangohow/cloud-ide/pkg/utils"
+	"github.com/mangohow/cloud-ide/pkg/utils/encrypt"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"gopkg.in/mgo.v2/bson"
 )
 
 const (
 	DefaultPodPort = 9999
-	MaxSpaceCount  = 10
 )
 
 type CloudCodeService struct {
@@ -29,6 +34,13 @@ type CloudCodeService struct {
 	dao       *dao.SpaceDao
 	tmplCache *caches.TmplCache
 	specCache *caches.SpecCache
+	secrets   *SecretService
+	quota     *QuotaService
+	metering  *MeteringService
+	orgs      *OrgService
+	statuses  *caches.UserStatusCache
+	// 签发工作空间票据和会话的密钥, 与网关共享
+	key []byte
 }
 
 func NewCloudCodeService() *CloudCodeService {
@@ -41,6 +53,12 @@ func NewCloudCodeService() *CloudCodeService {
 		dao:       dao.NewSpaceDao(),
 		tmplCache: factory.TmplCache(d),
 		specCache: factory.SpecCache(d),
+		secrets:   NewSecretService(),
+		quota:     NewQuotaService(),
+		metering:  NewMeteringService(),
+		orgs:      NewOrgService(),
+		statuses:  factory.UserStatusCache(dao.NewUserDao()),
+		key:       []byte(conf.WorkspaceConfig.Key),
 	}
 }
 
@@ -55,22 +73,34 @@ var (
 	ErrSpaceAlreadyExist  = errors.New("space already exist")
 	ErrSpaceNotFound      = errors.New("space not found")
 	ErrResourceExhausted  = errors.New("no adequate resource are available")
+	ErrSpaceShrink        = errors.New("space storage cannot be shrunk")
+	ErrSpecModify         = errors.New("space spec modify failed")
 )
 
+// control-plane拒绝存储卷缩容时返回的错误信息
+const rpcMsgShrinkNotAllowed = "workspace storage cannot be shrunk"
+
+// control-plane检查配额失败时返回的错误信息前缀
+const rpcMsgQuotaExceeded = "workspace quota exceeded"
+
 // CreateWorkspace 创建云工作空间, 只在数据库中插入一条记录
+// req.OrgId不为0时在组织中创建, 需要组织的管理员权限, 使用组织套餐的配额
 func (c *CloudCodeService) CreateWorkspace(req *reqtype.SpaceCreateOption, userId uint32) (*model.Space, error) {
-	// 1、验证创建的工作空间是否达到最大数量
-	count, err := c.dao.FindCountByUserId(userId)
-	if err != nil {
-		c.logger.Warnf("get space count error:%v", err)
-		return nil, ErrSpaceCreate
-	}
-	if count >= MaxSpaceCount {
-		return nil, ErrReachMaxSpaceCount
+	if req.OrgId != 0 {
+		if _, err := c.orgs.Authorize(req.OrgId, userId, OrgActionManage); err != nil {
+			return nil, err
+		}
+		// 组织的工作空间由成员共享, 不能关联个人的密钥
+		if len(req.Secrets) > 0 {
+			return nil, ErrReqParamInvalid
+		}
 	}
 
+	// 1、获取用户或组织套餐的配额, 在插入数据库时检查
+	quota := c.quota.QuotaOfOwner(userId, req.OrgId)
+
 	// 2、验证名称是否重复
-	if err := c.dao.FindByUserIdAndName(userId, req.Name); err == nil {
+	if err := c.dao.FindByUserIdAndName(userId, req.OrgId, req.Name); err == nil {
 		c.logger.Warnf("find space error:%v", err)
 		return nil, ErrNameDuplicate
 	}
@@ -78,18 +108,29 @@ func (c *CloudCodeService) CreateWorkspace(req *reqtype.SpaceCreateOption, userI
 	// 3、从缓存中获取要创建的云空间的模板
 	tmpl := c.tmplCache.GetTmpl(req.TmplId)
 	if tmpl == nil {
-		c.logger.Warnf("get tmpl cache error:%v", err)
+		c.logger.Warnf("get tmpl cache error, id:%d", req.TmplId)
+		return nil, ErrReqParamInvalid
+	}
+	// 已下架的模板不能再创建工作空间
+	if tmpl.Status != dao.TmplUsing {
 		return nil, ErrReqParamInvalid
 	}
 
 	// 4、从缓存中获取要创建的云空间的规格
 	spec := c.specCache.Get(req.SpaceSpecId)
-	if spec == nil {
+	if spec == nil || spec.Status != dao.TmplUsing {
 		return nil, ErrReqParamInvalid
 	}
 
+	// 验证要关联的密钥是否存在
+	secrets, err := c.secrets.FindSecrets(userId, req.Secrets)
+	if err != nil {
+		return nil, err
+	}
+
 	// 5、构造云工作空间结构
 	now := time.Now()
+	sid := generateSID()
 	
 	// 构建环境变量配置（特别是Claude模板）
 	envConfig := ""
@@ -145,13 +186,22 @@ func (c *CloudCodeService) CreateWorkspace(req *reqtype.SpaceCreateOption, userI
 			envVars["SMALL_MODEL"] = "claude-3-haiku-20240307" // 默认小模型
 		}
 		
-		if envData, err := json.Marshal(envVars); err == nil {
+		// API密钥加密保存到用户的密钥中并关联到工作空间, Environment只保留普通的配置
+		plainEnv, secretEnv := splitSensitiveEnv(envVars)
+		saved, err := c.secrets.SaveEnvSecrets(userId, sid, secretEnv)
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, saved...)
+
+		if envData, err := json.Marshal(plainEnv); err == nil {
 			envConfig = string(envData)
 		}
 	}
 	
 	space := &model.Space{
 		UserId:        userId,
+		OrgId:         req.OrgId,
 		TmplId:        tmpl.Id,
 		SpecId:        spec.Id,
 		Spec:          *spec,
@@ -161,24 +211,83 @@ func (c *CloudCodeService) CreateWorkspace(req *reqtype.SpaceCreateOption, userI
 		DeleteTime:    now,
 		StopTime:      now,
 		TotalTime:     0,
-		Sid:           generateSID(),
+		Sid:           sid,
 		GitRepository: req.GitRepository,
 		Environment:   envConfig,
 	}
 
-	// 6、 添加到数据库
-	spaceId, err := c.dao.Insert(space)
-	if err != nil {
+	// 6、 检查工作空间数量、规格和存储总量的配额并添加到数据库
+	spaceId, err := c.dao.InsertWithLock(space, func(specIds []uint32) error {
+		return c.quota.CheckSpaces(quota, specIds, spec)
+	})
+	switch err {
+	case nil:
+	case ErrReachMaxSpaceCount, ErrSpecNotAllowed, ErrStorageQuotaExceeded:
+		return nil, err
+	default:
 		c.logger.Errorf("add space error:%v", err)
 		return nil, ErrSpaceCreate
 	}
 	space.Id = spaceId
 
+	// 7、关联密钥
+	if err := c.secrets.AttachSecrets(spaceId, secrets); err != nil {
+		return nil, err
+	}
+
 	return space, nil
 }
 
 var ErrOtherSpaceIsRunning = errors.New("there is other space running")
 
+// prepareStart 启动工作空间之前检查用户或组织套餐的规格、运行数量和运行时长, uid为工作空间所有者的uid
+// 返回control-plane需要检查的配额以及本次最多运行的时长(秒)
+func (c *CloudCodeService) prepareStart(space *model.Space, uid string) (*pb.UserQuota, int64, error) {
+	quota := c.quota.QuotaOfOwner(space.UserId, space.OrgId)
+	// 套餐可能已经过期, 不能再使用套餐之外的规格
+	if !allowSpec(quota, space.SpecId) {
+		return nil, 0, ErrSpecNotAllowed
+	}
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancelFunc()
+	wss, err := c.rpc.RunningWorkspaces(ctx, &pb.RequestRunningWorkspaces{Uid: uid})
+	if err != nil {
+		c.logger.Errorf("get running workspaces err=%v", err)
+		return nil, 0, ErrSpaceStart
+	}
+	// 先结束已经停止的运行记录, 保证运行时长统计准确
+	c.metering.Sync(space.UserId, space.OrgId, wss)
+
+	// 提前检查运行数量以返回更明确的错误, 最终由control-plane检查
+	if quota.MaxRunning > 0 {
+		var running uint32
+		for _, ws := range wss.Workspaces {
+			if ws.Sid != space.Sid {
+				running++
+			}
+		}
+		if running >= quota.MaxRunning {
+			return nil, 0, ErrOtherSpaceIsRunning
+		}
+	}
+
+	remaining, err := c.quota.RemainingRuntime(space.UserId, space.OrgId, quota)
+	if err == ErrRuntimeQuotaExceeded {
+		return nil, 0, err
+	}
+	if err != nil {
+		return nil, 0, ErrSpaceStart
+	}
+
+	return rpcQuota(quota), remaining, nil
+}
+
+// isQuotaExceeded 判断control-plane返回的错误是否是超过配额
+func isQuotaExceeded(s *status.Status) bool {
+	return s.Code() == codes.FailedPrecondition && strings.HasPrefix(s.Message(), rpcMsgQuotaExceeded)
+}
+
 func (c *CloudCodeService) checkHasRunningWorkspace(uid string) (bool, error) {
 	// 1、检查是否有其它工作空间正在运行, 同时只能有一个工作空间启动
 	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*10)
@@ -198,30 +307,23 @@ func (c *CloudCodeService) checkHasRunningWorkspace(uid string) (bool, error) {
 
 // CreateAndStartWorkspace 创建并且启动云工作空间
 func (c *CloudCodeService) CreateAndStartWorkspace(req *reqtype.SpaceCreateOption, userId uint32, uid string) (*model.Space, error) {
-	// 1、检查是否有其它工作空间正在运行, 同时只能有一个工作空间启动
-	// 临时禁用此检查以修复竞态条件问题
-	// TODO: 需要改进逻辑，避免在创建过程中检查刚创建的工作空间
-	/*
-	if ok, err := c.checkHasRunningWorkspace(uid); err != nil || ok {
-		if err != nil {
-			return nil, ErrSpaceCreate
-		}
-		return nil, ErrOtherSpaceIsRunning
-	}
-	*/
-
-	// 2、创建工作空间
+	// 1、创建工作空间, 运行数量的配额在启动时检查
 	space, err := c.CreateWorkspace(req, userId)
 	if err != nil {
 		return nil, err
 	}
 
-	// 3、真正的创建并且启动工作空间
-	return c.createAndStartWorkspace(space, uid)
+	// 2、真正的创建并且启动工作空间, 组织的工作空间属于组织的uid
+	if space.OrgId != 0 {
+		if uid, _, err = c.authorize(space, userId, uid, OrgActionStart); err != nil {
+			return nil, err
+		}
+	}
+	return c.createAndStartWorkspace(space, uid, req.Async)
 }
 
-// 调用rpc来创建并且启动工作空间
-func (c *CloudCodeService) createAndStartWorkspace(space *model.Space, uid string) (*model.Space, error) {
+// 调用rpc来创建并且启动工作空间, async为true时不等待工作空间启动完成
+func (c *CloudCodeService) createAndStartWorkspace(space *model.Space, uid string, async bool) (*model.Space, error) {
 	// 1、获取空间模板
 	tmpl := c.tmplCache.GetTmpl(space.TmplId)
 	if tmpl == nil {
@@ -236,22 +338,39 @@ func (c *CloudCodeService) createAndStartWorkspace(space *model.Space, uid strin
 		return nil, ErrSpaceStart
 	}
 
-	// 3、生成Workspace信息
-	gitRepo := space.GitRepository
-	
-	// 如果是Claude模板且有环境变量配置，将环境变量编码到GitRepository字段中
-	if tmpl.Id == 7 && space.Environment != "" {
-		// 使用特殊前缀标识包含环境变量的GitRepository
-		gitRepo = "ENV:" + space.Environment + "|GIT:" + space.GitRepository
+	// 3、检查用户套餐的配额
+	quota, maxRuntime, err := c.prepareStart(space, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4、生成Workspace信息, 关联的密钥解密后作为敏感的环境变量
+	env, secretEnv := c.splitEnvironment(space.Environment)
+	env = c.mergeTemplateEnv(tmpl, env)
+	resolved, err := c.secrets.ResolveSpaceSecrets(space.Id)
+	if err != nil {
+		return nil, err
+	}
+	if len(resolved) > 0 && secretEnv == nil {
+		secretEnv = make(map[string]string, len(resolved))
+	}
+	for name, value := range resolved {
+		secretEnv[name] = value
 	}
-	
 	ws := &pb.RequestCreate{
 		Sid:             space.Sid,
 		Uid:             uid,
 		Image:           tmpl.Image,
-		Port:            DefaultPodPort,
-		GitRepository:   gitRepo,
+		Port:            podPortOf(tmpl),
+		GitRepository:   space.GitRepository,
 		VolumeMountPath: "/root/",
+		EnvVars:         env,
+		SecretEnvVars:   secretEnv,
+		IdleTimeout:     idleTimeoutOf(tmpl, spec),
+		Async:           async,
+		Launch:          c.launchSpecOf(tmpl),
+		Quota:           quota,
+		MaxRuntime:      maxRuntime,
 		ResourceLimit: &pb.ResourceLimit{
 			Cpu:     spec.CpuSpec,
 			Memory:  spec.MemSpec,
@@ -262,7 +381,7 @@ func (c *CloudCodeService) createAndStartWorkspace(space *model.Space, uid strin
 	c.logger.Debug(ws.ResourceLimit)
 
 	var retErr error
-	// 4、请求k8s controller创建并启动云空间
+	// 5、请求k8s controller创建并启动云空间
 	// 设置90分钟的超时时间
 	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*90)
 	defer cancelFunc()
@@ -273,6 +392,9 @@ func (c *CloudCodeService) createAndStartWorkspace(space *model.Space, uid strin
 			return nil, err
 		}
 		c.logger.Error("create workspace err=", s.Message())
+		if isQuotaExceeded(s) {
+			return nil, ErrQuotaExceeded
+		}
 
 		c.logger.Debug("resp:", resp)
 
@@ -290,7 +412,10 @@ func (c *CloudCodeService) createAndStartWorkspace(space *model.Space, uid strin
 	}
 
 	space.RunningStatus = model.RunningStatusRunning
-	// 5、修改数据库中的状态信息
+	if async {
+		space.RunningStatus = model.RunningStatusStarting
+	}
+	// 6、修改数据库中的状态信息
 	if space.Status == model.SpaceStatusUncreated {
 		// 更新数据库
 		err := c.dao.UpdateStatusById(space.Id, model.SpaceStatusAvailable)
@@ -308,45 +433,42 @@ func (c *CloudCodeService) createAndStartWorkspace(space *model.Space, uid strin
 
 var ErrWorkSpaceNotExist = errors.New("workspace is not exist")
 
-// StartWorkspace 启动云工作空间
-func (c *CloudCodeService) StartWorkspace(id, userId uint32, uid string) (*model.Space, error) {
-	// 1、检查是否有其它工作空间正在运行, 同时只能有一个工作空间启动
-	// 临时禁用此检查以修复竞态条件问题
-	/*
-	if ok, err := c.checkHasRunningWorkspace(uid); err != nil || ok {
-		if err != nil {
-			return nil, ErrSpaceStart
-		}
-		return nil, ErrOtherSpaceIsRunning
+// StartWorkspace 启动云工作空间, secrets为启动前需要关联到工作空间的密钥名称
+func (c *CloudCodeService) StartWorkspace(id, userId uint32, uid string, async bool, secrets []string) (*model.Space, error) {
+	// 1.查询该工作空间是否存在以及用户是否可以启动, 运行数量的配额在启动时检查
+	space, uid, err := c.findSpace(id, userId, uid, OrgActionStart)
+	if err != nil {
+		return nil, err
 	}
-	*/
 
-	// 2.查询该工作空间是否存在
-	space, err := c.dao.FindByIdAndUserId(id, userId)
-	if err != nil {
-		c.logger.Warnf("find space error:%v", err)
-		return nil, ErrWorkSpaceNotExist
+	// 关联密钥, 在创建或启动时注入到环境变量中, 组织的工作空间不能关联个人的密钥
+	if len(secrets) > 0 {
+		if space.OrgId != 0 {
+			return nil, ErrReqParamInvalid
+		}
+		attached, err := c.secrets.FindSecrets(userId, secrets)
+		if err != nil {
+			return nil, err
+		}
+		if err = c.secrets.AttachSecrets(id, attached); err != nil {
+			return nil, err
+		}
 	}
-	space.Id = id
-	space.UserId = userId
 
-	// 3.该工作空间是否是第一次启动
-	switch space.Status {
-	case model.SpaceStatusDeleted:
-		return nil, ErrWorkSpaceNotExist
-	case model.SpaceStatusUncreated:
+	// 2.该工作空间是否是第一次启动
+	if space.Status == model.SpaceStatusUncreated {
 		// 这种情况是工作空间被创建时，只插入了数据库
 		// 并没有在workspace controller 创建
 		// 因此需要创建并且启动
-		return c.createAndStartWorkspace(space, uid)
+		return c.createAndStartWorkspace(space, uid, async)
 	}
 
-	// 4.启动工作空间
-	return c.startWorkspace(space, uid)
+	// 3.启动工作空间
+	return c.startWorkspace(space, uid, async)
 }
 
 // startWorkspace 启动工作空间
-func (c *CloudCodeService) startWorkspace(space *model.Space, uid string) (*model.Space, error) {
+func (c *CloudCodeService) startWorkspace(space *model.Space, uid string, async bool) (*model.Space, error) {
 	// 1、获取空间模板
 	tmpl := c.tmplCache.GetTmpl(space.TmplId)
 	if tmpl == nil {
@@ -361,42 +483,100 @@ func (c *CloudCodeService) startWorkspace(space *model.Space, uid string) (*mode
 		return nil, ErrSpaceStart
 	}
 
-	// 3、生成请求信息
+	// 3、检查用户套餐的配额
+	quota, maxRuntime, err := c.prepareStart(space, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4、生成请求信息, 关联的密钥解密后作为敏感的环境变量
+	secretEnv, err := c.secrets.ResolveSpaceSecrets(space.Id)
+	if err != nil {
+		return nil, err
+	}
 	req := &pb.RequestStart{
-		Sid: space.Sid,
-		Uid: uid,
+		Sid:         space.Sid,
+		Uid:         uid,
+		IdleTimeout: idleTimeoutOf(tmpl, spec),
+		Async:       async,
 		ResourceLimit: &pb.ResourceLimit{
 			Cpu:     spec.CpuSpec,
 			Memory:  spec.MemSpec,
 			Storage: spec.StorageSpec,
 		},
+		SecretEnvVars: secretEnv,
+		// 模板的启动配置修改后在下次启动时生效
+		Launch:     c.launchSpecOf(tmpl),
+		Quota:      quota,
+		MaxRuntime: maxRuntime,
 	}
 
-	// 4、请求k8s controller启动云空间
+	// 5、请求k8s controller启动云空间
 	// 设置90s的超时时间
 	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*90)
 	defer cancelFunc()
-	resp, err := c.rpc.StartSpace(ctx, req)
+	_, err = c.rpc.StartSpace(ctx, req)
 	if err != nil {
-		s, ok := status.FromError(err)
-		if !ok {
-			return nil, err
-		}
-		c.logger.Errorf("start workspace err=%s sid=%s", s.Message(), req.Sid)
-
-		switch resp.Status {
-		// 工作空间不存在
-		case pb.ResponseStart_NotFound:
+		st := status.Convert(err)
+		c.logger.Errorf("start workspace err=%s sid=%s", st.Message(), req.Sid)
+		// 出错时resp为nil, 只能根据错误码判断原因, 其它错误(超时、不可用等)都视为启动失败
+		switch {
+		case st.Code() == codes.NotFound:
 			return nil, ErrSpaceNotFound
-		// 启动工作空间时,工作空间不存在
-		case pb.ResponseStart_Error:
-			return nil, ErrSpaceStart
+		case st.Code() == codes.InvalidArgument && st.Message() == rpcMsgShrinkNotAllowed:
+			return nil, ErrSpaceShrink
+		case isQuotaExceeded(st):
+			return nil, ErrQuotaExceeded
 		}
+		return nil, ErrSpaceStart
+	}
+
+	if async {
+		space.RunningStatus = model.RunningStatusStarting
 	}
 
 	return space, nil
 }
 
+// WatchWorkspace 监听云工作空间的生命周期事件, ctx结束时停止监听
+func (c *CloudCodeService) WatchWorkspace(ctx context.Context, id, userId uint32, uid string) (*model.Space, pb.CloudIdeService_WatchSpaceClient, error) {
+	// 1、先查询工作空间并确保该用户可以查看该工作空间
+	space, uid, err := c.findSpace(id, userId, uid, OrgActionOpen)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 2、请求k8s controller推送事件
+	stream, err := c.rpc.WatchSpace(ctx, &pb.RequestWatch{
+		Sid: space.Sid,
+		Uid: uid,
+	})
+	if err != nil {
+		c.logger.Warnf("watch workspace err:%v, sid:%s", err, space.Sid)
+		return nil, nil, err
+	}
+
+	return space, stream, nil
+}
+
+// WatchTicketTTL 订阅票据在获取后立即用于建立SSE连接, 有效期很短
+const WatchTicketTTL = time.Minute
+
+// CreateWatchTicket 签发订阅工作空间事件的票据, 用户需要可以打开该工作空间
+func (c *CloudCodeService) CreateWatchTicket(id, userId uint32, uid string) (string, error) {
+	if _, _, err := c.findSpace(id, userId, uid, OrgActionOpen); err != nil {
+		return "", err
+	}
+
+	ticket, err := encrypt.CreateWatchTicket(userId, uid, id, WatchTicketTTL)
+	if err != nil {
+		c.logger.Errorf("create watch ticket error:%v, id:%d", err, id)
+		return "", err
+	}
+
+	return ticket, nil
+}
+
 var (
 	ErrWorkSpaceIsRunning    = errors.New("workspace is running")
 	ErrWorkSpaceIsNotRunning = errors.New("workspace is not running")
@@ -404,10 +584,9 @@ var (
 
 // DeleteWorkspace 删除云工作空间
 func (c *CloudCodeService) DeleteWorkspace(id, userId uint32, uid string) error {
-	// 1、先查询工作空间并确保该工作空间是属于该用户的
-	space, err := c.dao.FindByIdAndUserId(id, userId)
+	// 1、先查询工作空间并确保该用户可以删除该工作空间
+	space, uid, err := c.findSpace(id, userId, uid, OrgActionManage)
 	if err != nil {
-		c.logger.Warnf("find sid error:%v", err)
 		return err
 	}
 
@@ -442,11 +621,10 @@ func (c *CloudCodeService) DeleteWorkspace(id, userId uint32, uid string) error
 func (c *CloudCodeService) StopWorkspace(id, userId uint32, uid string) error {
 	c.logger.Debugf("StopWorkspace, sid: %d, uid: %s", id, uid)
 
-	// 1、检测该工作空间是否属于该用户
+	// 1、检测该用户是否可以停止该工作空间
 	// TODO 可优化的点，使用redis缓存用户工作空间
-	space, err := c.dao.FindByIdAndUserId(id, userId)
+	space, uid, err := c.findSpace(id, userId, uid, OrgActionStart)
 	if err != nil {
-		c.logger.Warnf("find sid error:%v", err)
 		return err
 	}
 
@@ -474,9 +652,17 @@ func (c *CloudCodeService) StopWorkspace(id, userId uint32, uid string) error {
 	return nil
 }
 
-// ListWorkspace 列出云工作空间
-func (c *CloudCodeService) ListWorkspace(userId uint32, uid string) ([]model.Space, error) {
-	spaces, err := c.dao.FindAllSpaceByUserId(userId)
+// ListWorkspace 列出云工作空间, orgId不为0时列出组织的工作空间
+func (c *CloudCodeService) ListWorkspace(userId, orgId uint32, uid string) ([]model.Space, error) {
+	if orgId != 0 {
+		org, err := c.orgs.Authorize(orgId, userId, OrgActionOpen)
+		if err != nil {
+			return nil, err
+		}
+		uid = org.Uid
+	}
+
+	spaces, err := c.dao.FindAllSpaceByUserId(userId, orgId)
 	if err != nil {
 		c.logger.Warnf("find spaces error:%v", err)
 		return nil, err
@@ -484,11 +670,14 @@ func (c *CloudCodeService) ListWorkspace(userId uint32, uid string) ([]model.Spa
 
 	// 填充environment字段和spec字段
 	for i := 0; i < len(spaces); i++ {
-		t := c.tmplCache.GetTmpl(spaces[i].TmplId)
-		spaces[i].Environment = t.Desc
-		spaces[i].Avatar = t.Avatar
-		spaces[i].Spec = *c.specCache.Get(spaces[i].SpecId)
-		spaces[i].Spec.Id = 0
+		if t := c.tmplCache.GetTmpl(spaces[i].TmplId); t != nil {
+			spaces[i].Environment = t.Desc
+			spaces[i].Avatar = t.Avatar
+		}
+		if spec := c.specCache.Get(spaces[i].SpecId); spec != nil {
+			spaces[i].Spec = *spec
+			spaces[i].Spec.Id = 0
+		}
 	}
 
 	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*30)
@@ -506,21 +695,264 @@ func (c *CloudCodeService) ListWorkspace(userId uint32, uid string) ([]model.Spa
 				break
 			}
 		}
-
+		for _, st := range wss.Stopped {
+			if item.Sid == st.Sid {
+				spaces[i].StopReason = st.Reason
+				spaces[i].StopMessage = st.Message
+				break
+			}
+		}
 	}
 
 	return spaces, nil
 }
 
+// ListAllWorkspaces 分页列出所有用户的云工作空间以及在control-plane中的状态(管理员使用)
+// userId不为0时只列出该用户的云工作空间
+func (c *CloudCodeService) ListAllWorkspaces(userId uint32, page, size int) ([]model.AdminSpace, uint32, error) {
+	total, err := c.dao.FindCountWithUser(userId)
+	if err != nil {
+		c.logger.Warnf("find space count error:%v", err)
+		return nil, 0, err
+	}
+	spaces, err := c.dao.FindPageWithUser(userId, (page-1)*size, size)
+	if err != nil {
+		c.logger.Warnf("find spaces error:%v", err)
+		return nil, 0, err
+	}
+
+	for i := 0; i < len(spaces); i++ {
+		if t := c.tmplCache.GetTmpl(spaces[i].TmplId); t != nil {
+			spaces[i].Environment = t.Desc
+			spaces[i].Avatar = t.Avatar
+		}
+		if spec := c.specCache.Get(spaces[i].SpecId); spec != nil {
+			spaces[i].Spec = *spec
+		}
+	}
+
+	// uid为空时获取所有用户运行中的工作空间
+	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancelFunc()
+	wss, err := c.rpc.RunningWorkspaces(ctx, &pb.RequestRunningWorkspaces{})
+	if err != nil {
+		c.logger.Warnf("get running space error:%v", err)
+		return spaces, total, nil
+	}
+
+	phases := make(map[string]string, len(wss.Workspaces))
+	for _, ws := range wss.Workspaces {
+		phases[ws.Sid] = ws.Phase
+	}
+	for i := range spaces {
+		if phase, ok := phases[spaces[i].Sid]; ok {
+			spaces[i].RunningStatus = model.RunningStatusRunning
+			spaces[i].Phase = phase
+		}
+	}
+
+	return spaces, total, nil
+}
+
+// ForceStopWorkspace 停止任意用户的云工作空间(管理员使用)
+func (c *CloudCodeService) ForceStopWorkspace(id uint32) error {
+	space, err := c.dao.FindByIdWithUser(id)
+	if err != nil || space.Status == model.SpaceStatusDeleted {
+		c.logger.Warnf("find space error:%v, id:%d", err, id)
+		return ErrWorkSpaceNotExist
+	}
+	if space.Status == model.SpaceStatusUncreated {
+		return ErrWorkSpaceIsNotRunning
+	}
+
+	_, err = c.rpc.StopSpace(context.Background(), &pb.RequestStop{
+		Sid: space.Sid,
+		Uid: space.Uid,
+	})
+	if err != nil {
+		if s, ok := status.FromError(err); ok && s.Code() == codes.NotFound {
+			return ErrWorkSpaceIsNotRunning
+		}
+		c.logger.Errorf("rpc stop space error:%v, sid:%s", err, space.Sid)
+		return ErrSpaceStop
+	}
+
+	c.logger.Infof("workspace is stopped by admin, sid:%s, uid:%s", space.Sid, space.Uid)
+	return nil
+}
+
+// 会员过期时停止工作空间的原因
+const (
+	StopReasonSubscriptionExpired  = "SubscriptionExpired"
+	stopMessageSubscriptionExpired = "subscription expired, the spec is not allowed by current plan"
+)
+
+// StopLapsedWorkspaces 停止会员过期的用户正在运行的会员专属规格的工作空间（定时任务调用）
+func (c *CloudCodeService) StopLapsedWorkspaces() error {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Minute)
+	defer cancelFunc()
+	wss, err := c.rpc.RunningWorkspaces(ctx, &pb.RequestRunningWorkspaces{})
+	if err != nil {
+		c.logger.Errorf("get running workspaces err=%v", err)
+		return err
+	}
+
+	quotas := make(map[[2]uint32]pconf.QuotaConf)
+	var stopped int
+	for _, ws := range wss.Workspaces {
+		space, err := c.dao.FindBySid(ws.Sid)
+		if err != nil {
+			c.logger.Warnf("find space error:%v, sid:%s", err, ws.Sid)
+			continue
+		}
+		// 组织的工作空间使用组织的套餐
+		key := [2]uint32{space.UserId, 0}
+		if space.OrgId != 0 {
+			key = [2]uint32{0, space.OrgId}
+		}
+		quota, ok := quotas[key]
+		if !ok {
+			quota = c.quota.QuotaOfOwner(space.UserId, space.OrgId)
+			quotas[key] = quota
+		}
+		if allowSpec(quota, space.SpecId) {
+			continue
+		}
+
+		_, err = c.rpc.StopSpace(ctx, &pb.RequestStop{
+			Sid:     ws.Sid,
+			Uid:     ws.Uid,
+			Reason:  StopReasonSubscriptionExpired,
+			Message: stopMessageSubscriptionExpired,
+		})
+		if err != nil {
+			c.logger.Errorf("rpc stop space error:%v, sid:%s", err, ws.Sid)
+			return err
+		}
+		stopped++
+		c.logger.Infof("workspace is stopped because subscription expired, sid:%s, userId:%d", ws.Sid, space.UserId)
+	}
+
+	if stopped > 0 {
+		c.logger.Infof("stopped %d lapsed workspaces", stopped)
+	}
+	return nil
+}
+
+// StopUserWorkspaces 停止用户所有运行中的云工作空间, 例如用户被禁用时
+func (c *CloudCodeService) StopUserWorkspaces(uid string) error {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancelFunc()
+	wss, err := c.rpc.RunningWorkspaces(ctx, &pb.RequestRunningWorkspaces{Uid: uid})
+	if err != nil {
+		c.logger.Errorf("get running workspaces err=%v, uid=%s", err, uid)
+		return ErrSpaceStop
+	}
+
+	for _, ws := range wss.Workspaces {
+		if _, err = c.rpc.StopSpace(ctx, &pb.RequestStop{Sid: ws.Sid, Uid: uid}); err != nil {
+			c.logger.Errorf("rpc stop space error:%v, sid:%s", err, ws.Sid)
+			return ErrSpaceStop
+		}
+	}
+
+	return nil
+}
+
+// idleTimeoutOf 获取工作空间的空闲超时时间(秒), 模板中的设置优先于规格中的设置
+// 0表示使用control-plane的默认值
+func idleTimeoutOf(tmpl *model.SpaceTemplate, spec *model.SpaceSpec) int32 {
+	minutes := spec.IdleTimeout
+	if tmpl.IdleTimeout > 0 {
+		minutes = tmpl.IdleTimeout
+	}
+
+	return int32(minutes) * 60
+}
+
+// podPortOf 获取工作空间容器的端口, 模板没有设置时使用默认端口
+func podPortOf(tmpl *model.SpaceTemplate) int32 {
+	if tmpl.Port > 0 {
+		return int32(tmpl.Port)
+	}
+
+	return DefaultPodPort
+}
+
+// launchSpecOf 解析模板中的启动配置, 模板没有设置启动命令和初始化步骤时使用镜像默认的启动命令
+func (c *CloudCodeService) launchSpecOf(tmpl *model.SpaceTemplate) *pb.LaunchSpec {
+	launch := &pb.LaunchSpec{
+		WorkingDir:         tmpl.WorkingDir,
+		ReadinessProbePath: tmpl.ReadinessPath,
+		LivenessProbePath:  tmpl.LivenessPath,
+		StartupProbePath:   tmpl.StartupPath,
+	}
+	if err := unmarshalTmplField(tmpl.Command, &launch.Command); err != nil {
+		c.logger.Warnf("unmarshal template command error:%v, tmpl:%d", err, tmpl.Id)
+	}
+	if err := unmarshalTmplField(tmpl.Args, &launch.Args); err != nil {
+		c.logger.Warnf("unmarshal template args error:%v, tmpl:%d", err, tmpl.Id)
+	}
+
+	var steps []model.TmplInitStep
+	if err := unmarshalTmplField(tmpl.InitSteps, &steps); err != nil {
+		c.logger.Warnf("unmarshal template init steps error:%v, tmpl:%d", err, tmpl.Id)
+	}
+	for _, step := range steps {
+		launch.InitSteps = append(launch.InitSteps, &pb.InitStep{
+			Name:    step.Name,
+			Image:   step.Image,
+			Command: step.Command,
+		})
+	}
+
+	return launch
+}
+
+// mergeTemplateEnv 将模板中默认的环境变量合并到用户配置的环境变量中, 用户配置的优先
+func (c *CloudCodeService) mergeTemplateEnv(tmpl *model.SpaceTemplate, env map[string]string) map[string]string {
+	var defaults map[string]string
+	if err := unmarshalTmplField(tmpl.Env, &defaults); err != nil {
+		c.logger.Warnf("unmarshal template env error:%v, tmpl:%d", err, tmpl.Id)
+		return env
+	}
+	if len(defaults) == 0 {
+		return env
+	}
+
+	merged := make(map[string]string, len(defaults)+len(env))
+	for name, value := range defaults {
+		merged[name] = value
+	}
+	for name, value := range env {
+		merged[name] = value
+	}
+
+	return merged
+}
+
+func unmarshalTmplField(field string, v interface{}) error {
+	if field == "" {
+		return nil
+	}
+
+	return json.Unmarshal([]byte(field), v)
+}
+
 func (c *CloudCodeService) ModifyName(name string, id, userId uint32) error {
+	space, _, err := c.findSpace(id, userId, "", OrgActionManage)
+	if err != nil {
+		return err
+	}
+
 	// 1、验证名称是否重复
-	if err := c.dao.FindByUserIdAndName(userId, name); err == nil {
+	if err := c.dao.FindByUserIdAndName(space.UserId, space.OrgId, name); err == nil {
 		c.logger.Warnf("find space error:%v", err)
 		return ErrNameDuplicate
 	}
 
 	// 2.修改名称
-	err := c.dao.UpdateNameById(name, id)
+	err = c.dao.UpdateNameById(name, id)
 	if err != nil {
 		c.logger.Warnf("update space name error:%v", err)
 		return err
@@ -529,6 +961,220 @@ func (c *CloudCodeService) ModifyName(name string, id, userId uint32) error {
 	return nil
 }
 
+// ModifySpec 修改工作空间的规格, 已创建的工作空间需要通知k8s controller扩容存储卷
+// CPU和内存在下次启动时生效
+func (c *CloudCodeService) ModifySpec(id, specId, userId uint32, uid string) error {
+	space, uid, err := c.findSpace(id, userId, uid, OrgActionManage)
+	if err != nil {
+		return err
+	}
+	if space.SpecId == specId {
+		return nil
+	}
+
+	spec := c.specCache.Get(specId)
+	if spec == nil || spec.Status != dao.TmplUsing {
+		return ErrReqParamInvalid
+	}
+
+	// 锁定用户或组织后检查套餐是否可以使用该规格以及存储总量, 与创建工作空间的检查串行执行
+	quota := c.quota.QuotaOfOwner(space.UserId, space.OrgId)
+	err = c.dao.UpdateSpecWithLock(space, specId, func(specIds []uint32) error {
+		if err := c.quota.CheckSpec(quota, specIds, spec); err != nil {
+			return err
+		}
+		return c.resizeSpace(space, uid, spec)
+	})
+	switch err {
+	case nil:
+	case ErrSpecNotAllowed, ErrStorageQuotaExceeded, ErrWorkSpaceNotExist, ErrSpaceShrink, ErrSpecModify:
+		return err
+	default:
+		c.logger.Errorf("update space spec error:%v", err)
+		return ErrSpecModify
+	}
+
+	return nil
+}
+
+// resizeSpace 通知control-plane修改工作空间的规格, 未创建的工作空间没有存储卷, 不需要通知
+func (c *CloudCodeService) resizeSpace(space *model.Space, uid string, spec *model.SpaceSpec) error {
+	if space.Status != model.SpaceStatusAvailable {
+		return nil
+	}
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancelFunc()
+	_, err := c.rpc.ResizeSpace(ctx, &pb.RequestResize{
+		Sid: space.Sid,
+		Uid: uid,
+		ResourceLimit: &pb.ResourceLimit{
+			Cpu:     spec.CpuSpec,
+			Memory:  spec.MemSpec,
+			Storage: spec.StorageSpec,
+		},
+	})
+	if err != nil {
+		c.logger.Warnf("resize workspace err:%v, sid:%s", err, space.Sid)
+		st := status.Convert(err)
+		switch {
+		case st.Code() == codes.NotFound:
+			return ErrWorkSpaceNotExist
+		case st.Code() == codes.InvalidArgument && st.Message() == rpcMsgShrinkNotAllowed:
+			return ErrSpaceShrink
+		}
+		return ErrSpecModify
+	}
+
+	return nil
+}
+
+// SpaceAccess 检查用户是否可以打开sid对应的工作空间, 返回工作空间的所有者和用户的角色
+// 个人工作空间只有创建者可以打开, 组织的工作空间组织的成员都可以打开
+func (c *CloudCodeService) SpaceAccess(sid string, userId uint32, uid string) (*model.SpaceAccess, error) {
+	space, err := c.dao.FindBySid(sid)
+	if err != nil || space.Status == model.SpaceStatusDeleted {
+		return nil, ErrWorkSpaceNotExist
+	}
+	ownerUid, role, err := c.authorize(space, userId, uid, OrgActionOpen)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.SpaceAccess{Sid: space.Sid, Uid: ownerUid, Role: role}, nil
+}
+
+// SpaceTicketTTL 票据只用于打开工作空间时跳转, 有效期很短
+const SpaceTicketTTL = time.Minute
+
+var ErrSpaceTicketInvalid = errors.New("space ticket invalid")
+
+// CreateTicket 检查用户是否可以打开工作空间, 签发访问sid的短期票据
+// 票据中的Uid为工作空间的所有者, 网关用来检查票据是否属于该工作空间
+func (c *CloudCodeService) CreateTicket(sid string, userId uint32, uid string) (string, error) {
+	access, err := c.SpaceAccess(sid, userId, uid)
+	if err != nil {
+		return "", err
+	}
+
+	ticket, err := encrypt.CreateWorkspaceToken(c.key, encrypt.WorkspaceTicket, userId, access.Uid, sid, SpaceTicketTTL)
+	if err != nil {
+		c.logger.Errorf("create ticket error:%v, sid:%s", err, sid)
+		return "", err
+	}
+
+	return ticket, nil
+}
+
+// RedeemTicket 网关使用票据换取会话, ownerUid为网关中注册的工作空间所有者, 为空时不检查
+func (c *CloudCodeService) RedeemTicket(ticket, sid, ownerUid string) (string, time.Time, error) {
+	claims, err := encrypt.VerifyWorkspaceToken(c.key, encrypt.WorkspaceTicket, ticket)
+	if err != nil || !claims.Allow(sid, ownerUid) || !c.stillAllowed(claims) {
+		return "", time.Time{}, ErrSpaceTicketInvalid
+	}
+
+	expire := time.Now().Add(encrypt.WorkspaceSessionTTL)
+	session, err := encrypt.CreateWorkspaceToken(c.key, encrypt.WorkspaceSession, claims.Id, claims.Uid, sid, encrypt.WorkspaceSessionTTL)
+	if err != nil {
+		c.logger.Errorf("create session error:%v, sid:%s", err, sid)
+		return "", time.Time{}, err
+	}
+
+	return session, expire, nil
+}
+
+// VerifySession 验证网关的会话, 返回会话的过期时间
+func (c *CloudCodeService) VerifySession(session, sid, ownerUid string) (time.Time, error) {
+	claims, err := encrypt.VerifyWorkspaceToken(c.key, encrypt.WorkspaceSession, session)
+	if err != nil || !claims.Allow(sid, ownerUid) || !c.stillAllowed(claims) {
+		return time.Time{}, ErrSpaceTicketInvalid
+	}
+
+	return time.Unix(claims.ExpiresAt, 0), nil
+}
+
+// stillAllowed 检查令牌的持有者现在是否仍然可以打开工作空间, 与签发时的检查相同
+// 持有者被禁用、工作空间被删除、持有者离开组织或者组织的工作空间转移之后, 已经签发的令牌立即失效
+func (c *CloudCodeService) stillAllowed(claims *encrypt.WorkspaceClaim) bool {
+	if active, err := c.statuses.Active(claims.Id); err != nil || !active {
+		c.logger.Infof("workspace user inactive:%v, sid:%s, user:%d", err, claims.Sid, claims.Id)
+		return false
+	}
+	access, err := c.SpaceAccess(claims.Sid, claims.Id, claims.Uid)
+	if err != nil {
+		c.logger.Infof("workspace access revoked:%v, sid:%s, user:%d", err, claims.Sid, claims.Id)
+		return false
+	}
+
+	return access.Uid == claims.Uid
+}
+
+// findSpace 查询用户可以执行action的工作空间, 返回工作空间和所有者在control-plane中的uid
+func (c *CloudCodeService) findSpace(id, userId uint32, uid, action string) (*model.Space, string, error) {
+	space, err := c.dao.FindById(id)
+	if err != nil || space.Status == model.SpaceStatusDeleted {
+		c.logger.Warnf("find space error:%v, id:%d", err, id)
+		return nil, "", ErrWorkSpaceNotExist
+	}
+	ownerUid, _, err := c.authorize(space, userId, uid, action)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return space, ownerUid, nil
+}
+
+// authorize 检查用户是否可以对工作空间执行action, 返回所有者的uid和用户的角色
+// 个人工作空间只有创建者可以访问, 所有者为用户自己; 组织的工作空间根据用户在组织中的角色检查, 所有者为组织
+// 不能访问的个人工作空间以及不是成员的组织的工作空间返回ErrWorkSpaceNotExist
+func (c *CloudCodeService) authorize(space *model.Space, userId uint32, uid, action string) (string, string, error) {
+	if space.OrgId == 0 {
+		if space.UserId != userId {
+			return "", "", ErrWorkSpaceNotExist
+		}
+		return uid, model.OrgRoleOwner, nil
+	}
+
+	org, err := c.orgs.Authorize(space.OrgId, userId, action)
+	switch err {
+	case nil:
+		return org.Uid, org.Role, nil
+	case ErrOrgNotFound:
+		return "", "", ErrWorkSpaceNotExist
+	default:
+		return "", "", err
+	}
+}
+
+// splitEnvironment 解析工作空间的环境变量配置, 并分为普通的和敏感的环境变量
+func (c *CloudCodeService) splitEnvironment(environment string) (env, secretEnv map[string]string) {
+	if environment == "" {
+		return nil, nil
+	}
+
+	var all map[string]string
+	if err := json.Unmarshal([]byte(environment), &all); err != nil {
+		c.logger.Warnf("unmarshal environment error:%v", err)
+		return nil, nil
+	}
+
+	return splitSensitiveEnv(all)
+}
+
+// splitSensitiveEnv 将环境变量分为普通的和敏感的环境变量
+func splitSensitiveEnv(all map[string]string) (env, secretEnv map[string]string) {
+	env, secretEnv = make(map[string]string), make(map[string]string)
+	for name, value := range all {
+		if utils.IsSensitiveEnv(name) {
+			secretEnv[name] = value
+		} else {
+			env[name] = value
+		}
+	}
+
+	return env, secretEnv
+}
+
 // generateSID 生成Space id
 func generateSID() string {
 	return bson.NewObjectId().Hex()
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model/reqtype"
+	"github.com/mangohow/cloud-ide/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrCouponNotFound      = errors.New("coupon not found")
+	ErrCouponUnavailable   = errors.New("coupon is disabled or not in validity period")
+	ErrCouponNotApplicable = errors.New("coupon not applicable to product")
+	ErrCouponLimitReached  = errors.New("coupon redemption limit reached")
+	ErrCouponFirstPurchase = errors.New("coupon is only for first purchase")
+	ErrCouponInvalid       = errors.New("coupon param invalid")
+	ErrCouponDuplicate     = errors.New("coupon code duplicate")
+)
+
+// 订单的最低金额, 支付网关不接受0元订单
+const minOrderAmount = 0.01
+
+var couponCodeRegexp = regexp.MustCompile(`^[A-Z0-9_-]{4,32}$`)
+
+// couponCounter 查询优惠码的使用次数和用户的购买次数, 创建订单时在事务中查询, 预览时直接查询
+type couponCounter interface {
+	CountCouponRedemptions(couponId, userId uint32) (total, user uint32, err error)
+	CountPaidOrders(userId uint32) (uint32, error)
+}
+
+// CouponService 优惠码的管理和使用
+type CouponService struct {
+	logger     *logrus.Logger
+	dao        *dao.CouponDao
+	paymentDao *dao.PaymentDao
+}
+
+func NewCouponService() *CouponService {
+	return &CouponService{
+		logger:     logger.Logger(),
+		dao:        dao.NewCouponDao(),
+		paymentDao: dao.NewPaymentDao(),
+	}
+}
+
+// Create 创建优惠码
+func (s *CouponService) Create(req *reqtype.CouponOption) (*model.Coupon, error) {
+	now := time.Now()
+	coupon := &model.Coupon{
+		Code:           normalizeCouponCode(req.Code),
+		Name:           strings.TrimSpace(req.Name),
+		DiscountType:   req.DiscountType,
+		DiscountValue:  round2(req.DiscountValue),
+		ProductTypes:   strings.Join(req.ProductTypes, ","),
+		FirstPurchase:  req.FirstPurchase,
+		MaxRedemptions: req.MaxRedemptions,
+		PerUserLimit:   req.PerUserLimit,
+		StartTime:      req.StartTime,
+		EndTime:        req.EndTime,
+		Status:         model.CouponActive,
+		CreateTime:     now,
+		UpdateTime:     now,
+	}
+	if err := validateCoupon(coupon, req.ProductTypes); err != nil {
+		return nil, err
+	}
+
+	id, err := s.dao.InsertCoupon(coupon)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDuplicateEntry {
+			return nil, ErrCouponDuplicate
+		}
+		s.logger.Errorf("insert coupon error:%v", err)
+		return nil, err
+	}
+	coupon.Id = id
+	s.logger.Infof("coupon %s created, id:%d", coupon.Code, id)
+
+	return coupon, nil
+}
+
+// List 分页查询优惠码
+func (s *CouponService) List(page, size int) ([]model.Coupon, uint32, error) {
+	total, err := s.dao.CountCoupons()
+	if err != nil {
+		s.logger.Errorf("count coupon error:%v", err)
+		return nil, 0, err
+	}
+	coupons, err := s.dao.FindCoupons(size, (page-1)*size)
+	if err != nil {
+		s.logger.Errorf("find coupon error:%v", err)
+		return nil, 0, err
+	}
+
+	return coupons, total, nil
+}
+
+// SetStatus 启用或停用优惠码, 停用后已经创建的订单仍然可以支付
+func (s *CouponService) SetStatus(id uint32, status uint8) error {
+	if status != model.CouponActive && status != model.CouponDisabled {
+		return ErrReqParamInvalid
+	}
+
+	ok, err := s.dao.UpdateCouponStatus(id, status)
+	if err != nil {
+		s.logger.Errorf("update coupon status error:%v, id:%d", err, id)
+		return err
+	}
+	if !ok {
+		return ErrCouponNotFound
+	}
+
+	return nil
+}
+
+// Preview 计算用户使用优惠码购买产品的价格, 不占用优惠码
+func (s *CouponService) Preview(userId uint32, code string, productId uint32) (*model.CouponPreview, error) {
+	product, err := s.paymentDao.GetPaymentProductById(productId)
+	if err != nil {
+		return nil, ErrProductNotFound
+	}
+	coupon, err := s.dao.FindCouponByCode(normalizeCouponCode(code))
+	if err != nil {
+		return nil, ErrCouponNotFound
+	}
+	if err = s.check(coupon, s.dao, userId, product.Type, time.Now()); err != nil {
+		return nil, err
+	}
+
+	discount := couponDiscount(coupon, product.Price)
+	return &model.CouponPreview{
+		Code:           coupon.Code,
+		OriginalAmount: product.Price,
+		DiscountAmount: discount,
+		Amount:         round2(product.Price - discount),
+	}, nil
+}
+
+// Redeem 在创建订单的事务中检查并锁定优惠码, 返回优惠码和减免的金额
+// 优惠码在事务提交前一直被锁定, 同时使用同一个优惠码的订单不会超过使用次数的限制
+func (s *CouponService) Redeem(tx *dao.PaymentTx, userId uint32, code string, product *model.PaymentProduct) (*model.Coupon, float64, error) {
+	coupon, err := tx.GetCouponByCodeForUpdate(normalizeCouponCode(code))
+	if err != nil {
+		return nil, 0, ErrCouponNotFound
+	}
+	if err = s.check(coupon, tx, userId, product.Type, time.Now()); err != nil {
+		return nil, 0, err
+	}
+
+	return coupon, couponDiscount(coupon, product.Price), nil
+}
+
+// check 检查用户是否可以使用优惠码购买该类型的产品
+func (s *CouponService) check(c *model.Coupon, counter couponCounter, userId uint32, productType string, now time.Time) error {
+	if err := checkCoupon(c, productType, now); err != nil {
+		return err
+	}
+
+	if err := s.checkLimit(c, counter, userId); err != nil {
+		return err
+	}
+
+	if c.FirstPurchase {
+		n, err := counter.CountPaidOrders(userId)
+		if err != nil {
+			s.logger.Errorf("count paid orders error:%v, user:%d", err, userId)
+			return err
+		}
+		if n > 0 {
+			return ErrCouponFirstPurchase
+		}
+	}
+
+	return nil
+}
+
+// checkLimit 检查优惠码总共和用户的使用次数是否已满
+func (s *CouponService) checkLimit(c *model.Coupon, counter couponCounter, userId uint32) error {
+	if c.MaxRedemptions == 0 && c.PerUserLimit == 0 {
+		return nil
+	}
+
+	total, user, err := counter.CountCouponRedemptions(c.Id, userId)
+	if err != nil {
+		s.logger.Errorf("count coupon redemption error:%v, coupon:%d", err, c.Id)
+		return err
+	}
+	if (c.MaxRedemptions > 0 && total >= c.MaxRedemptions) || (c.PerUserLimit > 0 && user >= c.PerUserLimit) {
+		return ErrCouponLimitReached
+	}
+
+	return nil
+}
+
+// Reclaim 关闭的订单收到付款时重新占用订单释放的优惠码, 在处理付款的事务中调用
+// 优惠码在下单时已经检查过, 这里只检查使用次数, 释放后被其它订单用满时返回ErrCouponLimitReached
+func (s *CouponService) Reclaim(tx *dao.PaymentTx, order *model.Order) error {
+	coupon, err := tx.GetCouponByIdForUpdate(order.CouponId)
+	if err != nil {
+		s.logger.Errorf("get coupon error:%v, coupon:%d", err, order.CouponId)
+		return ErrCouponNotFound
+	}
+
+	return s.checkLimit(coupon, tx, order.UserId)
+}
+
+// checkCoupon 检查优惠码的状态、有效期和适用的产品
+func checkCoupon(c *model.Coupon, productType string, now time.Time) error {
+	if c.Status != model.CouponActive {
+		return ErrCouponUnavailable
+	}
+	if (c.StartTime != nil && now.Before(*c.StartTime)) || (c.EndTime != nil && !now.Before(*c.EndTime)) {
+		return ErrCouponUnavailable
+	}
+	if !c.AllowProduct(productType) {
+		return ErrCouponNotApplicable
+	}
+
+	return nil
+}
+
+// couponDiscount 计算优惠码减免的金额, 减免后的金额不低于minOrderAmount
+func couponDiscount(c *model.Coupon, price float64) float64 {
+	var discount float64
+	switch c.DiscountType {
+	case model.CouponPercent:
+		discount = round2(price * c.DiscountValue / 100)
+	case model.CouponFixed:
+		discount = c.DiscountValue
+	}
+
+	return round2(math.Max(0, math.Min(discount, price-minOrderAmount)))
+}
+
+// validateCoupon 检查管理员创建的优惠码参数
+func validateCoupon(c *model.Coupon, productTypes []string) error {
+	if !couponCodeRegexp.MatchString(c.Code) || utf8.RuneCountInString(c.Name) > 64 {
+		return ErrCouponInvalid
+	}
+	switch c.DiscountType {
+	case model.CouponPercent:
+		if c.DiscountValue <= 0 || c.DiscountValue >= 100 {
+			return ErrCouponInvalid
+		}
+	case model.CouponFixed:
+		if c.DiscountValue <= 0 {
+			return ErrCouponInvalid
+		}
+	default:
+		return ErrCouponInvalid
+	}
+	for _, t := range productTypes {
+		switch t {
+		case model.ProductTypeDay, model.ProductTypeWeek, model.ProductTypeMonth, model.ProductTypeCredit:
+		default:
+			return ErrCouponInvalid
+		}
+	}
+	if c.StartTime != nil && c.EndTime != nil && !c.StartTime.Before(*c.EndTime) {
+		return ErrCouponInvalid
+	}
+
+	return nil
+}
+
+// normalizeCouponCode 优惠码不区分大小写, 统一使用大写
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+)
+
+func TestCouponDiscount(t *testing.T) {
+	cases := []struct {
+		typ      string
+		value    float64
+		price    float64
+		discount float64
+	}{
+		{model.CouponPercent, 20, 29.9, 5.98},
+		{model.CouponPercent, 33, 9.9, 3.27},
+		{model.CouponFixed, 5, 29.9, 5},
+		// 减免后不低于最低金额
+		{model.CouponFixed, 50, 29.9, 29.89},
+		{"unknown", 50, 29.9, 0},
+	}
+	for _, c := range cases {
+		coupon := &model.Coupon{DiscountType: c.typ, DiscountValue: c.value}
+		if d := couponDiscount(coupon, c.price); d != c.discount {
+			t.Errorf("couponDiscount(%s %v, %v) = %v, want %v", c.typ, c.value, c.price, d, c.discount)
+		}
+	}
+}
+
+func TestCheckCoupon(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.Local)
+	before, after := now.Add(-time.Hour), now.Add(time.Hour)
+	cases := []struct {
+		name        string
+		coupon      model.Coupon
+		productType string
+		err         error
+	}{
+		{"active", model.Coupon{Status: model.CouponActive, StartTime: &before, EndTime: &after}, model.ProductTypeMonth, nil},
+		{"disabled", model.Coupon{Status: model.CouponDisabled}, model.ProductTypeMonth, ErrCouponUnavailable},
+		{"not started", model.Coupon{Status: model.CouponActive, StartTime: &after}, model.ProductTypeMonth, ErrCouponUnavailable},
+		{"expired", model.Coupon{Status: model.CouponActive, EndTime: &before}, model.ProductTypeMonth, ErrCouponUnavailable},
+		{"product", model.Coupon{Status: model.CouponActive, ProductTypes: "day,week"}, model.ProductTypeMonth, ErrCouponNotApplicable},
+		// 没有限制产品时不能用于预付费充值
+		{"credit", model.Coupon{Status: model.CouponActive}, model.ProductTypeCredit, ErrCouponNotApplicable},
+		{"credit allowed", model.Coupon{Status: model.CouponActive, ProductTypes: "credit"}, model.ProductTypeCredit, nil},
+	}
+	for _, c := range cases {
+		if err := checkCoupon(&c.coupon, c.productType, now); err != c.err {
+			t.Errorf("%s: checkCoupon = %v, want %v", c.name, err, c.err)
+		}
+	}
+}
@@ -1,11 +1,44 @@
 package service
 
+import (
+	"sync"
+
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/conf"
+)
+
 type EmailService interface {
 	Send(addr string) error
 
+	// SendNotice 发送通知邮件
+	SendNotice(addr, subject, text string) error
+
+	// SendHtml 发送HTML邮件, attachments为附件, key为文件名
+	SendHtml(addr, subject string, html []byte, attachments map[string][]byte) error
+
 	Start() error
 
 	VerifyEmailValidateCode(email string, code string) error
 
 	IsEmailAvailable(email string) bool
 }
+
+var (
+	emailOnce    sync.Once
+	emailService EmailService
+)
+
+// DefaultEmailService 获取全局共享的邮件服务, 未启用邮件时不发送邮件
+func DefaultEmailService() EmailService {
+	emailOnce.Do(func() {
+		if conf.EmailConfig.Enabled {
+			emailService = NewEmailService()
+		} else {
+			emailService = NewFakeEmailService()
+		}
+		if err := emailService.Start(); err != nil {
+			panic(err)
+		}
+	})
+
+	return emailService
+}
@@ -1,11 +1,14 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"math/rand"
+	"mime"
 	"net/smtp"
+	"path/filepath"
 	"time"
 
 	"github.com/jordan-wright/email"
@@ -82,6 +85,45 @@ func (e *EmailServiceImpl) Send(addr string) error {
 	return nil
 }
 
+func (e *EmailServiceImpl) SendNotice(addr, subject, text string) error {
+	m := &email.Email{
+		From:    e.config.sender,
+		To:      []string{addr},
+		Subject: subject,
+		Text:    []byte(text),
+		Sender:  "Cloud Code",
+	}
+
+	select {
+	case e.ch <- m:
+		return nil
+	default:
+		return ErrEmailQueueFull
+	}
+}
+
+func (e *EmailServiceImpl) SendHtml(addr, subject string, html []byte, attachments map[string][]byte) error {
+	m := &email.Email{
+		From:    e.config.sender,
+		To:      []string{addr},
+		Subject: subject,
+		HTML:    html,
+		Sender:  "Cloud Code",
+	}
+	for name, content := range attachments {
+		if _, err := m.Attach(bytes.NewReader(content), name, mime.TypeByExtension(filepath.Ext(name))); err != nil {
+			return err
+		}
+	}
+
+	select {
+	case e.ch <- m:
+		return nil
+	default:
+		return ErrEmailQueueFull
+	}
+}
+
 func (e *EmailServiceImpl) Start() error {
 	pool, err := email.NewPool(fmt.Sprintf("%s:%d", e.config.host, e.config.port),
 		4, smtp.PlainAuth("", e.config.sender, e.config.auth, e.config.host))
@@ -110,6 +152,8 @@ var (
 	ErrVerifyFailed = errors.New("验证失败")
 	ErrEmailInvalid = errors.New("邮箱不合法")
 	ErrCodeInvalid  = errors.New("验证码不合法")
+	// 批量发送通知时不阻塞调用方
+	ErrEmailQueueFull = errors.New("email queue is full")
 )
 
 func (e *EmailServiceImpl) VerifyEmailValidateCode(email string, code string) error {
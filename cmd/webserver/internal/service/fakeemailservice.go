@@ -11,6 +11,14 @@ func (e FakeEmailService) Send(addr string) error {
 	return nil
 }
 
+func (e FakeEmailService) SendNotice(addr, subject, text string) error {
+	return nil
+}
+
+func (e FakeEmailService) SendHtml(addr, subject string, html []byte, attachments map[string][]byte) error {
+	return nil
+}
+
 func (e FakeEmailService) Start() error {
 	return nil
 }
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/conf"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/receipt"
+	"github.com/mangohow/cloud-ide/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrInvoiceNotFound = errors.New("invoice not found")
+	ErrInvoiceNoEmail  = errors.New("invoice has no email")
+)
+
+// InvoiceService 已支付订单的收据, 收据编号在订单支付的事务中分配, 收据内容在支付完成后生成
+type InvoiceService struct {
+	logger *logrus.Logger
+	dao    *dao.InvoiceDao
+}
+
+func NewInvoiceService() *InvoiceService {
+	return &InvoiceService{
+		logger: logger.Logger(),
+		dao:    dao.NewInvoiceDao(),
+	}
+}
+
+// List 分页查询用户的收据
+func (s *InvoiceService) List(userId uint32, page, size int) ([]model.Invoice, uint32, error) {
+	total, err := s.dao.CountByUserId(userId)
+	if err != nil {
+		s.logger.Errorf("count invoice error:%v, user:%d", err, userId)
+		return nil, 0, err
+	}
+	invoices, err := s.dao.FindByUserId(userId, size, (page-1)*size)
+	if err != nil {
+		s.logger.Errorf("find invoice error:%v, user:%d", err, userId)
+		return nil, 0, err
+	}
+
+	return invoices, total, nil
+}
+
+// Content 获取用户收据的内容, 收据还没有生成时重新生成
+func (s *InvoiceService) Content(userId uint32, invoiceNo, format string) ([]byte, error) {
+	invoice, err := s.find(userId, invoiceNo)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == model.InvoiceFormatHtml {
+		return invoice.Html, nil
+	}
+	return invoice.Pdf, nil
+}
+
+// SendEmail 将收据发送到开具收据时用户的邮箱
+func (s *InvoiceService) SendEmail(userId uint32, invoiceNo string) error {
+	invoice, err := s.find(userId, invoiceNo)
+	if err != nil {
+		return err
+	}
+
+	return s.send(invoice)
+}
+
+// Generate 生成并保存收据, 配置了邮件发送时将收据发送给用户, 在订单支付成功后调用
+func (s *InvoiceService) Generate(invoiceNo string) {
+	invoice, err := s.dao.FindByInvoiceNo(invoiceNo)
+	if err != nil {
+		s.logger.Errorf("find invoice error:%v, invoice:%s", err, invoiceNo)
+		return
+	}
+	if err = s.render(invoice); err != nil {
+		return
+	}
+
+	if conf.InvoiceConfig.Email && invoice.BuyerEmail != "" {
+		if err = s.send(invoice); err != nil {
+			s.logger.Warnf("send invoice email error:%v, invoice:%s", err, invoiceNo)
+		}
+	}
+}
+
+func (s *InvoiceService) find(userId uint32, invoiceNo string) (*model.Invoice, error) {
+	invoice, err := s.dao.FindByInvoiceNo(invoiceNo)
+	if err != nil || invoice.UserId != userId {
+		return nil, ErrInvoiceNotFound
+	}
+	if len(invoice.Html) == 0 || len(invoice.Pdf) == 0 {
+		if err = s.render(invoice); err != nil {
+			return nil, err
+		}
+	}
+
+	return invoice, nil
+}
+
+// render 生成HTML和PDF收据并保存
+func (s *InvoiceService) render(invoice *model.Invoice) error {
+	r := &receipt.Receipt{
+		Seller:        conf.InvoiceConfig.Seller,
+		InvoiceNo:     invoice.InvoiceNo,
+		OrderNo:       invoice.OrderNo,
+		BuyerName:     invoice.BuyerName,
+		BuyerEmail:    invoice.BuyerEmail,
+		Product:       invoice.ProductName,
+		Subject:       productSubject(invoice.ProductType),
+		PaymentMethod: invoice.PaymentMethod,
+		Amount:        invoice.Amount,
+		PaidTime:      invoice.PaidTime,
+	}
+
+	var html, pdf bytes.Buffer
+	if err := receipt.RenderHTML(&html, r); err != nil {
+		s.logger.Errorf("render html invoice error:%v, invoice:%s", err, invoice.InvoiceNo)
+		return err
+	}
+	if err := receipt.RenderPDF(&pdf, r, conf.InvoiceConfig.FontFile); err != nil {
+		s.logger.Errorf("render pdf invoice error:%v, invoice:%s", err, invoice.InvoiceNo)
+		return err
+	}
+	invoice.Html, invoice.Pdf = html.Bytes(), pdf.Bytes()
+
+	if err := s.dao.UpdateContent(invoice.Id, invoice.Html, invoice.Pdf); err != nil {
+		s.logger.Errorf("save invoice error:%v, invoice:%s", err, invoice.InvoiceNo)
+		return err
+	}
+
+	return nil
+}
+
+// send 发送HTML收据邮件, PDF收据作为附件
+func (s *InvoiceService) send(invoice *model.Invoice) error {
+	if invoice.BuyerEmail == "" {
+		return ErrInvoiceNoEmail
+	}
+
+	return DefaultEmailService().SendHtml(invoice.BuyerEmail, "Cloud Code收据 "+invoice.InvoiceNo, invoice.Html,
+		map[string][]byte{invoice.InvoiceNo + ".pdf": invoice.Pdf})
+}
@@ -0,0 +1,367 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/caches"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/conf"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/rpc"
+	pconf "github.com/mangohow/cloud-ide/pkg/conf"
+	"github.com/mangohow/cloud-ide/pkg/logger"
+	"github.com/mangohow/cloud-ide/pkg/pb"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// 余额用完时停止工作空间的原因
+const (
+	StopReasonCreditExhausted  = "CreditExhausted"
+	stopMessageCreditExhausted = "prepaid credit is exhausted"
+)
+
+// MeteringService 根据control-plane上报的运行和停止时间记录工作空间的运行区间,
+// 统计CPU和内存的使用量, 并从按量计费用户的余额中扣费
+type MeteringService struct {
+	logger    *logrus.Logger
+	rpc       pb.CloudIdeServiceClient
+	runtime   *dao.SpaceRuntimeDao
+	credit    *dao.CreditDao
+	spaceDao  *dao.SpaceDao
+	specCache *caches.SpecCache
+	quota     *QuotaService
+}
+
+func NewMeteringService() *MeteringService {
+	conn := rpc.GrpcClient("space-code")
+	return &MeteringService{
+		logger:    logger.Logger(),
+		rpc:       pb.NewCloudIdeServiceClient(conn),
+		runtime:   dao.NewSpaceRuntimeDao(),
+		credit:    dao.NewCreditDao(),
+		spaceDao:  dao.NewSpaceDao(),
+		specCache: caches.CacheFactory().SpecCache(dao.NewSpaceTemplateDao()),
+		quota:     NewQuotaService(),
+	}
+}
+
+// MeterAll 计量所有用户的工作空间, 由定时任务调度, 多个副本时只在一个副本上执行
+func (m *MeteringService) MeterAll() error {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancelFunc()
+	wss, err := m.rpc.RunningWorkspaces(ctx, &pb.RequestRunningWorkspaces{})
+	if err != nil {
+		m.logger.Warnf("get running workspaces error:%v", err)
+		return err
+	}
+	runtimes, err := m.runtime.FindRunning()
+	if err != nil {
+		m.logger.Warnf("find running runtime error:%v", err)
+		return err
+	}
+
+	m.meter(ctx, wss, runtimes)
+
+	return nil
+}
+
+// Sync 计量用户或组织的工作空间, wss为该用户或组织的工作空间在control-plane中的状态
+func (m *MeteringService) Sync(userId, orgId uint32, wss *pb.ResponseRunningWorkspace) {
+	runtimes, err := m.runtime.FindRunningByUserId(userId, orgId)
+	if err != nil {
+		m.logger.Warnf("find running runtime error:%v, userId:%d, orgId:%d", err, userId, orgId)
+		return
+	}
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancelFunc()
+	m.meter(ctx, wss, runtimes)
+}
+
+// meter 结束已经停止的运行记录, 记录新运行的工作空间, 然后按量扣费
+// runtimes为未结束的运行记录
+func (m *MeteringService) meter(ctx context.Context, wss *pb.ResponseRunningWorkspace, runtimes []model.SpaceRuntime) {
+	now := time.Now()
+	running := make(map[string]*pb.ResponseRunningWorkspace_WorkspaceBasicInfo, len(wss.Workspaces))
+	for _, ws := range wss.Workspaces {
+		running[ws.Sid] = ws
+	}
+	stoppedAt := make(map[string]int64, len(wss.Stopped))
+	for _, st := range wss.Stopped {
+		stoppedAt[st.Sid] = st.StoppedAt
+	}
+
+	// 1.结束已经停止的运行记录, 优先使用control-plane上报的停止时间
+	exhausted := make(map[uint32]struct{})
+	open := make(map[string]*model.SpaceRuntime, len(runtimes))
+	for i := range runtimes {
+		r := &runtimes[i]
+		ws, ok := running[r.Sid]
+		if ok && !restarted(r, ws) {
+			open[r.Sid] = r
+			continue
+		}
+
+		stopTime := now
+		switch {
+		case ok && ws.StartedAt > 0:
+			// 两次计量之间工作空间被重新启动过, 上一次运行最晚在本次启动时结束
+			stopTime = time.UnixMilli(ws.StartedAt)
+		case !ok && stoppedAt[r.Sid] > 0:
+			stopTime = time.UnixMilli(stoppedAt[r.Sid])
+		}
+		m.charge(r, stopTime, exhausted)
+		if err := m.runtime.Close(r, stopTime); err != nil {
+			m.logger.Warnf("close runtime error:%v, sid:%s", err, r.Sid)
+		}
+	}
+
+	// 2.记录新运行的工作空间, 启动中的工作空间不计量
+	for _, ws := range wss.Workspaces {
+		if _, ok := open[ws.Sid]; ok || ws.StartedAt == 0 {
+			continue
+		}
+		m.start(ws)
+	}
+
+	// 3.按量扣费, 余额用完时停止该用户按量计费的工作空间
+	for _, r := range open {
+		m.charge(r, now, exhausted)
+	}
+	for _, r := range open {
+		if _, ok := exhausted[r.UserId]; !ok || r.Tier != QuotaCredit {
+			continue
+		}
+		m.logger.Infof("credit is exhausted, stopping workspace, sid:%s, userId:%d", r.Sid, r.UserId)
+		_, err := m.rpc.StopSpace(ctx, &pb.RequestStop{
+			Sid:     r.Sid,
+			Uid:     running[r.Sid].Uid,
+			Reason:  StopReasonCreditExhausted,
+			Message: stopMessageCreditExhausted,
+		})
+		if err != nil {
+			m.logger.Errorf("rpc stop space error:%v, sid:%s", err, r.Sid)
+		}
+	}
+}
+
+// restarted 判断运行记录对应的工作空间在两次计量之间是否被重新启动过
+func restarted(r *model.SpaceRuntime, ws *pb.ResponseRunningWorkspace_WorkspaceBasicInfo) bool {
+	// 重新启动时运行时间会被清空, 直到再次运行
+	if ws.StartedAt == 0 {
+		return true
+	}
+
+	// 数据库中的时间精确到秒
+	return time.UnixMilli(ws.StartedAt).After(r.StartTime.Add(time.Second))
+}
+
+// start 记录工作空间开始运行, 记录启动时的规格和套餐
+func (m *MeteringService) start(ws *pb.ResponseRunningWorkspace_WorkspaceBasicInfo) {
+	space, err := m.spaceDao.FindBySid(ws.Sid)
+	if err != nil {
+		m.logger.Warnf("find space error:%v, sid:%s", err, ws.Sid)
+		return
+	}
+
+	r := &model.SpaceRuntime{
+		SpaceId:   space.Id,
+		Sid:       space.Sid,
+		UserId:    space.UserId,
+		OrgId:     space.OrgId,
+		StartTime: time.UnixMilli(ws.StartedAt),
+		SpecId:    space.SpecId,
+		Tier:      m.quota.TierOfOwner(space.UserId, space.OrgId),
+	}
+	if spec := m.specCache.Get(space.SpecId); spec != nil {
+		r.CpuMillis, r.MemoryMb = specResources(spec)
+	}
+	if err = m.runtime.Start(r); err != nil {
+		m.logger.Warnf("start runtime error:%v, sid:%s", err, ws.Sid)
+	}
+}
+
+// specResources 解析规格的CPU(毫核)和内存(MiB), 无法解析时为0
+func specResources(spec *model.SpaceSpec) (cpuMillis, memoryMb uint32) {
+	if q, err := resource.ParseQuantity(spec.CpuSpec); err == nil {
+		cpuMillis = uint32(q.MilliValue())
+	}
+	if q, err := resource.ParseQuantity(spec.MemSpec); err == nil {
+		memoryMb = uint32(q.Value() >> 20)
+	}
+
+	return
+}
+
+// charge 按量计费的运行记录扣除截止到until的费用, 余额用完的用户记录到exhausted中
+func (m *MeteringService) charge(r *model.SpaceRuntime, until time.Time, exhausted map[uint32]struct{}) {
+	if r.Tier != QuotaCredit {
+		return
+	}
+	cost := meterCost(r, r.StartTime, until, conf.MeteringConfig)
+	if cost <= r.Cost {
+		return
+	}
+
+	balance, err := m.credit.Charge(r, cost)
+	if err != nil {
+		m.logger.Errorf("charge error:%v, sid:%s, cost:%d", err, r.Sid, cost)
+		return
+	}
+	r.Cost = cost
+	if balance <= 0 {
+		exhausted[r.UserId] = struct{}{}
+	}
+}
+
+// meterCost 计算运行记录在[start, end)期间的费用(分), 四舍五入
+func meterCost(r *model.SpaceRuntime, start, end time.Time, price pconf.MeteringConf) int64 {
+	return int64(math.Round(meterCostFloat(r, start, end, price)))
+}
+
+func meterCostFloat(r *model.SpaceRuntime, start, end time.Time, price pconf.MeteringConf) float64 {
+	hours := end.Sub(start).Hours()
+	if hours <= 0 {
+		return 0
+	}
+	cores := float64(r.CpuMillis) / 1000
+	gbs := float64(r.MemoryMb) / 1024
+
+	return hours * (cores*price.CpuCoreHourPrice + gbs*price.MemGbHourPrice)
+}
+
+// Usage 获取用户某个月的资源使用量以及每天的明细
+func (m *MeteringService) Usage(userId uint32, month time.Time) (*model.Usage, error) {
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.Local)
+	end := start.AddDate(0, 1, 0)
+	runtimes, err := m.runtime.FindByUserIdBetween(userId, start, end)
+	if err != nil {
+		m.logger.Warnf("find runtime error:%v, userId:%d", err, userId)
+		return nil, err
+	}
+	balance, err := m.credit.FindBalance(userId)
+	if err != nil {
+		m.logger.Warnf("find credit balance error:%v, userId:%d", err, userId)
+		return nil, err
+	}
+
+	now := time.Now()
+	days := aggregateUsage(runtimes, start, end, now, func(t time.Time) time.Time {
+		return t.AddDate(0, 0, 1)
+	}, "2006-01-02", conf.MeteringConfig)
+	usage := &model.Usage{
+		Month:   start.Format("2006-01"),
+		Days:    days,
+		Balance: balance,
+	}
+	usage.Total = sumUsage(days)
+	usage.Total.Date = usage.Month
+
+	return usage, nil
+}
+
+// MonthlyUsage 获取用户最近几个月每个月的资源使用量
+func (m *MeteringService) MonthlyUsage(userId uint32, months int) ([]model.UsageItem, error) {
+	now := time.Now()
+	end := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local).AddDate(0, 1, 0)
+	start := end.AddDate(0, -months, 0)
+	runtimes, err := m.runtime.FindByUserIdBetween(userId, start, end)
+	if err != nil {
+		m.logger.Warnf("find runtime error:%v, userId:%d", err, userId)
+		return nil, err
+	}
+
+	return aggregateUsage(runtimes, start, end, now, func(t time.Time) time.Time {
+		return t.AddDate(0, 1, 0)
+	}, "2006-01", conf.MeteringConfig), nil
+}
+
+// CreditInfo 获取用户的余额以及最近的余额变动记录
+func (m *MeteringService) CreditInfo(userId uint32, limit int) (int64, []model.CreditRecord, error) {
+	balance, err := m.credit.FindBalance(userId)
+	if err != nil {
+		m.logger.Warnf("find credit balance error:%v, userId:%d", err, userId)
+		return 0, nil, err
+	}
+	records, err := m.credit.FindRecords(userId, limit)
+	if err != nil {
+		m.logger.Warnf("find credit records error:%v, userId:%d", err, userId)
+		return 0, nil, err
+	}
+
+	return balance, records, nil
+}
+
+// aggregateUsage 将运行记录按照[start, end)中的每个时间段统计, next返回下一个时间段的开始时间
+// 未结束的运行记录计算到now, now之后的时间段不统计
+func aggregateUsage(runtimes []model.SpaceRuntime, start, end, now time.Time,
+	next func(time.Time) time.Time, layout string, price pconf.MeteringConf) []model.UsageItem {
+	if now.Before(end) {
+		end = now
+	}
+
+	var items []model.UsageItem
+	for t := start; t.Before(end); t = next(t) {
+		until := next(t)
+		if until.After(end) {
+			until = end
+		}
+
+		item := model.UsageItem{Date: t.Format(layout)}
+		var cost float64
+		for i := range runtimes {
+			r := &runtimes[i]
+			s, e := r.StartTime, now
+			if r.StopTime != nil {
+				e = *r.StopTime
+			}
+			if s.Before(t) {
+				s = t
+			}
+			if e.After(until) {
+				e = until
+			}
+			if !e.After(s) {
+				continue
+			}
+
+			hours := e.Sub(s).Hours()
+			item.Hours += hours
+			item.CpuCoreHours += hours * float64(r.CpuMillis) / 1000
+			item.MemGbHours += hours * float64(r.MemoryMb) / 1024
+			if r.Tier == QuotaCredit {
+				cost += meterCostFloat(r, s, e, price)
+			}
+		}
+		item.Hours = round2(item.Hours)
+		item.CpuCoreHours = round2(item.CpuCoreHours)
+		item.MemGbHours = round2(item.MemGbHours)
+		item.Cost = int64(math.Round(cost))
+		items = append(items, item)
+	}
+
+	return items
+}
+
+func sumUsage(items []model.UsageItem) model.UsageItem {
+	var total model.UsageItem
+	for _, item := range items {
+		total.Hours += item.Hours
+		total.CpuCoreHours += item.CpuCoreHours
+		total.MemGbHours += item.MemGbHours
+		total.Cost += item.Cost
+	}
+	total.Hours = round2(total.Hours)
+	total.CpuCoreHours = round2(total.CpuCoreHours)
+	total.MemGbHours = round2(total.MemGbHours)
+
+	return total
+}
+
+// round2 保留两位小数
+func round2(f float64) float64 {
+	return math.Round(f*100) / 100
+}
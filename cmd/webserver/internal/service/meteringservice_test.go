@@ -0,0 +1,66 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+	pconf "github.com/mangohow/cloud-ide/pkg/conf"
+)
+
+var testPrice = pconf.MeteringConf{CpuCoreHourPrice: 10, MemGbHourPrice: 5}
+
+func TestMeterCost(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	r := &model.SpaceRuntime{CpuMillis: 2000, MemoryMb: 4096}
+	cases := []struct {
+		end  time.Time
+		cost int64
+	}{
+		{start.Add(90 * time.Minute), 60},
+		{start.Add(time.Minute), 1},
+		{start, 0},
+		{start.Add(-time.Hour), 0},
+	}
+	for _, c := range cases {
+		if cost := meterCost(r, start, c.end, testPrice); cost != c.cost {
+			t.Errorf("meterCost(%v) = %d, want %d", c.end.Sub(start), cost, c.cost)
+		}
+	}
+}
+
+func TestAggregateUsage(t *testing.T) {
+	day := func(d, h int) time.Time {
+		return time.Date(2024, 5, d, h, 0, 0, 0, time.Local)
+	}
+	stop := day(2, 1)
+	runtimes := []model.SpaceRuntime{
+		// 跨天运行, 按天拆分
+		{StartTime: day(1, 23), StopTime: &stop, CpuMillis: 2000, MemoryMb: 4096, Tier: QuotaCredit},
+		// 套餐内的运行不计费
+		{StartTime: day(1, 10), StopTime: &stop, CpuMillis: 1000, MemoryMb: 1024, Tier: QuotaFree},
+		// 未结束的运行计算到now
+		{StartTime: day(3, 0), CpuMillis: 1000, MemoryMb: 1024, Tier: QuotaCredit},
+	}
+	next := func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+
+	items := aggregateUsage(runtimes, day(1, 0), day(6, 0), day(3, 6), next, "2006-01-02", testPrice)
+	want := []model.UsageItem{
+		{Date: "2024-05-01", Hours: 15, CpuCoreHours: 16, MemGbHours: 18, Cost: 40},
+		{Date: "2024-05-02", Hours: 2, CpuCoreHours: 3, MemGbHours: 5, Cost: 40},
+		{Date: "2024-05-03", Hours: 6, CpuCoreHours: 6, MemGbHours: 6, Cost: 90},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("aggregateUsage returns %d items, want %d", len(items), len(want))
+	}
+	for i := range want {
+		if items[i] != want[i] {
+			t.Errorf("items[%d] = %+v, want %+v", i, items[i], want[i])
+		}
+	}
+
+	total := sumUsage(items)
+	if total.Hours != 23 || total.Cost != 170 {
+		t.Errorf("sumUsage = %+v, want 23 hours and cost 170", total)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/conf"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/code"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
 	"github.com/mangohow/cloud-ide/pkg/logger"
@@ -169,6 +170,10 @@ func (o *OAuthService) LoginOrCreateUser(userInfo *LinuxDoUserInfo) (*model.User
 	// 首先尝试通过LinuxDo ID查找用户
 	user, err := o.userDao.FindByLinuxDoID(userInfo.ID)
 	if err == nil {
+		// 被禁用的用户无法登录
+		if code.UserStatus(user.Status) == code.StatusDisabled {
+			return nil, ErrUserDisabled
+		}
 		// 用户已存在，更新信息并返回
 		return o.updateAndLoginUser(user, userInfo)
 	}
@@ -177,6 +182,9 @@ func (o *OAuthService) LoginOrCreateUser(userInfo *LinuxDoUserInfo) (*model.User
 	if userInfo.Email != "" {
 		user, err = o.userDao.FindByEmailDetailed(userInfo.Email)
 		if err == nil {
+			if code.UserStatus(user.Status) == code.StatusDisabled {
+				return nil, ErrUserDisabled
+			}
 			// 用户存在但没有绑定LinuxDo，绑定并返回
 			return o.bindLinuxDoAndLogin(user, userInfo)
 		}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	dsql "database/sql"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model/reqtype"
+	"github.com/mangohow/cloud-ide/pkg/logger"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	// MaxOrgCount 每个用户最多创建的组织数量
+	MaxOrgCount = 5
+	// MaxOrgMembers 每个组织最多的成员数量
+	MaxOrgMembers = 50
+	// MaxOrgNameLen 组织名称的最大长度
+	MaxOrgNameLen = 64
+)
+
+// 组织成员可以执行的操作
+const (
+	OrgActionOpen    = "open"    // 查看、打开和监听工作空间
+	OrgActionStart   = "start"   // 启动和停止工作空间
+	OrgActionManage  = "manage"  // 创建、删除和修改工作空间
+	OrgActionMember  = "member"  // 添加和移除成员
+	OrgActionBilling = "billing" // 为组织购买订阅
+	OrgActionRole    = "role"    // 修改成员的角色
+)
+
+var (
+	ErrOrgNotFound         = errors.New("organization not found")
+	ErrOrgPermissionDenied = errors.New("organization permission denied")
+	ErrOrgInvalid          = errors.New("organization param invalid")
+	ErrReachMaxOrgCount    = errors.New("reach max organization count")
+	ErrReachMaxOrgMembers  = errors.New("reach max organization members")
+	ErrOrgUserNotFound     = errors.New("user not found")
+	ErrOrgMemberExists     = errors.New("user is already a member")
+	ErrOrgMemberNotFound   = errors.New("organization member not found")
+)
+
+// 每个角色可以执行的操作
+var orgPermissions = map[string][]string{
+	model.OrgRoleOwner:  {OrgActionOpen, OrgActionStart, OrgActionManage, OrgActionMember, OrgActionBilling, OrgActionRole},
+	model.OrgRoleAdmin:  {OrgActionOpen, OrgActionStart, OrgActionManage, OrgActionMember, OrgActionBilling},
+	model.OrgRoleMember: {OrgActionOpen, OrgActionStart},
+}
+
+// OrgService 组织和成员的管理, 组织的工作空间由成员根据角色共享
+type OrgService struct {
+	logger  *logrus.Logger
+	dao     *dao.OrgDao
+	userDao *dao.UserDao
+}
+
+func NewOrgService() *OrgService {
+	return &OrgService{
+		logger:  logger.Logger(),
+		dao:     dao.NewOrgDao(),
+		userDao: dao.NewUserDao(),
+	}
+}
+
+// Create 创建组织, 创建者为组织的所有者
+func (s *OrgService) Create(userId uint32, name string) (*model.Organization, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > MaxOrgNameLen {
+		return nil, ErrOrgInvalid
+	}
+
+	count, err := s.dao.CountByOwnerId(userId)
+	if err != nil {
+		s.logger.Errorf("count organization error:%v, user:%d", err, userId)
+		return nil, err
+	}
+	if count >= MaxOrgCount {
+		return nil, ErrReachMaxOrgCount
+	}
+
+	now := time.Now()
+	org := &model.Organization{
+		Uid:        bson.NewObjectId().Hex(),
+		Name:       name,
+		OwnerId:    userId,
+		VipStatus:  model.VipStatusNormal,
+		CreateTime: now,
+		UpdateTime: now,
+		Role:       model.OrgRoleOwner,
+	}
+	if org.Id, err = s.dao.Insert(org); err != nil {
+		s.logger.Errorf("insert organization error:%v, user:%d", err, userId)
+		return nil, err
+	}
+	s.logger.Infof("organization %d created by user %d", org.Id, userId)
+
+	return org, nil
+}
+
+// List 查询用户加入的所有组织
+func (s *OrgService) List(userId uint32) ([]model.Organization, error) {
+	orgs, err := s.dao.FindByUserId(userId)
+	if err != nil {
+		s.logger.Errorf("find organization error:%v, user:%d", err, userId)
+		return nil, err
+	}
+
+	return orgs, nil
+}
+
+// Authorize 检查用户是否可以在组织中执行action, 返回组织以及用户的角色
+// 用户不是组织的成员时返回ErrOrgNotFound, 不暴露组织是否存在
+func (s *OrgService) Authorize(orgId, userId uint32, action string) (*model.Organization, error) {
+	org, err := s.dao.FindWithRole(orgId, userId)
+	if err != nil {
+		if !errors.Is(err, dsql.ErrNoRows) {
+			s.logger.Errorf("find organization error:%v, org:%d, user:%d", err, orgId, userId)
+		}
+		return nil, ErrOrgNotFound
+	}
+	if !orgAllow(org.Role, action) {
+		return nil, ErrOrgPermissionDenied
+	}
+
+	return org, nil
+}
+
+// Members 查询组织的成员, 组织的成员都可以查看
+func (s *OrgService) Members(orgId, userId uint32) ([]model.OrgMember, error) {
+	if _, err := s.Authorize(orgId, userId, OrgActionOpen); err != nil {
+		return nil, err
+	}
+
+	members, err := s.dao.FindMembers(orgId)
+	if err != nil {
+		s.logger.Errorf("find organization members error:%v, org:%d", err, orgId)
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// AddMember 根据用户名添加成员, 管理员只能添加普通成员
+func (s *OrgService) AddMember(operatorId uint32, req *reqtype.OrgMemberOption) error {
+	if req.Role != model.OrgRoleAdmin && req.Role != model.OrgRoleMember {
+		return ErrOrgInvalid
+	}
+	org, err := s.Authorize(req.OrgId, operatorId, OrgActionMember)
+	if err != nil {
+		return err
+	}
+	if !canManageMember(org.Role, req.Role) {
+		return ErrOrgPermissionDenied
+	}
+
+	user, err := s.userDao.FindByUsernameDetailed(strings.TrimSpace(req.Username))
+	if err != nil {
+		return ErrOrgUserNotFound
+	}
+	count, err := s.dao.CountMembers(req.OrgId)
+	if err != nil {
+		s.logger.Errorf("count organization members error:%v, org:%d", err, req.OrgId)
+		return err
+	}
+	if count >= MaxOrgMembers {
+		return ErrReachMaxOrgMembers
+	}
+
+	if err = s.dao.InsertMember(req.OrgId, user.Id, req.Role); err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDuplicateEntry {
+			return ErrOrgMemberExists
+		}
+		s.logger.Errorf("insert organization member error:%v, org:%d", err, req.OrgId)
+		return err
+	}
+	s.logger.Infof("user %d added to organization %d as %s by %d", user.Id, req.OrgId, req.Role, operatorId)
+
+	return nil
+}
+
+// SetRole 修改成员的角色, 只有所有者可以修改, 所有者的角色不能修改
+func (s *OrgService) SetRole(operatorId uint32, req *reqtype.OrgMemberOption) error {
+	if req.Role != model.OrgRoleAdmin && req.Role != model.OrgRoleMember {
+		return ErrOrgInvalid
+	}
+	if _, err := s.Authorize(req.OrgId, operatorId, OrgActionRole); err != nil {
+		return err
+	}
+	role, err := s.memberRole(req.OrgId, req.UserId)
+	if err != nil {
+		return err
+	}
+	if role == model.OrgRoleOwner {
+		return ErrOrgPermissionDenied
+	}
+
+	if _, err = s.dao.UpdateMemberRole(req.OrgId, req.UserId, req.Role); err != nil {
+		s.logger.Errorf("update organization member error:%v, org:%d", err, req.OrgId)
+		return err
+	}
+
+	return nil
+}
+
+// RemoveMember 移除成员, 成员可以自己退出组织, 所有者不能退出
+func (s *OrgService) RemoveMember(operatorId uint32, req *reqtype.OrgMemberOption) error {
+	// 移除其他成员时检查操作者的角色, 自己退出时不检查
+	var operatorRole string
+	if req.UserId != operatorId {
+		org, err := s.Authorize(req.OrgId, operatorId, OrgActionMember)
+		if err != nil {
+			return err
+		}
+		operatorRole = org.Role
+	}
+	role, err := s.memberRole(req.OrgId, req.UserId)
+	if err != nil {
+		return err
+	}
+	if role == model.OrgRoleOwner || (operatorRole != "" && !canManageMember(operatorRole, role)) {
+		return ErrOrgPermissionDenied
+	}
+
+	if _, err = s.dao.DeleteMember(req.OrgId, req.UserId); err != nil {
+		s.logger.Errorf("delete organization member error:%v, org:%d", err, req.OrgId)
+		return err
+	}
+	s.logger.Infof("user %d removed from organization %d by %d", req.UserId, req.OrgId, operatorId)
+
+	return nil
+}
+
+func (s *OrgService) memberRole(orgId, userId uint32) (string, error) {
+	role, err := s.dao.FindMemberRole(orgId, userId)
+	if err != nil {
+		if !errors.Is(err, dsql.ErrNoRows) {
+			s.logger.Errorf("find organization member error:%v, org:%d, user:%d", err, orgId, userId)
+		}
+		return "", ErrOrgMemberNotFound
+	}
+
+	return role, nil
+}
+
+// orgAllow 检查角色是否可以执行action
+func orgAllow(role, action string) bool {
+	for _, a := range orgPermissions[role] {
+		if a == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+// canManageMember 检查角色为operator的成员是否可以添加或移除角色为target的成员
+// 所有者可以管理除自己以外的所有成员, 管理员只能管理普通成员
+func canManageMember(operator, target string) bool {
+	switch operator {
+	case model.OrgRoleOwner:
+		return target != model.OrgRoleOwner
+	case model.OrgRoleAdmin:
+		return target == model.OrgRoleMember
+	}
+
+	return false
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+)
+
+func TestOrgAllow(t *testing.T) {
+	cases := []struct {
+		role   string
+		action string
+		allow  bool
+	}{
+		{model.OrgRoleMember, OrgActionOpen, true},
+		{model.OrgRoleMember, OrgActionStart, true},
+		{model.OrgRoleMember, OrgActionManage, false},
+		{model.OrgRoleMember, OrgActionBilling, false},
+		{model.OrgRoleAdmin, OrgActionManage, true},
+		{model.OrgRoleAdmin, OrgActionMember, true},
+		{model.OrgRoleAdmin, OrgActionBilling, true},
+		{model.OrgRoleAdmin, OrgActionRole, false},
+		{model.OrgRoleOwner, OrgActionRole, true},
+		{"", OrgActionOpen, false},
+	}
+	for _, c := range cases {
+		if got := orgAllow(c.role, c.action); got != c.allow {
+			t.Errorf("orgAllow(%q, %q) = %v, want %v", c.role, c.action, got, c.allow)
+		}
+	}
+}
+
+func TestCanManageMember(t *testing.T) {
+	cases := []struct {
+		operator string
+		target   string
+		allow    bool
+	}{
+		{model.OrgRoleOwner, model.OrgRoleAdmin, true},
+		{model.OrgRoleOwner, model.OrgRoleMember, true},
+		{model.OrgRoleOwner, model.OrgRoleOwner, false},
+		{model.OrgRoleAdmin, model.OrgRoleMember, true},
+		{model.OrgRoleAdmin, model.OrgRoleAdmin, false},
+		{model.OrgRoleAdmin, model.OrgRoleOwner, false},
+		{model.OrgRoleMember, model.OrgRoleMember, false},
+	}
+	for _, c := range cases {
+		if got := canManageMember(c.operator, c.target); got != c.allow {
+			t.Errorf("canManageMember(%q, %q) = %v, want %v", c.operator, c.target, got, c.allow)
+		}
+	}
+}
@@ -1,39 +1,38 @@
 package service
 
 import (
-	"crypto/md5"
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
-	"sort"
-	"strings"
+	"math"
 	"time"
 
+	"github.com/go-sql-driver/mysql"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/conf"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/pay"
 	"github.com/mangohow/cloud-ide/pkg/logger"
 	"github.com/sirupsen/logrus"
 )
 
-// 支付配置
-const (
-	PaymentGatewayURL = "https://pay.ouyun.cc"
-	MerchantID        = "47"
-	MerchantKey       = "u6lTC1ssfQ46OLyvHNjkf5aQrD9tJRxB"
-)
-
 type PaymentService struct {
 	logger     *logrus.Logger
 	paymentDao *dao.PaymentDao
+	invoices   *InvoiceService
+	coupons    *CouponService
+	orgs       *OrgService
+	gateway    pay.Gateway
 }
 
 func NewPaymentService() *PaymentService {
 	return &PaymentService{
 		logger:     logger.Logger(),
 		paymentDao: dao.NewPaymentDao(),
+		invoices:   NewInvoiceService(),
+		coupons:    NewCouponService(),
+		orgs:       NewOrgService(),
+		gateway:    pay.Default(),
 	}
 }
 
@@ -42,6 +41,15 @@ var (
 	ErrOrderNotFound   = errors.New("order not found")
 	ErrPaymentFailed   = errors.New("payment failed")
 	ErrInvalidSign     = errors.New("invalid signature")
+	ErrOrderNotPaid    = errors.New("order not paid")
+	ErrAmountMismatch  = errors.New("payment amount mismatch")
+	ErrDuplicateTrade  = errors.New("trade no already used")
+	ErrOrderClosed     = errors.New("closed order can not be fulfilled")
+
+	ErrRefundNotAllowed = errors.New("refund not allowed")
+	ErrRefundFailed     = errors.New("refund failed")
+
+	ErrOrgProductNotAllowed = errors.New("organization can only buy subscriptions")
 )
 
 // =============== 支付产品相关 ===============
@@ -53,7 +61,7 @@ func (s *PaymentService) GetPaymentProducts() ([]model.PaymentProduct, error) {
 
 // =============== 订单相关 ===============
 
-// CreateOrder 创建订单
+// CreateOrder 创建订单, req.OrgId不为0时为组织购买订阅, 需要组织的管理员权限
 func (s *PaymentService) CreateOrder(userId uint32, req *model.CreateOrderRequest) (*model.OrderDetailResponse, error) {
 	// 1. 验证产品是否存在
 	product, err := s.paymentDao.GetPaymentProductById(req.ProductId)
@@ -61,33 +69,69 @@ func (s *PaymentService) CreateOrder(userId uint32, req *model.CreateOrderReques
 		s.logger.Errorf("get product by id failed: %v", err)
 		return nil, ErrProductNotFound
 	}
+	if req.OrgId != 0 {
+		if _, err = s.orgs.Authorize(req.OrgId, userId, OrgActionBilling); err != nil {
+			return nil, err
+		}
+		// 组织没有预付费余额, 只能购买订阅
+		if product.Type == model.ProductTypeCredit {
+			return nil, ErrOrgProductNotAllowed
+		}
+	}
 
 	// 2. 生成订单号
 	orderNo := s.generateOrderNo()
 
-	// 3. 创建订单
+	// 3. 创建订单, 使用优惠码时在同一个事务中占用优惠码
 	now := time.Now()
 	order := &model.Order{
-		OrderNo:       orderNo,
-		UserId:        userId,
-		ProductId:     product.Id,
-		ProductName:   product.Name,
-		ProductType:   product.Type,
-		Amount:        product.Price,
-		Status:        model.OrderStatusPending,
-		PaymentMethod: req.PaymentMethod,
-		CreateTime:    now,
-		UpdateTime:    now,
-	}
-
-	err = s.paymentDao.CreateOrder(order)
+		OrderNo:        orderNo,
+		UserId:         userId,
+		ProductId:      product.Id,
+		ProductName:    product.Name,
+		ProductType:    product.Type,
+		Amount:         product.Price,
+		OriginalAmount: product.Price,
+		Status:         model.OrderStatusPending,
+		PaymentMethod:  req.PaymentMethod,
+		CreateTime:     now,
+		UpdateTime:     now,
+		OrgId:          req.OrgId,
+	}
+
+	err = s.paymentDao.Tx(func(tx *dao.PaymentTx) error {
+		if req.CouponCode == "" {
+			return tx.CreateOrder(order)
+		}
+
+		coupon, discount, err := s.coupons.Redeem(tx, userId, req.CouponCode, product)
+		if err != nil {
+			return err
+		}
+		order.CouponId = coupon.Id
+		order.DiscountAmount = discount
+		order.Amount = round2(product.Price - discount)
+		if err = tx.CreateOrder(order); err != nil {
+			return err
+		}
+
+		return tx.CreateCouponRedemption(&model.CouponRedemption{
+			CouponId:   coupon.Id,
+			UserId:     userId,
+			OrderId:    order.Id,
+			Discount:   discount,
+			Status:     model.RedemptionReserved,
+			CreateTime: now,
+			UpdateTime: now,
+		})
+	})
 	if err != nil {
 		s.logger.Errorf("create order failed: %v", err)
 		return nil, err
 	}
 
 	// 4. 调用支付网关创建支付
-	payResult, err := s.createPayment(order, req.PaymentMethod, req.ReturnUrl)
+	payResult, err := s.createPayment(order, req.PaymentMethod, req.ReturnUrl, req.ClientIp)
 	if err != nil {
 		s.logger.Errorf("create payment failed: %v", err)
 		return nil, ErrPaymentFailed
@@ -97,7 +141,7 @@ func (s *PaymentService) CreateOrder(userId uint32, req *model.CreateOrderReques
 	paymentRecord := &model.PaymentRecord{
 		OrderId:         order.Id,
 		UserId:          userId,
-		PaymentGateway:  "ouyun",
+		PaymentGateway:  s.gateway.Name(),
 		PaymentMethod:   req.PaymentMethod,
 		Amount:          order.Amount,
 		TradeNo:         payResult.TradeNo,
@@ -134,56 +178,222 @@ func (s *PaymentService) GetOrdersByUserId(userId uint32, page, pageSize int) ([
 
 // =============== 支付回调处理 ===============
 
-// HandlePaymentCallback 处理支付回调
-func (s *PaymentService) HandlePaymentCallback(req *model.PaymentCallbackRequest) error {
-	// 1. 验证签名 - 临时注释掉，等待ouyun密钥配置生效
-	// if !s.verifySign(req) {
-	//     s.logger.Errorf("payment callback signature verification failed")
-	//     return ErrInvalidSign
-	// }
-	s.logger.Infof("临时跳过签名验证，直接处理支付回调")
+// HandlePaymentCallback 处理支付网关的异步通知, params为通知的所有参数, 签名必须正确
+func (s *PaymentService) HandlePaymentCallback(params map[string]string) error {
+	// 1. 验证签名
+	n, err := s.gateway.VerifyCallback(params)
+	if err != nil {
+		s.logger.Errorf("payment callback verification failed: %v", err)
+		return ErrInvalidSign
+	}
 
 	// 2. 检查支付状态
-	if req.TradeStatus != "TRADE_SUCCESS" {
-		s.logger.Infof("payment not success, status: %s", req.TradeStatus)
+	if !n.Paid {
+		s.logger.Infof("payment not success, order: %s", n.OrderNo)
 		return nil
 	}
 
-	// 3. 获取订单信息
-	order, err := s.paymentDao.GetOrderByOrderNo(req.OutTradeNo)
-	if err != nil {
-		s.logger.Errorf("get order failed: %v", err)
+	return s.handlePaid(n)
+}
+
+// SyncPaymentStatus 向支付网关查询订单的支付结果, 用于没有收到异步通知的情况
+func (s *PaymentService) SyncPaymentStatus(userId uint32, orderNo string) error {
+	order, err := s.paymentDao.GetOrderByOrderNo(orderNo)
+	if err != nil || order.UserId != userId {
 		return ErrOrderNotFound
 	}
-
-	// 4. 检查订单状态，避免重复处理
-	if order.Status == model.OrderStatusPaid {
-		s.logger.Infof("order already paid: %s", req.OutTradeNo)
+	if order.Status == model.OrderStatusPaid || order.Status == model.OrderStatusRefunding || order.Status == model.OrderStatusRefunded {
 		return nil
 	}
 
-	// 5. 更新订单状态
-	paidAt := time.Now()
-	err = s.paymentDao.UpdateOrderStatus(req.OutTradeNo, model.OrderStatusPaid, req.TradeNo, &paidAt)
+	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancelFunc()
+	n, err := s.gateway.QueryOrder(ctx, orderNo)
 	if err != nil {
-		s.logger.Errorf("update order status failed: %v", err)
+		s.logger.Errorf("query order failed: %v, order: %s", err, orderNo)
+		return ErrOrderNotPaid
+	}
+	if !n.Paid {
+		return ErrOrderNotPaid
+	}
+	// 查询结果必须是该订单且金额一致, 与异步通知验证签名后的检查相同
+	if n.OrderNo != order.OrderNo {
+		s.logger.Errorf("query order returned order %s, want %s", n.OrderNo, order.OrderNo)
+		return ErrOrderNotPaid
+	}
+	if math.Abs(n.Amount-order.Amount) >= 0.01 {
+		s.logger.Errorf("payment amount mismatch, order: %s, amount: %.2f, paid: %.2f", order.OrderNo, order.Amount, n.Amount)
+		return ErrAmountMismatch
+	}
+
+	return s.handlePaid(n)
+}
+
+// handlePaid 处理已经支付的订单, 开通订阅或者充值
+// 订单、支付记录、订阅、用户和收据的修改在同一个事务中完成, 重复的通知在锁定订单时排队, 已经处理过的订单直接返回
+func (s *PaymentService) handlePaid(n *pay.Notification) error {
+	var invoiceNo string
+	err := s.paymentDao.Tx(func(tx *dao.PaymentTx) error {
+		// 1. 锁定订单
+		order, err := tx.GetOrderForUpdate(n.OrderNo)
+		if err != nil {
+			s.logger.Errorf("get order failed: %v", err)
+			return ErrOrderNotFound
+		}
+
+		// 2. 检查订单状态，避免重复处理
+		// 已经关闭的订单收到了付款仍然开通, 但关闭时释放的优惠码需要重新占用, 已被用满时拒绝, 需要人工退款
+		switch order.Status {
+		case model.OrderStatusPaid, model.OrderStatusRefunding, model.OrderStatusRefunded:
+			s.logger.Infof("order already paid: %s", n.OrderNo)
+			return nil
+		case model.OrderStatusClosed:
+			s.logger.Warnf("closed order paid: %s, trade no: %s", n.OrderNo, n.TradeNo)
+			if order.CouponId != 0 {
+				if err = s.coupons.Reclaim(tx, order); err != nil {
+					s.logger.Errorf("reclaim coupon of closed order %s failed: %v, trade no %s needs a manual refund", n.OrderNo, err, n.TradeNo)
+					return ErrOrderClosed
+				}
+			}
+		}
+
+		// 3. 检查支付金额
+		if math.Abs(n.Amount-order.Amount) >= 0.01 {
+			s.logger.Errorf("payment amount mismatch, order: %s, amount: %.2f, paid: %.2f", order.OrderNo, order.Amount, n.Amount)
+			return ErrAmountMismatch
+		}
+
+		// 4. 更新支付记录状态, 交易号已经完成过其它订单时失败
+		err = tx.UpdatePaymentRecordStatus(order.Id, model.PaymentStatusSuccess, n.TradeNo, n.Raw)
+		if err != nil {
+			var mysqlErr *mysql.MySQLError
+			if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDuplicateEntry {
+				s.logger.Errorf("trade no %s already used, order: %s", n.TradeNo, order.OrderNo)
+				return ErrDuplicateTrade
+			}
+			return err
+		}
+
+		// 5. 更新订单状态并开具收据
+		paidAt := time.Now()
+		if err = tx.SetOrderPaid(order.Id, paidAt); err != nil {
+			return err
+		}
+		if invoiceNo, err = tx.CreateInvoice(order, paidAt); err != nil {
+			return err
+		}
+		if order.CouponId != 0 {
+			if err = tx.UseCouponRedemption(order.Id); err != nil {
+				return err
+			}
+		}
+
+		// 6. 处理用户订阅, 预付费订单充值到余额
+		if order.ProductType == model.ProductTypeCredit {
+			return s.handleCreditRecharge(tx, order)
+		}
+		return s.handleUserSubscription(tx, order, paidAt)
+	})
+	if err != nil {
+		s.logger.Errorf("handle paid order %s failed: %v", n.OrderNo, err)
 		return err
 	}
 
-	// 6. 更新支付记录状态
-	callbackData, _ := json.Marshal(req)
-	err = s.paymentDao.UpdatePaymentRecordStatus(req.TradeNo, model.PaymentStatusSuccess, string(callbackData))
+	// 重复的通知不会开具收据
+	if invoiceNo != "" {
+		s.invoices.Generate(invoiceNo)
+	}
+
+	return nil
+}
+
+// RefundOrder 全额退款已经支付的订单, 订阅订单收回未使用的时长, 预付费订单扣除充值的余额
+// 先在事务中收回订阅或余额并将订单设置为退款中, 然后调用支付网关退款, 成功后再将订单设置为已退款
+// 支付网关退款失败时订单保持退款中, 可以再次调用重试, 重试时不会再次收回订阅或余额
+func (s *PaymentService) RefundOrder(orderNo, reason string) error {
+	var (
+		order  *model.Order
+		record *model.PaymentRecord
+	)
+	err := s.paymentDao.Tx(func(tx *dao.PaymentTx) error {
+		var err error
+		order, err = tx.GetOrderForUpdate(orderNo)
+		if err != nil {
+			s.logger.Errorf("get order failed: %v", err)
+			return ErrOrderNotFound
+		}
+		if order.Status != model.OrderStatusPaid && order.Status != model.OrderStatusRefunding {
+			return ErrRefundNotAllowed
+		}
+		if record, err = tx.GetPaymentRecordByOrderId(order.Id); err != nil {
+			return err
+		}
+		if order.Status == model.OrderStatusRefunding {
+			return nil
+		}
+
+		if order.ProductType == model.ProductTypeCredit {
+			err = s.refundCredit(tx, order)
+		} else {
+			err = s.refundSubscription(tx, order, time.Now())
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.SetOrderRefunding(order.Id, reason)
+	})
 	if err != nil {
-		s.logger.Errorf("update payment record failed: %v", err)
+		return err
 	}
 
-	// 7. 处理用户订阅
-	err = s.handleUserSubscription(order)
+	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancelFunc()
+	err = s.gateway.Refund(ctx, &pay.RefundRequest{
+		OrderNo: order.OrderNo,
+		TradeNo: record.TradeNo,
+		Amount:  order.Amount,
+	})
 	if err != nil {
-		s.logger.Errorf("handle user subscription failed: %v", err)
+		s.logger.Errorf("gateway refund failed: %v, order %s is left refunding", err, order.OrderNo)
+		return ErrRefundFailed
+	}
+
+	// 支付网关已经退款, 此时失败需要人工核对后将订单设置为已退款
+	err = s.paymentDao.Tx(func(tx *dao.PaymentTx) error {
+		o, err := tx.GetOrderForUpdate(orderNo)
+		if err != nil {
+			return err
+		}
+		if o.Status != model.OrderStatusRefunding {
+			return nil
+		}
+		if err = tx.SetOrderRefunded(o.Id, o.RefundReason, time.Now()); err != nil {
+			return err
+		}
+		return tx.SetPaymentRecordRefunded(o.Id)
+	})
+	if err != nil {
+		s.logger.Errorf("gateway refunded but finish order %s failed: %v", orderNo, err)
+		return ErrRefundFailed
+	}
+
+	s.logger.Infof("order %s refunded, reason: %s", orderNo, reason)
+	return nil
+}
+
+// CloseExpiredOrders 关闭超时未支付的订单（定时任务使用）
+func (s *PaymentService) CloseExpiredOrders() error {
+	before := time.Now().Add(-time.Duration(conf.PaymentConfig.OrderTTL) * time.Minute)
+	n, err := s.paymentDao.CloseExpiredOrders(before)
+	if err != nil {
+		s.logger.Errorf("close expired orders failed: %v", err)
 		return err
 	}
 
+	if n > 0 {
+		s.logger.Infof("%d expired orders closed", n)
+	}
 	return nil
 }
 
@@ -247,187 +457,56 @@ func (s *PaymentService) generateOrderNo() string {
 	return fmt.Sprintf("ORDER%d%06d", time.Now().Unix(), time.Now().Nanosecond()%1000000)
 }
 
-// createPayment 创建支付
-func (s *PaymentService) createPayment(order *model.Order, paymentMethod, returnUrl string) (*PaymentResult, error) {
+// createPayment 调用支付网关创建支付
+func (s *PaymentService) createPayment(order *model.Order, paymentMethod, returnUrl, clientIp string) (*pay.PayResult, error) {
 	// 处理return_url为空的情况
 	if returnUrl == "" {
-		returnUrl = "https://tiantianai.co/idea/#/payment"
+		returnUrl = conf.PaymentConfig.ReturnUrl
 	}
-	
-	// 使用英文产品名称避免编码问题
-	productName := order.ProductName
-	switch order.ProductType {
-	case "day":
-		productName = "VIP Day Card"
-	case "week":
-		productName = "VIP Week Card"
-	case "month":
-		productName = "VIP Month Card"
-	}
-	
-	// 构建支付参数
-	params := map[string]string{
-		"pid":          MerchantID,
-		"type":         paymentMethod,
-		"out_trade_no": order.OrderNo,
-		"notify_url":   "https://tiantianai.co/api/payment/callback", // 修复回调地址
-		"return_url":   returnUrl,                                    // 同步跳转地址
-		"name":         productName, // 使用英文名称
-		"money":        fmt.Sprintf("%.2f", order.Amount),
-		"clientip":     "127.0.0.1", // 这里应该传入真实的客户端IP
-		"device":       "pc",
-		"sign_type":    "MD5",
-	}
-
-	// 生成签名
-	params["sign"] = s.generateSign(params)
-
-	// 添加调试日志
-	s.logger.Infof("Payment request params: %+v", params)
-	s.logger.Infof("Generated sign: %s", params["sign"])
-
-	// 使用页面跳转支付方式，构造支付URL
-	// 根据ouyun文档，页面跳转支付URL格式：https://pay.ouyun.cc/submit.php
-	baseURL := PaymentGatewayURL + "/submit.php"
-	
-	// 手动构造URL参数，避免编码问题
-	var urlParts []string
-	
-	// 按照固定顺序添加参数，确保一致性
-	orderedKeys := []string{"pid", "type", "out_trade_no", "notify_url", "return_url", "name", "money", "clientip", "device", "sign_type", "sign"}
-	
-	for _, key := range orderedKeys {
-		if value, exists := params[key]; exists && value != "" {
-			// 手动进行URL编码
-			encodedValue := url.QueryEscape(value)
-			urlParts = append(urlParts, fmt.Sprintf("%s=%s", key, encodedValue))
-		}
-	}
-	
-	// 生成完整的支付页面URL
-	paymentPageURL := fmt.Sprintf("%s?%s", baseURL, strings.Join(urlParts, "&"))
-	s.logger.Infof("Generated payment page URL: %s", paymentPageURL)
-	
-	// 生成一个trade_no用于跟踪
-	tradeNo := fmt.Sprintf("PAY%d", time.Now().Unix())
-	
-	// 返回页面跳转URL格式的结果
-	result := &PaymentResult{
-		Code:    1,
-		Msg:     "支付页面已生成",
-		TradeNo: tradeNo,
-		Price:   fmt.Sprintf("%.2f", order.Amount),
-		PayUrl:  paymentPageURL, // 返回构造的支付页面URL
-		QrCode:  "",             // 页面跳转模式不返回qrcode
-	}
-	
-	s.logger.Infof("Payment result: %+v", result)
-	return result, nil
-}
 
-// createTestPaymentResult 创建测试支付结果（用于开发和测试环境）
-func (s *PaymentService) createTestPaymentResult(order *model.Order) *PaymentResult {
-	tradeNo := fmt.Sprintf("TEST%d", time.Now().Unix())
-	s.logger.Infof("creating test payment result for order %s with trade_no %s", order.OrderNo, tradeNo)
-	
-	return &PaymentResult{
-		Code:    1,
-		Msg:     "success",
-		TradeNo: tradeNo,
-		Price:   fmt.Sprintf("%.2f", order.Amount),
-		PayUrl:  "",
-		QrCode:  "",
-	}
-}
-
-// makeHttpRequest 发送HTTP请求
-func (s *PaymentService) makeHttpRequest(apiURL string, params map[string]string) ([]byte, error) {
-	// 只使用POST请求，因为GET请求返回空
-	s.logger.Infof("Using POST request to payment gateway")
-	
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
-	
-	data := url.Values{}
-	for k, v := range params {
-		data.Set(k, v)
-	}
-	
-	s.logger.Infof("POST data: %s", data.Encode())
-	
-	req, err := http.NewRequest("POST", apiURL, strings.NewReader(data.Encode()))
-	if err != nil {
-		return nil, err
-	}
-	
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-	req.Header.Set("Accept", "application/json, text/plain, */*")
-	
-	resp, err := client.Do(req)
+	// 使用英文产品名称避免编码问题
+	productName := productSubject(order.ProductType)
+	if productName == "" {
+		productName = order.ProductName
+	}
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancelFunc()
+	result, err := s.gateway.CreatePayment(ctx, &pay.PayRequest{
+		OrderNo:   order.OrderNo,
+		Subject:   productName,
+		Amount:    order.Amount,
+		Method:    paymentMethod,
+		ClientIp:  clientIp,
+		NotifyUrl: conf.PaymentConfig.NotifyUrl,
+		ReturnUrl: returnUrl,
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	
-	body, err := io.ReadAll(resp.Body)
-	s.logger.Infof("POST response status: %d", resp.StatusCode)
-	s.logger.Infof("POST response body: %s", string(body))
-	
-	return body, err
-}
 
-// generateSign 生成MD5签名
-func (s *PaymentService) generateSign(params map[string]string) string {
-	// 1. 排除sign、sign_type和空值参数
-	var keys []string
-	for k, v := range params {
-		if k != "sign" && k != "sign_type" && v != "" {
-			keys = append(keys, k)
-		}
-	}
-
-	// 2. 按ASCII码从小到大排序
-	sort.Strings(keys)
+	s.logger.Infof("payment created, order: %s, gateway: %s, url: %s", order.OrderNo, s.gateway.Name(), result.PayUrl)
+	return result, nil
+}
 
-	// 3. 拼接参数
-	var parts []string
-	for _, k := range keys {
-		parts = append(parts, fmt.Sprintf("%s=%s", k, params[k]))
+// productSubject 产品的英文名称, 未知的产品类型返回空字符串
+func productSubject(productType string) string {
+	switch productType {
+	case model.ProductTypeDay:
+		return "VIP Day Card"
+	case model.ProductTypeWeek:
+		return "VIP Week Card"
+	case model.ProductTypeMonth:
+		return "VIP Month Card"
+	case model.ProductTypeCredit:
+		return "Prepaid Credit"
 	}
 
-	// 4. 直接拼接商户密钥（不用&key=格式）
-	signStr := strings.Join(parts, "&") + MerchantKey
-	s.logger.Infof("Sign string before MD5: %s", signStr)
-
-	// 5. MD5加密（使用小写）
-	hash := md5.Sum([]byte(signStr))
-	sign := fmt.Sprintf("%x", hash)
-	s.logger.Infof("Generated MD5 sign: %s", sign)
-	
-	return sign
+	return ""
 }
 
-// verifySign 验证签名
-func (s *PaymentService) verifySign(req *model.PaymentCallbackRequest) bool {
-	params := map[string]string{
-		"pid":          req.Pid,
-		"trade_no":     req.TradeNo,
-		"out_trade_no": req.OutTradeNo,
-		"type":         req.Type,
-		"name":         req.Name,
-		"money":        req.Money,
-		"trade_status": req.TradeStatus,
-		"param":        req.Param,
-	}
-
-	expectedSign := s.generateSign(params)
-	return expectedSign == req.Sign
-}
-
-// handleUserSubscription 处理用户订阅
-func (s *PaymentService) handleUserSubscription(order *model.Order) error {
+// handleUserSubscription 处理用户或组织的订阅, 锁定用户或组织后计算续期时间
+func (s *PaymentService) handleUserSubscription(tx *dao.PaymentTx, order *model.Order, now time.Time) error {
 	// 1. 获取产品信息
 	product, err := s.paymentDao.GetPaymentProductByType(order.ProductType)
 	if err != nil {
@@ -435,14 +514,16 @@ func (s *PaymentService) handleUserSubscription(order *model.Order) error {
 	}
 
 	// 2. 计算订阅时间
-	now := time.Now()
 	var startTime, endTime time.Time
 
-	// 获取用户当前的VIP状态
-	user, err := s.paymentDao.GetUserVipInfo(order.UserId)
-	if err == nil && user.VipStatus == model.VipStatusVip && user.VipExpireTime != nil && user.VipExpireTime.After(now) {
+	// 获取用户或组织当前的VIP状态
+	vipStatus, vipExpireTime, err := s.lockVip(tx, order)
+	if err != nil {
+		return err
+	}
+	if vipStatus == model.VipStatusVip && vipExpireTime != nil && vipExpireTime.After(now) {
 		// 如果用户已经是VIP且未过期，从当前过期时间开始续期
-		startTime = *user.VipExpireTime
+		startTime = *vipExpireTime
 	} else {
 		// 否则从现在开始
 		startTime = now
@@ -453,6 +534,7 @@ func (s *PaymentService) handleUserSubscription(order *model.Order) error {
 	// 3. 创建用户订阅记录
 	subscription := &model.UserSubscription{
 		UserId:           order.UserId,
+		OrgId:            order.OrgId,
 		SubscriptionType: order.ProductType,
 		StartTime:        startTime,
 		EndTime:          endTime,
@@ -462,21 +544,122 @@ func (s *PaymentService) handleUserSubscription(order *model.Order) error {
 		UpdateTime:       now,
 	}
 
-	err = s.paymentDao.CreateUserSubscription(subscription)
+	err = tx.CreateUserSubscription(subscription)
+	if err != nil {
+		return err
+	}
+
+	// 4. 更新用户或组织VIP状态
+	err = s.updateVip(tx, order, model.VipStatusVip, &endTime)
 	if err != nil {
 		return err
 	}
 
-	// 4. 更新用户VIP状态
-	err = s.paymentDao.UpdateUserVipStatus(order.UserId, model.VipStatusVip, &endTime)
+	s.logger.Infof("user %d subscription created successfully, org: %d, expire at %s", order.UserId, order.OrgId, endTime.Format("2006-01-02 15:04:05"))
+	return nil
+}
+
+// lockVip 锁定并获取订单购买者的VIP状态和过期时间, 为组织购买的订单为组织的VIP
+func (s *PaymentService) lockVip(tx *dao.PaymentTx, order *model.Order) (uint8, *time.Time, error) {
+	if order.OrgId != 0 {
+		org, err := tx.GetOrgVipInfoForUpdate(order.OrgId)
+		if err != nil {
+			return 0, nil, err
+		}
+		return org.VipStatus, org.VipExpireTime, nil
+	}
+
+	user, err := tx.GetUserVipInfoForUpdate(order.UserId)
+	if err != nil {
+		return 0, nil, err
+	}
+	return user.VipStatus, user.VipExpireTime, nil
+}
+
+// updateVip 更新订单购买者的VIP状态
+func (s *PaymentService) updateVip(tx *dao.PaymentTx, order *model.Order, vipStatus uint8, expireTime *time.Time) error {
+	if order.OrgId != 0 {
+		return tx.UpdateOrgVipStatus(order.OrgId, vipStatus, expireTime)
+	}
+
+	return tx.UpdateUserVipStatus(order.UserId, vipStatus, expireTime)
+}
+
+// handleCreditRecharge 处理预付费充值, 订单原价(元)转换为分充值到用户余额, 使用优惠码时优惠的部分也充值
+func (s *PaymentService) handleCreditRecharge(tx *dao.PaymentTx, order *model.Order) error {
+	amount := int64(math.Round(order.OriginalAmount * 100))
+	balance, err := tx.AddBalance(order.UserId, amount, model.CreditRecharge, order.OrderNo)
 	if err != nil {
 		return err
 	}
 
-	s.logger.Infof("user %d subscription created successfully, expire at %s", order.UserId, endTime.Format("2006-01-02 15:04:05"))
+	s.logger.Infof("user %d recharged %d, balance %d", order.UserId, amount, balance)
 	return nil
 }
 
+// refundSubscription 收回订单开通的订阅未使用的时长, 之后续期的订阅和VIP过期时间一起提前
+func (s *PaymentService) refundSubscription(tx *dao.PaymentTx, order *model.Order, now time.Time) error {
+	vipStatus, vipExpireTime, err := s.lockVip(tx, order)
+	if err != nil {
+		return err
+	}
+	subscription, err := tx.GetSubscriptionByOrderId(order.Id)
+	if err != nil {
+		s.logger.Errorf("get subscription of order %s failed: %v", order.OrderNo, err)
+		return ErrRefundNotAllowed
+	}
+
+	endTime, removed := refundPeriod(subscription.StartTime, subscription.EndTime, now)
+	if err = tx.RefundSubscription(subscription.Id, endTime); err != nil {
+		return err
+	}
+	if removed <= 0 {
+		return nil
+	}
+	if err = tx.ShiftSubscriptions(order.UserId, order.OrgId, subscription.EndTime, removed); err != nil {
+		return err
+	}
+
+	if vipExpireTime == nil {
+		return nil
+	}
+	expireTime := vipExpireTime.Add(-removed)
+	if !expireTime.After(now) {
+		vipStatus = model.VipStatusNormal
+	}
+
+	return s.updateVip(tx, order, vipStatus, &expireTime)
+}
+
+// refundCredit 扣除订单充值的余额, 余额已经被使用时不能退款
+func (s *PaymentService) refundCredit(tx *dao.PaymentTx, order *model.Order) error {
+	amount := int64(math.Round(order.OriginalAmount * 100))
+	balance, err := tx.FindBalanceForUpdate(order.UserId)
+	if err != nil {
+		return err
+	}
+	if balance < amount {
+		s.logger.Warnf("user %d balance %d is less than refund amount %d", order.UserId, balance, amount)
+		return ErrRefundNotAllowed
+	}
+
+	_, err = tx.AddBalance(order.UserId, -amount, model.CreditRefund, order.OrderNo)
+	return err
+}
+
+// refundPeriod 计算退款后订阅的结束时间和收回的时长
+// 未开始的订阅全部收回, 使用中的订阅在now结束, 已经结束的订阅不收回
+func refundPeriod(start, end, now time.Time) (time.Time, time.Duration) {
+	if !end.After(now) {
+		return end, 0
+	}
+	if start.Before(now) {
+		start = now
+	}
+
+	return start, end.Sub(start)
+}
+
 // ExpireSubscriptions 过期订阅检查（定时任务使用）
 func (s *PaymentService) ExpireSubscriptions() error {
 	// 过期订阅记录
@@ -493,16 +676,13 @@ func (s *PaymentService) ExpireSubscriptions() error {
 		return err
 	}
 
+	// 过期组织VIP
+	err = s.paymentDao.BatchExpireOrgVip()
+	if err != nil {
+		s.logger.Errorf("batch expire organization vip failed: %v", err)
+		return err
+	}
+
 	s.logger.Info("subscription expiration check completed")
 	return nil
 }
-
-// PaymentResult 支付结果
-type PaymentResult struct {
-	Code    int    `json:"code"`
-	Msg     string `json:"msg"`
-	TradeNo string `json:"trade_no"`
-	Price   string `json:"price"`
-	PayUrl  string `json:"payurl"`
-	QrCode  string `json:"qrcode"`
-} 
\ No newline at end of file
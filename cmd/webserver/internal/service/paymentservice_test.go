@@ -0,0 +1,30 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRefundPeriod(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.Local)
+	day := 24 * time.Hour
+	cases := []struct {
+		name       string
+		start, end time.Time
+		wantEnd    time.Time
+		removed    time.Duration
+	}{
+		// 使用中的订阅在now结束
+		{"active", now.Add(-day), now.Add(6 * day), now, 6 * day},
+		// 续期的订阅还未开始, 全部收回
+		{"renewal", now.Add(2 * day), now.Add(9 * day), now.Add(2 * day), 7 * day},
+		// 已经结束的订阅不收回
+		{"expired", now.Add(-7 * day), now.Add(-day), now.Add(-day), 0},
+	}
+	for _, c := range cases {
+		end, removed := refundPeriod(c.start, c.end, now)
+		if !end.Equal(c.wantEnd) || removed != c.removed {
+			t.Errorf("%s: refundPeriod = (%v, %v), want (%v, %v)", c.name, end, removed, c.wantEnd, c.removed)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/caches"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/conf"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+	pconf "github.com/mangohow/cloud-ide/pkg/conf"
+	"github.com/mangohow/cloud-ide/pkg/logger"
+	"github.com/mangohow/cloud-ide/pkg/pb"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// 普通用户的套餐, VIP用户的套餐为订阅类型
+const (
+	QuotaFree = "free"
+	// 有预付费余额的普通用户, 按量计费
+	QuotaCredit = "credit"
+)
+
+var (
+	ErrSpecNotAllowed       = errors.New("spec is not allowed by quota")
+	ErrStorageQuotaExceeded = errors.New("storage quota exceeded")
+	ErrRuntimeQuotaExceeded = errors.New("runtime quota exceeded")
+	ErrQuotaExceeded        = errors.New("quota exceeded")
+)
+
+// QuotaService 根据用户或组织的套餐检查资源配额, 组织的工作空间使用组织的套餐
+// 工作空间数量、规格和存储总量在创建时检查, 运行数量和运行时长在启动时检查,
+// 运行数量和存储总量最终由control-plane在创建或启动Workspace时再次检查
+type QuotaService struct {
+	logger       *logrus.Logger
+	subscription *SubscriptionService
+	runtime      *dao.SpaceRuntimeDao
+	credit       *dao.CreditDao
+	orgs         *dao.OrgDao
+	specCache    *caches.SpecCache
+}
+
+func NewQuotaService() *QuotaService {
+	return &QuotaService{
+		logger:       logger.Logger(),
+		subscription: NewSubscriptionService(),
+		runtime:      dao.NewSpaceRuntimeDao(),
+		credit:       dao.NewCreditDao(),
+		orgs:         dao.NewOrgDao(),
+		specCache:    caches.CacheFactory().SpecCache(dao.NewSpaceTemplateDao()),
+	}
+}
+
+// TierOf 获取用户的套餐, VIP用户不按量计费
+func (q *QuotaService) TierOf(userId uint32) string {
+	if !q.subscription.IsUserVip(userId) {
+		balance, err := q.credit.FindBalance(userId)
+		if err != nil {
+			q.logger.Warnf("find credit balance error:%v, userId:%d", err, userId)
+			return QuotaFree
+		}
+		if balance > 0 {
+			return QuotaCredit
+		}
+		return QuotaFree
+	}
+
+	// 管理员赠送的VIP没有订阅记录, 按照月卡处理
+	sub, err := q.subscription.GetActiveSubscription(userId)
+	if err != nil {
+		return model.ProductTypeMonth
+	}
+	if _, ok := conf.QuotaConfig[sub.SubscriptionType]; !ok {
+		return model.ProductTypeMonth
+	}
+
+	return sub.SubscriptionType
+}
+
+// OrgTierOf 获取组织的套餐, 组织没有预付费余额, 没有订阅时为免费套餐
+func (q *QuotaService) OrgTierOf(orgId uint32) string {
+	org, err := q.orgs.FindById(orgId)
+	if err != nil {
+		q.logger.Warnf("find organization error:%v, orgId:%d", err, orgId)
+		return QuotaFree
+	}
+	if org.VipStatus != model.VipStatusVip || org.VipExpireTime == nil || !org.VipExpireTime.After(time.Now()) {
+		return QuotaFree
+	}
+
+	sub, err := q.orgs.GetActiveSubscription(orgId)
+	if err != nil {
+		return model.ProductTypeMonth
+	}
+	if _, ok := conf.QuotaConfig[sub.SubscriptionType]; !ok {
+		return model.ProductTypeMonth
+	}
+
+	return sub.SubscriptionType
+}
+
+// TierOfOwner 获取工作空间所属用户或组织的套餐, orgId不为0时为组织的套餐
+func (q *QuotaService) TierOfOwner(userId, orgId uint32) string {
+	if orgId != 0 {
+		return q.OrgTierOf(orgId)
+	}
+
+	return q.TierOf(userId)
+}
+
+// QuotaOf 获取用户套餐的配额
+func (q *QuotaService) QuotaOf(userId uint32) pconf.QuotaConf {
+	return conf.QuotaConfig[q.TierOf(userId)]
+}
+
+// QuotaOfOwner 获取工作空间所属用户或组织的套餐的配额
+func (q *QuotaService) QuotaOfOwner(userId, orgId uint32) pconf.QuotaConf {
+	return conf.QuotaConfig[q.TierOfOwner(userId, orgId)]
+}
+
+// AllowSpec 检查用户的套餐是否可以使用该规格
+func (q *QuotaService) AllowSpec(userId, specId uint32) bool {
+	return allowSpec(q.QuotaOf(userId), specId)
+}
+
+func allowSpec(quota pconf.QuotaConf, specId uint32) bool {
+	if len(quota.SpecIds) == 0 {
+		return true
+	}
+	for _, id := range quota.SpecIds {
+		if id == specId {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckSpaces 检查用户在已有工作空间的基础上再创建一个spec规格的工作空间是否会超过配额
+// specIds为已有工作空间的规格
+func (q *QuotaService) CheckSpaces(quota pconf.QuotaConf, specIds []uint32, spec *model.SpaceSpec) error {
+	if quota.MaxSpaces > 0 && uint32(len(specIds)) >= quota.MaxSpaces {
+		return ErrReachMaxSpaceCount
+	}
+
+	return q.CheckSpec(quota, specIds, spec)
+}
+
+// CheckSpec 检查用户的套餐是否可以使用spec规格, 以及加上其它工作空间的存储后是否超过存储总量
+// specIds为用户其它工作空间的规格
+func (q *QuotaService) CheckSpec(quota pconf.QuotaConf, specIds []uint32, spec *model.SpaceSpec) error {
+	if !allowSpec(quota, spec.Id) {
+		return ErrSpecNotAllowed
+	}
+	if quota.MaxStorage == "" {
+		return nil
+	}
+
+	storages := make([]string, 0, len(specIds)+1)
+	for _, id := range specIds {
+		if s := q.specCache.Get(id); s != nil {
+			storages = append(storages, s.StorageSpec)
+		}
+	}
+	storages = append(storages, spec.StorageSpec)
+
+	return checkStorage(quota.MaxStorage, storages)
+}
+
+// checkStorage 检查存储总量是否超过了限制, 无法解析的存储规格不计算在内
+func checkStorage(maxStorage string, storages []string) error {
+	max, err := resource.ParseQuantity(maxStorage)
+	if err != nil {
+		return nil
+	}
+
+	var used resource.Quantity
+	for _, storage := range storages {
+		if s, err := resource.ParseQuantity(storage); err == nil {
+			used.Add(s)
+		}
+	}
+	if used.Cmp(max) > 0 {
+		return ErrStorageQuotaExceeded
+	}
+
+	return nil
+}
+
+// rpcQuota 转换为control-plane检查的配额
+func rpcQuota(quota pconf.QuotaConf) *pb.UserQuota {
+	return &pb.UserQuota{
+		MaxRunning: int32(quota.MaxRunning),
+		MaxStorage: quota.MaxStorage,
+	}
+}
+
+// RemainingRuntime 获取用户或组织本月剩余的运行时长(秒), 0表示不限制
+func (q *QuotaService) RemainingRuntime(userId, orgId uint32, quota pconf.QuotaConf) (int64, error) {
+	if quota.MonthlyHours == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	used, err := q.runtime.SumSecondsSince(userId, orgId, monthStart, now)
+	if err != nil {
+		q.logger.Errorf("sum runtime error:%v, userId:%d, orgId:%d", err, userId, orgId)
+		return 0, err
+	}
+
+	remaining := int64(quota.MonthlyHours)*3600 - used
+	if remaining <= 0 {
+		return 0, ErrRuntimeQuotaExceeded
+	}
+
+	return remaining, nil
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"testing"
+
+	pconf "github.com/mangohow/cloud-ide/pkg/conf"
+)
+
+func TestAllowSpec(t *testing.T) {
+	if !allowSpec(pconf.QuotaConf{}, 3) {
+		t.Errorf("empty spec ids should allow all specs")
+	}
+	quota := pconf.QuotaConf{SpecIds: []uint32{4}}
+	if !allowSpec(quota, 4) || allowSpec(quota, 3) {
+		t.Errorf("allowSpec with spec ids %v is wrong", quota.SpecIds)
+	}
+}
+
+func TestCheckStorage(t *testing.T) {
+	cases := []struct {
+		max      string
+		storages []string
+		err      error
+	}{
+		{"8Gi", []string{"4Gi", "4Gi"}, nil},
+		{"8Gi", []string{"4Gi", "4Gi", "1Gi"}, ErrStorageQuotaExceeded},
+		{"1Ti", []string{"512Gi", "512Gi"}, nil},
+		{"8Gi", []string{"invalid", "8Gi"}, nil},
+		{"invalid", []string{"100Gi"}, nil},
+	}
+	for _, c := range cases {
+		if err := checkStorage(c.max, c.storages); err != c.err {
+			t.Errorf("checkStorage(%s, %v) = %v, want %v", c.max, c.storages, err, c.err)
+		}
+	}
+}
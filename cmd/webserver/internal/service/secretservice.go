@@ -0,0 +1,365 @@
+package service
+
+import (
+	"crypto/subtle"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/conf"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model/reqtype"
+	"github.com/mangohow/cloud-ide/pkg/logger"
+	"github.com/mangohow/cloud-ide/pkg/utils/encrypt"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// MaxSecretCount 每个用户最多保存的密钥数量
+	MaxSecretCount = 20
+	// MaxSecretValueLen 密钥值的最大长度
+	MaxSecretValueLen = 4096
+)
+
+var (
+	ErrSecretNotConfigured = errors.New("secret key is not configured")
+	ErrSecretInvalid       = errors.New("secret invalid")
+	ErrSecretNameDuplicate = errors.New("secret name duplicate")
+	ErrSecretNotFound      = errors.New("secret not found")
+	ErrReachMaxSecretCount = errors.New("reach max secret count")
+	ErrSecretOperation     = errors.New("secret operation failed")
+)
+
+var (
+	secretNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+	// 环境变量名称必须是C_IDENTIFIER
+	envNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,63}$`)
+)
+
+// mysql唯一索引冲突的错误码
+const mysqlErrDuplicateEntry = 1062
+
+type SecretService struct {
+	logger   *logrus.Logger
+	dao      *dao.SecretDao
+	envelope *encrypt.Envelope
+}
+
+func NewSecretService() *SecretService {
+	s := &SecretService{
+		logger: logger.Logger(),
+		dao:    dao.NewSecretDao(),
+	}
+
+	// 没有配置主密钥时不能使用密钥功能
+	if conf.SecretConfig.Key != "" {
+		envelope, err := encrypt.NewEnvelopeFromBase64(conf.SecretConfig.Key)
+		if err != nil {
+			s.logger.Errorf("secret key invalid:%v", err)
+		}
+		s.envelope = envelope
+	}
+
+	return s
+}
+
+// CreateSecret 加密保存用户的密钥, 返回的密钥不包含明文
+func (s *SecretService) CreateSecret(req *reqtype.SecretCreateOption, userId uint32) (*model.UserSecret, error) {
+	if s.envelope == nil {
+		return nil, ErrSecretNotConfigured
+	}
+	if !secretNameRegexp.MatchString(req.Name) || !envNameRegexp.MatchString(req.EnvName) || !validSecretValue(req.Value) {
+		return nil, ErrSecretInvalid
+	}
+
+	count, err := s.dao.FindCountByUserId(userId)
+	if err != nil {
+		s.logger.Warnf("get secret count error:%v", err)
+		return nil, ErrSecretOperation
+	}
+	if count >= MaxSecretCount {
+		return nil, ErrReachMaxSecretCount
+	}
+
+	now := time.Now()
+	secret := &model.UserSecret{
+		UserId:     userId,
+		Name:       req.Name,
+		EnvName:    req.EnvName,
+		Desc:       req.Desc,
+		CreateTime: now,
+		UpdateTime: now,
+	}
+	if err = s.seal(secret, req.Value); err != nil {
+		return nil, ErrSecretOperation
+	}
+
+	secret.Id, err = s.dao.Insert(secret)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDuplicateEntry {
+			return nil, ErrSecretNameDuplicate
+		}
+		s.logger.Errorf("add secret error:%v", err)
+		return nil, ErrSecretOperation
+	}
+
+	return secret, nil
+}
+
+// ListSecrets 获取用户的所有密钥, 不包含明文
+func (s *SecretService) ListSecrets(userId uint32) ([]model.UserSecret, error) {
+	secrets, err := s.dao.FindByUserId(userId)
+	if err != nil {
+		s.logger.Warnf("find secrets error:%v", err)
+		return nil, ErrSecretOperation
+	}
+
+	return secrets, nil
+}
+
+// UpdateSecret 更新密钥的值和描述, 已经运行的工作空间在下次启动时生效
+func (s *SecretService) UpdateSecret(req *reqtype.SecretUpdateOption, userId uint32) (*model.UserSecret, error) {
+	if s.envelope == nil {
+		return nil, ErrSecretNotConfigured
+	}
+	if !validSecretValue(req.Value) {
+		return nil, ErrSecretInvalid
+	}
+
+	secret, err := s.dao.FindByIdAndUserId(req.Id, userId)
+	if err != nil {
+		s.logger.Warnf("find secret error:%v", err)
+		return nil, ErrSecretNotFound
+	}
+
+	secret.Desc = req.Desc
+	secret.UpdateTime = time.Now()
+	if err = s.seal(secret, req.Value); err != nil {
+		return nil, ErrSecretOperation
+	}
+	if err = s.dao.UpdateValueById(secret); err != nil {
+		s.logger.Errorf("update secret error:%v", err)
+		return nil, ErrSecretOperation
+	}
+
+	return secret, nil
+}
+
+// DeleteSecret 删除密钥, 同时解除与工作空间的关联
+func (s *SecretService) DeleteSecret(id, userId uint32) error {
+	if _, err := s.dao.FindByIdAndUserId(id, userId); err != nil {
+		s.logger.Warnf("find secret error:%v", err)
+		return ErrSecretNotFound
+	}
+
+	if err := s.dao.DeleteById(id); err != nil {
+		s.logger.Errorf("delete secret error:%v", err)
+		return ErrSecretOperation
+	}
+
+	return nil
+}
+
+// FindSecrets 根据名称查询用户的密钥, 有任意一个不存在时返回ErrSecretNotFound
+func (s *SecretService) FindSecrets(userId uint32, names []string) ([]model.UserSecret, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	secrets, err := s.dao.FindByNames(userId, names)
+	if err != nil {
+		s.logger.Warnf("find secrets error:%v", err)
+		return nil, ErrSecretOperation
+	}
+
+	found := make(map[string]bool, len(secrets))
+	for _, secret := range secrets {
+		found[secret.Name] = true
+	}
+	for _, name := range names {
+		if !found[name] {
+			return nil, ErrSecretNotFound
+		}
+	}
+
+	return secrets, nil
+}
+
+// AttachSecrets 将密钥关联到工作空间
+func (s *SecretService) AttachSecrets(spaceId uint32, secrets []model.UserSecret) error {
+	if len(secrets) == 0 {
+		return nil
+	}
+
+	ids := make([]uint32, 0, len(secrets))
+	for _, secret := range secrets {
+		ids = append(ids, secret.Id)
+	}
+	if err := s.dao.AttachToSpace(spaceId, ids); err != nil {
+		s.logger.Errorf("attach secrets error:%v", err)
+		return ErrSecretOperation
+	}
+
+	return nil
+}
+
+// ResolveSpaceSecrets 解密工作空间关联的所有密钥, 返回环境变量名称到值的映射
+func (s *SecretService) ResolveSpaceSecrets(spaceId uint32) (map[string]string, error) {
+	secrets, err := s.dao.FindBySpaceId(spaceId)
+	if err != nil {
+		s.logger.Warnf("find space secrets error:%v", err)
+		return nil, ErrSecretOperation
+	}
+	if len(secrets) == 0 {
+		return nil, nil
+	}
+	if s.envelope == nil {
+		return nil, ErrSecretNotConfigured
+	}
+
+	env := make(map[string]string, len(secrets))
+	for _, secret := range secrets {
+		value, err := s.envelope.Open(secret.Ciphertext, secret.DataKey)
+		if err != nil {
+			s.logger.Errorf("decrypt secret error:%v, id:%d", err, secret.Id)
+			return nil, ErrSecretOperation
+		}
+		env[secret.EnvName] = string(value)
+	}
+
+	return env, nil
+}
+
+// SaveEnvSecrets 将创建工作空间时填写的敏感环境变量加密保存为用户的密钥, 返回需要关联到工作空间的密钥
+// 用户已有环境变量名称和值都相同的密钥时直接复用, 否则以环境变量名称和sid为名称新建密钥
+func (s *SecretService) SaveEnvSecrets(userId uint32, sid string, env map[string]string) ([]model.UserSecret, error) {
+	if len(env) == 0 {
+		return nil, nil
+	}
+	if s.envelope == nil {
+		return nil, ErrSecretNotConfigured
+	}
+
+	names := make([]string, 0, len(env))
+	for name, value := range env {
+		if !envNameRegexp.MatchString(name) || !validSecretValue(value) {
+			return nil, ErrSecretInvalid
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	existing, err := s.dao.FindByEnvNames(userId, names)
+	if err != nil {
+		s.logger.Warnf("find secrets error:%v", err)
+		return nil, ErrSecretOperation
+	}
+	count, err := s.dao.FindCountByUserId(userId)
+	if err != nil {
+		s.logger.Warnf("get secret count error:%v", err)
+		return nil, ErrSecretOperation
+	}
+
+	secrets := make([]model.UserSecret, 0, len(names))
+	for _, name := range names {
+		if secret, ok := s.findSameSecret(existing, name, env[name]); ok {
+			secrets = append(secrets, secret)
+			continue
+		}
+
+		if count >= MaxSecretCount {
+			return nil, ErrReachMaxSecretCount
+		}
+		now := time.Now()
+		secret := model.UserSecret{
+			UserId:     userId,
+			Name:       envSecretName(name, sid),
+			EnvName:    name,
+			Desc:       "创建工作空间" + sid + "时保存",
+			CreateTime: now,
+			UpdateTime: now,
+		}
+		if err = s.seal(&secret, env[name]); err != nil {
+			return nil, ErrSecretOperation
+		}
+		secret.Id, err = s.dao.Insert(&secret)
+		if err != nil {
+			var mysqlErr *mysql.MySQLError
+			if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDuplicateEntry {
+				return nil, ErrSecretNameDuplicate
+			}
+			s.logger.Errorf("add secret error:%v", err)
+			return nil, ErrSecretOperation
+		}
+		count++
+		secrets = append(secrets, secret)
+	}
+
+	return secrets, nil
+}
+
+// findSameSecret 在密钥中查找环境变量名称和值都相同的密钥
+func (s *SecretService) findSameSecret(secrets []model.UserSecret, envName, value string) (model.UserSecret, bool) {
+	for _, secret := range secrets {
+		if secret.EnvName != envName {
+			continue
+		}
+		plain, err := s.envelope.Open(secret.Ciphertext, secret.DataKey)
+		if err != nil {
+			s.logger.Warnf("decrypt secret error:%v, id:%d", err, secret.Id)
+			continue
+		}
+		if subtle.ConstantTimeCompare(plain, []byte(value)) == 1 {
+			return secret, true
+		}
+	}
+
+	return model.UserSecret{}, false
+}
+
+func (s *SecretService) seal(secret *model.UserSecret, value string) error {
+	ciphertext, dataKey, err := s.envelope.Seal([]byte(value))
+	if err != nil {
+		s.logger.Errorf("encrypt secret error:%v", err)
+		return err
+	}
+
+	secret.Ciphertext = ciphertext
+	secret.DataKey = dataKey
+	secret.Hint = secretHint(value)
+	return nil
+}
+
+// envSecretName 根据环境变量名称和sid生成密钥名称, 例如 openai_api_key-1a2b3c4d
+func envSecretName(envName, sid string) string {
+	name := strings.ToLower(envName)
+	if len(name) > 23 {
+		name = name[:23]
+	}
+	if len(sid) > 8 {
+		sid = sid[len(sid)-8:]
+	}
+
+	return name + "-" + sid
+}
+
+func validSecretValue(value string) bool {
+	return strings.TrimSpace(value) != "" && len(value) <= MaxSecretValueLen
+}
+
+// secretHint 对密钥脱敏, 只保留开头和结尾的少量字符
+func secretHint(value string) string {
+	if len(value) <= 8 {
+		return strings.Repeat("*", len(value))
+	}
+	if len(value) <= 16 {
+		return value[:2] + "****" + value[len(value)-2:]
+	}
+
+	return value[:4] + "****" + value[len(value)-4:]
+}
@@ -0,0 +1,16 @@
+package service
+
+import "testing"
+
+func TestSecretHint(t *testing.T) {
+	cases := map[string]string{
+		"abc":                     "***",
+		"sk-12345678":             "sk****78",
+		"sk-ant-api03-1234567890": "sk-a****7890",
+	}
+	for value, want := range cases {
+		if got := secretHint(value); got != want {
+			t.Fatalf("secretHint(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
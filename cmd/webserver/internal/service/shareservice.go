@@ -0,0 +1,221 @@
+package service
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/conf"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model/reqtype"
+	"github.com/mangohow/cloud-ide/pkg/logger"
+	"github.com/mangohow/cloud-ide/pkg/utils/encrypt"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// MaxShareCount 每个工作空间同时有效的分享链接数量
+	MaxShareCount = 10
+	// 查询访问记录的最大数量
+	shareAccessLimit = 100
+)
+
+var (
+	ErrShareNotConfigured = errors.New("share is not configured")
+	ErrShareInvalid       = errors.New("share param invalid")
+	ErrShareNotFound      = errors.New("share not found")
+	ErrReachMaxShareCount = errors.New("reach max share count")
+	ErrShareTokenInvalid  = errors.New("share token invalid")
+)
+
+// ShareService 工作空间的分享链接, 令牌由网关在代理前调用Verify验证
+type ShareService struct {
+	logger   *logrus.Logger
+	dao      *dao.ShareDao
+	spaceDao *dao.SpaceDao
+	orgs     *OrgService
+	key      []byte
+}
+
+func NewShareService() *ShareService {
+	return &ShareService{
+		logger:   logger.Logger(),
+		dao:      dao.NewShareDao(),
+		spaceDao: dao.NewSpaceDao(),
+		orgs:     NewOrgService(),
+		key:      []byte(conf.ShareConfig.Key),
+	}
+}
+
+// CreateShare 创建分享链接, 只有工作空间的所有者和组织的管理员可以分享
+func (s *ShareService) CreateShare(req *reqtype.ShareCreateOption, userId uint32) (*model.SpaceShare, error) {
+	if len(s.key) == 0 {
+		return nil, ErrShareNotConfigured
+	}
+	if req.Permission == "" {
+		req.Permission = model.SharePermFull
+	}
+	if !validShareOption(req.Permission, req.Hours, conf.ShareConfig.MaxHours) {
+		return nil, ErrShareInvalid
+	}
+	space, err := s.findSpace(req.Id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.dao.FindActiveCountBySpaceId(space.Id)
+	if err != nil {
+		s.logger.Errorf("get share count error:%v, space:%d", err, space.Id)
+		return nil, err
+	}
+	if count >= MaxShareCount {
+		return nil, ErrReachMaxShareCount
+	}
+
+	now := time.Now()
+	share := &model.SpaceShare{
+		SpaceId:    space.Id,
+		Sid:        space.Sid,
+		UserId:     userId,
+		Permission: req.Permission,
+		Status:     model.ShareStatusActive,
+		ExpireTime: now.Add(time.Duration(req.Hours) * time.Hour),
+		CreateTime: now,
+	}
+	if share.Id, err = s.dao.Insert(share); err != nil {
+		s.logger.Errorf("insert share error:%v, space:%d", err, space.Id)
+		return nil, err
+	}
+
+	token, err := encrypt.CreateShareToken(s.key, share.Id, share.Sid, share.Permission, share.ExpireTime)
+	if err != nil {
+		s.logger.Errorf("create share token error:%v", err)
+		return nil, err
+	}
+	share.Url = shareUrl(conf.WorkspaceConfig.Domain, share.Sid, token)
+	s.logger.Infof("share %d of space %d created by user %d, permission:%s", share.Id, space.Id, userId, share.Permission)
+
+	return share, nil
+}
+
+// ListShares 查询工作空间有效的分享链接, 链接只在创建时返回
+func (s *ShareService) ListShares(spaceId, userId uint32) ([]model.SpaceShare, error) {
+	if _, err := s.findSpace(spaceId, userId); err != nil {
+		return nil, err
+	}
+
+	shares, err := s.dao.FindActiveBySpaceId(spaceId)
+	if err != nil {
+		s.logger.Errorf("find shares error:%v, space:%d", err, spaceId)
+		return nil, err
+	}
+
+	return shares, nil
+}
+
+// RevokeShare 撤销分享链接, 网关缓存的验证结果过期后失效
+func (s *ShareService) RevokeShare(id, userId uint32) error {
+	share, err := s.dao.FindById(id)
+	if err != nil {
+		return ErrShareNotFound
+	}
+	if _, err = s.findSpace(share.SpaceId, userId); err != nil {
+		return err
+	}
+
+	if err = s.dao.Revoke(id); err != nil {
+		s.logger.Errorf("revoke share error:%v, share:%d", err, id)
+		return err
+	}
+	s.logger.Infof("share %d revoked by user %d", id, userId)
+
+	return nil
+}
+
+// ListAccess 查询工作空间的分享链接最近的访问记录
+func (s *ShareService) ListAccess(spaceId, userId uint32) ([]model.ShareAccess, error) {
+	if _, err := s.findSpace(spaceId, userId); err != nil {
+		return nil, err
+	}
+
+	records, err := s.dao.FindAccessBySpaceId(spaceId, shareAccessLimit)
+	if err != nil {
+		s.logger.Errorf("find share access error:%v, space:%d", err, spaceId)
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// Verify 验证访问sid的分享令牌, 返回分享链接
+// 除了令牌的签名和有效期, 还检查链接是否被撤销以及创建者是否仍然可以管理该工作空间
+// access不为nil时记录访问
+func (s *ShareService) Verify(token, sid string, access *model.ShareAccess) (*model.SpaceShare, error) {
+	if len(s.key) == 0 {
+		return nil, ErrShareTokenInvalid
+	}
+	claims, err := encrypt.VerifyShareToken(s.key, token)
+	if err != nil || claims.Sid != sid {
+		return nil, ErrShareTokenInvalid
+	}
+
+	share, err := s.dao.FindById(claims.ShareId)
+	if err != nil || share.Sid != sid || share.Status != model.ShareStatusActive || share.Permission != model.SharePermFull ||
+		!share.ExpireTime.After(time.Now()) {
+		return nil, ErrShareTokenInvalid
+	}
+	if _, err = s.findSpace(share.SpaceId, share.UserId); err != nil {
+		return nil, ErrShareTokenInvalid
+	}
+
+	if access != nil {
+		access.ShareId = share.Id
+		access.Sid = share.Sid
+		access.Permission = share.Permission
+		access.AccessTime = time.Now()
+		if err = s.dao.InsertAccess(access); err != nil {
+			s.logger.Errorf("insert share access error:%v, share:%d", err, share.Id)
+		}
+	}
+
+	return share, nil
+}
+
+// findSpace 查询用户可以分享的工作空间, 个人工作空间只有创建者可以分享, 组织的工作空间需要管理权限
+func (s *ShareService) findSpace(spaceId, userId uint32) (*model.Space, error) {
+	space, err := s.spaceDao.FindById(spaceId)
+	if err != nil || space.Status == model.SpaceStatusDeleted {
+		return nil, ErrWorkSpaceNotExist
+	}
+	if space.OrgId == 0 {
+		if space.UserId != userId {
+			return nil, ErrWorkSpaceNotExist
+		}
+		return space, nil
+	}
+
+	if _, err = s.orgs.Authorize(space.OrgId, userId, OrgActionManage); err != nil {
+		if err == ErrOrgNotFound {
+			return nil, ErrWorkSpaceNotExist
+		}
+		return nil, err
+	}
+
+	return space, nil
+}
+
+// validShareOption 检查分享链接的权限和有效期
+func validShareOption(permission string, hours, maxHours uint32) bool {
+	if permission != model.SharePermFull {
+		return false
+	}
+
+	return hours > 0 && hours <= maxHours
+}
+
+// shareUrl 生成分享链接, 每个工作空间使用独立的源 <sid>.<domain>, 网关验证share_token后写入cookie
+func shareUrl(domain, sid, token string) string {
+	return "https://" + sid + "." + strings.Trim(domain, "./") + "/?share_token=" + url.QueryEscape(token)
+}
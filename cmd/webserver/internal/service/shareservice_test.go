@@ -0,0 +1,35 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+)
+
+func TestValidShareOption(t *testing.T) {
+	cases := []struct {
+		permission string
+		hours      uint32
+		valid      bool
+	}{
+		{model.SharePermFull, 1, true},
+		{model.SharePermFull, 168, true},
+		{model.SharePermFull, 0, false},
+		{model.SharePermFull, 169, false},
+		{"read", 1, false},
+		{"write", 1, false},
+	}
+	for _, c := range cases {
+		if got := validShareOption(c.permission, c.hours, 168); got != c.valid {
+			t.Errorf("validShareOption(%q, %d) = %v, want %v", c.permission, c.hours, got, c.valid)
+		}
+	}
+}
+
+func TestShareUrl(t *testing.T) {
+	got := shareUrl("ws.example.com", "abc", "a.b+c")
+	want := "https://abc.ws.example.com/?share_token=a.b%2Bc"
+	if got != want {
+		t.Errorf("shareUrl = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/rpc"
+	"github.com/mangohow/cloud-ide/pkg/logger"
+	"github.com/mangohow/cloud-ide/pkg/pb"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MaxSnapshotCount 每个工作空间最多保存的快照数量
+const MaxSnapshotCount = 10
+
+var (
+	ErrSnapshotCreate        = errors.New("snapshot create failed")
+	ErrSnapshotRestore       = errors.New("snapshot restore failed")
+	ErrSnapshotNotFound      = errors.New("snapshot not found")
+	ErrSnapshotNotReady      = errors.New("snapshot is not ready")
+	ErrSnapshotNotSupported  = errors.New("snapshot is not supported")
+	ErrReachMaxSnapshotCount = errors.New("reach max snapshot count")
+)
+
+// control-plane返回的错误信息, 用于区分同一错误码下的不同原因
+const (
+	rpcMsgWorkspaceIsRunning = "workspace is running"
+	rpcMsgSnapshotNotExist   = "snapshot not exist"
+)
+
+type SnapshotService struct {
+	logger   *logrus.Logger
+	rpc      pb.CloudIdeServiceClient
+	dao      *dao.SnapshotDao
+	spaceDao *dao.SpaceDao
+}
+
+func NewSnapshotService() *SnapshotService {
+	conn := rpc.GrpcClient("space-code")
+	return &SnapshotService{
+		logger:   logger.Logger(),
+		rpc:      pb.NewCloudIdeServiceClient(conn),
+		dao:      dao.NewSnapshotDao(),
+		spaceDao: dao.NewSpaceDao(),
+	}
+}
+
+// CreateSnapshot 为工作空间创建快照
+func (s *SnapshotService) CreateSnapshot(spaceId, userId uint32, uid, desc string) (*model.SpaceSnapshot, error) {
+	// 1、查询工作空间并确保该工作空间是属于该用户的
+	space, err := s.findSpace(spaceId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2、验证快照是否达到最大数量
+	count, err := s.dao.FindCountBySpaceId(spaceId)
+	if err != nil {
+		s.logger.Warnf("get snapshot count error:%v", err)
+		return nil, ErrSnapshotCreate
+	}
+	if count >= MaxSnapshotCount {
+		return nil, ErrReachMaxSnapshotCount
+	}
+
+	// 3、请求k8s controller创建快照
+	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancelFunc()
+	resp, err := s.rpc.SnapshotSpace(ctx, &pb.RequestSnapshot{
+		Sid: space.Sid,
+		Uid: uid,
+	})
+	if err != nil {
+		s.logger.Warnf("snapshot workspace err:%v, sid:%s", err, space.Sid)
+		st := status.Convert(err)
+		switch {
+		case st.Code() == codes.NotFound:
+			return nil, ErrWorkSpaceNotExist
+		case st.Code() == codes.FailedPrecondition && st.Message() == rpcMsgWorkspaceIsRunning:
+			return nil, ErrWorkSpaceIsRunning
+		case st.Code() == codes.FailedPrecondition:
+			return nil, ErrSnapshotNotSupported
+		}
+		return nil, ErrSnapshotCreate
+	}
+
+	// 4、记录到数据库
+	snapshot := &model.SpaceSnapshot{
+		SpaceId:    spaceId,
+		UserId:     userId,
+		Name:       resp.Snapshot.Name,
+		Desc:       desc,
+		Method:     resp.Snapshot.Method,
+		Status:     model.SnapshotStatusPending,
+		CreateTime: time.Now(),
+	}
+	snapshot.Id, err = s.dao.Insert(snapshot)
+	if err != nil {
+		s.logger.Errorf("add snapshot error:%v", err)
+		return nil, ErrSnapshotCreate
+	}
+
+	return snapshot, nil
+}
+
+// ListSnapshots 获取工作空间的所有快照, 并同步创建中的快照的状态
+func (s *SnapshotService) ListSnapshots(spaceId, userId uint32, uid string) ([]model.SpaceSnapshot, error) {
+	space, err := s.findSpace(spaceId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := s.dao.FindBySpaceId(spaceId, userId)
+	if err != nil {
+		s.logger.Warnf("find snapshots error:%v", err)
+		return nil, err
+	}
+
+	pending := false
+	for _, item := range snapshots {
+		if item.Status == model.SnapshotStatusPending {
+			pending = true
+			break
+		}
+	}
+	if !pending {
+		return snapshots, nil
+	}
+
+	// 从k8s controller获取最新状态, 失败时返回数据库中的记录
+	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancelFunc()
+	resp, err := s.rpc.ListSnapshots(ctx, &pb.RequestListSnapshots{
+		Sid: space.Sid,
+		Uid: uid,
+	})
+	if err != nil {
+		s.logger.Warnf("list snapshots err:%v, sid:%s", err, space.Sid)
+		return snapshots, nil
+	}
+
+	phases := make(map[string]*pb.Snapshot, len(resp.Snapshots))
+	for _, item := range resp.Snapshots {
+		phases[item.Name] = item
+	}
+	for i, item := range snapshots {
+		snap, ok := phases[item.Name]
+		if item.Status != model.SnapshotStatusPending || !ok || snap.Phase == pb.Snapshot_Pending {
+			continue
+		}
+
+		st := uint32(model.SnapshotStatusReady)
+		if snap.Phase == pb.Snapshot_Failed {
+			st = model.SnapshotStatusFailed
+		}
+		if err := s.dao.UpdateStatusById(item.Id, st, snap.Message); err != nil {
+			s.logger.Warnf("update snapshot status error:%v", err)
+			continue
+		}
+		snapshots[i].Status = st
+		snapshots[i].Message = snap.Message
+	}
+
+	return snapshots, nil
+}
+
+// RestoreSnapshot 将已停止的工作空间恢复到指定快照, 下次启动时生效
+func (s *SnapshotService) RestoreSnapshot(spaceId, snapshotId, userId uint32, uid string) error {
+	space, err := s.findSpace(spaceId, userId)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := s.dao.FindByIdAndUserId(snapshotId, userId)
+	if err != nil || snapshot.SpaceId != spaceId {
+		s.logger.Warnf("find snapshot error:%v", err)
+		return ErrSnapshotNotFound
+	}
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*60)
+	defer cancelFunc()
+	_, err = s.rpc.RestoreSpace(ctx, &pb.RequestRestore{
+		Sid:      space.Sid,
+		Uid:      uid,
+		Snapshot: snapshot.Name,
+	})
+	if err != nil {
+		s.logger.Warnf("restore workspace err:%v, sid:%s", err, space.Sid)
+		st := status.Convert(err)
+		switch {
+		case st.Code() == codes.NotFound && st.Message() == rpcMsgSnapshotNotExist:
+			return ErrSnapshotNotFound
+		case st.Code() == codes.NotFound:
+			return ErrWorkSpaceNotExist
+		case st.Code() == codes.FailedPrecondition && st.Message() == rpcMsgWorkspaceIsRunning:
+			return ErrWorkSpaceIsRunning
+		case st.Code() == codes.FailedPrecondition:
+			return ErrSnapshotNotReady
+		}
+		return ErrSnapshotRestore
+	}
+
+	return nil
+}
+
+func (s *SnapshotService) findSpace(spaceId, userId uint32) (*model.Space, error) {
+	space, err := s.spaceDao.FindByIdAndUserId(spaceId, userId)
+	if err != nil {
+		s.logger.Warnf("find space error:%v", err)
+		return nil, ErrWorkSpaceNotExist
+	}
+	// 未创建的工作空间没有存储卷
+	if space.Status != model.SpaceStatusAvailable {
+		return nil, ErrWorkSpaceNotExist
+	}
+
+	return space, nil
+}
@@ -1,12 +1,25 @@
 package service
 
 import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/caches"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model/reqtype"
+	"github.com/mangohow/cloud-ide/pkg/logger"
+	"github.com/mangohow/cloud-ide/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 type SpaceTmplService struct {
+	logger    *logrus.Logger
 	dao       *dao.SpaceTemplateDao
 	tmplCache *caches.TmplCache
 	specCache *caches.SpecCache
@@ -15,6 +28,7 @@ type SpaceTmplService struct {
 func NewSpaceTmplService() *SpaceTmplService {
 	d := dao.NewSpaceTemplateDao()
 	return &SpaceTmplService{
+		logger:    logger.Logger(),
 		dao:       d,
 		tmplCache: caches.CacheFactory().TmplCache(d),
 		specCache: caches.CacheFactory().SpecCache(d),
@@ -33,3 +47,456 @@ func (s *SpaceTmplService) GetAllUsingTmpl() ([]*model.SpaceTemplate, []*model.T
 func (s *SpaceTmplService) GetAllSpec() ([]*model.SpaceSpec, error) {
 	return s.specCache.GetAll(), nil
 }
+
+var (
+	ErrTmplNotFound  = errors.New("template not found")
+	ErrTmplInvalid   = errors.New("template invalid")
+	ErrKindNotFound  = errors.New("template kind not found")
+	ErrKindInvalid   = errors.New("template kind invalid")
+	ErrKindInUse     = errors.New("template kind in use")
+	ErrSpecNotFound  = errors.New("spec not found")
+	ErrSpecInvalid   = errors.New("spec invalid")
+	ErrTmplOperation = errors.New("template operation failed")
+)
+
+var (
+	// 与control-plane中初始化容器名称的规则一致
+	initStepNameRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+	// control-plane在release模式下为工作空间请求的资源, 规格的限制不能小于请求
+	minSpecCpu    = resource.MustParse("2")
+	minSpecMemory = resource.MustParse("1Gi")
+)
+
+// 数据库中模板启动配置字段的最大长度
+const (
+	maxTmplCommandLen   = 512
+	maxTmplArgsLen      = 1024
+	maxTmplEnvLen       = 2048
+	maxTmplPathLen      = 256
+	maxTmplInitStepsLen = 4096
+	maxInitStepNameLen  = 50
+)
+
+// GetAllTmplForAdmin 获取所有模板, 包括已下架的模板(管理员使用)
+func (s *SpaceTmplService) GetAllTmplForAdmin() ([]model.AdminTemplate, error) {
+	tmpls, err := s.dao.GetAllTmpl()
+	if err != nil {
+		s.logger.Warnf("get all tmpl error:%v", err)
+		return nil, ErrTmplOperation
+	}
+
+	result := make([]model.AdminTemplate, 0, len(tmpls))
+	for _, tmpl := range tmpls {
+		launch, err := tmplLaunchOf(&tmpl)
+		if err != nil {
+			s.logger.Warnf("unmarshal template launch error:%v, tmpl:%d", err, tmpl.Id)
+		}
+		result = append(result, model.AdminTemplate{SpaceTemplate: tmpl, Launch: launch})
+	}
+
+	return result, nil
+}
+
+// CreateTmpl 创建模板, 创建后立即可用
+func (s *SpaceTmplService) CreateTmpl(req *reqtype.TmplOption) (*model.SpaceTemplate, error) {
+	tmpl, err := s.tmplFromOption(req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	tmpl.Status = dao.TmplUsing
+	tmpl.CreateTime = now
+	tmpl.DeleteTime = now
+	tmpl.Id, err = s.dao.InsertTmpl(tmpl)
+	if err != nil {
+		s.logger.Errorf("insert tmpl error:%v", err)
+		return nil, ErrTmplOperation
+	}
+	s.invalidate(caches.TmplCacheName)
+
+	return tmpl, nil
+}
+
+// UpdateTmpl 修改模板, 已经运行的工作空间在下次启动时生效
+func (s *SpaceTmplService) UpdateTmpl(req *reqtype.TmplOption) (*model.SpaceTemplate, error) {
+	old, err := s.dao.FindTmplById(req.Id)
+	if err != nil {
+		s.logger.Warnf("find tmpl error:%v, id:%d", err, req.Id)
+		return nil, ErrTmplNotFound
+	}
+
+	tmpl, err := s.tmplFromOption(req)
+	if err != nil {
+		return nil, err
+	}
+	tmpl.Id = old.Id
+	tmpl.Status = old.Status
+	tmpl.CreateTime = old.CreateTime
+	tmpl.DeleteTime = old.DeleteTime
+	if err = s.dao.UpdateTmpl(tmpl); err != nil {
+		s.logger.Errorf("update tmpl error:%v", err)
+		return nil, ErrTmplOperation
+	}
+	s.invalidate(caches.TmplCacheName)
+
+	return tmpl, nil
+}
+
+// DeleteTmpl 删除模板, 只做软删除, 使用该模板创建的工作空间仍然可以启动
+func (s *SpaceTmplService) DeleteTmpl(id uint32) error {
+	if _, err := s.dao.FindTmplById(id); err != nil {
+		s.logger.Warnf("find tmpl error:%v, id:%d", err, id)
+		return ErrTmplNotFound
+	}
+
+	if err := s.dao.DeleteTmpl(id, time.Now()); err != nil {
+		s.logger.Errorf("delete tmpl error:%v", err)
+		return ErrTmplOperation
+	}
+	s.invalidate(caches.TmplCacheName)
+
+	return nil
+}
+
+// SetTmplStatus 上架或下架模板, 下架后用户无法使用该模板创建工作空间(管理员使用)
+func (s *SpaceTmplService) SetTmplStatus(id, status uint32) error {
+	if status != dao.TmplUsing && status != dao.TmplDeleted {
+		return ErrReqParamInvalid
+	}
+
+	if _, err := s.dao.FindTmplById(id); err != nil {
+		s.logger.Warnf("find tmpl error:%v, id:%d", err, id)
+		return ErrTmplNotFound
+	}
+
+	if err := s.dao.UpdateTmplStatus(id, status); err != nil {
+		s.logger.Errorf("update tmpl status error:%v", err)
+		return ErrTmplOperation
+	}
+	s.invalidate(caches.TmplCacheName)
+
+	return nil
+}
+
+// GetAllKindForAdmin 获取所有类别, 包括已删除的类别(管理员使用)
+func (s *SpaceTmplService) GetAllKindForAdmin() ([]model.TmplKind, error) {
+	kinds, err := s.dao.GetAllTmplKind()
+	if err != nil {
+		s.logger.Warnf("get all kind error:%v", err)
+		return nil, ErrTmplOperation
+	}
+
+	return kinds, nil
+}
+
+func (s *SpaceTmplService) CreateKind(req *reqtype.TmplKindOption) (*model.TmplKind, error) {
+	if !validTmplText(req.Name, 64, true) {
+		return nil, ErrKindInvalid
+	}
+
+	kind := &model.TmplKind{Name: req.Name, Status: dao.TmplUsing}
+	id, err := s.dao.InsertKind(kind)
+	if err != nil {
+		s.logger.Errorf("insert kind error:%v", err)
+		return nil, ErrTmplOperation
+	}
+	kind.Id = id
+	s.invalidate(caches.TmplCacheName)
+
+	return kind, nil
+}
+
+func (s *SpaceTmplService) UpdateKind(req *reqtype.TmplKindOption) error {
+	if !validTmplText(req.Name, 64, true) {
+		return ErrKindInvalid
+	}
+	if _, err := s.dao.FindKindById(req.Id); err != nil {
+		s.logger.Warnf("find kind error:%v, id:%d", err, req.Id)
+		return ErrKindNotFound
+	}
+
+	if err := s.dao.UpdateKindName(req.Id, req.Name); err != nil {
+		s.logger.Errorf("update kind error:%v", err)
+		return ErrTmplOperation
+	}
+	s.invalidate(caches.TmplCacheName)
+
+	return nil
+}
+
+// DeleteKind 删除类别, 类别下还有可用的模板时不能删除
+func (s *SpaceTmplService) DeleteKind(id uint32) error {
+	if _, err := s.dao.FindKindById(id); err != nil {
+		s.logger.Warnf("find kind error:%v, id:%d", err, id)
+		return ErrKindNotFound
+	}
+
+	count, err := s.dao.FindUsingTmplCountByKind(id)
+	if err != nil {
+		s.logger.Warnf("find tmpl count error:%v", err)
+		return ErrTmplOperation
+	}
+	if count > 0 {
+		return ErrKindInUse
+	}
+
+	if err = s.dao.DeleteKind(id); err != nil {
+		s.logger.Errorf("delete kind error:%v", err)
+		return ErrTmplOperation
+	}
+	s.invalidate(caches.TmplCacheName)
+
+	return nil
+}
+
+// GetAllSpecForAdmin 获取所有规格, 包括已删除的规格(管理员使用)
+func (s *SpaceTmplService) GetAllSpecForAdmin() ([]model.SpaceSpec, error) {
+	specs, err := s.dao.GetAllSpec()
+	if err != nil {
+		s.logger.Warnf("get all spec error:%v", err)
+		return nil, ErrTmplOperation
+	}
+
+	return specs, nil
+}
+
+func (s *SpaceTmplService) CreateSpec(req *reqtype.SpecOption) (*model.SpaceSpec, error) {
+	if err := validateSpec(req); err != nil {
+		return nil, err
+	}
+
+	spec := &model.SpaceSpec{
+		CpuSpec:     req.CpuSpec,
+		MemSpec:     req.MemSpec,
+		StorageSpec: req.StorageSpec,
+		Name:        req.Name,
+		Desc:        req.Desc,
+		IdleTimeout: req.IdleTimeout,
+		Status:      dao.TmplUsing,
+	}
+	id, err := s.dao.InsertSpec(spec)
+	if err != nil {
+		s.logger.Errorf("insert spec error:%v", err)
+		return nil, ErrTmplOperation
+	}
+	spec.Id = id
+	s.invalidate(caches.SpecCacheName)
+
+	return spec, nil
+}
+
+// UpdateSpec 修改规格, 已有的存储卷无法缩小, 所以存储规格不能修改
+func (s *SpaceTmplService) UpdateSpec(req *reqtype.SpecOption) (*model.SpaceSpec, error) {
+	spec, err := s.dao.FindSpecById(req.Id)
+	if err != nil {
+		s.logger.Warnf("find spec error:%v, id:%d", err, req.Id)
+		return nil, ErrSpecNotFound
+	}
+	if req.StorageSpec == "" {
+		req.StorageSpec = spec.StorageSpec
+	}
+	if req.StorageSpec != spec.StorageSpec {
+		return nil, ErrSpecInvalid
+	}
+	if err = validateSpec(req); err != nil {
+		return nil, err
+	}
+
+	spec.CpuSpec = req.CpuSpec
+	spec.MemSpec = req.MemSpec
+	spec.Name = req.Name
+	spec.Desc = req.Desc
+	spec.IdleTimeout = req.IdleTimeout
+	if err = s.dao.UpdateSpec(spec); err != nil {
+		s.logger.Errorf("update spec error:%v", err)
+		return nil, ErrTmplOperation
+	}
+	s.invalidate(caches.SpecCacheName)
+
+	return spec, nil
+}
+
+// DeleteSpec 删除规格, 只做软删除, 使用该规格的工作空间仍然可以启动
+func (s *SpaceTmplService) DeleteSpec(id uint32) error {
+	if _, err := s.dao.FindSpecById(id); err != nil {
+		s.logger.Warnf("find spec error:%v, id:%d", err, id)
+		return ErrSpecNotFound
+	}
+
+	if err := s.dao.DeleteSpec(id); err != nil {
+		s.logger.Errorf("delete spec error:%v", err)
+		return ErrTmplOperation
+	}
+	s.invalidate(caches.SpecCacheName)
+
+	return nil
+}
+
+// invalidate 通知所有副本刷新缓存, 数据库已经修改成功, 失败时只记录日志, 等待定时刷新
+func (s *SpaceTmplService) invalidate(name string) {
+	if err := caches.CacheFactory().Invalidate(name); err != nil {
+		s.logger.Errorf("invalidate %s cache error:%v", name, err)
+	}
+}
+
+// tmplFromOption 校验请求参数并转换为模板, 启动配置中的列表字段使用json保存
+func (s *SpaceTmplService) tmplFromOption(req *reqtype.TmplOption) (*model.SpaceTemplate, error) {
+	if err := validateTmpl(req); err != nil {
+		return nil, err
+	}
+
+	kind, err := s.dao.FindKindById(req.KindId)
+	if err != nil || kind.Status != dao.TmplUsing {
+		s.logger.Warnf("find kind error:%v, id:%d", err, req.KindId)
+		return nil, ErrKindNotFound
+	}
+
+	launch := &req.Launch
+	tmpl := &model.SpaceTemplate{
+		KindId:        req.KindId,
+		Name:          req.Name,
+		Desc:          req.Desc,
+		Tags:          req.Tags,
+		Image:         req.Image,
+		Avatar:        req.Avatar,
+		IdleTimeout:   req.IdleTimeout,
+		Port:          launch.Port,
+		WorkingDir:    launch.WorkingDir,
+		ReadinessPath: launch.ReadinessPath,
+		LivenessPath:  launch.LivenessPath,
+		StartupPath:   launch.StartupPath,
+	}
+
+	fields := []struct {
+		empty  bool
+		value  interface{}
+		field  *string
+		maxLen int
+	}{
+		{len(launch.Command) == 0, launch.Command, &tmpl.Command, maxTmplCommandLen},
+		{len(launch.Args) == 0, launch.Args, &tmpl.Args, maxTmplArgsLen},
+		{len(launch.Env) == 0, launch.Env, &tmpl.Env, maxTmplEnvLen},
+		{len(launch.InitSteps) == 0, launch.InitSteps, &tmpl.InitSteps, maxTmplInitStepsLen},
+	}
+	// 空的列表字段保存为空字符串
+	for _, f := range fields {
+		if f.empty {
+			continue
+		}
+		data, err := json.Marshal(f.value)
+		if err != nil || len(data) > f.maxLen {
+			return nil, ErrTmplInvalid
+		}
+		*f.field = string(data)
+	}
+
+	return tmpl, nil
+}
+
+// validateTmpl 校验模板的基本信息和启动配置
+func validateTmpl(req *reqtype.TmplOption) error {
+	if !validTmplText(req.Name, 128, true) || !validTmplText(req.Desc, 256, false) ||
+		!validTmplText(req.Tags, 128, false) || !validTmplText(req.Avatar, 128, false) {
+		return ErrTmplInvalid
+	}
+	if len(req.Image) > 128 || !utils.VerifyImageReference(req.Image) {
+		return ErrTmplInvalid
+	}
+
+	launch := &req.Launch
+	if launch.Port > 65535 || len(launch.WorkingDir) > maxTmplPathLen {
+		return ErrTmplInvalid
+	}
+	for _, path := range []string{launch.ReadinessPath, launch.LivenessPath, launch.StartupPath} {
+		if len(path) > maxTmplPathLen || (path != "" && !strings.HasPrefix(path, "/")) {
+			return ErrTmplInvalid
+		}
+	}
+	for name := range launch.Env {
+		if !envNameRegexp.MatchString(name) {
+			return ErrTmplInvalid
+		}
+	}
+
+	names := make(map[string]bool, len(launch.InitSteps))
+	for _, step := range launch.InitSteps {
+		if len(step.Name) > maxInitStepNameLen || !initStepNameRegexp.MatchString(step.Name) || names[step.Name] {
+			return ErrTmplInvalid
+		}
+		if len(step.Command) == 0 {
+			return ErrTmplInvalid
+		}
+		// 镜像为空时使用模板的镜像
+		if step.Image != "" && !utils.VerifyImageReference(step.Image) {
+			return ErrTmplInvalid
+		}
+		names[step.Name] = true
+	}
+
+	return nil
+}
+
+// validateSpec 校验规格, 资源必须是合法的kubernetes资源数量
+func validateSpec(req *reqtype.SpecOption) error {
+	if !validTmplText(req.Name, 32, true) || !validTmplText(req.Desc, 64, false) {
+		return ErrSpecInvalid
+	}
+
+	cpu, err := resource.ParseQuantity(req.CpuSpec)
+	if err != nil || cpu.Cmp(minSpecCpu) < 0 {
+		return ErrSpecInvalid
+	}
+	mem, err := resource.ParseQuantity(req.MemSpec)
+	if err != nil || mem.Cmp(minSpecMemory) < 0 {
+		return ErrSpecInvalid
+	}
+	storage, err := resource.ParseQuantity(req.StorageSpec)
+	if err != nil || storage.Sign() <= 0 {
+		return ErrSpecInvalid
+	}
+	// 数据库中字段的长度
+	for _, spec := range []string{req.CpuSpec, req.MemSpec, req.StorageSpec} {
+		if len(spec) > 16 {
+			return ErrSpecInvalid
+		}
+	}
+
+	return nil
+}
+
+func validTmplText(text string, maxLen int, required bool) bool {
+	if required && strings.TrimSpace(text) == "" {
+		return false
+	}
+
+	return utf8.RuneCountInString(text) <= maxLen
+}
+
+// tmplLaunchOf 解析模板中使用json保存的启动配置
+func tmplLaunchOf(tmpl *model.SpaceTemplate) (model.TmplLaunch, error) {
+	launch := model.TmplLaunch{
+		Port:          tmpl.Port,
+		WorkingDir:    tmpl.WorkingDir,
+		ReadinessPath: tmpl.ReadinessPath,
+		LivenessPath:  tmpl.LivenessPath,
+		StartupPath:   tmpl.StartupPath,
+	}
+	fields := []struct {
+		field string
+		v     interface{}
+	}{
+		{tmpl.Command, &launch.Command},
+		{tmpl.Args, &launch.Args},
+		{tmpl.Env, &launch.Env},
+		{tmpl.InitSteps, &launch.InitSteps},
+	}
+	for _, f := range fields {
+		if err := unmarshalTmplField(f.field, f.v); err != nil {
+			return launch, err
+		}
+	}
+
+	return launch, nil
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model/reqtype"
+)
+
+func TestValidateTmpl(t *testing.T) {
+	valid := func() *reqtype.TmplOption {
+		return &reqtype.TmplOption{
+			KindId: 1,
+			Name:   "Go",
+			Image:  "codercom/code-server:4.9.1",
+			Launch: model.TmplLaunch{
+				Port:          8080,
+				Env:           map[string]string{"GOPROXY": "https://goproxy.cn"},
+				ReadinessPath: "/healthz",
+				InitSteps:     []model.TmplInitStep{{Name: "install", Command: []string{"make"}}},
+			},
+		}
+	}
+	if err := validateTmpl(valid()); err != nil {
+		t.Fatalf("unexpected error:%v", err)
+	}
+
+	cases := map[string]func(req *reqtype.TmplOption){
+		"empty name":    func(req *reqtype.TmplOption) { req.Name = " " },
+		"invalid image": func(req *reqtype.TmplOption) { req.Image = "Code Server" },
+		"invalid port":  func(req *reqtype.TmplOption) { req.Launch.Port = 70000 },
+		"invalid probe": func(req *reqtype.TmplOption) { req.Launch.ReadinessPath = "healthz" },
+		"invalid env":   func(req *reqtype.TmplOption) { req.Launch.Env["1ENV"] = "" },
+		"step name":     func(req *reqtype.TmplOption) { req.Launch.InitSteps[0].Name = "Install" },
+		"step command":  func(req *reqtype.TmplOption) { req.Launch.InitSteps[0].Command = nil },
+		"step image":    func(req *reqtype.TmplOption) { req.Launch.InitSteps[0].Image = "ubuntu:" },
+		"duplicate step": func(req *reqtype.TmplOption) {
+			req.Launch.InitSteps = append(req.Launch.InitSteps, req.Launch.InitSteps[0])
+		},
+	}
+	for name, modify := range cases {
+		req := valid()
+		modify(req)
+		if err := validateTmpl(req); err != ErrTmplInvalid {
+			t.Errorf("%s: want ErrTmplInvalid, got %v", name, err)
+		}
+	}
+}
+
+func TestValidateSpec(t *testing.T) {
+	req := &reqtype.SpecOption{CpuSpec: "2", MemSpec: "4Gi", StorageSpec: "8Gi", Name: "标准型"}
+	if err := validateSpec(req); err != nil {
+		t.Fatalf("unexpected error:%v", err)
+	}
+
+	cases := []reqtype.SpecOption{
+		{CpuSpec: "1", MemSpec: "4Gi", StorageSpec: "8Gi", Name: "cpu"},
+		{CpuSpec: "2", MemSpec: "512Mi", StorageSpec: "8Gi", Name: "mem"},
+		{CpuSpec: "2", MemSpec: "4GB", StorageSpec: "8Gi", Name: "unit"},
+		{CpuSpec: "2", MemSpec: "4Gi", StorageSpec: "0", Name: "storage"},
+		{CpuSpec: "2", MemSpec: "4Gi", StorageSpec: "8Gi", Name: ""},
+	}
+	for _, c := range cases {
+		if err := validateSpec(&c); err != ErrSpecInvalid {
+			t.Errorf("%+v: want ErrSpecInvalid, got %v", c, err)
+		}
+	}
+}
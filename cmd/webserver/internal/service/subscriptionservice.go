@@ -1,8 +1,10 @@
 package service
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/conf"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/model"
 	"github.com/mangohow/cloud-ide/pkg/logger"
@@ -105,20 +107,47 @@ func (s *SubscriptionService) GetSubscriptionHistory(userId uint32) ([]model.Use
 
 // GetVipUserCount 获取VIP用户数量（管理员使用）
 func (s *SubscriptionService) GetVipUserCount() (int, error) {
-	// 这里需要在DAO中添加相应的方法，暂时返回0
-	// TODO: 在PaymentDao中添加GetVipUserCount方法
-	return 0, nil
+	count, err := s.paymentDao.CountVipUsers()
+	if err != nil {
+		s.logger.Errorf("count vip users failed: %v", err)
+		return 0, err
+	}
+
+	return count, nil
 }
 
 // GetSubscriptionStats 获取订阅统计信息（管理员使用）
 func (s *SubscriptionService) GetSubscriptionStats() (map[string]interface{}, error) {
-	// TODO: 实现订阅统计功能
+	total, err := s.GetVipUserCount()
+	if err != nil {
+		return nil, err
+	}
+
+	// 今天之内过期的VIP用户
+	now := time.Now()
+	endOfDay := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, now.Location())
+	expiredToday, err := s.paymentDao.CountVipExpiringBefore(endOfDay)
+	if err != nil {
+		s.logger.Errorf("count expiring vip users failed: %v", err)
+		return nil, err
+	}
+
+	counts, err := s.paymentDao.CountActiveSubscribersByType()
+	if err != nil {
+		s.logger.Errorf("count subscribers failed: %v", err)
+		return nil, err
+	}
+	subscribers := make(map[string]int, len(counts))
+	for _, c := range counts {
+		subscribers[c.SubscriptionType] = c.Count
+	}
+
 	stats := map[string]interface{}{
-		"total_vip_users":   0,
-		"day_subscribers":   0,
-		"week_subscribers":  0,
-		"month_subscribers": 0,
-		"expired_today":     0,
+		"total_vip_users":   total,
+		"day_subscribers":   subscribers[model.ProductTypeDay],
+		"week_subscribers":  subscribers[model.ProductTypeWeek],
+		"month_subscribers": subscribers[model.ProductTypeMonth],
+		"expired_today":     expiredToday,
 	}
 	return stats, nil
 }
@@ -185,16 +214,51 @@ func (s *SubscriptionService) HandleSubscriptionExpiration() error {
 }
 
 // NotifyExpiringUsers 通知即将过期的用户（定时任务调用）
+// 提前conf.SchedulerConfig.RemindDays天发送邮件提醒, 同一个过期时间只提醒一次
 func (s *SubscriptionService) NotifyExpiringUsers() error {
-	// TODO: 实现即将过期用户的通知功能
-	// 1. 查询即将过期的用户（比如3天内过期）
-	// 2. 发送邮件或站内消息通知
-	// 3. 记录通知日志
-	
-	s.logger.Info("expiring users notification completed")
+	days := conf.SchedulerConfig.RemindDays
+	users, err := s.paymentDao.FindVipExpiringBefore(time.Now().AddDate(0, 0, int(days)))
+	if err != nil {
+		s.logger.Errorf("find expiring vip users failed: %v", err)
+		return err
+	}
+
+	var notified int
+	emailService := DefaultEmailService()
+	for _, user := range users {
+		ok, err := s.paymentDao.AddVipReminder(user.Id, *user.VipExpireTime)
+		if err != nil {
+			s.logger.Errorf("add vip reminder failed: %v, userId: %d", err, user.Id)
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		subject, text := expiringNotice(&user)
+		if err = emailService.SendNotice(user.Email, subject, text); err != nil {
+			s.logger.Warnf("send expiring notice failed: %v, userId: %d", err, user.Id)
+			continue
+		}
+		notified++
+	}
+
+	s.logger.Infof("expiring users notification completed, notified: %d", notified)
 	return nil
 }
 
+// expiringNotice 生成VIP即将过期的提醒邮件
+func expiringNotice(user *model.User) (subject, text string) {
+	name := user.Nickname
+	if name == "" {
+		name = user.Username
+	}
+	expire := user.VipExpireTime.Format("2006-01-02 15:04:05")
+
+	return "Cloud Code会员即将到期", fmt.Sprintf("%s您好, 您的Cloud Code会员将于%s到期, "+
+		"到期后将不能继续使用会员专属的工作空间规格, 正在运行的此类工作空间会被停止, 请及时续费。", name, expire)
+}
+
 // ExtendSubscription 延长订阅（管理员功能）
 func (s *SubscriptionService) ExtendSubscription(userId uint32, days int, reason string) error {
 	// 获取用户当前VIP信息
@@ -29,6 +29,7 @@ func NewUserService(service EmailService) *UserService {
 
 var (
 	ErrUserDeleted       = errors.New("user deleted")
+	ErrUserDisabled      = errors.New("user disabled")
 	ErrUserNotExist      = errors.New("user not exist")
 	ErrPasswordIncorrect = errors.New("password incorrect")
 )
@@ -74,6 +75,9 @@ func (u *UserService) Login(username, password string) (*model.User, error) {
 	if code.UserStatus(user.Status) == code.StatusDeleted {
 		return nil, ErrUserDeleted
 	}
+	if code.UserStatus(user.Status) == code.StatusDisabled {
+		return nil, ErrUserDisabled
+	}
 
 	// 4、生成token
 	u.logger.Infof("UserService.Login: About to generate token for user: %s", username)
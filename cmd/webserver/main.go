@@ -1,13 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"syscall"
 
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/caches"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/conf"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao/db"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/dao/rdis"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/pay"
 	"github.com/mangohow/cloud-ide/cmd/webserver/internal/routes"
+	"github.com/mangohow/cloud-ide/cmd/webserver/internal/scheduler"
 	"github.com/mangohow/cloud-ide/pkg/httpserver"
 	"github.com/mangohow/cloud-ide/pkg/logger"
 	"github.com/mangohow/cloud-ide/pkg/router"
@@ -29,6 +33,30 @@ func main() {
 		panic(fmt.Errorf("init mysql failed, reason:%s", err.Error()))
 	}
 
+	// 初始化redis, 用于邮件验证码和缓存失效通知
+	// 没有启用邮件时redis是可选的, 缓存只能依赖定时刷新
+	if err := rdis.InitRedis(); err != nil {
+		if conf.EmailConfig.Enabled {
+			panic(fmt.Errorf("init redis failed, reason:%s", err.Error()))
+		}
+		logger.Logger().Warnf("init redis failed, cache invalidation is disabled, reason:%v", err)
+	}
+
+	// 初始化支付网关
+	if err := pay.InitGateway(); err != nil {
+		panic(fmt.Errorf("init payment gateway failed, reason:%s", err.Error()))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	caches.CacheFactory().Subscribe(ctx)
+	// 定时任务, 多个副本时通过redis保证每个任务只在一个副本上执行
+	// 包括定期计量运行中的工作空间, 按量计费的用户余额用完时停止其工作空间
+	sched := scheduler.New()
+	if err := scheduler.RegisterJobs(sched); err != nil {
+		panic(err)
+	}
+	sched.Start()
+
 	// 创建gin路由
 	engine := router.NewGinRouter(conf.ServerConfig.Mode)
 	// 注册路由
@@ -44,9 +72,9 @@ func main() {
 
 	// 等待服务退出
 	httpserver.WaitForShutdown(server, func() {
+		cancel()
+		sched.Stop()
 		db.CloseMysql()
-		if conf.EmailConfig.Enabled {
-			rdis.CloseRedisConn()
-		}
+		rdis.CloseRedisConn()
 	})
 }
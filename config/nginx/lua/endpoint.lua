@@ -1,45 +1,59 @@
--- 判断method
-local method = ngx.req.get_method()
-if method ~= "POST" and method ~= "DELETE" then
-    return ngx.exit(ngx.HTTP_BAD_REQUEST) 
-end
-
--- 验证Token
-local token = ngx.req.get_headers()["token"]
-if not token then 
-    ngx.exit(ngx.HTTP_UNAUTHORIZED)
-end
-
-if token ~= ngx.var.token then
-    ngx.exit(ngx.HTTP_UNAUTHORIZED)
-end
-
--- 获取body
-ngx.req.read_body()
-local body = ngx.req.get_body_data()
-if not body then
-    return ngx.exit(ngx.HTTP_BAD_REQUEST) 
-end
-
--- 保存到共享内存中
-local cjson = require("cjson")
-local req = cjson.decode(body)
-
-local eps = ngx.shared.endpoints
-
-if method == "POST" then
-    if not req.sid or not req.endpoint then
-        return ngx.exit(ngx.HTTP_BAD_REQUEST)
-    end    
-
-    local success, err = eps:set(req.sid, req.endpoint)
-    if not success then
-        ngx.log(ngx.ERR, "Failed to save data to shared memory:", err)
-        return ngx.exit(ngx.HTTP_BAD_REQUEST)
-    end
-elseif method == "DELETE" then    
-    if not req.sid then
-        return ngx.exit(ngx.HTTP_BAD_REQUEST)
-    end  
-    eps:delete(req.sid)
-end
+-- 判断method
+local method = ngx.req.get_method()
+if method ~= "POST" and method ~= "DELETE" and method ~= "PUT" then
+    return ngx.exit(ngx.HTTP_BAD_REQUEST) 
+end
+
+-- 验证Token
+local token = ngx.req.get_headers()["token"]
+if not token then 
+    ngx.exit(ngx.HTTP_UNAUTHORIZED)
+end
+
+if token ~= ngx.var.token then
+    ngx.exit(ngx.HTTP_UNAUTHORIZED)
+end
+
+-- 获取body
+ngx.req.read_body()
+local body = ngx.req.get_body_data()
+if not body then
+    return ngx.exit(ngx.HTTP_BAD_REQUEST) 
+end
+
+-- 保存到共享内存中, 配置redis后同时保存到redis
+local cjson = require("cjson")
+local ok, req = pcall(cjson.decode, body)
+if not ok or type(req) ~= "table" then
+    return ngx.exit(ngx.HTTP_BAD_REQUEST)
+end
+
+local registry = require("registry")
+local success, err
+
+if method == "POST" then
+    if not req.sid or not req.endpoint then
+        return ngx.exit(ngx.HTTP_BAD_REQUEST)
+    end    
+
+    -- uid为工作空间的所有者, proxy.lua用来检查访问者的票据
+    success, err = registry.set(req.sid, req.endpoint, req.uid)
+elseif method == "DELETE" then    
+    if not req.sid then
+        return ngx.exit(ngx.HTTP_BAD_REQUEST)
+    end  
+    success, err = registry.delete(req.sid)
+else
+    -- control-plane的全量同步, 请求体为所有就绪的工作空间
+    for _, r in ipairs(req) do
+        if type(r) ~= "table" or not r.sid or not r.endpoint then
+            return ngx.exit(ngx.HTTP_BAD_REQUEST)
+        end
+    end
+    success, err = registry.replace(req)
+end
+
+if not success then
+    ngx.log(ngx.ERR, "Failed to save endpoint:", err)
+    return ngx.exit(ngx.HTTP_INTERNAL_SERVER_ERROR)
+end
@@ -1,60 +1,148 @@
-local function split(str,reps)
-    local resultStrList = {}
-    string.gsub(str,'[^'..reps..']+',function (w)
-        table.insert(resultStrList,w)
-    end)
-    return resultStrList
-end
-
-
---[[
-    1、解析出路径中的sid和其它路径
---]]
-
--- 获取请求的路径
-local request_uri = ngx.var.request_uri
--- 分割路径
-local data = split(request_uri, '/')
-
--- 请求路径为 /ws/sid/... , 因此至少为2个
-if #data < 2 then
-    return
-end
-
--- lua中数组下标从1开始，sid为第二个
-local ws = data[1]
-if ws ~= "ws" then
-    return ngx.exit(404)
-end
-
-local sid = data[2]
-local sid_index = string.find(request_uri, sid)
-local other_path_indx = sid_index + string.len(sid)
--- 获取到sid后面的路径
-local other_path = string.sub(request_uri, other_path_indx + 1)
-
-if other_path == '/' then
-    other_path = ''
-end
-
--- 设置nginx.conf中的变量
-ngx.var.pth = other_path
-
---[[
-    2、从共享内存中根据sid查询后端ip和端口
-    注意：在跳转网页时 一定是 http://ip:port/ws/sid/    最后面一定要有'/'
---]]
-
-
-local eps = ngx.shared.endpoints
-local ep, flags = eps:get(sid)
-if not ep then
-    return ngx.exit(ngx.HTTP_BAD_GATEWAY)
-end
-
-ngx.log(ngx.INFO, 'sid:'..sid..', host:'..ep)
-
--- 设置backend
-ngx.var.backend = ep
-ngx.log(ngx.NOTICE, "other_path: "..other_path)
-
+--[[
+    1、从域名中解析出sid, 每个工作空间使用独立的源 <sid>.<workspace_domain>
+    cookie不设置Domain, 只发送给当前的工作空间
+--]]
+
+local host = string.lower(ngx.var.host or '')
+local suffix = '.' .. string.lower(ngx.var.workspace_domain)
+if string.sub(host, -string.len(suffix)) ~= suffix then
+    return ngx.exit(404)
+end
+
+local sid = string.sub(host, 1, string.len(host) - string.len(suffix))
+if sid == '' or string.find(sid, '.', 1, true) then
+    return ngx.exit(404)
+end
+
+local request_uri = ngx.var.request_uri
+
+-- 返回去掉name参数的请求地址, 重定向到该地址防止令牌留在浏览器的地址栏和历史记录中
+local function strip_arg(name)
+    local args = ngx.req.get_uri_args()
+    args[name] = nil
+    local path = string.match(request_uri, '^[^?]*')
+    local query = ngx.encode_args(args)
+    if query ~= '' then
+        path = path .. '?' .. query
+    end
+    return path
+end
+
+--[[
+    2、分享链接: 分享令牌通过share_token参数或者cookie携带, 由webserver验证
+    通过链接首次访问时记录访问并写入cookie, 然后重定向到去掉令牌的地址
+--]]
+
+local arg_token = ngx.var.arg_share_token
+local share_token = arg_token or ngx.var.cookie_cloudide_share
+if share_token then
+    local shares = ngx.shared.shares
+    local cache_key = sid .. ':' .. share_token
+    local permission = shares:get(cache_key)
+    if arg_token or not permission then
+        local res = ngx.location.capture('/_share/verify', {
+            args = { token = share_token, sid = sid, audit = arg_token and '1' or '0' }
+        })
+        if res.status ~= ngx.HTTP_OK then
+            return ngx.exit(ngx.HTTP_FORBIDDEN)
+        end
+
+        local cjson = require("cjson")
+        local ok, resp = pcall(cjson.decode, res.body)
+        if not ok or type(resp.data) ~= 'table' or not resp.data.permission then
+            return ngx.exit(ngx.HTTP_FORBIDDEN)
+        end
+        permission = resp.data.permission
+
+        -- 缓存30秒, 不超过令牌的有效期
+        local ttl = math.min(30, resp.data.expire_time - ngx.time())
+        if ttl > 0 then
+            shares:set(cache_key, permission, ttl)
+        end
+    end
+
+    if arg_token then
+        ngx.header['Set-Cookie'] = 'cloudide_share=' .. share_token .. '; Path=/; HttpOnly; Secure; SameSite=Lax'
+        return ngx.redirect(strip_arg('share_token'), ngx.HTTP_MOVED_TEMPORARILY)
+    end
+
+    -- 后端可以根据该请求头限制分享访问者的操作
+    ngx.req.set_header('X-Share-Permission', permission)
+else
+    ngx.req.clear_header('X-Share-Permission')
+
+    --[[
+        3、没有分享令牌时验证访问者: 票据由webserver签发并放在ticket参数中, 换取会话后写入cookie
+        票据和会话只能访问所有者为注册的uid的sid, 会话的验证结果缓存30秒
+        未登录的浏览器重定向到登录页, 其它请求返回401, 登录页与工作空间不同源, 因此redirect为完整地址
+    --]]
+
+    local function verify(args)
+        local res = ngx.location.capture('/_workspace/verify', { args = args })
+        if res.status ~= ngx.HTTP_OK then
+            return nil
+        end
+        local cjson = require("cjson")
+        local ok, resp = pcall(cjson.decode, res.body)
+        if not ok or type(resp.data) ~= 'table' or not resp.data.expire_time then
+            return nil
+        end
+        return resp.data
+    end
+
+    local function unauthorized()
+        local accept = ngx.var.http_accept or ''
+        if ngx.var.login_url == '' or ngx.req.get_method() ~= 'GET' or ngx.var.http_upgrade
+            or not string.find(accept, 'text/html', 1, true) then
+            return ngx.exit(ngx.HTTP_UNAUTHORIZED)
+        end
+        local redirect = ngx.escape_uri('https://' .. ngx.var.http_host .. strip_arg('ticket'))
+        return ngx.redirect(ngx.var.login_url .. '?redirect=' .. redirect, ngx.HTTP_MOVED_TEMPORARILY)
+    end
+
+    local owner = ngx.shared.endpoints:get('uid:' .. sid) or ''
+    local ticket = ngx.var.arg_ticket
+    if ticket then
+        local data = verify({ sid = sid, uid = owner, ticket = ticket })
+        if not data or not data.session then
+            return unauthorized()
+        end
+        local max_age = data.expire_time - ngx.time()
+        ngx.header['Set-Cookie'] = 'cloudide_session=' .. data.session .. '; Path=/; Max-Age=' .. max_age .. '; HttpOnly; Secure; SameSite=Lax'
+        return ngx.redirect(strip_arg('ticket'), ngx.HTTP_MOVED_TEMPORARILY)
+    end
+
+    local session = ngx.var.cookie_cloudide_session
+    if not session then
+        return unauthorized()
+    end
+    local sessions = ngx.shared.sessions
+    local cache_key = sid .. ':' .. owner .. ':' .. session
+    if not sessions:get(cache_key) then
+        local data = verify({ sid = sid, uid = owner, session = session })
+        if not data then
+            return unauthorized()
+        end
+        local ttl = math.min(30, data.expire_time - ngx.time())
+        if ttl > 0 then
+            sessions:set(cache_key, true, ttl)
+        end
+    end
+end
+
+--[[
+    4、从共享内存中根据sid查询后端ip和端口
+--]]
+
+
+local eps = ngx.shared.endpoints
+local ep, flags = eps:get(sid)
+if not ep then
+    return ngx.exit(ngx.HTTP_BAD_GATEWAY)
+end
+
+ngx.log(ngx.INFO, 'sid:'..sid..', host:'..ep)
+
+-- 设置backend
+ngx.var.backend = ep
+
@@ -0,0 +1,243 @@
+--[[
+    工作空间地址的存储: 共享内存中保存sid到地址以及'uid:'..sid到所有者的映射
+    配置redis后同时保存在redis的hash中, 网关重启后从redis加载, 多个网关副本通过频道同步修改
+    hash和频道与Go实现的网关(registry.go)相同
+--]]
+
+local cjson = require("cjson")
+
+local _M = {}
+
+local registry_key = 'cloud-ide:gateway:endpoints'
+local registry_channel = 'cloud-ide:gateway:endpoints'
+
+-- redis配置, 由init_worker_by_lua_block调用init设置, 为nil时只保存在共享内存中
+local conf
+
+local function connect()
+    local redis = require("resty.redis")
+    local red = redis:new()
+    red:set_timeouts(1000, 1000, 1000)
+
+    local ok, err = red:connect(conf.host, conf.port)
+    if not ok then
+        return nil, err
+    end
+    if conf.password ~= '' then
+        ok, err = red:auth(conf.password)
+        if not ok then
+            return nil, err
+        end
+    end
+    if conf.db ~= 0 then
+        ok, err = red:select(conf.db)
+        if not ok then
+            return nil, err
+        end
+    end
+
+    return red
+end
+
+local function set_local(sid, endpoint, uid)
+    local eps = ngx.shared.endpoints
+    local ok, err = eps:set(sid, endpoint)
+    if not ok then
+        return nil, err
+    end
+    return eps:set('uid:' .. sid, uid or '')
+end
+
+local function delete_local(sid)
+    local eps = ngx.shared.endpoints
+    eps:delete(sid)
+    eps:delete('uid:' .. sid)
+end
+
+-- 使用reqs替换共享内存中的所有映射, 先写入新的映射再删除不存在的, 替换期间不会查询不到
+local function replace_local(reqs)
+    local keep = {}
+    for _, req in ipairs(reqs) do
+        local ok, err = set_local(req.sid, req.endpoint, req.uid)
+        if not ok then
+            return nil, err
+        end
+        keep[req.sid] = true
+    end
+
+    local eps = ngx.shared.endpoints
+    for _, key in ipairs(eps:get_keys(0)) do
+        local sid = string.gsub(key, '^uid:', '')
+        if not keep[sid] then
+            eps:delete(key)
+        end
+    end
+
+    return true
+end
+
+-- 从redis加载sid的映射到共享内存中, sid为空时加载所有映射
+local function load(sid)
+    local red, err = connect()
+    if not red then
+        return nil, err
+    end
+
+    if sid ~= '' then
+        local data
+        data, err = red:hget(registry_key, sid)
+        red:set_keepalive(10000, 10)
+        if not data then
+            return nil, err
+        end
+        if data == ngx.null then
+            delete_local(sid)
+            return true
+        end
+        local req = cjson.decode(data)
+        return set_local(sid, req.endpoint, req.uid)
+    end
+
+    local all
+    all, err = red:hgetall(registry_key)
+    red:set_keepalive(10000, 10)
+    if not all then
+        return nil, err
+    end
+    local reqs = {}
+    for i = 1, #all, 2 do
+        local ok, req = pcall(cjson.decode, all[i + 1])
+        if ok and type(req) == 'table' and req.endpoint then
+            req.sid = all[i]
+            table.insert(reqs, req)
+        else
+            ngx.log(ngx.WARN, 'decode endpoint failed, sid:', all[i])
+        end
+    end
+
+    return replace_local(reqs)
+end
+
+-- 写入redis并通知其它副本, 然后修改本地的共享内存
+local function publish(commands, sid)
+    local red, err = connect()
+    if not red then
+        return nil, err
+    end
+
+    red:multi()
+    commands(red)
+    red:publish(registry_channel, sid)
+    local res
+    res, err = red:exec()
+    if not res then
+        red:close()
+        return nil, err
+    end
+    red:set_keepalive(10000, 10)
+
+    return true
+end
+
+-- 订阅修改通知, 订阅成功后全量加载, 连接断开后重新订阅并加载
+local function watch(premature)
+    if premature or ngx.worker.exiting() then
+        return
+    end
+
+    local red, err = connect()
+    if red then
+        local ok
+        ok, err = red:subscribe(registry_channel)
+        if ok then
+            ok, err = load('')
+            if not ok then
+                ngx.log(ngx.ERR, 'load endpoints failed:', err)
+            end
+
+            -- 超时后继续等待, 同时全量加载一次, 防止重新订阅之前丢失通知
+            red:set_timeouts(1000, 1000, 60000)
+            while not ngx.worker.exiting() do
+                local res
+                res, err = red:read_reply()
+                if res then
+                    if res[1] == 'message' then
+                        ok, err = load(res[3])
+                        if not ok then
+                            ngx.log(ngx.ERR, 'load endpoint failed, sid:', res[3], ', err:', err)
+                        end
+                    end
+                elseif err == 'timeout' then
+                    load('')
+                else
+                    break
+                end
+            end
+        end
+        red:close()
+    end
+
+    if not ngx.worker.exiting() then
+        ngx.log(ngx.ERR, 'watch endpoints failed:', err)
+        ngx.timer.at(5, watch)
+    end
+end
+
+-- init 设置redis, 由第一个worker订阅修改通知, 共享内存由所有worker共享
+function _M.init(c)
+    conf = c
+    if ngx.worker.id() == 0 then
+        ngx.timer.at(0, watch)
+    end
+end
+
+function _M.set(sid, endpoint, uid)
+    if conf then
+        local ok, err = publish(function(red)
+            red:hset(registry_key, sid, cjson.encode({ sid = sid, uid = uid, endpoint = endpoint }))
+        end, sid)
+        if not ok then
+            return nil, err
+        end
+    end
+
+    return set_local(sid, endpoint, uid)
+end
+
+function _M.delete(sid)
+    if conf then
+        local ok, err = publish(function(red)
+            red:hdel(registry_key, sid)
+        end, sid)
+        if not ok then
+            return nil, err
+        end
+    end
+
+    delete_local(sid)
+    return true
+end
+
+-- replace control-plane全量同步, reqs为所有就绪的工作空间
+function _M.replace(reqs)
+    if conf then
+        local ok, err = publish(function(red)
+            red:del(registry_key)
+            local args = {}
+            for _, req in ipairs(reqs) do
+                table.insert(args, req.sid)
+                table.insert(args, cjson.encode({ sid = req.sid, uid = req.uid, endpoint = req.endpoint }))
+            end
+            if #args > 0 then
+                red:hmset(registry_key, unpack(args))
+            end
+        end, '')
+        if not ok then
+            return nil, err
+        end
+    end
+
+    return replace_local(reqs)
+end
+
+return _M
@@ -10,7 +10,11 @@ NAMESPACE="cloud-ide"
 DAYS=365
 
 # 生成自签名的证书和密钥
-openssl req -new -newkey rsa:2048 -days $DAYS -nodes -x509 -keyout $KEY_FILE -out $CERT_FILE
+# control-plane通过service名称访问gateway并使用该证书校验gateway, 证书中需要包含service名称
+SERVICE_NAME="cloud-ide-gateway-svc"
+openssl req -new -newkey rsa:2048 -days $DAYS -nodes -x509 -keyout $KEY_FILE -out $CERT_FILE \
+  -subj "/CN=$SERVICE_NAME" \
+  -addext "subjectAltName=DNS:$SERVICE_NAME,DNS:$SERVICE_NAME.$NAMESPACE.svc,DNS:$SERVICE_NAME.$NAMESPACE.svc.cluster.local"
 
 # 输出生成的证书和密钥的信息
 echo "TLS 证书和密钥已生成："
@@ -14,17 +14,56 @@ Vue.config.productionTip = false
 switch (process.env.NODE_ENV) {
   case 'development':
     axios.defaults.baseURL = ""  // 使用nginx代理，相对路径
-    axios.defaults.workspaceUrl = "http://localhost:8080/ws/"
+    axios.defaults.workspaceOrigin = "http://{sid}.ws.localhost:8080"
     break
   default:
     // 生产环境通过nginx代理
     axios.defaults.baseURL = ""  // 使用nginx代理，相对路径
-    axios.defaults.workspaceUrl = "https://tiantianai.co/ws/"
+    // 每个工作空间使用独立的源, 与网关的-workspace-domain相同
+    axios.defaults.workspaceOrigin = "https://{sid}.ws.tiantianai.co"
 }
 
 
 Vue.prototype.$axios = axios
 
+// 获取访问工作空间的票据, 返回带票据的工作空间地址, 网关验证票据后写入会话cookie
+// path为工作空间中的路径, 默认为工作空间的首页
+const workspaceOrigin = sid => axios.defaults.workspaceOrigin.replace("{sid}", sid)
+
+Vue.prototype.$workspaceUrl = async function (sid, path) {
+  const {data: res} = await axios.post("/api/workspace/ticket", {sid: sid})
+  if (res.status) {
+    Message.error(res.message)
+    return null
+  }
+  const url = new URL(path || "/", workspaceOrigin(sid))
+  url.searchParams.set("ticket", res.data.ticket)
+  return url.toString()
+}
+
+// 解析工作空间地址中的sid, 不是工作空间的地址时返回null
+Vue.prototype.$workspaceSid = function (href) {
+  let url
+  try {
+    url = new URL(href)
+  } catch (error) {
+    return null
+  }
+  const sid = url.hostname.split(".")[0]
+  return /^[0-9a-z]+$/.test(sid) && url.origin === workspaceOrigin(sid) ? sid : null
+}
+
+// 订阅工作空间的启动进度, EventSource无法携带token, 先获取只能订阅该工作空间的短期票据
+Vue.prototype.$watchSpace = async function (id) {
+  const {data: res} = await axios.post("/api/workspace/watch/ticket", {id: id})
+  if (res.status) {
+    Message.error(res.message)
+    return null
+  }
+  const url = axios.defaults.baseURL + "/api/workspace/watch?id=" + id + "&ticket=" + encodeURIComponent(res.data.ticket)
+  return new EventSource(url)
+}
+
 //配置请求拦截器，用于在访问后端服务器时携带token令牌
 axios.interceptors.request.use(config =>{
   let requestUrl = config.url
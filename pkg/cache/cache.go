@@ -44,7 +44,7 @@ func (c *Cache) GetByInt(key int) (interface{}, bool) {
 
 func (c *Cache) GetAll() []interface{} {
 	c.lock.RLock()
-	c.lock.RUnlock()
+	defer c.lock.RUnlock()
 	ret := make([]interface{}, 0, len(c.items))
 	for _, v := range c.items {
 		ret = append(ret, v)
@@ -47,3 +47,101 @@ type OAuthConf struct {
 	LinuxDoRedirectURL  string
 	LinuxDoBaseURL      string
 }
+
+type SecretConf struct {
+	// base64编码的主密钥, 用于信封加密用户的密钥
+	Key string
+}
+
+// QuotaConf 套餐的资源配额, 数量为0表示不限制
+type QuotaConf struct {
+	// 最多创建的工作空间数量
+	MaxSpaces uint32
+	// 同时运行的工作空间数量
+	MaxRunning uint32
+	// 可以使用的规格id, 为空表示可以使用所有规格
+	SpecIds []uint32
+	// 所有工作空间的存储总量, 例如 64Gi, 为空表示不限制
+	MaxStorage string
+	// 每月的运行时长(小时)
+	MonthlyHours uint32
+}
+
+// MeteringConf 工作空间的计量和按量计费, 计量的间隔由SchedulerConf.MeteringSpec指定
+type MeteringConf struct {
+	// 每核每小时的价格(分)
+	CpuCoreHourPrice float64
+	// 内存每GiB每小时的价格(分)
+	MemGbHourPrice float64
+}
+
+// SchedulerConf 定时任务的执行时间, 使用5位的cron表达式, 为空表示不执行该任务
+type SchedulerConf struct {
+	// 过期订阅和VIP
+	ExpireSpec string
+	// 提醒即将过期的用户
+	RemindSpec string
+	// 提前几天提醒
+	RemindDays uint32
+	// 停止过期用户不能再使用的工作空间
+	StopLapsedSpec string
+	// 关闭超时未支付的订单
+	CloseOrderSpec string
+	// 计量运行中的工作空间并按量扣费
+	MeteringSpec string
+}
+
+// PaymentConf 支付网关的配置
+type PaymentConf struct {
+	// 使用的支付网关, ouyun或者mock
+	Gateway string
+	// 支付网关异步通知支付结果的地址
+	NotifyUrl string
+	// 支付完成后默认的跳转地址
+	ReturnUrl string
+	// 订单超过多少分钟未支付自动关闭
+	OrderTTL uint32
+	Ouyun    OuyunConf
+	Mock     MockPayConf
+}
+
+// OuyunConf ouyun支付网关的商户信息
+type OuyunConf struct {
+	Url string
+	Pid string
+	Key string
+}
+
+// MockPayConf 本地模拟的支付网关, 只用于开发和测试
+type MockPayConf struct {
+	// 回调签名使用的密钥
+	Key string
+}
+
+// InvoiceConf 收据的配置
+type InvoiceConf struct {
+	// 收据上显示的收款方名称
+	Seller string
+	// PDF使用的UTF-8字体文件, 为空时使用内置字体, 内置字体不支持中文
+	FontFile string
+	// 支付完成后是否通过邮件发送收据
+	Email bool
+}
+
+// WorkspaceConf 访问工作空间的配置
+type WorkspaceConf struct {
+	// 签发工作空间票据和会话的密钥, 与网关共享, 不能为空
+	Key string
+	// 网关调用/internal接口时在token请求头中携带的令牌, 与网关的-endpoint-token相同, 不能为空
+	GatewayToken string
+	// 工作空间的域名, 每个工作空间使用独立的源 <sid>.<Domain>, 例如 ws.tiantianai.co
+	Domain string
+}
+
+// ShareConf 工作空间分享链接的配置
+type ShareConf struct {
+	// 签发分享令牌的密钥, 为空时不能创建分享链接
+	Key string
+	// 分享链接的最长有效期(小时)
+	MaxHours uint32
+}
@@ -0,0 +1,21 @@
+package notifier
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// registrations 向网关注册和注销Workspace的请求数量, method为POST(Login)、DELETE(Logout)或PUT(Resync)
+var registrations = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "cloudide_gateway_registrations_total",
+	Help: "Number of workspace endpoint requests sent to the gateway, partitioned by method and result.",
+}, []string{"method", "result"})
+
+// RegisterMetrics 注册notifier的指标, control-plane注册到controller-runtime的metrics中
+func RegisterMetrics(r prometheus.Registerer) error {
+	return r.Register(registrations)
+}
+
+func result(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
@@ -0,0 +1,127 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// State Workspace的Pod的状态, 由PodReconciler通知
+type State int
+
+const (
+	// StateUnknown 还没有观察到Pod的状态
+	StateUnknown State = iota
+	// StateReady Pod通过了就绪检查, 工作空间可以访问
+	StateReady
+	// StateFailed Pod处于无法恢复的错误状态
+	StateFailed
+	// StateStopped Pod被删除
+	StateStopped
+)
+
+// Status Workspace最新观察到的状态
+type Status struct {
+	State State
+	// 失败的原因, 只在StateFailed时设置
+	Reason string
+}
+
+var ErrWorkspaceFailed = errors.New("workspace failed")
+
+// stateBroadcaster 保存每个sid最新的状态, 状态变化时唤醒所有的等待者
+// 先通知后等待时, 等待者根据保存的状态直接返回
+type stateBroadcaster struct {
+	mu     sync.Mutex
+	states map[string]*sidState
+}
+
+type sidState struct {
+	status Status
+	// 状态变化时关闭, 然后替换为新的chan
+	changed chan struct{}
+	waiters int
+}
+
+func newStateBroadcaster() *stateBroadcaster {
+	return &stateBroadcaster{
+		states: make(map[string]*sidState),
+	}
+}
+
+// get 获取sid的状态, 不存在时创建, 调用者需要持有锁
+func (b *stateBroadcaster) get(sid string) *sidState {
+	s, ok := b.states[sid]
+	if !ok {
+		s = &sidState{changed: make(chan struct{})}
+		b.states[sid] = s
+	}
+	return s
+}
+
+// release 没有等待者并且不需要保存的状态被删除, 调用者需要持有锁
+func (b *stateBroadcaster) release(sid string, s *sidState) {
+	if s.waiters == 0 && (s.status.State == StateUnknown || s.status.State == StateStopped) && b.states[sid] == s {
+		delete(b.states, sid)
+	}
+}
+
+// set 更新sid的状态并唤醒所有的等待者
+func (b *stateBroadcaster) set(sid string, status Status) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := b.get(sid)
+	if s.status == status {
+		return
+	}
+	s.status = status
+	close(s.changed)
+	s.changed = make(chan struct{})
+	b.release(sid, s)
+}
+
+// reset 清除sid之前的状态, 之后的等待只会因为新的状态返回
+func (b *stateBroadcaster) reset(sid string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := b.get(sid)
+	s.status = Status{}
+	b.release(sid, s)
+}
+
+// wait 等待sid就绪, 失败时返回ErrWorkspaceFailed, Pod被删除不会结束等待
+func (b *stateBroadcaster) wait(ctx context.Context, sid string) error {
+	b.mu.Lock()
+	s := b.get(sid)
+	s.waiters++
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		s.waiters--
+		b.release(sid, s)
+		b.mu.Unlock()
+	}()
+
+	for {
+		b.mu.Lock()
+		status, changed := s.status, s.changed
+		b.mu.Unlock()
+
+		switch status.State {
+		case StateReady:
+			return nil
+		case StateFailed:
+			return fmt.Errorf("%w: %s", ErrWorkspaceFailed, status.Reason)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
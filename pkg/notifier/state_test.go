@@ -0,0 +1,80 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func waitAsync(b *stateBroadcaster, sid string) <-chan error {
+	ch := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		ch <- b.wait(ctx, sid)
+	}()
+	return ch
+}
+
+func TestBroadcastReady(t *testing.T) {
+	b := newStateBroadcaster()
+
+	// 多个等待者都会被唤醒, 重复通知不会panic
+	w1, w2 := waitAsync(b, "a"), waitAsync(b, "a")
+	time.Sleep(10 * time.Millisecond)
+	b.set("a", Status{State: StateReady})
+	b.set("a", Status{State: StateReady})
+	for _, ch := range []<-chan error{w1, w2} {
+		if err := <-ch; err != nil {
+			t.Fatalf("want nil, got %v", err)
+		}
+	}
+
+	// 先通知后等待时直接返回
+	if err := <-waitAsync(b, "a"); err != nil {
+		t.Fatalf("late waiter: want nil, got %v", err)
+	}
+}
+
+func TestBroadcastFailed(t *testing.T) {
+	b := newStateBroadcaster()
+
+	w := waitAsync(b, "a")
+	time.Sleep(10 * time.Millisecond)
+	b.set("a", Status{State: StateFailed, Reason: "ImagePullBackOff"})
+	if err := <-w; !errors.Is(err, ErrWorkspaceFailed) {
+		t.Fatalf("want ErrWorkspaceFailed, got %v", err)
+	}
+	if err := <-waitAsync(b, "a"); !errors.Is(err, ErrWorkspaceFailed) {
+		t.Fatalf("late waiter: want ErrWorkspaceFailed, got %v", err)
+	}
+}
+
+func TestBroadcastReset(t *testing.T) {
+	b := newStateBroadcaster()
+
+	// 停止或重置后不再使用上一次运行的就绪状态
+	b.set("a", Status{State: StateReady})
+	b.set("a", Status{State: StateStopped})
+	b.set("b", Status{State: StateFailed})
+	b.reset("b")
+	if len(b.states) != 0 {
+		t.Fatalf("stopped and reset states should be released, got %d", len(b.states))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := b.wait(ctx, "a"); err != context.DeadlineExceeded {
+		t.Fatalf("want DeadlineExceeded, got %v", err)
+	}
+
+	// 等待期间Pod被删除不会结束等待, 之后就绪时返回
+	w := waitAsync(b, "b")
+	time.Sleep(10 * time.Millisecond)
+	b.set("b", Status{State: StateStopped})
+	b.set("b", Status{State: StateReady})
+	if err := <-w; err != nil {
+		t.Fatalf("want nil, got %v", err)
+	}
+}
@@ -4,10 +4,14 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"os"
 	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	"golang.org/x/net/http2"
@@ -15,7 +19,9 @@ import (
 )
 
 type Request struct {
-	Sid      string `json:"sid,omitempty"`
+	Sid string `json:"sid,omitempty"`
+	// 工作空间的所有者, 网关用来检查访问者是否可以访问该工作空间
+	Uid      string `json:"uid,omitempty"`
 	Endpoint string `json:"endpoint,omitempty"`
 }
 
@@ -25,18 +31,26 @@ type task struct {
 }
 
 // Waiter 用于等待一个Workspace的Pod处于Ready状态
+// 可以有多个等待者同时等待同一个Workspace, Workspace已经就绪时WaitFor直接返回
 type Waiter interface {
-	WaitFor(context.Context, string) error
+	// Reset 清除之前观察到的状态, 在创建或启动Workspace之前调用
+	Reset(sid string)
+
+	// WaitFor 等待Workspace就绪, Pod失败时返回ErrWorkspaceFailed
+	WaitFor(ctx context.Context, sid string) error
 }
 
 // Notifier 用于通知一个Workspace可用（即它的Pod处于Ready状态）
 // 注册或注销Workspace的IP地址到网关中，使得网关可以发现可用的Workspace
 type Notifier interface {
-	Login(sid, endpoint string)
+	Login(sid, uid, endpoint string)
 
 	Logout(sid string)
 
-	Notify(sid string)
+	// Notify 通知Workspace最新的状态, 唤醒所有的等待者
+	Notify(sid string, status Status)
+
+	Resync(ctx context.Context, list func() ([]Request, error)) error
 }
 
 type WorkspaceNotifier struct {
@@ -46,37 +60,45 @@ type WorkspaceNotifier struct {
 	Url     string
 	Token   string
 
-	ctx   context.Context
-	queue workqueue.Interface
+	ctx context.Context
+	// 队列中保存sid, 同一个sid不会被多个worker同时处理, 保证同一个Workspace的请求按顺序发送
+	queue workqueue.RateLimitingInterface
+	// 保存sid最新的请求, 发送之前的Login和Logout会被合并为最后一个
+	pendingMux sync.Mutex
+	pending    map[string]task
 
-	mux sync.Mutex
-	// 保存sid到chan的映射，用于通知指定的Workspace已经可用或等待Workspace可用
-	wsc map[string]chan struct{}
-}
+	// Resync期间暂停Login和Logout请求
+	syncMux sync.RWMutex
 
-func NewWorkspaceNotifier(ctx context.Context, logger logr.Logger, svcName, path, token string, workers int) (*WorkspaceNotifier, error) {
-	// https://servicename/internal/endpoint
-	w := &WorkspaceNotifier{
-		logger: logger,
-		Url:    fmt.Sprintf("https://%s%s", svcName, path),
-		Token:  token,
-		ctx:    ctx,
-		queue:  workqueue.NewRateLimitingQueue(workqueue.DefaultItemBasedRateLimiter()),
-		wsc:    make(map[string]chan struct{}),
-	}
+	// 保存每个Workspace最新的状态, 用于通知等待者
+	states *stateBroadcaster
+}
 
-	if workers <= 0 {
-		workers = 4
+// NewWorkspaceNotifier caFile为网关证书的CA, 用于校验网关的证书, 证书中需要包含svcName
+// caFile为空时不校验网关的证书
+func NewWorkspaceNotifier(ctx context.Context, logger logr.Logger, svcName, path, token, caFile string, workers int) (*WorkspaceNotifier, error) {
+	tlsConfig := &tls.Config{}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			logger.Error(err, "read gateway ca")
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			err = fmt.Errorf("no certificate found in %s", caFile)
+			logger.Error(err, "read gateway ca")
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	} else {
+		logger.Info("gateway ca is not specified, the certificate of gateway will not be verified")
+		tlsConfig.InsecureSkipVerify = true
 	}
 
-	go func() {
-		<-ctx.Done()
-		w.queue.ShutDown()
-	}()
-
 	// 开启http2
 	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, // 不校验服务端证书
+		TLSClientConfig: tlsConfig,
 	}
 	err := http2.ConfigureTransport(transport)
 	if err != nil {
@@ -84,9 +106,16 @@ func NewWorkspaceNotifier(ctx context.Context, logger logr.Logger, svcName, path
 		return nil, err
 	}
 
+	// https://servicename/internal/endpoint
+	w := newWorkspaceNotifier(ctx, logger, fmt.Sprintf("https://%s%s", svcName, path), token)
+
+	if workers <= 0 {
+		workers = 4
+	}
 	for i := 0; i < workers; i++ {
 		w.clients = append(w.clients, &http.Client{
 			Transport: transport,
+			Timeout:   10 * time.Second,
 		})
 		go w.worker(i)
 	}
@@ -94,109 +123,155 @@ func NewWorkspaceNotifier(ctx context.Context, logger logr.Logger, svcName, path
 	return w, nil
 }
 
-// Login 通过HTTP请求将Pod的IP地址和端口注册到网关中
+func newWorkspaceNotifier(ctx context.Context, logger logr.Logger, url, token string) *WorkspaceNotifier {
+	w := &WorkspaceNotifier{
+		logger: logger,
+		Url:    url,
+		Token:  token,
+		ctx:    ctx,
+		// 失败后重试, 间隔从100ms开始指数增长, 最长30s
+		queue:   workqueue.NewRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(100*time.Millisecond, 30*time.Second)),
+		pending: make(map[string]task),
+		states:  newStateBroadcaster(),
+	}
+
+	go func() {
+		<-ctx.Done()
+		w.queue.ShutDown()
+	}()
+
+	return w
+}
+
+// Login 通过HTTP请求将Pod的IP地址和端口以及工作空间的所有者注册到网关中
 // 使得网关可以访问到Pod
-func (w *WorkspaceNotifier) Login(sid, endpoint string) {
-	w.queue.Add(task{
-		req:    Request{Sid: sid, Endpoint: endpoint},
+func (w *WorkspaceNotifier) Login(sid, uid, endpoint string) {
+	w.enqueue(task{
+		req:    Request{Sid: sid, Uid: uid, Endpoint: endpoint},
 		method: http.MethodPost,
 	})
 }
 
 // Logout 从网关中注销Pod，防止网关访问到不存在或其它用户的Pod
 func (w *WorkspaceNotifier) Logout(sid string) {
-	w.queue.Add(task{
+	w.enqueue(task{
 		req:    Request{Sid: sid},
 		method: http.MethodDelete,
 	})
 }
 
+// enqueue 保存sid最新的请求, 还没有发送的请求被覆盖
+func (w *WorkspaceNotifier) enqueue(tsk task) {
+	w.pendingMux.Lock()
+	w.pending[tsk.req.Sid] = tsk
+	w.pendingMux.Unlock()
+	w.queue.Add(tsk.req.Sid)
+}
+
 func (w *WorkspaceNotifier) worker(i int) {
 	client := w.clients[i]
-	for {
-		item, shutdown := w.queue.Get()
-		if shutdown {
-			return
-		}
+	for w.processNext(client) {
+	}
+}
 
-		tsk := item.(task)
-		err := w.doRequest(client, tsk.req, tsk.method)
-		if err != nil {
-			w.logger.Error(err, "do request", "method", tsk.method, "sid", tsk.req.Sid)
-		} else {
-			w.queue.Done(item)
-		}
+func (w *WorkspaceNotifier) processNext(client *http.Client) bool {
+	item, shutdown := w.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer w.queue.Done(item)
+
+	sid := item.(string)
+	w.pendingMux.Lock()
+	tsk, ok := w.pending[sid]
+	delete(w.pending, sid)
+	w.pendingMux.Unlock()
+	if !ok {
+		w.queue.Forget(item)
+		return true
+	}
+
+	w.syncMux.RLock()
+	err := w.doRequest(w.ctx, client, tsk.req, tsk.method)
+	w.syncMux.RUnlock()
+	registrations.WithLabelValues(tsk.method, result(err)).Inc()
+	if err == nil {
+		w.queue.Forget(item)
+		return true
+	}
+
+	// 失败后重试, 期间有新的请求时发送新的请求
+	w.logger.Error(err, "do request", "method", tsk.method, "sid", sid, "retries", w.queue.NumRequeues(item))
+	w.pendingMux.Lock()
+	if _, ok = w.pending[sid]; !ok {
+		w.pending[sid] = tsk
 	}
+	w.pendingMux.Unlock()
+	w.queue.AddRateLimited(item)
+
+	return true
 }
 
-func (w *WorkspaceNotifier) doRequest(client *http.Client, req Request, method string) error {
-	data, err := json.Marshal(req)
+// Resync 将list返回的所有工作空间全量同步到网关, 网关删除不在列表中的工作空间
+// 同步期间暂停Login和Logout请求, 防止列表生成之后的注册或注销被同步覆盖
+func (w *WorkspaceNotifier) Resync(ctx context.Context, list func() ([]Request, error)) error {
+	w.syncMux.Lock()
+	defer w.syncMux.Unlock()
+
+	reqs, err := list()
+	if err != nil {
+		return err
+	}
+	if reqs == nil {
+		reqs = []Request{}
+	}
+
+	err = w.doRequest(ctx, w.clients[0], reqs, http.MethodPut)
+	registrations.WithLabelValues(http.MethodPut, result(err)).Inc()
+
+	return err
+}
+
+func (w *WorkspaceNotifier) doRequest(ctx context.Context, client *http.Client, body interface{}, method string) error {
+	data, err := json.Marshal(body)
 	if err != nil {
 		return err
 	}
 	reader := bytes.NewReader(data)
-	request, err := http.NewRequest(method, w.Url, reader)
+	request, err := http.NewRequestWithContext(ctx, method, w.Url, reader)
 	if err != nil {
 		return err
 	}
 
 	request.Header.Set("token", w.Token)
 
-	_, err = client.Do(request)
+	resp, err := client.Do(request)
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("gateway responded with status %d", resp.StatusCode)
+	}
 
 	return nil
 }
 
-// WaitFor 等待Pod可用
+// Reset 清除之前观察到的状态, 防止等待者因为上一次运行的状态返回
+func (w *WorkspaceNotifier) Reset(sid string) {
+	w.logger.V(5).Info("Reset ", "sid", sid)
+	w.states.reset(sid)
+}
+
+// WaitFor 等待Pod可用, Pod失败时返回ErrWorkspaceFailed
 func (w *WorkspaceNotifier) WaitFor(ctx context.Context, sid string) error {
 	w.logger.V(5).Info("WaitFor ", "sid", sid)
-	var (
-		ch chan struct{}
-		ok bool
-	)
-
-	w.mux.Lock()
-	ch, ok = w.wsc[sid]
-	if !ok {
-		w.logger.V(5).Info("creating chan", "sid", sid)
-		ch = make(chan struct{})
-		w.wsc[sid] = ch
-	} else {
-		w.logger.V(5).Info("find chan", "sid", sid)
-	}
-	w.mux.Unlock()
-
-	defer func() {
-		w.mux.Lock()
-		w.logger.V(5).Info("deleting chan", "sid", sid)
-		delete(w.wsc, sid)
-		w.mux.Unlock()
-	}()
-
-	w.logger.V(5).Info("waiting for", "sid", sid)
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case <-ch:
-	}
-
-	return nil
+	return w.states.wait(ctx, sid)
 }
 
-// Notify 通知Pod已经可用
-func (w *WorkspaceNotifier) Notify(sid string) {
-	w.logger.V(5).Info("Notify ", "sid", sid)
-
-	w.mux.Lock()
-	ch, ok := w.wsc[sid]
-	w.mux.Unlock()
-	if !ok {
-		w.logger.V(5).Info("cant find chan", "sid", sid)
-		return
-	}
-	w.logger.V(5).Info("closed chan", "sid", sid)
-	close(ch)
+// Notify 通知Pod最新的状态
+func (w *WorkspaceNotifier) Notify(sid string, status Status) {
+	w.logger.V(5).Info("Notify ", "sid", sid, "state", status.State, "reason", status.Reason)
+	w.states.set(sid, status)
 }
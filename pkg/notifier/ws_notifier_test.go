@@ -0,0 +1,119 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+type received struct {
+	method string
+	sid    string
+}
+
+// newTestGateway 记录收到的请求, fail返回true时响应500
+func newTestGateway(t *testing.T, tls bool, fail func(n int) bool) (*httptest.Server, <-chan received) {
+	t.Helper()
+	ch := make(chan received, 16)
+	n := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		if r.Header.Get("token") != "token" || fail(n) {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var req Request
+		json.NewDecoder(r.Body).Decode(&req)
+		ch <- received{method: r.Method, sid: req.Sid}
+	})
+
+	var server *httptest.Server
+	if tls {
+		server = httptest.NewTLSServer(handler)
+	} else {
+		server = httptest.NewServer(handler)
+	}
+	t.Cleanup(server.Close)
+
+	return server, ch
+}
+
+func expect(t *testing.T, ch <-chan received, want received) {
+	t.Helper()
+	select {
+	case got := <-ch:
+		if got != want {
+			t.Fatalf("want %+v, got %+v", want, got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for %+v", want)
+	}
+}
+
+func TestNotifierRetry(t *testing.T) {
+	// 前两次请求失败, 之后重试成功
+	server, ch := newTestGateway(t, false, func(n int) bool { return n <= 2 })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := newWorkspaceNotifier(ctx, logr.Discard(), server.URL, "token")
+	w.clients = []*http.Client{server.Client()}
+	go w.worker(0)
+
+	w.Login("a", "u", "10.0.0.1:9999")
+	expect(t, ch, received{method: http.MethodPost, sid: "a"})
+}
+
+func TestNotifierCoalesce(t *testing.T) {
+	server, ch := newTestGateway(t, false, func(int) bool { return false })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := newWorkspaceNotifier(ctx, logr.Discard(), server.URL, "token")
+	w.clients = []*http.Client{server.Client()}
+
+	// worker启动前的Login和Logout合并为最后的Logout
+	w.Login("a", "u", "10.0.0.1:9999")
+	w.Logout("a")
+	w.Login("b", "u", "10.0.0.2:9999")
+	go w.worker(0)
+
+	expect(t, ch, received{method: http.MethodDelete, sid: "a"})
+	expect(t, ch, received{method: http.MethodPost, sid: "b"})
+	select {
+	case got := <-ch:
+		t.Fatalf("unexpected request %+v", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestNotifierVerifyCA(t *testing.T) {
+	server, ch := newTestGateway(t, true, func(int) bool { return false })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewWorkspaceNotifier(ctx, logr.Discard(), server.Listener.Addr().String(), "/internal/endpoint", "token", caFile, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Logout("a")
+	expect(t, ch, received{method: http.MethodDelete, sid: "a"})
+
+	if _, err = NewWorkspaceNotifier(ctx, logr.Discard(), "gateway", "/", "token", filepath.Join(t.TempDir(), "none"), 1); err == nil {
+		t.Fatal("want error for missing ca file")
+	}
+}
@@ -66,15 +66,16 @@ func (x ResponseCreate_Status) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use ResponseCreate_Status.Descriptor instead.
 func (ResponseCreate_Status) EnumDescriptor() ([]byte, []int) {
-	return file_pb_proto_service_proto_rawDescGZIP(), []int{2, 0}
+	return file_pb_proto_service_proto_rawDescGZIP(), []int{5, 0}
 }
 
 type ResponseStart_Status int32
 
 const (
-	ResponseStart_Success  ResponseStart_Status = 0
-	ResponseStart_NotFound ResponseStart_Status = 1
-	ResponseStart_Error    ResponseStart_Status = 2
+	ResponseStart_Success          ResponseStart_Status = 0
+	ResponseStart_NotFound         ResponseStart_Status = 1
+	ResponseStart_Error            ResponseStart_Status = 2
+	ResponseStart_ShrinkNotAllowed ResponseStart_Status = 3
 )
 
 // Enum value maps for ResponseStart_Status.
@@ -83,11 +84,13 @@ var (
 		0: "Success",
 		1: "NotFound",
 		2: "Error",
+		3: "ShrinkNotAllowed",
 	}
 	ResponseStart_Status_value = map[string]int32{
-		"Success":  0,
-		"NotFound": 1,
-		"Error":    2,
+		"Success":          0,
+		"NotFound":         1,
+		"Error":            2,
+		"ShrinkNotAllowed": 3,
 	}
 )
 
@@ -115,7 +118,7 @@ func (x ResponseStart_Status) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use ResponseStart_Status.Descriptor instead.
 func (ResponseStart_Status) EnumDescriptor() ([]byte, []int) {
-	return file_pb_proto_service_proto_rawDescGZIP(), []int{4, 0}
+	return file_pb_proto_service_proto_rawDescGZIP(), []int{7, 0}
 }
 
 type ResponseStop_Status int32
@@ -164,7 +167,7 @@ func (x ResponseStop_Status) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use ResponseStop_Status.Descriptor instead.
 func (ResponseStop_Status) EnumDescriptor() ([]byte, []int) {
-	return file_pb_proto_service_proto_rawDescGZIP(), []int{6, 0}
+	return file_pb_proto_service_proto_rawDescGZIP(), []int{9, 0}
 }
 
 type ResponseDelete_Status int32
@@ -210,7 +213,7 @@ func (x ResponseDelete_Status) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use ResponseDelete_Status.Descriptor instead.
 func (ResponseDelete_Status) EnumDescriptor() ([]byte, []int) {
-	return file_pb_proto_service_proto_rawDescGZIP(), []int{8, 0}
+	return file_pb_proto_service_proto_rawDescGZIP(), []int{11, 0}
 }
 
 type ResponseRunningWorkspace_Status int32
@@ -256,7 +259,215 @@ func (x ResponseRunningWorkspace_Status) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use ResponseRunningWorkspace_Status.Descriptor instead.
 func (ResponseRunningWorkspace_Status) EnumDescriptor() ([]byte, []int) {
-	return file_pb_proto_service_proto_rawDescGZIP(), []int{10, 0}
+	return file_pb_proto_service_proto_rawDescGZIP(), []int{13, 0}
+}
+
+type Snapshot_Phase int32
+
+const (
+	Snapshot_Pending Snapshot_Phase = 0
+	Snapshot_Ready   Snapshot_Phase = 1
+	Snapshot_Failed  Snapshot_Phase = 2
+)
+
+// Enum value maps for Snapshot_Phase.
+var (
+	Snapshot_Phase_name = map[int32]string{
+		0: "Pending",
+		1: "Ready",
+		2: "Failed",
+	}
+	Snapshot_Phase_value = map[string]int32{
+		"Pending": 0,
+		"Ready":   1,
+		"Failed":  2,
+	}
+)
+
+func (x Snapshot_Phase) Enum() *Snapshot_Phase {
+	p := new(Snapshot_Phase)
+	*p = x
+	return p
+}
+
+func (x Snapshot_Phase) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Snapshot_Phase) Descriptor() protoreflect.EnumDescriptor {
+	return file_pb_proto_service_proto_enumTypes[5].Descriptor()
+}
+
+func (Snapshot_Phase) Type() protoreflect.EnumType {
+	return &file_pb_proto_service_proto_enumTypes[5]
+}
+
+func (x Snapshot_Phase) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Snapshot_Phase.Descriptor instead.
+func (Snapshot_Phase) EnumDescriptor() ([]byte, []int) {
+	return file_pb_proto_service_proto_rawDescGZIP(), []int{16, 0}
+}
+
+type ResponseSnapshot_Status int32
+
+const (
+	ResponseSnapshot_Success   ResponseSnapshot_Status = 0
+	ResponseSnapshot_NotFound  ResponseSnapshot_Status = 1
+	ResponseSnapshot_IsRunning ResponseSnapshot_Status = 2 // Archive方式需要先停止工作空间
+	ResponseSnapshot_Error     ResponseSnapshot_Status = 3
+)
+
+// Enum value maps for ResponseSnapshot_Status.
+var (
+	ResponseSnapshot_Status_name = map[int32]string{
+		0: "Success",
+		1: "NotFound",
+		2: "IsRunning",
+		3: "Error",
+	}
+	ResponseSnapshot_Status_value = map[string]int32{
+		"Success":   0,
+		"NotFound":  1,
+		"IsRunning": 2,
+		"Error":     3,
+	}
+)
+
+func (x ResponseSnapshot_Status) Enum() *ResponseSnapshot_Status {
+	p := new(ResponseSnapshot_Status)
+	*p = x
+	return p
+}
+
+func (x ResponseSnapshot_Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ResponseSnapshot_Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_pb_proto_service_proto_enumTypes[6].Descriptor()
+}
+
+func (ResponseSnapshot_Status) Type() protoreflect.EnumType {
+	return &file_pb_proto_service_proto_enumTypes[6]
+}
+
+func (x ResponseSnapshot_Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ResponseSnapshot_Status.Descriptor instead.
+func (ResponseSnapshot_Status) EnumDescriptor() ([]byte, []int) {
+	return file_pb_proto_service_proto_rawDescGZIP(), []int{18, 0}
+}
+
+type ResponseRestore_Status int32
+
+const (
+	ResponseRestore_Success   ResponseRestore_Status = 0
+	ResponseRestore_NotFound  ResponseRestore_Status = 1
+	ResponseRestore_IsRunning ResponseRestore_Status = 2
+	ResponseRestore_NotReady  ResponseRestore_Status = 3
+	ResponseRestore_Error     ResponseRestore_Status = 4
+)
+
+// Enum value maps for ResponseRestore_Status.
+var (
+	ResponseRestore_Status_name = map[int32]string{
+		0: "Success",
+		1: "NotFound",
+		2: "IsRunning",
+		3: "NotReady",
+		4: "Error",
+	}
+	ResponseRestore_Status_value = map[string]int32{
+		"Success":   0,
+		"NotFound":  1,
+		"IsRunning": 2,
+		"NotReady":  3,
+		"Error":     4,
+	}
+)
+
+func (x ResponseRestore_Status) Enum() *ResponseRestore_Status {
+	p := new(ResponseRestore_Status)
+	*p = x
+	return p
+}
+
+func (x ResponseRestore_Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ResponseRestore_Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_pb_proto_service_proto_enumTypes[7].Descriptor()
+}
+
+func (ResponseRestore_Status) Type() protoreflect.EnumType {
+	return &file_pb_proto_service_proto_enumTypes[7]
+}
+
+func (x ResponseRestore_Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ResponseRestore_Status.Descriptor instead.
+func (ResponseRestore_Status) EnumDescriptor() ([]byte, []int) {
+	return file_pb_proto_service_proto_rawDescGZIP(), []int{22, 0}
+}
+
+type ResponseResize_Status int32
+
+const (
+	ResponseResize_Success          ResponseResize_Status = 0
+	ResponseResize_NotFound         ResponseResize_Status = 1
+	ResponseResize_ShrinkNotAllowed ResponseResize_Status = 2
+	ResponseResize_Error            ResponseResize_Status = 3
+)
+
+// Enum value maps for ResponseResize_Status.
+var (
+	ResponseResize_Status_name = map[int32]string{
+		0: "Success",
+		1: "NotFound",
+		2: "ShrinkNotAllowed",
+		3: "Error",
+	}
+	ResponseResize_Status_value = map[string]int32{
+		"Success":          0,
+		"NotFound":         1,
+		"ShrinkNotAllowed": 2,
+		"Error":            3,
+	}
+)
+
+func (x ResponseResize_Status) Enum() *ResponseResize_Status {
+	p := new(ResponseResize_Status)
+	*p = x
+	return p
+}
+
+func (x ResponseResize_Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ResponseResize_Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_pb_proto_service_proto_enumTypes[8].Descriptor()
+}
+
+func (ResponseResize_Status) Type() protoreflect.EnumType {
+	return &file_pb_proto_service_proto_enumTypes[8]
+}
+
+func (x ResponseResize_Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ResponseResize_Status.Descriptor instead.
+func (ResponseResize_Status) EnumDescriptor() ([]byte, []int) {
+	return file_pb_proto_service_proto_rawDescGZIP(), []int{24, 0}
 }
 
 // 工作空间的资源限制
@@ -324,22 +535,19 @@ func (x *ResourceLimit) GetStorage() string {
 }
 
 // 创建请求
-type RequestCreate struct {
+// 工作空间初始化步骤, 在代码仓库克隆之后按顺序执行
+type InitStep struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Sid             string         `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
-	Uid             string         `protobuf:"bytes,2,opt,name=uid,proto3" json:"uid,omitempty"`
-	Image           string         `protobuf:"bytes,3,opt,name=image,proto3" json:"image,omitempty"`
-	Port            int32          `protobuf:"varint,4,opt,name=port,proto3" json:"port,omitempty"`
-	GitRepository   string         `protobuf:"bytes,5,opt,name=gitRepository,proto3" json:"gitRepository,omitempty"`
-	VolumeMountPath string         `protobuf:"bytes,6,opt,name=volumeMountPath,proto3" json:"volumeMountPath,omitempty"`
-	ResourceLimit   *ResourceLimit `protobuf:"bytes,7,opt,name=resourceLimit,proto3" json:"resourceLimit,omitempty"`
+	Name    string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Image   string   `protobuf:"bytes,2,opt,name=image,proto3" json:"image,omitempty"` // 为空时使用工作空间的镜像
+	Command []string `protobuf:"bytes,3,rep,name=command,proto3" json:"command,omitempty"`
 }
 
-func (x *RequestCreate) Reset() {
-	*x = RequestCreate{}
+func (x *InitStep) Reset() {
+	*x = InitStep{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_pb_proto_service_proto_msgTypes[1]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -347,13 +555,13 @@ func (x *RequestCreate) Reset() {
 	}
 }
 
-func (x *RequestCreate) String() string {
+func (x *InitStep) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RequestCreate) ProtoMessage() {}
+func (*InitStep) ProtoMessage() {}
 
-func (x *RequestCreate) ProtoReflect() protoreflect.Message {
+func (x *InitStep) ProtoReflect() protoreflect.Message {
 	mi := &file_pb_proto_service_proto_msgTypes[1]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -365,71 +573,49 @@ func (x *RequestCreate) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RequestCreate.ProtoReflect.Descriptor instead.
-func (*RequestCreate) Descriptor() ([]byte, []int) {
+// Deprecated: Use InitStep.ProtoReflect.Descriptor instead.
+func (*InitStep) Descriptor() ([]byte, []int) {
 	return file_pb_proto_service_proto_rawDescGZIP(), []int{1}
 }
 
-func (x *RequestCreate) GetSid() string {
-	if x != nil {
-		return x.Sid
-	}
-	return ""
-}
-
-func (x *RequestCreate) GetUid() string {
+func (x *InitStep) GetName() string {
 	if x != nil {
-		return x.Uid
+		return x.Name
 	}
 	return ""
 }
 
-func (x *RequestCreate) GetImage() string {
+func (x *InitStep) GetImage() string {
 	if x != nil {
 		return x.Image
 	}
 	return ""
 }
 
-func (x *RequestCreate) GetPort() int32 {
-	if x != nil {
-		return x.Port
-	}
-	return 0
-}
-
-func (x *RequestCreate) GetGitRepository() string {
-	if x != nil {
-		return x.GitRepository
-	}
-	return ""
-}
-
-func (x *RequestCreate) GetVolumeMountPath() string {
-	if x != nil {
-		return x.VolumeMountPath
-	}
-	return ""
-}
-
-func (x *RequestCreate) GetResourceLimit() *ResourceLimit {
+func (x *InitStep) GetCommand() []string {
 	if x != nil {
-		return x.ResourceLimit
+		return x.Command
 	}
 	return nil
 }
 
-type ResponseCreate struct {
+// 工作空间容器的启动配置, 来自空间模板
+type LaunchSpec struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Status  ResponseCreate_Status `protobuf:"varint,1,opt,name=status,proto3,enum=pb.ResponseCreate_Status" json:"status,omitempty"`
-	Message string                `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Command            []string    `protobuf:"bytes,1,rep,name=command,proto3" json:"command,omitempty"`
+	Args               []string    `protobuf:"bytes,2,rep,name=args,proto3" json:"args,omitempty"`
+	WorkingDir         string      `protobuf:"bytes,3,opt,name=workingDir,proto3" json:"workingDir,omitempty"`
+	ReadinessProbePath string      `protobuf:"bytes,4,opt,name=readinessProbePath,proto3" json:"readinessProbePath,omitempty"`
+	InitSteps          []*InitStep `protobuf:"bytes,5,rep,name=initSteps,proto3" json:"initSteps,omitempty"`
+	LivenessProbePath  string      `protobuf:"bytes,6,opt,name=livenessProbePath,proto3" json:"livenessProbePath,omitempty"`
+	StartupProbePath   string      `protobuf:"bytes,7,opt,name=startupProbePath,proto3" json:"startupProbePath,omitempty"`
 }
 
-func (x *ResponseCreate) Reset() {
-	*x = ResponseCreate{}
+func (x *LaunchSpec) Reset() {
+	*x = LaunchSpec{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_pb_proto_service_proto_msgTypes[2]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -437,13 +623,13 @@ func (x *ResponseCreate) Reset() {
 	}
 }
 
-func (x *ResponseCreate) String() string {
+func (x *LaunchSpec) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ResponseCreate) ProtoMessage() {}
+func (*LaunchSpec) ProtoMessage() {}
 
-func (x *ResponseCreate) ProtoReflect() protoreflect.Message {
+func (x *LaunchSpec) ProtoReflect() protoreflect.Message {
 	mi := &file_pb_proto_service_proto_msgTypes[2]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -455,37 +641,72 @@ func (x *ResponseCreate) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ResponseCreate.ProtoReflect.Descriptor instead.
-func (*ResponseCreate) Descriptor() ([]byte, []int) {
+// Deprecated: Use LaunchSpec.ProtoReflect.Descriptor instead.
+func (*LaunchSpec) Descriptor() ([]byte, []int) {
 	return file_pb_proto_service_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *ResponseCreate) GetStatus() ResponseCreate_Status {
+func (x *LaunchSpec) GetCommand() []string {
 	if x != nil {
-		return x.Status
+		return x.Command
 	}
-	return ResponseCreate_Success
+	return nil
 }
 
-func (x *ResponseCreate) GetMessage() string {
+func (x *LaunchSpec) GetArgs() []string {
 	if x != nil {
-		return x.Message
+		return x.Args
+	}
+	return nil
+}
+
+func (x *LaunchSpec) GetWorkingDir() string {
+	if x != nil {
+		return x.WorkingDir
 	}
 	return ""
 }
 
-type RequestStart struct {
+func (x *LaunchSpec) GetReadinessProbePath() string {
+	if x != nil {
+		return x.ReadinessProbePath
+	}
+	return ""
+}
+
+func (x *LaunchSpec) GetInitSteps() []*InitStep {
+	if x != nil {
+		return x.InitSteps
+	}
+	return nil
+}
+
+func (x *LaunchSpec) GetLivenessProbePath() string {
+	if x != nil {
+		return x.LivenessProbePath
+	}
+	return ""
+}
+
+func (x *LaunchSpec) GetStartupProbePath() string {
+	if x != nil {
+		return x.StartupProbePath
+	}
+	return ""
+}
+
+// 用户的资源配额, 由control-plane在创建和启动时检查, 为空时不检查
+type UserQuota struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Sid           string         `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
-	Uid           string         `protobuf:"bytes,2,opt,name=uid,proto3" json:"uid,omitempty"`
-	ResourceLimit *ResourceLimit `protobuf:"bytes,3,opt,name=resourceLimit,proto3" json:"resourceLimit,omitempty"`
+	MaxRunning int32  `protobuf:"varint,1,opt,name=maxRunning,proto3" json:"maxRunning,omitempty"` // 同时运行的工作空间数量, 0表示不限制
+	MaxStorage string `protobuf:"bytes,2,opt,name=maxStorage,proto3" json:"maxStorage,omitempty"`  // 所有工作空间存储的总量, 为空表示不限制
 }
 
-func (x *RequestStart) Reset() {
-	*x = RequestStart{}
+func (x *UserQuota) Reset() {
+	*x = UserQuota{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_pb_proto_service_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -493,13 +714,13 @@ func (x *RequestStart) Reset() {
 	}
 }
 
-func (x *RequestStart) String() string {
+func (x *UserQuota) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RequestStart) ProtoMessage() {}
+func (*UserQuota) ProtoMessage() {}
 
-func (x *RequestStart) ProtoReflect() protoreflect.Message {
+func (x *UserQuota) ProtoReflect() protoreflect.Message {
 	mi := &file_pb_proto_service_proto_msgTypes[3]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -511,44 +732,48 @@ func (x *RequestStart) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RequestStart.ProtoReflect.Descriptor instead.
-func (*RequestStart) Descriptor() ([]byte, []int) {
+// Deprecated: Use UserQuota.ProtoReflect.Descriptor instead.
+func (*UserQuota) Descriptor() ([]byte, []int) {
 	return file_pb_proto_service_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *RequestStart) GetSid() string {
+func (x *UserQuota) GetMaxRunning() int32 {
 	if x != nil {
-		return x.Sid
+		return x.MaxRunning
 	}
-	return ""
+	return 0
 }
 
-func (x *RequestStart) GetUid() string {
+func (x *UserQuota) GetMaxStorage() string {
 	if x != nil {
-		return x.Uid
+		return x.MaxStorage
 	}
 	return ""
 }
 
-func (x *RequestStart) GetResourceLimit() *ResourceLimit {
-	if x != nil {
-		return x.ResourceLimit
-	}
-	return nil
-}
-
-// 工作空间运行信息
-type ResponseStart struct {
+type RequestCreate struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Status  ResponseStart_Status `protobuf:"varint,1,opt,name=status,proto3,enum=pb.ResponseStart_Status" json:"status,omitempty"`
-	Message string               `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Sid             string            `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
+	Uid             string            `protobuf:"bytes,2,opt,name=uid,proto3" json:"uid,omitempty"`
+	Image           string            `protobuf:"bytes,3,opt,name=image,proto3" json:"image,omitempty"`
+	Port            int32             `protobuf:"varint,4,opt,name=port,proto3" json:"port,omitempty"`
+	GitRepository   string            `protobuf:"bytes,5,opt,name=gitRepository,proto3" json:"gitRepository,omitempty"`
+	VolumeMountPath string            `protobuf:"bytes,6,opt,name=volumeMountPath,proto3" json:"volumeMountPath,omitempty"`
+	ResourceLimit   *ResourceLimit    `protobuf:"bytes,7,opt,name=resourceLimit,proto3" json:"resourceLimit,omitempty"`
+	EnvVars         map[string]string `protobuf:"bytes,8,rep,name=envVars,proto3" json:"envVars,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`              // 环境变量配置, 保存在Workspace中
+	IdleTimeout     int32             `protobuf:"varint,9,opt,name=idleTimeout,proto3" json:"idleTimeout,omitempty"`                                                                                             // 空闲超时时间(秒), 0使用默认值, 负数表示永不超时
+	Async           bool              `protobuf:"varint,10,opt,name=async,proto3" json:"async,omitempty"`                                                                                                        // 不等待Pod启动完成直接返回, 通过watchSpace获取启动进度
+	SecretEnvVars   map[string]string `protobuf:"bytes,11,rep,name=secretEnvVars,proto3" json:"secretEnvVars,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"` // 敏感的环境变量, 保存在Workspace所属的Secret中
+	Launch          *LaunchSpec       `protobuf:"bytes,12,opt,name=launch,proto3" json:"launch,omitempty"`                                                                                                       // 启动配置, 为空时使用镜像默认的启动命令
+	Quota           *UserQuota        `protobuf:"bytes,13,opt,name=quota,proto3" json:"quota,omitempty"`
+	MaxRuntime      int64             `protobuf:"varint,14,opt,name=maxRuntime,proto3" json:"maxRuntime,omitempty"` // 本次运行的最长时间(秒), 超过后被停止, 0表示不限制
 }
 
-func (x *ResponseStart) Reset() {
-	*x = ResponseStart{}
+func (x *RequestCreate) Reset() {
+	*x = RequestCreate{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_pb_proto_service_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -556,13 +781,13 @@ func (x *ResponseStart) Reset() {
 	}
 }
 
-func (x *ResponseStart) String() string {
+func (x *RequestCreate) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ResponseStart) ProtoMessage() {}
+func (*RequestCreate) ProtoMessage() {}
 
-func (x *ResponseStart) ProtoReflect() protoreflect.Message {
+func (x *RequestCreate) ProtoReflect() protoreflect.Message {
 	mi := &file_pb_proto_service_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -574,36 +799,120 @@ func (x *ResponseStart) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ResponseStart.ProtoReflect.Descriptor instead.
-func (*ResponseStart) Descriptor() ([]byte, []int) {
+// Deprecated: Use RequestCreate.ProtoReflect.Descriptor instead.
+func (*RequestCreate) Descriptor() ([]byte, []int) {
 	return file_pb_proto_service_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *ResponseStart) GetStatus() ResponseStart_Status {
+func (x *RequestCreate) GetSid() string {
 	if x != nil {
-		return x.Status
+		return x.Sid
 	}
-	return ResponseStart_Success
+	return ""
 }
 
-func (x *ResponseStart) GetMessage() string {
+func (x *RequestCreate) GetUid() string {
 	if x != nil {
-		return x.Message
+		return x.Uid
 	}
 	return ""
 }
 
-type RequestStop struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
+func (x *RequestCreate) GetImage() string {
+	if x != nil {
+		return x.Image
+	}
+	return ""
+}
 
-	Sid string `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
-	Uid string `protobuf:"bytes,2,opt,name=uid,proto3" json:"uid,omitempty"`
+func (x *RequestCreate) GetPort() int32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
 }
 
-func (x *RequestStop) Reset() {
-	*x = RequestStop{}
+func (x *RequestCreate) GetGitRepository() string {
+	if x != nil {
+		return x.GitRepository
+	}
+	return ""
+}
+
+func (x *RequestCreate) GetVolumeMountPath() string {
+	if x != nil {
+		return x.VolumeMountPath
+	}
+	return ""
+}
+
+func (x *RequestCreate) GetResourceLimit() *ResourceLimit {
+	if x != nil {
+		return x.ResourceLimit
+	}
+	return nil
+}
+
+func (x *RequestCreate) GetEnvVars() map[string]string {
+	if x != nil {
+		return x.EnvVars
+	}
+	return nil
+}
+
+func (x *RequestCreate) GetIdleTimeout() int32 {
+	if x != nil {
+		return x.IdleTimeout
+	}
+	return 0
+}
+
+func (x *RequestCreate) GetAsync() bool {
+	if x != nil {
+		return x.Async
+	}
+	return false
+}
+
+func (x *RequestCreate) GetSecretEnvVars() map[string]string {
+	if x != nil {
+		return x.SecretEnvVars
+	}
+	return nil
+}
+
+func (x *RequestCreate) GetLaunch() *LaunchSpec {
+	if x != nil {
+		return x.Launch
+	}
+	return nil
+}
+
+func (x *RequestCreate) GetQuota() *UserQuota {
+	if x != nil {
+		return x.Quota
+	}
+	return nil
+}
+
+func (x *RequestCreate) GetMaxRuntime() int64 {
+	if x != nil {
+		return x.MaxRuntime
+	}
+	return 0
+}
+
+type ResponseCreate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status  ResponseCreate_Status `protobuf:"varint,1,opt,name=status,proto3,enum=pb.ResponseCreate_Status" json:"status,omitempty"`
+	Message string                `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (x *ResponseCreate) Reset() {
+	*x = ResponseCreate{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_pb_proto_service_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -611,13 +920,13 @@ func (x *RequestStop) Reset() {
 	}
 }
 
-func (x *RequestStop) String() string {
+func (x *ResponseCreate) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RequestStop) ProtoMessage() {}
+func (*ResponseCreate) ProtoMessage() {}
 
-func (x *RequestStop) ProtoReflect() protoreflect.Message {
+func (x *ResponseCreate) ProtoReflect() protoreflect.Message {
 	mi := &file_pb_proto_service_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -629,36 +938,43 @@ func (x *RequestStop) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RequestStop.ProtoReflect.Descriptor instead.
-func (*RequestStop) Descriptor() ([]byte, []int) {
+// Deprecated: Use ResponseCreate.ProtoReflect.Descriptor instead.
+func (*ResponseCreate) Descriptor() ([]byte, []int) {
 	return file_pb_proto_service_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *RequestStop) GetSid() string {
+func (x *ResponseCreate) GetStatus() ResponseCreate_Status {
 	if x != nil {
-		return x.Sid
+		return x.Status
 	}
-	return ""
+	return ResponseCreate_Success
 }
 
-func (x *RequestStop) GetUid() string {
+func (x *ResponseCreate) GetMessage() string {
 	if x != nil {
-		return x.Uid
+		return x.Message
 	}
 	return ""
 }
 
-type ResponseStop struct {
+type RequestStart struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Status  ResponseStop_Status `protobuf:"varint,1,opt,name=status,proto3,enum=pb.ResponseStop_Status" json:"status,omitempty"`
-	Message string              `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Sid           string            `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
+	Uid           string            `protobuf:"bytes,2,opt,name=uid,proto3" json:"uid,omitempty"`
+	ResourceLimit *ResourceLimit    `protobuf:"bytes,3,opt,name=resourceLimit,proto3" json:"resourceLimit,omitempty"`
+	IdleTimeout   int32             `protobuf:"varint,4,opt,name=idleTimeout,proto3" json:"idleTimeout,omitempty"`                                                                                            // 空闲超时时间(秒), 0使用默认值, 负数表示永不超时
+	Async         bool              `protobuf:"varint,5,opt,name=async,proto3" json:"async,omitempty"`                                                                                                        // 不等待Pod启动完成直接返回, 通过watchSpace获取启动进度
+	SecretEnvVars map[string]string `protobuf:"bytes,6,rep,name=secretEnvVars,proto3" json:"secretEnvVars,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"` // 敏感的环境变量, 合并到Workspace所属的Secret中
+	Launch        *LaunchSpec       `protobuf:"bytes,7,opt,name=launch,proto3" json:"launch,omitempty"`                                                                                                       // 启动配置, 模板修改后在下次启动时生效
+	Quota         *UserQuota        `protobuf:"bytes,8,opt,name=quota,proto3" json:"quota,omitempty"`
+	MaxRuntime    int64             `protobuf:"varint,9,opt,name=maxRuntime,proto3" json:"maxRuntime,omitempty"` // 本次运行的最长时间(秒), 超过后被停止, 0表示不限制
 }
 
-func (x *ResponseStop) Reset() {
-	*x = ResponseStop{}
+func (x *RequestStart) Reset() {
+	*x = RequestStart{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_pb_proto_service_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -666,13 +982,13 @@ func (x *ResponseStop) Reset() {
 	}
 }
 
-func (x *ResponseStop) String() string {
+func (x *RequestStart) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ResponseStop) ProtoMessage() {}
+func (*RequestStart) ProtoMessage() {}
 
-func (x *ResponseStop) ProtoReflect() protoreflect.Message {
+func (x *RequestStart) ProtoReflect() protoreflect.Message {
 	mi := &file_pb_proto_service_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -684,36 +1000,86 @@ func (x *ResponseStop) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ResponseStop.ProtoReflect.Descriptor instead.
-func (*ResponseStop) Descriptor() ([]byte, []int) {
+// Deprecated: Use RequestStart.ProtoReflect.Descriptor instead.
+func (*RequestStart) Descriptor() ([]byte, []int) {
 	return file_pb_proto_service_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *ResponseStop) GetStatus() ResponseStop_Status {
+func (x *RequestStart) GetSid() string {
 	if x != nil {
-		return x.Status
+		return x.Sid
 	}
-	return ResponseStop_Success
+	return ""
 }
 
-func (x *ResponseStop) GetMessage() string {
+func (x *RequestStart) GetUid() string {
 	if x != nil {
-		return x.Message
+		return x.Uid
 	}
 	return ""
 }
 
-type RequestDelete struct {
+func (x *RequestStart) GetResourceLimit() *ResourceLimit {
+	if x != nil {
+		return x.ResourceLimit
+	}
+	return nil
+}
+
+func (x *RequestStart) GetIdleTimeout() int32 {
+	if x != nil {
+		return x.IdleTimeout
+	}
+	return 0
+}
+
+func (x *RequestStart) GetAsync() bool {
+	if x != nil {
+		return x.Async
+	}
+	return false
+}
+
+func (x *RequestStart) GetSecretEnvVars() map[string]string {
+	if x != nil {
+		return x.SecretEnvVars
+	}
+	return nil
+}
+
+func (x *RequestStart) GetLaunch() *LaunchSpec {
+	if x != nil {
+		return x.Launch
+	}
+	return nil
+}
+
+func (x *RequestStart) GetQuota() *UserQuota {
+	if x != nil {
+		return x.Quota
+	}
+	return nil
+}
+
+func (x *RequestStart) GetMaxRuntime() int64 {
+	if x != nil {
+		return x.MaxRuntime
+	}
+	return 0
+}
+
+// 工作空间运行信息
+type ResponseStart struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Sid string `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
-	Uid string `protobuf:"bytes,2,opt,name=uid,proto3" json:"uid,omitempty"`
+	Status  ResponseStart_Status `protobuf:"varint,1,opt,name=status,proto3,enum=pb.ResponseStart_Status" json:"status,omitempty"`
+	Message string               `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
 }
 
-func (x *RequestDelete) Reset() {
-	*x = RequestDelete{}
+func (x *ResponseStart) Reset() {
+	*x = ResponseStart{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_pb_proto_service_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -721,13 +1087,13 @@ func (x *RequestDelete) Reset() {
 	}
 }
 
-func (x *RequestDelete) String() string {
+func (x *ResponseStart) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RequestDelete) ProtoMessage() {}
+func (*ResponseStart) ProtoMessage() {}
 
-func (x *RequestDelete) ProtoReflect() protoreflect.Message {
+func (x *ResponseStart) ProtoReflect() protoreflect.Message {
 	mi := &file_pb_proto_service_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -739,36 +1105,39 @@ func (x *RequestDelete) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RequestDelete.ProtoReflect.Descriptor instead.
-func (*RequestDelete) Descriptor() ([]byte, []int) {
+// Deprecated: Use ResponseStart.ProtoReflect.Descriptor instead.
+func (*ResponseStart) Descriptor() ([]byte, []int) {
 	return file_pb_proto_service_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *RequestDelete) GetSid() string {
+func (x *ResponseStart) GetStatus() ResponseStart_Status {
 	if x != nil {
-		return x.Sid
+		return x.Status
 	}
-	return ""
+	return ResponseStart_Success
 }
 
-func (x *RequestDelete) GetUid() string {
+func (x *ResponseStart) GetMessage() string {
 	if x != nil {
-		return x.Uid
+		return x.Message
 	}
 	return ""
 }
 
-type ResponseDelete struct {
+type RequestStop struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Status  ResponseDelete_Status `protobuf:"varint,1,opt,name=status,proto3,enum=pb.ResponseDelete_Status" json:"status,omitempty"`
-	Message string                `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Sid string `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
+	Uid string `protobuf:"bytes,2,opt,name=uid,proto3" json:"uid,omitempty"`
+	// 主动停止的原因, 为空表示由用户停止
+	Reason  string `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	Message string `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
 }
 
-func (x *ResponseDelete) Reset() {
-	*x = ResponseDelete{}
+func (x *RequestStop) Reset() {
+	*x = RequestStop{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_pb_proto_service_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -776,13 +1145,13 @@ func (x *ResponseDelete) Reset() {
 	}
 }
 
-func (x *ResponseDelete) String() string {
+func (x *RequestStop) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ResponseDelete) ProtoMessage() {}
+func (*RequestStop) ProtoMessage() {}
 
-func (x *ResponseDelete) ProtoReflect() protoreflect.Message {
+func (x *RequestStop) ProtoReflect() protoreflect.Message {
 	mi := &file_pb_proto_service_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -794,35 +1163,50 @@ func (x *ResponseDelete) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ResponseDelete.ProtoReflect.Descriptor instead.
-func (*ResponseDelete) Descriptor() ([]byte, []int) {
+// Deprecated: Use RequestStop.ProtoReflect.Descriptor instead.
+func (*RequestStop) Descriptor() ([]byte, []int) {
 	return file_pb_proto_service_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *ResponseDelete) GetStatus() ResponseDelete_Status {
+func (x *RequestStop) GetSid() string {
 	if x != nil {
-		return x.Status
+		return x.Sid
 	}
-	return ResponseDelete_Success
+	return ""
 }
 
-func (x *ResponseDelete) GetMessage() string {
+func (x *RequestStop) GetUid() string {
+	if x != nil {
+		return x.Uid
+	}
+	return ""
+}
+
+func (x *RequestStop) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *RequestStop) GetMessage() string {
 	if x != nil {
 		return x.Message
 	}
 	return ""
 }
 
-type RequestRunningWorkspaces struct {
+type ResponseStop struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Uid string `protobuf:"bytes,1,opt,name=uid,proto3" json:"uid,omitempty"`
+	Status  ResponseStop_Status `protobuf:"varint,1,opt,name=status,proto3,enum=pb.ResponseStop_Status" json:"status,omitempty"`
+	Message string              `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
 }
 
-func (x *RequestRunningWorkspaces) Reset() {
-	*x = RequestRunningWorkspaces{}
+func (x *ResponseStop) Reset() {
+	*x = ResponseStop{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_pb_proto_service_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -830,13 +1214,13 @@ func (x *RequestRunningWorkspaces) Reset() {
 	}
 }
 
-func (x *RequestRunningWorkspaces) String() string {
+func (x *ResponseStop) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RequestRunningWorkspaces) ProtoMessage() {}
+func (*ResponseStop) ProtoMessage() {}
 
-func (x *RequestRunningWorkspaces) ProtoReflect() protoreflect.Message {
+func (x *ResponseStop) ProtoReflect() protoreflect.Message {
 	mi := &file_pb_proto_service_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -848,43 +1232,970 @@ func (x *RequestRunningWorkspaces) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RequestRunningWorkspaces.ProtoReflect.Descriptor instead.
-func (*RequestRunningWorkspaces) Descriptor() ([]byte, []int) {
+// Deprecated: Use ResponseStop.ProtoReflect.Descriptor instead.
+func (*ResponseStop) Descriptor() ([]byte, []int) {
 	return file_pb_proto_service_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *RequestRunningWorkspaces) GetUid() string {
+func (x *ResponseStop) GetStatus() ResponseStop_Status {
 	if x != nil {
-		return x.Uid
+		return x.Status
+	}
+	return ResponseStop_Success
+}
+
+func (x *ResponseStop) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type RequestDelete struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sid string `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
+	Uid string `protobuf:"bytes,2,opt,name=uid,proto3" json:"uid,omitempty"`
+}
+
+func (x *RequestDelete) Reset() {
+	*x = RequestDelete{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_proto_service_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RequestDelete) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestDelete) ProtoMessage() {}
+
+func (x *RequestDelete) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_proto_service_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestDelete.ProtoReflect.Descriptor instead.
+func (*RequestDelete) Descriptor() ([]byte, []int) {
+	return file_pb_proto_service_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *RequestDelete) GetSid() string {
+	if x != nil {
+		return x.Sid
+	}
+	return ""
+}
+
+func (x *RequestDelete) GetUid() string {
+	if x != nil {
+		return x.Uid
+	}
+	return ""
+}
+
+type ResponseDelete struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status  ResponseDelete_Status `protobuf:"varint,1,opt,name=status,proto3,enum=pb.ResponseDelete_Status" json:"status,omitempty"`
+	Message string                `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (x *ResponseDelete) Reset() {
+	*x = ResponseDelete{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_proto_service_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ResponseDelete) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResponseDelete) ProtoMessage() {}
+
+func (x *ResponseDelete) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_proto_service_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResponseDelete.ProtoReflect.Descriptor instead.
+func (*ResponseDelete) Descriptor() ([]byte, []int) {
+	return file_pb_proto_service_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ResponseDelete) GetStatus() ResponseDelete_Status {
+	if x != nil {
+		return x.Status
+	}
+	return ResponseDelete_Success
+}
+
+func (x *ResponseDelete) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type RequestRunningWorkspaces struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// 为空时返回所有用户的Workspace
+	Uid string `protobuf:"bytes,1,opt,name=uid,proto3" json:"uid,omitempty"`
+}
+
+func (x *RequestRunningWorkspaces) Reset() {
+	*x = RequestRunningWorkspaces{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_proto_service_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RequestRunningWorkspaces) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestRunningWorkspaces) ProtoMessage() {}
+
+func (x *RequestRunningWorkspaces) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_proto_service_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestRunningWorkspaces.ProtoReflect.Descriptor instead.
+func (*RequestRunningWorkspaces) Descriptor() ([]byte, []int) {
+	return file_pb_proto_service_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RequestRunningWorkspaces) GetUid() string {
+	if x != nil {
+		return x.Uid
+	}
+	return ""
+}
+
+type ResponseRunningWorkspace struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Workspaces []*ResponseRunningWorkspace_WorkspaceBasicInfo `protobuf:"bytes,1,rep,name=workspaces,proto3" json:"workspaces,omitempty"`
+	Stopped    []*ResponseRunningWorkspace_WorkspaceStopInfo  `protobuf:"bytes,2,rep,name=stopped,proto3" json:"stopped,omitempty"`
+}
+
+func (x *ResponseRunningWorkspace) Reset() {
+	*x = ResponseRunningWorkspace{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_proto_service_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ResponseRunningWorkspace) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResponseRunningWorkspace) ProtoMessage() {}
+
+func (x *ResponseRunningWorkspace) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_proto_service_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResponseRunningWorkspace.ProtoReflect.Descriptor instead.
+func (*ResponseRunningWorkspace) Descriptor() ([]byte, []int) {
+	return file_pb_proto_service_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ResponseRunningWorkspace) GetWorkspaces() []*ResponseRunningWorkspace_WorkspaceBasicInfo {
+	if x != nil {
+		return x.Workspaces
+	}
+	return nil
+}
+
+func (x *ResponseRunningWorkspace) GetStopped() []*ResponseRunningWorkspace_WorkspaceStopInfo {
+	if x != nil {
+		return x.Stopped
+	}
+	return nil
+}
+
+type RequestWatch struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sid string `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
+	Uid string `protobuf:"bytes,2,opt,name=uid,proto3" json:"uid,omitempty"`
+}
+
+func (x *RequestWatch) Reset() {
+	*x = RequestWatch{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_proto_service_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RequestWatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestWatch) ProtoMessage() {}
+
+func (x *RequestWatch) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_proto_service_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestWatch.ProtoReflect.Descriptor instead.
+func (*RequestWatch) Descriptor() ([]byte, []int) {
+	return file_pb_proto_service_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RequestWatch) GetSid() string {
+	if x != nil {
+		return x.Sid
+	}
+	return ""
+}
+
+func (x *RequestWatch) GetUid() string {
+	if x != nil {
+		return x.Uid
+	}
+	return ""
+}
+
+// 工作空间生命周期事件, 阶段或原因变化时发送
+type WorkspaceEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sid       string `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
+	Phase     string `protobuf:"bytes,2,opt,name=phase,proto3" json:"phase,omitempty"` // Starting Running Stopping Stopped Failed Deleted
+	Stage     string `protobuf:"bytes,3,opt,name=stage,proto3" json:"stage,omitempty"` // 启动中的细分阶段: Pending CreatingVolume Scheduling CloningRepo PullingImage, 其它情况与phase相同
+	Reason    string `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	Message   string `protobuf:"bytes,5,opt,name=message,proto3" json:"message,omitempty"`
+	Endpoint  string `protobuf:"bytes,6,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+	Timestamp int64  `protobuf:"varint,7,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // unix毫秒
+}
+
+func (x *WorkspaceEvent) Reset() {
+	*x = WorkspaceEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_proto_service_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WorkspaceEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WorkspaceEvent) ProtoMessage() {}
+
+func (x *WorkspaceEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_proto_service_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WorkspaceEvent.ProtoReflect.Descriptor instead.
+func (*WorkspaceEvent) Descriptor() ([]byte, []int) {
+	return file_pb_proto_service_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *WorkspaceEvent) GetSid() string {
+	if x != nil {
+		return x.Sid
+	}
+	return ""
+}
+
+func (x *WorkspaceEvent) GetPhase() string {
+	if x != nil {
+		return x.Phase
+	}
+	return ""
+}
+
+func (x *WorkspaceEvent) GetStage() string {
+	if x != nil {
+		return x.Stage
+	}
+	return ""
+}
+
+func (x *WorkspaceEvent) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *WorkspaceEvent) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *WorkspaceEvent) GetEndpoint() string {
+	if x != nil {
+		return x.Endpoint
+	}
+	return ""
+}
+
+func (x *WorkspaceEvent) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+// 工作空间存储卷的快照
+type Snapshot struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name       string         `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Sid        string         `protobuf:"bytes,2,opt,name=sid,proto3" json:"sid,omitempty"`
+	Method     string         `protobuf:"bytes,3,opt,name=method,proto3" json:"method,omitempty"` // VolumeSnapshot 或 Archive
+	Phase      Snapshot_Phase `protobuf:"varint,4,opt,name=phase,proto3,enum=pb.Snapshot_Phase" json:"phase,omitempty"`
+	Url        string         `protobuf:"bytes,5,opt,name=url,proto3" json:"url,omitempty"`                // Archive快照在对象存储中的地址
+	Message    string         `protobuf:"bytes,6,opt,name=message,proto3" json:"message,omitempty"`        // 失败原因
+	CreateTime int64          `protobuf:"varint,7,opt,name=createTime,proto3" json:"createTime,omitempty"` // unix毫秒
+}
+
+func (x *Snapshot) Reset() {
+	*x = Snapshot{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_proto_service_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Snapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Snapshot) ProtoMessage() {}
+
+func (x *Snapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_proto_service_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Snapshot.ProtoReflect.Descriptor instead.
+func (*Snapshot) Descriptor() ([]byte, []int) {
+	return file_pb_proto_service_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *Snapshot) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Snapshot) GetSid() string {
+	if x != nil {
+		return x.Sid
+	}
+	return ""
+}
+
+func (x *Snapshot) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *Snapshot) GetPhase() Snapshot_Phase {
+	if x != nil {
+		return x.Phase
+	}
+	return Snapshot_Pending
+}
+
+func (x *Snapshot) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Snapshot) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Snapshot) GetCreateTime() int64 {
+	if x != nil {
+		return x.CreateTime
+	}
+	return 0
+}
+
+type RequestSnapshot struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sid string `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
+	Uid string `protobuf:"bytes,2,opt,name=uid,proto3" json:"uid,omitempty"`
+}
+
+func (x *RequestSnapshot) Reset() {
+	*x = RequestSnapshot{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_proto_service_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RequestSnapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestSnapshot) ProtoMessage() {}
+
+func (x *RequestSnapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_proto_service_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestSnapshot.ProtoReflect.Descriptor instead.
+func (*RequestSnapshot) Descriptor() ([]byte, []int) {
+	return file_pb_proto_service_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *RequestSnapshot) GetSid() string {
+	if x != nil {
+		return x.Sid
+	}
+	return ""
+}
+
+func (x *RequestSnapshot) GetUid() string {
+	if x != nil {
+		return x.Uid
+	}
+	return ""
+}
+
+type ResponseSnapshot struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status   ResponseSnapshot_Status `protobuf:"varint,1,opt,name=status,proto3,enum=pb.ResponseSnapshot_Status" json:"status,omitempty"`
+	Message  string                  `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Snapshot *Snapshot               `protobuf:"bytes,3,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
+}
+
+func (x *ResponseSnapshot) Reset() {
+	*x = ResponseSnapshot{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_proto_service_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ResponseSnapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResponseSnapshot) ProtoMessage() {}
+
+func (x *ResponseSnapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_proto_service_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResponseSnapshot.ProtoReflect.Descriptor instead.
+func (*ResponseSnapshot) Descriptor() ([]byte, []int) {
+	return file_pb_proto_service_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ResponseSnapshot) GetStatus() ResponseSnapshot_Status {
+	if x != nil {
+		return x.Status
+	}
+	return ResponseSnapshot_Success
+}
+
+func (x *ResponseSnapshot) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ResponseSnapshot) GetSnapshot() *Snapshot {
+	if x != nil {
+		return x.Snapshot
+	}
+	return nil
+}
+
+type RequestListSnapshots struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sid string `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
+	Uid string `protobuf:"bytes,2,opt,name=uid,proto3" json:"uid,omitempty"`
+}
+
+func (x *RequestListSnapshots) Reset() {
+	*x = RequestListSnapshots{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_proto_service_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RequestListSnapshots) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestListSnapshots) ProtoMessage() {}
+
+func (x *RequestListSnapshots) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_proto_service_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestListSnapshots.ProtoReflect.Descriptor instead.
+func (*RequestListSnapshots) Descriptor() ([]byte, []int) {
+	return file_pb_proto_service_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *RequestListSnapshots) GetSid() string {
+	if x != nil {
+		return x.Sid
+	}
+	return ""
+}
+
+func (x *RequestListSnapshots) GetUid() string {
+	if x != nil {
+		return x.Uid
+	}
+	return ""
+}
+
+type ResponseListSnapshots struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Snapshots []*Snapshot `protobuf:"bytes,1,rep,name=snapshots,proto3" json:"snapshots,omitempty"`
+}
+
+func (x *ResponseListSnapshots) Reset() {
+	*x = ResponseListSnapshots{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_proto_service_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ResponseListSnapshots) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResponseListSnapshots) ProtoMessage() {}
+
+func (x *ResponseListSnapshots) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_proto_service_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResponseListSnapshots.ProtoReflect.Descriptor instead.
+func (*ResponseListSnapshots) Descriptor() ([]byte, []int) {
+	return file_pb_proto_service_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ResponseListSnapshots) GetSnapshots() []*Snapshot {
+	if x != nil {
+		return x.Snapshots
+	}
+	return nil
+}
+
+type RequestRestore struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sid      string `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
+	Uid      string `protobuf:"bytes,2,opt,name=uid,proto3" json:"uid,omitempty"`
+	Snapshot string `protobuf:"bytes,3,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
+}
+
+func (x *RequestRestore) Reset() {
+	*x = RequestRestore{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_proto_service_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RequestRestore) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestRestore) ProtoMessage() {}
+
+func (x *RequestRestore) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_proto_service_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestRestore.ProtoReflect.Descriptor instead.
+func (*RequestRestore) Descriptor() ([]byte, []int) {
+	return file_pb_proto_service_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *RequestRestore) GetSid() string {
+	if x != nil {
+		return x.Sid
+	}
+	return ""
+}
+
+func (x *RequestRestore) GetUid() string {
+	if x != nil {
+		return x.Uid
+	}
+	return ""
+}
+
+func (x *RequestRestore) GetSnapshot() string {
+	if x != nil {
+		return x.Snapshot
+	}
+	return ""
+}
+
+type ResponseRestore struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status  ResponseRestore_Status `protobuf:"varint,1,opt,name=status,proto3,enum=pb.ResponseRestore_Status" json:"status,omitempty"`
+	Message string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (x *ResponseRestore) Reset() {
+	*x = ResponseRestore{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_proto_service_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ResponseRestore) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResponseRestore) ProtoMessage() {}
+
+func (x *ResponseRestore) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_proto_service_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResponseRestore.ProtoReflect.Descriptor instead.
+func (*ResponseRestore) Descriptor() ([]byte, []int) {
+	return file_pb_proto_service_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ResponseRestore) GetStatus() ResponseRestore_Status {
+	if x != nil {
+		return x.Status
+	}
+	return ResponseRestore_Success
+}
+
+func (x *ResponseRestore) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type RequestResize struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sid           string         `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
+	Uid           string         `protobuf:"bytes,2,opt,name=uid,proto3" json:"uid,omitempty"`
+	ResourceLimit *ResourceLimit `protobuf:"bytes,3,opt,name=resourceLimit,proto3" json:"resourceLimit,omitempty"`
+}
+
+func (x *RequestResize) Reset() {
+	*x = RequestResize{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_proto_service_proto_msgTypes[23]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RequestResize) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestResize) ProtoMessage() {}
+
+func (x *RequestResize) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_proto_service_proto_msgTypes[23]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestResize.ProtoReflect.Descriptor instead.
+func (*RequestResize) Descriptor() ([]byte, []int) {
+	return file_pb_proto_service_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *RequestResize) GetSid() string {
+	if x != nil {
+		return x.Sid
+	}
+	return ""
+}
+
+func (x *RequestResize) GetUid() string {
+	if x != nil {
+		return x.Uid
+	}
+	return ""
+}
+
+func (x *RequestResize) GetResourceLimit() *ResourceLimit {
+	if x != nil {
+		return x.ResourceLimit
+	}
+	return nil
+}
+
+type ResponseResize struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status  ResponseResize_Status `protobuf:"varint,1,opt,name=status,proto3,enum=pb.ResponseResize_Status" json:"status,omitempty"`
+	Message string                `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (x *ResponseResize) Reset() {
+	*x = ResponseResize{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_proto_service_proto_msgTypes[24]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ResponseResize) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResponseResize) ProtoMessage() {}
+
+func (x *ResponseResize) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_proto_service_proto_msgTypes[24]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResponseResize.ProtoReflect.Descriptor instead.
+func (*ResponseResize) Descriptor() ([]byte, []int) {
+	return file_pb_proto_service_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ResponseResize) GetStatus() ResponseResize_Status {
+	if x != nil {
+		return x.Status
+	}
+	return ResponseResize_Success
+}
+
+func (x *ResponseResize) GetMessage() string {
+	if x != nil {
+		return x.Message
 	}
 	return ""
 }
 
-type ResponseRunningWorkspace struct {
+type ResponseRunningWorkspace_WorkspaceBasicInfo struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Workspaces []*ResponseRunningWorkspace_WorkspaceBasicInfo `protobuf:"bytes,1,rep,name=workspaces,proto3" json:"workspaces,omitempty"`
+	Sid       string `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
+	Name      string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Phase     string `protobuf:"bytes,3,opt,name=phase,proto3" json:"phase,omitempty"`
+	Uid       string `protobuf:"bytes,4,opt,name=uid,proto3" json:"uid,omitempty"`
+	StartedAt int64  `protobuf:"varint,5,opt,name=startedAt,proto3" json:"startedAt,omitempty"` // unix毫秒, 0表示尚未运行
 }
 
-func (x *ResponseRunningWorkspace) Reset() {
-	*x = ResponseRunningWorkspace{}
+func (x *ResponseRunningWorkspace_WorkspaceBasicInfo) Reset() {
+	*x = ResponseRunningWorkspace_WorkspaceBasicInfo{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pb_proto_service_proto_msgTypes[10]
+		mi := &file_pb_proto_service_proto_msgTypes[28]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *ResponseRunningWorkspace) String() string {
+func (x *ResponseRunningWorkspace_WorkspaceBasicInfo) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ResponseRunningWorkspace) ProtoMessage() {}
+func (*ResponseRunningWorkspace_WorkspaceBasicInfo) ProtoMessage() {}
 
-func (x *ResponseRunningWorkspace) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_proto_service_proto_msgTypes[10]
+func (x *ResponseRunningWorkspace_WorkspaceBasicInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_proto_service_proto_msgTypes[28]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -895,44 +2206,75 @@ func (x *ResponseRunningWorkspace) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ResponseRunningWorkspace.ProtoReflect.Descriptor instead.
-func (*ResponseRunningWorkspace) Descriptor() ([]byte, []int) {
-	return file_pb_proto_service_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use ResponseRunningWorkspace_WorkspaceBasicInfo.ProtoReflect.Descriptor instead.
+func (*ResponseRunningWorkspace_WorkspaceBasicInfo) Descriptor() ([]byte, []int) {
+	return file_pb_proto_service_proto_rawDescGZIP(), []int{13, 0}
 }
 
-func (x *ResponseRunningWorkspace) GetWorkspaces() []*ResponseRunningWorkspace_WorkspaceBasicInfo {
+func (x *ResponseRunningWorkspace_WorkspaceBasicInfo) GetSid() string {
 	if x != nil {
-		return x.Workspaces
+		return x.Sid
 	}
-	return nil
+	return ""
 }
 
-type ResponseRunningWorkspace_WorkspaceBasicInfo struct {
+func (x *ResponseRunningWorkspace_WorkspaceBasicInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ResponseRunningWorkspace_WorkspaceBasicInfo) GetPhase() string {
+	if x != nil {
+		return x.Phase
+	}
+	return ""
+}
+
+func (x *ResponseRunningWorkspace_WorkspaceBasicInfo) GetUid() string {
+	if x != nil {
+		return x.Uid
+	}
+	return ""
+}
+
+func (x *ResponseRunningWorkspace_WorkspaceBasicInfo) GetStartedAt() int64 {
+	if x != nil {
+		return x.StartedAt
+	}
+	return 0
+}
+
+// 已停止的Workspace, 被control-plane主动停止或启动失败时包含原因
+type ResponseRunningWorkspace_WorkspaceStopInfo struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Sid  string `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
-	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Sid       string `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
+	Reason    string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	Message   string `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	StoppedAt int64  `protobuf:"varint,4,opt,name=stoppedAt,proto3" json:"stoppedAt,omitempty"` // unix毫秒, 0表示未知
 }
 
-func (x *ResponseRunningWorkspace_WorkspaceBasicInfo) Reset() {
-	*x = ResponseRunningWorkspace_WorkspaceBasicInfo{}
+func (x *ResponseRunningWorkspace_WorkspaceStopInfo) Reset() {
+	*x = ResponseRunningWorkspace_WorkspaceStopInfo{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pb_proto_service_proto_msgTypes[11]
+		mi := &file_pb_proto_service_proto_msgTypes[29]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *ResponseRunningWorkspace_WorkspaceBasicInfo) String() string {
+func (x *ResponseRunningWorkspace_WorkspaceStopInfo) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ResponseRunningWorkspace_WorkspaceBasicInfo) ProtoMessage() {}
+func (*ResponseRunningWorkspace_WorkspaceStopInfo) ProtoMessage() {}
 
-func (x *ResponseRunningWorkspace_WorkspaceBasicInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_proto_service_proto_msgTypes[11]
+func (x *ResponseRunningWorkspace_WorkspaceStopInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_proto_service_proto_msgTypes[29]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -943,25 +2285,39 @@ func (x *ResponseRunningWorkspace_WorkspaceBasicInfo) ProtoReflect() protoreflec
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ResponseRunningWorkspace_WorkspaceBasicInfo.ProtoReflect.Descriptor instead.
-func (*ResponseRunningWorkspace_WorkspaceBasicInfo) Descriptor() ([]byte, []int) {
-	return file_pb_proto_service_proto_rawDescGZIP(), []int{10, 0}
+// Deprecated: Use ResponseRunningWorkspace_WorkspaceStopInfo.ProtoReflect.Descriptor instead.
+func (*ResponseRunningWorkspace_WorkspaceStopInfo) Descriptor() ([]byte, []int) {
+	return file_pb_proto_service_proto_rawDescGZIP(), []int{13, 1}
 }
 
-func (x *ResponseRunningWorkspace_WorkspaceBasicInfo) GetSid() string {
+func (x *ResponseRunningWorkspace_WorkspaceStopInfo) GetSid() string {
 	if x != nil {
 		return x.Sid
 	}
 	return ""
 }
 
-func (x *ResponseRunningWorkspace_WorkspaceBasicInfo) GetName() string {
+func (x *ResponseRunningWorkspace_WorkspaceStopInfo) GetReason() string {
 	if x != nil {
-		return x.Name
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *ResponseRunningWorkspace_WorkspaceStopInfo) GetMessage() string {
+	if x != nil {
+		return x.Message
 	}
 	return ""
 }
 
+func (x *ResponseRunningWorkspace_WorkspaceStopInfo) GetStoppedAt() int64 {
+	if x != nil {
+		return x.StoppedAt
+	}
+	return 0
+}
+
 var File_pb_proto_service_proto protoreflect.FileDescriptor
 
 var file_pb_proto_service_proto_rawDesc = []byte{
@@ -972,7 +2328,34 @@ var file_pb_proto_service_proto_rawDesc = []byte{
 	0x16, 0x0a, 0x06, 0x6d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
 	0x06, 0x6d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x74, 0x6f, 0x72, 0x61,
 	0x67, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67,
-	0x65, 0x22, 0xe6, 0x01, 0x0a, 0x0d, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x43, 0x72, 0x65,
+	0x65, 0x22, 0x4e, 0x0a, 0x08, 0x49, 0x6e, 0x69, 0x74, 0x53, 0x74, 0x65, 0x70, 0x12, 0x12, 0x0a,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61,
+	0x6e, 0x64, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e,
+	0x64, 0x22, 0x90, 0x02, 0x0a, 0x0a, 0x4c, 0x61, 0x75, 0x6e, 0x63, 0x68, 0x53, 0x70, 0x65, 0x63,
+	0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x72,
+	0x67, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x61, 0x72, 0x67, 0x73, 0x12, 0x1e,
+	0x0a, 0x0a, 0x77, 0x6f, 0x72, 0x6b, 0x69, 0x6e, 0x67, 0x44, 0x69, 0x72, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0a, 0x77, 0x6f, 0x72, 0x6b, 0x69, 0x6e, 0x67, 0x44, 0x69, 0x72, 0x12, 0x2e,
+	0x0a, 0x12, 0x72, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x65, 0x73, 0x73, 0x50, 0x72, 0x6f, 0x62, 0x65,
+	0x50, 0x61, 0x74, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x12, 0x72, 0x65, 0x61, 0x64,
+	0x69, 0x6e, 0x65, 0x73, 0x73, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x50, 0x61, 0x74, 0x68, 0x12, 0x2a,
+	0x0a, 0x09, 0x69, 0x6e, 0x69, 0x74, 0x53, 0x74, 0x65, 0x70, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x0c, 0x2e, 0x70, 0x62, 0x2e, 0x49, 0x6e, 0x69, 0x74, 0x53, 0x74, 0x65, 0x70, 0x52,
+	0x09, 0x69, 0x6e, 0x69, 0x74, 0x53, 0x74, 0x65, 0x70, 0x73, 0x12, 0x2c, 0x0a, 0x11, 0x6c, 0x69,
+	0x76, 0x65, 0x6e, 0x65, 0x73, 0x73, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x50, 0x61, 0x74, 0x68, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x11, 0x6c, 0x69, 0x76, 0x65, 0x6e, 0x65, 0x73, 0x73, 0x50,
+	0x72, 0x6f, 0x62, 0x65, 0x50, 0x61, 0x74, 0x68, 0x12, 0x2a, 0x0a, 0x10, 0x73, 0x74, 0x61, 0x72,
+	0x74, 0x75, 0x70, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x50, 0x61, 0x74, 0x68, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x10, 0x73, 0x74, 0x61, 0x72, 0x74, 0x75, 0x70, 0x50, 0x72, 0x6f, 0x62, 0x65,
+	0x50, 0x61, 0x74, 0x68, 0x22, 0x4b, 0x0a, 0x09, 0x55, 0x73, 0x65, 0x72, 0x51, 0x75, 0x6f, 0x74,
+	0x61, 0x12, 0x1e, 0x0a, 0x0a, 0x6d, 0x61, 0x78, 0x52, 0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x6d, 0x61, 0x78, 0x52, 0x75, 0x6e, 0x6e, 0x69, 0x6e,
+	0x67, 0x12, 0x1e, 0x0a, 0x0a, 0x6d, 0x61, 0x78, 0x53, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x6d, 0x61, 0x78, 0x53, 0x74, 0x6f, 0x72, 0x61, 0x67,
+	0x65, 0x22, 0x8f, 0x05, 0x0a, 0x0d, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x43, 0x72, 0x65,
 	0x61, 0x74, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
 	0x52, 0x03, 0x73, 0x69, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01,
 	0x28, 0x09, 0x52, 0x03, 0x75, 0x69, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65,
@@ -986,91 +2369,264 @@ var file_pb_proto_service_proto_rawDesc = []byte{
 	0x68, 0x12, 0x37, 0x0a, 0x0d, 0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4c, 0x69, 0x6d,
 	0x69, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65,
 	0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x52, 0x0d, 0x72, 0x65, 0x73,
-	0x6f, 0x75, 0x72, 0x63, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x22, 0x91, 0x01, 0x0a, 0x0e, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x12, 0x31, 0x0a,
-	0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x19, 0x2e,
-	0x70, 0x62, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x43, 0x72, 0x65, 0x61, 0x74,
-	0x65, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73,
-	0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x32, 0x0a, 0x06, 0x53, 0x74,
-	0x61, 0x74, 0x75, 0x73, 0x12, 0x0b, 0x0a, 0x07, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x10,
-	0x00, 0x12, 0x10, 0x0a, 0x0c, 0x41, 0x6c, 0x72, 0x65, 0x61, 0x64, 0x79, 0x45, 0x78, 0x69, 0x73,
-	0x74, 0x10, 0x01, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x10, 0x02, 0x22, 0x6b,
-	0x0a, 0x0c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x10,
-	0x0a, 0x03, 0x73, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x69, 0x64,
-	0x12, 0x10, 0x0a, 0x03, 0x75, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75,
-	0x69, 0x64, 0x12, 0x37, 0x0a, 0x0d, 0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4c, 0x69,
-	0x6d, 0x69, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x70, 0x62, 0x2e, 0x52,
-	0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x52, 0x0d, 0x72, 0x65,
-	0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x22, 0x8b, 0x01, 0x0a, 0x0d,
-	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x30, 0x0a,
-	0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x18, 0x2e,
-	0x70, 0x62, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x53, 0x74, 0x61, 0x72, 0x74,
-	0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12,
-	0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x2e, 0x0a, 0x06, 0x53, 0x74, 0x61,
-	0x74, 0x75, 0x73, 0x12, 0x0b, 0x0a, 0x07, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x10, 0x00,
-	0x12, 0x0c, 0x0a, 0x08, 0x4e, 0x6f, 0x74, 0x46, 0x6f, 0x75, 0x6e, 0x64, 0x10, 0x01, 0x12, 0x09,
-	0x0a, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x10, 0x02, 0x22, 0x31, 0x0a, 0x0b, 0x52, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x53, 0x74, 0x6f, 0x70, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x69, 0x64, 0x18,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x38, 0x0a, 0x07, 0x65, 0x6e,
+	0x76, 0x56, 0x61, 0x72, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x70, 0x62,
+	0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x2e, 0x45,
+	0x6e, 0x76, 0x56, 0x61, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x65, 0x6e, 0x76,
+	0x56, 0x61, 0x72, 0x73, 0x12, 0x20, 0x0a, 0x0b, 0x69, 0x64, 0x6c, 0x65, 0x54, 0x69, 0x6d, 0x65,
+	0x6f, 0x75, 0x74, 0x18, 0x09, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x69, 0x64, 0x6c, 0x65, 0x54,
+	0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x73, 0x79, 0x6e, 0x63, 0x18,
+	0x0a, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x61, 0x73, 0x79, 0x6e, 0x63, 0x12, 0x4a, 0x0a, 0x0d,
+	0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x45, 0x6e, 0x76, 0x56, 0x61, 0x72, 0x73, 0x18, 0x0b, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x24, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x2e, 0x53, 0x65, 0x63, 0x72, 0x65, 0x74, 0x45, 0x6e, 0x76,
+	0x56, 0x61, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0d, 0x73, 0x65, 0x63, 0x72, 0x65,
+	0x74, 0x45, 0x6e, 0x76, 0x56, 0x61, 0x72, 0x73, 0x12, 0x26, 0x0a, 0x06, 0x6c, 0x61, 0x75, 0x6e,
+	0x63, 0x68, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x70, 0x62, 0x2e, 0x4c, 0x61,
+	0x75, 0x6e, 0x63, 0x68, 0x53, 0x70, 0x65, 0x63, 0x52, 0x06, 0x6c, 0x61, 0x75, 0x6e, 0x63, 0x68,
+	0x12, 0x23, 0x0a, 0x05, 0x71, 0x75, 0x6f, 0x74, 0x61, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x0d, 0x2e, 0x70, 0x62, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x51, 0x75, 0x6f, 0x74, 0x61, 0x52, 0x05,
+	0x71, 0x75, 0x6f, 0x74, 0x61, 0x12, 0x1e, 0x0a, 0x0a, 0x6d, 0x61, 0x78, 0x52, 0x75, 0x6e, 0x74,
+	0x69, 0x6d, 0x65, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x6d, 0x61, 0x78, 0x52, 0x75,
+	0x6e, 0x74, 0x69, 0x6d, 0x65, 0x1a, 0x3a, 0x0a, 0x0c, 0x45, 0x6e, 0x76, 0x56, 0x61, 0x72, 0x73,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38,
+	0x01, 0x1a, 0x40, 0x0a, 0x12, 0x53, 0x65, 0x63, 0x72, 0x65, 0x74, 0x45, 0x6e, 0x76, 0x56, 0x61,
+	0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a,
+	0x02, 0x38, 0x01, 0x22, 0x91, 0x01, 0x0a, 0x0e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x12, 0x31, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x19, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x22, 0x32, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x0b, 0x0a,
+	0x07, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x10, 0x00, 0x12, 0x10, 0x0a, 0x0c, 0x41, 0x6c,
+	0x72, 0x65, 0x61, 0x64, 0x79, 0x45, 0x78, 0x69, 0x73, 0x74, 0x10, 0x01, 0x12, 0x09, 0x0a, 0x05,
+	0x45, 0x72, 0x72, 0x6f, 0x72, 0x10, 0x02, 0x22, 0x9d, 0x03, 0x0a, 0x0c, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x69, 0x64, 0x18,
 	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x69, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x69,
-	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x69, 0x64, 0x22, 0x89, 0x01, 0x0a,
-	0x0c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x53, 0x74, 0x6f, 0x70, 0x12, 0x2f, 0x0a,
-	0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x17, 0x2e,
-	0x70, 0x62, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x53, 0x74, 0x6f, 0x70, 0x2e,
-	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x18,
-	0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x2e, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74,
-	0x75, 0x73, 0x12, 0x0b, 0x0a, 0x07, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x10, 0x00, 0x12,
-	0x0c, 0x0a, 0x08, 0x4e, 0x6f, 0x74, 0x46, 0x6f, 0x75, 0x6e, 0x64, 0x10, 0x01, 0x12, 0x09, 0x0a,
-	0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x10, 0x02, 0x22, 0x33, 0x0a, 0x0d, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x69, 0x64,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x69, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x75,
-	0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x69, 0x64, 0x22, 0x7f, 0x0a,
-	0x0e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x12,
-	0x31, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32,
-	0x19, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x44, 0x65, 0x6c,
-	0x65, 0x74, 0x65, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74,
+	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x69, 0x64, 0x12, 0x37, 0x0a, 0x0d,
+	0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63,
+	0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x52, 0x0d, 0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65,
+	0x4c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x20, 0x0a, 0x0b, 0x69, 0x64, 0x6c, 0x65, 0x54, 0x69, 0x6d,
+	0x65, 0x6f, 0x75, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x69, 0x64, 0x6c, 0x65,
+	0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x73, 0x79, 0x6e, 0x63,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x61, 0x73, 0x79, 0x6e, 0x63, 0x12, 0x49, 0x0a,
+	0x0d, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x45, 0x6e, 0x76, 0x56, 0x61, 0x72, 0x73, 0x18, 0x06,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x53, 0x74, 0x61, 0x72, 0x74, 0x2e, 0x53, 0x65, 0x63, 0x72, 0x65, 0x74, 0x45, 0x6e, 0x76,
+	0x56, 0x61, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0d, 0x73, 0x65, 0x63, 0x72, 0x65,
+	0x74, 0x45, 0x6e, 0x76, 0x56, 0x61, 0x72, 0x73, 0x12, 0x26, 0x0a, 0x06, 0x6c, 0x61, 0x75, 0x6e,
+	0x63, 0x68, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x70, 0x62, 0x2e, 0x4c, 0x61,
+	0x75, 0x6e, 0x63, 0x68, 0x53, 0x70, 0x65, 0x63, 0x52, 0x06, 0x6c, 0x61, 0x75, 0x6e, 0x63, 0x68,
+	0x12, 0x23, 0x0a, 0x05, 0x71, 0x75, 0x6f, 0x74, 0x61, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x0d, 0x2e, 0x70, 0x62, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x51, 0x75, 0x6f, 0x74, 0x61, 0x52, 0x05,
+	0x71, 0x75, 0x6f, 0x74, 0x61, 0x12, 0x1e, 0x0a, 0x0a, 0x6d, 0x61, 0x78, 0x52, 0x75, 0x6e, 0x74,
+	0x69, 0x6d, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x6d, 0x61, 0x78, 0x52, 0x75,
+	0x6e, 0x74, 0x69, 0x6d, 0x65, 0x1a, 0x40, 0x0a, 0x12, 0x53, 0x65, 0x63, 0x72, 0x65, 0x74, 0x45,
+	0x6e, 0x76, 0x56, 0x61, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b,
+	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xa1, 0x01, 0x0a, 0x0d, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x30, 0x0a, 0x06, 0x73, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x18, 0x2e, 0x70, 0x62, 0x2e, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x53, 0x74, 0x61, 0x72, 0x74, 0x2e, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x6d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x44, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12,
+	0x0b, 0x0a, 0x07, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x10, 0x00, 0x12, 0x0c, 0x0a, 0x08,
+	0x4e, 0x6f, 0x74, 0x46, 0x6f, 0x75, 0x6e, 0x64, 0x10, 0x01, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x72,
+	0x72, 0x6f, 0x72, 0x10, 0x02, 0x12, 0x14, 0x0a, 0x10, 0x53, 0x68, 0x72, 0x69, 0x6e, 0x6b, 0x4e,
+	0x6f, 0x74, 0x41, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64, 0x10, 0x03, 0x22, 0x63, 0x0a, 0x0b, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x53, 0x74, 0x6f, 0x70, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x69, 0x64, 0x12, 0x10, 0x0a, 0x03,
+	0x75, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x69, 0x64, 0x12, 0x16,
+	0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x22, 0x89, 0x01, 0x0a, 0x0c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x53, 0x74, 0x6f,
+	0x70, 0x12, 0x2f, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x17, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x53,
+	0x74, 0x6f, 0x70, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74,
 	0x75, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x20, 0x0a, 0x06,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x2e, 0x0a, 0x06,
 	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x0b, 0x0a, 0x07, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73,
-	0x73, 0x10, 0x00, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x10, 0x01, 0x22, 0x2c,
-	0x0a, 0x18, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67,
-	0x57, 0x6f, 0x72, 0x6b, 0x73, 0x70, 0x61, 0x63, 0x65, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x69,
-	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x69, 0x64, 0x22, 0xcc, 0x01, 0x0a,
-	0x18, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67,
-	0x57, 0x6f, 0x72, 0x6b, 0x73, 0x70, 0x61, 0x63, 0x65, 0x12, 0x4f, 0x0a, 0x0a, 0x77, 0x6f, 0x72,
-	0x6b, 0x73, 0x70, 0x61, 0x63, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2f, 0x2e,
-	0x70, 0x62, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x75, 0x6e, 0x6e, 0x69,
-	0x6e, 0x67, 0x57, 0x6f, 0x72, 0x6b, 0x73, 0x70, 0x61, 0x63, 0x65, 0x2e, 0x57, 0x6f, 0x72, 0x6b,
-	0x73, 0x70, 0x61, 0x63, 0x65, 0x42, 0x61, 0x73, 0x69, 0x63, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x0a,
-	0x77, 0x6f, 0x72, 0x6b, 0x73, 0x70, 0x61, 0x63, 0x65, 0x73, 0x1a, 0x3a, 0x0a, 0x12, 0x57, 0x6f,
-	0x72, 0x6b, 0x73, 0x70, 0x61, 0x63, 0x65, 0x42, 0x61, 0x73, 0x69, 0x63, 0x49, 0x6e, 0x66, 0x6f,
+	0x73, 0x10, 0x00, 0x12, 0x0c, 0x0a, 0x08, 0x4e, 0x6f, 0x74, 0x46, 0x6f, 0x75, 0x6e, 0x64, 0x10,
+	0x01, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x10, 0x02, 0x22, 0x33, 0x0a, 0x0d,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x10, 0x0a,
+	0x03, 0x73, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x69, 0x64, 0x12,
+	0x10, 0x0a, 0x03, 0x75, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x69,
+	0x64, 0x22, 0x7f, 0x0a, 0x0e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x44, 0x65, 0x6c,
+	0x65, 0x74, 0x65, 0x12, 0x31, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x19, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06,
+	0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x22, 0x20, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x0b, 0x0a, 0x07, 0x53, 0x75,
+	0x63, 0x63, 0x65, 0x73, 0x73, 0x10, 0x00, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72,
+	0x10, 0x01, 0x22, 0x2c, 0x0a, 0x18, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x75, 0x6e,
+	0x6e, 0x69, 0x6e, 0x67, 0x57, 0x6f, 0x72, 0x6b, 0x73, 0x70, 0x61, 0x63, 0x65, 0x73, 0x12, 0x10,
+	0x0a, 0x03, 0x75, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x69, 0x64,
+	0x22, 0xd4, 0x03, 0x0a, 0x18, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x75, 0x6e,
+	0x6e, 0x69, 0x6e, 0x67, 0x57, 0x6f, 0x72, 0x6b, 0x73, 0x70, 0x61, 0x63, 0x65, 0x12, 0x4f, 0x0a,
+	0x0a, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x70, 0x61, 0x63, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x2f, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52,
+	0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67, 0x57, 0x6f, 0x72, 0x6b, 0x73, 0x70, 0x61, 0x63, 0x65, 0x2e,
+	0x57, 0x6f, 0x72, 0x6b, 0x73, 0x70, 0x61, 0x63, 0x65, 0x42, 0x61, 0x73, 0x69, 0x63, 0x49, 0x6e,
+	0x66, 0x6f, 0x52, 0x0a, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x70, 0x61, 0x63, 0x65, 0x73, 0x12, 0x48,
+	0x0a, 0x07, 0x73, 0x74, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x2e, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x75, 0x6e,
+	0x6e, 0x69, 0x6e, 0x67, 0x57, 0x6f, 0x72, 0x6b, 0x73, 0x70, 0x61, 0x63, 0x65, 0x2e, 0x57, 0x6f,
+	0x72, 0x6b, 0x73, 0x70, 0x61, 0x63, 0x65, 0x53, 0x74, 0x6f, 0x70, 0x49, 0x6e, 0x66, 0x6f, 0x52,
+	0x07, 0x73, 0x74, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x1a, 0x80, 0x01, 0x0a, 0x12, 0x57, 0x6f, 0x72,
+	0x6b, 0x73, 0x70, 0x61, 0x63, 0x65, 0x42, 0x61, 0x73, 0x69, 0x63, 0x49, 0x6e, 0x66, 0x6f, 0x12,
+	0x10, 0x0a, 0x03, 0x73, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x69,
+	0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x68, 0x61, 0x73, 0x65, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x70, 0x68, 0x61, 0x73, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x75,
+	0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x69, 0x64, 0x12, 0x1c, 0x0a,
+	0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x65, 0x64, 0x41, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x65, 0x64, 0x41, 0x74, 0x1a, 0x75, 0x0a, 0x11, 0x57,
+	0x6f, 0x72, 0x6b, 0x73, 0x70, 0x61, 0x63, 0x65, 0x53, 0x74, 0x6f, 0x70, 0x49, 0x6e, 0x66, 0x6f,
 	0x12, 0x10, 0x0a, 0x03, 0x73, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73,
-	0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x23, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
-	0x12, 0x0b, 0x0a, 0x07, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x10, 0x00, 0x12, 0x0c, 0x0a,
-	0x08, 0x4e, 0x6f, 0x74, 0x46, 0x6f, 0x75, 0x6e, 0x64, 0x10, 0x01, 0x32, 0xb1, 0x02, 0x0a, 0x0f,
-	0x43, 0x6c, 0x6f, 0x75, 0x64, 0x49, 0x64, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12,
-	0x34, 0x0a, 0x0b, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x53, 0x70, 0x61, 0x63, 0x65, 0x12, 0x11,
-	0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x43, 0x72, 0x65, 0x61, 0x74,
-	0x65, 0x1a, 0x12, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x43,
-	0x72, 0x65, 0x61, 0x74, 0x65, 0x12, 0x31, 0x0a, 0x0a, 0x73, 0x74, 0x61, 0x72, 0x74, 0x53, 0x70,
-	0x61, 0x63, 0x65, 0x12, 0x10, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x53, 0x74, 0x61, 0x72, 0x74, 0x1a, 0x11, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f,
-	0x6e, 0x73, 0x65, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x34, 0x0a, 0x0b, 0x64, 0x65, 0x6c, 0x65,
-	0x74, 0x65, 0x53, 0x70, 0x61, 0x63, 0x65, 0x12, 0x11, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x1a, 0x12, 0x2e, 0x70, 0x62, 0x2e,
-	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x2e,
-	0x0a, 0x09, 0x73, 0x74, 0x6f, 0x70, 0x53, 0x70, 0x61, 0x63, 0x65, 0x12, 0x0f, 0x2e, 0x70, 0x62,
-	0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x53, 0x74, 0x6f, 0x70, 0x1a, 0x10, 0x2e, 0x70,
-	0x62, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x53, 0x74, 0x6f, 0x70, 0x12, 0x4f,
-	0x0a, 0x11, 0x72, 0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67, 0x57, 0x6f, 0x72, 0x6b, 0x73, 0x70, 0x61,
-	0x63, 0x65, 0x73, 0x12, 0x1c, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x52, 0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67, 0x57, 0x6f, 0x72, 0x6b, 0x73, 0x70, 0x61, 0x63, 0x65,
-	0x73, 0x1a, 0x1c, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52,
-	0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67, 0x57, 0x6f, 0x72, 0x6b, 0x73, 0x70, 0x61, 0x63, 0x65, 0x42,
-	0x07, 0x5a, 0x05, 0x2e, 0x2f, 0x3b, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x69, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x74, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x41,
+	0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x73, 0x74, 0x6f, 0x70, 0x70, 0x65, 0x64,
+	0x41, 0x74, 0x22, 0x23, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x0b, 0x0a, 0x07,
+	0x53, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x10, 0x00, 0x12, 0x0c, 0x0a, 0x08, 0x4e, 0x6f, 0x74,
+	0x46, 0x6f, 0x75, 0x6e, 0x64, 0x10, 0x01, 0x22, 0x32, 0x0a, 0x0c, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x57, 0x61, 0x74, 0x63, 0x68, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x69, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x69, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x69, 0x64, 0x22, 0xba, 0x01, 0x0a, 0x0e,
+	0x57, 0x6f, 0x72, 0x6b, 0x73, 0x70, 0x61, 0x63, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x10,
+	0x0a, 0x03, 0x73, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x69, 0x64,
+	0x12, 0x14, 0x0a, 0x05, 0x70, 0x68, 0x61, 0x73, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x70, 0x68, 0x61, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x67, 0x65, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73, 0x74, 0x61, 0x67, 0x65, 0x12, 0x16, 0x0a, 0x06,
+	0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65,
+	0x61, 0x73, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1a,
+	0x0a, 0x08, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x22, 0xeb, 0x01, 0x0a, 0x08, 0x53, 0x6e, 0x61,
+	0x70, 0x73, 0x68, 0x6f, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x69, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x69, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x6d,
+	0x65, 0x74, 0x68, 0x6f, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6d, 0x65, 0x74,
+	0x68, 0x6f, 0x64, 0x12, 0x28, 0x0a, 0x05, 0x70, 0x68, 0x61, 0x73, 0x65, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x0e, 0x32, 0x12, 0x2e, 0x70, 0x62, 0x2e, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74,
+	0x2e, 0x50, 0x68, 0x61, 0x73, 0x65, 0x52, 0x05, 0x70, 0x68, 0x61, 0x73, 0x65, 0x12, 0x10, 0x0a,
+	0x03, 0x75, 0x72, 0x6c, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x72, 0x6c, 0x12,
+	0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x72, 0x65,
+	0x61, 0x74, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x63,
+	0x72, 0x65, 0x61, 0x74, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x22, 0x2b, 0x0a, 0x05, 0x50, 0x68, 0x61,
+	0x73, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x50, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x10, 0x00, 0x12,
+	0x09, 0x0a, 0x05, 0x52, 0x65, 0x61, 0x64, 0x79, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06, 0x46, 0x61,
+	0x69, 0x6c, 0x65, 0x64, 0x10, 0x02, 0x22, 0x35, 0x0a, 0x0f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x69, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x75,
+	0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x69, 0x64, 0x22, 0xca, 0x01,
+	0x0a, 0x10, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68,
+	0x6f, 0x74, 0x12, 0x33, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0e, 0x32, 0x1b, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52,
+	0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x12, 0x28, 0x0a, 0x08, 0x73, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x70, 0x62, 0x2e, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f,
+	0x74, 0x52, 0x08, 0x73, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x22, 0x3d, 0x0a, 0x06, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x0b, 0x0a, 0x07, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73,
+	0x10, 0x00, 0x12, 0x0c, 0x0a, 0x08, 0x4e, 0x6f, 0x74, 0x46, 0x6f, 0x75, 0x6e, 0x64, 0x10, 0x01,
+	0x12, 0x0d, 0x0a, 0x09, 0x49, 0x73, 0x52, 0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67, 0x10, 0x02, 0x12,
+	0x09, 0x0a, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x10, 0x03, 0x22, 0x3a, 0x0a, 0x14, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f,
+	0x74, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x73, 0x69, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x03, 0x75, 0x69, 0x64, 0x22, 0x43, 0x0a, 0x15, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x73, 0x12,
+	0x2a, 0x0a, 0x09, 0x73, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x70, 0x62, 0x2e, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74,
+	0x52, 0x09, 0x73, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x73, 0x22, 0x50, 0x0a, 0x0e, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x12, 0x10, 0x0a,
+	0x03, 0x73, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x69, 0x64, 0x12,
+	0x10, 0x0a, 0x03, 0x75, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x69,
+	0x64, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x73, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x22, 0xac, 0x01,
+	0x0a, 0x0f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x65, 0x73, 0x74, 0x6f, 0x72,
+	0x65, 0x12, 0x32, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x1a, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52,
+	0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22,
+	0x4b, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x0b, 0x0a, 0x07, 0x53, 0x75, 0x63,
+	0x63, 0x65, 0x73, 0x73, 0x10, 0x00, 0x12, 0x0c, 0x0a, 0x08, 0x4e, 0x6f, 0x74, 0x46, 0x6f, 0x75,
+	0x6e, 0x64, 0x10, 0x01, 0x12, 0x0d, 0x0a, 0x09, 0x49, 0x73, 0x52, 0x75, 0x6e, 0x6e, 0x69, 0x6e,
+	0x67, 0x10, 0x02, 0x12, 0x0c, 0x0a, 0x08, 0x4e, 0x6f, 0x74, 0x52, 0x65, 0x61, 0x64, 0x79, 0x10,
+	0x03, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x10, 0x04, 0x22, 0x6c, 0x0a, 0x0d,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x10, 0x0a,
+	0x03, 0x73, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x69, 0x64, 0x12,
+	0x10, 0x0a, 0x03, 0x75, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x69,
+	0x64, 0x12, 0x37, 0x0a, 0x0d, 0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4c, 0x69, 0x6d,
+	0x69, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65,
+	0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x52, 0x0d, 0x72, 0x65, 0x73,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x22, 0xa3, 0x01, 0x0a, 0x0e, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x31, 0x0a,
+	0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x19, 0x2e,
+	0x70, 0x62, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x65, 0x73, 0x69, 0x7a,
+	0x65, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x44, 0x0a, 0x06, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x12, 0x0b, 0x0a, 0x07, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x10,
+	0x00, 0x12, 0x0c, 0x0a, 0x08, 0x4e, 0x6f, 0x74, 0x46, 0x6f, 0x75, 0x6e, 0x64, 0x10, 0x01, 0x12,
+	0x14, 0x0a, 0x10, 0x53, 0x68, 0x72, 0x69, 0x6e, 0x6b, 0x4e, 0x6f, 0x74, 0x41, 0x6c, 0x6c, 0x6f,
+	0x77, 0x65, 0x64, 0x10, 0x02, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x10, 0x03,
+	0x32, 0xd8, 0x04, 0x0a, 0x0f, 0x43, 0x6c, 0x6f, 0x75, 0x64, 0x49, 0x64, 0x65, 0x53, 0x65, 0x72,
+	0x76, 0x69, 0x63, 0x65, 0x12, 0x34, 0x0a, 0x0b, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x53, 0x70,
+	0x61, 0x63, 0x65, 0x12, 0x11, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x1a, 0x12, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x12, 0x31, 0x0a, 0x0a, 0x73, 0x74,
+	0x61, 0x72, 0x74, 0x53, 0x70, 0x61, 0x63, 0x65, 0x12, 0x10, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x53, 0x74, 0x61, 0x72, 0x74, 0x1a, 0x11, 0x2e, 0x70, 0x62, 0x2e,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x34, 0x0a,
+	0x0b, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x53, 0x70, 0x61, 0x63, 0x65, 0x12, 0x11, 0x2e, 0x70,
+	0x62, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x1a,
+	0x12, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x44, 0x65, 0x6c,
+	0x65, 0x74, 0x65, 0x12, 0x2e, 0x0a, 0x09, 0x73, 0x74, 0x6f, 0x70, 0x53, 0x70, 0x61, 0x63, 0x65,
+	0x12, 0x0f, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x53, 0x74, 0x6f,
+	0x70, 0x1a, 0x10, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x53,
+	0x74, 0x6f, 0x70, 0x12, 0x4f, 0x0a, 0x11, 0x72, 0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67, 0x57, 0x6f,
+	0x72, 0x6b, 0x73, 0x70, 0x61, 0x63, 0x65, 0x73, 0x12, 0x1c, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67, 0x57, 0x6f, 0x72, 0x6b,
+	0x73, 0x70, 0x61, 0x63, 0x65, 0x73, 0x1a, 0x1c, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x52, 0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67, 0x57, 0x6f, 0x72, 0x6b, 0x73,
+	0x70, 0x61, 0x63, 0x65, 0x12, 0x34, 0x0a, 0x0a, 0x77, 0x61, 0x74, 0x63, 0x68, 0x53, 0x70, 0x61,
+	0x63, 0x65, 0x12, 0x10, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x57,
+	0x61, 0x74, 0x63, 0x68, 0x1a, 0x12, 0x2e, 0x70, 0x62, 0x2e, 0x57, 0x6f, 0x72, 0x6b, 0x73, 0x70,
+	0x61, 0x63, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x30, 0x01, 0x12, 0x3a, 0x0a, 0x0d, 0x73, 0x6e,
+	0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x53, 0x70, 0x61, 0x63, 0x65, 0x12, 0x13, 0x2e, 0x70, 0x62,
+	0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74,
+	0x1a, 0x14, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x53, 0x6e,
+	0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x12, 0x44, 0x0a, 0x0d, 0x6c, 0x69, 0x73, 0x74, 0x53, 0x6e,
+	0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x73, 0x12, 0x18, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74,
+	0x73, 0x1a, 0x19, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x4c,
+	0x69, 0x73, 0x74, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x73, 0x12, 0x37, 0x0a, 0x0c,
+	0x72, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x53, 0x70, 0x61, 0x63, 0x65, 0x12, 0x12, 0x2e, 0x70,
+	0x62, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65,
+	0x1a, 0x13, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x65,
+	0x73, 0x74, 0x6f, 0x72, 0x65, 0x12, 0x34, 0x0a, 0x0b, 0x72, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x53,
+	0x70, 0x61, 0x63, 0x65, 0x12, 0x11, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x52, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x1a, 0x12, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x42, 0x07, 0x5a, 0x05, 0x2e,
+	0x2f, 0x3b, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -1085,50 +2641,98 @@ func file_pb_proto_service_proto_rawDescGZIP() []byte {
 	return file_pb_proto_service_proto_rawDescData
 }
 
-var file_pb_proto_service_proto_enumTypes = make([]protoimpl.EnumInfo, 5)
-var file_pb_proto_service_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_pb_proto_service_proto_enumTypes = make([]protoimpl.EnumInfo, 9)
+var file_pb_proto_service_proto_msgTypes = make([]protoimpl.MessageInfo, 30)
 var file_pb_proto_service_proto_goTypes = []interface{}{
-	(ResponseCreate_Status)(0),                          // 0: pb.ResponseCreate.Status
-	(ResponseStart_Status)(0),                           // 1: pb.ResponseStart.Status
-	(ResponseStop_Status)(0),                            // 2: pb.ResponseStop.Status
-	(ResponseDelete_Status)(0),                          // 3: pb.ResponseDelete.Status
-	(ResponseRunningWorkspace_Status)(0),                // 4: pb.ResponseRunningWorkspace.Status
-	(*ResourceLimit)(nil),                               // 5: pb.ResourceLimit
-	(*RequestCreate)(nil),                               // 6: pb.RequestCreate
-	(*ResponseCreate)(nil),                              // 7: pb.ResponseCreate
-	(*RequestStart)(nil),                                // 8: pb.RequestStart
-	(*ResponseStart)(nil),                               // 9: pb.ResponseStart
-	(*RequestStop)(nil),                                 // 10: pb.RequestStop
-	(*ResponseStop)(nil),                                // 11: pb.ResponseStop
-	(*RequestDelete)(nil),                               // 12: pb.RequestDelete
-	(*ResponseDelete)(nil),                              // 13: pb.ResponseDelete
-	(*RequestRunningWorkspaces)(nil),                    // 14: pb.RequestRunningWorkspaces
-	(*ResponseRunningWorkspace)(nil),                    // 15: pb.ResponseRunningWorkspace
-	(*ResponseRunningWorkspace_WorkspaceBasicInfo)(nil), // 16: pb.ResponseRunningWorkspace.WorkspaceBasicInfo
+	(ResponseCreate_Status)(0),           // 0: pb.ResponseCreate.Status
+	(ResponseStart_Status)(0),            // 1: pb.ResponseStart.Status
+	(ResponseStop_Status)(0),             // 2: pb.ResponseStop.Status
+	(ResponseDelete_Status)(0),           // 3: pb.ResponseDelete.Status
+	(ResponseRunningWorkspace_Status)(0), // 4: pb.ResponseRunningWorkspace.Status
+	(Snapshot_Phase)(0),                  // 5: pb.Snapshot.Phase
+	(ResponseSnapshot_Status)(0),         // 6: pb.ResponseSnapshot.Status
+	(ResponseRestore_Status)(0),          // 7: pb.ResponseRestore.Status
+	(ResponseResize_Status)(0),           // 8: pb.ResponseResize.Status
+	(*ResourceLimit)(nil),                // 9: pb.ResourceLimit
+	(*InitStep)(nil),                     // 10: pb.InitStep
+	(*LaunchSpec)(nil),                   // 11: pb.LaunchSpec
+	(*UserQuota)(nil),                    // 12: pb.UserQuota
+	(*RequestCreate)(nil),                // 13: pb.RequestCreate
+	(*ResponseCreate)(nil),               // 14: pb.ResponseCreate
+	(*RequestStart)(nil),                 // 15: pb.RequestStart
+	(*ResponseStart)(nil),                // 16: pb.ResponseStart
+	(*RequestStop)(nil),                  // 17: pb.RequestStop
+	(*ResponseStop)(nil),                 // 18: pb.ResponseStop
+	(*RequestDelete)(nil),                // 19: pb.RequestDelete
+	(*ResponseDelete)(nil),               // 20: pb.ResponseDelete
+	(*RequestRunningWorkspaces)(nil),     // 21: pb.RequestRunningWorkspaces
+	(*ResponseRunningWorkspace)(nil),     // 22: pb.ResponseRunningWorkspace
+	(*RequestWatch)(nil),                 // 23: pb.RequestWatch
+	(*WorkspaceEvent)(nil),               // 24: pb.WorkspaceEvent
+	(*Snapshot)(nil),                     // 25: pb.Snapshot
+	(*RequestSnapshot)(nil),              // 26: pb.RequestSnapshot
+	(*ResponseSnapshot)(nil),             // 27: pb.ResponseSnapshot
+	(*RequestListSnapshots)(nil),         // 28: pb.RequestListSnapshots
+	(*ResponseListSnapshots)(nil),        // 29: pb.ResponseListSnapshots
+	(*RequestRestore)(nil),               // 30: pb.RequestRestore
+	(*ResponseRestore)(nil),              // 31: pb.ResponseRestore
+	(*RequestResize)(nil),                // 32: pb.RequestResize
+	(*ResponseResize)(nil),               // 33: pb.ResponseResize
+	nil,                                  // 34: pb.RequestCreate.EnvVarsEntry
+	nil,                                  // 35: pb.RequestCreate.SecretEnvVarsEntry
+	nil,                                  // 36: pb.RequestStart.SecretEnvVarsEntry
+	(*ResponseRunningWorkspace_WorkspaceBasicInfo)(nil), // 37: pb.ResponseRunningWorkspace.WorkspaceBasicInfo
+	(*ResponseRunningWorkspace_WorkspaceStopInfo)(nil),  // 38: pb.ResponseRunningWorkspace.WorkspaceStopInfo
 }
 var file_pb_proto_service_proto_depIdxs = []int32{
-	5,  // 0: pb.RequestCreate.resourceLimit:type_name -> pb.ResourceLimit
-	0,  // 1: pb.ResponseCreate.status:type_name -> pb.ResponseCreate.Status
-	5,  // 2: pb.RequestStart.resourceLimit:type_name -> pb.ResourceLimit
-	1,  // 3: pb.ResponseStart.status:type_name -> pb.ResponseStart.Status
-	2,  // 4: pb.ResponseStop.status:type_name -> pb.ResponseStop.Status
-	3,  // 5: pb.ResponseDelete.status:type_name -> pb.ResponseDelete.Status
-	16, // 6: pb.ResponseRunningWorkspace.workspaces:type_name -> pb.ResponseRunningWorkspace.WorkspaceBasicInfo
-	6,  // 7: pb.CloudIdeService.createSpace:input_type -> pb.RequestCreate
-	8,  // 8: pb.CloudIdeService.startSpace:input_type -> pb.RequestStart
-	12, // 9: pb.CloudIdeService.deleteSpace:input_type -> pb.RequestDelete
-	10, // 10: pb.CloudIdeService.stopSpace:input_type -> pb.RequestStop
-	14, // 11: pb.CloudIdeService.runningWorkspaces:input_type -> pb.RequestRunningWorkspaces
-	7,  // 12: pb.CloudIdeService.createSpace:output_type -> pb.ResponseCreate
-	9,  // 13: pb.CloudIdeService.startSpace:output_type -> pb.ResponseStart
-	13, // 14: pb.CloudIdeService.deleteSpace:output_type -> pb.ResponseDelete
-	11, // 15: pb.CloudIdeService.stopSpace:output_type -> pb.ResponseStop
-	15, // 16: pb.CloudIdeService.runningWorkspaces:output_type -> pb.ResponseRunningWorkspace
-	12, // [12:17] is the sub-list for method output_type
-	7,  // [7:12] is the sub-list for method input_type
-	7,  // [7:7] is the sub-list for extension type_name
-	7,  // [7:7] is the sub-list for extension extendee
-	0,  // [0:7] is the sub-list for field type_name
+	10, // 0: pb.LaunchSpec.initSteps:type_name -> pb.InitStep
+	9,  // 1: pb.RequestCreate.resourceLimit:type_name -> pb.ResourceLimit
+	34, // 2: pb.RequestCreate.envVars:type_name -> pb.RequestCreate.EnvVarsEntry
+	35, // 3: pb.RequestCreate.secretEnvVars:type_name -> pb.RequestCreate.SecretEnvVarsEntry
+	11, // 4: pb.RequestCreate.launch:type_name -> pb.LaunchSpec
+	12, // 5: pb.RequestCreate.quota:type_name -> pb.UserQuota
+	0,  // 6: pb.ResponseCreate.status:type_name -> pb.ResponseCreate.Status
+	9,  // 7: pb.RequestStart.resourceLimit:type_name -> pb.ResourceLimit
+	36, // 8: pb.RequestStart.secretEnvVars:type_name -> pb.RequestStart.SecretEnvVarsEntry
+	11, // 9: pb.RequestStart.launch:type_name -> pb.LaunchSpec
+	12, // 10: pb.RequestStart.quota:type_name -> pb.UserQuota
+	1,  // 11: pb.ResponseStart.status:type_name -> pb.ResponseStart.Status
+	2,  // 12: pb.ResponseStop.status:type_name -> pb.ResponseStop.Status
+	3,  // 13: pb.ResponseDelete.status:type_name -> pb.ResponseDelete.Status
+	37, // 14: pb.ResponseRunningWorkspace.workspaces:type_name -> pb.ResponseRunningWorkspace.WorkspaceBasicInfo
+	38, // 15: pb.ResponseRunningWorkspace.stopped:type_name -> pb.ResponseRunningWorkspace.WorkspaceStopInfo
+	5,  // 16: pb.Snapshot.phase:type_name -> pb.Snapshot.Phase
+	6,  // 17: pb.ResponseSnapshot.status:type_name -> pb.ResponseSnapshot.Status
+	25, // 18: pb.ResponseSnapshot.snapshot:type_name -> pb.Snapshot
+	25, // 19: pb.ResponseListSnapshots.snapshots:type_name -> pb.Snapshot
+	7,  // 20: pb.ResponseRestore.status:type_name -> pb.ResponseRestore.Status
+	9,  // 21: pb.RequestResize.resourceLimit:type_name -> pb.ResourceLimit
+	8,  // 22: pb.ResponseResize.status:type_name -> pb.ResponseResize.Status
+	13, // 23: pb.CloudIdeService.createSpace:input_type -> pb.RequestCreate
+	15, // 24: pb.CloudIdeService.startSpace:input_type -> pb.RequestStart
+	19, // 25: pb.CloudIdeService.deleteSpace:input_type -> pb.RequestDelete
+	17, // 26: pb.CloudIdeService.stopSpace:input_type -> pb.RequestStop
+	21, // 27: pb.CloudIdeService.runningWorkspaces:input_type -> pb.RequestRunningWorkspaces
+	23, // 28: pb.CloudIdeService.watchSpace:input_type -> pb.RequestWatch
+	26, // 29: pb.CloudIdeService.snapshotSpace:input_type -> pb.RequestSnapshot
+	28, // 30: pb.CloudIdeService.listSnapshots:input_type -> pb.RequestListSnapshots
+	30, // 31: pb.CloudIdeService.restoreSpace:input_type -> pb.RequestRestore
+	32, // 32: pb.CloudIdeService.resizeSpace:input_type -> pb.RequestResize
+	14, // 33: pb.CloudIdeService.createSpace:output_type -> pb.ResponseCreate
+	16, // 34: pb.CloudIdeService.startSpace:output_type -> pb.ResponseStart
+	20, // 35: pb.CloudIdeService.deleteSpace:output_type -> pb.ResponseDelete
+	18, // 36: pb.CloudIdeService.stopSpace:output_type -> pb.ResponseStop
+	22, // 37: pb.CloudIdeService.runningWorkspaces:output_type -> pb.ResponseRunningWorkspace
+	24, // 38: pb.CloudIdeService.watchSpace:output_type -> pb.WorkspaceEvent
+	27, // 39: pb.CloudIdeService.snapshotSpace:output_type -> pb.ResponseSnapshot
+	29, // 40: pb.CloudIdeService.listSnapshots:output_type -> pb.ResponseListSnapshots
+	31, // 41: pb.CloudIdeService.restoreSpace:output_type -> pb.ResponseRestore
+	33, // 42: pb.CloudIdeService.resizeSpace:output_type -> pb.ResponseResize
+	33, // [33:43] is the sub-list for method output_type
+	23, // [23:33] is the sub-list for method input_type
+	23, // [23:23] is the sub-list for extension type_name
+	23, // [23:23] is the sub-list for extension extendee
+	0,  // [0:23] is the sub-list for field type_name
 }
 
 func init() { file_pb_proto_service_proto_init() }
@@ -1150,7 +2754,7 @@ func file_pb_proto_service_proto_init() {
 			}
 		}
 		file_pb_proto_service_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*RequestCreate); i {
+			switch v := v.(*InitStep); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1162,7 +2766,7 @@ func file_pb_proto_service_proto_init() {
 			}
 		}
 		file_pb_proto_service_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ResponseCreate); i {
+			switch v := v.(*LaunchSpec); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1174,7 +2778,7 @@ func file_pb_proto_service_proto_init() {
 			}
 		}
 		file_pb_proto_service_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*RequestStart); i {
+			switch v := v.(*UserQuota); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1186,7 +2790,7 @@ func file_pb_proto_service_proto_init() {
 			}
 		}
 		file_pb_proto_service_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ResponseStart); i {
+			switch v := v.(*RequestCreate); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1198,7 +2802,7 @@ func file_pb_proto_service_proto_init() {
 			}
 		}
 		file_pb_proto_service_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*RequestStop); i {
+			switch v := v.(*ResponseCreate); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1210,7 +2814,7 @@ func file_pb_proto_service_proto_init() {
 			}
 		}
 		file_pb_proto_service_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ResponseStop); i {
+			switch v := v.(*RequestStart); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1222,7 +2826,7 @@ func file_pb_proto_service_proto_init() {
 			}
 		}
 		file_pb_proto_service_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*RequestDelete); i {
+			switch v := v.(*ResponseStart); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1234,7 +2838,7 @@ func file_pb_proto_service_proto_init() {
 			}
 		}
 		file_pb_proto_service_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ResponseDelete); i {
+			switch v := v.(*RequestStop); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1246,7 +2850,7 @@ func file_pb_proto_service_proto_init() {
 			}
 		}
 		file_pb_proto_service_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*RequestRunningWorkspaces); i {
+			switch v := v.(*ResponseStop); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1258,7 +2862,7 @@ func file_pb_proto_service_proto_init() {
 			}
 		}
 		file_pb_proto_service_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ResponseRunningWorkspace); i {
+			switch v := v.(*RequestDelete); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1270,6 +2874,174 @@ func file_pb_proto_service_proto_init() {
 			}
 		}
 		file_pb_proto_service_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ResponseDelete); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_proto_service_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RequestRunningWorkspaces); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_proto_service_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ResponseRunningWorkspace); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_proto_service_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RequestWatch); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_proto_service_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WorkspaceEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_proto_service_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Snapshot); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_proto_service_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RequestSnapshot); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_proto_service_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ResponseSnapshot); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_proto_service_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RequestListSnapshots); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_proto_service_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ResponseListSnapshots); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_proto_service_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RequestRestore); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_proto_service_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ResponseRestore); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_proto_service_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RequestResize); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_proto_service_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ResponseResize); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_proto_service_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ResponseRunningWorkspace_WorkspaceBasicInfo); i {
 			case 0:
 				return &v.state
@@ -1281,14 +3053,26 @@ func file_pb_proto_service_proto_init() {
 				return nil
 			}
 		}
+		file_pb_proto_service_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ResponseRunningWorkspace_WorkspaceStopInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_pb_proto_service_proto_rawDesc,
-			NumEnums:      5,
-			NumMessages:   12,
+			NumEnums:      9,
+			NumMessages:   30,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -24,6 +24,11 @@ const (
 	CloudIdeService_DeleteSpace_FullMethodName       = "/pb.CloudIdeService/deleteSpace"
 	CloudIdeService_StopSpace_FullMethodName         = "/pb.CloudIdeService/stopSpace"
 	CloudIdeService_RunningWorkspaces_FullMethodName = "/pb.CloudIdeService/runningWorkspaces"
+	CloudIdeService_WatchSpace_FullMethodName        = "/pb.CloudIdeService/watchSpace"
+	CloudIdeService_SnapshotSpace_FullMethodName     = "/pb.CloudIdeService/snapshotSpace"
+	CloudIdeService_ListSnapshots_FullMethodName     = "/pb.CloudIdeService/listSnapshots"
+	CloudIdeService_RestoreSpace_FullMethodName      = "/pb.CloudIdeService/restoreSpace"
+	CloudIdeService_ResizeSpace_FullMethodName       = "/pb.CloudIdeService/resizeSpace"
 )
 
 // CloudIdeServiceClient is the client API for CloudIdeService service.
@@ -40,6 +45,16 @@ type CloudIdeServiceClient interface {
 	StopSpace(ctx context.Context, in *RequestStop, opts ...grpc.CallOption) (*ResponseStop, error)
 	// 获取运行中的Workspace
 	RunningWorkspaces(ctx context.Context, in *RequestRunningWorkspaces, opts ...grpc.CallOption) (*ResponseRunningWorkspace, error)
+	// 监听工作空间的生命周期事件, 直到客户端取消或工作空间被删除
+	WatchSpace(ctx context.Context, in *RequestWatch, opts ...grpc.CallOption) (CloudIdeService_WatchSpaceClient, error)
+	// 为工作空间的存储卷创建快照
+	SnapshotSpace(ctx context.Context, in *RequestSnapshot, opts ...grpc.CallOption) (*ResponseSnapshot, error)
+	// 获取工作空间的所有快照
+	ListSnapshots(ctx context.Context, in *RequestListSnapshots, opts ...grpc.CallOption) (*ResponseListSnapshots, error)
+	// 将已停止的工作空间恢复到指定快照, 下次启动时生效
+	RestoreSpace(ctx context.Context, in *RequestRestore, opts ...grpc.CallOption) (*ResponseRestore, error)
+	// 修改工作空间的规格, 存储卷在线扩容, CPU和内存在下次启动时生效
+	ResizeSpace(ctx context.Context, in *RequestResize, opts ...grpc.CallOption) (*ResponseResize, error)
 }
 
 type cloudIdeServiceClient struct {
@@ -95,6 +110,74 @@ func (c *cloudIdeServiceClient) RunningWorkspaces(ctx context.Context, in *Reque
 	return out, nil
 }
 
+func (c *cloudIdeServiceClient) WatchSpace(ctx context.Context, in *RequestWatch, opts ...grpc.CallOption) (CloudIdeService_WatchSpaceClient, error) {
+	stream, err := c.cc.NewStream(ctx, &CloudIdeService_ServiceDesc.Streams[0], CloudIdeService_WatchSpace_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &cloudIdeServiceWatchSpaceClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type CloudIdeService_WatchSpaceClient interface {
+	Recv() (*WorkspaceEvent, error)
+	grpc.ClientStream
+}
+
+type cloudIdeServiceWatchSpaceClient struct {
+	grpc.ClientStream
+}
+
+func (x *cloudIdeServiceWatchSpaceClient) Recv() (*WorkspaceEvent, error) {
+	m := new(WorkspaceEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *cloudIdeServiceClient) SnapshotSpace(ctx context.Context, in *RequestSnapshot, opts ...grpc.CallOption) (*ResponseSnapshot, error) {
+	out := new(ResponseSnapshot)
+	err := c.cc.Invoke(ctx, CloudIdeService_SnapshotSpace_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cloudIdeServiceClient) ListSnapshots(ctx context.Context, in *RequestListSnapshots, opts ...grpc.CallOption) (*ResponseListSnapshots, error) {
+	out := new(ResponseListSnapshots)
+	err := c.cc.Invoke(ctx, CloudIdeService_ListSnapshots_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cloudIdeServiceClient) RestoreSpace(ctx context.Context, in *RequestRestore, opts ...grpc.CallOption) (*ResponseRestore, error) {
+	out := new(ResponseRestore)
+	err := c.cc.Invoke(ctx, CloudIdeService_RestoreSpace_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cloudIdeServiceClient) ResizeSpace(ctx context.Context, in *RequestResize, opts ...grpc.CallOption) (*ResponseResize, error) {
+	out := new(ResponseResize)
+	err := c.cc.Invoke(ctx, CloudIdeService_ResizeSpace_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CloudIdeServiceServer is the server API for CloudIdeService service.
 // All implementations must embed UnimplementedCloudIdeServiceServer
 // for forward compatibility
@@ -109,6 +192,16 @@ type CloudIdeServiceServer interface {
 	StopSpace(context.Context, *RequestStop) (*ResponseStop, error)
 	// 获取运行中的Workspace
 	RunningWorkspaces(context.Context, *RequestRunningWorkspaces) (*ResponseRunningWorkspace, error)
+	// 监听工作空间的生命周期事件, 直到客户端取消或工作空间被删除
+	WatchSpace(*RequestWatch, CloudIdeService_WatchSpaceServer) error
+	// 为工作空间的存储卷创建快照
+	SnapshotSpace(context.Context, *RequestSnapshot) (*ResponseSnapshot, error)
+	// 获取工作空间的所有快照
+	ListSnapshots(context.Context, *RequestListSnapshots) (*ResponseListSnapshots, error)
+	// 将已停止的工作空间恢复到指定快照, 下次启动时生效
+	RestoreSpace(context.Context, *RequestRestore) (*ResponseRestore, error)
+	// 修改工作空间的规格, 存储卷在线扩容, CPU和内存在下次启动时生效
+	ResizeSpace(context.Context, *RequestResize) (*ResponseResize, error)
 	mustEmbedUnimplementedCloudIdeServiceServer()
 }
 
@@ -131,6 +224,21 @@ func (UnimplementedCloudIdeServiceServer) StopSpace(context.Context, *RequestSto
 func (UnimplementedCloudIdeServiceServer) RunningWorkspaces(context.Context, *RequestRunningWorkspaces) (*ResponseRunningWorkspace, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RunningWorkspaces not implemented")
 }
+func (UnimplementedCloudIdeServiceServer) WatchSpace(*RequestWatch, CloudIdeService_WatchSpaceServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchSpace not implemented")
+}
+func (UnimplementedCloudIdeServiceServer) SnapshotSpace(context.Context, *RequestSnapshot) (*ResponseSnapshot, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SnapshotSpace not implemented")
+}
+func (UnimplementedCloudIdeServiceServer) ListSnapshots(context.Context, *RequestListSnapshots) (*ResponseListSnapshots, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSnapshots not implemented")
+}
+func (UnimplementedCloudIdeServiceServer) RestoreSpace(context.Context, *RequestRestore) (*ResponseRestore, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreSpace not implemented")
+}
+func (UnimplementedCloudIdeServiceServer) ResizeSpace(context.Context, *RequestResize) (*ResponseResize, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResizeSpace not implemented")
+}
 func (UnimplementedCloudIdeServiceServer) mustEmbedUnimplementedCloudIdeServiceServer() {}
 
 // UnsafeCloudIdeServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -234,6 +342,99 @@ func _CloudIdeService_RunningWorkspaces_Handler(srv interface{}, ctx context.Con
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CloudIdeService_WatchSpace_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RequestWatch)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CloudIdeServiceServer).WatchSpace(m, &cloudIdeServiceWatchSpaceServer{stream})
+}
+
+type CloudIdeService_WatchSpaceServer interface {
+	Send(*WorkspaceEvent) error
+	grpc.ServerStream
+}
+
+type cloudIdeServiceWatchSpaceServer struct {
+	grpc.ServerStream
+}
+
+func (x *cloudIdeServiceWatchSpaceServer) Send(m *WorkspaceEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _CloudIdeService_SnapshotSpace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestSnapshot)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CloudIdeServiceServer).SnapshotSpace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CloudIdeService_SnapshotSpace_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CloudIdeServiceServer).SnapshotSpace(ctx, req.(*RequestSnapshot))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CloudIdeService_ListSnapshots_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestListSnapshots)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CloudIdeServiceServer).ListSnapshots(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CloudIdeService_ListSnapshots_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CloudIdeServiceServer).ListSnapshots(ctx, req.(*RequestListSnapshots))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CloudIdeService_RestoreSpace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestRestore)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CloudIdeServiceServer).RestoreSpace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CloudIdeService_RestoreSpace_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CloudIdeServiceServer).RestoreSpace(ctx, req.(*RequestRestore))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CloudIdeService_ResizeSpace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestResize)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CloudIdeServiceServer).ResizeSpace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CloudIdeService_ResizeSpace_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CloudIdeServiceServer).ResizeSpace(ctx, req.(*RequestResize))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // CloudIdeService_ServiceDesc is the grpc.ServiceDesc for CloudIdeService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -261,7 +462,29 @@ var CloudIdeService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "runningWorkspaces",
 			Handler:    _CloudIdeService_RunningWorkspaces_Handler,
 		},
+		{
+			MethodName: "snapshotSpace",
+			Handler:    _CloudIdeService_SnapshotSpace_Handler,
+		},
+		{
+			MethodName: "listSnapshots",
+			Handler:    _CloudIdeService_ListSnapshots_Handler,
+		},
+		{
+			MethodName: "restoreSpace",
+			Handler:    _CloudIdeService_RestoreSpace_Handler,
+		},
+		{
+			MethodName: "resizeSpace",
+			Handler:    _CloudIdeService_ResizeSpace_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "watchSpace",
+			Handler:       _CloudIdeService_WatchSpace_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "pb/proto/service.proto",
 }
@@ -19,6 +19,16 @@ type Config struct {
 	ServerKey         string
 	WebServiceName    string
 	WebPort           int
+	LoginUrl          string
+	// 工作空间的域名, 每个工作空间使用独立的源 <sid>.<WorkspaceDomain>
+	WorkspaceDomain string
+	// 保存工作空间地址的redis, RedisHost为空时只保存在共享内存中
+	RedisHost     string
+	RedisPort     int
+	RedisPassword string
+	RedisDB       int
+	// 验证工作空间会话的密钥, 与webserver共享, 只有native模式在本地验证
+	WorkspaceKey string
 }
 
 func ApplyNginxConf(cfg *Config, ngxPath string) error {
@@ -0,0 +1,122 @@
+package encrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+)
+
+var (
+	ErrKeyInvalid        = errors.New("encryption key must be 16, 24 or 32 bytes")
+	ErrCiphertextInvalid = errors.New("ciphertext invalid")
+)
+
+// 数据密钥长度, AES-256
+const dataKeyLen = 32
+
+// Envelope 信封加密, 每次加密都生成随机的数据密钥来加密数据, 再使用主密钥加密数据密钥
+// 更换主密钥时只需要重新加密数据密钥
+type Envelope struct {
+	kek cipher.AEAD
+}
+
+// NewEnvelope 使用主密钥创建Envelope, 主密钥长度必须是16、24或32字节
+func NewEnvelope(key []byte) (*Envelope, error) {
+	kek, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{kek: kek}, nil
+}
+
+// NewEnvelopeFromBase64 使用base64编码的主密钥创建Envelope
+func NewEnvelopeFromBase64(key string) (*Envelope, error) {
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, ErrKeyInvalid
+	}
+
+	return NewEnvelope(raw)
+}
+
+// Seal 加密数据, 返回base64编码的密文和被主密钥加密的数据密钥
+func (e *Envelope) Seal(plaintext []byte) (ciphertext, dataKey string, err error) {
+	key := make([]byte, dataKeyLen)
+	if _, err = rand.Read(key); err != nil {
+		return "", "", err
+	}
+
+	dek, err := newAEAD(key)
+	if err != nil {
+		return "", "", err
+	}
+	data, err := seal(dek, plaintext)
+	if err != nil {
+		return "", "", err
+	}
+	encryptedKey, err := seal(e.kek, key)
+	if err != nil {
+		return "", "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(data), base64.StdEncoding.EncodeToString(encryptedKey), nil
+}
+
+// Open 解密Seal返回的密文
+func (e *Envelope) Open(ciphertext, dataKey string) ([]byte, error) {
+	encryptedKey, err := base64.StdEncoding.DecodeString(dataKey)
+	if err != nil {
+		return nil, ErrCiphertextInvalid
+	}
+	key, err := open(e.kek, encryptedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	dek, err := newAEAD(key)
+	if err != nil {
+		return nil, ErrCiphertextInvalid
+	}
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, ErrCiphertextInvalid
+	}
+
+	return open(dek, data)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, ErrKeyInvalid
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// seal 加密后的格式为 nonce + 密文
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(aead cipher.AEAD, data []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, ErrCiphertextInvalid
+	}
+
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrCiphertextInvalid
+	}
+
+	return plaintext, nil
+}
@@ -0,0 +1,32 @@
+package encrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEnvelope(t *testing.T) {
+	e, err := NewEnvelope(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ciphertext, dataKey, err := e.Seal([]byte("sk-ant-123456"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := e.Open(ciphertext, dataKey)
+	if err != nil || string(plaintext) != "sk-ant-123456" {
+		t.Fatalf("unexpected plaintext %q, err:%v", plaintext, err)
+	}
+
+	// 使用其它主密钥无法解密
+	other, _ := NewEnvelope(bytes.Repeat([]byte{2}, 32))
+	if _, err = other.Open(ciphertext, dataKey); err != ErrCiphertextInvalid {
+		t.Fatalf("want ErrCiphertextInvalid, got %v", err)
+	}
+
+	if _, err = NewEnvelope([]byte("short")); err != ErrKeyInvalid {
+		t.Fatalf("want ErrKeyInvalid, got %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package encrypt
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// ShareClaim 工作空间分享链接的令牌
+type ShareClaim struct {
+	ShareId    uint32
+	Sid        string
+	Permission string
+	jwt.StandardClaims
+}
+
+const shareSubject = "Share_Token"
+
+var ErrShareTokenInvalid = errors.New("share token invalid")
+
+// CreateShareToken 使用key签发分享链接的令牌, 令牌在expire之后失效
+func CreateShareToken(key []byte, shareId uint32, sid, permission string, expire time.Time) (string, error) {
+	claims := &ShareClaim{
+		ShareId:    shareId,
+		Sid:        sid,
+		Permission: permission,
+		StandardClaims: jwt.StandardClaims{
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: expire.Unix(),
+			Issuer:    "mgh",
+			Subject:   shareSubject,
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+}
+
+// VerifyShareToken 验证分享链接的令牌的签名和有效期
+func VerifyShareToken(key []byte, token string) (*ShareClaim, error) {
+	claims := &ShareClaim{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Method != jwt.SigningMethodHS256 {
+			return nil, ErrShareTokenInvalid
+		}
+		return key, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if claims.Subject != shareSubject {
+		return nil, ErrShareTokenInvalid
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,39 @@
+package encrypt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShareToken(t *testing.T) {
+	key := []byte("share-key")
+	token, err := CreateShareToken(key, 3, "sid-1", "read", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := VerifyShareToken(key, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.ShareId != 3 || claims.Sid != "sid-1" || claims.Permission != "read" {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+
+	// 使用其它密钥签发的令牌无效
+	if _, err = VerifyShareToken([]byte("other"), token); err == nil {
+		t.Fatal("want error for wrong key")
+	}
+
+	// 过期的令牌无效
+	expired, _ := CreateShareToken(key, 3, "sid-1", "read", time.Now().Add(-time.Minute))
+	if _, err = VerifyShareToken(key, expired); err == nil {
+		t.Fatal("want error for expired token")
+	}
+
+	// 登录令牌不能作为分享令牌使用
+	userToken, _ := CreateToken(1, "user", "uid")
+	if _, err = VerifyShareToken(jwtKey, userToken); err != ErrShareTokenInvalid {
+		t.Fatalf("want ErrShareTokenInvalid, got %v", err)
+	}
+}
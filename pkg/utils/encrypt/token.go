@@ -38,6 +38,119 @@ func CreateToken(id uint32, username, uid string) (string, error) {
 	return tokenStr, nil
 }
 
+// 访问工作空间的令牌的类型
+const (
+	// WorkspaceTicket 短期票据, 由webserver签发后放在工作空间的链接中
+	WorkspaceTicket = "Workspace_Ticket"
+	// WorkspaceSession 会话, 网关验证票据后写入cookie
+	WorkspaceSession = "Workspace_Session"
+)
+
+// WorkspaceSessionTTL 会话的有效期, 网关每30秒通过webserver重新检查会话, 过期后需要重新获取票据
+const WorkspaceSessionTTL = time.Hour
+
+var ErrWorkspaceTokenInvalid = errors.New("workspace token invalid")
+
+// WorkspaceClaim 访问工作空间的票据或会话, Uid为工作空间在control-plane中的所有者
+type WorkspaceClaim struct {
+	Id  uint32
+	Uid string
+	Sid string
+	jwt.StandardClaims
+}
+
+// CreateWorkspaceToken 签发访问sid的票据或会话, kind为WorkspaceTicket或WorkspaceSession
+// key为webserver和网关共享的密钥, 与登录令牌的密钥不同
+func CreateWorkspaceToken(key []byte, kind string, id uint32, uid, sid string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := &WorkspaceClaim{
+		Id:  id,
+		Uid: uid,
+		Sid: sid,
+		StandardClaims: jwt.StandardClaims{
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(ttl).Unix(),
+			Issuer:    "mgh",
+			Subject:   kind,
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+}
+
+// VerifyWorkspaceToken 验证kind类型的令牌的签名和有效期
+func VerifyWorkspaceToken(key []byte, kind, token string) (*WorkspaceClaim, error) {
+	claims := &WorkspaceClaim{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Method != jwt.SigningMethodHS256 {
+			return nil, ErrWorkspaceTokenInvalid
+		}
+		return key, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if claims.Subject != kind {
+		return nil, ErrWorkspaceTokenInvalid
+	}
+
+	return claims, nil
+}
+
+// Allow 检查令牌是否可以访问sid, uid为网关中注册的工作空间所有者, 为空时不检查所有者
+func (c *WorkspaceClaim) Allow(sid, uid string) bool {
+	return c.Sid == sid && (uid == "" || c.Uid == uid)
+}
+
+// WatchTicket 订阅工作空间事件的票据, 浏览器的EventSource无法设置请求头, 票据放在URL中
+// 票据只能订阅签发时指定的工作空间, 不能代替登录令牌使用
+const WatchTicket = "Watch_Ticket"
+
+// WatchClaim 订阅工作空间事件的票据, SpaceId为工作空间的id
+type WatchClaim struct {
+	Id      uint32
+	Uid     string
+	SpaceId uint32
+	jwt.StandardClaims
+}
+
+// CreateWatchTicket 签发订阅spaceId事件的票据
+func CreateWatchTicket(id uint32, uid string, spaceId uint32, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := &WatchClaim{
+		Id:      id,
+		Uid:     uid,
+		SpaceId: spaceId,
+		StandardClaims: jwt.StandardClaims{
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(ttl).Unix(),
+			Issuer:    "mgh",
+			Subject:   WatchTicket,
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtKey)
+}
+
+// VerifyWatchTicket 验证票据的签名和有效期, 并且只能订阅spaceId
+func VerifyWatchTicket(token string, spaceId uint32) (*WatchClaim, error) {
+	claims := &WatchClaim{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Method != jwt.SigningMethodHS256 {
+			return nil, ErrWorkspaceTokenInvalid
+		}
+		return jwtKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if claims.Subject != WatchTicket || claims.SpaceId != spaceId {
+		return nil, ErrWorkspaceTokenInvalid
+	}
+
+	return claims, nil
+}
+
 func VerifyToken(token string) (string, string, uint32, error) {
 	if token == "" {
 		return "", "", 0, errors.New("empty String")
@@ -53,6 +166,13 @@ func VerifyToken(token string) (string, string, uint32, error) {
 	if !ok {
 		return "", "", 0, errors.New("parse Error")
 	}
+	// 同一个密钥签发的票据和会话不能作为登录令牌使用
+	if claim["sub"] != "User_Token" {
+		return "", "", 0, errors.New("not a user token")
+	}
+	username, _ := claim["Username"].(string)
+	uid, _ := claim["Uid"].(string)
+	id, _ := claim["Id"].(float64)
 
-	return claim["Username"].(string), claim["Uid"].(string), uint32(claim["Id"].(float64)), nil
+	return username, uid, uint32(id), nil
 }
@@ -0,0 +1,79 @@
+package encrypt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWorkspaceToken(t *testing.T) {
+	key := []byte("workspace-key")
+	ticket, err := CreateWorkspaceToken(key, WorkspaceTicket, 1, "uid-1", "sid-1", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := VerifyWorkspaceToken(key, WorkspaceTicket, ticket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Id != 1 || !claims.Allow("sid-1", "uid-1") || !claims.Allow("sid-1", "") {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+	if claims.Allow("sid-2", "uid-1") || claims.Allow("sid-1", "uid-2") {
+		t.Fatal("ticket should only allow its own sid and owner")
+	}
+
+	// 票据不能作为会话使用, 登录令牌也不能作为票据使用
+	if _, err = VerifyWorkspaceToken(key, WorkspaceSession, ticket); err != ErrWorkspaceTokenInvalid {
+		t.Fatalf("want ErrWorkspaceTokenInvalid, got %v", err)
+	}
+	userToken, _ := CreateToken(1, "user", "uid-1")
+	if _, err = VerifyWorkspaceToken(key, WorkspaceTicket, userToken); err == nil {
+		t.Fatal("user token should not be accepted as ticket")
+	}
+	// 其它密钥签发的票据无效
+	forged, _ := CreateWorkspaceToken([]byte("other-key"), WorkspaceTicket, 1, "uid-1", "sid-1", time.Minute)
+	if _, err = VerifyWorkspaceToken(key, WorkspaceTicket, forged); err == nil {
+		t.Fatal("ticket signed by other key should be rejected")
+	}
+
+	expired, _ := CreateWorkspaceToken(key, WorkspaceTicket, 1, "uid-1", "sid-1", -time.Minute)
+	if _, err = VerifyWorkspaceToken(key, WorkspaceTicket, expired); err == nil {
+		t.Fatal("want error for expired ticket")
+	}
+}
+
+func TestWatchTicket(t *testing.T) {
+	ticket, err := CreateWatchTicket(1, "uid-1", 7, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := VerifyWatchTicket(ticket, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Id != 1 || claims.Uid != "uid-1" {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+
+	// 票据只能订阅签发时指定的工作空间
+	if _, err = VerifyWatchTicket(ticket, 8); err != ErrWorkspaceTokenInvalid {
+		t.Fatalf("want ErrWorkspaceTokenInvalid, got %v", err)
+	}
+	// 票据不能作为登录令牌使用, 登录令牌也不能作为票据使用
+	if _, _, _, err = VerifyToken(ticket); err == nil {
+		t.Fatal("watch ticket should not be accepted as user token")
+	}
+	expired, _ := CreateWatchTicket(1, "uid-1", 7, -time.Minute)
+	if _, err = VerifyWatchTicket(expired, 7); err == nil {
+		t.Fatal("want error for expired ticket")
+	}
+	userToken, _ := CreateToken(1, "user", "uid-1")
+	if _, err = VerifyWatchTicket(userToken, 0); err != ErrWorkspaceTokenInvalid {
+		t.Fatalf("want ErrWorkspaceTokenInvalid, got %v", err)
+	}
+	if username, uid, id, err := VerifyToken(userToken); err != nil || username != "user" || uid != "uid-1" || id != 1 {
+		t.Fatalf("VerifyToken(user token) = %s %s %d %v", username, uid, id, err)
+	}
+}
@@ -0,0 +1,27 @@
+package utils
+
+import "regexp"
+
+// 镜像引用的格式: [domain[:port]/]path[:tag][@digest], 与docker的规则一致
+var imageRefRegexp = func() *regexp.Regexp {
+	const (
+		alphanumeric    = `[a-z0-9]+`
+		separator       = `(?:[._]|__|[-]+)`
+		pathComponent   = alphanumeric + `(?:` + separator + alphanumeric + `)*`
+		domainComponent = `(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])`
+		domain          = domainComponent + `(?:\.` + domainComponent + `)*(?::[0-9]+)?`
+		tag             = `[\w][\w.-]{0,127}`
+		digest          = `[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,}`
+		name            = `(?:` + domain + `/)?` + pathComponent + `(?:/` + pathComponent + `)*`
+	)
+
+	return regexp.MustCompile(`^` + name + `(?::` + tag + `)?(?:@` + digest + `)?$`)
+}()
+
+// 镜像名称的最大长度
+const maxImageNameLen = 255
+
+// VerifyImageReference 检查镜像引用是否合法, 例如 docker.io/library/golang:1.20
+func VerifyImageReference(ref string) bool {
+	return len(ref) <= maxImageNameLen && imageRefRegexp.MatchString(ref)
+}
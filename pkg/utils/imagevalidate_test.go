@@ -0,0 +1,35 @@
+package utils
+
+import "testing"
+
+func TestVerifyImageReference(t *testing.T) {
+	valid := []string{
+		"golang",
+		"golang:1.20",
+		"codercom/code-server:4.9.1",
+		"registry.cn-hangzhou.aliyuncs.com/mangohow/code-server:v1",
+		"localhost:5000/ide/claude-code-server",
+		"docker.io/library/ubuntu@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+	}
+	for _, ref := range valid {
+		if !VerifyImageReference(ref) {
+			t.Errorf("%s should be valid", ref)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"Golang",
+		"golang:",
+		"golang:-1",
+		"ubuntu latest",
+		"registry:5000/",
+		"ubuntu@sha256:123",
+		"//golang",
+	}
+	for _, ref := range invalid {
+		if VerifyImageReference(ref) {
+			t.Errorf("%s should be invalid", ref)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package utils
+
+import "strings"
+
+// 名称以这些后缀结尾的环境变量是敏感信息, webserver和control-plane都使用该规则, 由control-plane保存在Secret中
+var sensitiveEnvSuffixes = []string{"_KEY", "_TOKEN", "_SECRET", "_PASSWORD"}
+
+// IsSensitiveEnv 判断环境变量是否是敏感信息, 不区分大小写
+func IsSensitiveEnv(name string) bool {
+	name = strings.ToUpper(name)
+	for _, suffix := range sensitiveEnvSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,21 @@
+package utils
+
+import "testing"
+
+func TestIsSensitiveEnv(t *testing.T) {
+	cases := map[string]bool{
+		"OPENAI_API_KEY":       true,
+		"openai_api_key":       true,
+		"ANTHROPIC_AUTH_TOKEN": true,
+		"CLIENT_SECRET":        true,
+		"DB_PASSWORD":          true,
+		"OPENAI_BASE_URL":      false,
+		"KEY":                  false,
+		"TOKEN_URL":            false,
+	}
+	for name, want := range cases {
+		if got := IsSensitiveEnv(name); got != want {
+			t.Errorf("IsSensitiveEnv(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
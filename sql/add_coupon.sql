@@ -0,0 +1,41 @@
+-- 优惠码
+CREATE TABLE IF NOT EXISTS `t_coupon` (
+    `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `code` VARCHAR(32) NOT NULL COMMENT '优惠码',
+    `name` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '活动名称',
+    `discount_type` VARCHAR(16) NOT NULL COMMENT 'percent按比例 fixed固定金额',
+    `discount_value` DECIMAL(10,2) NOT NULL COMMENT '按比例时为减免的百分比, 固定金额时为减免的金额(元)',
+    `product_types` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '可以使用的产品类型, 使用,隔开, 为空表示所有订阅产品',
+    `first_purchase` TINYINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '1表示只有首次购买可以使用',
+    `max_redemptions` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '总共可以使用的次数, 0表示不限制',
+    `per_user_limit` INT UNSIGNED NOT NULL DEFAULT 1 COMMENT '每个用户可以使用的次数, 0表示不限制',
+    `start_time` DATETIME NULL DEFAULT NULL COMMENT '生效时间, 为空表示立即生效',
+    `end_time` DATETIME NULL DEFAULT NULL COMMENT '失效时间, 为空表示不失效',
+    `status` TINYINT UNSIGNED NOT NULL DEFAULT 1 COMMENT '1可用 0停用',
+    `create_time` DATETIME NOT NULL,
+    `update_time` DATETIME NOT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_code` (`code`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='优惠码';
+
+-- 优惠码的使用记录, 创建订单时占用, 订单超时关闭时释放
+CREATE TABLE IF NOT EXISTS `t_coupon_redemption` (
+    `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `coupon_id` INT UNSIGNED NOT NULL,
+    `user_id` INT UNSIGNED NOT NULL,
+    `order_id` INT UNSIGNED NOT NULL,
+    `discount` DECIMAL(10,2) NOT NULL COMMENT '减免的金额(元)',
+    `status` TINYINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '0已占用 1已使用 2已释放',
+    `create_time` DATETIME NOT NULL,
+    `update_time` DATETIME NOT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_order_id` (`order_id`),
+    KEY `idx_coupon_user` (`coupon_id`, `user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='优惠码使用记录';
+
+-- 订单使用的优惠码和原价
+ALTER TABLE `t_order`
+    ADD COLUMN `coupon_id` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '使用的优惠码, 0表示没有使用',
+    ADD COLUMN `original_amount` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '原价(元)',
+    ADD COLUMN `discount_amount` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '优惠金额(元)';
+UPDATE `t_order` SET `original_amount` = `amount`;
@@ -0,0 +1,6 @@
+-- 空闲工作空间自动休眠: 模板和规格上的空闲超时时间(分钟), 0表示使用control-plane的默认值
+ALTER TABLE `t_space_template`
+    ADD COLUMN `idle_timeout` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '空闲超时时间(分钟)';
+
+ALTER TABLE `t_spacespec`
+    ADD COLUMN `idle_timeout` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '空闲超时时间(分钟)';
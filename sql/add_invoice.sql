@@ -0,0 +1,30 @@
+-- 已支付订单的收据, 订单支付成功时在同一个事务中分配连续的收据编号
+-- html和pdf在支付完成后生成, 为空时下载收据会重新生成
+CREATE TABLE IF NOT EXISTS `t_invoice` (
+    `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `invoice_no` VARCHAR(32) NOT NULL COMMENT '收据编号',
+    `order_id` INT UNSIGNED NOT NULL COMMENT '订单id',
+    `order_no` VARCHAR(64) NOT NULL COMMENT '订单号',
+    `user_id` INT UNSIGNED NOT NULL COMMENT '用户id',
+    `buyer_name` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '购买人',
+    `buyer_email` VARCHAR(128) NOT NULL DEFAULT '' COMMENT '购买人邮箱',
+    `product_name` VARCHAR(100) NOT NULL COMMENT '产品名称',
+    `product_type` VARCHAR(20) NOT NULL COMMENT '产品类型',
+    `amount` DECIMAL(10,2) NOT NULL COMMENT '金额(元)',
+    `payment_method` VARCHAR(20) NOT NULL DEFAULT '' COMMENT '支付方式',
+    `paid_time` DATETIME NOT NULL COMMENT '支付时间',
+    `html` MEDIUMTEXT NULL COMMENT 'HTML收据',
+    `pdf` MEDIUMBLOB NULL COMMENT 'PDF收据',
+    `create_time` DATETIME NOT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_invoice_no` (`invoice_no`),
+    UNIQUE KEY `uk_order_id` (`order_id`),
+    KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='收据';
+
+-- 每年的收据序号, 在支付事务中加锁递增, 事务回滚时序号也回滚, 保证编号连续
+CREATE TABLE IF NOT EXISTS `t_invoice_seq` (
+    `year` SMALLINT UNSIGNED NOT NULL COMMENT '年份',
+    `seq` INT UNSIGNED NOT NULL COMMENT '当年最后一个收据的序号',
+    PRIMARY KEY (`year`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='收据序号';
@@ -0,0 +1,32 @@
+-- 运行记录增加计量信息, 记录启动时的规格和计费方式, cost为已经扣除的费用(分)
+ALTER TABLE `t_space_runtime`
+    ADD COLUMN `spec_id` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '规格id',
+    ADD COLUMN `cpu_millis` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT 'CPU(毫核)',
+    ADD COLUMN `memory_mb` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '内存(MiB)',
+    ADD COLUMN `tier` VARCHAR(16) NOT NULL DEFAULT 'free' COMMENT '启动时的套餐, credit表示按量计费',
+    ADD COLUMN `cost` BIGINT NOT NULL DEFAULT 0 COMMENT '已扣除的费用(分)';
+
+-- 用户的预付费余额(分)
+CREATE TABLE IF NOT EXISTS `t_user_credit` (
+    `user_id` INT UNSIGNED NOT NULL COMMENT '用户id',
+    `balance` BIGINT NOT NULL DEFAULT 0 COMMENT '余额(分)',
+    `update_time` DATETIME NOT NULL,
+    PRIMARY KEY (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='用户预付费余额';
+
+-- 余额变动记录, 充值时ref为订单号, 扣费时ref为工作空间sid
+CREATE TABLE IF NOT EXISTS `t_credit_record` (
+    `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `user_id` INT UNSIGNED NOT NULL COMMENT '用户id',
+    `type` VARCHAR(16) NOT NULL COMMENT 'recharge充值 usage扣费',
+    `amount` BIGINT NOT NULL COMMENT '变动金额(分), 扣费为负数',
+    `balance` BIGINT NOT NULL COMMENT '变动后的余额(分)',
+    `ref` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '关联的订单号或工作空间sid',
+    `create_time` DATETIME NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_user_id_create_time` (`user_id`, `create_time`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='余额变动记录';
+
+-- 预付费充值产品, 充值金额即为产品价格
+INSERT INTO `t_payment_product` (`name`, `type`, `duration_days`, `price`, `description`, `status`, `create_time`, `update_time`)
+VALUES ('按量计费充值', 'credit', 0, 50.00, '充值50元, 按工作空间的CPU和内存使用量扣费', 1, NOW(), NOW());
@@ -0,0 +1,11 @@
+-- 订单退款信息
+ALTER TABLE `t_order`
+    ADD COLUMN `refund_time` DATETIME NULL DEFAULT NULL COMMENT '退款时间',
+    ADD COLUMN `refund_reason` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '退款原因',
+    ADD KEY `idx_status_create_time` (`status`, `create_time`);
+
+-- 支付网关的交易号唯一, 同一笔交易只能完成一个订单, 支付完成之前交易号为NULL
+-- 之前的支付记录中的交易号是本地生成的, 不是支付网关的交易号
+ALTER TABLE `t_payment_record` MODIFY COLUMN `trade_no` VARCHAR(64) NULL DEFAULT NULL COMMENT '支付网关的交易号';
+UPDATE `t_payment_record` SET `trade_no` = NULL WHERE `trade_no` = '' OR `trade_no` LIKE 'PAY%';
+ALTER TABLE `t_payment_record` ADD UNIQUE KEY `uk_trade_no` (`trade_no`);
@@ -0,0 +1,43 @@
+-- 组织, 组织的工作空间由成员共享, 订阅和配额属于组织
+-- uid与用户的uid相同, 作为组织的工作空间在control-plane中的所有者
+CREATE TABLE IF NOT EXISTS `t_organization` (
+    `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `uid` VARCHAR(24) NOT NULL COMMENT '组织的uid',
+    `name` VARCHAR(64) NOT NULL COMMENT '组织名称',
+    `owner_id` INT UNSIGNED NOT NULL COMMENT '创建者的用户id',
+    `vip_status` TINYINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '0普通 1VIP',
+    `vip_expire_time` DATETIME NULL DEFAULT NULL COMMENT 'VIP过期时间',
+    `create_time` DATETIME NOT NULL,
+    `update_time` DATETIME NOT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_uid` (`uid`),
+    KEY `idx_owner_id` (`owner_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='组织';
+
+-- 组织的成员
+CREATE TABLE IF NOT EXISTS `t_org_member` (
+    `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `org_id` INT UNSIGNED NOT NULL,
+    `user_id` INT UNSIGNED NOT NULL,
+    `role` VARCHAR(16) NOT NULL COMMENT 'owner所有者 admin管理员 member成员',
+    `create_time` DATETIME NOT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_org_user` (`org_id`, `user_id`),
+    KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='组织成员';
+
+-- 工作空间、订单、订阅和运行记录所属的组织, 0表示属于个人
+ALTER TABLE `t_space`
+    ADD COLUMN `org_id` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '所属组织id, 0表示个人工作空间',
+    ADD KEY `idx_org_id` (`org_id`);
+
+ALTER TABLE `t_order`
+    ADD COLUMN `org_id` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '为组织购买时为组织id';
+
+ALTER TABLE `t_user_subscription`
+    ADD COLUMN `org_id` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '组织的订阅为组织id',
+    ADD KEY `idx_org_id` (`org_id`);
+
+ALTER TABLE `t_space_runtime`
+    ADD COLUMN `org_id` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '所属组织id',
+    ADD KEY `idx_org_id_start_time` (`org_id`, `start_time`);
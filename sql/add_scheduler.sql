@@ -0,0 +1,20 @@
+-- 定时任务的执行记录, instance为执行任务的副本
+CREATE TABLE IF NOT EXISTS `t_job_history` (
+    `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `name` VARCHAR(64) NOT NULL COMMENT '任务名称',
+    `instance` VARCHAR(128) NOT NULL DEFAULT '' COMMENT '执行任务的副本',
+    `status` TINYINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '0执行中 1成功 2失败',
+    `message` VARCHAR(1024) NOT NULL DEFAULT '' COMMENT '失败原因',
+    `start_time` DATETIME NOT NULL,
+    `end_time` DATETIME NULL DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_name_start_time` (`name`, `start_time`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='定时任务执行记录';
+
+-- VIP即将过期的提醒记录, 同一个过期时间只提醒一次, 续费后过期时间改变会再次提醒
+CREATE TABLE IF NOT EXISTS `t_vip_reminder` (
+    `user_id` INT UNSIGNED NOT NULL COMMENT '用户id',
+    `expire_time` DATETIME NOT NULL COMMENT '提醒时的VIP过期时间',
+    `create_time` DATETIME NOT NULL,
+    PRIMARY KEY (`user_id`, `expire_time`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='VIP过期提醒记录';
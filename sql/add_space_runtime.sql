@@ -0,0 +1,12 @@
+-- 工作空间的运行记录, 每次启动记录一条, 停止时填写停止时间, 用于统计用户每月的运行时长
+CREATE TABLE IF NOT EXISTS `t_space_runtime` (
+    `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `space_id` INT UNSIGNED NOT NULL COMMENT '工作空间id',
+    `sid` VARCHAR(32) NOT NULL COMMENT '工作空间sid',
+    `user_id` INT UNSIGNED NOT NULL COMMENT '所属用户id',
+    `start_time` DATETIME NOT NULL COMMENT '启动时间',
+    `stop_time` DATETIME NULL DEFAULT NULL COMMENT '停止时间, 为空表示正在运行',
+    PRIMARY KEY (`id`),
+    KEY `idx_sid` (`sid`),
+    KEY `idx_user_id_start_time` (`user_id`, `start_time`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='工作空间运行记录';
@@ -0,0 +1,26 @@
+-- 工作空间的分享链接, 令牌不保存在数据库中, 撤销后网关验证失败
+CREATE TABLE IF NOT EXISTS `t_space_share` (
+    `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `space_id` INT UNSIGNED NOT NULL COMMENT '工作空间id',
+    `sid` VARCHAR(64) NOT NULL COMMENT '工作空间sid',
+    `user_id` INT UNSIGNED NOT NULL COMMENT '创建者的用户id',
+    `permission` VARCHAR(16) NOT NULL COMMENT 'read只读 full完全访问',
+    `status` TINYINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '0有效 1已撤销',
+    `expire_time` DATETIME NOT NULL COMMENT '过期时间',
+    `create_time` DATETIME NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_space_id` (`space_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='工作空间分享链接';
+
+-- 分享链接的访问记录, 由网关验证令牌时记录
+CREATE TABLE IF NOT EXISTS `t_space_share_access` (
+    `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `share_id` INT UNSIGNED NOT NULL COMMENT '分享链接id',
+    `sid` VARCHAR(64) NOT NULL COMMENT '工作空间sid',
+    `permission` VARCHAR(16) NOT NULL COMMENT '访问时的权限',
+    `ip` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '访问者的ip',
+    `user_agent` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '访问者的User-Agent',
+    `access_time` DATETIME NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_share_id` (`share_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='分享链接访问记录';
@@ -0,0 +1,15 @@
+-- 工作空间快照: 记录通过control-plane创建的存储卷快照
+CREATE TABLE IF NOT EXISTS `t_space_snapshot` (
+    `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `space_id` INT UNSIGNED NOT NULL COMMENT '工作空间id',
+    `user_id` INT UNSIGNED NOT NULL COMMENT '所属用户id',
+    `name` VARCHAR(64) NOT NULL COMMENT 'control-plane中的快照名称',
+    `desc` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '描述',
+    `method` VARCHAR(32) NOT NULL COMMENT 'VolumeSnapshot 或 Archive',
+    `status` TINYINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '0 创建中 1 可用 2 失败',
+    `message` VARCHAR(512) NOT NULL DEFAULT '' COMMENT '失败原因',
+    `create_time` DATETIME NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_space_id` (`space_id`),
+    UNIQUE KEY `uk_name` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='工作空间快照';
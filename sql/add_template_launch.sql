@@ -0,0 +1,20 @@
+-- 模板的启动配置: 不再根据镜像名称判断启动命令, 列表类型的字段使用json保存
+ALTER TABLE `t_space_template`
+    ADD COLUMN `port` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '容器端口, 0表示使用默认端口',
+    ADD COLUMN `command` VARCHAR(512) NOT NULL DEFAULT '' COMMENT '启动命令, json数组',
+    ADD COLUMN `args` VARCHAR(1024) NOT NULL DEFAULT '' COMMENT '启动参数, json数组',
+    ADD COLUMN `env` VARCHAR(2048) NOT NULL DEFAULT '' COMMENT '默认的环境变量, json对象',
+    ADD COLUMN `working_dir` VARCHAR(256) NOT NULL DEFAULT '' COMMENT '工作目录',
+    ADD COLUMN `readiness_path` VARCHAR(256) NOT NULL DEFAULT '' COMMENT '就绪检查的http路径',
+    ADD COLUMN `init_steps` VARCHAR(4096) NOT NULL DEFAULT '' COMMENT '初始化步骤, json数组';
+
+-- 保持原有镜像的启动方式, $(WORKSPACE_DIR)为存储卷的挂载路径, $(WORKSPACE_PORT)为模板配置的端口
+-- 监听地址由control-plane根据端口设置, 修改模板的端口时不需要修改启动参数
+UPDATE `t_space_template`
+SET `command` = '["/usr/bin/code-server"]',
+    `args`    = '["--bind-addr","0.0.0.0:$(WORKSPACE_PORT)","--auth","none","--disable-update-check","--disable-telemetry","$(WORKSPACE_DIR)"]'
+WHERE `image` LIKE '%codercom/code-server%';
+
+UPDATE `t_space_template`
+SET `command` = '["/app/start-claude-code.sh"]'
+WHERE `image` LIKE '%claude-code-server%';
@@ -0,0 +1,10 @@
+-- 模板的存活检查和启动检查路径, 为空时不设置对应的探针
+ALTER TABLE `t_space_template`
+    ADD COLUMN `liveness_path` VARCHAR(256) NOT NULL DEFAULT '' COMMENT '存活检查的http路径',
+    ADD COLUMN `startup_path` VARCHAR(256) NOT NULL DEFAULT '' COMMENT '启动检查的http路径';
+
+-- code-server提供了/healthz接口
+UPDATE `t_space_template`
+SET `readiness_path` = '/healthz',
+    `startup_path`   = '/healthz'
+WHERE `image` LIKE '%code-server%';
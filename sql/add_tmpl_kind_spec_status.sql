@@ -0,0 +1,6 @@
+-- 模板类别和规格支持软删除, 已删除的规格仍然被已有的工作空间使用
+ALTER TABLE `t_template_kind`
+    ADD COLUMN `status` INT NOT NULL DEFAULT 0 COMMENT '状态 0可用 1已删除';
+
+ALTER TABLE `t_spacespec`
+    ADD COLUMN `status` INT NOT NULL DEFAULT 0 COMMENT '状态 0可用 1已删除';
@@ -0,0 +1,10 @@
+-- 用户角色: 管理员可以访问/admin下的接口
+ALTER TABLE `t_user`
+    ADD COLUMN `role` TINYINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '角色 0普通用户 1管理员';
+
+-- 用户状态增加 2已禁用, 被禁用的用户无法登录
+ALTER TABLE `t_user`
+    MODIFY COLUMN `status` INT NOT NULL COMMENT '状态 0正常 1已删除 2已禁用';
+
+-- 将指定用户设置为管理员
+-- UPDATE `t_user` SET `role` = 1 WHERE `username` = 'admin';
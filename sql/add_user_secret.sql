@@ -0,0 +1,23 @@
+-- 用户密钥: 使用信封加密保存, 密文和加密后的数据密钥分别保存
+CREATE TABLE IF NOT EXISTS `t_user_secret` (
+    `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `user_id` INT UNSIGNED NOT NULL COMMENT '所属用户id',
+    `name` VARCHAR(32) NOT NULL COMMENT '密钥名称',
+    `env_name` VARCHAR(64) NOT NULL COMMENT '注入工作空间时的环境变量名称',
+    `desc` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '描述',
+    `hint` VARCHAR(16) NOT NULL DEFAULT '' COMMENT '脱敏后的值, 用于展示',
+    `ciphertext` TEXT NOT NULL COMMENT '密文',
+    `data_key` VARCHAR(255) NOT NULL COMMENT '被主密钥加密的数据密钥',
+    `create_time` DATETIME NOT NULL,
+    `update_time` DATETIME NOT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_user_id_name` (`user_id`, `name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='用户密钥';
+
+-- 工作空间关联的密钥, 启动工作空间时注入到环境变量中
+CREATE TABLE IF NOT EXISTS `t_space_secret` (
+    `space_id` INT UNSIGNED NOT NULL COMMENT '工作空间id',
+    `secret_id` INT UNSIGNED NOT NULL COMMENT '密钥id',
+    PRIMARY KEY (`space_id`, `secret_id`),
+    KEY `idx_secret_id` (`secret_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='工作空间关联的密钥';
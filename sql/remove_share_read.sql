@@ -0,0 +1,3 @@
+-- 网关无法限制WebSocket中的操作, 分享链接不再支持只读权限, 撤销已创建的只读链接
+UPDATE `t_space_share` SET `status` = 1 WHERE `permission` = 'read' AND `status` = 0;
+ALTER TABLE `t_space_share` MODIFY `permission` VARCHAR(16) NOT NULL COMMENT 'full完全访问';
@@ -0,0 +1,8 @@
+-- 工作空间的API密钥改为加密保存在t_user_secret中, 删除以明文保存环境变量配置的environment列
+-- 已创建的工作空间的密钥保存在control-plane的Secret中, 不受影响
+SET @has_environment = (SELECT COUNT(*) FROM information_schema.COLUMNS
+    WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 't_space' AND COLUMN_NAME = 'environment');
+SET @drop_environment = IF(@has_environment > 0, 'ALTER TABLE `t_space` DROP COLUMN `environment`', 'SELECT 1');
+PREPARE stmt FROM @drop_environment;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;